@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/cmd/util"
+	cisync "github.com/Conflux-Chain/confura/sync"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// backfill options
+	backfillOpt struct {
+		epochFrom uint64
+		epochTo   uint64
+		eth       bool
+		force     bool
+		batch     uint64
+	}
+
+	backfillCmd = &cobra.Command{
+		Use:   "backfill",
+		Short: "Re-fetch and re-persist the specified epoch (or block for evm space) range into database",
+		Run:   backfill,
+	}
+)
+
+func init() {
+	backfillCmd.Flags().Uint64Var(&backfillOpt.epochFrom, "from", 0, "epoch (or block) number to backfill from")
+	backfillCmd.MarkFlagRequired("from")
+
+	backfillCmd.Flags().Uint64Var(&backfillOpt.epochTo, "to", 0, "epoch (or block) number to backfill to (inclusive)")
+	backfillCmd.MarkFlagRequired("to")
+
+	backfillCmd.Flags().BoolVar(&backfillOpt.eth, "eth", false, "backfill evm space rather than core space")
+	backfillCmd.Flags().BoolVar(
+		&backfillOpt.force, "force", false, "overwrite the stored epochs, otherwise only missing epochs backfilled",
+	)
+	backfillCmd.Flags().Uint64Var(&backfillOpt.batch, "batch", 10, "max number of epochs to persist at a time")
+
+	syncCmd.AddCommand(backfillCmd)
+}
+
+func backfill(*cobra.Command, []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	syncCtx := util.MustInitSyncContext(storeCtx)
+	defer syncCtx.Close()
+
+	var backfiller *cisync.RangeBackfiller
+	if backfillOpt.eth {
+		if syncCtx.EthDB == nil {
+			logrus.Fatal("EVM space database not configured")
+		}
+
+		backfiller = cisync.MustNewEthRangeBackfiller(syncCtx.SyncEths[0], syncCtx.EthDB, backfillOpt.batch)
+	} else {
+		if syncCtx.CfxDB == nil {
+			logrus.Fatal("Core space database not configured")
+		}
+
+		backfiller = cisync.MustNewCfxRangeBackfiller(syncCtx.SyncCfxs[0], syncCtx.CfxDB, backfillOpt.batch)
+	}
+
+	logger := logrus.WithFields(logrus.Fields{
+		"from":  backfillOpt.epochFrom,
+		"to":    backfillOpt.epochTo,
+		"eth":   backfillOpt.eth,
+		"force": backfillOpt.force,
+	})
+
+	num, err := backfiller.Backfill(context.Background(), backfillOpt.epochFrom, backfillOpt.epochTo, backfillOpt.force)
+	if err != nil {
+		logger.WithError(err).WithField("backfilled", num).Fatal("Failed to backfill epochs")
+	}
+
+	logger.WithField("backfilled", num).Info("Succeeded to backfill epochs")
+}
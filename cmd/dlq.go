@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	cisync "github.com/Conflux-Chain/confura/sync"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// dead letter queue options
+	dlqOpt struct {
+		eth bool
+		all bool
+	}
+
+	dlqCmd = &cobra.Command{
+		Use:   "dlq",
+		Short: "Manage the epochs (or blocks for evm space) parked in dead letter queue during sync",
+	}
+
+	dlqListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the epochs parked in dead letter queue",
+		Run:   listDeadLetterEpochs,
+	}
+
+	dlqRetryCmd = &cobra.Command{
+		Use:   "retry",
+		Short: "Re-fetch and overwrite the unresolved epochs parked in dead letter queue",
+		Run:   retryDeadLetterEpochs,
+	}
+)
+
+func init() {
+	dlqCmd.PersistentFlags().BoolVar(&dlqOpt.eth, "eth", false, "evm space rather than core space")
+
+	dlqListCmd.Flags().BoolVar(&dlqOpt.all, "all", false, "list the resolved epochs as well")
+	dlqCmd.AddCommand(dlqListCmd)
+
+	dlqCmd.AddCommand(dlqRetryCmd)
+
+	syncCmd.AddCommand(dlqCmd)
+}
+
+func mustGetDeadLetterStore(storeCtx util.StoreContext) *mysql.MysqlStore {
+	if dlqOpt.eth {
+		if storeCtx.EthDB == nil {
+			logrus.Fatal("EVM space database not configured")
+		}
+
+		return storeCtx.EthDB
+	}
+
+	if storeCtx.CfxDB == nil {
+		logrus.Fatal("Core space database not configured")
+	}
+
+	return storeCtx.CfxDB
+}
+
+func listDeadLetterEpochs(*cobra.Command, []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	entries, err := mustGetDeadLetterStore(storeCtx).DeadLetterEpochs(dlqOpt.all, 0)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to get dead letter epochs")
+	}
+
+	if len(entries) == 0 {
+		logrus.Info("No dead letter epoch found")
+		return
+	}
+
+	logrus.WithField("total", len(entries)).Info("Dead letter epochs loaded:")
+
+	for i := range entries {
+		logrus.WithField("entry", entries[i]).Info("Dead letter epoch #", i)
+	}
+}
+
+func retryDeadLetterEpochs(*cobra.Command, []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	syncCtx := util.MustInitSyncContext(storeCtx)
+	defer syncCtx.Close()
+
+	db := mustGetDeadLetterStore(storeCtx)
+
+	var backfiller *cisync.RangeBackfiller
+	if dlqOpt.eth {
+		backfiller = cisync.MustNewEthRangeBackfiller(syncCtx.SyncEths[0], db, 1)
+	} else {
+		backfiller = cisync.MustNewCfxRangeBackfiller(syncCtx.SyncCfxs[0], db, 1)
+	}
+
+	logger := logrus.WithField("eth", dlqOpt.eth)
+
+	num, err := cisync.RetryDeadLetterEpochs(context.Background(), backfiller)
+	if err != nil {
+		logger.WithError(err).WithField("resolved", num).Fatal("Failed to retry dead letter epochs")
+	}
+
+	logger.WithField("resolved", num).Info("Succeeded to retry dead letter epochs")
+}
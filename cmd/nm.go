@@ -62,11 +62,11 @@ func startNodeManagerService(*cobra.Command, []string) {
 }
 
 func startNativeSpaceNodeServer(ctx context.Context, wg *sync.WaitGroup, storeCtx util.StoreContext) {
-	server, endpoint := node.Factory().CreatRpcServer(storeCtx.CfxDB)
+	server, endpoint := node.Factory().CreatRpcServer(ctx, wg, storeCtx.CfxDB)
 	go server.MustServeGraceful(ctx, wg, endpoint, rpc.ProtocolHttp)
 }
 
 func startEvmSpaceNodeServer(ctx context.Context, wg *sync.WaitGroup, storeCtx util.StoreContext) {
-	server, endpoint := node.EthFactory().CreatRpcServer(storeCtx.EthDB)
+	server, endpoint := node.EthFactory().CreatRpcServer(ctx, wg, storeCtx.EthDB)
 	go server.MustServeGraceful(ctx, wg, endpoint, rpc.ProtocolHttp)
 }
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/Conflux-Chain/confura/store/mysql"
 	"github.com/Conflux-Chain/confura/util/acl"
 	"github.com/Conflux-Chain/confura/util/rate"
 	"github.com/pkg/errors"
@@ -19,6 +20,9 @@ type keysetCmdConfig struct {
 	LimitKey  string         // rate limit key
 	LimitType rate.LimitType // rate limit type (0 - by key, 1 - by IP)
 	Memo      string         // rate limit memo
+
+	// restriction of source IPs and origins
+	Restriction mysql.RateLimitRestriction
 }
 
 var (
@@ -36,6 +40,12 @@ var (
 		Run:   addKey,
 	}
 
+	restrictKeyCmd = &cobra.Command{
+		Use:   "rsk",
+		Short: "Restrict rate limit key by source IPs and origins",
+		Run:   restrictKey,
+	}
+
 	delKeyCmd = &cobra.Command{
 		Use:   "rmk",
 		Short: "Remove rate limit key",
@@ -61,6 +71,11 @@ func init() {
 	hookKeysetCmdLimitKeyFlag(addKeyCmd, false)
 	hookKeysetCmdMemoFlag(addKeyCmd)
 	hookKeysetCmdAllowListFlag(addKeyCmd)
+	hookKeysetCmdRestrictionFlags(addKeyCmd)
+
+	Cmd.AddCommand(restrictKeyCmd)
+	hookKeysetCmdFlags(restrictKeyCmd, true, false, true, false)
+	hookKeysetCmdRestrictionFlags(restrictKeyCmd)
 
 	Cmd.AddCommand(delKeyCmd)
 	hookKeysetCmdFlags(delKeyCmd, true, false, true, false)
@@ -77,6 +92,10 @@ func addKey(cmd *cobra.Command, args []string) {
 	defer storeCtx.Close()
 
 	err := validateKeysetCmdConfig(true, false, true)
+	if err == nil {
+		err = validateKeysetCmdRestriction()
+	}
+
 	if err != nil {
 		logrus.WithField("config", keysetCfg).WithError(err).Info("Invalid command config")
 		return
@@ -126,14 +145,15 @@ func addKey(cmd *cobra.Command, args []string) {
 	}
 
 	logger.WithFields(logrus.Fields{
-		"allowlist": acl,
-		"limitKey":  limitKey,
-		"limitType": limitTypeMap[keysetCfg.LimitType],
+		"allowlist":   acl,
+		"limitKey":    limitKey,
+		"limitType":   limitTypeMap[keysetCfg.LimitType],
+		"restriction": keysetCfg.Restriction,
 	}).Info("Press the Enter Key to add new rate limit key")
 	fmt.Scanln() // wait for Enter Key
 
 	err = dbs.RateLimitStore.AddRateLimit(
-		strategy.ID, acl.ID, keysetCfg.LimitType, limitKey, keysetCfg.Memo,
+		strategy.ID, acl.ID, keysetCfg.LimitType, limitKey, keysetCfg.Memo, keysetCfg.Restriction,
 	)
 	if err != nil {
 		logrus.WithError(err).Info("Failed to add rate limit key")
@@ -143,6 +163,50 @@ func addKey(cmd *cobra.Command, args []string) {
 	logrus.Info("New rate limit key added")
 }
 
+func restrictKey(cmd *cobra.Command, args []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	err := validateKeysetCmdConfig(false, true, false)
+	if err == nil {
+		err = validateKeysetCmdRestriction()
+	}
+
+	if err != nil {
+		logrus.WithField("config", keysetCfg).WithError(err).Info("Invalid command config")
+		return
+	}
+
+	dbs, err := storeCtx.GetMysqlStore(keysetCfg.Network)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to get mysql store by network")
+		return
+	}
+
+	if dbs == nil {
+		logrus.Info("DB store is unavailable")
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"limitKey":    keysetCfg.LimitKey,
+		"restriction": keysetCfg.Restriction,
+	}).Info("Press the Enter Key to restrict the rate limit key (empty to unrestrict)")
+	fmt.Scanln() // wait for Enter Key
+
+	updated, err := dbs.UpdateRateLimitRestriction(keysetCfg.LimitKey, keysetCfg.Restriction)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to restrict the rate limit key")
+		return
+	}
+
+	if updated {
+		logrus.WithField("limitKey", keysetCfg.LimitKey).Info("Rate limit key restricted")
+	} else {
+		logrus.WithField("limitKey", keysetCfg.LimitKey).Info("Rate limit key not existed or unchanged")
+	}
+}
+
 func delKey(cmd *cobra.Command, args []string) {
 	storeCtx := util.MustInitStoreContext()
 	defer storeCtx.Close()
@@ -236,11 +300,12 @@ func listKeys(cmd *cobra.Command, args []string) {
 		}
 
 		logrus.WithFields(logrus.Fields{
-			"strategy":  strategy.Name,
-			"limitKey":  k.LimitKey,
-			"limitType": limitTypeMap[rate.LimitType(k.LimitType)],
-			"allowList": allowLists[k.AclID],
-			"memo":      k.Memo,
+			"strategy":    strategy.Name,
+			"limitKey":    k.LimitKey,
+			"limitType":   limitTypeMap[rate.LimitType(k.LimitType)],
+			"allowList":   allowLists[k.AclID],
+			"memo":        k.Memo,
+			"restriction": k.RateLimitRestriction,
 		}).Info("Key #", i)
 	}
 }
@@ -284,6 +349,13 @@ func validateKeysetCmdConfig(validateStrategy, validateLimitKey, validateLimitTy
 	return nil
 }
 
+func validateKeysetCmdRestriction() error {
+	r := keysetCfg.Restriction
+
+	_, err := acl.NewKeyRestriction(r.AllowedIps, r.DeniedIps, r.AllowedOrigins)
+	return err
+}
+
 func hookKeysetCmdFlags(keysetCmd *cobra.Command, hookNetwork, hookStrategy, hookLimitKey, hookLimitType bool) {
 	if hookNetwork { // RPC network space
 		keysetCmd.Flags().StringVarP(
@@ -334,3 +406,17 @@ func hookKeysetCmdAllowListFlag(keysetCmd *cobra.Command) {
 		&keysetCfg.AllowList, "acl", "l", "", "allowlist used",
 	)
 }
+
+func hookKeysetCmdRestrictionFlags(keysetCmd *cobra.Command) {
+	keysetCmd.Flags().StringVar(
+		&keysetCfg.Restriction.AllowedIps, "allowIps", "", "comma separated source IPs or CIDR ranges allowed",
+	)
+
+	keysetCmd.Flags().StringVar(
+		&keysetCfg.Restriction.DeniedIps, "denyIps", "", "comma separated source IPs or CIDR ranges denied",
+	)
+
+	keysetCmd.Flags().StringVar(
+		&keysetCfg.Restriction.AllowedOrigins, "allowOrigins", "", "comma separated HTTP origins allowed (wildcard supported)",
+	)
+}
@@ -79,6 +79,10 @@ func start(cmd *cobra.Command, args []string) {
 	storeCtx := util.MustInitStoreContext()
 	defer storeCtx.Close()
 
+	if syncServerEnabled || rpcServerEnabled {
+		storeCtx.MustServeStoreHealth(ctx, syncServerEnabled)
+	}
+
 	if syncServerEnabled { // start sync
 		syncCtx := util.MustInitSyncContext(storeCtx)
 		defer syncCtx.Close()
@@ -87,8 +91,8 @@ func start(cmd *cobra.Command, args []string) {
 	}
 
 	if rpcServerEnabled { // start RPC
-		startNativeSpaceRpcServer(ctx, wg, storeCtx)
-		startEvmSpaceRpcServer(ctx, wg, storeCtx)
+		startNativeSpaceRpcServer(ctx, wg, storeCtx, nil)
+		startEvmSpaceRpcServer(ctx, wg, storeCtx, nil)
 		startNativeSpaceBridgeRpcServer(ctx, wg, storeCtx)
 	}
 
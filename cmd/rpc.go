@@ -12,16 +12,26 @@ import (
 	"github.com/Conflux-Chain/confura/cmd/util"
 	"github.com/Conflux-Chain/confura/node"
 	"github.com/Conflux-Chain/confura/rpc"
+	"github.com/Conflux-Chain/confura/rpc/graphql"
 	"github.com/Conflux-Chain/confura/rpc/handler"
+	"github.com/Conflux-Chain/confura/store/mysql"
 	"github.com/Conflux-Chain/confura/store/redis"
+	cisync "github.com/Conflux-Chain/confura/sync"
 	"github.com/Conflux-Chain/confura/util/acl"
 	"github.com/Conflux-Chain/confura/util/rate"
 	"github.com/Conflux-Chain/confura/util/relay"
 	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/slowlog"
+	"github.com/Conflux-Chain/confura/util/usage"
 	vfclient "github.com/Conflux-Chain/confura/virtualfilter/client"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/web3go"
 )
 
+// routing key of full node for readiness probe
+const readinessProbeKey = "readyz"
+
 var (
 	// RPC boot options
 	rpcOpt struct {
@@ -67,12 +77,24 @@ func startRpcService(*cobra.Command, []string) {
 	storeCtx := util.MustInitStoreContext()
 	defer storeCtx.Close()
 
+	storeCtx.MustServeStoreHealth(ctx, false)
+
+	// prepare stores of extra networks served by the same instance
+	var networks []rpcNetwork
+	for _, conf := range util.MustNewNetworkConfigsFromViper() {
+		network := rpcNetwork{NetworkConfig: conf}
+		conf.MustApply(func() { network.storeCtx = util.MustInitStoreContext() })
+		defer network.storeCtx.Close()
+
+		networks = append(networks, network)
+	}
+
 	if rpcOpt.cfxEnabled { // start core space RPC
-		startNativeSpaceRpcServer(ctx, &wg, storeCtx)
+		startNativeSpaceRpcServer(ctx, &wg, storeCtx, networks)
 	}
 
 	if rpcOpt.ethEnabled { // start evm space RPC
-		startEvmSpaceRpcServer(ctx, &wg, storeCtx)
+		startEvmSpaceRpcServer(ctx, &wg, storeCtx, networks)
 	}
 
 	if rpcOpt.cfxBridgeEnabled { // start core space bridge RPC
@@ -82,16 +104,101 @@ func startRpcService(*cobra.Command, []string) {
 	util.GracefulShutdown(&wg, cancel)
 }
 
+// rpcNetwork is an extra network served by the same instance along with its own stores.
+type rpcNetwork struct {
+	util.NetworkConfig
+	storeCtx util.StoreContext
+}
+
+// mustServeRpc serves RPC server at the endpoint, along with RPC servers of the extra networks
+// routed by URL path prefix on the same endpoint if any.
+func mustServeRpc(
+	ctx context.Context, wg *sync.WaitGroup, endpoint string, protocol rpcutil.Protocol,
+	server *rpcutil.Server, networks []rpcNetwork, networkServers []*rpcutil.Server,
+) {
+	if len(networks) == 0 {
+		server.MustServeGraceful(ctx, wg, endpoint, protocol)
+		return
+	}
+
+	routeServer := rpcutil.NewRouteServer(server.String(), protocol)
+	routeServer.MustRoute("/", server)
+
+	for i, network := range networks {
+		routeServer.MustRoute(network.Route, networkServers[i])
+	}
+
+	routeServer.MustServeGraceful(ctx, wg, endpoint)
+}
+
 // startNativeSpaceRpcServer starts core space RPC server
-func startNativeSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx util.StoreContext) {
+func startNativeSpaceRpcServer(
+	ctx context.Context, wg *sync.WaitGroup, storeCtx util.StoreContext, networks []rpcNetwork,
+) {
+	server := mustNewNativeSpaceRpcServer(ctx, wg, storeCtx, node.Factory().CreateRouter())
+	mustStartUsageAccounting(ctx, wg, "rpc.usage", "cfx", storeCtx.CfxDB)
+	mustStartSlowLog(ctx, wg, "rpc.slowlog", "cfx")
+
+	// initialize RPC servers of extra networks with network specific settings
+	networkServers := make([]*rpcutil.Server, len(networks))
+	for i, network := range networks {
+		network.MustApply(func() {
+			cfxFactory, _ := node.MustNewFactoriesFromViper()
+			networkServers[i] = mustNewNativeSpaceRpcServer(ctx, wg, network.storeCtx, cfxFactory.CreateRouter())
+		})
+	}
+
+	// serve HTTP endpoint
+	httpEndpoint := viper.GetString("rpc.endpoint")
+	go mustServeRpc(ctx, wg, httpEndpoint, rpcutil.ProtocolHttp, server, networks, networkServers)
+
+	// serve Websocket endpoint
+	if wsEndpoint := viper.GetString("rpc.wsEndpoint"); len(wsEndpoint) > 0 {
+		go mustServeRpc(ctx, wg, wsEndpoint, rpcutil.ProtocolWS, server, networks, networkServers)
+	}
+
+	// serve HTTP over Unix domain socket for co-located clients
+	if unixEndpoint := viper.GetString("rpc.unixEndpoint"); len(unixEndpoint) > 0 {
+		go mustServeRpc(ctx, wg, unixEndpoint, rpcutil.ProtocolHttp, server, networks, networkServers)
+	}
+
+	// serve debug endpoint
+	if debugEndpoint := viper.GetString("rpc.debugEndpoint"); len(debugEndpoint) > 0 {
+		server := rpc.MustNewDebugServer(viper.GetString("rpc.debugAuthToken"))
+		go server.MustServeGraceful(ctx, wg, debugEndpoint, rpcutil.ProtocolHttp)
+	}
+
+	// serve GraphQL endpoint over indexed chain data
+	if conf, ok := graphql.MustNewConfigFromViper("rpc.graphql"); ok {
+		if storeCtx.CfxDB == nil {
+			logrus.Fatal("DB store required for GraphQL server")
+		}
+
+		rateReg := rate.NewRegistry(rate.NewKeyLoader(storeCtx.CfxDB.LoadRateLimitKeyInfos), acl.NewCfxValidator)
+		go rateReg.AutoReload(15*time.Second, storeCtx.CfxDB.LoadRateLimitConfigs)
+
+		middleware := rpc.NewHttpContextMiddleware("cfx", rateReg)
+		graphql.MustServeGraceful(ctx, wg, conf, storeCtx.CfxDB, middleware)
+	}
+}
+
+// mustNewNativeSpaceRpcServer creates core space RPC server with the specified stores and router.
+func mustNewNativeSpaceRpcServer(
+	ctx context.Context, wg *sync.WaitGroup, storeCtx util.StoreContext, router node.Router,
+) *rpcutil.Server {
 	var rateReg *rate.Registry
 
-	router := node.Factory().CreateRouter()
 	clientProvider := node.NewCfxClientProvider(storeCtx.CfxDB, router)
 	relayer := relay.MustNewTxnRelayerFromViper()
 
+	// readiness probe of upstream full nodes and sync lag
+	storeCtx.RegisterReadinessProbe("cfxrpc", storeCtx.CfxDB, util.CfxHeadFunc(func() (sdk.ClientOperator, error) {
+		return clientProvider.GetClient(readinessProbeKey)
+	}))
+
 	option := rpc.CfxAPIOption{
-		TxnHandler: handler.MustNewCfxTxnHandler(relayer),
+		TxnHandler:  handler.MustNewCfxTxnHandler(relayer),
+		HeadTracker: handler.MustNewCfxHeadTrackerFromViper(clientProvider),
 	}
 
 	if vfc, ok := vfclient.MustNewCfxClientFromViper(); ok {
@@ -102,6 +209,10 @@ func startNativeSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx
 	// initialize store handler
 	if storeCtx.CfxDB != nil {
 		option.StoreHandler = handler.NewCfxCommonStoreHandler("db", storeCtx.CfxDB, option.StoreHandler)
+		option.ReorgStore = storeCtx.CfxDB
+		if storeCtx.CfxDB.IsTokenTransferIndexed() {
+			option.TokenStore = storeCtx.CfxDB
+		}
 
 		rateKeyLoader := rate.NewKeyLoader(storeCtx.CfxDB.LoadRateLimitKeyInfos)
 		rateReg = rate.NewRegistry(rateKeyLoader, acl.NewCfxValidator)
@@ -110,6 +221,19 @@ func startNativeSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx
 		go rateReg.AutoReload(15*time.Second, storeCtx.CfxDB.LoadRateLimitConfigs)
 	}
 
+	// initialize near-head in-memory window for epochs not persisted into database yet
+	var nearHeadSyncer *cisync.NearHeadSyncer
+	if storeCtx.CfxDB != nil {
+		if cfxs := rpcutil.MustNewCfxClientsFromViper(); len(cfxs) > 0 {
+			nearHeadSyncer = cisync.MustNewNearHeadSyncerFromViper(cfxs[0], storeCtx.CfxDB)
+		}
+	}
+
+	if nearHeadSyncer != nil {
+		go nearHeadSyncer.Sync(ctx, wg)
+		option.StoreHandler = handler.NewCfxCommonStoreHandler("memory", nearHeadSyncer.Store(), option.StoreHandler)
+	}
+
 	if storeCtx.CfxCache != nil {
 		option.StoreHandler = handler.NewCfxCommonStoreHandler("cache", storeCtx.CfxCache, option.StoreHandler)
 	}
@@ -129,41 +253,94 @@ func startNativeSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx
 			)
 		}
 
+		// initialize federated logs handler if historical backend configured
+		federatedHandler, ok := handler.MustNewCfxFederatedLogsHandlerFromViper(storeCtx.CfxDB)
+		if ok {
+			logrus.Info("Federated logs handler enabled with historical backend")
+		}
+
 		// initialize logs api handler
-		option.LogApiHandler = handler.NewCfxLogsApiHandler(storeCtx.CfxDB, prunedHandler)
+		option.LogApiHandler = handler.NewCfxLogsApiHandler(storeCtx.CfxDB, prunedHandler, federatedHandler)
+		if nearHeadSyncer != nil {
+			option.LogApiHandler.WithNearHeadStore(nearHeadSyncer.Store())
+		}
+
+		if planner := handler.MustNewLogQueryPlannerFromViper(storeCtx.CfxDB); planner != nil {
+			option.LogApiHandler.WithQueryPlanner(planner)
+		}
 	}
 
 	// initialize RPC server
 	exposedModules := viper.GetStringSlice("rpc.exposedModules")
-	server := rpc.MustNewNativeSpaceServer(rateReg, clientProvider, gasHandler, exposedModules, option)
+	return rpc.MustNewNativeSpaceServer(rateReg, clientProvider, gasHandler, exposedModules, option)
+}
+
+// startEvmSpaceRpcServer starts evm space RPC server
+func startEvmSpaceRpcServer(
+	ctx context.Context, wg *sync.WaitGroup, storeCtx util.StoreContext, networks []rpcNetwork,
+) {
+	server := mustNewEvmSpaceRpcServer(ctx, wg, storeCtx, node.EthFactory().CreateRouter())
+	mustStartUsageAccounting(ctx, wg, "ethrpc.usage", "eth", storeCtx.EthDB)
+	mustStartSlowLog(ctx, wg, "ethrpc.slowlog", "eth")
+	mustStartAbiRegistryAdmin(ctx, wg, storeCtx.EthDB)
+
+	// initialize RPC servers of extra networks with network specific settings
+	networkServers := make([]*rpcutil.Server, len(networks))
+	for i, network := range networks {
+		network.MustApply(func() {
+			_, ethFactory := node.MustNewFactoriesFromViper()
+			networkServers[i] = mustNewEvmSpaceRpcServer(ctx, wg, network.storeCtx, ethFactory.CreateRouter())
+		})
+	}
 
 	// serve HTTP endpoint
-	httpEndpoint := viper.GetString("rpc.endpoint")
-	go server.MustServeGraceful(ctx, wg, httpEndpoint, rpcutil.ProtocolHttp)
+	httpEndpoint := viper.GetString("ethrpc.endpoint")
+	go mustServeRpc(ctx, wg, httpEndpoint, rpcutil.ProtocolHttp, server, networks, networkServers)
 
 	// serve Websocket endpoint
-	if wsEndpoint := viper.GetString("rpc.wsEndpoint"); len(wsEndpoint) > 0 {
-		go server.MustServeGraceful(ctx, wg, wsEndpoint, rpcutil.ProtocolWS)
+	if wsEndpoint := viper.GetString("ethrpc.wsEndpoint"); len(wsEndpoint) > 0 {
+		go mustServeRpc(ctx, wg, wsEndpoint, rpcutil.ProtocolWS, server, networks, networkServers)
+	}
+
+	// serve HTTP over Unix domain socket for co-located clients
+	if unixEndpoint := viper.GetString("ethrpc.unixEndpoint"); len(unixEndpoint) > 0 {
+		go mustServeRpc(ctx, wg, unixEndpoint, rpcutil.ProtocolHttp, server, networks, networkServers)
 	}
 
 	// serve debug endpoint
-	if debugEndpoint := viper.GetString("rpc.debugEndpoint"); len(debugEndpoint) > 0 {
-		server := rpc.MustNewDebugServer()
+	if debugEndpoint := viper.GetString("ethrpc.debugEndpoint"); len(debugEndpoint) > 0 {
+		server := rpc.MustNewDebugServer(viper.GetString("ethrpc.debugAuthToken"))
 		go server.MustServeGraceful(ctx, wg, debugEndpoint, rpcutil.ProtocolHttp)
 	}
 }
 
-// startEvmSpaceRpcServer starts evm space RPC server
-func startEvmSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx util.StoreContext) {
+// mustNewEvmSpaceRpcServer creates evm space RPC server with the specified stores and router.
+func mustNewEvmSpaceRpcServer(
+	ctx context.Context, wg *sync.WaitGroup, storeCtx util.StoreContext, router node.Router,
+) *rpcutil.Server {
 	var rateReg *rate.Registry
 
-	router := node.EthFactory().CreateRouter()
 	clientProvider := node.NewEthClientProvider(storeCtx.EthDB, router)
 	relayer := relay.MustNewEthTxnRelayerFromViper()
 
+	// readiness probe of upstream full nodes and sync lag
+	storeCtx.RegisterReadinessProbe("ethrpc", storeCtx.EthDB, util.EthHeadFunc(func() (*web3go.Client, error) {
+		w3c, err := clientProvider.GetClient(readinessProbeKey)
+		if err != nil {
+			return nil, err
+		}
+
+		return w3c.Client, nil
+	}))
+
 	option := rpc.EthAPIOption{
-		TxnHandler: handler.MustNewEthTxnHandler(relayer),
+		TxnHandler:  handler.MustNewEthTxnHandler(relayer),
+		Txpool:      handler.MustNewEthTxpoolAggregatorFromViper(clientProvider),
+		HeadTracker: handler.MustNewEthHeadTrackerFromViper(clientProvider),
 	}
+	option.FinalityResolver = handler.MustNewEthFinalityResolverFromViper(option.HeadTracker)
+	option.BlockCache = handler.MustNewEthBlockCacheFromViper(option.HeadTracker)
+	option.SyncingAggregator = handler.MustNewEthSyncingAggregatorFromViper(option.HeadTracker, storeCtx.EthDB)
 
 	if vfc, ok := vfclient.MustNewEthClientFromViper(); ok {
 		option.VirtualFilterClient = vfc
@@ -173,11 +350,47 @@ func startEvmSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx ut
 	// initialize gas station handler
 	gasHandler := handler.MustNewEthGasStationHandlerFromViper(clientProvider)
 
+	// initialize near-head in-memory window for blocks not persisted into database yet
+	var nearHeadSyncer *cisync.NearHeadSyncer
+	if storeCtx.EthDB != nil {
+		if w3cs := rpcutil.MustNewEthClientsFromViper(); len(w3cs) > 0 {
+			nearHeadSyncer = cisync.MustNewEthNearHeadSyncerFromViper(w3cs[0], storeCtx.EthDB)
+		}
+	}
+
+	if nearHeadSyncer != nil {
+		go nearHeadSyncer.Sync(ctx, wg)
+	}
+
 	if storeCtx.EthDB != nil {
 		// initialize store handler
 		option.StoreHandler = handler.NewEthStoreHandler(storeCtx.EthDB, nil)
+		option.ReorgStore = storeCtx.EthDB
+		if storeCtx.EthDB.IsTokenTransferIndexed() {
+			option.TokenStore = storeCtx.EthDB
+		}
+		if storeCtx.EthDB.IsInternalTxIndexed() {
+			option.InternalTxStore = storeCtx.EthDB
+		}
 		// initialize logs api handler
 		option.LogApiHandler = handler.NewEthLogsApiHandler(storeCtx.EthDB)
+		if planner := handler.MustNewLogQueryPlannerFromViper(storeCtx.EthDB); planner != nil {
+			option.LogApiHandler.WithQueryPlanner(planner)
+		}
+		if nearHeadSyncer != nil {
+			option.LogApiHandler.WithNearHeadStore(nearHeadSyncer.Store())
+		}
+		if federatedHandler, ok := handler.MustNewEthFederatedLogsHandlerFromViper(); ok {
+			option.LogApiHandler.WithHistoricalBackend(federatedHandler)
+			logrus.Info("Federated logs handler enabled with eth historical backend")
+		}
+		option.LazyFilters = handler.MustNewEthLazyLogFiltersFromViper(option.HeadTracker, storeCtx.EthDB)
+		// initialize gas oracle
+		option.GasOracle = handler.MustNewEthGasOracleFromViper(storeCtx.EthDB)
+		// initialize trace result cache
+		option.TraceCache = handler.MustNewEthTraceCacheFromViper(storeCtx.EthDB, option.FinalityResolver)
+		// initialize contract ABI registry
+		option.AbiRegistry = handler.MustNewEthAbiRegistryFromViper(storeCtx.EthDB)
 
 		rateKeyLoader := rate.NewKeyLoader(storeCtx.EthDB.LoadRateLimitKeyInfos)
 		rateReg = rate.NewRegistry(rateKeyLoader, acl.NewEthValidator)
@@ -188,22 +401,75 @@ func startEvmSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx ut
 
 	// initialize RPC server
 	exposedModules := viper.GetStringSlice("ethrpc.exposedModules")
-	server := rpc.MustNewEvmSpaceServer(rateReg, clientProvider, gasHandler, exposedModules, option)
+	return rpc.MustNewEvmSpaceServer(rateReg, clientProvider, gasHandler, exposedModules, option)
+}
 
-	// serve HTTP endpoint
-	httpEndpoint := viper.GetString("ethrpc.endpoint")
-	go server.MustServeGraceful(ctx, wg, httpEndpoint, rpcutil.ProtocolHttp)
+// mustStartUsageAccounting starts to account usages per API key of the RPC namespace into database,
+// along with the admin endpoint to query usages if configured.
+func mustStartUsageAccounting(
+	ctx context.Context, wg *sync.WaitGroup, key, namespace string, db *mysql.MysqlStore,
+) {
+	conf, ok := usage.MustNewConfigFromViper(key)
+	if !ok {
+		return
+	}
 
-	// serve Websocket endpoint
-	if wsEndpoint := viper.GetString("ethrpc.wsEndpoint"); len(wsEndpoint) > 0 {
-		go server.MustServeGraceful(ctx, wg, wsEndpoint, rpcutil.ProtocolWS)
+	if db == nil {
+		logrus.Fatal("DB store required for usage accounting")
 	}
 
-	// serve debug endpoint
-	if debugEndpoint := viper.GetString("ethrpc.debugEndpoint"); len(debugEndpoint) > 0 {
-		server := rpc.MustNewDebugServer()
-		go server.MustServeGraceful(ctx, wg, debugEndpoint, rpcutil.ProtocolHttp)
+	accountant := usage.NewAccountant(conf, db.UsageStore)
+	usage.Register(namespace, accountant)
+	go accountant.Run(ctx, wg)
+
+	if len(conf.AdminEndpoint) > 0 {
+		usage.MustServeAdmin(ctx, wg, conf, db.UsageStore)
 	}
+
+	logrus.WithField("namespace", namespace).Info("Usage accounting enabled")
+}
+
+// mustStartSlowLog starts to record slow requests of the RPC namespace, along with the admin
+// endpoint to query slow requests and top offenders if configured.
+func mustStartSlowLog(ctx context.Context, wg *sync.WaitGroup, key, namespace string) {
+	conf, ok := slowlog.MustNewConfigFromViper(key)
+	if !ok {
+		return
+	}
+
+	recorder := slowlog.NewRecorder(conf)
+	slowlog.Register(namespace, recorder)
+
+	if len(conf.AdminEndpoint) > 0 {
+		server := rpcutil.MustNewAdminServer(namespace+"_slowlog_admin", map[string]interface{}{
+			"slowlog": slowlog.NewAdminAPI(recorder),
+		}, rpcutil.MustNewBearerAuthMiddleware(conf.AuthToken))
+
+		go server.MustServeGraceful(ctx, wg, conf.AdminEndpoint, rpcutil.ProtocolHttp)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"namespace": namespace,
+		"threshold": conf.Threshold,
+	}).Info("Slow request recorder enabled")
+}
+
+// mustStartAbiRegistryAdmin starts the admin endpoint to manage contract ABIs if configured. Note,
+// uploaded ABIs take effect on RPC servers once the cached ones expired.
+func mustStartAbiRegistryAdmin(ctx context.Context, wg *sync.WaitGroup, db *mysql.MysqlStore) {
+	registry := handler.MustNewEthAbiRegistryFromViper(db)
+	if registry == nil || len(registry.Config().AdminEndpoint) == 0 {
+		return
+	}
+
+	conf := registry.Config()
+	server := rpcutil.MustNewAdminServer("eth_abi_admin", map[string]interface{}{
+		"abi": handler.NewEthAbiAdminAPI(registry),
+	}, rpcutil.MustNewBearerAuthMiddleware(conf.AuthToken))
+
+	go server.MustServeGraceful(ctx, wg, conf.AdminEndpoint, rpcutil.ProtocolHttp)
+
+	logrus.WithField("endpoint", conf.AdminEndpoint).Info("Contract ABI registry admin enabled")
 }
 
 // startNativeSpaceBridgeRpcServer starts core space bridge RPC server
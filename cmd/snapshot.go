@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/Conflux-Chain/confura/snapshot"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// snapshot options
+	snapshotOpt struct {
+		dir     string
+		eth     bool
+		batch   uint64
+		from    uint64
+		to      uint64
+		segment uint64
+	}
+
+	exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export the specified epoch (or block for evm space) range from database into segment files",
+		Run:   exportSnapshot,
+	}
+
+	importCmd = &cobra.Command{
+		Use:   "import",
+		Short: "Import segment files of snapshot directory into database",
+		Run:   importSnapshot,
+	}
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{exportCmd, importCmd} {
+		cmd.Flags().StringVar(&snapshotOpt.dir, "dir", "", "snapshot directory of segment files")
+		cmd.MarkFlagRequired("dir")
+
+		cmd.Flags().BoolVar(&snapshotOpt.eth, "eth", false, "evm space rather than core space")
+	}
+
+	exportCmd.Flags().Uint64Var(&snapshotOpt.from, "from", 0, "epoch (or block) number to export from")
+	exportCmd.MarkFlagRequired("from")
+
+	exportCmd.Flags().Uint64Var(&snapshotOpt.to, "to", 0, "epoch (or block) number to export to (inclusive)")
+	exportCmd.MarkFlagRequired("to")
+
+	exportCmd.Flags().Uint64Var(&snapshotOpt.segment, "segment", 10000, "number of epochs per segment file")
+	importCmd.Flags().Uint64Var(&snapshotOpt.batch, "batch", 100, "max number of epochs to persist at a time")
+
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+}
+
+func exportSnapshot(*cobra.Command, []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	space, db, disabler := mustSnapshotStore(storeCtx)
+
+	exporter, err := snapshot.NewExporter(space, db, disabler, snapshotOpt.dir, snapshotOpt.segment)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create snapshot exporter")
+	}
+
+	logger := logrus.WithFields(logrus.Fields{
+		"space": space,
+		"dir":   snapshotOpt.dir,
+		"from":  snapshotOpt.from,
+		"to":    snapshotOpt.to,
+	})
+
+	segments, err := exporter.Export(context.Background(), snapshotOpt.from, snapshotOpt.to)
+	if err != nil {
+		logger.WithError(err).WithField("exported", len(segments)).Fatal("Failed to export snapshot")
+	}
+
+	logger.WithField("exported", len(segments)).Info("Succeeded to export snapshot")
+}
+
+func importSnapshot(*cobra.Command, []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	space, db, disabler := mustSnapshotStore(storeCtx)
+
+	importer, err := snapshot.NewImporter(space, db, disabler, snapshotOpt.dir, snapshotOpt.batch)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create snapshot importer")
+	}
+
+	logger := logrus.WithFields(logrus.Fields{
+		"space": space,
+		"dir":   snapshotOpt.dir,
+	})
+
+	num, err := importer.Import(context.Background())
+	if err != nil {
+		logger.WithError(err).WithField("imported", num).Fatal("Failed to import snapshot")
+	}
+
+	logger.WithField("imported", num).Info("Succeeded to import snapshot")
+}
+
+func mustSnapshotStore(storeCtx util.StoreContext) (string, *mysql.MysqlStore, store.ChainDataDisabler) {
+	if snapshotOpt.eth {
+		if storeCtx.EthDB == nil {
+			logrus.Fatal("EVM space database not configured")
+		}
+
+		return "eth", storeCtx.EthDB, store.EthStoreConfig()
+	}
+
+	if storeCtx.CfxDB == nil {
+		logrus.Fatal("Core space database not configured")
+	}
+
+	return "cfx", storeCtx.CfxDB, store.StoreConfig()
+}
@@ -6,6 +6,10 @@ import (
 
 	"github.com/Conflux-Chain/confura/cmd/util"
 	cisync "github.com/Conflux-Chain/confura/sync"
+	"github.com/Conflux-Chain/confura/sync/publish"
+	"github.com/Conflux-Chain/confura/sync/webhook"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/openweb3/web3go"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -49,6 +53,8 @@ func startSyncService(*cobra.Command, []string) {
 	storeCtx := util.MustInitStoreContext()
 	defer storeCtx.Close()
 
+	storeCtx.MustServeStoreHealth(ctx, true)
+
 	syncCtx := util.MustInitSyncContext(storeCtx)
 	defer syncCtx.Close()
 
@@ -81,21 +87,60 @@ func startSyncServiceAdaptively(ctx context.Context, wg *sync.WaitGroup, syncCtx
 func startSyncCfxDatabase(ctx context.Context, wg *sync.WaitGroup, syncCtx util.SyncContext) *cisync.DatabaseSyncer {
 	logrus.Info("Start to sync core space blockchain data into database")
 
+	// publish synced chain data to message broker if enabled
+	if publisher := publish.MustNewPublisherFromViper("cfx"); publisher != nil {
+		syncCtx.CfxDB.AddEpochDataObserver(publisher)
+		go publisher.Run(ctx, wg)
+	}
+
+	// notify webhooks of matched event logs if enabled
+	if dispatcher := webhook.MustNewDispatcherFromViper(syncCtx.CfxDB, nil); dispatcher != nil {
+		syncCtx.CfxDB.AddEpochDataObserver(dispatcher)
+		go dispatcher.Run(ctx, wg)
+	}
+
 	syncer := cisync.MustNewDatabaseSyncer(syncCtx.SyncCfxs, syncCtx.CfxDB)
 	go syncer.Sync(ctx, wg)
 
+	// readiness probe of upstream full node and sync lag
+	syncCtx.RegisterReadinessProbe("cfxsync", syncCtx.CfxDB, util.CfxHeadFunc(func() (sdk.ClientOperator, error) {
+		return syncCtx.SyncCfxs[0], nil
+	}))
+
 	// start core space db prune
 	go syncCtx.CfxDB.Prune()
 
+	// start core space epoch gap backfill if enabled
+	if backfiller := cisync.MustNewGapBackfillerFromViper(syncCtx.SyncCfxs, syncCtx.CfxDB); backfiller != nil {
+		go backfiller.Run(ctx, wg)
+	}
+
 	return syncer
 }
 
 func startSyncEthDatabase(ctx context.Context, wg *sync.WaitGroup, syncCtx util.SyncContext) {
 	logrus.Info("Start to sync evm space blockchain data into database")
 
+	// publish synced chain data to message broker if enabled
+	if publisher := publish.MustNewPublisherFromViper("eth"); publisher != nil {
+		syncCtx.EthDB.AddEpochDataObserver(publisher)
+		go publisher.Run(ctx, wg)
+	}
+
+	// notify webhooks of matched event logs if enabled
+	if dispatcher := webhook.MustNewDispatcherFromViper(syncCtx.EthDB, syncCtx.SyncEths); dispatcher != nil {
+		syncCtx.EthDB.AddEpochDataObserver(dispatcher)
+		go dispatcher.Run(ctx, wg)
+	}
+
 	ethSyncer := cisync.MustNewEthSyncer(syncCtx.SyncEths, syncCtx.EthDB)
 	go ethSyncer.Sync(ctx, wg)
 
+	// readiness probe of upstream full node and sync lag
+	syncCtx.RegisterReadinessProbe("ethsync", syncCtx.EthDB, util.EthHeadFunc(func() (*web3go.Client, error) {
+		return syncCtx.SyncEths[0], nil
+	}))
+
 	// start evm space db prune
 	go syncCtx.EthDB.Prune()
 }
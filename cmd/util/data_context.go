@@ -1,14 +1,17 @@
 package util
 
 import (
+	"context"
 	"errors"
 	"strings"
 
 	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/health"
 	"github.com/Conflux-Chain/confura/store/mysql"
 	"github.com/Conflux-Chain/confura/store/redis"
 	"github.com/Conflux-Chain/confura/util/rpc"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
 	"github.com/openweb3/web3go"
 )
 
@@ -17,6 +20,9 @@ type StoreContext struct {
 	CfxDB    *mysql.MysqlStore
 	EthDB    *mysql.MysqlStore
 	CfxCache *redis.RedisStore
+
+	// store health checker, nil if disabled
+	Health *health.Checker
 }
 
 func MustInitStoreContext() StoreContext {
@@ -58,6 +64,74 @@ func (ctx *StoreContext) Close() {
 	}
 }
 
+// MustServeStoreHealth starts the store health checker if enabled, where `checkWrites` indicates
+// whether to check recent write success, which only applies to sync service.
+func (ctx *StoreContext) MustServeStoreHealth(c context.Context, checkWrites bool) {
+	checker, ok := health.MustNewCheckerFromViper(checkWrites)
+	if !ok {
+		return
+	}
+
+	if ctx.CfxDB != nil {
+		checker.Register("cfxdb", ctx.CfxDB)
+	}
+
+	if ctx.EthDB != nil {
+		checker.Register("ethdb", ctx.EthDB)
+	}
+
+	checker.MustServe(c)
+	ctx.Health = checker
+}
+
+// RegisterReadinessProbe registers readiness probe to check the availability of upstream full
+// nodes, and the sync lag of db store if not nil, which takes effect only if health checker enabled.
+func (ctx *StoreContext) RegisterReadinessProbe(name string, db *mysql.MysqlStore, head func() (uint64, error)) {
+	if ctx.Health == nil {
+		return
+	}
+
+	if db == nil {
+		ctx.Health.RegisterProbe(name, health.NewSyncLagProbe(head, nil, 0))
+	} else {
+		ctx.Health.RegisterProbe(name, health.NewSyncLagProbe(head, db, ctx.Health.MaxSyncLag()))
+	}
+}
+
+// CfxHeadFunc returns func to get the latest confirmed epoch from core space full node.
+func CfxHeadFunc(client func() (sdk.ClientOperator, error)) func() (uint64, error) {
+	return func() (uint64, error) {
+		cfx, err := client()
+		if err != nil {
+			return 0, err
+		}
+
+		epoch, err := cfx.GetEpochNumber(types.EpochLatestConfirmed)
+		if err != nil {
+			return 0, err
+		}
+
+		return epoch.ToInt().Uint64(), nil
+	}
+}
+
+// EthHeadFunc returns func to get the latest block number from evm space full node.
+func EthHeadFunc(client func() (*web3go.Client, error)) func() (uint64, error) {
+	return func() (uint64, error) {
+		w3c, err := client()
+		if err != nil {
+			return 0, err
+		}
+
+		bn, err := w3c.Eth.BlockNumber()
+		if err != nil {
+			return 0, err
+		}
+
+		return bn.Uint64(), nil
+	}
+}
+
 // GetMysqlStore returns mysql store by network space
 func (ctx *StoreContext) GetMysqlStore(network string) (store *mysql.MysqlStore, err error) {
 	switch {
@@ -82,11 +156,15 @@ func MustInitSyncContext(storeCtx StoreContext) SyncContext {
 	sc := SyncContext{StoreContext: storeCtx}
 
 	if storeCtx.CfxDB != nil || storeCtx.CfxCache != nil {
-		sc.SyncCfxs = rpc.MustNewCfxClientsFromViper(rpc.WithClientHookMetrics(true))
+		sc.SyncCfxs = rpc.MustNewCfxClientsFromViper(
+			rpc.WithClientHookMetrics(true), rpc.WithClientSyncThrottle(true),
+		)
 	}
 
 	if storeCtx.EthDB != nil {
-		sc.SyncEths = rpc.MustNewEthClientsFromViper(rpc.WithClientHookMetrics(true))
+		sc.SyncEths = rpc.MustNewEthClientsFromViper(
+			rpc.WithClientHookMetrics(true), rpc.WithClientSyncThrottle(true),
+		)
 	}
 
 	return sc
@@ -0,0 +1,136 @@
+package util
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NetworkConfig is the settings of an extra network (e.g. testnet or custom chain) served by the
+// same instance, which has its own upstream full nodes and stores.
+type NetworkConfig struct {
+	// network name, e.g. `testnet`
+	Name string
+	// URL path prefix to route RPC requests, e.g. `/testnet`
+	Route string
+	// settings to override the base configurations for this network, e.g. `node.urls` and
+	// `store.mysql.database`
+	Overrides map[string]interface{}
+}
+
+// MustNewNetworkConfigsFromViper loads the extra networks served by the same instance.
+func MustNewNetworkConfigsFromViper() []NetworkConfig {
+	// list of structs is not supported by viper util, which only respects nested keys
+	var networks []NetworkConfig
+	if err := viper.UnmarshalKey("networks", &networks); err != nil {
+		logrus.WithError(err).Fatal("Failed to unmarshal network configs from viper")
+	}
+
+	names := make(map[string]bool)
+	for _, n := range networks {
+		logger := logrus.WithField("network", n.Name)
+
+		if len(n.Name) == 0 || len(strings.Trim(n.Route, "/")) == 0 {
+			logger.Fatal("Network name and route path required")
+		}
+
+		if names[n.Name] {
+			logger.Fatal("Duplicate network configured")
+		}
+
+		names[n.Name] = true
+	}
+
+	return networks
+}
+
+// MustApply runs the specified function with the base viper settings overridden by the network's,
+// so that components created within will use the network specific settings, e.g. full nodes and
+// stores. The base settings will be restored afterwards.
+//
+// Note, it is not concurrency safe and is only supposed to be used during bootstrap.
+func (n NetworkConfig) MustApply(fn func()) {
+	overrides := make(map[string]interface{})
+	flattenSettings("", n.Overrides, overrides)
+
+	bases := make(map[string]interface{}, len(overrides))
+	var absents []string
+
+	for key, value := range overrides {
+		if viper.IsSet(key) {
+			bases[key] = viper.Get(key)
+		} else {
+			absents = append(absents, key)
+		}
+
+		viper.Set(key, value)
+	}
+
+	defer func() {
+		for key, value := range bases {
+			viper.Set(key, value)
+		}
+
+		for _, key := range absents {
+			unsetViperKey(key)
+		}
+	}()
+
+	logrus.WithFields(logrus.Fields{
+		"network":   n.Name,
+		"overrides": len(overrides),
+	}).Info("Network specific settings applied")
+
+	fn()
+}
+
+// unsetViperKey removes the key set by `viper.Set`, since viper doesn't support to unset any key.
+//
+// Note, it relies on that `viper.Get` returns the nested map of override settings created by
+// `viper.Set` for the parent key, which is then modified in place. Parents left empty are removed
+// as well, except the top level one.
+func unsetViperKey(key string) {
+	path := strings.Split(key, ".")
+	if len(path) == 1 {
+		// nil override falls through to the config file and defaults
+		viper.Set(key, nil)
+		return
+	}
+
+	for i := len(path) - 1; i > 0; i-- {
+		parent, ok := viper.Get(strings.Join(path[:i], ".")).(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		if delete(parent, path[i]); len(parent) > 0 {
+			return
+		}
+	}
+}
+
+// flattenSettings flattens the nested settings into dot delimited keys as viper does.
+func flattenSettings(prefix string, settings map[string]interface{}, result map[string]interface{}) {
+	for key, value := range settings {
+		key = strings.ToLower(key)
+		if len(prefix) > 0 {
+			key = prefix + "." + key
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flattenSettings(key, v, result)
+		case map[interface{}]interface{}:
+			m := make(map[string]interface{}, len(v))
+			for k, val := range v {
+				if ks, ok := k.(string); ok {
+					m[ks] = val
+				}
+			}
+			flattenSettings(key, m, result)
+		default:
+			result[key] = value
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworkConfigMustApply(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	viper.Set("node.urls", []string{"http://mainnet:12537"})
+	viper.Set("store.mysql.database", "mainnet")
+	viper.Set("store.mysql.dsn", "root@tcp(127.0.0.1:3306)/")
+
+	network := NetworkConfig{
+		Name:  "testnet",
+		Route: "/testnet",
+		Overrides: map[string]interface{}{
+			"store": map[interface{}]interface{}{
+				"mysql": map[string]interface{}{"database": "testnet"},
+				"redis": map[string]interface{}{"url": "redis://testnet:6379"},
+			},
+			"node": map[string]interface{}{"urls": []string{"http://testnet:12537"}},
+		},
+	}
+
+	network.MustApply(func() {
+		assert.Equal(t, []string{"http://testnet:12537"}, viper.Get("node.urls"))
+		assert.Equal(t, "testnet", viper.GetString("store.mysql.database"))
+		assert.Equal(t, "root@tcp(127.0.0.1:3306)/", viper.GetString("store.mysql.dsn"))
+		assert.Equal(t, "redis://testnet:6379", viper.GetString("store.redis.url"))
+	})
+
+	// base settings restored
+	assert.Equal(t, []string{"http://mainnet:12537"}, viper.Get("node.urls"))
+	assert.Equal(t, "mainnet", viper.GetString("store.mysql.database"))
+	assert.Equal(t, "root@tcp(127.0.0.1:3306)/", viper.GetString("store.mysql.dsn"))
+
+	// absent settings unset rather than restored as nil
+	assert.False(t, viper.IsSet("store.redis.url"))
+	assert.NotContains(t, viper.AllKeys(), "store.redis.url")
+}
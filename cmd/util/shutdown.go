@@ -6,7 +6,11 @@ import (
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/tracing"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
 	"github.com/sirupsen/logrus"
 )
 
@@ -17,8 +21,31 @@ type GracefulShutdownContext struct {
 	Wg  *sync.WaitGroup
 }
 
+// shutdownConfig is the configuration of coordinated shutdown for zero-downtime rolling deploys.
+type shutdownConfig struct {
+	// duration to keep serving while draining (readiness check fails) before shutdown,
+	// so that load balancers could deregister the instance, disabled if zero
+	DrainDelay time.Duration
+	// deadline for in-flight requests to finish
+	Timeout time.Duration `default:"30s"`
+	// deadline for background tasks to clean up after in-flight requests finished, e.g., some
+	// delegate filters on full nodes could only be uninstalled once no more requests served
+	CleanupTimeout time.Duration `default:"30s"`
+}
+
 // GracefulShutdown supports to clean up goroutines after termination signal captured.
+//
+// The shutdown is coordinated in phases:
+// 1. drain for a while if configured, during which readiness check fails;
+// 2. stop accepting new connections, and let in-flight requests finish up to the deadline;
+// 3. wait for background tasks (eg., flush queued events and persist sync state) up to the cleanup
+// deadline after in-flight requests finished.
 func GracefulShutdown(wg *sync.WaitGroup, cancel context.CancelFunc) {
+	var conf shutdownConfig
+	viper.MustUnmarshalKey("shutdown", &conf)
+
+	rpcutil.DefaultShutdownTimeout = conf.Timeout
+
 	// Handle sigterm and await termChan signal
 	termChan := make(chan os.Signal, 1)
 	signal.Notify(termChan, syscall.SIGTERM, syscall.SIGINT)
@@ -27,11 +54,39 @@ func GracefulShutdown(wg *sync.WaitGroup, cancel context.CancelFunc) {
 	<-termChan
 	logrus.Info("SIGTERM/SIGINT received, shutdown process initiated")
 
+	if conf.DrainDelay > 0 {
+		rpcutil.StartDraining()
+		logrus.WithField("drainDelay", conf.DrainDelay).Info("Draining before shutdown...")
+
+		select {
+		case <-time.After(conf.DrainDelay):
+		case <-termChan:
+			logrus.Info("Signal received again, skip draining")
+		}
+	}
+
 	// Cancel to notify active goroutines to clean up.
 	cancel()
 
-	logrus.Info("Waiting for shutdown...")
-	wg.Wait()
+	// servers stop gracefully within timeout, while background tasks clean up concurrently and
+	// could last until cleanup timeout after that
+	timeout := conf.Timeout + conf.CleanupTimeout
+	logrus.WithField("timeout", timeout).Info("Waiting for shutdown...")
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		logrus.Warn("Shutdown timed out, some goroutines may not be cleaned up")
+	}
+
+	// flush pending trace spans
+	tracing.Shutdown()
 
 	logrus.Info("Shutdown gracefully")
 }
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/cmd/util"
+	cisync "github.com/Conflux-Chain/confura/sync"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// verify options
+	verifyOpt struct {
+		epochFrom uint64
+		epochTo   uint64
+		samples   uint64
+		eth       bool
+	}
+
+	verifyCmd = &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the data in database against full node by sampling epochs (or blocks for evm space)",
+		Run:   verify,
+	}
+)
+
+func init() {
+	verifyCmd.Flags().Uint64Var(&verifyOpt.epochFrom, "from", 0, "epoch (or block) number to verify from")
+	verifyCmd.MarkFlagRequired("from")
+
+	verifyCmd.Flags().Uint64Var(&verifyOpt.epochTo, "to", 0, "epoch (or block) number to verify to (inclusive)")
+	verifyCmd.MarkFlagRequired("to")
+
+	verifyCmd.Flags().Uint64Var(
+		&verifyOpt.samples, "sample", 100, "number of epochs to randomly sample, 0 to verify all within range",
+	)
+	verifyCmd.Flags().BoolVar(&verifyOpt.eth, "eth", false, "verify evm space rather than core space")
+
+	rootCmd.AddCommand(verifyCmd)
+}
+
+func verify(*cobra.Command, []string) {
+	storeCtx := util.MustInitStoreContext()
+	defer storeCtx.Close()
+
+	syncCtx := util.MustInitSyncContext(storeCtx)
+	defer syncCtx.Close()
+
+	var verifier *cisync.EpochVerifier
+	if verifyOpt.eth {
+		if syncCtx.EthDB == nil {
+			logrus.Fatal("EVM space database not configured")
+		}
+
+		verifier = cisync.MustNewEthEpochVerifier(syncCtx.SyncEths[0], syncCtx.EthDB)
+	} else {
+		if syncCtx.CfxDB == nil {
+			logrus.Fatal("Core space database not configured")
+		}
+
+		verifier = cisync.MustNewCfxEpochVerifier(syncCtx.SyncCfxs[0], syncCtx.CfxDB)
+	}
+
+	logger := logrus.WithFields(logrus.Fields{
+		"from":   verifyOpt.epochFrom,
+		"to":     verifyOpt.epochTo,
+		"sample": verifyOpt.samples,
+		"eth":    verifyOpt.eth,
+	})
+
+	sampled, mismatches, err := verifier.Verify(
+		context.Background(), verifyOpt.epochFrom, verifyOpt.epochTo, verifyOpt.samples,
+	)
+	if err != nil {
+		logger.WithError(err).WithField("sampled", len(sampled)).Fatal("Failed to verify epochs")
+	}
+
+	logger = logger.WithFields(logrus.Fields{
+		"sampled":    len(sampled),
+		"mismatches": len(mismatches),
+	})
+
+	if len(mismatches) > 0 {
+		logger.Fatal("Verified epochs with mismatches between database and full node")
+	}
+
+	logger.Info("Succeeded to verify epochs without any mismatch")
+}
@@ -67,7 +67,7 @@ func startEvmSpaceVirtualFilterServer(ctx context.Context, wg *sync.WaitGroup, s
 	// serve HTTP endpoint
 	vfServer, httpEndpoint := virtualfilter.MustNewEvmSpaceServerFromViper(
 		util.GracefulShutdownContext{Ctx: ctx, Wg: wg},
-		storeCtx.EthDB.VirtualFilterLogStore,
+		storeCtx.EthDB,
 	)
 
 	go vfServer.MustServeGraceful(ctx, wg, httpEndpoint, rpcutil.ProtocolHttp)
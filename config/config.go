@@ -5,9 +5,14 @@ import (
 	"github.com/Conflux-Chain/confura/rpc"
 	"github.com/Conflux-Chain/confura/store"
 	"github.com/Conflux-Chain/confura/util/blacklist"
+	"github.com/Conflux-Chain/confura/util/logging"
+	imetrics "github.com/Conflux-Chain/confura/util/metrics"
 	"github.com/Conflux-Chain/confura/util/pprof"
+	"github.com/Conflux-Chain/confura/util/reload"
 	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	"github.com/Conflux-Chain/confura/util/rpc/cache"
+	"github.com/Conflux-Chain/confura/util/slo"
+	"github.com/Conflux-Chain/confura/util/tracing"
 	"github.com/Conflux-Chain/go-conflux-util/config"
 	metricUtil "github.com/Conflux-Chain/go-conflux-util/metrics"
 	"github.com/ethereum/go-ethereum/metrics"
@@ -25,10 +30,20 @@ func Init() {
 
 	// init utilities eg., viper, alert, metrics and logging
 	config.MustInit(viperEnvPrefix)
+	logging.MustInitFromViper()
 
 	// init pprof
 	pprof.MustInit()
 
+	// init Prometheus exporter
+	imetrics.MustInitPrometheus()
+
+	// init tracing
+	tracing.MustInit()
+
+	// init SLO tracking
+	slo.MustInitFromViper()
+
 	// init misc util
 	cache.MustInitFromViper()
 	rpcutil.MustInit()
@@ -40,4 +55,7 @@ func Init() {
 	node.MustInit()
 	// init rpc
 	rpc.MustInit()
+
+	// watch config changes to hot-reload tunable settings
+	reload.MustInitFromViper()
 }
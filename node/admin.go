@@ -0,0 +1,391 @@
+package node
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// gRPC service name of node manager admin
+	adminServiceName = "confura.node.Admin"
+	// gRPC content subtype to encode admin messages as JSON
+	AdminContentSubtype = "json"
+)
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonCodec encodes gRPC messages as JSON, so that admin messages could be defined as
+// plain Go structs without protobuf code generation.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                               { return AdminContentSubtype }
+
+// NodeRequest is the admin request to operate on a full node of group.
+type NodeRequest struct {
+	Group Group
+	URL   string
+}
+
+// GroupRequest is the admin request to query full nodes of group.
+type GroupRequest struct {
+	Group Group
+}
+
+// NodeInfo is the health and routing state of a managed full node.
+type NodeInfo struct {
+	Group     Group
+	Name      string
+	URL       string
+	Weight    int
+	Local     bool            // whether in the same region
+	Unhealthy bool            // whether removed from hash ring due to unhealthy
+	Drained   bool            // whether drained for maintenance
+	Routable  bool            // whether in the hash ring to serve requests
+	Score     float64         // health score
+	Status    json.RawMessage // heartbeat status
+}
+
+// ListNodesResponse is the admin response of ListNodes.
+type ListNodesResponse struct {
+	Nodes []NodeInfo
+}
+
+// RouteEntry is the route table entry of a full node on the hash ring.
+type RouteEntry struct {
+	Name       string
+	URL        string
+	Replicas   int     // virtual members on the hash ring
+	Partitions int     // hash ring partitions owned
+	Share      float64 // share of partitions owned
+}
+
+// RouteTableResponse is the admin response of RouteTable.
+type RouteTableResponse struct {
+	Group   Group
+	Entries []RouteEntry
+}
+
+// DrainResponse is the admin response of Drain and Undrain.
+type DrainResponse struct {
+	Node NodeInfo
+}
+
+// AdminServer is the server API of node manager admin service.
+type AdminServer interface {
+	ListNodes(context.Context, *GroupRequest) (*ListNodesResponse, error)
+	RouteTable(context.Context, *GroupRequest) (*RouteTableResponse, error)
+	Drain(context.Context, *NodeRequest) (*DrainResponse, error)
+	Undrain(context.Context, *NodeRequest) (*DrainResponse, error)
+}
+
+// adminServiceDesc is the gRPC service descriptor for AdminServer.
+var adminServiceDesc = grpc.ServiceDesc{
+	ServiceName: adminServiceName,
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListNodes",
+			Handler: adminHandler(func(srv AdminServer, ctx context.Context, req *GroupRequest) (interface{}, error) {
+				return srv.ListNodes(ctx, req)
+			}),
+		},
+		{
+			MethodName: "RouteTable",
+			Handler: adminHandler(func(srv AdminServer, ctx context.Context, req *GroupRequest) (interface{}, error) {
+				return srv.RouteTable(ctx, req)
+			}),
+		},
+		{
+			MethodName: "Drain",
+			Handler: adminHandler(func(srv AdminServer, ctx context.Context, req *NodeRequest) (interface{}, error) {
+				return srv.Drain(ctx, req)
+			}),
+		},
+		{
+			MethodName: "Undrain",
+			Handler: adminHandler(func(srv AdminServer, ctx context.Context, req *NodeRequest) (interface{}, error) {
+				return srv.Undrain(ctx, req)
+			}),
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+// adminHandler adapts typed admin method to gRPC method handler.
+func adminHandler[T any](
+	call func(srv AdminServer, ctx context.Context, req *T) (interface{}, error),
+) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(
+		srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor,
+	) (interface{}, error) {
+		req := new(T)
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+
+		if interceptor == nil {
+			return call(srv.(AdminServer), ctx, req)
+		}
+
+		info := &grpc.UnaryServerInfo{Server: srv}
+		return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return call(srv.(AdminServer), ctx, req.(*T))
+		})
+	}
+}
+
+// adminService implements AdminServer upon node pool.
+type adminService struct {
+	h *apiHandler
+}
+
+func (s *adminService) ListNodes(ctx context.Context, req *GroupRequest) (*ListNodesResponse, error) {
+	var groups []Group
+	if len(req.Group) > 0 {
+		groups = append(groups, req.Group)
+	} else {
+		groups = s.h.pool.groups()
+	}
+
+	res := &ListNodesResponse{}
+	for _, grp := range groups {
+		if m, ok := s.h.pool.manager(grp); ok {
+			res.Nodes = append(res.Nodes, m.nodeInfos()...)
+		}
+	}
+
+	return res, nil
+}
+
+func (s *adminService) RouteTable(ctx context.Context, req *GroupRequest) (*RouteTableResponse, error) {
+	m, ok := s.h.pool.manager(req.Group)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "group %v not found", req.Group)
+	}
+
+	return &RouteTableResponse{Group: req.Group, Entries: m.routeTable()}, nil
+}
+
+func (s *adminService) Drain(ctx context.Context, req *NodeRequest) (*DrainResponse, error) {
+	return s.drain(req, true)
+}
+
+func (s *adminService) Undrain(ctx context.Context, req *NodeRequest) (*DrainResponse, error) {
+	return s.drain(req, false)
+}
+
+func (s *adminService) drain(req *NodeRequest, drain bool) (*DrainResponse, error) {
+	m, ok := s.h.pool.manager(req.Group)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "group %v not found", req.Group)
+	}
+
+	nodeName := rpc.Url2NodeName(req.URL)
+
+	var found bool
+	if drain {
+		found = m.Drain(nodeName)
+	} else {
+		found = m.Undrain(nodeName)
+	}
+
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "node %v not found", req.URL)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"group":   req.Group,
+		"node":    nodeName,
+		"drained": drain,
+	}).Info("Node drain state changed by admin")
+
+	for _, info := range m.nodeInfos() {
+		if info.Name == nodeName {
+			return &DrainResponse{Node: info}, nil
+		}
+	}
+
+	return nil, status.Errorf(codes.NotFound, "node %v not found", req.URL)
+}
+
+// adminAuthInterceptor authenticates admin requests by bearer token in the `authorization` metadata.
+func adminAuthInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		for _, v := range md.Get("authorization") {
+			provided := strings.TrimPrefix(v, "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1 {
+				return handler(ctx, req)
+			}
+		}
+
+		return nil, status.Error(codes.Unauthenticated, "invalid admin auth token")
+	}
+}
+
+// mustServeAdmin serves the node manager admin gRPC service at the specified endpoint,
+// which will be gracefully stopped once context done.
+func mustServeAdmin(ctx context.Context, wg *sync.WaitGroup, endpoint, token string, h *apiHandler) {
+	if len(token) == 0 {
+		logrus.Fatal("Auth token required for node manager admin service")
+	}
+
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		logrus.WithError(err).WithField("endpoint", endpoint).Fatal("Failed to listen for node manager admin service")
+	}
+
+	opts := []grpc.ServerOption{grpc.UnaryInterceptor(adminAuthInterceptor(token))}
+
+	// require client certificates for mutual TLS if admin TLS configured
+	tlsConf := rpc.MustNewAdminTLSConfigFromViper()
+	if tlsConf != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConf)))
+	}
+
+	server := grpc.NewServer(opts...)
+	server.RegisterService(&adminServiceDesc, &adminService{h: h})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+
+	go func() {
+		logrus.WithFields(logrus.Fields{
+			"endpoint": endpoint,
+			"tls":      tlsConf != nil,
+		}).Info("Node manager admin service started")
+
+		if err := server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			logrus.WithError(err).Fatal("Failed to serve node manager admin service")
+		}
+	}()
+}
+
+// AdminClient is the client of node manager admin service.
+type AdminClient struct {
+	conn  *grpc.ClientConn
+	token string
+}
+
+// NewAdminClient creates admin client upon gRPC connection with auth token. Note, the connection
+// should be dialed with client certificate if admin TLS configured.
+func NewAdminClient(conn *grpc.ClientConn, token string) *AdminClient {
+	return &AdminClient{conn: conn, token: token}
+}
+
+func (c *AdminClient) ListNodes(ctx context.Context, group Group) (*ListNodesResponse, error) {
+	res := &ListNodesResponse{}
+	err := c.invoke(ctx, "ListNodes", &GroupRequest{Group: group}, res)
+	return res, err
+}
+
+func (c *AdminClient) RouteTable(ctx context.Context, group Group) (*RouteTableResponse, error) {
+	res := &RouteTableResponse{}
+	err := c.invoke(ctx, "RouteTable", &GroupRequest{Group: group}, res)
+	return res, err
+}
+
+func (c *AdminClient) Drain(ctx context.Context, group Group, url string) (*DrainResponse, error) {
+	res := &DrainResponse{}
+	err := c.invoke(ctx, "Drain", &NodeRequest{Group: group, URL: url}, res)
+	return res, err
+}
+
+func (c *AdminClient) Undrain(ctx context.Context, group Group, url string) (*DrainResponse, error) {
+	res := &DrainResponse{}
+	err := c.invoke(ctx, "Undrain", &NodeRequest{Group: group, URL: url}, res)
+	return res, err
+}
+
+func (c *AdminClient) invoke(ctx context.Context, method string, req, res interface{}) error {
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.token)
+	return c.conn.Invoke(
+		ctx, "/"+adminServiceName+"/"+method, req, res, grpc.CallContentSubtype(AdminContentSubtype),
+	)
+}
+
+// nodeInfos returns the health and routing states of all managed full nodes.
+func (m *Manager) nodeInfos() (res []NodeInfo) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for name, n := range m.nodes {
+		nodeStatus := n.Status()
+		statusJson, _ := json.Marshal(&nodeStatus)
+
+		res = append(res, NodeInfo{
+			Group:     m.group,
+			Name:      name,
+			URL:       n.Url(),
+			Weight:    m.weights[name],
+			Local:     cfg.isLocal(name),
+			Unhealthy: m.monitorStatuses[name].unhealthy,
+			Drained:   m.drained[name],
+			Routable:  m.ringed[name],
+			Score:     m.scoreOf(name),
+			Status:    statusJson,
+		})
+	}
+
+	return res
+}
+
+// routeTable returns the hash ring partitions owned by each routable full node.
+func (m *Manager) routeTable() (res []RouteEntry) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ring := m.hashRing
+	if len(m.localRing.GetMembers()) > 0 {
+		ring = m.localRing
+	}
+
+	partitions := make(map[string]int)
+	if len(ring.GetMembers()) > 0 {
+		for partId := 0; partId < cfg.HashRing.PartitionCount; partId++ {
+			partitions[ring.GetPartitionOwner(partId).(Node).Name()]++
+		}
+	}
+
+	for name, n := range m.nodes {
+		if !m.ringed[name] {
+			continue
+		}
+
+		res = append(res, RouteEntry{
+			Name:       name,
+			URL:        n.Url(),
+			Replicas:   m.weights[name],
+			Partitions: partitions[name],
+			Share:      float64(partitions[name]) / float64(cfg.HashRing.PartitionCount),
+		})
+	}
+
+	return res
+}
@@ -13,8 +13,10 @@ type CfxClientProvider struct {
 	*clientProvider
 }
 
-func newCfxClient(url string) (interface{}, error) {
-	return rpc.NewCfxClient(url, rpc.WithClientHookMetrics(true), rpc.WithClientHookCache(true))
+func newCfxClient(url string, group Group) (interface{}, error) {
+	return rpc.NewCfxClient(
+		url, rpc.WithClientHookMetrics(true), rpc.WithClientHookCache(true), rpc.WithClientNodeGroup(string(group)),
+	)
 }
 
 func NewCfxClientProvider(db *mysql.MysqlStore, router Router) *CfxClientProvider {
@@ -33,10 +35,23 @@ func (p *CfxClientProvider) GetClient(key string, groups ...Group) (sdk.ClientOp
 	return client.(sdk.ClientOperator), nil
 }
 
-// GetClientByIP gets client of specific group (or use normal HTTP group as default) by remote IP address.
-func (p *CfxClientProvider) GetClientByIP(ctx context.Context, groups ...Group) (sdk.ClientOperator, error) {
-	remoteAddr := remoteAddrFromContext(ctx)
-	client, err := p.getClient(remoteAddr, cfxNodeGroup(groups...))
+// GetClientByAffinity gets client of specific group (or use normal HTTP group as default) by
+// access token if present, otherwise by remote IP address.
+func (p *CfxClientProvider) GetClientByAffinity(ctx context.Context, groups ...Group) (sdk.ClientOperator, error) {
+	client, err := p.getClient(affinityKeyFromContext(ctx), cfxNodeGroup(groups...))
+	if err != nil {
+		return nil, err
+	}
+
+	return client.(sdk.ClientOperator), nil
+}
+
+// GetAlternativeClientByAffinity gets client of specific group (or use normal HTTP group as default),
+// which is routed to any full node other than the excluded one.
+func (p *CfxClientProvider) GetAlternativeClientByAffinity(
+	ctx context.Context, excludedUrl string, groups ...Group,
+) (sdk.ClientOperator, error) {
+	client, err := p.getAlternativeClient(affinityKeyFromContext(ctx), cfxNodeGroup(groups...), excludedUrl)
 	if err != nil {
 		return nil, err
 	}
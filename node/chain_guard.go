@@ -0,0 +1,123 @@
+package node
+
+import (
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var errChainIdMismatch = errors.New("chain ID mismatch")
+
+// chainIdentified is implemented by full nodes which could be guarded by chain ID.
+type chainIdentified interface {
+	// fetchChainId fetches the chain ID (network ID for core space) from full node.
+	fetchChainId() (uint64, error)
+	// chainState returns the chain ID cache and guard of full node.
+	chainState() *nodeChainState
+}
+
+// chainGuard guards against full nodes of mismatched chain ID, e.g., node URL of another network
+// misconfigured by operator, which is catastrophic to mix data across networks.
+type chainGuard struct {
+	space   string
+	chainId atomic.Uint64 // expected chain ID, 0 means determined by the first verified full node
+}
+
+func newChainGuard(space string, chainId uint64) *chainGuard {
+	g := &chainGuard{space: space}
+	g.chainId.Store(chainId)
+
+	return g
+}
+
+// verify checks the chain ID of full node against the expected one.
+func (g *chainGuard) verify(nodeName string, chainId uint64) error {
+	if g.chainId.CompareAndSwap(0, chainId) {
+		logrus.WithFields(logrus.Fields{
+			"space":   g.space,
+			"node":    nodeName,
+			"chainId": chainId,
+		}).Warn("Chain ID not configured, determined by the first verified full node")
+		return nil
+	}
+
+	if expected := g.chainId.Load(); expected != chainId {
+		return errors.WithMessagef(errChainIdMismatch, "expected %v but got %v", expected, chainId)
+	}
+
+	return nil
+}
+
+// nodeChainState caches the chain ID of full node once verified.
+type nodeChainState struct {
+	guard   atomic.Pointer[chainGuard] // nil if not guarded
+	chainId atomic.Uint64              // cached chain ID, 0 means not verified yet
+}
+
+// verifyChainId verifies the chain ID of full node if guarded, which is fetched from full node
+// unless cached. Note, the chain ID is re-fetched once invalidated, e.g., heartbeat failed, since
+// the full node might be replaced behind the same URL.
+func verifyChainId(n Node) error {
+	ci, ok := n.(chainIdentified)
+	if !ok {
+		return nil
+	}
+
+	state := ci.chainState()
+	guard := state.guard.Load()
+	if guard == nil || state.chainId.Load() != 0 {
+		return nil
+	}
+
+	chainId, err := ci.fetchChainId()
+	if err != nil {
+		return errors.WithMessage(err, "failed to get chain ID")
+	}
+
+	if err := guard.verify(n.Name(), chainId); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"space": guard.space,
+			"node":  n.Name(),
+			"url":   n.Url(),
+		}).WithError(err).Error("Refused to route to full node of another network")
+		return err
+	}
+
+	state.chainId.Store(chainId)
+
+	return nil
+}
+
+// invalidateChainId invalidates the cached chain ID of full node to verify again.
+func invalidateChainId(n Node) {
+	if ci, ok := n.(chainIdentified); ok {
+		ci.chainState().chainId.Store(0)
+	}
+}
+
+// guardedNodeFactory wraps the node factory to verify chain ID of full nodes once created, and
+// rejects those of mismatched chain ID. Note, full nodes failed to fetch chain ID are accepted,
+// which will be verified during heartbeat later.
+func guardedNodeFactory(nf nodeFactory, guard *chainGuard) nodeFactory {
+	return func(group Group, name, url string) (Node, error) {
+		n, err := nf(group, name, url)
+		if err != nil {
+			return nil, err
+		}
+
+		ci, ok := n.(chainIdentified)
+		if !ok {
+			return n, nil
+		}
+
+		ci.chainState().guard.Store(guard)
+
+		if err := verifyChainId(n); errors.Is(err, errChainIdMismatch) {
+			n.Close()
+			return nil, err
+		}
+
+		return n, nil
+	}
+}
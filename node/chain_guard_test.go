@@ -0,0 +1,57 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type dummyChainNode struct {
+	*dummyNode
+	chainId uint64
+	err     error
+	fetched int
+}
+
+func newDummyChainNode(name string, chainId uint64) *dummyChainNode {
+	n, _ := newDummyNode(GroupEthHttp, name, "http://"+name)
+	return &dummyChainNode{dummyNode: n, chainId: chainId}
+}
+
+func (n *dummyChainNode) fetchChainId() (uint64, error) {
+	n.fetched++
+	return n.chainId, n.err
+}
+
+func TestChainGuardDeterminedByFirstNode(t *testing.T) {
+	guard := newChainGuard("eth", 0)
+
+	assert.NoError(t, guard.verify("node0", 71))
+	assert.NoError(t, guard.verify("node1", 71))
+	assert.ErrorIs(t, guard.verify("node2", 1030), errChainIdMismatch)
+}
+
+func TestVerifyChainId(t *testing.T) {
+	guard := newChainGuard("eth", 71)
+
+	// not guarded
+	n := newDummyChainNode("node0", 1030)
+	assert.NoError(t, verifyChainId(n))
+	assert.Equal(t, 0, n.fetched)
+
+	n.chainState().guard.Store(guard)
+	assert.ErrorIs(t, verifyChainId(n), errChainIdMismatch)
+
+	// chain ID fixed behind the same URL
+	n.chainId = 71
+	assert.NoError(t, verifyChainId(n))
+	assert.NoError(t, verifyChainId(n))
+	assert.Equal(t, 2, n.fetched) // cached once verified
+
+	// re-fetched once invalidated
+	invalidateChainId(n)
+	n.err = errors.New("connection refused")
+	assert.Error(t, verifyChainId(n))
+	assert.NotErrorIs(t, verifyChainId(n), errChainIdMismatch)
+}
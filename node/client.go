@@ -2,6 +2,8 @@ package node
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,6 +18,9 @@ import (
 const (
 	RouteKeyCacheSize       = 5000
 	RouteCacheExpirationTTL = 60 * time.Second
+
+	// grace period to close the evicted client, so that in-flight requests won't be dropped
+	evictedClientCloseDelay = time.Minute
 )
 
 var (
@@ -23,8 +28,8 @@ var (
 	ErrNotSupportedRouter = errors.New("not supported router")
 )
 
-// clientFactory factory method to create RPC client for fullnode proxy.
-type clientFactory func(url string) (interface{}, error)
+// clientFactory factory method to create RPC client of node group for fullnode proxy.
+type clientFactory func(url string, group Group) (interface{}, error)
 
 // clientProvider provides different RPC client based on request IP to achieve load balance
 // or with node group for resource isolation. Generally, it is used by RPC server to delegate
@@ -44,13 +49,50 @@ type clientProvider struct {
 }
 
 func newClientProvider(db *mysql.MysqlStore, router Router, factory clientFactory) *clientProvider {
-	return &clientProvider{
+	p := &clientProvider{
 		db:            db,
 		router:        router,
 		factory:       factory,
 		clients:       &util.ConcurrentMap{},
 		routeKeyCache: util.NewExpirableLruCache(RouteKeyCacheSize, RouteCacheExpirationTTL),
 	}
+
+	// credential of WebSocket full node is applied at dial time, so re-dial once rotated
+	rpc.OnWsCredentialRotated(p.evictClients)
+
+	return p
+}
+
+// evictClients evicts the clients of the full node host, so that new clients will be created
+// upon next request, and closes the evicted clients after a grace period.
+func (p *clientProvider) evictClients(host string) {
+	p.clients.Range(func(grp, clients interface{}) bool {
+		clients.(*util.ConcurrentMap).Range(func(nodeName, client interface{}) bool {
+			h, _, _ := strings.Cut(nodeName.(string), "/")
+			if idx := strings.LastIndex(h, "@"); idx >= 0 { // strip user info if any
+				h = h[idx+1:]
+			}
+
+			if h != host {
+				return true
+			}
+
+			clients.(*util.ConcurrentMap).Delete(nodeName)
+
+			logrus.WithFields(logrus.Fields{
+				"node":  nodeName,
+				"group": grp,
+			}).Info("Evicted full node client due to credential rotation")
+
+			if closer, ok := client.(interface{ Close() }); ok {
+				time.AfterFunc(evictedClientCloseDelay, closer.Close)
+			}
+
+			return true
+		})
+
+		return true
+	})
 }
 
 // getOrRegisterGroup gets or registers node group
@@ -145,6 +187,24 @@ func (p *clientProvider) getClient(key string, group Group) (interface{}, error)
 	return p.getOrRegisterClient(url, group)
 }
 
+// maxAlternativeRoutes is the max number of routing attempts to find an alternative full node.
+const maxAlternativeRoutes = 3
+
+// getAlternativeClient gets client of the specified group, which is routed to any full node
+// other than the excluded one.
+func (p *clientProvider) getAlternativeClient(key string, group Group, excludedUrl string) (interface{}, error) {
+	excluded := rpc.Url2NodeName(excludedUrl)
+
+	for i := 1; i <= maxAlternativeRoutes; i++ {
+		url := p.router.Route(group, []byte(fmt.Sprintf("%v#%v", key, i)))
+		if len(url) > 0 && rpc.Url2NodeName(url) != excluded {
+			return p.getOrRegisterClient(url, group)
+		}
+	}
+
+	return nil, ErrClientUnavailable
+}
+
 // getOrRegisterClient gets or registers RPC client for fullnode proxy.
 func (p *clientProvider) getOrRegisterClient(url string, group Group) (interface{}, error) {
 	clients := p.getOrRegisterGroup(group)
@@ -161,7 +221,7 @@ func (p *clientProvider) getOrRegisterClient(url string, group Group) (interface
 		// TODO improvements required
 		// 1. Necessary retry? (but longer timeout). Better to let user side to decide.
 		// 2. Different metrics for different full nodes.
-		return p.factory(url)
+		return p.factory(url, group)
 	})
 
 	if err != nil {
@@ -178,6 +238,19 @@ func (p *clientProvider) getOrRegisterClient(url string, group Group) (interface
 	return client, nil
 }
 
+// affinityKeyFromContext returns the route key to stick client to the same full node, which is
+// the access token if present, otherwise the remote IP address.
+//
+// Note, IP address may change frequently for mobile or NAT-rotating clients, so access token is
+// preferred to keep a consistent delegate full node.
+func affinityKeyFromContext(ctx context.Context) string {
+	if token, ok := handlers.GetAccessTokenFromContext(ctx); ok && len(token) > 0 {
+		return token
+	}
+
+	return remoteAddrFromContext(ctx)
+}
+
 func remoteAddrFromContext(ctx context.Context) string {
 	if ip, ok := handlers.GetIPAddressFromContext(ctx); ok {
 		return ip
@@ -0,0 +1,28 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAffinityKeyFromContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "unknown_ip", affinityKeyFromContext(ctx))
+
+	// fallback to remote IP address if no access token
+	ctx = context.WithValue(ctx, handlers.CtxKeyRealIP, "10.0.0.1")
+	assert.Equal(t, "10.0.0.1", affinityKeyFromContext(ctx))
+
+	ctx = context.WithValue(ctx, handlers.CtxKeyAccessToken, "")
+	assert.Equal(t, "10.0.0.1", affinityKeyFromContext(ctx))
+
+	// access token takes precedence, so that route sticks even though IP address changed
+	ctx = context.WithValue(ctx, handlers.CtxKeyAccessToken, "token1")
+	assert.Equal(t, "token1", affinityKeyFromContext(ctx))
+
+	ctx = context.WithValue(ctx, handlers.CtxKeyRealIP, "10.0.0.2")
+	assert.Equal(t, "token1", affinityKeyFromContext(ctx))
+}
@@ -1,65 +1,123 @@
 package node
 
 import (
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/Conflux-Chain/confura/util/reload"
+	"github.com/Conflux-Chain/confura/util/rpc"
 	"github.com/Conflux-Chain/go-conflux-util/viper"
 	"github.com/buraksezer/consistent"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
 // Node manager component always uses configuration from viper.
 
 var cfg config
+var profilesMu sync.RWMutex // guards the hot-reloadable node profiles
 var urlCfg map[Group]UrlConfig
 var ethUrlCfg map[Group]UrlConfig
 
+// RPC method (lower case) => node group
+var methodGroups, ethMethodGroups map[string]Group
+
 func MustInit() {
 	viper.MustUnmarshalKey("node", &cfg)
 	logrus.WithField("config", cfg).Debug("Node manager configurations loaded.")
 
-	urlCfg = map[Group]UrlConfig{
+	urlCfg, ethUrlCfg = newUrlConfigs(&cfg)
+
+	var err error
+	if methodGroups, err = newMethodGroups(cfg.Router.MethodGroups, "cfx"); err != nil {
+		logrus.WithError(err).Fatal("Invalid core space method groups config")
+	}
+
+	if ethMethodGroups, err = newMethodGroups(cfg.Router.EthMethodGroups, "eth"); err != nil {
+		logrus.WithError(err).Fatal("Invalid evm space method groups config")
+	}
+
+	reload.Register("node", nodeProfilesConfig{cfg.NodeProfiles}, nodeProfilesConfig.validate, nodeProfilesConfig.apply)
+}
+
+// newUrlConfigs returns the full node URLs of core space and evm space node groups.
+func newUrlConfigs(c *config) (cfxUrlCfg, ethUrlCfg map[Group]UrlConfig) {
+	cfxUrlCfg = map[Group]UrlConfig{
 		GroupCfxHttp: {
-			Nodes:    cfg.URLs,
-			Failover: cfg.Router.ChainedFailover.URL,
+			Nodes:    c.URLs,
+			Failover: c.Router.ChainedFailover.URL,
 		},
 		GroupCfxFullState: {
-			Nodes: cfg.FullStateURLs,
+			Nodes: c.FullStateURLs,
 		},
 		GroupCfxWs: {
-			Nodes:    cfg.WSURLs,
-			Failover: cfg.Router.ChainedFailover.WSURL,
+			Nodes:    c.WSURLs,
+			Failover: c.Router.ChainedFailover.WSURL,
 		},
 		GroupCfxArchives: {
-			Nodes: cfg.ArchiveNodes,
+			Nodes: c.ArchiveNodes,
 		},
 		GroupCfxLogs: {
-			Nodes: cfg.LogNodes,
+			Nodes: c.LogNodes,
 		},
 		GroupCfxFilter: {
-			Nodes: cfg.FilterNodes,
+			Nodes: c.FilterNodes,
 		},
 	}
 
 	ethUrlCfg = map[Group]UrlConfig{
 		GroupEthHttp: {
-			Nodes:    cfg.EthURLs,
-			Failover: cfg.Router.ChainedFailover.EthURL,
+			Nodes:    c.EthURLs,
+			Failover: c.Router.ChainedFailover.EthURL,
 		},
 		GroupEthFullState: {
-			Nodes: cfg.EthFullStateURLs,
+			Nodes: c.EthFullStateURLs,
 		},
 		GroupEthWs: {
-			Nodes:    cfg.EthWSURLs,
-			Failover: cfg.Router.ChainedFailover.EthWSURL,
+			Nodes:    c.EthWSURLs,
+			Failover: c.Router.ChainedFailover.EthWSURL,
 		},
 		GroupEthLogs: {
-			Nodes: cfg.EthLogNodes,
+			Nodes: c.EthLogNodes,
 		},
 		GroupEthFilter: {
-			Nodes: cfg.EthFilterNodes,
+			Nodes: c.EthFilterNodes,
 		},
+		GroupEthArchives: {
+			Nodes: c.EthArchiveNodes,
+		},
+	}
+
+	return cfxUrlCfg, ethUrlCfg
+}
+
+// nodeProfilesConfig is the hot-reloadable node routing profiles.
+type nodeProfilesConfig struct {
+	NodeProfiles []NodeProfile
+}
+
+func (c nodeProfilesConfig) validate() error {
+	for _, np := range c.NodeProfiles {
+		if len(np.URL) == 0 {
+			return errors.New("node url required in profile")
+		}
+
+		if np.Weight < 0 {
+			return errors.Errorf("negative weight for node %v", np.URL)
+		}
 	}
+
+	return nil
+}
+
+// apply updates the node profiles, and rebalances hash rings of all node managers.
+func (c nodeProfilesConfig) apply() {
+	profilesMu.Lock()
+	cfg.NodeProfiles = c.NodeProfiles
+	profilesMu.Unlock()
+
+	reweightPools()
 }
 
 type config struct {
@@ -76,10 +134,22 @@ type config struct {
 	FilterNodes      []string
 	EthFilterNodes   []string
 	ArchiveNodes     []string
-	HashRing         struct {
+	EthArchiveNodes  []string
+	Region           string // region (or zone) of the current instance to prefer local full nodes
+	// bearer token to authenticate clients of node manager RPC (e.g., `NodeRpcRouter`), which is
+	// required to serve or dial node manager RPC
+	AuthToken string
+	// expected chain ID of core space (network ID) and evm space full nodes, which is determined
+	// by the first verified full node if zero
+	ChainId      uint64
+	EthChainId   uint64
+	NodeProfiles []NodeProfile
+	HashRing     struct {
 		PartitionCount    int     `default:"15739"`
 		ReplicationFactor int     `default:"51"`
 		Load              float64 `default:"1.25"`
+		// window to drain old assignments gradually when hash ring rebalanced, disabled if zero
+		DampeningWindow time.Duration
 	}
 	Monitor struct {
 		Interval time.Duration `default:"1s"`
@@ -93,11 +163,36 @@ type config struct {
 			RemindInterval time.Duration `default:"5m"`
 			SuccessCounter uint64        `default:"60"`
 		}
+		Score struct {
+			Enabled           bool          `default:"true"`
+			SmoothingFactor   float64       `default:"0.2"`
+			LatencyBaseline   time.Duration `default:"300ms"`
+			DegradedThreshold float64       `default:"0.5"`
+		}
+	}
+	Discovery struct {
+		URL      string
+		EthURL   string
+		Interval time.Duration `default:"1m"`
+		Persist  bool
+		// bearer token to authenticate with config service, which is required
+		AuthToken string
+		// max ratio of discovered full nodes allowed to deregister in a single round
+		MaxShrinkRatio float64 `default:"0.5"`
+		// consecutive rounds a group missing from config service before deregistered
+		GroupRemovalRounds int `default:"3"`
+	}
+	Admin struct {
+		Endpoint    string // gRPC admin endpoint for core space, disabled if empty
+		EthEndpoint string // gRPC admin endpoint for evm space, disabled if empty
+		AuthToken   string
 	}
 	Router struct {
 		RedisURL        string
 		NodeRPCURL      string
 		EthNodeRPCURL   string
+		MethodGroups    map[string]string // RPC method (or prefix with `*` suffix) => node group
+		EthMethodGroups map[string]string
 		ChainedFailover struct {
 			URL      string
 			WSURL    string
@@ -116,6 +211,36 @@ func (c *config) HashRingRaw() consistent.Config {
 	}
 }
 
+// NodeProfile is the routing profile of a full node.
+type NodeProfile struct {
+	URL    string
+	Weight int    // routing weight, which is 1 by default
+	Region string // region (or zone) where the full node is deployed
+}
+
+// profileOf returns the configured routing profile of the specified node.
+func (c *config) profileOf(nodeName string) NodeProfile {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+
+	for _, np := range c.NodeProfiles {
+		if rpc.Url2NodeName(np.URL) == nodeName {
+			if np.Weight <= 0 {
+				np.Weight = 1
+			}
+
+			return np
+		}
+	}
+
+	return NodeProfile{Weight: 1}
+}
+
+// isLocal checks if the full node is deployed in the same region as the current instance.
+func (c *config) isLocal(nodeName string) bool {
+	return len(c.Region) > 0 && c.profileOf(nodeName).Region == c.Region
+}
+
 func Config() *config {
 	return &cfg
 }
@@ -125,6 +250,76 @@ type UrlConfig struct {
 	Failover string
 }
 
+// newMethodGroups validates and creates the RPC method to node group mapping of the specified
+// space. Note, method must be either the full method name or prefix with `_*` suffix, and group
+// name must begin with the space name.
+func newMethodGroups(conf map[string]string, space string) (map[string]Group, error) {
+	res := make(map[string]Group)
+	for method, grp := range conf {
+		if idx := strings.Index(method, "_"); idx <= 0 || idx == len(method)-1 {
+			return nil, errors.Errorf("invalid method %v", method)
+		}
+
+		if strings.Contains(strings.TrimSuffix(method, "_*"), "*") {
+			return nil, errors.Errorf("invalid method prefix %v, `_*` suffix expected", method)
+		}
+
+		group := strings.ToLower(grp)
+		if len(group) <= len(space) || !strings.HasPrefix(group, space) {
+			return nil, errors.Errorf("invalid node group %v of method %v, %v space group expected", grp, method, space)
+		}
+
+		res[strings.ToLower(method)] = Group(group)
+	}
+
+	return res, nil
+}
+
+// CfxMethodGroup returns the core space node group configured to route the specified RPC method.
+func CfxMethodGroup(method string) (Group, bool) {
+	return lookupMethodGroup(methodGroups, method)
+}
+
+// EthMethodGroup returns the evm space node group configured to route the specified RPC method.
+func EthMethodGroup(method string) (Group, bool) {
+	return lookupMethodGroup(ethMethodGroups, method)
+}
+
+// CfxMethodGroupOrDefault returns the core space node group configured to route the specified RPC
+// method, or the default group if not configured.
+func CfxMethodGroupOrDefault(method string, defaultGroup Group) Group {
+	if grp, ok := CfxMethodGroup(method); ok {
+		return grp
+	}
+
+	return defaultGroup
+}
+
+// EthMethodGroupOrDefault returns the evm space node group configured to route the specified RPC
+// method, or the default group if not configured.
+func EthMethodGroupOrDefault(method string, defaultGroup Group) Group {
+	if grp, ok := EthMethodGroup(method); ok {
+		return grp
+	}
+
+	return defaultGroup
+}
+
+// lookupMethodGroup looks up node group either by the full method name or by prefix, eg., `trace_*`.
+func lookupMethodGroup(method2Groups map[string]Group, method string) (Group, bool) {
+	method = strings.ToLower(method)
+	if grp, ok := method2Groups[method]; ok {
+		return grp, true
+	}
+
+	if idx := strings.Index(method, "_"); idx > 0 {
+		grp, ok := method2Groups[method[:idx+1]+"*"]
+		return grp, ok
+	}
+
+	return "", false
+}
+
 func CfxUrlConfig() map[Group]UrlConfig {
 	return urlCfg
 }
@@ -0,0 +1,59 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMethodGroups(t *testing.T) {
+	method2Groups, err := newMethodGroups(map[string]string{
+		"trace_*":  "cfxarchives",
+		"CFX_CALL": "CfxFullState",
+	}, "cfx")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Group{
+		"trace_*":  GroupCfxArchives,
+		"cfx_call": GroupCfxFullState,
+	}, method2Groups)
+
+	invalidConfs := []map[string]string{
+		{"trace": "cfxarchives"},        // neither full method name nor prefix
+		{"trace_": "cfxarchives"},       // empty method name
+		{"tr*_*": "cfxarchives"},        // wildcard within prefix
+		{"trace_*": "etharchives"},      // group of another space
+		{"trace_*": "cfx"},              // space name only
+		{"trace_*": ""},                 // empty group
+		{"debug_trace*": "cfxarchives"}, // wildcard not as `_*` suffix
+	}
+
+	for _, conf := range invalidConfs {
+		_, err := newMethodGroups(conf, "cfx")
+		assert.Error(t, err, conf)
+	}
+}
+
+func TestLookupMethodGroup(t *testing.T) {
+	method2Groups, err := newMethodGroups(map[string]string{
+		"debug_*":  "ethfullstate",
+		"eth_call": "ethfullstate",
+		"eth_*":    "ethhttp",
+	}, "eth")
+	assert.NoError(t, err)
+
+	grp, ok := lookupMethodGroup(method2Groups, "debug_traceTransaction")
+	assert.True(t, ok)
+	assert.Equal(t, GroupEthFullState, grp)
+
+	// full method name takes precedence over prefix
+	grp, ok = lookupMethodGroup(method2Groups, "eth_Call")
+	assert.True(t, ok)
+	assert.Equal(t, GroupEthFullState, grp)
+
+	grp, ok = lookupMethodGroup(method2Groups, "eth_getLogs")
+	assert.True(t, ok)
+	assert.Equal(t, GroupEthHttp, grp)
+
+	_, ok = lookupMethodGroup(method2Groups, "net_version")
+	assert.False(t, ok)
+}
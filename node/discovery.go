@@ -0,0 +1,280 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const discoveryRequestTimeout = 10 * time.Second
+
+// discoverer periodically discovers upstream full nodes from some config service, and
+// registers or deregisters the full nodes at runtime.
+//
+// The config service is expected to respond with JSON of full node URLs by group, eg.,
+// {"cfxhttp": ["http://node1:12537", "http://node2:12537"], "cfxfilter": ["http://node3:12537"]}.
+//
+// Note, only the full nodes discovered before will be deregistered, and the configured or
+// manually registered full nodes are never touched. If persisted, the discovered full nodes are
+// persisted into db as well, so that they could be reconciled after restart.
+//
+// Besides, config service is authenticated by bearer token, and changes of a group that empties or
+// shrinks the discovered full nodes too much (e.g., misconfigured or compromised config service)
+// are refused. A group disappeared from the response is deregistered only if missing for several
+// consecutive rounds.
+type discoverer struct {
+	url                string
+	authToken          string
+	interval           time.Duration
+	persist            bool    // whether to persist the route group changes into db
+	maxShrinkRatio     float64 // max ratio of discovered full nodes allowed to deregister in a round
+	groupRemovalRounds int     // consecutive rounds a group missing before deregistered
+	client             *http.Client
+	h                  *apiHandler
+
+	// discovered full nodes: group => node name => url
+	discovered map[Group]map[string]string
+	// number of consecutive rounds that group of discovered full nodes missing
+	missed map[Group]int
+}
+
+func newDiscoverer(url string, h *apiHandler) *discoverer {
+	return &discoverer{
+		url:                url,
+		authToken:          cfg.Discovery.AuthToken,
+		interval:           cfg.Discovery.Interval,
+		persist:            cfg.Discovery.Persist,
+		maxShrinkRatio:     cfg.Discovery.MaxShrinkRatio,
+		groupRemovalRounds: cfg.Discovery.GroupRemovalRounds,
+		client:             &http.Client{Timeout: discoveryRequestTimeout},
+		h:                  h,
+		discovered:         make(map[Group]map[string]string),
+		missed:             make(map[Group]int),
+	}
+}
+
+// load loads the persisted discovered full nodes, which are registered from db at startup.
+func (d *discoverer) load() error {
+	if !d.persist || d.h.dbs == nil {
+		return nil
+	}
+
+	groups, err := d.h.dbs.LoadDiscoveredNodeGroups()
+	if err != nil {
+		return err
+	}
+
+	for name, grp := range groups {
+		discovered := make(map[string]string)
+		for _, url := range grp.Nodes {
+			discovered[rpc.Url2NodeName(url)] = url
+		}
+
+		d.discovered[Group(name)] = discovered
+	}
+
+	return nil
+}
+
+// run discovers full nodes periodically until context done.
+func (d *discoverer) run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	if err := d.load(); err != nil {
+		logrus.WithError(err).Fatal("Failed to load discovered full nodes from db")
+	}
+
+	logrus.WithField("url", d.url).Info("Node discovery started")
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.discover(ctx); err != nil {
+			logrus.WithField("url", d.url).WithError(err).Warn("Failed to discover full nodes")
+		}
+
+		select {
+		case <-ctx.Done():
+			logrus.Info("Node discovery stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// discover fetches the latest full nodes from config service, and reconciles with node pool.
+func (d *discoverer) discover(ctx context.Context) error {
+	group2Urls, err := d.fetch(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "failed to fetch full nodes")
+	}
+
+	for grp, urls := range group2Urls {
+		latest := make(map[string]string)
+		for _, url := range urls {
+			latest[rpc.Url2NodeName(url)] = url
+		}
+
+		delete(d.missed, grp)
+
+		if err := d.validate(grp, latest); err != nil {
+			logrus.WithField("group", grp).WithError(err).Warn("Discovered full nodes refused")
+			continue
+		}
+
+		d.reconcile(grp, latest)
+	}
+
+	// groups disappeared from config service
+	for grp := range d.discovered {
+		if _, ok := group2Urls[grp]; ok {
+			continue
+		}
+
+		d.missed[grp]++
+
+		// never deregister all groups at once, which is most likely misconfigured
+		if len(group2Urls) == 0 || d.missed[grp] < d.groupRemovalRounds {
+			logrus.WithFields(logrus.Fields{
+				"group":  grp,
+				"missed": d.missed[grp],
+			}).Warn("Group of discovered full nodes disappeared")
+			continue
+		}
+
+		d.reconcile(grp, nil)
+		delete(d.missed, grp)
+	}
+
+	return nil
+}
+
+// validate refuses the latest full nodes of group if the discovered full nodes would be emptied,
+// or shrunk beyond the max ratio.
+func (d *discoverer) validate(grp Group, latest map[string]string) error {
+	discovered := d.discovered[grp]
+	if len(discovered) == 0 {
+		return nil
+	}
+
+	if len(latest) == 0 {
+		return errors.New("all discovered full nodes disappeared")
+	}
+
+	var numRemoved int
+	for nn := range discovered {
+		if _, ok := latest[nn]; !ok {
+			numRemoved++
+		}
+	}
+
+	if ratio := float64(numRemoved) / float64(len(discovered)); ratio > d.maxShrinkRatio {
+		return errors.Errorf("%v of %v discovered full nodes disappeared", numRemoved, len(discovered))
+	}
+
+	return nil
+}
+
+// reconcile registers newly discovered full nodes, and deregisters the disappeared ones.
+func (d *discoverer) reconcile(grp Group, latest map[string]string) {
+	discovered := d.discovered[grp]
+	if discovered == nil {
+		discovered = make(map[string]string)
+		d.discovered[grp] = discovered
+	}
+
+	logger := logrus.WithField("group", grp)
+
+	for nn, url := range latest {
+		if _, ok := discovered[nn]; ok {
+			continue
+		}
+
+		if err := d.h.addGroupNode(grp, url, d.persist); err != nil {
+			logger.WithField("url", url).WithError(err).Warn("Failed to register discovered full node")
+			continue
+		}
+
+		discovered[nn] = url
+		logger.WithField("url", url).Info("Discovered full node registered")
+	}
+
+	for nn, url := range discovered {
+		if _, ok := latest[nn]; ok {
+			continue
+		}
+
+		if err := d.h.delGroupNode(grp, url, d.persist); err != nil {
+			logger.WithField("url", url).WithError(err).Warn("Failed to deregister disappeared full node")
+			continue
+		}
+
+		delete(discovered, nn)
+		logger.WithField("url", url).Info("Disappeared full node deregistered")
+	}
+
+	if len(discovered) == 0 {
+		delete(d.discovered, grp)
+	}
+
+	d.save(grp, discovered)
+}
+
+// save persists the discovered full nodes of group if necessary.
+func (d *discoverer) save(grp Group, discovered map[string]string) {
+	if !d.persist || d.h.dbs == nil {
+		return
+	}
+
+	var err error
+	if len(discovered) == 0 {
+		err = d.h.dbs.DelDiscoveredNodeGroup(string(grp))
+	} else {
+		nodes := make([]string, 0, len(discovered))
+		for _, url := range discovered {
+			nodes = append(nodes, url)
+		}
+
+		sort.Strings(nodes)
+		err = d.h.dbs.StoreDiscoveredNodeGroup(&mysql.NodeRouteGroup{Name: string(grp), Nodes: nodes})
+	}
+
+	if err != nil {
+		logrus.WithField("group", grp).WithError(err).Warn("Failed to persist discovered full nodes")
+	}
+}
+
+func (d *discoverer) fetch(ctx context.Context) (map[Group][]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+d.authToken)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+
+	var group2Urls map[Group][]string
+	if err := json.NewDecoder(resp.Body).Decode(&group2Urls); err != nil {
+		return nil, errors.WithMessage(err, "failed to decode response")
+	}
+
+	return group2Urls, nil
+}
@@ -0,0 +1,98 @@
+package node
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscovererFetchAuth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(`{"cfxhttp": ["http://node1:12537"]}`))
+	}))
+	defer srv.Close()
+
+	d := &discoverer{url: srv.URL, authToken: "token1", client: srv.Client()}
+	group2Urls, err := d.fetch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[Group][]string{"cfxhttp": {"http://node1:12537"}}, group2Urls)
+
+	d.authToken = "token2"
+	_, err = d.fetch(context.Background())
+	assert.Error(t, err)
+}
+
+func TestDiscovererValidate(t *testing.T) {
+	d := &discoverer{
+		maxShrinkRatio: 0.5,
+		discovered: map[Group]map[string]string{
+			"cfxhttp": {"n1": "url1", "n2": "url2", "n3": "url3", "n4": "url4"},
+		},
+	}
+
+	// newly discovered group and nodes
+	assert.NoError(t, d.validate("cfxhttp", map[string]string{
+		"n1": "url1", "n2": "url2", "n3": "url3", "n4": "url4", "n5": "url5",
+	}))
+	assert.NoError(t, d.validate("cfxfilter", map[string]string{"n6": "url6"}))
+
+	// shrunk within ratio
+	assert.NoError(t, d.validate("cfxhttp", map[string]string{"n1": "url1", "n2": "url2"}))
+
+	// shrunk beyond ratio
+	assert.Error(t, d.validate("cfxhttp", map[string]string{"n1": "url1"}))
+
+	// replaced beyond ratio
+	assert.Error(t, d.validate("cfxhttp", map[string]string{
+		"n1": "url1", "n5": "url5", "n6": "url6", "n7": "url7",
+	}))
+
+	// group emptied
+	assert.Error(t, d.validate("cfxhttp", map[string]string{}))
+}
+
+func TestDiscovererGroupRemoval(t *testing.T) {
+	var resp atomic.Value
+	resp.Store(`{}`)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(resp.Load().(string)))
+	}))
+	defer srv.Close()
+
+	d := &discoverer{
+		url:                srv.URL,
+		groupRemovalRounds: 2,
+		client:             srv.Client(),
+		h:                  &apiHandler{pool: newNodePool(nil)},
+		discovered: map[Group]map[string]string{
+			"cfxws": {"n1": "ws://n1"},
+		},
+		missed: make(map[Group]int),
+	}
+
+	// never deregistered if all groups disappeared
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, d.discover(context.Background()))
+		assert.Contains(t, d.discovered, Group("cfxws"))
+	}
+
+	// deregistered after missing for consecutive rounds
+	resp.Store(`{"cfxhttp": []}`)
+	d.missed = make(map[Group]int)
+
+	assert.NoError(t, d.discover(context.Background()))
+	assert.Contains(t, d.discovered, Group("cfxws"))
+
+	assert.NoError(t, d.discover(context.Background()))
+	assert.NotContains(t, d.discovered, Group("cfxws"))
+}
@@ -25,8 +25,10 @@ type EthClientProvider struct {
 	*clientProvider
 }
 
-func newEthClient(url string) (interface{}, error) {
-	client, err := rpcutil.NewEthClient(url, rpcutil.WithClientHookMetrics(true), rpcutil.WithClientHookCache(true))
+func newEthClient(url string, group Group) (interface{}, error) {
+	client, err := rpcutil.NewEthClient(
+		url, rpcutil.WithClientHookMetrics(true), rpcutil.WithClientHookCache(true), rpcutil.WithClientNodeGroup(string(group)),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -52,10 +54,23 @@ func (p *EthClientProvider) GetClient(key string, groups ...Group) (*Web3goClien
 	return client.(*Web3goClient), nil
 }
 
-// GetClientByIP gets client of specific group (or use normal HTTP group as default) by remote IP address.
-func (p *EthClientProvider) GetClientByIP(ctx context.Context, groups ...Group) (*Web3goClient, error) {
-	remoteAddr := remoteAddrFromContext(ctx)
-	client, err := p.getClient(remoteAddr, ethNodeGroup(groups...))
+// GetClientByAffinity gets client of specific group (or use normal HTTP group as default) by
+// access token if present, otherwise by remote IP address.
+func (p *EthClientProvider) GetClientByAffinity(ctx context.Context, groups ...Group) (*Web3goClient, error) {
+	client, err := p.getClient(affinityKeyFromContext(ctx), ethNodeGroup(groups...))
+	if err != nil {
+		return nil, err
+	}
+
+	return client.(*Web3goClient), nil
+}
+
+// GetAlternativeClientByAffinity gets client of specific group (or use normal HTTP group as default),
+// which is routed to any full node other than the excluded one.
+func (p *EthClientProvider) GetAlternativeClientByAffinity(
+	ctx context.Context, excludedUrl string, groups ...Group,
+) (*Web3goClient, error) {
+	client, err := p.getAlternativeClient(affinityKeyFromContext(ctx), ethNodeGroup(groups...), excludedUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -1,10 +1,12 @@
 package node
 
 import (
+	"context"
 	"sync"
 
 	"github.com/Conflux-Chain/confura/store/mysql"
 	"github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
 )
 
 var (
@@ -22,7 +24,8 @@ func Factory() *factory {
 			func(group Group, name, url string) (Node, error) {
 				return NewCfxNode(group, name, url)
 			},
-			cfg.Endpoint, urlCfg, cfg.Router.NodeRPCURL,
+			newChainGuard("cfx", cfg.ChainId),
+			cfg.Endpoint, urlCfg, cfg.Router.RedisURL, cfg.Router.NodeRPCURL, cfg.AuthToken, cfg.Discovery.URL, cfg.Admin.Endpoint,
 		)
 	})
 
@@ -36,36 +39,80 @@ func EthFactory() *factory {
 			func(group Group, name, url string) (Node, error) {
 				return NewEthNode(group, name, url)
 			},
-			cfg.EthEndpoint, ethUrlCfg, cfg.Router.EthNodeRPCURL,
+			newChainGuard("eth", cfg.EthChainId),
+			cfg.EthEndpoint, ethUrlCfg, cfg.Router.RedisURL, cfg.Router.EthNodeRPCURL, cfg.AuthToken, cfg.Discovery.EthURL, cfg.Admin.EthEndpoint,
 		)
 	})
 
 	return ethFactory
 }
 
+// MustNewFactoriesFromViper creates core space and evm space instance factories from the
+// `node` settings of viper to route requests to a dedicated upstream pool, e.g. for another network
+// served by the same instance. Note, node monitor and hash ring settings are shared among factories.
+func MustNewFactoriesFromViper() (cfxf, ethf *factory) {
+	var c config
+	viper.MustUnmarshalKey("node", &c)
+
+	cfxUrlCfg, ethUrlCfg := newUrlConfigs(&c)
+
+	cfxf = newFactory(
+		func(group Group, name, url string) (Node, error) {
+			return NewCfxNode(group, name, url)
+		},
+		newChainGuard("cfx", c.ChainId),
+		c.Endpoint, cfxUrlCfg, c.Router.RedisURL, c.Router.NodeRPCURL, c.AuthToken, c.Discovery.URL, c.Admin.Endpoint,
+	)
+
+	ethf = newFactory(
+		func(group Group, name, url string) (Node, error) {
+			return NewEthNode(group, name, url)
+		},
+		newChainGuard("eth", c.EthChainId),
+		c.EthEndpoint, ethUrlCfg, c.Router.RedisURL, c.Router.EthNodeRPCURL, c.AuthToken, c.Discovery.EthURL, c.Admin.EthEndpoint,
+	)
+
+	return cfxf, ethf
+}
+
 // factory creates router and RPC server.
 type factory struct {
+	redisUrl       string
 	nodeRpcUrl     string
+	authToken      string // bearer token of node manager RPC
+	discoveryUrl   string
+	adminEndpoint  string
 	rpcSrvEndpoint string
 	groupConf      map[Group]UrlConfig
 	nodeFactory    nodeFactory
 }
 
-func newFactory(nf nodeFactory, rpcSrvEndpoint string, groupConf map[Group]UrlConfig, nodeRpcUrl string) *factory {
+func newFactory(
+	nf nodeFactory, guard *chainGuard, rpcSrvEndpoint string, groupConf map[Group]UrlConfig,
+	redisUrl, nodeRpcUrl, authToken, discoveryUrl, adminEndpoint string,
+) *factory {
 	return &factory{
+		redisUrl:       redisUrl,
 		nodeRpcUrl:     nodeRpcUrl,
-		nodeFactory:    nf,
+		authToken:      authToken,
+		discoveryUrl:   discoveryUrl,
+		adminEndpoint:  adminEndpoint,
+		nodeFactory:    guardedNodeFactory(nf, guard),
 		rpcSrvEndpoint: rpcSrvEndpoint,
 		groupConf:      groupConf,
 	}
 }
 
-// CreatRpcServer creates node manager RPC server
-func (f *factory) CreatRpcServer(db *mysql.MysqlStore) (*rpc.Server, string) {
-	return MustNewServer(db, f.nodeFactory, f.groupConf), f.rpcSrvEndpoint
+// CreatRpcServer creates node manager RPC server, and the full node discovery and admin service
+// (if configured) will be terminated once context done.
+func (f *factory) CreatRpcServer(
+	ctx context.Context, wg *sync.WaitGroup, db *mysql.MysqlStore,
+) (*rpc.Server, string) {
+	server := MustNewServer(ctx, wg, db, f.nodeFactory, f.groupConf, f.authToken, f.discoveryUrl, f.adminEndpoint)
+	return server, f.rpcSrvEndpoint
 }
 
 // CreateRouter creates node router
 func (f *factory) CreateRouter() Router {
-	return MustNewRouter(cfg.Router.RedisURL, f.nodeRpcUrl, f.groupConf)
+	return MustNewRouter(f.redisUrl, f.nodeRpcUrl, f.authToken, f.groupConf)
 }
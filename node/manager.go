@@ -1,6 +1,7 @@
 package node
 
 import (
+	"fmt"
 	"strings"
 	"sync"
 
@@ -15,13 +16,20 @@ type nodeFactory func(group Group, name, url string) (Node, error)
 // Manager manages full node cluster, including:
 // 1. Monitor node health and disable/enable full node automatically.
 // 2. Implements Router interface to route RPC requests to different full nodes
-// in manner of consistent hashing.
+// in manner of weighted consistent hashing, and away from degraded full nodes.
+// 3. Prefers full nodes in the same region, and falls back to other regions if
+// no local full node available.
 type Manager struct {
-	group    Group
-	nodes    map[string]Node        // node name => Node
-	hashRing *consistent.Consistent // consistent hashing algorithm
-	resolver RepartitionResolver    // support repartition for hash ring
-	mu       sync.RWMutex
+	group     Group
+	nodes     map[string]Node        // node name => Node
+	weights   map[string]int         // node name => routing weight
+	hashRing  *consistent.Consistent // consistent hashing algorithm
+	localRing *consistent.Consistent // consistent hashing for full nodes in the same region
+	resolver  RepartitionResolver    // support repartition for hash ring
+	dampener  *rebalanceDampener     // dampens hash ring rebalance
+	ringed    map[string]bool        // node name => whether added into hash ring
+	drained   map[string]bool        // node name => whether drained for maintenance
+	mu        sync.RWMutex
 
 	// health monitor
 	monitorStatuses map[string]monitorStatus // node name => monitor status
@@ -36,9 +44,14 @@ func NewManagerWithRepartition(group Group, resolver RepartitionResolver) *Manag
 	return &Manager{
 		group:           group,
 		nodes:           make(map[string]Node),
+		weights:         make(map[string]int),
 		resolver:        resolver,
+		dampener:        newRebalanceDampener(cfg.HashRing.DampeningWindow),
+		ringed:          make(map[string]bool),
+		drained:         make(map[string]bool),
 		monitorStatuses: make(map[string]monitorStatus),
 		hashRing:        consistent.New(nil, cfg.HashRingRaw()),
+		localRing:       consistent.New(nil, cfg.HashRingRaw()),
 	}
 }
 
@@ -50,7 +63,8 @@ func (m *Manager) Add(nodes ...Node) {
 	for _, n := range nodes {
 		if _, ok := m.nodes[n.Name()]; !ok {
 			m.nodes[n.Name()] = n
-			m.hashRing.Add(n)
+			m.weights[n.Name()] = cfg.profileOf(n.Name()).Weight
+			m.addToRing(n, m.weights[n.Name()])
 		}
 	}
 }
@@ -65,7 +79,9 @@ func (m *Manager) Remove(nodeNames ...string) {
 			node.Close()
 			delete(m.nodes, nn)
 			delete(m.monitorStatuses, nn)
-			m.hashRing.Remove(nn)
+			delete(m.drained, nn)
+			m.removeFromRing(nn, m.weights[nn])
+			delete(m.weights, nn)
 		}
 	}
 }
@@ -107,6 +123,64 @@ func (m *Manager) String() string {
 	return strings.Join(nodes, ", ")
 }
 
+// reweight applies the latest configured weights of all full nodes, and re-adds full nodes
+// with weight changed into hash ring(s).
+func (m *Manager) reweight() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, n := range m.nodes {
+		weight := cfg.profileOf(name).Weight
+		if weight == m.weights[name] {
+			continue
+		}
+
+		if m.ringed[name] {
+			m.removeFromRing(name, m.weights[name])
+			m.weights[name] = weight
+			m.addToRing(n, weight)
+		} else {
+			m.weights[name] = weight
+		}
+	}
+}
+
+// Drain drains the specified full node for maintenance, so that no more requests will be
+// routed to it until undrained. Returns false if node not found.
+func (m *Manager) Drain(nodeName string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.nodes[nodeName]; !ok {
+		return false
+	}
+
+	m.drained[nodeName] = true
+	m.removeFromRing(nodeName, m.weights[nodeName])
+
+	return true
+}
+
+// Undrain undrains the specified full node, which will be added back into hash ring unless
+// unhealthy. Returns false if node not found.
+func (m *Manager) Undrain(nodeName string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, ok := m.nodes[nodeName]
+	if !ok {
+		return false
+	}
+
+	delete(m.drained, nodeName)
+
+	if !m.monitorStatuses[nodeName].unhealthy {
+		m.addToRing(n, m.weights[nodeName])
+	}
+
+	return true
+}
+
 // Distribute distributes a full node by specified key.
 func (m *Manager) Distribute(key []byte) Node {
 	k := xxhash.Sum64(key)
@@ -115,21 +189,165 @@ func (m *Manager) Distribute(key []byte) Node {
 	defer m.mu.RUnlock()
 
 	// Use repartition resolver to distribute if configured.
-	if name, ok := m.resolver.Get(k); ok {
+	if name, ok := m.resolver.Get(k); ok && m.ringed[name] && !m.isDegraded(name) {
 		return m.nodes[name]
 	}
 
-	member := m.hashRing.LocateKey(key)
+	// stick to the previous assignment until drained to the rebalanced hash ring
+	if member, ok := m.dampener.locate(key, k); ok {
+		if name := member.(Node).Name(); m.ringed[name] && !m.isDegraded(name) {
+			return m.nodes[name]
+		}
+	}
+
+	// prefer full nodes in the same region if available
+	ring := m.hashRing
+	if len(m.localRing.GetMembers()) > 0 {
+		ring = m.localRing
+	}
+
+	member := ring.LocateKey(key)
 	if member == nil { // in case of empty consistent member
 		return nil
 	}
 
-	node := member.(Node)
+	node := m.nodes[member.(Node).Name()]
+	if m.isDegraded(node.Name()) {
+		node = m.redistribute(key, k, node, ring, m.hashRing)
+	}
+
 	m.resolver.Put(k, node.Name())
 
 	return node
 }
 
+// redistribute selects an alternative full node among the closest members on the hash rings
+// in order for the specified key, with probability in proportion to weight * health score.
+func (m *Manager) redistribute(key []byte, k uint64, degraded Node, rings ...*consistent.Consistent) Node {
+	for _, ring := range rings {
+		if node := m.redistributeOnRing(key, k, ring); node != nil {
+			return node
+		}
+	}
+
+	return degraded
+}
+
+func (m *Manager) redistributeOnRing(key []byte, k uint64, ring *consistent.Consistent) Node {
+	members, err := ring.GetClosestN(key, len(ring.GetMembers()))
+	if err != nil {
+		return nil
+	}
+
+	var candidates []Node
+	var scores []float64
+	var total float64
+
+	visited := make(map[string]bool)
+	for _, member := range members {
+		name := member.(Node).Name()
+		if visited[name] || m.isDegraded(name) {
+			continue
+		}
+
+		visited[name] = true
+
+		score := float64(m.weights[name]) * m.scoreOf(name)
+		candidates = append(candidates, m.nodes[name])
+		scores = append(scores, score)
+		total += score
+	}
+
+	if len(candidates) == 0 || total <= 0 { // all nodes degraded
+		return nil
+	}
+
+	// pick deterministically by key so that the same key is routed to the same node
+	target := float64(k%10000) / 10000 * total
+	for i, score := range scores {
+		if target < score {
+			return candidates[i]
+		}
+
+		target -= score
+	}
+
+	return candidates[len(candidates)-1]
+}
+
+// scoreOf returns the health score of the specified node, which defaults to 1 if not reported yet.
+func (m *Manager) scoreOf(nodeName string) float64 {
+	if status, ok := m.monitorStatuses[nodeName]; ok && status.scored {
+		return status.score
+	}
+
+	return 1
+}
+
+// isDegraded checks if the specified node is degraded by health score.
+func (m *Manager) isDegraded(nodeName string) bool {
+	return m.scoreOf(nodeName) < cfg.Monitor.Score.DegradedThreshold
+}
+
+// addToRing adds node into hash ring(s) as virtual members as many as weight.
+func (m *Manager) addToRing(n Node, weight int) {
+	if m.ringed[n.Name()] {
+		return
+	}
+
+	m.dampener.snapshot(m.hashRing, m.localRing)
+	m.ringed[n.Name()] = true
+
+	isLocal := cfg.isLocal(n.Name())
+
+	for i := 0; i < weight; i++ {
+		m.hashRing.Add(newWeightedMember(n, i))
+
+		if isLocal {
+			m.localRing.Add(newWeightedMember(n, i))
+		}
+	}
+}
+
+// removeFromRing removes all virtual members of node from hash ring(s).
+func (m *Manager) removeFromRing(nodeName string, weight int) {
+	if !m.ringed[nodeName] {
+		return
+	}
+
+	m.dampener.snapshot(m.hashRing, m.localRing)
+	delete(m.ringed, nodeName)
+
+	for i := 0; i < weight; i++ {
+		m.hashRing.Remove(weightedMemberName(nodeName, i))
+		m.localRing.Remove(weightedMemberName(nodeName, i))
+	}
+}
+
+// weightedMember is a virtual member of node on the hash ring, so that node with larger
+// weight owns more partitions.
+type weightedMember struct {
+	Node
+	replica int
+}
+
+func newWeightedMember(n Node, replica int) *weightedMember {
+	return &weightedMember{Node: n, replica: replica}
+}
+
+func weightedMemberName(nodeName string, replica int) string {
+	if replica == 0 { // keep compatible with the unweighted hash ring
+		return nodeName
+	}
+
+	return fmt.Sprintf("%v#%v", nodeName, replica)
+}
+
+// String implements the consistent.Member interface.
+func (wm *weightedMember) String() string {
+	return weightedMemberName(wm.Name(), wm.replica)
+}
+
 // Route implements the Router interface.
 func (m *Manager) Route(key []byte) string {
 	if n := m.Distribute(key); n != nil {
@@ -13,6 +13,8 @@ type monitorStatus struct {
 	epoch            uint64    // the latest epoch height
 	unhealthy        bool      // whether the node is unhealthy
 	unhealthReportAt time.Time // the last unhealthy report time
+	score            float64   // the latest health score
+	scored           bool      // whether health score ever reported
 }
 
 // Implementations for HealthMonitor interface.
@@ -85,7 +87,9 @@ func (m *Manager) ReportUnhealthy(nodeName string, remind bool, reason error) {
 	}
 
 	// remove unhealthy node from hash ring
-	m.hashRing.Remove(nodeName)
+	m.mu.Lock()
+	m.removeFromRing(nodeName, m.weights[nodeName])
+	m.mu.Unlock()
 
 	// FIXME update repartition cache if configured
 }
@@ -102,12 +106,21 @@ func (m *Manager) updateUnhealthy(nodeName string) {
 
 // ReportHealthy reports healthy status of managed node to manager.
 func (m *Manager) ReportHealthy(nodeName string) {
+	m.updateHealthy(nodeName)
+
 	// alert
 	logrus.WithField("node", nodeName).Warn("Node became healthy now")
 
 	// add recovered node into hash ring again
-	if n, ok := m.Get(nodeName); ok {
-		m.hashRing.Add(n)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.drained[nodeName] { // keep drained until undrained manually
+		return
+	}
+
+	if n, ok := m.nodes[nodeName]; ok {
+		m.addToRing(n, m.weights[nodeName])
 	} else { // this should not happen, but just in case
 		logrus.WithField("node", nodeName).Error("Node not found in manager")
 	}
@@ -122,3 +135,22 @@ func (m *Manager) updateHealthy(nodeName string) {
 	status.unhealthReportAt = time.Time{}
 	m.monitorStatuses[nodeName] = status
 }
+
+// ReportScore reports the latest health score of managed node to manager.
+func (m *Manager) ReportScore(nodeName string, score float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := m.monitorStatuses[nodeName]
+	wasDegraded := status.scored && status.score < cfg.Monitor.Score.DegradedThreshold
+	status.score, status.scored = score, true
+	m.monitorStatuses[nodeName] = status
+
+	if isDegraded := score < cfg.Monitor.Score.DegradedThreshold; isDegraded != wasDegraded {
+		logrus.WithFields(logrus.Fields{
+			"node":  nodeName,
+			"group": m.group,
+			"score": score,
+		}).Warn("Node health score crossed degraded threshold")
+	}
+}
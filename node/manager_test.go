@@ -0,0 +1,170 @@
+package node
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthScore(t *testing.T) {
+	MustInit()
+
+	baseline := cfg.Monitor.Score.LatencyBaseline
+	maxLag := cfg.Monitor.Unhealth.EpochsFallBehind
+
+	assert.Equal(t, 1.0, healthScore(baseline/2, 0, 0))
+	assert.InDelta(t, 0.5, healthScore(baseline*2, 0, 0), 1e-9)
+	assert.InDelta(t, 0.75, healthScore(baseline, 0.25, 0), 1e-9)
+	assert.InDelta(t, 0.5, healthScore(baseline, 0, maxLag/2), 0.05)
+	assert.Equal(t, 0.0, healthScore(baseline, 0, maxLag))
+	assert.Equal(t, 0.0, healthScore(baseline, 1, 0))
+}
+
+func TestManagerRouteAwayFromDegradedNode(t *testing.T) {
+	MustInit()
+
+	m := NewManager(GroupCfxHttp)
+	for _, url := range testGroupNodeUrls[GroupCfxHttp] {
+		n, _ := newDummyNode(GroupCfxHttp, rpc.Url2NodeName(url), url)
+		m.Add(n)
+	}
+
+	keys := make([][]byte, 1000)
+	for i := range keys {
+		keys[i] = []byte(strconv.Itoa(i))
+	}
+
+	degraded := m.Distribute(keys[0]).Name()
+	m.ReportScore(degraded, cfg.Monitor.Score.DegradedThreshold/2)
+
+	for _, key := range keys {
+		n := m.Distribute(key)
+		assert.NotEqual(t, degraded, n.Name())
+		// routed to the same node for the same key
+		assert.Equal(t, n.Name(), m.Distribute(key).Name())
+	}
+
+	// recovered
+	m.ReportScore(degraded, 1)
+	assert.Equal(t, degraded, m.Distribute(keys[0]).Name())
+}
+
+func TestManagerWeightedRoute(t *testing.T) {
+	MustInit()
+
+	urls := testGroupNodeUrls[GroupCfxHttp]
+	cfg.NodeProfiles = []NodeProfile{{URL: urls[0], Weight: 3}}
+	defer func() { cfg.NodeProfiles = nil }()
+
+	m := NewManager(GroupCfxHttp)
+	for _, url := range urls {
+		n, _ := newDummyNode(GroupCfxHttp, rpc.Url2NodeName(url), url)
+		m.Add(n)
+	}
+
+	counter := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		counter[m.Distribute([]byte(strconv.Itoa(i))).Url()]++
+	}
+
+	// node with weight 3 serves about 60% requests
+	assert.Greater(t, counter[urls[0]], counter[urls[1]]*2)
+	assert.Greater(t, counter[urls[0]], counter[urls[2]]*2)
+}
+
+func TestManagerRouteLocalRegion(t *testing.T) {
+	MustInit()
+
+	urls := testGroupNodeUrls[GroupCfxHttp]
+	cfg.Region = "us"
+	cfg.NodeProfiles = []NodeProfile{{URL: urls[0], Region: "us"}, {URL: urls[1], Region: "eu"}}
+	defer func() { cfg.Region, cfg.NodeProfiles = "", nil }()
+
+	m := NewManager(GroupCfxHttp)
+	for _, url := range urls {
+		n, _ := newDummyNode(GroupCfxHttp, rpc.Url2NodeName(url), url)
+		m.Add(n)
+	}
+
+	local := rpc.Url2NodeName(urls[0])
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, local, m.Distribute([]byte(strconv.Itoa(i))).Name())
+	}
+
+	// fall back to other regions if local node degraded
+	m.ReportScore(local, 0)
+	for i := 0; i < 100; i++ {
+		assert.NotEqual(t, local, m.Distribute([]byte(strconv.Itoa(i))).Name())
+	}
+}
+
+func TestManagerRebalanceDampening(t *testing.T) {
+	MustInit()
+
+	cfg.HashRing.DampeningWindow = time.Hour
+	defer func() { cfg.HashRing.DampeningWindow = 0 }()
+
+	urls := testGroupNodeUrls[GroupCfxHttp]
+	m := NewManager(GroupCfxHttp)
+	for _, url := range urls[:2] {
+		n, _ := newDummyNode(GroupCfxHttp, rpc.Url2NodeName(url), url)
+		m.Add(n)
+	}
+
+	// initial rebalance settled
+	m.dampener.rebalancedAt = time.Now().Add(-time.Hour)
+
+	assignments := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		assignments[key] = m.Distribute([]byte(key)).Name()
+	}
+
+	// old assignments retained right after new node joined
+	n, _ := newDummyNode(GroupCfxHttp, rpc.Url2NodeName(urls[2]), urls[2])
+	m.Add(n)
+
+	for key, name := range assignments {
+		assert.Equal(t, name, m.Distribute([]byte(key)).Name())
+	}
+
+	// all drained after dampening window passed
+	m.dampener.rebalancedAt = time.Now().Add(-time.Hour)
+
+	var migrated int
+	for key, name := range assignments {
+		if m.Distribute([]byte(key)).Name() != name {
+			migrated++
+		}
+	}
+
+	assert.Greater(t, migrated, 0)
+}
+
+func TestManagerDrain(t *testing.T) {
+	MustInit()
+
+	m := NewManager(GroupCfxHttp)
+	for _, url := range testGroupNodeUrls[GroupCfxHttp] {
+		n, _ := newDummyNode(GroupCfxHttp, rpc.Url2NodeName(url), url)
+		m.Add(n)
+	}
+
+	drained := m.Distribute([]byte("0")).Name()
+	assert.True(t, m.Drain(drained))
+	assert.False(t, m.Drain("unknown"))
+
+	for i := 0; i < 1000; i++ {
+		assert.NotEqual(t, drained, m.Distribute([]byte(strconv.Itoa(i))).Name())
+	}
+
+	// drained node not routed even recovered from unhealthy
+	m.ReportHealthy(drained)
+	assert.NotEqual(t, drained, m.Distribute([]byte("0")).Name())
+
+	assert.True(t, m.Undrain(drained))
+	assert.Equal(t, drained, m.Distribute([]byte("0")).Name())
+}
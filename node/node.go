@@ -36,6 +36,7 @@ type Node interface {
 }
 
 type baseNode struct {
+	chain        nodeChainState
 	mu           sync.Mutex
 	name         string
 	url          string
@@ -62,6 +63,10 @@ func (n *baseNode) Status() Status {
 	return n.atomicStatus.Load().(Status)
 }
 
+func (n *baseNode) chainState() *nodeChainState {
+	return &n.chain
+}
+
 func (n *baseNode) String() string {
 	return n.name
 }
@@ -142,7 +147,7 @@ type EthNode struct {
 // NewEthNode creates an instance of evm space node and start to monitor
 // node health in a separate goroutine until node closed.
 func NewEthNode(group Group, name, url string) (*EthNode, error) {
-	eth, err := rpc.NewEthClient(url)
+	eth, err := rpc.NewEthClient(url, rpc.WithClientNodeGroup(string(group)))
 	if err != nil {
 		return nil, err
 	}
@@ -175,6 +180,19 @@ func (n *EthNode) LatestEpochNumber() (uint64, error) {
 	return block.Uint64(), nil
 }
 
+func (n *EthNode) fetchChainId() (uint64, error) {
+	chainId, err := n.Eth.ChainId()
+	if err != nil {
+		return 0, err
+	}
+
+	if chainId == nil { // this shouldn't happen, but just in case...
+		return 0, errors.New("invalid chain ID")
+	}
+
+	return *chainId, nil
+}
+
 // CfxNode represents a core space fullnode with friendly name and health status.
 type CfxNode struct {
 	sdk.ClientOperator
@@ -184,7 +202,7 @@ type CfxNode struct {
 // NewCfxNode creates an instance of core space fullnode and start to monitor
 // node health in a separate goroutine until node closed.
 func NewCfxNode(group Group, name, url string) (*CfxNode, error) {
-	cfx, err := rpc.NewCfxClient(url)
+	cfx, err := rpc.NewCfxClient(url, rpc.WithClientNodeGroup(string(group)))
 	if err != nil {
 		return nil, err
 	}
@@ -217,6 +235,15 @@ func (n *CfxNode) LatestEpochNumber() (uint64, error) {
 	return epoch.ToInt().Uint64(), nil
 }
 
+func (n *CfxNode) fetchChainId() (uint64, error) {
+	networkId, err := n.GetNetworkID()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(networkId), nil
+}
+
 func (n *CfxNode) Close() {
 	n.baseNode.Close()
 	n.ClientOperator.Close()
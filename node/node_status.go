@@ -3,6 +3,7 @@ package node
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/Conflux-Chain/confura/util/metrics"
@@ -30,6 +31,9 @@ type HealthMonitor interface {
 
 	// ReportHealthy fired when full node becomes healthy.
 	ReportHealthy(nodeName string)
+
+	// ReportScore fired when health score of full node updated.
+	ReportScore(nodeName string, score float64)
 }
 
 // Status represents the node status, including current epoch number and health status.
@@ -42,6 +46,10 @@ type Status struct {
 	successCounter   uint64
 	failureCounter   uint64
 
+	// exponentially weighted moving average of heartbeat latency and error rate
+	ewmaLatency time.Duration
+	ewmaErrRate float64
+
 	latestHeartBeatErrs *ring.Ring
 }
 
@@ -84,6 +92,9 @@ func (s *Status) MarshalJSON() ([]byte, error) {
 		SuccessCounter   uint64 `json:"successCounter"`
 		FailureCounter   uint64 `json:"failureCounter"`
 
+		EwmaLatency string `json:"ewmaLatency"`
+		EwmaErrRate string `json:"ewmaErrRate"`
+
 		LatestHeartBeatErrs []string `json:"latestHeartBeatErrs"`
 	}
 
@@ -99,6 +110,8 @@ func (s *Status) MarshalJSON() ([]byte, error) {
 		LatestStateEpoch: s.latestStateEpoch,
 		SuccessCounter:   s.successCounter,
 		FailureCounter:   s.failureCounter,
+		EwmaLatency:      fmt.Sprintf("%.2f(ms)", float64(s.ewmaLatency)/1e6),
+		EwmaErrRate:      fmt.Sprintf("%.2f%%", s.ewmaErrRate*100),
 	}
 
 	hbErrors := s.latestHeartBeatErrs.Values()
@@ -113,7 +126,15 @@ func (s *Status) MarshalJSON() ([]byte, error) {
 func (s *Status) heartbeat(n Node) {
 	start := time.Now()
 	epoch, err := n.LatestEpochNumber()
+	if err == nil {
+		// full node of mismatched chain ID is regarded as failure, so as to be removed from routing
+		err = verifyChainId(n)
+	} else {
+		invalidateChainId(n)
+	}
+
 	s.metric.update(start, err)
+	s.updateEwma(time.Since(start), err)
 	if err != nil {
 		s.failureCounter++
 		s.successCounter = 0
@@ -129,9 +150,60 @@ func (s *Status) heartbeat(n Node) {
 	}
 }
 
+// updateEwma updates the moving average of latency and error rate with the latest heartbeat.
+func (s *Status) updateEwma(latency time.Duration, err error) {
+	alpha := cfg.Monitor.Score.SmoothingFactor
+
+	var errRate float64
+	if err != nil {
+		errRate = 1
+		// failed request is regarded as the max latency
+		latency = cfg.Monitor.Unhealth.MaxLatency
+	}
+
+	if s.successCounter+s.failureCounter == 0 { // first heartbeat
+		s.ewmaLatency, s.ewmaErrRate = latency, errRate
+		return
+	}
+
+	s.ewmaLatency = time.Duration(alpha*float64(latency) + (1-alpha)*float64(s.ewmaLatency))
+	s.ewmaErrRate = alpha*errRate + (1-alpha)*s.ewmaErrRate
+}
+
+// Score returns the health score of node in range [0, 1] against the target epoch.
+func (s *Status) Score(targetEpoch uint64) float64 {
+	var lag uint64
+	if targetEpoch > s.latestStateEpoch {
+		lag = targetEpoch - s.latestStateEpoch
+	}
+
+	return healthScore(s.ewmaLatency, s.ewmaErrRate, lag)
+}
+
+// healthScore calculates health score in range [0, 1], which is the product of latency factor,
+// success rate and epoch lag factor.
+func healthScore(latency time.Duration, errRate float64, lag uint64) float64 {
+	latencyFactor := 1.0
+	if baseline := cfg.Monitor.Score.LatencyBaseline; latency > baseline {
+		latencyFactor = float64(baseline) / float64(latency)
+	}
+
+	lagFactor := 0.0
+	if maxLag := cfg.Monitor.Unhealth.EpochsFallBehind; lag < maxLag {
+		lagFactor = 1 - float64(lag)/float64(maxLag)
+	}
+
+	return latencyFactor * math.Max(0, 1-errRate) * lagFactor
+}
+
 // updateHealth reports health status to monitor.
 func (s *Status) updateHealth(monitor HealthMonitor) {
-	reason := s.checkHealth(monitor.HealthyEpoch())
+	healthyEpoch := monitor.HealthyEpoch()
+	if cfg.Monitor.Score.Enabled {
+		monitor.ReportScore(s.nodeName, s.Score(healthyEpoch))
+	}
+
+	reason := s.checkHealth(healthyEpoch)
 	unhealthy, unhealthReportAt := monitor.HealthStatus(s.nodeName)
 
 	if unhealthy {
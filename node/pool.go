@@ -27,6 +27,34 @@ type nodePool struct {
 	nodes map[string]refNode
 }
 
+var (
+	// node pools to rebalance once node profiles reloaded
+	activePools   []*nodePool
+	activePoolsMu sync.Mutex
+)
+
+// registerPool registers node pool to rebalance once node profiles reloaded.
+func registerPool(p *nodePool) {
+	activePoolsMu.Lock()
+	defer activePoolsMu.Unlock()
+
+	activePools = append(activePools, p)
+}
+
+// reweightPools applies the latest node weights to all registered node pools.
+func reweightPools() {
+	activePoolsMu.Lock()
+	defer activePoolsMu.Unlock()
+
+	for _, p := range activePools {
+		for _, grp := range p.groups() {
+			if m, ok := p.manager(grp); ok {
+				m.reweight()
+			}
+		}
+	}
+}
+
 func newNodePool(nf nodeFactory) *nodePool {
 	return &nodePool{
 		nf:       nf,
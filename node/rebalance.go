@@ -0,0 +1,73 @@
+package node
+
+import (
+	"time"
+
+	"github.com/buraksezer/consistent"
+)
+
+// rebalanceDampener dampens the hash ring rebalance when full node joins or leaves, so that
+// the existing key assignments (eg., delegated filters and subscriptions) will be drained to
+// the new hash ring gradually within the dampening window rather than mass-migrated at once.
+type rebalanceDampener struct {
+	window time.Duration // dampening window, disabled if zero
+
+	// hash rings snapshotted right before the latest rebalance
+	prevRing, prevLocalRing *consistent.Consistent
+	rebalancedAt            time.Time
+}
+
+func newRebalanceDampener(window time.Duration) *rebalanceDampener {
+	return &rebalanceDampener{window: window}
+}
+
+// snapshot snapshots the hash rings before rebalance.
+func (d *rebalanceDampener) snapshot(ring, localRing *consistent.Consistent) {
+	if d.window <= 0 {
+		return
+	}
+
+	d.prevRing = snapshotRing(ring)
+	d.prevLocalRing = snapshotRing(localRing)
+	d.rebalancedAt = time.Now()
+}
+
+// snapshotRing copies the hash ring, or returns nil if no member, which consistent hashing
+// doesn't allow to create.
+func snapshotRing(ring *consistent.Consistent) *consistent.Consistent {
+	members := ring.GetMembers()
+	if len(members) == 0 {
+		return nil
+	}
+
+	return consistent.New(members, cfg.HashRingRaw())
+}
+
+// locate locates the previously assigned member of the specified key, if the key is still
+// not drained to the new hash ring yet.
+func (d *rebalanceDampener) locate(key []byte, k uint64) (consistent.Member, bool) {
+	if d.prevRing == nil && d.prevLocalRing == nil {
+		return nil, false
+	}
+
+	elapsed := time.Since(d.rebalancedAt)
+	if elapsed >= d.window { // dampening window passed
+		return nil, false
+	}
+
+	// keys are drained in proportion to the elapsed time of the dampening window
+	if float64(k%10000)/10000 < float64(elapsed)/float64(d.window) {
+		return nil, false
+	}
+
+	ring := d.prevRing
+	if d.prevLocalRing != nil {
+		ring = d.prevLocalRing
+	}
+
+	if ring == nil {
+		return nil, false
+	}
+
+	return ring.LocateKey(key), true
+}
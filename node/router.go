@@ -36,6 +36,7 @@ const (
 	GroupEthWs        Group = "ethws"
 	GroupEthFilter    Group = "ethfilter"
 	GroupEthLogs      Group = "ethlogs"
+	GroupEthArchives  Group = "etharchives"
 )
 
 // Space parses space from group name
@@ -63,7 +64,7 @@ type NodeProvider interface {
 }
 
 // MustNewRouter creates an instance of Router.
-func MustNewRouter(redisURL string, nodeRPCURL string, groupConf map[Group]UrlConfig) Router {
+func MustNewRouter(redisURL, nodeRPCURL, authToken string, groupConf map[Group]UrlConfig) Router {
 	var routers []Router
 
 	// Add redis router if configured
@@ -81,7 +82,7 @@ func MustNewRouter(redisURL string, nodeRPCURL string, groupConf map[Group]UrlCo
 	// Add node rpc router if configured
 	if len(nodeRPCURL) > 0 {
 		// http://127.0.0.1:22530
-		client, err := rpc.DialHTTP(nodeRPCURL)
+		client, err := rpcutil.DialAdminHTTP(nodeRPCURL, authToken)
 		if err != nil {
 			logrus.WithError(err).Fatal("Failed to create rpc client")
 		}
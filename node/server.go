@@ -1,6 +1,7 @@
 package node
 
 import (
+	"context"
 	"sync"
 
 	"github.com/Conflux-Chain/confura/store/mysql"
@@ -14,9 +15,15 @@ var (
 	errDbNotAvailableForPersistence = errors.New("db not available for persistence")
 )
 
-// MustNewServer creates node management RPC server
-func MustNewServer(db *mysql.MysqlStore, nf nodeFactory, grpConf map[Group]UrlConfig) *rpc.Server {
+// MustNewServer creates node management RPC server authenticated by bearer token (and client
+// certificate if admin TLS configured), and starts to discover full nodes from config service if
+// discovery URL configured, and serves admin gRPC service if admin endpoint configured.
+func MustNewServer(
+	ctx context.Context, wg *sync.WaitGroup, db *mysql.MysqlStore, nf nodeFactory,
+	grpConf map[Group]UrlConfig, authToken, discoveryUrl, adminEndpoint string,
+) *rpc.Server {
 	npool := newNodePool(nf)
+	registerPool(npool)
 
 	if db != nil {
 		// load node route group config from db
@@ -41,9 +48,24 @@ func MustNewServer(db *mysql.MysqlStore, nf nodeFactory, grpConf map[Group]UrlCo
 		}
 	}
 
-	return rpc.MustNewServer("node", map[string]interface{}{
-		"node": &api{h: &apiHandler{dbs: db, pool: npool}},
-	})
+	h := &apiHandler{dbs: db, pool: npool}
+
+	if len(discoveryUrl) > 0 {
+		if len(cfg.Discovery.AuthToken) == 0 {
+			logrus.WithField("url", discoveryUrl).Fatal("Auth token required for node discovery")
+		}
+
+		wg.Add(1)
+		go newDiscoverer(discoveryUrl, h).run(ctx, wg)
+	}
+
+	if len(adminEndpoint) > 0 {
+		mustServeAdmin(ctx, wg, adminEndpoint, cfg.Admin.AuthToken, h)
+	}
+
+	return rpc.MustNewAdminServer("node", map[string]interface{}{
+		"node": &api{h: h},
+	}, rpc.MustNewBearerAuthMiddleware(authToken))
 }
 
 // api node management RPC APIs.
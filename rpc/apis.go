@@ -60,11 +60,33 @@ func nativeSpaceApis(
 	option ...CfxAPIOption,
 ) []API {
 	stateHandler := handler.NewCfxStateHandler(clientProvider)
+	cfxApi := newCfxAPI(clientProvider, option...)
+
 	return []API{
 		{
 			Namespace: "cfx",
 			Version:   "1.0",
-			Service:   newCfxAPI(clientProvider, option...),
+			Service:   cfxApi,
+			Public:    true,
+		}, {
+			Namespace: "crossspace",
+			Version:   "1.0",
+			Service:   &crossSpaceAPI{cfxApi},
+			Public:    true,
+		}, {
+			Namespace: "reorg",
+			Version:   "1.0",
+			Service:   &reorgAPI{cfxApi.ReorgStore},
+			Public:    true,
+		}, {
+			Namespace: "token",
+			Version:   "1.0",
+			Service:   &cfxTokenAPI{cfxApi.TokenStore},
+			Public:    true,
+		}, {
+			Namespace: "snapshot",
+			Version:   "1.0",
+			Service:   newCfxSnapshotAPI(),
 			Public:    true,
 		}, {
 			Namespace: "txpool",
@@ -105,12 +127,44 @@ func evmSpaceApis(
 	clientProvider *node.EthClientProvider,
 	gashandler *handler.EthGasStationHandler,
 	option ...EthAPIOption) ([]API, error) {
-	stateHandler := handler.NewEthStateHandler(clientProvider)
+	var opt EthAPIOption
+	if len(option) > 0 {
+		opt = option[0]
+	}
+
+	stateHandler := handler.MustNewEthStateHandlerFromViper(clientProvider, opt.HeadTracker)
+	ethApi := mustNewEthAPI(clientProvider, option...)
+
 	return []API{
 		{
 			Namespace: "eth",
 			Version:   "1.0",
-			Service:   mustNewEthAPI(clientProvider, option...),
+			Service:   ethApi,
+			Public:    true,
+		}, {
+			Namespace: "abi",
+			Version:   "1.0",
+			Service:   &ethAbiAPI{ethApi, opt.AbiRegistry},
+			Public:    true,
+		}, {
+			Namespace: "reorg",
+			Version:   "1.0",
+			Service:   &reorgAPI{opt.ReorgStore},
+			Public:    true,
+		}, {
+			Namespace: "token",
+			Version:   "1.0",
+			Service:   &ethTokenAPI{opt.TokenStore},
+			Public:    true,
+		}, {
+			Namespace: "internaltx",
+			Version:   "1.0",
+			Service:   &internalTxAPI{opt.InternalTxStore},
+			Public:    true,
+		}, {
+			Namespace: "snapshot",
+			Version:   "1.0",
+			Service:   newEthSnapshotAPI(),
 			Public:    true,
 		}, {
 			Namespace: "web3",
@@ -122,10 +176,15 @@ func evmSpaceApis(
 			Version:   "1.0",
 			Service:   &netAPI{},
 			Public:    true,
+		}, {
+			Namespace: "txpool",
+			Version:   "1.0",
+			Service:   &ethTxPoolAPI{opt.Txpool},
+			Public:    true,
 		}, {
 			Namespace: "trace",
 			Version:   "1.0",
-			Service:   &ethTraceAPI{stateHandler},
+			Service:   &ethTraceAPI{stateHandler, opt.TraceCache},
 			Public:    false,
 		}, {
 			Namespace: "parity",
@@ -135,7 +194,7 @@ func evmSpaceApis(
 		}, {
 			Namespace: "debug",
 			Version:   "1.0",
-			Service:   &ethDebugAPI{stateHandler},
+			Service:   &ethDebugAPI{stateHandler, opt.TraceCache},
 			Public:    false,
 		}, {
 			Namespace: "gasstation",
@@ -0,0 +1,176 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+// batchConfig represents the configuration to split JSON-RPC batch requests.
+type batchConfig struct {
+	Enabled bool
+	// max number of batch items executed in parallel for each batch
+	Concurrency int `default:"8"`
+}
+
+// batchMiddleware splits JSON-RPC batch request over HTTP into single requests, which are executed
+// in parallel with concurrency capped per batch, and reassembles the responses in order.
+//
+// Note, each batch item is handled as a single request by the RPC server, so that store-served
+// items are executed locally while the rest are fanned out to upstream full nodes.
+func batchMiddleware(conf batchConfig) handlers.Middleware {
+	return func(next http.Handler) http.Handler {
+		if !conf.Enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			var items []json.RawMessage
+			if !isBatch(body) || json.Unmarshal(body, &items) != nil || len(items) <= 1 {
+				// not a batch or too small to split, leave it to the RPC server
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			responses := executeBatch(next, r, items, conf.Concurrency)
+
+			metrics.Registry.RPC.BatchLatency().Update(time.Since(start).Nanoseconds())
+			metrics.Registry.RPC.BatchSize().Update(int64(len(items)))
+
+			writeBatchResponses(w, responses)
+		})
+	}
+}
+
+// isBatch checks if the request body is a JSON array.
+func isBatch(body []byte) bool {
+	body = bytes.TrimLeft(body, " \t\r\n")
+	return len(body) > 0 && body[0] == '['
+}
+
+// executeBatch executes batch items in parallel, and returns responses in the same order.
+func executeBatch(next http.Handler, r *http.Request, items []json.RawMessage, concurrency int) []*batchResponse {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	responses := make([]*batchResponse, len(items))
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i := range items {
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			subReq := r.Clone(r.Context())
+			subReq.Body = io.NopCloser(bytes.NewReader(items[i]))
+			subReq.ContentLength = int64(len(items[i]))
+			subReq.Header.Set("Content-Length", strconv.Itoa(len(items[i])))
+
+			responses[i] = newBatchResponse()
+			next.ServeHTTP(responses[i], subReq)
+		}(i)
+	}
+
+	wg.Wait()
+
+	return responses
+}
+
+// writeBatchResponses writes the batch responses as JSON array, skipping empty responses of
+// notifications.
+func writeBatchResponses(w http.ResponseWriter, responses []*batchResponse) {
+	var buf bytes.Buffer
+
+	for _, resp := range responses {
+		data := bytes.TrimSpace(resp.body.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		if !json.Valid(data) { // eg., HTTP error of invalid request
+			logrus.WithField("status", resp.status).Debug("Invalid JSON response of batch item")
+			data = []byte(`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":` +
+				strconv.Quote(http.StatusText(resp.status)) + `}}`)
+		}
+
+		if buf.Len() == 0 {
+			buf.WriteByte('[')
+			copyHeaders(w.Header(), resp.header)
+		} else {
+			buf.WriteByte(',')
+		}
+
+		buf.Write(data)
+	}
+
+	if buf.Len() == 0 { // all notifications
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	buf.WriteByte(']')
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+func copyHeaders(dst, src http.Header) {
+	for k, v := range src {
+		if k != "Content-Length" {
+			dst[k] = v
+		}
+	}
+}
+
+// batchResponse buffers HTTP response of batch item.
+type batchResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBatchResponse() *batchResponse {
+	return &batchResponse{header: make(http.Header), status: http.StatusOK}
+}
+
+func (resp *batchResponse) Header() http.Header {
+	return resp.header
+}
+
+func (resp *batchResponse) Write(data []byte) (int, error) {
+	return resp.body.Write(data)
+}
+
+func (resp *batchResponse) WriteHeader(statusCode int) {
+	resp.status = statusCode
+}
@@ -0,0 +1,48 @@
+package rpc
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchMiddleware(t *testing.T) {
+	// echo request ID as result with reverse delay, and no response for notification
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ ID *int }
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &req))
+
+		if req.ID == nil {
+			return
+		}
+
+		time.Sleep(time.Duration(10-*req.ID) * time.Millisecond)
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + strconv.Itoa(*req.ID) + `,"result":"ok"}`))
+	})
+
+	handler := batchMiddleware(batchConfig{Enabled: true, Concurrency: 4})(echo)
+
+	body := `[{"id":1},{"id":2},{"method":"notify"},{"id":3}]`
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+	var responses []struct{ ID int }
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &responses))
+	assert.Equal(t, 3, len(responses))
+	for i, resp := range responses {
+		assert.Equal(t, i+1, resp.ID)
+	}
+
+	// single request not split
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":5}`)))
+	assert.Equal(t, `{"jsonrpc":"2.0","id":5,"result":"ok"}`, w.Body.String())
+}
@@ -2,10 +2,14 @@ package rpc
 
 import (
 	"context"
+	"math"
 
 	"github.com/Conflux-Chain/confura/node"
 	"github.com/Conflux-Chain/confura/rpc/handler"
+	"github.com/Conflux-Chain/confura/store"
+	citypes "github.com/Conflux-Chain/confura/types"
 	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/logging"
 	"github.com/Conflux-Chain/confura/util/metrics"
 	vfclient "github.com/Conflux-Chain/confura/virtualfilter/client"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
@@ -13,17 +17,33 @@ import (
 	postypes "github.com/Conflux-Chain/go-conflux-sdk/types/pos"
 	logutil "github.com/Conflux-Chain/go-conflux-util/log"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
 const (
 	rpcMethodCfxGetLogs = "cfx_getLogs"
+
+	// The default and maximum number of transactions returned per page for account transactions.
+	defaultAccountTxnsPageSize = 100
+	maxAccountTxnsPageSize     = 1000
+	// The maximum number of transactions to skip, since deep offsets scan all skipped rows in store.
+	// Clients shall narrow the epoch range instead to paginate further.
+	maxAccountTxnsSkip = 10000
 )
 
 var (
 	emptyEpochs             = []*types.Epoch{}
 	emptyEpochOrBlockHashes = []*types.EpochOrBlockHash{}
 	emptyLogs               = []types.Log{}
+
+	errTooManyAccountTxnsPerPage = errors.Errorf(
+		"the page size exceeds the maximum allowed (%v)", maxAccountTxnsPageSize,
+	)
+	errTooManyAccountTxnsSkipped = errors.Errorf(
+		"the skip exceeds the maximum allowed (%v), please narrow the epoch range instead", maxAccountTxnsSkip,
+	)
+	errZeroAccountTxnsPageSize = errors.New("the page size must be positive")
 )
 
 type CfxAPIOption struct {
@@ -31,6 +51,9 @@ type CfxAPIOption struct {
 	LogApiHandler       *handler.CfxLogsApiHandler
 	TxnHandler          *handler.CfxTxnHandler
 	VirtualFilterClient *vfclient.CfxClient
+	HeadTracker         *handler.CfxHeadTracker
+	ReorgStore          ReorgEventStore
+	TokenStore          TokenTransferStore
 }
 
 // cfxAPI provides main proxy API for core space.
@@ -40,6 +63,8 @@ type cfxAPI struct {
 	inputEpochMetric metrics.InputEpochMetric
 	stateHandler     *handler.CfxStateHandler
 	etPubsubLogger   *logutil.ErrorTolerantLogger
+	// routes reads of recently sent transactions to the accepting full node, nil if disabled
+	sentTxns *sentTxnRouter
 }
 
 func newCfxAPI(provider *node.CfxClientProvider, option ...CfxAPIOption) *cfxAPI {
@@ -53,6 +78,7 @@ func newCfxAPI(provider *node.CfxClientProvider, option ...CfxAPIOption) *cfxAPI
 		provider:       provider,
 		stateHandler:   handler.NewCfxStateHandler(provider),
 		etPubsubLogger: logutil.NewErrorTolerantLogger(logutil.DefaultETConfig),
+		sentTxns:       mustNewSentTxnRouterFromViper("rpc.readYourWrites"),
 	}
 }
 
@@ -80,6 +106,16 @@ func (api *cfxAPI) GasPrice(ctx context.Context) (*hexutil.Big, error) {
 func (api *cfxAPI) EpochNumber(ctx context.Context, epoch *types.Epoch) (*hexutil.Big, error) {
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(epoch, "cfx_epochNumber", cfx)
+
+	if api.HeadTracker != nil {
+		epochNum, ok := api.HeadTracker.EpochNumber(epoch)
+		metrics.Registry.RPC.Percentage("cfx_epochNumber", "headTracker").Mark(ok)
+
+		if ok {
+			return epochNum, nil
+		}
+	}
+
 	return cfx.GetEpochNumber(epoch)
 }
 
@@ -152,7 +188,7 @@ func (api *cfxAPI) GetStorageRoot(ctx context.Context, address types.Address, ep
 func (api *cfxAPI) GetBlockByHash(ctx context.Context, blockHash types.Hash, includeTxs bool) (interface{}, error) {
 	metrics.Registry.RPC.Percentage("cfx_getBlockByHash", "includeTxs").Mark(includeTxs)
 
-	logger := logrus.WithFields(logrus.Fields{"blockHash": blockHash, "includeTxs": includeTxs})
+	logger := logging.FromContext(ctx).WithFields(logrus.Fields{"blockHash": blockHash, "includeTxs": includeTxs})
 
 	if !util.IsInterfaceValNil(api.StoreHandler) {
 		block, err := api.StoreHandler.GetBlockByHash(ctx, blockHash, includeTxs)
@@ -186,12 +222,18 @@ func (api *cfxAPI) GetBlockByHashWithPivotAssumption(
 func (api *cfxAPI) GetBlockByEpochNumber(ctx context.Context, epoch types.Epoch, includeTxs bool) (interface{}, error) {
 	metrics.Registry.RPC.Percentage("cfx_getBlockByEpochNumber", "includeTxs").Mark(includeTxs)
 
-	logger := logrus.WithFields(logrus.Fields{"epoch": epoch, "includeTxs": includeTxs})
+	logger := logging.FromContext(ctx).WithFields(logrus.Fields{"epoch": epoch, "includeTxs": includeTxs})
 
 	cfx := GetCfxClientFromContext(ctx)
 
 	api.inputEpochMetric.Update(&epoch, "cfx_getBlockByEpochNumber", cfx)
 
+	if api.HeadTracker != nil && !includeTxs {
+		if header, ok := api.HeadTracker.Header(&epoch); ok {
+			return header, nil
+		}
+	}
+
 	if !util.IsInterfaceValNil(api.StoreHandler) {
 		block, err := api.StoreHandler.GetBlockByEpochNumber(ctx, &epoch, includeTxs)
 
@@ -216,7 +258,7 @@ func (api *cfxAPI) GetBlockByBlockNumber(
 	ctx context.Context, blockNumer hexutil.Uint64, includeTxs bool) (interface{}, error) {
 	metrics.Registry.RPC.Percentage("cfx_getBlockByBlockNumber", "details").Mark(includeTxs)
 
-	logger := logrus.WithFields(logrus.Fields{"blockNumber": blockNumer, "includeTxs": includeTxs})
+	logger := logging.FromContext(ctx).WithFields(logrus.Fields{"blockNumber": blockNumer, "includeTxs": includeTxs})
 
 	if !util.IsInterfaceValNil(api.StoreHandler) {
 		block, err := api.StoreHandler.GetBlockByBlockNumber(ctx, blockNumer, includeTxs)
@@ -249,15 +291,21 @@ func (api *cfxAPI) GetNextNonce(ctx context.Context, address types.Address, epoc
 	return api.stateHandler.GetNextNonce(ctx, cfx, address, toEpochOrBlockHashSlice(epoch)...)
 }
 
-func (api *cfxAPI) SendRawTransaction(ctx context.Context, signedTx hexutil.Bytes) (types.Hash, error) {
+func (api *cfxAPI) SendRawTransaction(ctx context.Context, signedTx hexutil.Bytes) (txHash types.Hash, err error) {
 	cfx := GetCfxClientFromContext(ctx)
 
 	if api.TxnHandler != nil {
 		cgroup := GetClientGroupFromContext(ctx)
-		return api.TxnHandler.SendRawTxn(cfx, cgroup, signedTx)
+		txHash, err = api.TxnHandler.SendRawTxn(cfx, cgroup, signedTx)
+	} else {
+		txHash, err = cfx.SendRawTransaction(signedTx)
 	}
 
-	return cfx.SendRawTransaction(signedTx)
+	if err == nil {
+		api.sentTxns.track(ctx, txHash.String())
+	}
+
+	return txHash, err
 }
 
 func (api *cfxAPI) Call(ctx context.Context, request types.CallRequest, epoch *types.EpochOrBlockHash) (hexutil.Bytes, error) {
@@ -304,7 +352,17 @@ func (api *cfxAPI) getLogs(
 }
 
 func (api *cfxAPI) GetTransactionByHash(ctx context.Context, txHash types.Hash) (*types.Transaction, error) {
-	logger := logrus.WithFields(logrus.Fields{"txHash": txHash})
+	logger := logging.FromContext(ctx).WithFields(logrus.Fields{"txHash": txHash})
+
+	// read your writes from the full node that accepted the transaction recently
+	if cfx, ok := api.sentTxns.cfxClient(ctx, txHash.String()); ok {
+		txn, err := cfx.GetTransactionByHash(txHash)
+		metrics.Registry.RPC.Percentage("cfx_getTransactionByHash", "readYourWrites").Mark(err == nil && txn != nil)
+
+		if err == nil && txn != nil {
+			return txn, nil
+		}
+	}
 
 	if !util.IsInterfaceValNil(api.StoreHandler) {
 		txn, err := api.StoreHandler.GetTransactionByHash(ctx, txHash)
@@ -338,7 +396,7 @@ func (api *cfxAPI) CheckBalanceAgainstTransaction(
 }
 
 func (api *cfxAPI) GetBlocksByEpoch(ctx context.Context, epoch types.Epoch) ([]types.Hash, error) {
-	logger := logrus.WithFields(logrus.Fields{"epoch": epoch})
+	logger := logging.FromContext(ctx).WithFields(logrus.Fields{"epoch": epoch})
 
 	cfx := GetCfxClientFromContext(ctx)
 	api.inputEpochMetric.Update(&epoch, "cfx_getBlocksByEpoch", cfx)
@@ -366,7 +424,17 @@ func (api *cfxAPI) GetSkippedBlocksByEpoch(ctx context.Context, epoch types.Epoc
 }
 
 func (api *cfxAPI) GetTransactionReceipt(ctx context.Context, txHash types.Hash) (*types.TransactionReceipt, error) {
-	logger := logrus.WithFields(logrus.Fields{"txHash": txHash})
+	logger := logging.FromContext(ctx).WithFields(logrus.Fields{"txHash": txHash})
+
+	// read your writes from the full node that accepted the transaction recently
+	if cfx, ok := api.sentTxns.cfxClient(ctx, txHash.String()); ok {
+		rcpt, err := cfx.GetTransactionReceipt(txHash)
+		metrics.Registry.RPC.Percentage("cfx_getTransactionReceipt", "readYourWrites").Mark(err == nil && rcpt != nil)
+
+		if err == nil && rcpt != nil {
+			return rcpt, nil
+		}
+	}
 
 	if !util.IsInterfaceValNil(api.StoreHandler) {
 		rcpt, err := api.StoreHandler.GetTransactionReceipt(ctx, txHash)
@@ -412,6 +480,15 @@ func (api *cfxAPI) GetConfirmationRiskByHash(ctx context.Context, blockHash type
 }
 
 func (api *cfxAPI) GetStatus(ctx context.Context) (types.Status, error) {
+	if api.HeadTracker != nil {
+		status, ok := api.HeadTracker.Status()
+		metrics.Registry.RPC.Percentage("cfx_getStatus", "headTracker").Mark(ok)
+
+		if ok {
+			return status, nil
+		}
+	}
+
 	cfx := GetCfxClientFromContext(ctx)
 	return cfx.GetStatus()
 }
@@ -471,8 +548,8 @@ func (api *cfxAPI) FeeHistory(
 		return nil, errTooManyBlocksForFeeHistory
 	}
 
-	if len(rewardPercentiles) > maxRewardPercentileCnt {
-		return nil, errTooManyRewardPercentiles
+	if err := validateRewardPercentiles(rewardPercentiles); err != nil {
+		return nil, err
 	}
 
 	cfx := GetCfxClientFromContext(ctx)
@@ -489,6 +566,79 @@ func (api *cfxAPI) GetFeeBurnt(ctx context.Context, epoch ...*types.Epoch) (info
 	return cfx.GetFeeBurnt(epoch...)
 }
 
+// GetAccountTransactions returns paginated hashes of transactions sent from the specified account,
+// which is only served from store since fullnode has no such index.
+func (api *cfxAPI) GetAccountTransactions(
+	ctx context.Context, address types.Address, paging *citypes.AccountTxnsPaging,
+) (*citypes.AccountTransactions, error) {
+	if util.IsInterfaceValNil(api.StoreHandler) {
+		return nil, store.ErrUnsupported
+	}
+
+	filter, err := newAccountTxnFilter(address, paging)
+	if err != nil {
+		return nil, err
+	}
+
+	txHashes, err := api.StoreHandler.GetAccountTransactions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &citypes.AccountTransactions{Hashes: txHashes}
+	if uint64(len(txHashes)) == filter.Limit && filter.Skip+filter.Limit <= maxAccountTxnsSkip {
+		nextSkip := hexutil.Uint64(filter.Skip + filter.Limit)
+		result.NextSkip = &nextSkip
+	}
+
+	return result, nil
+}
+
+// newAccountTxnFilter creates the filter to query account transactions with the paging validated.
+func newAccountTxnFilter(address types.Address, paging *citypes.AccountTxnsPaging) (store.AccountTxnFilter, error) {
+	filter := store.AccountTxnFilter{
+		Address: address.GetHexAddress(),
+		EpochTo: math.MaxUint64,
+		Limit:   defaultAccountTxnsPageSize,
+	}
+
+	if paging != nil {
+		if paging.FromEpoch != nil {
+			filter.EpochFrom = uint64(*paging.FromEpoch)
+		}
+
+		if paging.ToEpoch != nil {
+			filter.EpochTo = uint64(*paging.ToEpoch)
+		}
+
+		if paging.Skip != nil {
+			filter.Skip = uint64(*paging.Skip)
+		}
+
+		if paging.Limit != nil {
+			filter.Limit = uint64(*paging.Limit)
+		}
+	}
+
+	if filter.EpochFrom > filter.EpochTo {
+		return filter, ErrInvalidLogFilterEpochRange
+	}
+
+	if filter.Limit == 0 {
+		return filter, errZeroAccountTxnsPageSize
+	}
+
+	if filter.Limit > maxAccountTxnsPageSize {
+		return filter, errTooManyAccountTxnsPerPage
+	}
+
+	if filter.Skip > maxAccountTxnsSkip {
+		return filter, errTooManyAccountTxnsSkipped
+	}
+
+	return filter, nil
+}
+
 func (h *cfxAPI) collectHitStats(method string, hit bool) {
 	metrics.Registry.RPC.StoreHit(method, "store").Mark(hit)
 }
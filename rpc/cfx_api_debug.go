@@ -3,11 +3,27 @@ package rpc
 import (
 	"context"
 
+	"github.com/Conflux-Chain/confura/util"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
 )
 
 func (api *cfxAPI) GetEpochReceipts(
 	ctx context.Context, epoch types.EpochOrBlockHash, includeEthRecepits ...bool,
 ) (receipts [][]types.TransactionReceipt, err error) {
+	// only core space receipts of specified epoch number are available in store
+	includeEth := len(includeEthRecepits) > 0 && includeEthRecepits[0]
+	if !includeEth && !util.IsInterfaceValNil(api.StoreHandler) {
+		if e, ok := epoch.IsEpoch(); ok {
+			if epochNum, ok := e.ToInt(); ok {
+				receipts, err = api.StoreHandler.GetEpochReceipts(ctx, epochNum.Uint64())
+				api.collectHitStats("cfx_getEpochReceipts", err == nil)
+
+				if err == nil {
+					return receipts, nil
+				}
+			}
+		}
+	}
+
 	return GetCfxClientFromContext(ctx).GetEpochReceipts(epoch, includeEthRecepits...)
 }
@@ -35,7 +35,7 @@ func (api *cfxAPI) NewFilter(ctx context.Context, filterCrit types.LogFilter) (*
 	metrics.UpdateCfxRpcLogFilter(rpcMethodCfxNewFilter, cfx, &filterCrit)
 
 	if api.VirtualFilterClient != nil {
-		fid, err := api.VirtualFilterClient.NewFilter(cfx.GetNodeURL(), &filterCrit)
+		fid, err := api.VirtualFilterClient.NewFilter(ctx, cfx.GetNodeURL(), &filterCrit)
 		return fid, errVirtualFilterProxyErrorOrNil(err)
 	}
 
@@ -48,7 +48,7 @@ func (api *cfxAPI) NewBlockFilter(ctx context.Context) (*rpc.ID, error) {
 	cfx := GetCfxClientFromContext(ctx)
 
 	if api.VirtualFilterClient != nil {
-		fid, err := api.VirtualFilterClient.NewBlockFilter(cfx.GetNodeURL())
+		fid, err := api.VirtualFilterClient.NewBlockFilter(ctx, cfx.GetNodeURL())
 		return fid, errVirtualFilterProxyErrorOrNil(err)
 	}
 
@@ -64,7 +64,7 @@ func (api *cfxAPI) NewPendingTransactionFilter(ctx context.Context) (*rpc.ID, er
 	cfx := GetCfxClientFromContext(ctx)
 
 	if api.VirtualFilterClient != nil {
-		fid, err := api.VirtualFilterClient.NewPendingTransactionFilter(cfx.GetNodeURL())
+		fid, err := api.VirtualFilterClient.NewPendingTransactionFilter(ctx, cfx.GetNodeURL())
 		return fid, errVirtualFilterProxyErrorOrNil(err)
 	}
 
@@ -74,7 +74,7 @@ func (api *cfxAPI) NewPendingTransactionFilter(ctx context.Context) (*rpc.ID, er
 // UninstallFilter removes the filter with the given filter id.
 func (api *cfxAPI) UninstallFilter(ctx context.Context, fid rpc.ID) (bool, error) {
 	if api.VirtualFilterClient != nil {
-		ok, err := api.VirtualFilterClient.UninstallFilter(fid)
+		ok, err := api.VirtualFilterClient.UninstallFilter(ctx, fid)
 		return ok, errVirtualFilterProxyErrorOrNil(err)
 	}
 
@@ -89,7 +89,7 @@ func (api *cfxAPI) UninstallFilter(ctx context.Context, fid rpc.ID) (bool, error
 // (pending)Log filters return []types.CfxFilterLog.
 func (api *cfxAPI) GetFilterChanges(ctx context.Context, fid rpc.ID) (interface{}, error) {
 	if api.VirtualFilterClient != nil {
-		res, err := api.VirtualFilterClient.GetFilterChanges(fid)
+		res, err := api.VirtualFilterClient.GetFilterChanges(ctx, fid)
 		return res, errVirtualFilterProxyErrorOrNil(err)
 	}
 
@@ -105,14 +105,16 @@ func (api *cfxAPI) GetFilterLogs(ctx context.Context, fid rpc.ID) ([]types.Log,
 		return cfx.(*sdk.Client).Filter().GetFilterLogs(fid)
 	}
 
-	fq, err := api.VirtualFilterClient.GetLogFilter(fid)
+	fq, err := api.VirtualFilterClient.GetLogFilter(ctx, fid)
 	if err != nil {
 		return emptyLogs, errVirtualFilterProxyErrorOrNil(err)
 	}
 
-	cfx, err := api.provider.GetClientByIP(ctx, node.GroupCfxLogs)
+	// event logs are queried from the same node group as `cfx_getLogs`
+	grp := node.CfxMethodGroupOrDefault(rpcMethodCfxGetLogs, node.GroupCfxLogs)
+	cfx, err := api.provider.GetClientByAffinity(ctx, grp)
 	if err != nil {
-		return emptyLogs, errors.WithMessage(err, "failed to get client by ip")
+		return emptyLogs, errors.WithMessage(err, "failed to get client")
 	}
 
 	return api.getLogs(ctx, cfx, *fq, rpcMethodCfxGetFilterLogs)
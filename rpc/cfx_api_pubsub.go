@@ -4,13 +4,13 @@ import (
 	"context"
 
 	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/util/logging"
 	"github.com/Conflux-Chain/confura/util/metrics"
 	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
 	"github.com/openweb3/go-rpc-provider"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 )
 
 // PubSub notification
@@ -22,15 +22,20 @@ func (api *cfxAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 	psCtx, supported, err := api.pubsubCtxFromContext(ctx)
 
 	if !supported {
-		logrus.WithError(err).Error("NewHeads pubsub notification unsupported")
+		logging.FromContext(ctx).WithError(err).Error("NewHeads pubsub notification unsupported")
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
 
 	if err != nil {
-		logrus.WithError(err).Error("NewHeads pubsub context error")
+		logging.FromContext(ctx).WithError(err).Error("NewHeads pubsub context error")
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
+	release, err := rpcutil.AcquireWsSubscription(ctx)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
 	rpcSub := psCtx.notifier.CreateSubscription()
 
 	headersCh := make(chan *types.BlockHeader, pubsubChannelBufferSize)
@@ -38,13 +43,14 @@ func (api *cfxAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 
 	dSub, err := dClient.delegateSubscribeNewHeads(rpcSub.ID, headersCh)
 	api.etPubsubLogger.Log(
-		logrus.WithError(err), err, "Failed to delegate pubsub NewHeads",
+		logging.FromContext(ctx).WithError(err), err, "Failed to delegate pubsub NewHeads",
 	)
 	if err != nil {
+		release()
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
-	logger := logrus.WithField("rpcSubID", rpcSub.ID)
+	logger := logging.FromContext(ctx).WithField("rpcSubID", rpcSub.ID)
 
 	nodeName := rpcutil.Url2NodeName(psCtx.cfx.GetNodeURL())
 	counter := metrics.Registry.PubSub.Sessions("cfx", "new_heads", nodeName)
@@ -53,6 +59,7 @@ func (api *cfxAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 	go func() {
 		defer dSub.unsubscribe()
 		defer counter.Dec(1)
+		defer release()
 
 		for {
 			select {
@@ -91,15 +98,20 @@ func (api *cfxAPI) Epochs(ctx context.Context, subEpoch *types.Epoch) (*rpc.Subs
 
 	psCtx, supported, err := api.pubsubCtxFromContext(ctx)
 	if !supported {
-		logrus.WithError(err).Errorf("Epochs pubsub notification unsupported (%v)", subEpoch)
+		logging.FromContext(ctx).WithError(err).Errorf("Epochs pubsub notification unsupported (%v)", subEpoch)
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
 
 	if err != nil {
-		logrus.WithError(err).Errorf("Epochs pubsub context error (%v)", subEpoch)
+		logging.FromContext(ctx).WithError(err).Errorf("Epochs pubsub context error (%v)", subEpoch)
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
+	release, err := rpcutil.AcquireWsSubscription(ctx)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
 	rpcSub := psCtx.notifier.CreateSubscription()
 
 	epochsCh := make(chan *types.WebsocketEpochResponse, pubsubChannelBufferSize)
@@ -107,14 +119,15 @@ func (api *cfxAPI) Epochs(ctx context.Context, subEpoch *types.Epoch) (*rpc.Subs
 
 	dSub, err := dClient.delegateSubscribeEpochs(rpcSub.ID, epochsCh, *subEpoch)
 	api.etPubsubLogger.Log(
-		logrus.WithField("subEpoch", subEpoch),
+		logging.FromContext(ctx).WithField("subEpoch", subEpoch),
 		err, "Failed to delegate pubsub epochs subscription",
 	)
 	if err != nil {
+		release()
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
-	logger := logrus.WithField("rpcSubID", rpcSub.ID)
+	logger := logging.FromContext(ctx).WithField("rpcSubID", rpcSub.ID)
 
 	nodeName := rpcutil.Url2NodeName(psCtx.cfx.GetNodeURL())
 	counter := metrics.Registry.PubSub.Sessions("cfx", "epochs", nodeName)
@@ -123,6 +136,7 @@ func (api *cfxAPI) Epochs(ctx context.Context, subEpoch *types.Epoch) (*rpc.Subs
 	go func() {
 		defer dSub.unsubscribe()
 		defer counter.Dec(1)
+		defer release()
 
 		for {
 			select {
@@ -155,15 +169,20 @@ func (api *cfxAPI) Logs(ctx context.Context, filter types.LogFilter) (*rpc.Subsc
 
 	psCtx, supported, err := api.pubsubCtxFromContext(ctx)
 	if !supported {
-		logrus.WithError(err).Error("Logs pubsub notification unsupported")
+		logging.FromContext(ctx).WithError(err).Error("Logs pubsub notification unsupported")
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
 
 	if err != nil {
-		logrus.WithError(err).Error("Logs pubsub context error")
+		logging.FromContext(ctx).WithError(err).Error("Logs pubsub context error")
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
+	release, err := rpcutil.AcquireWsSubscription(ctx)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
 	rpcSub := psCtx.notifier.CreateSubscription()
 
 	logsCh := make(chan *types.SubscriptionLog, pubsubChannelBufferSize)
@@ -171,14 +190,15 @@ func (api *cfxAPI) Logs(ctx context.Context, filter types.LogFilter) (*rpc.Subsc
 
 	dSub, err := dClient.delegateSubscribeLogs(rpcSub.ID, logsCh, filter)
 	api.etPubsubLogger.Log(
-		logrus.WithField("filter", filter),
+		logging.FromContext(ctx).WithField("filter", filter),
 		err, "Failed to delegate pubsub logs subscription",
 	)
 	if err != nil {
+		release()
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
-	logger := logrus.WithField("rpcSubID", rpcSub.ID)
+	logger := logging.FromContext(ctx).WithField("rpcSubID", rpcSub.ID)
 
 	nodeName := rpcutil.Url2NodeName(psCtx.cfx.GetNodeURL())
 	counter := metrics.Registry.PubSub.Sessions("cfx", "logs", nodeName)
@@ -187,6 +207,7 @@ func (api *cfxAPI) Logs(ctx context.Context, filter types.LogFilter) (*rpc.Subsc
 	go func() {
 		defer dSub.unsubscribe()
 		defer counter.Dec(1)
+		defer release()
 
 		for {
 			select {
@@ -233,9 +254,9 @@ func (api *cfxAPI) pubsubCtxFromContext(ctx context.Context) (psCtx *pubsubConte
 		return
 	}
 
-	cfx, err := api.provider.GetClientByIP(ctx, node.GroupCfxWs)
+	cfx, err := api.provider.GetClientByAffinity(ctx, node.GroupCfxWs)
 	if err != nil {
-		err = errors.WithMessage(err, "failed to get cfx wsclient by ip")
+		err = errors.WithMessage(err, "failed to get cfx wsclient")
 		return
 	}
 
@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"math"
+	"testing"
+
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAccountTxnFilter(t *testing.T) {
+	address := cfxaddress.MustNewFromBase32("cfx:acckucyy5fhzknbxmeexwtaj3bxmeg25b2b50pta6v")
+	uint64Ptr := func(v uint64) *hexutil.Uint64 { return (*hexutil.Uint64)(&v) }
+
+	// defaults
+	filter, err := newAccountTxnFilter(address, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), filter.EpochFrom)
+	assert.Equal(t, uint64(math.MaxUint64), filter.EpochTo)
+	assert.Equal(t, uint64(defaultAccountTxnsPageSize), filter.Limit)
+
+	filter, err = newAccountTxnFilter(address, &citypes.AccountTxnsPaging{
+		FromEpoch: uint64Ptr(10), ToEpoch: uint64Ptr(20), Skip: uint64Ptr(maxAccountTxnsSkip), Limit: uint64Ptr(1),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(maxAccountTxnsSkip), filter.Skip)
+	assert.Equal(t, uint64(1), filter.Limit)
+
+	for _, paging := range []*citypes.AccountTxnsPaging{
+		{FromEpoch: uint64Ptr(20), ToEpoch: uint64Ptr(10)},
+		{Limit: uint64Ptr(0)},
+		{Limit: uint64Ptr(maxAccountTxnsPageSize + 1)},
+		{Skip: uint64Ptr(maxAccountTxnsSkip + 1)},
+	} {
+		_, err := newAccountTxnFilter(address, paging)
+		assert.Error(t, err)
+	}
+}
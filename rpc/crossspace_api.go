@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"context"
+	"math/big"
+
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+const (
+	rpcMethodCrossSpaceGetCalls = "crossspace_getCalls"
+)
+
+// crossSpaceAPI provides confura extension RPCs to map between core space and eSpace entities.
+type crossSpaceAPI struct {
+	cfx *cfxAPI
+}
+
+// GetMappedAddress returns the eSpace mapped address of core space address.
+func (api *crossSpaceAPI) GetMappedAddress(ctx context.Context, addr types.Address) (common.Address, error) {
+	return addr.GetMappedEVMSpaceAddress(), nil
+}
+
+// GetTransactionCalls returns the cross space calls of the core space transaction, or nil if
+// the transaction not executed yet.
+func (api *crossSpaceAPI) GetTransactionCalls(
+	ctx context.Context, txHash types.Hash,
+) ([]*citypes.CrossSpaceCall, error) {
+	receipt, err := api.cfx.GetTransactionReceipt(ctx, txHash)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+
+	var epochNum *hexutil.Big
+	if receipt.EpochNumber != nil {
+		epochNum = (*hexutil.Big)(new(big.Int).SetUint64(uint64(*receipt.EpochNumber)))
+	}
+
+	// receipt logs are not fulfilled with transaction info
+	logs := make([]types.Log, len(receipt.Logs))
+	for i := range receipt.Logs {
+		logs[i] = receipt.Logs[i]
+		logs[i].TransactionHash = &receipt.TransactionHash
+		logs[i].BlockHash = &receipt.BlockHash
+		logs[i].EpochNumber = epochNum
+	}
+
+	return citypes.DecodeCrossSpaceCalls(logs), nil
+}
+
+// GetCalls returns the cross space calls matching the given log filter, which are decoded from
+// event logs of internal contract `CrossSpaceCall`. Note, the address and topics of log filter
+// will be ignored.
+func (api *crossSpaceAPI) GetCalls(ctx context.Context, fq types.LogFilter) ([]*citypes.CrossSpaceCall, error) {
+	cfx := GetCfxClientFromContext(ctx)
+
+	networkId, err := cfx.GetNetworkID()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get network ID")
+	}
+
+	contract, err := cfxaddress.NewFromHex(citypes.CrossSpaceCallContract, networkId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid cross space call contract address")
+	}
+
+	// outcome events are required as well to decode cross space calls
+	fq.Address, fq.Topics = []types.Address{contract}, nil
+
+	logs, err := api.cfx.getLogs(ctx, cfx, fq, rpcMethodCrossSpaceGetCalls)
+	if err != nil {
+		return nil, err
+	}
+
+	return citypes.DecodeCrossSpaceCalls(logs), nil
+}
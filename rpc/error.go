@@ -1,10 +1,28 @@
 package rpc
 
 import (
+	"github.com/Conflux-Chain/confura/node"
 	"github.com/Conflux-Chain/confura/store"
+	rpcErrors "github.com/Conflux-Chain/confura/util/rpc/errors"
 	"github.com/pkg/errors"
 )
 
+func init() {
+	// map store and upstream errors into uniform error codes
+	rpcErrors.Register(rpcErrors.CodeUpstreamUnavailable, node.ErrClientUnavailable)
+	rpcErrors.Register(rpcErrors.CodeRangeTooLarge, store.ErrFilterQuerySetTooLarge, store.ErrFilterResultSetTooLarge)
+	rpcErrors.Register(rpcErrors.CodeReorged, store.ErrChainReorged, store.ErrEpochPivotSwitched)
+
+	rpcErrors.RegisterPatterns(
+		rpcErrors.CodeRangeTooLarge,
+		// e.g., query too expensive or response body too large of handlers
+		"please narrow down your filter condition",
+		// upstream full nodes
+		"query returned more than", "block range is too large", "exceed maximum block range",
+	)
+	rpcErrors.RegisterPatterns(rpcErrors.CodeReorged, "pivot switched", "chain reorg")
+}
+
 // rpc errors conform to fullnode
 
 var (
@@ -33,14 +51,14 @@ var (
 func ErrExceedLogFilterBlockHashLimit(size int) error {
 	return errors.Errorf(
 		"filter.block_hashes can contain up to %v hashes; %v were provided.",
-		store.MaxLogBlockHashesSize, size,
+		store.MaxLogBlockHashesSize(), size,
 	)
 }
 
 func ErrExceedLogFilterAddrLimit(size int) error {
 	return errors.Errorf(
 		"filter.address can contain up to %v addresses; %v were provided.",
-		store.MaxLogFilterAddrCount, size,
+		store.MaxLogFilterAddrCount(), size,
 	)
 }
 
@@ -51,6 +69,6 @@ func ErrExceedLogFilterTopicDimension(size int) error {
 func ErrExceedLogFilterTopicLimit(size int) error {
 	return errors.Errorf(
 		"filter.topics can contain up to  %v topics per dimension; %v were provided.",
-		store.MaxLogFilterTopicCount, size,
+		store.MaxLogFilterTopicCount(), size,
 	)
 }
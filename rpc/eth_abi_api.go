@@ -0,0 +1,35 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/rpc/handler"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+var (
+	errAbiRegistryUnavailable = errors.New("contract ABI registry not enabled")
+)
+
+// ethAbiAPI provides extension RPCs to decode event logs with the contract ABIs uploaded by admin,
+// so that explorer-style consumers needn't maintain ABIs on their own.
+type ethAbiAPI struct {
+	ethApi   *ethAPI
+	registry *handler.EthAbiRegistry // nil if not enabled
+}
+
+// GetDecodedLogs returns event logs matching the filter as `eth_getLogs`, along with the decoded
+// event name and arguments if the contract ABI is known.
+func (api *ethAbiAPI) GetDecodedLogs(ctx context.Context, fq web3Types.FilterQuery) ([]handler.EthDecodedLog, error) {
+	if api.registry == nil {
+		return nil, errAbiRegistryUnavailable
+	}
+
+	logs, err := api.ethApi.GetLogs(ctx, fq)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.registry.DecodeLogs(logs)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/Conflux-Chain/confura/rpc/handler"
 	"github.com/Conflux-Chain/confura/store"
 	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/logging"
 	"github.com/Conflux-Chain/confura/util/metrics"
 	vfclient "github.com/Conflux-Chain/confura/virtualfilter/client"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
@@ -46,11 +47,43 @@ var (
 	errNoMatchingReceiptFound = errors.New("no matching receipts found: this may indicate potential data corruption")
 )
 
+// validateRewardPercentiles validates the reward percentiles of fee history, which must be within
+// [0, 100] in ascending order, and bounded in number.
+func validateRewardPercentiles(percentiles []float64) error {
+	if len(percentiles) > maxRewardPercentileCnt {
+		return errTooManyRewardPercentiles
+	}
+
+	for i, p := range percentiles {
+		if p < 0 || p > 100 {
+			return errors.Errorf("invalid reward percentile: %v", p)
+		}
+
+		if i > 0 && p < percentiles[i-1] {
+			return errors.Errorf("invalid reward percentile: #%d:%v > #%d:%v", i-1, percentiles[i-1], i, p)
+		}
+	}
+
+	return nil
+}
+
 type EthAPIOption struct {
 	StoreHandler        *handler.EthStoreHandler
 	LogApiHandler       *handler.EthLogsApiHandler
 	TxnHandler          *handler.EthTxnHandler
 	VirtualFilterClient *vfclient.EthClient
+	GasOracle           *handler.EthGasOracle
+	Txpool              *handler.EthTxpoolAggregator
+	TraceCache          *handler.EthTraceCache
+	HeadTracker         *handler.EthHeadTracker
+	FinalityResolver    *handler.EthFinalityResolver
+	BlockCache          *handler.EthBlockCache
+	SyncingAggregator   *handler.EthSyncingAggregator
+	LazyFilters         *handler.EthLazyLogFilters
+	AbiRegistry         *handler.EthAbiRegistry
+	ReorgStore          ReorgEventStore
+	TokenStore          TokenTransferStore
+	InternalTxStore     InternalTxStore
 }
 
 // ethAPI provides Ethereum relative API within evm space according to:
@@ -65,6 +98,18 @@ type ethAPI struct {
 
 	// return empty data before eSpace hardfork block number
 	hardforkBlockNumber web3Types.BlockNumber
+
+	// extension modes of block or pending transaction filters, which are loaded from the virtual
+	// filter service (if enabled) on missed so as to be shared among RPC proxy instances
+	extFilterModes *util.ExpirableLruCache
+
+	// settings of `logsStream` subscription
+	logStream ethLogStreamConfig
+	// settings to replay the missed event logs for `logs` subscription
+	logsReplay ethLogsReplayConfig
+
+	// routes reads of recently sent transactions to the accepting full node, nil if disabled
+	sentTxns *sentTxnRouter
 }
 
 func mustNewEthAPI(provider *node.EthClientProvider, option ...EthAPIOption) *ethAPI {
@@ -90,9 +135,13 @@ func mustNewEthAPI(provider *node.EthClientProvider, option ...EthAPIOption) *et
 	return &ethAPI{
 		EthAPIOption:        opt,
 		provider:            provider,
-		stateHandler:        handler.NewEthStateHandler(provider),
+		stateHandler:        handler.MustNewEthStateHandlerFromViper(provider, opt.HeadTracker),
 		etPubsubLogger:      logutil.NewErrorTolerantLogger(logutil.DefaultETConfig),
 		hardforkBlockNumber: util.GetEthHardforkBlockNumber(*chainId),
+		extFilterModes:      util.NewExpirableLruCache(maxExtFilterModes, extFilterModeTTL),
+		logStream:           mustNewEthLogStreamConfigFromViper(),
+		logsReplay:          mustNewEthLogsReplayConfigFromViper(),
+		sentTxns:            mustNewSentTxnRouterFromViper("ethrpc.readYourWrites"),
 	}
 }
 
@@ -103,10 +152,19 @@ func (api *ethAPI) GetBlockByHash(
 ) (*web3Types.Block, error) {
 	metrics.Registry.RPC.Percentage("eth_getBlockByHash", "fullTx").Mark(fullTx)
 
-	logger := logrus.WithFields(logrus.Fields{
+	logger := logging.FromContext(ctx).WithFields(logrus.Fields{
 		"blockHash": blockHash.Hex(), "includeTxs": fullTx,
 	})
 
+	if api.BlockCache != nil {
+		block, ok := api.BlockCache.BlockByHash(blockHash, fullTx)
+		metrics.Registry.RPC.Percentage("eth_getBlockByHash", "blockCache").Mark(ok)
+
+		if ok {
+			return block, nil
+		}
+	}
+
 	if !store.EthStoreConfig().IsChainBlockDisabled() && !util.IsInterfaceValNil(api.StoreHandler) {
 		block, err := api.StoreHandler.GetBlockByHash(ctx, blockHash, fullTx)
 		metrics.Registry.RPC.StoreHit("eth_getBlockByHash", "store").Mark(err == nil)
@@ -132,6 +190,15 @@ func (api *ethAPI) ChainId(ctx context.Context) (*hexutil.Uint64, error) {
 
 // BlockNumber returns the block number of the chain head.
 func (api *ethAPI) BlockNumber(ctx context.Context) (*hexutil.Big, error) {
+	if api.HeadTracker != nil {
+		blockNum, ok := api.HeadTracker.BlockNumber()
+		metrics.Registry.RPC.Percentage("eth_blockNumber", "headTracker").Mark(ok)
+
+		if ok {
+			return blockNum, nil
+		}
+	}
+
 	w3c := GetEthClientFromContext(ctx)
 	blockNum, err := w3c.Eth.BlockNumber()
 	return (*hexutil.Big)(blockNum), err
@@ -158,13 +225,35 @@ func (api *ethAPI) GetBlockByNumber(
 ) (interface{}, error) {
 	metrics.Registry.RPC.Percentage("eth_getBlockByNumber", "fullTx").Mark(fullTx)
 
-	logger := logrus.WithFields(logrus.Fields{
+	logger := logging.FromContext(ctx).WithFields(logrus.Fields{
 		"blockNum": blockNum, "includeTxs": fullTx,
 	})
 
 	w3c := GetEthClientFromContext(ctx)
 	api.inputBlockMetric.Update1(&blockNum, "eth_getBlockByNumber", w3c.Eth)
 
+	if api.HeadTracker != nil && !fullTx && isEthHeadTag(blockNum) {
+		header, ok := api.HeadTracker.Header(blockNum)
+		metrics.Registry.RPC.Percentage("eth_getBlockByNumber", "headTracker").Mark(ok)
+
+		if ok {
+			return header, nil
+		}
+	}
+
+	if err := api.resolveFinalityTag(w3c, &blockNum); err != nil {
+		return nil, err
+	}
+
+	if api.BlockCache != nil && blockNum >= 0 {
+		block, ok := api.BlockCache.BlockByNumber(uint64(blockNum), fullTx)
+		metrics.Registry.RPC.Percentage("eth_getBlockByNumber", "blockCache").Mark(ok)
+
+		if ok {
+			return block, nil
+		}
+	}
+
 	if !store.EthStoreConfig().IsChainBlockDisabled() && !util.IsInterfaceValNil(api.StoreHandler) {
 		block, err := api.StoreHandler.GetBlockByNumber(ctx, &blockNum, fullTx)
 		metrics.Registry.RPC.StoreHit("eth_getBlockByNumber", "store").Mark(err == nil)
@@ -185,6 +274,31 @@ func (api *ethAPI) GetBlockByNumber(
 	return lazyBlock, nil
 }
 
+// resolveFinalityTag resolves `safe` or `finalized` block tag to concrete block number if necessary.
+func (api *ethAPI) resolveFinalityTag(w3c *node.Web3goClient, blockNum *web3Types.BlockNumber) error {
+	if blockNum == nil || api.FinalityResolver == nil || !handler.IsFinalityTag(*blockNum) {
+		return nil
+	}
+
+	resolved, err := api.FinalityResolver.Resolve(w3c.Client, *blockNum)
+	if err != nil {
+		return err
+	}
+
+	*blockNum = resolved
+	return nil
+}
+
+// isEthHeadTag checks if the block number is a chain head tag tracked by head tracker.
+func isEthHeadTag(blockNum web3Types.BlockNumber) bool {
+	switch blockNum {
+	case web3Types.LatestBlockNumber, web3Types.SafeBlockNumber, web3Types.FinalizedBlockNumber:
+		return true
+	default:
+		return false
+	}
+}
+
 // GetUncleByBlockNumberAndIndex returns the uncle block for the given block hash and index.
 func (api *ethAPI) GetUncleByBlockNumberAndIndex(
 	ctx context.Context, blockNr web3Types.BlockNumber, index hexutil.Uint,
@@ -221,6 +335,17 @@ func (api *ethAPI) ProtocolVersion(ctx context.Context) (string, error) {
 
 // GasPrice returns the current gas price in wei.
 func (api *ethAPI) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+	if api.GasOracle != nil {
+		gasPrice, err := api.GasOracle.GasPrice(ctx)
+		metrics.Registry.RPC.Percentage("eth_gasPrice", "gasOracle").Mark(err == nil)
+
+		if err == nil {
+			return (*hexutil.Big)(gasPrice), nil
+		}
+
+		logging.FromContext(ctx).WithError(err).Debug("Gas oracle failed to suggest gas price")
+	}
+
 	w3c := GetEthClientFromContext(ctx)
 	gasPrice, err := w3c.Eth.GasPrice()
 	return (*hexutil.Big)(gasPrice), err
@@ -260,15 +385,21 @@ func (api *ethAPI) GetTransactionCount(
 //
 // If the transaction was a contract creation use the TransactionReceipt method to get the
 // contract address after the transaction has been mined.
-func (api *ethAPI) SendRawTransaction(ctx context.Context, signedTx hexutil.Bytes) (common.Hash, error) {
+func (api *ethAPI) SendRawTransaction(ctx context.Context, signedTx hexutil.Bytes) (txHash common.Hash, err error) {
 	w3c := GetEthClientFromContext(ctx)
 
 	if api.TxnHandler != nil {
 		cgroup := GetClientGroupFromContext(ctx)
-		return api.TxnHandler.SendRawTxn(w3c, cgroup, signedTx)
+		txHash, err = api.TxnHandler.SendRawTxn(w3c, cgroup, signedTx)
+	} else {
+		txHash, err = w3c.Eth.SendRawTransaction(signedTx)
+	}
+
+	if err == nil {
+		api.sentTxns.track(ctx, txHash.Hex())
 	}
 
-	return w3c.Eth.SendRawTransaction(signedTx)
+	return txHash, err
 }
 
 // SubmitTransaction is an alias of `SendRawTransaction` method.
@@ -300,7 +431,26 @@ func (api *ethAPI) EstimateGas(
 
 // TransactionByHash returns the transaction with the given hash.
 func (api *ethAPI) GetTransactionByHash(ctx context.Context, hash common.Hash) (*web3Types.TransactionDetail, error) {
-	logger := logrus.WithField("txHash", hash.Hex())
+	logger := logging.FromContext(ctx).WithField("txHash", hash.Hex())
+
+	// read your writes from the full node that accepted the transaction recently
+	if w3c, ok := api.sentTxns.ethClient(ctx, hash.Hex()); ok {
+		tx, err := w3c.Eth.TransactionByHash(hash)
+		metrics.Registry.RPC.Percentage("eth_getTransactionByHash", "readYourWrites").Mark(err == nil && tx != nil)
+
+		if err == nil && tx != nil {
+			return tx, nil
+		}
+	}
+
+	if api.BlockCache != nil {
+		tx, ok := api.BlockCache.TransactionByHash(hash)
+		metrics.Registry.RPC.Percentage("eth_getTransactionByHash", "blockCache").Mark(ok)
+
+		if ok {
+			return tx, nil
+		}
+	}
 
 	if !store.EthStoreConfig().IsChainTxnDisabled() && !util.IsInterfaceValNil(api.StoreHandler) {
 		tx, err := api.StoreHandler.GetTransactionByHash(ctx, hash)
@@ -328,20 +478,30 @@ func (api *ethAPI) GetTransactionReceipt(ctx context.Context, txHash common.Hash
 		}
 	}()
 
+	// read your writes from the full node that accepted the transaction recently
+	if w3c, ok := api.sentTxns.ethClient(ctx, txHash.Hex()); ok {
+		receipt, err := w3c.Eth.TransactionReceipt(txHash)
+		metrics.Registry.RPC.Percentage("eth_getTransactionReceipt", "readYourWrites").Mark(err == nil && receipt != nil)
+
+		if err == nil && receipt != nil {
+			return receipt, nil
+		}
+	}
+
 	if !store.EthStoreConfig().IsChainReceiptDisabled() && !util.IsInterfaceValNil(api.StoreHandler) {
 		receipt, err = api.StoreHandler.GetTransactionReceipt(ctx, txHash)
 		metrics.Registry.RPC.StoreHit("eth_getTransactionReceipt", "store").Mark(err == nil)
 		if err == nil {
-			logrus.WithField("txHash", txHash.Hex()).
+			logging.FromContext(ctx).WithField("txHash", txHash.Hex()).
 				Debug("Loading eth data for eth_getTransactionReceipt hit in the ethstore")
 			return receipt, nil
 		}
 
-		logrus.WithField("txHash", txHash.Hex()).WithError(err).
+		logging.FromContext(ctx).WithField("txHash", txHash.Hex()).WithError(err).
 			Debug("Loading eth data for eth_getTransactionReceipt missed from the ethstore")
 	}
 
-	logrus.WithField("txHash", txHash.Hex()).Debug("Delegating eth_getTransactionReceipt rpc request to fullnode")
+	logging.FromContext(ctx).WithField("txHash", txHash.Hex()).Debug("Delegating eth_getTransactionReceipt rpc request to fullnode")
 
 	w3c := GetEthClientFromContext(ctx)
 	receipt, err = w3c.Eth.TransactionReceipt(txHash)
@@ -400,6 +560,14 @@ func (api *ethAPI) GetTransactionReceipt(ctx context.Context, txHash common.Hash
 func (api *ethAPI) GetBlockReceipts(
 	ctx context.Context, blockNrOrHash *web3Types.BlockNumberOrHash,
 ) ([]*web3Types.Receipt, error) {
+	if !store.EthStoreConfig().IsChainReceiptDisabled() && !util.IsInterfaceValNil(api.StoreHandler) {
+		receipts, err := api.StoreHandler.GetBlockReceipts(ctx, blockNrOrHash)
+		metrics.Registry.RPC.StoreHit("eth_getBlockReceipts", "store").Mark(err == nil)
+		if err == nil {
+			return receipts, nil
+		}
+	}
+
 	w3c := GetEthClientFromContext(ctx)
 	receipts, err := w3c.Eth.BlockReceipts(blockNrOrHash)
 	if err != nil {
@@ -485,6 +653,10 @@ func (api *ethAPI) getLogs(
 		return ethEmptyLogs, ErrInvalidEthLogFilter
 	}
 
+	if err := api.resolveFilterFinalityTags(w3c, fq); err != nil {
+		return ethEmptyLogs, err
+	}
+
 	if err := NormalizeEthLogFilter(w3c.Client, flag, fq, api.hardforkBlockNumber); err != nil {
 		return ethEmptyLogs, err
 	}
@@ -508,6 +680,17 @@ func (api *ethAPI) getLogs(
 	return w3c.Eth.Logs(*fq)
 }
 
+// resolveFilterFinalityTags resolves `safe` or `finalized` block tags of log filter.
+func (api *ethAPI) resolveFilterFinalityTags(w3c *node.Web3goClient, fq *web3Types.FilterQuery) error {
+	for _, bn := range []*web3Types.BlockNumber{fq.FromBlock, fq.ToBlock} {
+		if err := api.resolveFinalityTag(w3c, bn); err != nil {
+			return errors.WithMessage(err, "failed to resolve finality block tag")
+		}
+	}
+
+	return nil
+}
+
 // GetBlockTransactionCountByHash returns the total number of transactions in the given block.
 func (api *ethAPI) GetBlockTransactionCountByHash(ctx context.Context, blockHash common.Hash) (*hexutil.Big, error) {
 	w3c := GetEthClientFromContext(ctx)
@@ -528,7 +711,16 @@ func (api *ethAPI) GetBlockTransactionCountByNumber(ctx context.Context, blockNu
 
 // Syncing returns an object with data about the sync status or false.
 // https://openethereum.github.io/JSONRPC-eth-module#eth_syncing
-func (api *ethAPI) Syncing(ctx context.Context) (web3Types.SyncStatus, error) {
+func (api *ethAPI) Syncing(ctx context.Context) (interface{}, error) {
+	if api.SyncingAggregator != nil {
+		result, ok := api.SyncingAggregator.Syncing()
+		metrics.Registry.RPC.Percentage("eth_syncing", "aggregated").Mark(ok)
+
+		if ok {
+			return result, nil
+		}
+	}
+
 	w3c := GetEthClientFromContext(ctx)
 	return w3c.Eth.Syncing()
 }
@@ -556,6 +748,17 @@ func (api *ethAPI) Mining(ctx context.Context) (bool, error) {
 // MaxPriorityFeePerGas returns a fee per gas that is an estimate of how much you can pay as
 // a priority fee, or "tip", to get a transaction included in the current block.
 func (api *ethAPI) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	if api.GasOracle != nil {
+		priorityFee, err := api.GasOracle.MaxPriorityFeePerGas(ctx)
+		metrics.Registry.RPC.Percentage("eth_maxPriorityFeePerGas", "gasOracle").Mark(err == nil)
+
+		if err == nil {
+			return (*hexutil.Big)(priorityFee), nil
+		}
+
+		logging.FromContext(ctx).WithError(err).Debug("Gas oracle failed to suggest max priority fee per gas")
+	}
+
 	w3c := GetEthClientFromContext(ctx)
 	priorityFee, err := w3c.Eth.MaxPriorityFeePerGas()
 	return (*hexutil.Big)(priorityFee), err
@@ -611,8 +814,19 @@ func (api *ethAPI) FeeHistory(
 		return nil, errTooManyBlocksForFeeHistory
 	}
 
-	if len(rewardPercentiles) > maxRewardPercentileCnt {
-		return nil, errTooManyRewardPercentiles
+	if err := validateRewardPercentiles(rewardPercentiles); err != nil {
+		return nil, err
+	}
+
+	if api.GasOracle != nil {
+		val, err = api.GasOracle.FeeHistory(ctx, uint64(blockCount), lastBlock, rewardPercentiles)
+		metrics.Registry.RPC.Percentage("eth_feeHistory", "gasOracle").Mark(err == nil)
+
+		if err == nil {
+			return val, nil
+		}
+
+		logging.FromContext(ctx).WithError(err).Debug("Gas oracle failed to get fee history")
 	}
 
 	w3c := GetEthClientFromContext(ctx)
@@ -2,17 +2,30 @@ package rpc
 
 import (
 	"context"
+	"time"
 
 	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/rpc/handler"
+	citypes "github.com/Conflux-Chain/confura/types"
 	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/confura/util/rpc/cache"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/openweb3/go-rpc-provider"
 	web3Types "github.com/openweb3/web3go/types"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 const (
 	rpcMethodEthNewFilter     = "eth_newFilter"
 	rpcMethodEthGetFilterLogs = "eth_getFilterLogs"
+
+	rpcMethodEthGetFilterChanges = "eth_getFilterChanges"
+
+	// max number of block (or pending transaction) filter extension modes to cache
+	maxExtFilterModes = 10_000
+	// expiration duration of cached filter extension modes since last polling
+	extFilterModeTTL = 5 * time.Minute
 )
 
 func isEthFilterRpcMethod(method string) bool {
@@ -46,25 +59,69 @@ func (api *ethAPI) NewFilter(ctx context.Context, fq web3Types.FilterQuery) (*rp
 	w3c := GetEthClientFromContext(ctx)
 	metrics.UpdateEthRpcLogFilter(rpcMethodEthNewFilter, w3c.Eth, &fq)
 
+	// `safe` or `finalized` block tags are resolved once the filter created
+	if err := api.resolveFilterFinalityTags(w3c, &fq); err != nil {
+		return nil, err
+	}
+
+	if fq.FromBlock != nil && fq.ToBlock != nil && *fq.FromBlock >= 0 && *fq.FromBlock > *fq.ToBlock {
+		return nil, ErrInvalidLogFilterBlockRange
+	}
+
+	// log filters of bounded block range are served from store until near head blocks required
+	if api.LazyFilters != nil && handler.IsLazyFilter(&fq) {
+		fid, ok := api.LazyFilters.NewFilter(fq)
+		metrics.Registry.RPC.Percentage(rpcMethodEthNewFilter, "lazy").Mark(ok)
+
+		if ok {
+			return &fid, nil
+		}
+	}
+
+	return api.newDelegateFilter(ctx, w3c, &fq)
+}
+
+// newDelegateFilter creates the log filter on virtual filter service if enabled, otherwise on
+// full node.
+func (api *ethAPI) newDelegateFilter(
+	ctx context.Context, w3c *node.Web3goClient, fq *web3Types.FilterQuery,
+) (*rpc.ID, error) {
 	if api.VirtualFilterClient != nil {
-		fid, err := api.VirtualFilterClient.NewFilter(w3c.URL, &fq)
+		fid, err := api.VirtualFilterClient.NewFilter(ctx, w3c.URL, fq)
 		return fid, errVirtualFilterProxyErrorOrNil(err)
 	}
 
-	return w3c.Filter.NewLogFilter(&fq)
+	return w3c.Filter.NewLogFilter(fq)
 }
 
 // NewBlockFilter creates a filter that fetches blocks that are imported into the chain.
 // It is part of the filter package since polling goes with eth_getFilterChanges.
-func (api *ethAPI) NewBlockFilter(ctx context.Context) (*rpc.ID, error) {
+//
+// As a confura extension, block header summaries rather than only block hashes will be
+// returned for filter changes if `includeHeaders` option specified.
+//
+// Note, extension mode is persisted on virtual filter service (if enabled) along with the filter,
+// so that filter changes could be polled from any RPC proxy instance.
+func (api *ethAPI) NewBlockFilter(ctx context.Context, opt *citypes.BlockFilterOption) (fid *rpc.ID, err error) {
 	w3c := GetEthClientFromContext(ctx)
 
+	extMode := citypes.FilterExtModeNone
+	if opt != nil && opt.IncludeHeaders {
+		extMode = citypes.FilterExtModeBlockHeaders
+	}
+
 	if api.VirtualFilterClient != nil {
-		fid, err := api.VirtualFilterClient.NewBlockFilter(w3c.URL)
-		return fid, errVirtualFilterProxyErrorOrNil(err)
+		fid, err = api.VirtualFilterClient.NewBlockFilter(ctx, w3c.URL, extMode)
+		err = errVirtualFilterProxyErrorOrNil(err)
+	} else {
+		fid, err = w3c.Filter.NewBlockFilter()
+	}
+
+	if err == nil && fid != nil {
+		api.extFilterModes.Add(*fid, extMode)
 	}
 
-	return w3c.Filter.NewBlockFilter()
+	return fid, err
 }
 
 // NewPendingTransactionFilter creates a filter that fetches pending transaction hashes
@@ -72,21 +129,83 @@ func (api *ethAPI) NewBlockFilter(ctx context.Context) (*rpc.ID, error) {
 //
 // It is part of the filter package because this filter can be used through the
 // `eth_getFilterChanges` polling method that is also used for log filters.
-func (api *ethAPI) NewPendingTransactionFilter(ctx context.Context) (*rpc.ID, error) {
+//
+// Like geth, full transactions rather than only transaction hashes will be returned for
+// filter changes if `fullTx` specified, which are fetched from full node in a single batch.
+func (api *ethAPI) NewPendingTransactionFilter(ctx context.Context, fullTx *bool) (fid *rpc.ID, err error) {
 	w3c := GetEthClientFromContext(ctx)
 
+	extMode := citypes.FilterExtModeNone
+	if fullTx != nil && *fullTx {
+		extMode = citypes.FilterExtModeFullTx
+	}
+
 	if api.VirtualFilterClient != nil {
-		fid, err := api.VirtualFilterClient.NewPendingTransactionFilter(w3c.URL)
-		return fid, errVirtualFilterProxyErrorOrNil(err)
+		fid, err = api.VirtualFilterClient.NewPendingTransactionFilter(ctx, w3c.URL, extMode)
+		err = errVirtualFilterProxyErrorOrNil(err)
+	} else {
+		fid, err = w3c.Filter.NewPendingTransactionFilter()
+	}
+
+	if err == nil && fid != nil {
+		api.extFilterModes.Add(*fid, extMode)
 	}
 
-	return w3c.Filter.NewPendingTransactionFilter()
+	return fid, err
+}
+
+// getFilterExtMode returns the extension mode of block or pending transaction filter, which is
+// loaded from virtual filter service (if enabled) on missed, e.g., filter created on another RPC
+// proxy instance. Expiration is refreshed on every polling.
+func (api *ethAPI) getFilterExtMode(ctx context.Context, fid rpc.ID) citypes.FilterExtMode {
+	if v, ok := api.extFilterModes.Get(fid); ok {
+		api.extFilterModes.Add(fid, v)
+		return v.(citypes.FilterExtMode)
+	}
+
+	if api.VirtualFilterClient == nil {
+		return citypes.FilterExtModeNone
+	}
+
+	extMode, err := api.VirtualFilterClient.GetFilterExtMode(ctx, fid)
+	if err != nil {
+		logrus.WithField("fid", fid).
+			WithError(err).
+			Debug("Failed to get filter extension mode from virtual filter service")
+		return citypes.FilterExtModeNone
+	}
+
+	api.extFilterModes.Add(fid, extMode)
+	return extMode
 }
 
 // UninstallFilter removes the filter with the given filter id.
 func (api *ethAPI) UninstallFilter(ctx context.Context, fid rpc.ID) (bool, error) {
+	api.extFilterModes.Del(fid)
+
+	if api.LazyFilters != nil {
+		if delegate, ok := api.LazyFilters.UninstallFilter(fid); ok {
+			if delegate != nil {
+				// delegate filter will expire on upstream anyway if failed to uninstall
+				if _, err := api.uninstallDelegateFilter(ctx, *delegate); err != nil {
+					logrus.WithField("delegate", *delegate).
+						WithError(err).
+						Debug("Failed to uninstall delegate filter of lazy log filter")
+				}
+			}
+
+			return true, nil
+		}
+	}
+
+	return api.uninstallDelegateFilter(ctx, fid)
+}
+
+// uninstallDelegateFilter removes the filter from virtual filter service if enabled, otherwise
+// from full node.
+func (api *ethAPI) uninstallDelegateFilter(ctx context.Context, fid rpc.ID) (bool, error) {
 	if api.VirtualFilterClient != nil {
-		ok, err := api.VirtualFilterClient.UninstallFilter(fid)
+		ok, err := api.VirtualFilterClient.UninstallFilter(ctx, fid)
 		return ok, errVirtualFilterProxyErrorOrNil(err)
 	}
 
@@ -98,34 +217,198 @@ func (api *ethAPI) UninstallFilter(ctx context.Context, fid rpc.ID) (bool, error
 // last time it was called. This can be used for polling.
 //
 // For pending transaction and block filters the result is []common.Hash.
-// (pending) Log filters return []Log.
+// (pending) Log filters return []Log. Block filters in extended mode return
+// []BlockHeaderSummary instead, and pending transaction filters in full
+// transaction mode return []TransactionDetail.
 func (api *ethAPI) GetFilterChanges(ctx context.Context, fid rpc.ID) (interface{}, error) {
+	w3c := GetEthClientFromContext(ctx)
+
+	if api.LazyFilters != nil && api.LazyFilters.HasFilter(fid) {
+		delegate := &ethLazyFilterDelegate{ctx: ctx, api: api, w3c: w3c}
+		getLogs := func(fq *web3Types.FilterQuery) ([]web3Types.Log, error) {
+			return api.getLogs(ctx, w3c, fq, rpcMethodEthGetFilterChanges)
+		}
+
+		return api.LazyFilters.GetFilterChanges(fid, delegate, getLogs)
+	}
+
+	res, err := api.getDelegateFilterChanges(ctx, w3c, fid)
+	if err != nil || res == nil {
+		return res, err
+	}
+
+	// only block or pending transaction filter changes could be extended
+	if len(res.Logs) > 0 || len(res.Hashes) == 0 {
+		return res, nil
+	}
+
+	switch api.getFilterExtMode(ctx, fid) {
+	case citypes.FilterExtModeFullTx:
+		return api.loadPendingTransactions(ctx, w3c, res.Hashes)
+	case citypes.FilterExtModeBlockHeaders:
+		return summarizeBlockHeaders(ctx, w3c, res.Hashes), nil
+	default:
+		return res, nil
+	}
+}
+
+// getDelegateFilterChanges polls the filter changes from virtual filter service if enabled,
+// otherwise from full node.
+func (api *ethAPI) getDelegateFilterChanges(
+	ctx context.Context, w3c *node.Web3goClient, fid rpc.ID,
+) (*web3Types.FilterChanges, error) {
 	if api.VirtualFilterClient != nil {
-		res, err := api.VirtualFilterClient.GetFilterChanges(fid)
+		res, err := api.VirtualFilterClient.GetFilterChanges(ctx, fid)
 		return res, errVirtualFilterProxyErrorOrNil(err)
 	}
 
-	w3c := GetEthClientFromContext(ctx)
 	return w3c.Filter.GetFilterChanges(fid)
 }
 
+// ethLazyFilterDelegate creates and polls the delegate filter of lazy log filter on upstream.
+type ethLazyFilterDelegate struct {
+	ctx context.Context
+	api *ethAPI
+	w3c *node.Web3goClient
+}
+
+func (d *ethLazyFilterDelegate) BlockNumber() (uint64, error) {
+	bn, err := d.w3c.Eth.BlockNumber()
+	if err != nil {
+		return 0, err
+	}
+
+	return bn.Uint64(), nil
+}
+
+func (d *ethLazyFilterDelegate) NewFilter(crit *web3Types.FilterQuery) (*rpc.ID, error) {
+	return d.api.newDelegateFilter(d.ctx, d.w3c, crit)
+}
+
+func (d *ethLazyFilterDelegate) GetFilterChanges(fid rpc.ID) (*web3Types.FilterChanges, error) {
+	return d.api.getDelegateFilterChanges(d.ctx, d.w3c, fid)
+}
+
+// loadPendingTransactions loads the full transactions from full node in a single batch for pending
+// transaction filter changes in full transaction mode. Transactions not found (e.g., dropped from
+// txpool) are ignored.
+func (api *ethAPI) loadPendingTransactions(
+	ctx context.Context, w3c *node.Web3goClient, txHashes []common.Hash,
+) ([]*web3Types.TransactionDetail, error) {
+	if len(txHashes) == 0 {
+		return []*web3Types.TransactionDetail{}, nil
+	}
+
+	batchElems := make([]rpc.BatchElem, 0, len(txHashes))
+	for _, txHash := range txHashes {
+		batchElems = append(batchElems, rpc.BatchElem{
+			Method: "eth_getTransactionByHash",
+			Args:   []interface{}{txHash},
+			Result: new(web3Types.TransactionDetail),
+		})
+	}
+
+	if err := w3c.Provider().BatchCallContext(ctx, batchElems); err != nil {
+		return nil, errors.WithMessage(err, "failed to batch get pending transactions")
+	}
+
+	txns := make([]*web3Types.TransactionDetail, 0, len(batchElems))
+	for i := range batchElems {
+		txn := batchElems[i].Result.(*web3Types.TransactionDetail)
+		if batchElems[i].Error != nil || txn.Hash == (common.Hash{}) {
+			logrus.WithField("txHash", txHashes[i]).
+				WithError(batchElems[i].Error).
+				Debug("Failed to load pending transaction for filter changes")
+			continue
+		}
+
+		txns = append(txns, txn)
+	}
+
+	return txns, nil
+}
+
+// summarizeBlockHeaders loads block header summaries for block filter changes in extended mode,
+// where block headers missed in cache are loaded from full node in a single batch. If failed to
+// load, summary with block hash only will be returned, so that consumer could still follow up as
+// usual.
+func summarizeBlockHeaders(
+	ctx context.Context, w3c *node.Web3goClient, blockHashes []common.Hash,
+) []*citypes.BlockHeaderSummary {
+	summaries := make([]*citypes.BlockHeaderSummary, len(blockHashes))
+
+	var batchElems []rpc.BatchElem
+	var batchIndices []int
+
+	for i, bh := range blockHashes {
+		if block, ok := cache.EthDefault.PeekBlockHeader(bh); ok {
+			summaries[i] = citypes.NewBlockHeaderSummary(block)
+			continue
+		}
+
+		summaries[i] = &citypes.BlockHeaderSummary{Hash: bh}
+		batchIndices = append(batchIndices, i)
+		batchElems = append(batchElems, rpc.BatchElem{
+			Method: "eth_getBlockByHash",
+			Args:   []interface{}{bh, false},
+			Result: new(web3Types.Block),
+		})
+	}
+
+	if len(batchElems) == 0 {
+		return summaries
+	}
+
+	if err := w3c.Provider().BatchCallContext(ctx, batchElems); err != nil {
+		logrus.WithError(err).Debug("Failed to batch load block headers for block filter changes")
+		return summaries
+	}
+
+	for i := range batchElems {
+		block := batchElems[i].Result.(*web3Types.Block)
+		if batchElems[i].Error != nil || block.Hash == (common.Hash{}) {
+			logrus.WithField("blockHash", blockHashes[batchIndices[i]]).
+				WithError(batchElems[i].Error).
+				Debug("Failed to load block header for block filter changes")
+			continue
+		}
+
+		cache.EthDefault.GetBlockHeaderWithFunc(block.Hash, func() (interface{}, error) {
+			return block, nil
+		})
+
+		summaries[batchIndices[i]] = citypes.NewBlockHeaderSummary(block)
+	}
+
+	return summaries
+}
+
 // GetFilterLogs returns the logs for the filter with the given id.
 // If the filter could not be found an empty array of logs is returned.
 func (api *ethAPI) GetFilterLogs(ctx context.Context, fid rpc.ID) ([]web3Types.Log, error) {
+	if api.LazyFilters != nil {
+		if fq, ok := api.LazyFilters.FilterCrit(fid); ok {
+			w3c := GetEthClientFromContext(ctx)
+			return api.getLogs(ctx, w3c, fq, rpcMethodEthGetFilterLogs)
+		}
+	}
+
 	if api.VirtualFilterClient == nil {
 		// delegate to full node if no virtual filter client provided
 		w3c := GetEthClientFromContext(ctx)
 		return w3c.Filter.GetFilterLogs(fid)
 	}
 
-	fq, err := api.VirtualFilterClient.GetLogFilter(fid)
+	fq, err := api.VirtualFilterClient.GetLogFilter(ctx, fid)
 	if err != nil {
 		return ethEmptyLogs, errVirtualFilterProxyErrorOrNil(err)
 	}
 
-	w3c, err := api.provider.GetClientByIP(ctx, node.GroupEthLogs)
+	// event logs are queried from the same node group as `eth_getLogs`
+	grp := node.EthMethodGroupOrDefault(rpcMethodEthGetLogs, node.GroupEthLogs)
+	w3c, err := api.provider.GetClientByAffinity(ctx, grp)
 	if err != nil {
-		return ethEmptyLogs, errors.WithMessage(err, "failed to get client by ip")
+		return ethEmptyLogs, errors.WithMessage(err, "failed to get client")
 	}
 
 	return api.getLogs(ctx, w3c, fq, rpcMethodEthGetFilterLogs)
@@ -0,0 +1,155 @@
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/node"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/rpc/cache"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/web3go"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEthBlockService struct {
+	blocks map[common.Hash]uint64
+}
+
+func (s *fakeEthBlockService) GetBlockByHash(blockHash common.Hash, fullTx bool) (map[string]interface{}, error) {
+	bn, ok := s.blocks[blockHash]
+	if !ok {
+		return nil, nil
+	}
+
+	return map[string]interface{}{
+		"hash":         blockHash,
+		"parentHash":   common.Hash{},
+		"number":       hexutil.EncodeUint64(bn),
+		"difficulty":   "0x0",
+		"transactions": []common.Hash{},
+	}, nil
+}
+
+type fakeEthTxService struct {
+	txs map[common.Hash]uint64 // tx hash => nonce
+}
+
+func (s *fakeEthTxService) GetTransactionByHash(txHash common.Hash) (map[string]interface{}, error) {
+	nonce, ok := s.txs[txHash]
+	if !ok {
+		return nil, nil
+	}
+
+	return map[string]interface{}{
+		"hash":  txHash,
+		"nonce": hexutil.EncodeUint64(nonce),
+		"gas":   "0x5208",
+		"input": "0x",
+		"value": "0x0",
+		"v":     "0x0",
+		"r":     "0x0",
+		"s":     "0x0",
+		"to":    nil,
+	}, nil
+}
+
+func TestLoadPendingTransactions(t *testing.T) {
+	tx1, tx2, dropped := common.HexToHash("0xb1"), common.HexToHash("0xb2"), common.HexToHash("0xb3")
+
+	srv := rpc.NewServer()
+	assert.NoError(t, srv.RegisterName("eth", &fakeEthTxService{
+		txs: map[common.Hash]uint64{tx1: 1, tx2: 2},
+	}))
+
+	var requests int32
+	httpSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		srv.ServeHTTP(w, r)
+	}))
+	defer httpSrv.Close()
+
+	client, err := web3go.NewClient(httpSrv.URL)
+	assert.NoError(t, err)
+
+	api := &ethAPI{}
+	w3c := &node.Web3goClient{Client: client, URL: httpSrv.URL}
+
+	txns, err := api.loadPendingTransactions(context.Background(), w3c, []common.Hash{tx2, dropped, tx1})
+	assert.NoError(t, err)
+
+	// transactions are loaded in a single batch, and dropped ones are ignored
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	assert.Len(t, txns, 2)
+	assert.Equal(t, tx2, txns[0].Hash)
+	assert.Equal(t, uint64(2), txns[0].Nonce)
+	assert.Equal(t, tx1, txns[1].Hash)
+
+	// no request for empty changes
+	txns, err = api.loadPendingTransactions(context.Background(), w3c, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, txns)
+	assert.Empty(t, txns)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestSummarizeBlockHeaders(t *testing.T) {
+	cached, fetched, missing := common.HexToHash("0xa1"), common.HexToHash("0xa2"), common.HexToHash("0xa3")
+
+	srv := rpc.NewServer()
+	assert.NoError(t, srv.RegisterName("eth", &fakeEthBlockService{
+		blocks: map[common.Hash]uint64{fetched: 2},
+	}))
+
+	var requests int32
+	httpSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		srv.ServeHTTP(w, r)
+	}))
+	defer httpSrv.Close()
+
+	client, err := web3go.NewClient(httpSrv.URL)
+	assert.NoError(t, err)
+
+	cache.EthDefault.GetBlockHeaderWithFunc(cached, func() (interface{}, error) {
+		return &web3Types.Block{Hash: cached, Number: big.NewInt(1)}, nil
+	})
+
+	w3c := &node.Web3goClient{Client: client, URL: httpSrv.URL}
+	summaries := summarizeBlockHeaders(context.Background(), w3c, []common.Hash{cached, fetched, missing})
+
+	// block headers missed in cache are loaded in a single batch
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	assert.Len(t, summaries, 3)
+	assert.Equal(t, int64(1), summaries[0].Number.ToInt().Int64())
+	assert.Equal(t, fetched, summaries[1].Hash)
+	assert.NotNil(t, summaries[1].Number)
+	assert.Equal(t, &citypes.BlockHeaderSummary{Hash: missing}, summaries[2])
+
+	// loaded block header is cached
+	_, ok := cache.EthDefault.PeekBlockHeader(fetched)
+	assert.True(t, ok)
+}
+
+func TestGetFilterExtMode(t *testing.T) {
+	api := &ethAPI{extFilterModes: util.NewExpirableLruCache(10, time.Minute)}
+
+	api.extFilterModes.Add(rpc.ID("0x1"), citypes.FilterExtModeBlockHeaders)
+	assert.Equal(t, citypes.FilterExtModeBlockHeaders, api.getFilterExtMode(context.Background(), "0x1"))
+
+	// standard mode if neither cached nor virtual filter service enabled
+	assert.Equal(t, citypes.FilterExtModeNone, api.getFilterExtMode(context.Background(), "0x2"))
+
+	// pending transaction filter in full transaction mode
+	api.extFilterModes.Add(rpc.ID("0x3"), citypes.FilterExtModeFullTx)
+	assert.Equal(t, citypes.FilterExtModeFullTx, api.getFilterExtMode(context.Background(), "0x3"))
+}
@@ -4,34 +4,41 @@ import (
 	"context"
 
 	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/util/logging"
 	"github.com/Conflux-Chain/confura/util/metrics"
 	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/openweb3/go-rpc-provider"
 	"github.com/openweb3/web3go/types"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 )
 
 // eSpace PubSub notification
 // TODO:
 // 1. restrict total sessions and sessions per IP, otherwise it maybe susceptible
 // to flooding attack;
-// 2. `newPendingTransactions` and `syncing` are not implemented in the fullnode yet.
+// 2. `syncing` is not implemented in the fullnode yet, while `newPendingTransactions` is served
+// from the txpool aggregator.
 
 // NewHeads send a notification each time a new header (block) is appended to the chain.
 func (api *ethAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 	psCtx, supported, err := api.pubsubCtxFromContext(ctx)
 
 	if !supported {
-		logrus.WithError(err).Error("NewHeads pubsub notification unsupported")
+		logging.FromContext(ctx).WithError(err).Error("NewHeads pubsub notification unsupported")
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
 
 	if err != nil {
-		logrus.WithError(err).Error("NewHeads pubsub context error")
+		logging.FromContext(ctx).WithError(err).Error("NewHeads pubsub context error")
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
+	release, err := rpcutil.AcquireWsSubscription(ctx)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
 	rpcSub := psCtx.notifier.CreateSubscription()
 
 	headersCh := make(chan *types.Header, pubsubChannelBufferSize)
@@ -39,13 +46,14 @@ func (api *ethAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 
 	dSub, err := dClient.delegateSubscribeNewHeads(rpcSub.ID, headersCh)
 	api.etPubsubLogger.Log(
-		logrus.WithError(err), err, "Failed to delegate pubsub NewHeads",
+		logging.FromContext(ctx).WithError(err), err, "Failed to delegate pubsub NewHeads",
 	)
 	if err != nil {
+		release()
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
-	logger := logrus.WithField("rpcSubID", rpcSub.ID)
+	logger := logging.FromContext(ctx).WithField("rpcSubID", rpcSub.ID)
 
 	nodeName := rpcutil.Url2NodeName(psCtx.eth.URL)
 	counter := metrics.Registry.PubSub.Sessions("eth", "new_heads", nodeName)
@@ -54,6 +62,7 @@ func (api *ethAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 	go func() {
 		defer dSub.unsubscribe()
 		defer counter.Dec(1)
+		defer release()
 
 		for {
 			select {
@@ -81,20 +90,29 @@ func (api *ethAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 }
 
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
-func (api *ethAPI) Logs(ctx context.Context, filter types.FilterQuery) (*rpc.Subscription, error) {
+// If `resumeFromBlock` specified, the missed event logs since the block will be replayed before
+// live event logs, so that clients could resume the subscription after reconnected without gap.
+func (api *ethAPI) Logs(
+	ctx context.Context, filter types.FilterQuery, opt *EthLogsSubscribeOption,
+) (*rpc.Subscription, error) {
 	metrics.Registry.PubSub.InputLogFilter("eth").Mark(!isEmptyEthLogFilter(filter))
 
 	psCtx, supported, err := api.pubsubCtxFromContext(ctx)
 	if !supported {
-		logrus.WithError(err).Error("Logs pubsub notification unsupported")
+		logging.FromContext(ctx).WithError(err).Error("Logs pubsub notification unsupported")
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
 
 	if err != nil {
-		logrus.WithError(err).Error("Logs pubsub context error")
+		logging.FromContext(ctx).WithError(err).Error("Logs pubsub context error")
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
+	release, err := rpcutil.AcquireWsSubscription(ctx)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
 	rpcSub := psCtx.notifier.CreateSubscription()
 
 	logsCh := make(chan *types.Log, pubsubChannelBufferSize)
@@ -102,14 +120,29 @@ func (api *ethAPI) Logs(ctx context.Context, filter types.FilterQuery) (*rpc.Sub
 
 	dSub, err := dClient.delegateSubscribeLogs(rpcSub.ID, logsCh, filter)
 	api.etPubsubLogger.Log(
-		logrus.WithField("filter", filter),
+		logging.FromContext(ctx).WithField("filter", filter),
 		err, "Failed to delegate pubsub logs subscription",
 	)
 	if err != nil {
+		release()
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
-	logger := logrus.WithField("rpcSubID", rpcSub.ID)
+	// replay after the delegate subscription created, so that no event logs missed in between
+	var replay *ethLogsReplay
+	if opt != nil && opt.ResumeFromBlock != nil {
+		replay, err = api.newEthLogsReplay(ctx, filter, uint64(*opt.ResumeFromBlock))
+		if err != nil {
+			dSub.unsubscribe()
+			release()
+			return &rpc.Subscription{}, err
+		}
+	}
+
+	// replay outlives the subscribe request, but keeps the values (eg., request ID) of context
+	replayCtx := context.WithoutCancel(ctx)
+
+	logger := logging.FromContext(ctx).WithField("rpcSubID", rpcSub.ID)
 
 	nodeName := rpcutil.Url2NodeName(psCtx.eth.URL)
 	counter := metrics.Registry.PubSub.Sessions("eth", "logs", nodeName)
@@ -118,10 +151,24 @@ func (api *ethAPI) Logs(ctx context.Context, filter types.FilterQuery) (*rpc.Sub
 	go func() {
 		defer dSub.unsubscribe()
 		defer counter.Dec(1)
+		defer release()
+
+		if replay != nil {
+			if err := replay.run(replayCtx, psCtx.notifier, rpcSub); err != nil {
+				// close connection so that client would reconnect and resume again
+				logger.WithError(err).Debug("Failed to replay logs for pubsub subscription")
+				psCtx.rpcClient.Close()
+				return
+			}
+		}
 
 		for {
 			select {
 			case log := <-logsCh:
+				if replay.isReplayed(log) {
+					continue
+				}
+
 				logger.WithField("log", log).Debug("Received new log from pubsub delegate")
 				psCtx.notifier.Notify(rpcSub.ID, log)
 
@@ -144,6 +191,57 @@ func (api *ethAPI) Logs(ctx context.Context, filter types.FilterQuery) (*rpc.Sub
 	return rpcSub, nil
 }
 
+// NewPendingTransactions creates a subscription that fires for new pending transactions hashes
+// from the txpool aggregated among full nodes, which requires txpool aggregator enabled.
+func (api *ethAPI) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	if api.Txpool == nil {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	release, err := rpcutil.AcquireWsSubscription(ctx)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	txnsCh := make(chan common.Hash, pubsubChannelBufferSize)
+	unsubscribe := api.Txpool.SubscribePendingTxns(txnsCh)
+
+	logger := logging.FromContext(ctx).WithField("rpcSubID", rpcSub.ID)
+
+	counter := metrics.Registry.PubSub.Sessions("eth", "new_pending_txs", "aggregated")
+	counter.Inc(1)
+
+	go func() {
+		defer unsubscribe()
+		defer counter.Dec(1)
+		defer release()
+
+		for {
+			select {
+			case txHash := <-txnsCh:
+				notifier.Notify(rpcSub.ID, txHash)
+
+			case err = <-rpcSub.Err(): // client connection closed or error
+				logger.WithError(err).Debug("NewPendingTransactions pubsub subscription error")
+				return
+
+			case <-notifier.Closed():
+				logger.Debug("NewPendingTransactions pubsub connection closed")
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 type epubsubContext struct {
 	notifier  *rpc.Notifier
 	rpcClient *rpc.Client
@@ -164,9 +262,9 @@ func (api *ethAPI) pubsubCtxFromContext(ctx context.Context) (psCtx *epubsubCont
 		return
 	}
 
-	eth, err := api.provider.GetClientByIP(ctx, node.GroupEthWs)
+	eth, err := api.provider.GetClientByAffinity(ctx, node.GroupEthWs)
 	if err != nil {
-		err = errors.WithMessage(err, "failed to get eth wsclient by ip")
+		err = errors.WithMessage(err, "failed to get eth wsclient")
 		return
 	}
 
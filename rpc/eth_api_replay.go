@@ -0,0 +1,141 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	rpcMethodEthLogsReplay = "eth_logsReplay"
+)
+
+var (
+	errLogsReplayBlockHashUnsupported = errors.New("block hash filter not supported to resume logs subscription")
+)
+
+// ethLogsReplayConfig is the settings to replay the missed event logs for `logs` subscription.
+type ethLogsReplayConfig struct {
+	// max number of blocks to replay, which is limited by the subscription queue size
+	MaxBlocks uint64 `default:"1000"`
+}
+
+func mustNewEthLogsReplayConfigFromViper() ethLogsReplayConfig {
+	var conf ethLogsReplayConfig
+	viper.MustUnmarshalKey("ethrpc.logsReplay", &conf)
+
+	return conf
+}
+
+// EthLogsSubscribeOption is the extra option of `logs` subscription, e.g.,
+// `eth_subscribe("logs", filter, {"resumeFromBlock": "0x100"})`.
+type EthLogsSubscribeOption struct {
+	// block number to replay the missed event logs from, e.g., the next block of the last received
+	// event log before reconnected
+	ResumeFromBlock *hexutil.Uint64 `json:"resumeFromBlock"`
+}
+
+// ethLogsReplay replays the historical event logs of a block range before live event logs, so that
+// clients could resume `logs` subscription after reconnected without gap.
+type ethLogsReplay struct {
+	api       *ethAPI
+	w3c       *node.Web3goClient
+	filter    web3Types.FilterQuery
+	fromBlock uint64
+	toBlock   uint64 // the latest block when subscribed
+}
+
+// newEthLogsReplay creates logs replay from the specified block to the latest block, which should
+// be called after the live subscription created, so that no event logs will be missed in between.
+// Returns nil if nothing to replay.
+func (api *ethAPI) newEthLogsReplay(
+	ctx context.Context, filter web3Types.FilterQuery, fromBlock uint64,
+) (*ethLogsReplay, error) {
+	if filter.BlockHash != nil {
+		return nil, errLogsReplayBlockHashUnsupported
+	}
+
+	w3c := GetEthClientFromContext(ctx)
+
+	// query full node rather than head tracker, which may be stale and lead to missed event logs
+	latest, err := w3c.Eth.BlockNumber()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get latest block number")
+	}
+
+	toBlock := latest.Uint64()
+	if fromBlock > toBlock {
+		return nil, nil
+	}
+
+	if toBlock-fromBlock+1 > api.logsReplay.MaxBlocks {
+		return nil, errors.Errorf(
+			"block range exceeds the maximum allowed %v to resume logs subscription", api.logsReplay.MaxBlocks,
+		)
+	}
+
+	return &ethLogsReplay{
+		api:       api,
+		w3c:       w3c,
+		filter:    filter,
+		fromBlock: fromBlock,
+		toBlock:   toBlock,
+	}, nil
+}
+
+// run queries the event logs chunk by chunk, and notifies them one by one.
+func (r *ethLogsReplay) run(ctx context.Context, notifier *rpc.Notifier, sub *rpc.Subscription) error {
+	chunkBlocks := r.api.logStream.ChunkBlocks
+
+	var numReplayed int64
+	for from := r.fromBlock; from <= r.toBlock; {
+		select {
+		case err := <-sub.Err(): // client unsubscribed or connection closed
+			return err
+		case <-notifier.Closed():
+			return errors.New("connection closed")
+		default:
+		}
+
+		to := min(from+chunkBlocks-1, r.toBlock)
+
+		chunkFq := r.filter
+		chunkFrom, chunkTo := web3Types.BlockNumber(from), web3Types.BlockNumber(to)
+		chunkFq.FromBlock, chunkFq.ToBlock = &chunkFrom, &chunkTo
+
+		logs, err := r.api.getLogs(ctx, r.w3c, &chunkFq, rpcMethodEthLogsReplay)
+		if err != nil && to > from && isFilterOversizedError(err) {
+			// split into smaller block range and try again
+			chunkBlocks = suggestedChunkBlocks(err, from, to)
+			continue
+		}
+
+		if err != nil {
+			return errors.WithMessagef(err, "failed to get logs of block range [%v, %v]", from, to)
+		}
+
+		for i := range logs {
+			if err := notifier.Notify(sub.ID, &logs[i]); err != nil {
+				return errors.WithMessage(err, "failed to notify log")
+			}
+		}
+
+		numReplayed += int64(len(logs))
+		from, chunkBlocks = to+1, min(chunkBlocks*2, r.api.logStream.ChunkBlocks)
+	}
+
+	metrics.Registry.PubSub.LogsReplayed("eth").Update(numReplayed)
+
+	return nil
+}
+
+// isReplayed checks if the live event log was already replayed, which should be skipped.
+func (r *ethLogsReplay) isReplayed(log *web3Types.Log) bool {
+	return r != nil && !log.Removed && log.BlockNumber <= r.toBlock
+}
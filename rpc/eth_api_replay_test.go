@@ -0,0 +1,21 @@
+package rpc
+
+import (
+	"testing"
+
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEthLogsReplayIsReplayed(t *testing.T) {
+	// nothing replayed
+	var replay *ethLogsReplay
+	assert.False(t, replay.isReplayed(&web3Types.Log{BlockNumber: 100}))
+
+	replay = &ethLogsReplay{fromBlock: 90, toBlock: 100}
+	assert.True(t, replay.isReplayed(&web3Types.Log{BlockNumber: 100}))
+	assert.False(t, replay.isReplayed(&web3Types.Log{BlockNumber: 101}))
+
+	// reverted event logs due to chain reorg are always delivered
+	assert.False(t, replay.isReplayed(&web3Types.Log{BlockNumber: 99, Removed: true}))
+}
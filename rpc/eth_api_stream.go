@@ -0,0 +1,240 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/util/logging"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/confura/util/rate"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	rpcMethodEthLogsStream = "eth_logsStream"
+)
+
+var (
+	errLogsStreamBlockHashUnsupported = errors.New("block hash filter not supported for logs stream, use eth_getLogs instead")
+)
+
+// ethLogStreamConfig is the settings of `logsStream` subscription.
+type ethLogStreamConfig struct {
+	// max number of blocks to query at a time (default: 1000)
+	ChunkBlocks uint64 `default:"1000"`
+	// max number of blocks of the whole stream
+	MaxBlocks uint64 `default:"1000000"`
+}
+
+func mustNewEthLogStreamConfigFromViper() ethLogStreamConfig {
+	var conf ethLogStreamConfig
+	viper.MustUnmarshalKey("ethrpc.logStream", &conf)
+
+	conf.ChunkBlocks = max(conf.ChunkBlocks, 1)
+	return conf
+}
+
+// EthLogStreamChunk is the notification of `logsStream` subscription, which contains the event
+// logs of a block range. The last notification is marked as done, or with error if failed.
+type EthLogStreamChunk struct {
+	FromBlock hexutil.Uint64  `json:"fromBlock"`
+	ToBlock   hexutil.Uint64  `json:"toBlock"`
+	Logs      []web3Types.Log `json:"logs"`
+	Done      bool            `json:"done"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// LogsStream creates a subscription to stream the historical event logs matched with the given
+// filter criteria chunk by chunk, so that clients could start processing results of very large
+// log queries before the full scan completes.
+//
+// Chunks are queried one after another only once the previous one was written to the connection,
+// so that the stream is paced by how fast the client consumes. Chunks with too many event logs are
+// split into smaller block ranges automatically.
+func (api *ethAPI) LogsStream(ctx context.Context, fq web3Types.FilterQuery) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	if fq.BlockHash != nil {
+		return &rpc.Subscription{}, errLogsStreamBlockHashUnsupported
+	}
+
+	// stream outlives the subscribe request, but keeps the values (eg., request ID) of context
+	streamCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	w3c := GetEthClientFromContext(streamCtx)
+
+	if err := api.resolveFilterFinalityTags(w3c, &fq); err != nil {
+		cancel()
+		return &rpc.Subscription{}, err
+	}
+
+	if err := NormalizeEthLogFilter(w3c.Client, LogFilterTypeBlockRange, &fq, api.hardforkBlockNumber); err != nil {
+		cancel()
+		return &rpc.Subscription{}, err
+	}
+
+	if err := ValidateEthLogFilter(LogFilterTypeBlockRange, &fq); err != nil {
+		cancel()
+		return &rpc.Subscription{}, err
+	}
+
+	fromBlock, toBlock := uint64(*fq.FromBlock), uint64(*fq.ToBlock)
+	if toBlock-fromBlock+1 > api.logStream.MaxBlocks {
+		cancel()
+		return &rpc.Subscription{}, errors.Errorf(
+			"block range exceeds the maximum allowed %v for logs stream", api.logStream.MaxBlocks,
+		)
+	}
+
+	release, err := rpcutil.AcquireWsSubscription(ctx)
+	if err != nil {
+		cancel()
+		return &rpc.Subscription{}, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	logger := logging.FromContext(ctx).WithField("rpcSubID", rpcSub.ID)
+
+	counter := metrics.Registry.PubSub.Sessions("eth", "logs_stream", "store")
+	counter.Inc(1)
+
+	go func() {
+		defer cancel()
+		defer counter.Dec(1)
+		defer release()
+
+		stream := ethLogStream{
+			conf: api.logStream,
+			getLogs: func(ctx context.Context, fq *web3Types.FilterQuery) ([]web3Types.Log, error) {
+				return api.getLogs(ctx, w3c, fq, rpcMethodEthLogsStream)
+			},
+			notify: func(chunk *EthLogStreamChunk) error {
+				return notifier.Notify(rpcSub.ID, chunk)
+			},
+			closed: func() error {
+				select {
+				case <-rpcSub.Err(): // client unsubscribed or connection closed
+					return errors.New("unsubscribed")
+				case <-notifier.Closed():
+					return errors.New("connection closed")
+				default:
+					return nil
+				}
+			},
+			limit: limitLogsStreamChunk,
+		}
+
+		if err := stream.run(streamCtx, fq, fromBlock, toBlock); err != nil {
+			logger.WithError(err).Debug("Logs stream subscription terminated")
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// limitLogsStreamChunk charges the rate limits of the API key (or IP) for each chunk, which is
+// queried as an `eth_getLogs` request.
+func limitLogsStreamChunk(ctx context.Context) error {
+	registry, ok := ctx.Value(handlers.CtxKeyRateRegistry).(*rate.Registry)
+	if !ok {
+		return nil
+	}
+
+	for _, resource := range []string{"rpc_all_qps", "rpc_all_daily", "eth_getLogs_qps"} {
+		if err := registry.Limit(ctx, resource); err != nil {
+			return errors.WithMessage(err, "request rate exceeded")
+		}
+	}
+
+	return nil
+}
+
+// ethLogStream streams the event logs of a block range chunk by chunk.
+type ethLogStream struct {
+	conf    ethLogStreamConfig
+	getLogs func(ctx context.Context, fq *web3Types.FilterQuery) ([]web3Types.Log, error)
+	notify  func(chunk *EthLogStreamChunk) error
+	// returns error once client unsubscribed or connection closed
+	closed func() error
+	// charges rate limit for each chunk queried
+	limit func(ctx context.Context) error
+}
+
+func (s *ethLogStream) run(ctx context.Context, fq web3Types.FilterQuery, fromBlock, toBlock uint64) error {
+	chunkBlocks := s.conf.ChunkBlocks
+
+	for from := fromBlock; from <= toBlock; {
+		if err := s.closed(); err != nil {
+			return err
+		}
+
+		to := min(from+chunkBlocks-1, toBlock)
+
+		chunkFq := fq
+		chunkFrom, chunkTo := web3Types.BlockNumber(from), web3Types.BlockNumber(to)
+		chunkFq.FromBlock, chunkFq.ToBlock = &chunkFrom, &chunkTo
+
+		err := s.limit(ctx)
+
+		var logs []web3Types.Log
+		if err == nil {
+			logs, err = s.getLogs(ctx, &chunkFq)
+		}
+
+		if err != nil && to > from && isFilterOversizedError(err) {
+			// split into smaller block range and try again
+			chunkBlocks = suggestedChunkBlocks(err, from, to)
+			continue
+		}
+
+		if err != nil {
+			s.notify(&EthLogStreamChunk{
+				FromBlock: hexutil.Uint64(from), ToBlock: hexutil.Uint64(to), Error: err.Error(),
+			})
+			return err
+		}
+
+		err = s.notify(&EthLogStreamChunk{
+			FromBlock: hexutil.Uint64(from), ToBlock: hexutil.Uint64(to), Logs: logs,
+		})
+		if err != nil {
+			return errors.WithMessage(err, "failed to notify chunk")
+		}
+
+		metrics.Registry.PubSub.LogsStreamChunkSize("eth").Update(int64(len(logs)))
+
+		// grow back chunk size gradually once the dense block range passed
+		from, chunkBlocks = to+1, min(chunkBlocks*2, s.conf.ChunkBlocks)
+	}
+
+	return s.notify(&EthLogStreamChunk{
+		FromBlock: hexutil.Uint64(fromBlock), ToBlock: hexutil.Uint64(toBlock), Logs: ethEmptyLogs, Done: true,
+	})
+}
+
+// isFilterOversizedError checks if the log query failed due to too many event logs to return.
+func isFilterOversizedError(err error) bool {
+	return errors.Is(err, store.ErrFilterResultSetTooLarge) || errors.Is(err, store.ErrFilterQuerySetTooLarge)
+}
+
+// suggestedChunkBlocks returns the number of blocks to query for the next chunk, which is either
+// the suggested block range by oversized error, or half of the current chunk.
+func suggestedChunkBlocks(err error, from, to uint64) uint64 {
+	var suggestedErr *store.SuggestedFilterOversizedError[store.SuggestedBlockRange]
+	if errors.As(err, &suggestedErr) {
+		suggested := suggestedErr.SuggestedRange
+		if suggested.From == from && suggested.To >= from && suggested.To < to {
+			return suggested.To - from + 1
+		}
+	}
+
+	return max((to-from+1)/2, 1)
+}
@@ -0,0 +1,119 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestedChunkBlocks(t *testing.T) {
+	// halve if no suggested block range
+	assert.Equal(t, uint64(500), suggestedChunkBlocks(store.ErrFilterResultSetTooLarge, 1, 1000))
+	assert.Equal(t, uint64(1), suggestedChunkBlocks(store.ErrFilterResultSetTooLarge, 1, 2))
+
+	// follow the suggested block range
+	suggested := store.NewSuggestedBlockRange(1, 100, 0)
+	err := errors.WithMessage(store.NewSuggestedFilterResultSetTooLargeError(&suggested), "failed to get logs")
+	assert.True(t, isFilterOversizedError(err))
+	assert.Equal(t, uint64(100), suggestedChunkBlocks(err, 1, 1000))
+
+	// ignore the suggested block range not starting from the chunk
+	suggested = store.NewSuggestedBlockRange(50, 100, 0)
+	err = store.NewSuggestedFilterResultSetTooLargeError(&suggested)
+	assert.Equal(t, uint64(500), suggestedChunkBlocks(err, 1, 1000))
+
+	assert.False(t, isFilterOversizedError(errors.New("timeout")))
+}
+
+// newTestLogStream creates a logs stream, which returns one event log per block until `denseTo`
+// and fails with oversized error if more than `maxLogs` event logs queried at a time.
+func newTestLogStream(chunkBlocks uint64, maxLogs, denseTo int, chunks *[]*EthLogStreamChunk) *ethLogStream {
+	return &ethLogStream{
+		conf: ethLogStreamConfig{ChunkBlocks: chunkBlocks},
+		getLogs: func(ctx context.Context, fq *web3Types.FilterQuery) ([]web3Types.Log, error) {
+			var logs []web3Types.Log
+			for bn := *fq.FromBlock; bn <= *fq.ToBlock && int(bn) <= denseTo; bn++ {
+				logs = append(logs, web3Types.Log{BlockNumber: uint64(bn)})
+			}
+
+			if len(logs) > maxLogs {
+				return nil, store.ErrFilterResultSetTooLarge
+			}
+
+			return logs, nil
+		},
+		notify: func(chunk *EthLogStreamChunk) error {
+			*chunks = append(*chunks, chunk)
+			return nil
+		},
+		closed: func() error { return nil },
+		limit:  func(ctx context.Context) error { return nil },
+	}
+}
+
+func TestEthLogStreamRun(t *testing.T) {
+	var chunks []*EthLogStreamChunk
+	stream := newTestLogStream(8, 2, 4, &chunks)
+
+	err := stream.run(context.Background(), web3Types.FilterQuery{}, 1, 10)
+	assert.NoError(t, err)
+
+	// oversized chunk split, and grown back once passed
+	var ranges [][2]uint64
+	var numLogs int
+	for _, chunk := range chunks[:len(chunks)-1] {
+		ranges = append(ranges, [2]uint64{uint64(chunk.FromBlock), uint64(chunk.ToBlock)})
+		numLogs += len(chunk.Logs)
+	}
+
+	assert.Equal(t, [][2]uint64{{1, 2}, {3, 6}, {7, 10}}, ranges)
+	assert.Equal(t, 4, numLogs)
+
+	last := chunks[len(chunks)-1]
+	assert.True(t, last.Done)
+	assert.Empty(t, last.Error)
+}
+
+func TestEthLogStreamRateLimited(t *testing.T) {
+	var chunks []*EthLogStreamChunk
+	stream := newTestLogStream(2, 10, 10, &chunks)
+
+	// rate limit charged per chunk
+	var numCharged int
+	stream.limit = func(ctx context.Context) error {
+		if numCharged++; numCharged > 2 {
+			return errors.New("rate exceeded")
+		}
+
+		return nil
+	}
+
+	err := stream.run(context.Background(), web3Types.FilterQuery{}, 1, 10)
+	assert.Error(t, err)
+
+	assert.Len(t, chunks, 3)
+	assert.Equal(t, uint64(5), uint64(chunks[2].FromBlock))
+	assert.NotEmpty(t, chunks[2].Error)
+	assert.False(t, chunks[2].Done)
+}
+
+func TestEthLogStreamClosed(t *testing.T) {
+	var chunks []*EthLogStreamChunk
+	stream := newTestLogStream(2, 10, 10, &chunks)
+
+	stream.closed = func() error {
+		if len(chunks) >= 1 {
+			return errors.New("unsubscribed")
+		}
+
+		return nil
+	}
+
+	err := stream.run(context.Background(), web3Types.FilterQuery{}, 1, 10)
+	assert.Error(t, err)
+	assert.Len(t, chunks, 1)
+}
@@ -0,0 +1,22 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRewardPercentiles(t *testing.T) {
+	assert.NoError(t, validateRewardPercentiles(nil))
+	assert.NoError(t, validateRewardPercentiles([]float64{0, 25, 25, 50, 100}))
+
+	// out of range
+	assert.Error(t, validateRewardPercentiles([]float64{-1}))
+	assert.Error(t, validateRewardPercentiles([]float64{10, 100.5}))
+
+	// not in ascending order
+	assert.Error(t, validateRewardPercentiles([]float64{50, 25}))
+
+	// too many percentiles
+	assert.ErrorIs(t, validateRewardPercentiles(make([]float64, maxRewardPercentileCnt+1)), errTooManyRewardPercentiles)
+}
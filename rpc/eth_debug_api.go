@@ -10,12 +10,19 @@ import (
 
 type ethDebugAPI struct {
 	stateHandler *handler.EthStateHandler
+	traceCache   *handler.EthTraceCache // nil if disabled
 }
 
 func (api *ethDebugAPI) TraceTransaction(
 	ctx context.Context, txnHash common.Hash, opts ...*types.GethDebugTracingOptions) (*types.GethTrace, error) {
 	w3c := GetEthClientFromContext(ctx)
-	return api.stateHandler.DebugTraceTransaction(ctx, w3c, txnHash, opts...)
+	if api.traceCache == nil {
+		return api.stateHandler.DebugTraceTransaction(ctx, w3c, txnHash, opts...)
+	}
+
+	return api.traceCache.DebugTraceTransaction(w3c.Client, txnHash, opts, func() (*types.GethTrace, error) {
+		return api.stateHandler.DebugTraceTransaction(ctx, w3c, txnHash, opts...)
+	})
 }
 
 func (api *ethDebugAPI) TraceBlockByHash(
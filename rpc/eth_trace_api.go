@@ -2,15 +2,23 @@ package rpc
 
 import (
 	"context"
+	"strings"
 
 	"github.com/Conflux-Chain/confura/rpc/handler"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/openweb3/web3go/types"
 )
 
+// isEthTraceRpcMethod checks if the RPC method is `trace_*` or `debug_*`, which are routed to
+// archive nodes if configured.
+func isEthTraceRpcMethod(method string) bool {
+	return strings.HasPrefix(method, "trace_") || strings.HasPrefix(method, "debug_")
+}
+
 // ethTraceAPI provides evm space trace RPC proxy API.
 type ethTraceAPI struct {
 	stateHandler *handler.EthStateHandler
+	traceCache   *handler.EthTraceCache // nil if disabled
 }
 
 func (api *ethTraceAPI) Block(ctx context.Context, blockNumOrHash types.BlockNumberOrHash) ([]types.LocalizedTrace, error) {
@@ -25,5 +33,11 @@ func (api *ethTraceAPI) Filter(ctx context.Context, filter types.TraceFilter) ([
 
 func (api *ethTraceAPI) Transaction(ctx context.Context, txHash common.Hash) ([]types.LocalizedTrace, error) {
 	w3c := GetEthClientFromContext(ctx)
-	return api.stateHandler.TraceTransaction(ctx, w3c, txHash)
+	if api.traceCache == nil {
+		return api.stateHandler.TraceTransaction(ctx, w3c, txHash)
+	}
+
+	return api.traceCache.TraceTransaction(w3c.Client, txHash, func() ([]types.LocalizedTrace, error) {
+		return api.stateHandler.TraceTransaction(ctx, w3c, txHash)
+	})
 }
@@ -0,0 +1,29 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/rpc/handler"
+	"github.com/Conflux-Chain/confura/util/metrics"
+)
+
+// ethTxPoolAPI provides evm space txpool RPC proxy API.
+type ethTxPoolAPI struct {
+	aggregator *handler.EthTxpoolAggregator // aggregated txpool, nil if disabled
+}
+
+// Status returns the number of pending and queued transactions, which is aggregated from all
+// full nodes if txpool aggregator enabled.
+func (api *ethTxPoolAPI) Status(ctx context.Context) (status handler.TxpoolStatus, err error) {
+	if api.aggregator != nil {
+		status, ok := api.aggregator.Status()
+		metrics.Registry.RPC.Percentage("txpool_status", "aggregated").Mark(ok)
+
+		if ok {
+			return status, nil
+		}
+	}
+
+	err = GetEthClientFromContext(ctx).Provider().CallContext(ctx, &status, "txpool_status")
+	return status, err
+}
@@ -0,0 +1,36 @@
+package graphql
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+type ctxKeyQueryCost struct{}
+
+// queryCost is the budget of records read from store per GraphQL query, so that nested queries
+// (e.g., receipts of all transactions within a range of blocks) can't fan out unboundedly.
+type queryCost struct {
+	max  int64
+	used atomic.Int64
+}
+
+func withQueryCost(ctx context.Context, max int) context.Context {
+	return context.WithValue(ctx, ctxKeyQueryCost{}, &queryCost{max: int64(max)})
+}
+
+// chargeQueryCost charges the specified number of records to read from store against the budget
+// of query, and returns error if exceeded.
+func chargeQueryCost(ctx context.Context, n int) error {
+	cost, ok := ctx.Value(ctxKeyQueryCost{}).(*queryCost)
+	if !ok || cost.max <= 0 {
+		return nil
+	}
+
+	if cost.used.Add(int64(n)) > cost.max {
+		return errors.Errorf("query cost exceeds %v records", cost.max)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Conflux-Chain/confura/util/rate"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+)
+
+// rateLimitResources are the rate limit resources charged per GraphQL request, which are shared
+// with JSON-RPC requests except the `graphql_qps` for GraphQL requests only.
+var rateLimitResources = []string{"rpc_all_qps", "rpc_all_daily", "graphql_qps"}
+
+// authMiddleware authenticates requests by API key along with its source IP and origin
+// restrictions, and limits request rate by the rate limit registry if any. Note, it requires
+// the request context injected by the RPC server HTTP middleware, e.g., API key and client IP.
+func authMiddleware(conf Config, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		ki, ok := rate.SVipStatusFromContext(ctx)
+		if ok && handlers.IsAccessTokenValid(ctx) {
+			if ki.Restriction != nil {
+				ip, _ := handlers.GetClientIPFromContext(ctx)
+				origin, _ := handlers.GetRequestOriginFromContext(ctx)
+
+				if err := ki.Restriction.Check(ip, origin); err != nil {
+					http.Error(w, err.Error(), http.StatusForbidden)
+					return
+				}
+			}
+
+			ctx = context.WithValue(ctx, handlers.CtxKeyAuthId, ki.Key)
+		} else if conf.ApiKeyRequired {
+			http.Error(w, "valid API key required", http.StatusUnauthorized)
+			return
+		}
+
+		if registry, ok := ctx.Value(handlers.CtxKeyRateRegistry).(*rate.Registry); ok {
+			for _, resource := range rateLimitResources {
+				if err := registry.Limit(ctx, resource); err != nil {
+					http.Error(w, "request rate exceeded: "+err.Error(), http.StatusTooManyRequests)
+					return
+				}
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
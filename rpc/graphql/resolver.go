@@ -0,0 +1,205 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/pkg/errors"
+)
+
+var (
+	errBlockHashOrNumberRequired = errors.New("either block hash or number must be specified")
+	errInvalidBlockRange         = errors.New("invalid block range")
+)
+
+// Store is the core space chain data store to query from.
+type Store interface {
+	store.Readable
+	store.LogPageReadable
+	store.BlockRangeReadable
+
+	IsRecordNotFound(err error) bool
+}
+
+// Resolver is the root query resolver upon store.
+type Resolver struct {
+	conf  Config
+	store Store
+}
+
+func NewResolver(conf Config, s Store) *Resolver {
+	return &Resolver{conf: conf, store: s}
+}
+
+func (r *Resolver) Block(ctx context.Context, args struct {
+	Hash   *string
+	Number *Long
+}) (*Block, error) {
+	if err := chargeQueryCost(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	var block *store.BlockSummary
+	var err error
+
+	switch {
+	case args.Hash != nil:
+		block, err = r.store.GetBlockSummaryByHash(ctx, types.Hash(*args.Hash))
+	case args.Number != nil:
+		block, err = r.store.GetBlockSummaryByBlockNumber(ctx, uint64(*args.Number))
+	default:
+		return nil, errBlockHashOrNumberRequired
+	}
+
+	if r.store.IsRecordNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get block")
+	}
+
+	return r.newBlock(block.CfxBlockSummary), nil
+}
+
+func (r *Resolver) newBlock(block *types.BlockSummary) *Block {
+	return &Block{store: r.store, block: block, maxPageSize: r.conf.MaxPageSize}
+}
+
+func (r *Resolver) Blocks(ctx context.Context, args struct {
+	From Long
+	To   Long
+}) ([]*Block, error) {
+	if args.From < 0 || args.From > args.To {
+		return nil, errInvalidBlockRange
+	}
+
+	if uint64(args.To-args.From) >= r.conf.MaxBlockRange {
+		return nil, errors.Errorf("block range exceeds %v", r.conf.MaxBlockRange)
+	}
+
+	if err := chargeQueryCost(ctx, int(args.To-args.From)+1); err != nil {
+		return nil, err
+	}
+
+	summaries, err := r.store.GetBlockSummariesByBlockNumberRange(ctx, uint64(args.From), uint64(args.To))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get blocks")
+	}
+
+	blocks := make([]*Block, len(summaries))
+	for i, v := range summaries {
+		blocks[i] = r.newBlock(v.CfxBlockSummary)
+	}
+
+	return blocks, nil
+}
+
+func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash string }) (*Transaction, error) {
+	if err := chargeQueryCost(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	tx, err := r.store.GetTransaction(ctx, types.Hash(args.Hash))
+	if r.store.IsRecordNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get transaction")
+	}
+
+	return &Transaction{store: r.store, tx: tx.CfxTransaction}, nil
+}
+
+// LogFilterInput is the GraphQL input of log filter.
+type LogFilterInput struct {
+	FromBlock Long
+	ToBlock   Long
+	Addresses *[]string
+	Topics    *[][]string
+}
+
+func (r *Resolver) Logs(ctx context.Context, args struct {
+	Filter LogFilterInput
+	First  int32
+	Skip   int32
+}) (*LogPage, error) {
+	if args.First <= 0 || uint64(args.First) > r.conf.MaxPageSize {
+		return nil, errors.Errorf("first must be in range (0, %v]", r.conf.MaxPageSize)
+	}
+
+	if args.Skip < 0 {
+		return nil, errors.New("skip must not be negative")
+	}
+
+	filter, err := r.parseLogFilter(args.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := chargeQueryCost(ctx, int(args.First)); err != nil {
+		return nil, err
+	}
+
+	// fetch one more event log to determine if there are more pages
+	logs, err := r.store.GetLogPage(ctx, filter, uint64(args.Skip), uint64(args.First)+1)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get logs")
+	}
+
+	page := paginate(logs, args.First, 0)
+
+	res := &LogPage{
+		logs:    make([]*Log, len(page)),
+		hasMore: len(logs) > len(page),
+	}
+
+	for i := range page {
+		log, _ := page[i].ToCfxLog()
+		res.logs[i] = &Log{log: log}
+	}
+
+	return res, nil
+}
+
+func (r *Resolver) parseLogFilter(input LogFilterInput) (store.LogFilter, error) {
+	if input.FromBlock < 0 || input.FromBlock > input.ToBlock {
+		return store.LogFilter{}, errInvalidBlockRange
+	}
+
+	if uint64(input.ToBlock-input.FromBlock) >= r.conf.MaxBlockRange {
+		return store.LogFilter{}, errors.Errorf("block range exceeds %v", r.conf.MaxBlockRange)
+	}
+
+	filter := types.LogFilter{
+		FromBlock: types.NewBigInt(uint64(input.FromBlock)),
+		ToBlock:   types.NewBigInt(uint64(input.ToBlock)),
+	}
+
+	if input.Addresses != nil {
+		for _, v := range *input.Addresses {
+			addr, err := cfxaddress.NewFromBase32(v)
+			if err != nil {
+				return store.LogFilter{}, errors.WithMessagef(err, "invalid address %v", v)
+			}
+
+			filter.Address = append(filter.Address, addr)
+		}
+	}
+
+	if input.Topics != nil {
+		for _, topics := range *input.Topics {
+			var hashes []types.Hash
+			for _, v := range topics {
+				hashes = append(hashes, types.Hash(v))
+			}
+
+			filter.Topics = append(filter.Topics, hashes)
+		}
+	}
+
+	return store.ParseCfxLogFilter(uint64(input.FromBlock), uint64(input.ToBlock), &filter), nil
+}
@@ -0,0 +1,196 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchema(t *testing.T) {
+	// resolvers must match the schema
+	_, err := graphql.ParseSchema(schema, NewResolver(Config{}, nil))
+	assert.NoError(t, err)
+}
+
+func TestUnmarshalLong(t *testing.T) {
+	var l Long
+
+	assert.NoError(t, l.UnmarshalGraphQL(int32(100)))
+	assert.Equal(t, Long(100), l)
+
+	assert.NoError(t, l.UnmarshalGraphQL("0x10"))
+	assert.Equal(t, Long(16), l)
+
+	assert.NoError(t, l.UnmarshalGraphQL("200"))
+	assert.Equal(t, Long(200), l)
+
+	assert.Error(t, l.UnmarshalGraphQL("abc"))
+	assert.Error(t, l.UnmarshalGraphQL(true))
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	assert.Equal(t, []int{1, 2}, paginate(items, 2, 0))
+	assert.Equal(t, []int{4, 5}, paginate(items, 10, 3))
+	assert.Nil(t, paginate(items, 2, 5))
+	assert.Nil(t, paginate(items, 0, 0))
+}
+
+func TestParseLogFilter(t *testing.T) {
+	r := NewResolver(Config{MaxBlockRange: 100}, nil)
+
+	filter, err := r.parseLogFilter(LogFilterInput{FromBlock: 10, ToBlock: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), filter.BlockFrom)
+	assert.Equal(t, uint64(20), filter.BlockTo)
+
+	_, err = r.parseLogFilter(LogFilterInput{FromBlock: 20, ToBlock: 10})
+	assert.Error(t, err)
+
+	_, err = r.parseLogFilter(LogFilterInput{FromBlock: 0, ToBlock: 100})
+	assert.Error(t, err)
+
+	addrs := []string{"invalid"}
+	_, err = r.parseLogFilter(LogFilterInput{FromBlock: 0, ToBlock: 1, Addresses: &addrs})
+	assert.Error(t, err)
+}
+
+// testStore serves event logs and blocks of block number [0, numBlocks) from memory.
+type testStore struct {
+	Store
+
+	numLogs   int
+	numBlocks uint64
+	queries   int
+}
+
+func (s *testStore) GetLogPage(ctx context.Context, filter store.LogFilter, skip, limit uint64) ([]*store.Log, error) {
+	s.queries++
+
+	var logs []*store.Log
+	for i := skip; i < uint64(s.numLogs) && uint64(len(logs)) < limit; i++ {
+		logs = append(logs, &store.Log{LogIndex: i, Extra: []byte("{}")})
+	}
+
+	return logs, nil
+}
+
+func (s *testStore) GetBlockSummariesByBlockNumberRange(ctx context.Context, from, to uint64) ([]*store.BlockSummary, error) {
+	s.queries++
+
+	var blocks []*store.BlockSummary
+	for bn := from; bn <= to && bn < s.numBlocks; bn++ {
+		blocks = append(blocks, &store.BlockSummary{
+			CfxBlockSummary: &types.BlockSummary{
+				BlockHeader:  types.BlockHeader{BlockNumber: types.NewBigInt(bn)},
+				Transactions: []types.Hash{"0x01", "0x02"},
+			},
+		})
+	}
+
+	return blocks, nil
+}
+
+func (s *testStore) GetTransaction(ctx context.Context, txHash types.Hash) (*store.Transaction, error) {
+	s.queries++
+	return &store.Transaction{CfxTransaction: &types.Transaction{Hash: txHash}}, nil
+}
+
+func TestResolveLogs(t *testing.T) {
+	s := &testStore{numLogs: 5}
+	r := NewResolver(Config{MaxBlockRange: 100, MaxPageSize: 10}, s)
+
+	query := func(first, skip int32) (*LogPage, error) {
+		return r.Logs(context.Background(), struct {
+			Filter LogFilterInput
+			First  int32
+			Skip   int32
+		}{Filter: LogFilterInput{FromBlock: 0, ToBlock: 10}, First: first, Skip: skip})
+	}
+
+	page, err := query(2, 0)
+	assert.NoError(t, err)
+	assert.Len(t, page.Logs(), 2)
+	assert.True(t, page.HasMore())
+
+	page, err = query(2, 3)
+	assert.NoError(t, err)
+	assert.Len(t, page.Logs(), 2)
+	assert.Equal(t, int64(3), page.Logs()[0].log.LogIndex.ToInt().Int64())
+	assert.False(t, page.HasMore())
+
+	page, err = query(2, 5)
+	assert.NoError(t, err)
+	assert.Empty(t, page.Logs())
+	assert.False(t, page.HasMore())
+
+	_, err = query(2, -1)
+	assert.Error(t, err)
+}
+
+func TestResolveBlocks(t *testing.T) {
+	s := &testStore{numBlocks: 5}
+	r := NewResolver(Config{MaxBlockRange: 100}, s)
+
+	blocks, err := r.Blocks(context.Background(), struct {
+		From Long
+		To   Long
+	}{From: 2, To: 10})
+	assert.NoError(t, err)
+
+	// blocks not synced yet are absent, and all blocks loaded in a single query
+	assert.Len(t, blocks, 3)
+	assert.Equal(t, 1, s.queries)
+}
+
+func serveTestQuery(t *testing.T, h http.Handler, query string) (int, []interface{}) {
+	body, err := json.Marshal(map[string]string{"query": query})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body))))
+
+	if w.Code != http.StatusOK {
+		return w.Code, nil
+	}
+
+	var resp struct{ Errors []interface{} }
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	return w.Code, resp.Errors
+}
+
+func TestQueryCostLimit(t *testing.T) {
+	conf := Config{MaxBlockRange: 100, MaxPageSize: 10, MaxDepth: 10, MaxCost: 8}
+	query := `{ blocks(from: 0, to: 2) { transactions(first: 10) { hash } } }`
+
+	// 3 blocks and 6 transactions
+	_, errs := serveTestQuery(t, MustNewHandler(conf, &testStore{numBlocks: 5}), query)
+	assert.NotEmpty(t, errs)
+
+	conf.MaxCost = 9
+	_, errs = serveTestQuery(t, MustNewHandler(conf, &testStore{numBlocks: 5}), query)
+	assert.Empty(t, errs)
+
+	// page size of nested transactions capped
+	query = `{ blocks(from: 0, to: 2) { transactions(first: 11) { hash } } }`
+	_, errs = serveTestQuery(t, MustNewHandler(conf, &testStore{numBlocks: 5}), query)
+	assert.NotEmpty(t, errs)
+}
+
+func TestApiKeyRequired(t *testing.T) {
+	conf := Config{MaxBlockRange: 100, MaxPageSize: 10, MaxDepth: 10, ApiKeyRequired: true}
+
+	code, _ := serveTestQuery(t, MustNewHandler(conf, &testStore{numBlocks: 5}), `{ blocks(from: 0, to: 2) { number } }`)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
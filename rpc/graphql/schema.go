@@ -0,0 +1,89 @@
+package graphql
+
+// schema is the GraphQL schema of core space chain data indexed in store, in which big
+// integers are encoded as hex strings.
+const schema = `
+scalar Long
+
+schema {
+	query: Query
+}
+
+type Query {
+	# Block by hash or block number, either of which must be specified.
+	block(hash: String, number: Long): Block
+	# Blocks within the inclusive block number range.
+	blocks(from: Long!, to: Long!): [Block!]!
+	# Transaction by hash.
+	transaction(hash: String!): Transaction
+	# Event logs matched by the filter, paginated by first and skip.
+	logs(filter: LogFilter!, first: Int = 100, skip: Int = 0): LogPage!
+}
+
+input LogFilter {
+	fromBlock: Long!
+	toBlock: Long!
+	addresses: [String!]
+	topics: [[String!]!]
+}
+
+type LogPage {
+	logs: [Log!]!
+	hasMore: Boolean!
+}
+
+type Block {
+	hash: String!
+	parentHash: String!
+	number: Long!
+	epochNumber: Long!
+	height: Long!
+	timestamp: Long!
+	miner: String!
+	gasLimit: String!
+	gasUsed: String
+	transactionCount: Int!
+	transactions(first: Int = 100, skip: Int = 0): [Transaction!]!
+}
+
+type Transaction {
+	hash: String!
+	nonce: String!
+	from: String!
+	to: String
+	value: String!
+	gas: String!
+	gasPrice: String
+	data: String!
+	blockHash: String
+	index: Long
+	status: Long
+	contractCreated: String
+	receipt: Receipt
+}
+
+type Receipt {
+	transactionHash: String!
+	index: Long!
+	blockHash: String!
+	epochNumber: Long
+	gasUsed: String
+	gasFee: String
+	contractCreated: String
+	outcomeStatus: Long!
+	txExecErrorMsg: String
+	logs: [Log!]!
+}
+
+type Log {
+	address: String!
+	topics: [String!]!
+	data: String!
+	blockHash: String
+	epochNumber: Long
+	transactionHash: String
+	transactionIndex: Long
+	logIndex: Long
+	transactionLogIndex: Long
+}
+`
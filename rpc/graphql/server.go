@@ -0,0 +1,96 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/sirupsen/logrus"
+)
+
+// Config represents the configuration of GraphQL server.
+type Config struct {
+	// served HTTP endpoint, GraphQL server disabled if empty
+	Endpoint string
+	// max number of blocks to query at a time
+	MaxBlockRange uint64 `default:"100"`
+	// max number of items per page
+	MaxPageSize uint64 `default:"1000"`
+	// max depth of GraphQL query
+	MaxDepth int `default:"10"`
+	// max number of records read from store per query, 0 means unlimited
+	MaxCost int `default:"1000"`
+	// whether to reject requests without valid API key
+	ApiKeyRequired bool
+}
+
+// MustNewConfigFromViper loads GraphQL server configuration from viper, and returns false if
+// GraphQL server not enabled.
+func MustNewConfigFromViper(key string) (conf Config, ok bool) {
+	viper.MustUnmarshalKey(key, &conf)
+	return conf, len(conf.Endpoint) > 0
+}
+
+// MustNewHandler creates HTTP handler to serve GraphQL queries upon store, which authenticates and
+// rate limits requests, and limits the cost of each query.
+func MustNewHandler(conf Config, s Store) http.Handler {
+	parsed := graphql.MustParseSchema(schema, NewResolver(conf, s), graphql.MaxDepth(conf.MaxDepth))
+	relayHandler := &relay.Handler{Schema: parsed}
+
+	return authMiddleware(conf, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		relayHandler.ServeHTTP(w, r.WithContext(withQueryCost(r.Context(), conf.MaxCost)))
+	}))
+}
+
+// MustServeGraceful serves GraphQL queries at `/graphql` until graceful shutdown, in which the
+// middleware injects request context (e.g., API key and rate limit registry) for authentication.
+func MustServeGraceful(
+	ctx context.Context, wg *sync.WaitGroup, conf Config, s Store, middleware handlers.Middleware,
+) {
+	mux := http.NewServeMux()
+	mux.Handle("/graphql", middleware(MustNewHandler(conf, s)))
+
+	server := &http.Server{
+		Addr:      conf.Endpoint,
+		Handler:   mux,
+		TLSConfig: rpcutil.MustNewTLSConfigFromViper("graphql"),
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		<-ctx.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), rpcutil.DefaultShutdownTimeout)
+		defer cancel()
+
+		if err := server.Shutdown(ctx); err != nil {
+			logrus.WithError(err).Error("Failed to shutdown GraphQL server")
+		}
+	}()
+
+	go func() {
+		logrus.WithFields(logrus.Fields{
+			"endpoint": conf.Endpoint,
+			"tls":      server.TLSConfig != nil,
+		}).Info("GraphQL server started")
+
+		var err error
+		if server.TLSConfig != nil {
+			// certificates already loaded in TLS config
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+
+		if err != nil && err != http.ErrServerClosed {
+			logrus.WithError(err).Fatal("Failed to serve GraphQL server")
+		}
+	}()
+}
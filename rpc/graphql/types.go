@@ -0,0 +1,249 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+// Long is the GraphQL scalar of 64-bit integer, which accepts either number or (hex) string input.
+type Long int64
+
+func (Long) ImplementsGraphQLType(name string) bool { return name == "Long" }
+
+func (l *Long) UnmarshalGraphQL(input interface{}) error {
+	switch v := input.(type) {
+	case int32:
+		*l = Long(v)
+	case int64:
+		*l = Long(v)
+	case float64:
+		*l = Long(v)
+	case string:
+		val, err := strconv.ParseInt(v, 0, 64)
+		if err != nil {
+			return errors.WithMessagef(err, "invalid Long value %v", v)
+		}
+		*l = Long(val)
+	default:
+		return fmt.Errorf("unexpected type %T for Long", input)
+	}
+
+	return nil
+}
+
+func bigToLong(v *hexutil.Big) Long {
+	if v == nil {
+		return 0
+	}
+
+	return Long(v.ToInt().Int64())
+}
+
+func bigToLongPtr(v *hexutil.Big) *Long {
+	if v == nil {
+		return nil
+	}
+
+	l := bigToLong(v)
+	return &l
+}
+
+func uint64ToLongPtr(v *hexutil.Uint64) *Long {
+	if v == nil {
+		return nil
+	}
+
+	l := Long(*v)
+	return &l
+}
+
+func bigToHex(v *hexutil.Big) string {
+	if v == nil {
+		return hexutil.EncodeBig(big.NewInt(0))
+	}
+
+	return v.String()
+}
+
+func bigToHexPtr(v *hexutil.Big) *string {
+	if v == nil {
+		return nil
+	}
+
+	s := v.String()
+	return &s
+}
+
+func hashToStrPtr(v *types.Hash) *string {
+	if v == nil {
+		return nil
+	}
+
+	s := v.String()
+	return &s
+}
+
+func addrToStrPtr(v *types.Address) *string {
+	if v == nil {
+		return nil
+	}
+
+	s := v.String()
+	return &s
+}
+
+// Block resolves block summary.
+type Block struct {
+	store       Store
+	block       *types.BlockSummary
+	maxPageSize uint64
+}
+
+func (b *Block) Hash() string            { return b.block.Hash.String() }
+func (b *Block) ParentHash() string      { return b.block.ParentHash.String() }
+func (b *Block) Number() Long            { return bigToLong(b.block.BlockNumber) }
+func (b *Block) EpochNumber() Long       { return bigToLong(b.block.EpochNumber) }
+func (b *Block) Height() Long            { return bigToLong(b.block.Height) }
+func (b *Block) Timestamp() Long         { return bigToLong(b.block.Timestamp) }
+func (b *Block) Miner() string           { return b.block.Miner.String() }
+func (b *Block) GasLimit() string        { return bigToHex(b.block.GasLimit) }
+func (b *Block) GasUsed() *string        { return bigToHexPtr(b.block.GasUsed) }
+func (b *Block) TransactionCount() int32 { return int32(len(b.block.Transactions)) }
+
+func (b *Block) Transactions(ctx context.Context, args struct {
+	First int32
+	Skip  int32
+}) ([]*Transaction, error) {
+	if args.First <= 0 || uint64(args.First) > b.maxPageSize {
+		return nil, errors.Errorf("first must be in range (0, %v]", b.maxPageSize)
+	}
+
+	hashes := paginate(b.block.Transactions, args.First, args.Skip)
+	if err := chargeQueryCost(ctx, len(hashes)); err != nil {
+		return nil, err
+	}
+
+	txns := make([]*Transaction, 0, len(hashes))
+	for _, hash := range hashes {
+		tx, err := b.store.GetTransaction(ctx, hash)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to get transaction %v", hash)
+		}
+
+		txns = append(txns, &Transaction{store: b.store, tx: tx.CfxTransaction})
+	}
+
+	return txns, nil
+}
+
+// Transaction resolves transaction.
+type Transaction struct {
+	store Store
+	tx    *types.Transaction
+}
+
+func (t *Transaction) Hash() string             { return t.tx.Hash.String() }
+func (t *Transaction) Nonce() string            { return bigToHex(t.tx.Nonce) }
+func (t *Transaction) From() string             { return t.tx.From.String() }
+func (t *Transaction) To() *string              { return addrToStrPtr(t.tx.To) }
+func (t *Transaction) Value() string            { return bigToHex(t.tx.Value) }
+func (t *Transaction) Gas() string              { return bigToHex(t.tx.Gas) }
+func (t *Transaction) GasPrice() *string        { return bigToHexPtr(t.tx.GasPrice) }
+func (t *Transaction) Data() string             { return t.tx.Data }
+func (t *Transaction) BlockHash() *string       { return hashToStrPtr(t.tx.BlockHash) }
+func (t *Transaction) Index() *Long             { return uint64ToLongPtr(t.tx.TransactionIndex) }
+func (t *Transaction) Status() *Long            { return uint64ToLongPtr(t.tx.Status) }
+func (t *Transaction) ContractCreated() *string { return addrToStrPtr(t.tx.ContractCreated) }
+
+func (t *Transaction) Receipt(ctx context.Context) (*Receipt, error) {
+	if err := chargeQueryCost(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	receipt, err := t.store.GetReceipt(ctx, t.tx.Hash)
+	if t.store.IsRecordNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get receipt")
+	}
+
+	return &Receipt{receipt: receipt.CfxReceipt}, nil
+}
+
+// Receipt resolves transaction receipt.
+type Receipt struct {
+	receipt *types.TransactionReceipt
+}
+
+func (r *Receipt) TransactionHash() string  { return r.receipt.TransactionHash.String() }
+func (r *Receipt) Index() Long              { return Long(r.receipt.Index) }
+func (r *Receipt) BlockHash() string        { return r.receipt.BlockHash.String() }
+func (r *Receipt) EpochNumber() *Long       { return uint64ToLongPtr(r.receipt.EpochNumber) }
+func (r *Receipt) GasUsed() *string         { return bigToHexPtr(r.receipt.GasUsed) }
+func (r *Receipt) GasFee() *string          { return bigToHexPtr(r.receipt.GasFee) }
+func (r *Receipt) ContractCreated() *string { return addrToStrPtr(r.receipt.ContractCreated) }
+func (r *Receipt) OutcomeStatus() Long      { return Long(r.receipt.OutcomeStatus) }
+func (r *Receipt) TxExecErrorMsg() *string  { return r.receipt.TxExecErrorMsg }
+
+func (r *Receipt) Logs() []*Log {
+	logs := make([]*Log, len(r.receipt.Logs))
+	for i := range r.receipt.Logs {
+		logs[i] = &Log{log: &r.receipt.Logs[i]}
+	}
+
+	return logs
+}
+
+// Log resolves event log.
+type Log struct {
+	log *types.Log
+}
+
+func (l *Log) Address() string            { return l.log.Address.String() }
+func (l *Log) Data() string               { return l.log.Data.String() }
+func (l *Log) BlockHash() *string         { return hashToStrPtr(l.log.BlockHash) }
+func (l *Log) EpochNumber() *Long         { return bigToLongPtr(l.log.EpochNumber) }
+func (l *Log) TransactionHash() *string   { return hashToStrPtr(l.log.TransactionHash) }
+func (l *Log) TransactionIndex() *Long    { return bigToLongPtr(l.log.TransactionIndex) }
+func (l *Log) LogIndex() *Long            { return bigToLongPtr(l.log.LogIndex) }
+func (l *Log) TransactionLogIndex() *Long { return bigToLongPtr(l.log.TransactionLogIndex) }
+
+func (l *Log) Topics() []string {
+	topics := make([]string, len(l.log.Topics))
+	for i := range l.log.Topics {
+		topics[i] = l.log.Topics[i].String()
+	}
+
+	return topics
+}
+
+// LogPage is a page of event logs.
+type LogPage struct {
+	logs    []*Log
+	hasMore bool
+}
+
+func (p *LogPage) Logs() []*Log  { return p.logs }
+func (p *LogPage) HasMore() bool { return p.hasMore }
+
+// paginate returns the page of items by number of items to skip and return.
+func paginate[T any](items []T, first, skip int32) []T {
+	if skip < 0 || int(skip) >= len(items) || first <= 0 {
+		return nil
+	}
+
+	end := int(skip) + int(first)
+	if end > len(items) {
+		end = len(items)
+	}
+
+	return items[skip:end]
+}
@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"math/big"
 	"sort"
+	"sync/atomic"
 
 	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/memory"
 	"github.com/Conflux-Chain/confura/store/mysql"
 	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util/logging"
 	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/confura/util/reload"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
 	"github.com/Conflux-Chain/go-conflux-util/viper"
@@ -19,23 +23,39 @@ import (
 )
 
 var (
-	// Maximum number of bytes for the response body of getLogs requests
-	maxGetLogsResponseBytes     uint64
-	errResponseBodySizeTooLarge error
+	// Maximum number of bytes for the response body of getLogs requests, which is hot-reloadable
+	maxGetLogsResponseBytes atomic.Uint64
 
 	errEventLogsTooStale = errors.New("event logs are too stale (already pruned)")
 )
 
+type resourceLimitConfig struct {
+	MaxGetLogsResponseBytes uint64 `default:"10485760"` // default 10MB
+}
+
 func MustInitFromViper() {
-	var resrcLimit struct {
-		MaxGetLogsResponseBytes uint64 `default:"10485760"` // default 10MB
-	}
+	var resrcLimit resourceLimitConfig
 	viper.MustUnmarshalKey("requestControl.resourceLimits", &resrcLimit)
 
-	maxGetLogsResponseBytes = resrcLimit.MaxGetLogsResponseBytes
-	errResponseBodySizeTooLarge = fmt.Errorf(
+	maxGetLogsResponseBytes.Store(resrcLimit.MaxGetLogsResponseBytes)
+
+	reload.Register("requestControl.resourceLimits", resrcLimit,
+		func(conf resourceLimitConfig) error {
+			if conf.MaxGetLogsResponseBytes == 0 {
+				return errors.New("max getLogs response bytes must be positive")
+			}
+			return nil
+		},
+		func(conf resourceLimitConfig) {
+			maxGetLogsResponseBytes.Store(conf.MaxGetLogsResponseBytes)
+		},
+	)
+}
+
+func errResponseBodySizeTooLarge() error {
+	return fmt.Errorf(
 		"result body size is too large with more than %d bytes, please narrow down your filter condition",
-		maxGetLogsResponseBytes,
+		maxGetLogsResponseBytes.Load(),
 	)
 }
 
@@ -43,18 +63,90 @@ func MustInitFromViper() {
 type CfxLogsApiHandler struct {
 	ms *mysql.MysqlStore
 
-	prunedHandler *CfxPrunedLogsHandler // optional
+	prunedHandler    *CfxPrunedLogsHandler    // optional
+	federatedHandler *CfxFederatedLogsHandler // optional
+	nearHeadStore    *memory.NearHeadStore    // optional
+	planner          *LogQueryPlanner         // optional
+}
+
+func NewCfxLogsApiHandler(
+	ms *mysql.MysqlStore,
+	prunedHandler *CfxPrunedLogsHandler,
+	federatedHandler *CfxFederatedLogsHandler,
+) *CfxLogsApiHandler {
+	return &CfxLogsApiHandler{ms: ms, prunedHandler: prunedHandler, federatedHandler: federatedHandler}
+}
+
+// WithNearHeadStore enables to query event logs of near-head epochs, which are not persisted into
+// database yet, from the in-memory store rather than fullnode.
+func (handler *CfxLogsApiHandler) WithNearHeadStore(nhs *memory.NearHeadStore) *CfxLogsApiHandler {
+	handler.nearHeadStore = nhs
+	return handler
 }
 
-func NewCfxLogsApiHandler(ms *mysql.MysqlStore, prunedHandler *CfxPrunedLogsHandler) *CfxLogsApiHandler {
-	return &CfxLogsApiHandler{ms, prunedHandler}
+// WithQueryPlanner enables to plan the execution of event logs query within database by cost.
+func (handler *CfxLogsApiHandler) WithQueryPlanner(planner *LogQueryPlanner) *CfxLogsApiHandler {
+	handler.planner = planner
+	return handler
 }
 
+// GetLogs gets event logs from local store and fullnode in hybrid, and returns true if any hit in
+// the local store.
+//
+// Note, the log filter is assumed to be validated and normalized (with numbered epochs) the same as
+// evm space, and the fullnode requests are canceled along with the request context.
 func (handler *CfxLogsApiHandler) GetLogs(
 	ctx context.Context,
 	cfx sdk.ClientOperator,
 	filter *types.LogFilter,
 	delegatedRpcMethod string,
+) ([]types.Log, bool, error) {
+	cfx = cfxClientWithContext(ctx, cfx)
+
+	if handler.federatedHandler == nil {
+		return handler.getLiveLogs(ctx, cfx, filter, delegatedRpcMethod)
+	}
+
+	// federate the historical part, which has not been synchronized locally, to historical backend
+	histFilter, liveFilter, err := handler.federatedHandler.SplitLogFilter(filter)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if histFilter == nil {
+		return handler.getLiveLogs(ctx, cfx, filter, delegatedRpcMethod)
+	}
+
+	if len(delegatedRpcMethod) > 0 {
+		metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/split/federated").Mark(true)
+	}
+
+	logs, err := handler.federatedHandler.GetLogs(ctx, *histFilter)
+	if err != nil || liveFilter == nil {
+		return logs, false, err
+	}
+
+	liveLogs, hitStore, err := handler.getLiveLogs(ctx, cfx, liveFilter, delegatedRpcMethod)
+	if err != nil {
+		return nil, false, err
+	}
+
+	logs = append(logs, liveLogs...)
+
+	// ensure merged result set never oversized
+	if handler.RequireBoundChecks(filter) && uint64(len(logs)) > store.MaxLogLimit {
+		return nil, false, newSuggestedResultSetOversizedError(cfx, filter, &logs[store.MaxLogLimit])
+	}
+
+	return logs, hitStore, nil
+}
+
+// getLiveLogs gets event logs from local store and fullnode.
+func (handler *CfxLogsApiHandler) getLiveLogs(
+	ctx context.Context,
+	cfx sdk.ClientOperator,
+	filter *types.LogFilter,
+	delegatedRpcMethod string,
 ) ([]types.Log, bool, error) {
 	// record the reorg version before query to ensure data consistence
 	lastReorgVersion, err := handler.ms.GetReorgVersion()
@@ -118,6 +210,7 @@ func (handler *CfxLogsApiHandler) getLogsReorgGuard(
 
 	var logs []types.Log
 	var accumulator int
+	var hitStore bool
 
 	useBoundCheck := handler.RequireBoundChecks(filter)
 	if len(dbFilters) > 0 {
@@ -137,41 +230,85 @@ func (handler *CfxLogsApiHandler) getLogsReorgGuard(
 			return nil, false, err
 		}
 
-		dbLogs, err := handler.ms.GetLogs(ctx, dbFilters[i])
+		// plan the execution path within database by cost
+		if handler.planner != nil {
+			fnLogs, ok, err := handler.getPlannedFullnodeLogs(ctx, cfx, &dbFilters[i], delegatedRpcMethod)
+			if err != nil {
+				return nil, false, handler.convertSuggestedFilterOversizedErrorIfAny(filter, err)
+			}
+
+			if ok { // cheaper to query from fullnode
+				for i := range fnLogs {
+					if accumulator += len(fnLogs[i].Data); useBoundCheck && uint64(accumulator) > maxGetLogsResponseBytes.Load() {
+						return nil, false, newSuggestedBodyBytesOversizedError(cfx, filter, &fnLogs[i])
+					}
+				}
+
+				logs = append(logs, fnLogs...)
+				continue
+			}
+		}
+
+		var err error
+		hitStore = true
 
-		// succeeded to get logs from database
-		if err == nil {
-			for _, v := range dbLogs {
-				if accumulator += len(v.Extra); useBoundCheck && uint64(accumulator) > maxGetLogsResponseBytes {
-					return nil, false, newSuggestedBodyBytesOversizedError(cfx, filter, v)
+		if useBoundCheck {
+			var dbLogs []*store.Log
+			dbLogs, err = handler.ms.GetLogs(ctx, dbFilters[i])
+
+			// succeeded to get logs from database
+			if err == nil {
+				if len(delegatedRpcMethod) > 0 {
+					metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/store/pruned").Mark(false)
 				}
 
+				for _, v := range dbLogs {
+					if accumulator += len(v.Extra); uint64(accumulator) > maxGetLogsResponseBytes.Load() {
+						return nil, false, newSuggestedBodyBytesOversizedError(cfx, filter, v)
+					}
+
+					log, _ := v.ToCfxLog()
+					logs = append(logs, *log)
+				}
+
+				continue
+			}
+		} else {
+			// iterate data from database chunk by chunk for unbounded queries, which could be huge
+			numLogs := len(logs)
+			err = handler.ms.ForEachLog(ctx, dbFilters[i], func(v *store.Log) error {
+				accumulator += len(v.Extra)
+
 				log, _ := v.ToCfxLog()
 				logs = append(logs, *log)
-			}
+				return nil
+			})
 
-			continue
-		}
+			if err == nil {
+				if len(delegatedRpcMethod) > 0 {
+					metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/store/pruned").Mark(false)
+				}
 
-		// convert suggested block range back to epoch range for log filter with epoch range
-		if filter.FromEpoch != nil {
-			var valErr *store.SuggestedFilterOversizedError[store.SuggestedBlockRange]
-			if errors.As(err, &valErr) {
-				oversizedErr := handler.convertSuggestedFilterOversizedError(filter, valErr)
-				return nil, false, oversizedErr
+				continue
 			}
+
+			// discard the partially iterated event logs
+			logs = logs[:numLogs]
 		}
 
-		if !errors.Is(err, store.ErrAlreadyPruned) {
-			return nil, false, err
+		pruned := errors.Is(err, store.ErrAlreadyPruned)
+		if len(delegatedRpcMethod) > 0 {
+			metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/store/pruned").Mark(pruned)
 		}
 
-		// data already pruned
-		if handler.prunedHandler == nil {
-			return nil, false, errEventLogsTooStale
+		// event logs of epochs parked into dead letter queue are not persisted either
+		pruned = pruned || errors.Is(err, store.ErrEpochParked)
+
+		if !pruned {
+			return nil, false, handler.convertSuggestedFilterOversizedErrorIfAny(filter, err)
 		}
 
-		// try to query pruned logs from archive fullnode
+		// try to query pruned (or parked) logs from historical backend, archive fullnode or the delegated fullnode
 		originalFilter := dbFilters[i].Cfx()
 		if originalFilter == nil {
 			return nil, false, errors.WithMessage(
@@ -179,18 +316,13 @@ func (handler *CfxLogsApiHandler) getLogsReorgGuard(
 			)
 		}
 
-		// ensure fullnode delegation is rational
-		if err := handler.checkFullnodeLogFilter(originalFilter); err != nil {
-			return nil, false, err
-		}
-
-		fnLogs, err := handler.prunedHandler.GetLogs(ctx, *originalFilter)
+		fnLogs, err := handler.getPrunedLogs(ctx, cfx, originalFilter)
 		if err != nil {
 			return nil, false, err
 		}
 
 		for i := range fnLogs {
-			if accumulator += len(fnLogs[i].Data); useBoundCheck && uint64(accumulator) > maxGetLogsResponseBytes {
+			if accumulator += len(fnLogs[i].Data); useBoundCheck && uint64(accumulator) > maxGetLogsResponseBytes.Load() {
 				return nil, false, newSuggestedBodyBytesOversizedError(cfx, filter, &fnLogs[i])
 			}
 		}
@@ -204,18 +336,25 @@ func (handler *CfxLogsApiHandler) getLogsReorgGuard(
 			return nil, false, err
 		}
 
-		// ensure split log filter for fullnode is rational
-		if err := handler.checkFullnodeLogFilter(fnFilter); err != nil {
+		// try to query near-head event logs from memory first
+		fnLogs, ok, err := handler.getNearHeadLogs(ctx, fnFilter, delegatedRpcMethod)
+		if err != nil {
 			return nil, false, err
 		}
 
-		fnLogs, err := cfx.GetLogs(*fnFilter)
-		if err != nil {
-			return nil, false, err
+		if !ok {
+			// ensure split log filter for fullnode is rational
+			if err := handler.checkFullnodeLogFilter(fnFilter); err != nil {
+				return nil, false, err
+			}
+
+			if fnLogs, err = cfx.GetLogs(*fnFilter); err != nil {
+				return nil, false, err
+			}
 		}
 
 		for i := range fnLogs {
-			if accumulator += len(fnLogs[i].Data); useBoundCheck && uint64(accumulator) > maxGetLogsResponseBytes {
+			if accumulator += len(fnLogs[i].Data); useBoundCheck && uint64(accumulator) > maxGetLogsResponseBytes.Load() {
 				return nil, false, newSuggestedBodyBytesOversizedError(cfx, filter, &fnLogs[i])
 			}
 		}
@@ -228,8 +367,8 @@ func (handler *CfxLogsApiHandler) getLogsReorgGuard(
 	}
 
 	// Rare case: log context information for diagnostic purposes if the result exceeds limits.
-	if uint64(len(logs)) > store.MaxLogLimit || uint64(accumulator) > maxGetLogsResponseBytes {
-		logrus.WithFields(logrus.Fields{
+	if uint64(len(logs)) > store.MaxLogLimit || uint64(accumulator) > maxGetLogsResponseBytes.Load() {
+		logging.FromContext(ctx).WithFields(logrus.Fields{
 			"logFilter":         filter,
 			"databaseFilters":   dbFilters,
 			"fullnodeFilter":    fnFilter,
@@ -239,7 +378,46 @@ func (handler *CfxLogsApiHandler) getLogsReorgGuard(
 		}).Info("Exceeded limits for getLogs response")
 	}
 
-	return logs, len(dbFilters) > 0, nil
+	return logs, hitStore, nil
+}
+
+// getPlannedFullnodeLogs gets event logs from fullnode if cheaper than database as planned, or false
+// if planned to query from database, in which case the block range of database filter may be narrowed.
+func (handler *CfxLogsApiHandler) getPlannedFullnodeLogs(
+	ctx context.Context,
+	cfx sdk.ClientOperator,
+	dbFilter *store.LogFilter,
+	delegatedRpcMethod string,
+) ([]types.Log, bool, error) {
+	plan, err := handler.planner.plan(ctx, *dbFilter, delegatedRpcMethod)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if plan.path == logScanPathNone { // no event logs at all
+		return nil, true, nil
+	}
+
+	if plan.path != logScanPathFullnode {
+		*dbFilter = plan.filter
+		return nil, false, nil
+	}
+
+	originalFilter := dbFilter.Cfx()
+	if originalFilter == nil || handler.checkFullnodeLogFilter(originalFilter) != nil {
+		return nil, false, nil
+	}
+
+	if err := checkTimeout(ctx); err != nil {
+		return nil, false, err
+	}
+
+	logs, err := cfx.GetLogs(*originalFilter)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return logs, true, nil
 }
 
 func (handler *CfxLogsApiHandler) splitLogFilter(
@@ -437,15 +615,103 @@ func (handler *CfxLogsApiHandler) splitLogFilterByEpochRange(
 	return []store.LogFilter{dbFilter}, &fnFilter, nil
 }
 
+// getNearHeadLogs gets event logs from the near-head memory store, or false if the log filter is
+// not fully covered by the memory store.
+func (handler *CfxLogsApiHandler) getNearHeadLogs(
+	ctx context.Context, filter *types.LogFilter, delegatedRpcMethod string,
+) (logs []types.Log, hit bool, err error) {
+	if handler.nearHeadStore == nil {
+		return nil, false, nil
+	}
+
+	if len(delegatedRpcMethod) > 0 {
+		defer func() {
+			metrics.Registry.RPC.Percentage(delegatedRpcMethod, "nearHead").Mark(hit)
+		}()
+	}
+
+	epochRange, ok := handler.nearHeadStore.EpochRange()
+	if !ok {
+		return nil, false, nil
+	}
+
+	var blockFrom, blockTo citypes.RangeUint64
+	if filterEpochRange, valid := calculateEpochRange(filter); valid {
+		blockFrom, ok = handler.nearHeadStore.BlockRange(filterEpochRange.From)
+		if !ok {
+			return nil, false, nil
+		}
+
+		if blockTo, ok = handler.nearHeadStore.BlockRange(filterEpochRange.To); !ok {
+			return nil, false, nil
+		}
+	} else if filterBlockRange, valid := calculateCfxBlockRange(filter); valid {
+		fromRange, ok1 := handler.nearHeadStore.BlockRange(epochRange.From)
+		toRange, ok2 := handler.nearHeadStore.BlockRange(epochRange.To)
+		if !ok1 || !ok2 || filterBlockRange.From < fromRange.From || filterBlockRange.To > toRange.To {
+			return nil, false, nil
+		}
+
+		blockFrom.From, blockTo.To = filterBlockRange.From, filterBlockRange.To
+	} else {
+		return nil, false, nil
+	}
+
+	slogs, err := handler.nearHeadStore.GetLogs(ctx, store.ParseCfxLogFilter(blockFrom.From, blockTo.To, filter))
+	if err != nil {
+		return nil, false, err
+	}
+
+	logs = make([]types.Log, 0, len(slogs))
+	for _, v := range slogs {
+		log, _ := v.ToCfxLog()
+		logs = append(logs, *log)
+	}
+
+	return logs, true, nil
+}
+
+// cfxClientWithContext binds the request context to core space client if supported, so that the
+// fullnode requests will be canceled along with the request context.
+func cfxClientWithContext(ctx context.Context, cfx sdk.ClientOperator) sdk.ClientOperator {
+	if client, ok := cfx.(*sdk.Client); ok {
+		return client.WithContext(ctx)
+	}
+
+	return cfx
+}
+
+// getPrunedLogs gets event logs pruned from database, from historical backend, archive fullnode or
+// the delegated fullnode in order.
+func (handler *CfxLogsApiHandler) getPrunedLogs(
+	ctx context.Context, cfx sdk.ClientOperator, filter *types.LogFilter,
+) ([]types.Log, error) {
+	if handler.federatedHandler != nil {
+		return handler.federatedHandler.GetLogs(ctx, *filter)
+	}
+
+	// ensure fullnode delegation is rational
+	if err := handler.checkFullnodeLogFilter(filter); err != nil {
+		return nil, err
+	}
+
+	if handler.prunedHandler != nil {
+		return handler.prunedHandler.GetLogs(ctx, *filter)
+	}
+
+	// fall back to the delegated fullnode the same as evm space
+	return cfx.GetLogs(*filter)
+}
+
 // checkFullnodeLogFilter checks if the log filter is rational for fullnode delegation.
 //
 // Note this function assumes the log filter is valid and normalized.
 func (handler *CfxLogsApiHandler) checkFullnodeLogFilter(filter *types.LogFilter) error {
 	// Epoch range bound checking
 	if epochRange, valid := calculateEpochRange(filter); valid {
-		numEpochs := epochRange.To - epochRange.From + 1
-		if numEpochs > uint64(store.MaxLogEpochRange) {
-			epochRange.To = epochRange.From + uint64(store.MaxLogEpochRange) - 1
+		numEpochs, maxEpochs := epochRange.To-epochRange.From+1, store.MaxLogEpochRange()
+		if numEpochs > maxEpochs {
+			epochRange.To = epochRange.From + maxEpochs - 1
 			suggestedRange := store.NewSuggestedEpochRange(epochRange.From, epochRange.To)
 			return store.NewSuggestedFilterQuerySetTooLargeError(&suggestedRange)
 		}
@@ -453,9 +719,9 @@ func (handler *CfxLogsApiHandler) checkFullnodeLogFilter(filter *types.LogFilter
 
 	// Block range bound checking
 	if blockRange, valid := calculateCfxBlockRange(filter); valid {
-		numBlocks := blockRange.To - blockRange.From + 1
-		if numBlocks > uint64(store.MaxLogBlockRange) {
-			blockRange.To = blockRange.From + uint64(store.MaxLogBlockRange) - 1
+		numBlocks, maxBlocks := blockRange.To-blockRange.From+1, store.MaxLogBlockRange()
+		if numBlocks > maxBlocks {
+			blockRange.To = blockRange.From + maxBlocks - 1
 			suggestedRange := store.SuggestedBlockRange{RangeUint64: blockRange}
 			return store.NewSuggestedFilterQuerySetTooLargeError(&suggestedRange)
 		}
@@ -464,6 +730,21 @@ func (handler *CfxLogsApiHandler) checkFullnodeLogFilter(filter *types.LogFilter
 	return nil
 }
 
+// convertSuggestedFilterOversizedErrorIfAny converts suggested block range back to epoch range for
+// log filter with epoch range if any.
+func (handler *CfxLogsApiHandler) convertSuggestedFilterOversizedErrorIfAny(filter *types.LogFilter, err error) error {
+	if filter.FromEpoch == nil {
+		return err
+	}
+
+	var valErr *store.SuggestedFilterOversizedError[store.SuggestedBlockRange]
+	if errors.As(err, &valErr) {
+		return handler.convertSuggestedFilterOversizedError(filter, valErr)
+	}
+
+	return err
+}
+
 // convert suggested block range back to epoch range if possible
 func (handler *CfxLogsApiHandler) convertSuggestedFilterOversizedError(
 	filter *types.LogFilter, oversizedErr *store.SuggestedFilterOversizedError[store.SuggestedBlockRange]) error {
@@ -499,7 +780,7 @@ func (handler *CfxLogsApiHandler) RequireBoundChecks(filter *types.LogFilter) bo
 
 func newSuggestedBodyBytesOversizedError[T types.Log | store.Log](
 	cfx sdk.ClientOperator, filter *types.LogFilter, exceedingLog *T) error {
-	return newSuggestedFilterOversizedError[T](errResponseBodySizeTooLarge, cfx, filter, exceedingLog)
+	return newSuggestedFilterOversizedError[T](errResponseBodySizeTooLarge(), cfx, filter, exceedingLog)
 }
 
 func newSuggestedResultSetOversizedError[T types.Log | store.Log](
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"math/big"
+
+	citypes "github.com/Conflux-Chain/confura/types"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// federationConfig represents the configurations of query federation.
+type federationConfig struct {
+	HistoricalBackend string // remote confura endpoint URL
+}
+
+func mustNewFederationConfigFromViper(key string) federationConfig {
+	var cfg federationConfig
+	viper.MustUnmarshalKey(key, &cfg)
+	return cfg
+}
+
+// federatedEpochStore provides the local epoch range boundaries to split log filter.
+type federatedEpochStore interface {
+	MinEpoch() (uint64, bool, error)
+	BlockRange(epoch uint64) (citypes.RangeUint64, bool, error)
+}
+
+// CfxFederatedLogsHandler RPC handler to federate event log queries, whose epoch ranges have not
+// been synchronized locally, to some remote confura deployment as the historical backend.
+type CfxFederatedLogsHandler struct {
+	store  federatedEpochStore
+	remote *sdk.Client
+}
+
+// MustNewCfxFederatedLogsHandlerFromViper creates a federated logs handler if historical backend configured.
+func MustNewCfxFederatedLogsHandlerFromViper(store federatedEpochStore) (*CfxFederatedLogsHandler, bool) {
+	cfg := mustNewFederationConfigFromViper("rpc.federation")
+	if len(cfg.HistoricalBackend) == 0 {
+		return nil, false
+	}
+
+	// never inject node credentials into the remote confura endpoint
+	remote, err := rpcutil.NewCfxClient(
+		cfg.HistoricalBackend, rpcutil.WithClientHookMetrics(true), rpcutil.WithClientExternal(true),
+	)
+	if err != nil {
+		logrus.WithField("url", cfg.HistoricalBackend).
+			WithError(err).
+			Fatal("Failed to create client for historical backend")
+	}
+
+	return &CfxFederatedLogsHandler{store: store, remote: remote}, true
+}
+
+// GetLogs queries event logs from the historical backend, which will be canceled along with the
+// request context.
+func (h *CfxFederatedLogsHandler) GetLogs(ctx context.Context, filter types.LogFilter) ([]types.Log, error) {
+	logs, err := h.remote.WithContext(ctx).GetLogs(filter)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get logs from historical backend")
+	}
+
+	return logs, nil
+}
+
+// SplitLogFilter splits the log filter into the historical part, which is prior to the
+// earliest epoch synchronized locally, and the live part. Either part could be nil.
+func (h *CfxFederatedLogsHandler) SplitLogFilter(filter *types.LogFilter) (
+	histFilter, liveFilter *types.LogFilter, err error,
+) {
+	// block hashes are resolved by fullnode and never federated
+	if len(filter.BlockHashes) > 0 {
+		return nil, filter, nil
+	}
+
+	minEpoch, ok, err := h.store.MinEpoch()
+	if err != nil || !ok {
+		return nil, filter, err
+	}
+
+	if filter.FromBlock != nil && filter.ToBlock != nil {
+		blockRange, ok, err := h.store.BlockRange(minEpoch)
+		if err != nil || !ok {
+			return nil, filter, err
+		}
+
+		blockFrom, blockTo := filter.FromBlock.ToInt().Uint64(), filter.ToBlock.ToInt().Uint64()
+		if blockFrom >= blockRange.From {
+			return nil, filter, nil
+		}
+
+		histFilter = h.newPartialLogFilter(filter)
+		histFilter.FromBlock = filter.FromBlock
+		histFilter.ToBlock = (*hexutil.Big)(new(big.Int).SetUint64(min(blockTo, blockRange.From-1)))
+
+		if blockTo >= blockRange.From {
+			liveFilter = h.newPartialLogFilter(filter)
+			liveFilter.FromBlock = (*hexutil.Big)(new(big.Int).SetUint64(blockRange.From))
+			liveFilter.ToBlock = filter.ToBlock
+		}
+
+		return histFilter, liveFilter, nil
+	}
+
+	epochFrom, ok := filter.FromEpoch.ToInt()
+	if !ok {
+		return nil, filter, nil
+	}
+
+	epochTo, ok := filter.ToEpoch.ToInt()
+	if !ok {
+		return nil, filter, nil
+	}
+
+	if epochFrom.Uint64() >= minEpoch {
+		return nil, filter, nil
+	}
+
+	histFilter = h.newPartialLogFilter(filter)
+	histFilter.FromEpoch = filter.FromEpoch
+	histFilter.ToEpoch = types.NewEpochNumberUint64(min(epochTo.Uint64(), minEpoch-1))
+
+	if epochTo.Uint64() >= minEpoch {
+		liveFilter = h.newPartialLogFilter(filter)
+		liveFilter.FromEpoch = types.NewEpochNumberUint64(minEpoch)
+		liveFilter.ToEpoch = filter.ToEpoch
+	}
+
+	return histFilter, liveFilter, nil
+}
+
+func (h *CfxFederatedLogsHandler) newPartialLogFilter(filter *types.LogFilter) *types.LogFilter {
+	return &types.LogFilter{
+		Address: filter.Address,
+		Topics:  filter.Topics,
+	}
+}
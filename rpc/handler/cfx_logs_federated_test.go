@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"math/big"
+	"testing"
+
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockFederatedEpochStore synchronized epochs since 100, whose blocks since 1000.
+type mockFederatedEpochStore struct{}
+
+func (mockFederatedEpochStore) MinEpoch() (uint64, bool, error) {
+	return 100, true, nil
+}
+
+func (mockFederatedEpochStore) BlockRange(epoch uint64) (citypes.RangeUint64, bool, error) {
+	return citypes.RangeUint64{From: epoch * 10, To: epoch*10 + 9}, true, nil
+}
+
+func TestCfxFederatedSplitLogFilterByEpoch(t *testing.T) {
+	h := &CfxFederatedLogsHandler{store: mockFederatedEpochStore{}}
+
+	// all synchronized locally
+	filter := &types.LogFilter{FromEpoch: types.NewEpochNumberUint64(100), ToEpoch: types.NewEpochNumberUint64(200)}
+	hist, live, err := h.SplitLogFilter(filter)
+	assert.NoError(t, err)
+	assert.Nil(t, hist)
+	assert.Equal(t, filter, live)
+
+	// all historical
+	filter = &types.LogFilter{FromEpoch: types.NewEpochNumberUint64(10), ToEpoch: types.NewEpochNumberUint64(20)}
+	hist, live, err = h.SplitLogFilter(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, types.NewEpochNumberUint64(10), hist.FromEpoch)
+	assert.Equal(t, types.NewEpochNumberUint64(20), hist.ToEpoch)
+	assert.Nil(t, live)
+
+	// across the boundary
+	filter = &types.LogFilter{FromEpoch: types.NewEpochNumberUint64(10), ToEpoch: types.NewEpochNumberUint64(200)}
+	hist, live, err = h.SplitLogFilter(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, types.NewEpochNumberUint64(99), hist.ToEpoch)
+	assert.Equal(t, types.NewEpochNumberUint64(100), live.FromEpoch)
+	assert.Equal(t, types.NewEpochNumberUint64(200), live.ToEpoch)
+
+	// epoch tags never federated
+	filter = &types.LogFilter{FromEpoch: types.EpochEarliest, ToEpoch: types.EpochLatestState}
+	hist, live, err = h.SplitLogFilter(filter)
+	assert.NoError(t, err)
+	assert.Nil(t, hist)
+	assert.Equal(t, filter, live)
+}
+
+func TestCfxFederatedSplitLogFilterByBlock(t *testing.T) {
+	h := &CfxFederatedLogsHandler{store: mockFederatedEpochStore{}}
+	newBig := func(v uint64) *hexutil.Big { return (*hexutil.Big)(new(big.Int).SetUint64(v)) }
+
+	filter := &types.LogFilter{FromBlock: newBig(500), ToBlock: newBig(1500)}
+	hist, live, err := h.SplitLogFilter(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, newBig(500), hist.FromBlock)
+	assert.Equal(t, newBig(999), hist.ToBlock)
+	assert.Equal(t, newBig(1000), live.FromBlock)
+	assert.Equal(t, newBig(1500), live.ToBlock)
+
+	// block hashes never federated
+	filter = &types.LogFilter{BlockHashes: []types.Hash{"0x01"}}
+	hist, live, err = h.SplitLogFilter(filter)
+	assert.NoError(t, err)
+	assert.Nil(t, hist)
+	assert.Equal(t, filter, live)
+}
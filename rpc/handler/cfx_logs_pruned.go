@@ -9,6 +9,7 @@ import (
 	"github.com/Conflux-Chain/confura/node"
 	"github.com/Conflux-Chain/confura/rpc/throttle"
 	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util/logging"
 	"github.com/Conflux-Chain/confura/util/rpc"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
@@ -50,7 +51,7 @@ func (h *CfxPrunedLogsHandler) GetLogs(ctx context.Context, filter types.LogFilt
 		return logs, nil
 	}
 
-	client, err := h.pool.GetClientByIP(ctx, node.GroupCfxArchives)
+	client, err := h.pool.GetClientByAffinity(ctx, node.GroupCfxArchives)
 	if err == node.ErrClientUnavailable {
 		return nil, errQuotaNotEnough
 	}
@@ -80,7 +81,7 @@ func (h *CfxPrunedLogsHandler) getLogsByUser(ctx context.Context, filter types.L
 
 	user, ok, err := h.store.GetUserByKey(key)
 	if err != nil {
-		logrus.WithError(err).WithField("key", key).Warn("Failed to get user by key")
+		logging.FromContext(ctx).WithError(err).WithField("key", key).Warn("Failed to get user by key")
 		return nil, false, err
 	}
 
@@ -91,7 +92,7 @@ func (h *CfxPrunedLogsHandler) getLogsByUser(ctx context.Context, filter types.L
 	// TODO cache client for user
 	client, err := sdk.NewClient(user.NodeUrl)
 	if err != nil {
-		logrus.WithError(err).WithFields(logrus.Fields{
+		logging.FromContext(ctx).WithError(err).WithFields(logrus.Fields{
 			"user": user.Name,
 			"node": user.NodeUrl,
 		}).Warn("Failed to connect to full node for user")
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCfxLogsClient serves event logs as the delegated fullnode.
+type fakeCfxLogsClient struct {
+	sdk.ClientOperator
+
+	logs    []types.Log
+	queried int
+}
+
+func (c *fakeCfxLogsClient) GetLogs(filter types.LogFilter) ([]types.Log, error) {
+	c.queried++
+	return c.logs, nil
+}
+
+func TestCfxGetPrunedLogsFromDelegatedFullnode(t *testing.T) {
+	cfx := &fakeCfxLogsClient{logs: []types.Log{{Data: []byte{1}}}}
+	h := NewCfxLogsApiHandler(nil, nil, nil)
+
+	// neither historical backend nor archive fullnode configured
+	logs, err := h.getPrunedLogs(context.Background(), cfx, &types.LogFilter{BlockHashes: []types.Hash{"0x01"}})
+	assert.NoError(t, err)
+	assert.Equal(t, cfx.logs, logs)
+	assert.Equal(t, 1, cfx.queried)
+
+	// too large range is never delegated to fullnode
+	filter := &types.LogFilter{
+		FromEpoch: types.NewEpochNumberUint64(0),
+		ToEpoch:   types.NewEpochNumberUint64(store.MaxLogEpochRange()),
+	}
+	_, err = h.getPrunedLogs(context.Background(), cfx, filter)
+	assert.ErrorIs(t, err, store.ErrFilterQuerySetTooLarge)
+	assert.Equal(t, 1, cfx.queried)
+}
@@ -730,7 +730,7 @@ func (h *CfxStateHandler) doRequest(
 		return result, err, false
 	}
 
-	fsCfx, cperr := h.cp.GetClientByIP(ctx, node.GroupCfxFullState)
+	fsCfx, cperr := h.cp.GetClientByAffinity(ctx, node.GroupCfxFullState)
 	if cperr == nil {
 		result, err = clientFunc(fsCfx)
 	}
@@ -153,6 +153,76 @@ func (h *CfxStoreHandler) GetTransactionReceipt(
 	return
 }
 
+// GetEpochReceipts returns receipts of the epoch grouped by block with a single query.
+func (h *CfxStoreHandler) GetEpochReceipts(
+	ctx context.Context, epochNumber uint64,
+) (receipts [][]types.TransactionReceipt, err error) {
+	rcptStore, ok := h.store.(store.EpochReceiptReadable)
+	if store.StoreConfig().IsChainReceiptDisabled() || !ok {
+		err = store.ErrUnsupported
+	} else {
+		receipts, err = h.getEpochReceipts(ctx, rcptStore, epochNumber)
+	}
+
+	h.collectHitStats("cfx_getEpochReceipts", err)
+
+	if err != nil && h.next != nil {
+		return h.next.GetEpochReceipts(ctx, epochNumber)
+	}
+
+	return
+}
+
+func (h *CfxStoreHandler) getEpochReceipts(
+	ctx context.Context, rcptStore store.EpochReceiptReadable, epochNumber uint64,
+) ([][]types.TransactionReceipt, error) {
+	blockHashes, err := h.store.GetBlocksByEpoch(ctx, epochNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	srcpts, err := rcptStore.GetEpochReceipts(ctx, epochNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// group receipts by block in the order of blocks within epoch
+	blockReceipts := make(map[types.Hash][]types.TransactionReceipt, len(blockHashes))
+	for _, srcpt := range srcpts {
+		blockHash := srcpt.CfxReceipt.BlockHash
+		blockReceipts[blockHash] = append(blockReceipts[blockHash], *srcpt.CfxReceipt)
+	}
+
+	receipts := make([][]types.TransactionReceipt, len(blockHashes))
+	for i, blockHash := range blockHashes {
+		receipts[i] = blockReceipts[blockHash]
+		if receipts[i] == nil {
+			receipts[i] = []types.TransactionReceipt{}
+		}
+	}
+
+	return receipts, nil
+}
+
+func (h *CfxStoreHandler) GetAccountTransactions(
+	ctx context.Context, filter store.AccountTxnFilter,
+) (txHashes []types.Hash, err error) {
+	atStore, ok := h.store.(store.AccountTxnReadable)
+	if store.StoreConfig().IsChainTxnDisabled() || !ok {
+		err = store.ErrUnsupported
+	} else {
+		txHashes, err = atStore.GetAccountTransactions(ctx, filter)
+	}
+
+	h.collectHitStats("cfx_getAccountTransactions", err)
+
+	if err != nil && h.next != nil {
+		return h.next.GetAccountTransactions(ctx, filter)
+	}
+
+	return
+}
+
 func (h *CfxStoreHandler) collectHitStats(method string, err error) {
 	if !errors.Is(err, store.ErrUnsupported) { // ignore unsupported samples
 		metrics.Registry.RPC.StoreHit(method, h.sname).Mark(err == nil)
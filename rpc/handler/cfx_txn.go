@@ -2,6 +2,7 @@ package handler
 
 import (
 	"strings"
+	"sync"
 
 	"github.com/Conflux-Chain/confura/node"
 	"github.com/Conflux-Chain/confura/util"
@@ -9,6 +10,7 @@ import (
 	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
 	"github.com/Conflux-Chain/go-conflux-util/viper"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -24,6 +26,7 @@ type CfxTxnHandler struct {
 	nclient  *rpc.Client         // node RPC client
 	clients  *util.ConcurrentMap // sdk clients: node name => RPC client
 	relayTxn bool                // whether to relay to other group nodes while sending txn
+	tracker  *PendingTxnTracker  // pending transaction tracker, nil if disabled
 }
 
 func MustNewCfxTxnHandler(relayer relay.TxnRelayer) *CfxTxnHandler {
@@ -34,7 +37,7 @@ func MustNewCfxTxnHandler(relayer relay.TxnRelayer) *CfxTxnHandler {
 	if nodeRpcUrl := node.Config().Router.NodeRPCURL; len(nodeRpcUrl) > 0 {
 		var err error
 
-		nodeRpcClient, err = rpc.DialHTTP(nodeRpcUrl)
+		nodeRpcClient, err = rpcutil.DialAdminHTTP(nodeRpcUrl, node.Config().AuthToken)
 		if err != nil {
 			logrus.WithField("nodeRpcUrl", nodeRpcUrl).
 				WithError(err).
@@ -47,15 +50,31 @@ func MustNewCfxTxnHandler(relayer relay.TxnRelayer) *CfxTxnHandler {
 		nclient:  nodeRpcClient,
 		clients:  &util.ConcurrentMap{},
 		relayTxn: cfg.RelayTxn,
+		tracker:  MustNewPendingTxnTrackerFromViper(),
 	}
 }
 
 func (h *CfxTxnHandler) SendRawTxn(cfx sdk.ClientOperator, group node.Group, signedTx hexutil.Bytes) (types.Hash, error) {
+	tx, tracked := h.decodeTrackedTxn(cfx, signedTx)
 	txHash, err := cfx.SendRawTransaction(signedTx)
 	if err != nil {
+		if tracked {
+			err = h.tracker.Diagnose(err, tx.sender, tx.nonce, func() (uint64, error) {
+				nonce, err := cfx.GetNextNonce(cfxaddress.MustNewFromBase32(tx.sender))
+				if err != nil {
+					return 0, err
+				}
+				return nonce.ToInt().Uint64(), nil
+			})
+		}
+
 		return txHash, err
 	}
 
+	if tracked {
+		h.tracker.Track(tx.sender, tx.nonce, tx.hash)
+	}
+
 	// relay transaction broadcasting asynchronously
 	if h.relayer != nil && !h.relayer.Relay(signedTx) {
 		logrus.Info("Txn relay pool is full, dropping transaction")
@@ -69,6 +88,39 @@ func (h *CfxTxnHandler) SendRawTxn(cfx sdk.ClientOperator, group node.Group, sig
 	return txHash, err
 }
 
+// decodeTrackedTxn decodes the raw transaction for pending transaction tracking if enabled.
+func (h *CfxTxnHandler) decodeTrackedTxn(cfx sdk.ClientOperator, signedTx hexutil.Bytes) (txn pendingTxn, ok bool) {
+	if h.tracker == nil {
+		return txn, false
+	}
+
+	networkId, err := cfx.GetNetworkID()
+	if err != nil {
+		return txn, false
+	}
+
+	var tx types.SignedTransaction
+	if err := tx.Decode(signedTx, networkId); err != nil || tx.UnsignedTransaction.Nonce == nil {
+		return txn, false
+	}
+
+	sender, err := tx.Sender(networkId)
+	if err != nil {
+		return txn, false
+	}
+
+	hash, err := tx.Hash()
+	if err != nil {
+		return txn, false
+	}
+
+	return pendingTxn{
+		sender: sender.String(),
+		nonce:  tx.UnsignedTransaction.Nonce.ToInt().Uint64(),
+		hash:   hexutil.Encode(hash),
+	}, true
+}
+
 // replicateRawTxnSendingByGroup synchronously replicate raw txn sending to all full nodes of some specific group
 func (h *CfxTxnHandler) replicateRawTxnSendingByGroup(cfx sdk.ClientOperator, group node.Group, signedTx hexutil.Bytes) {
 	if h.nclient != nil { // fetch group nodes from node RPC
@@ -94,6 +146,10 @@ func (h *CfxTxnHandler) replicateRawTxnSendingByGroup(cfx sdk.ClientOperator, gr
 func (h *CfxTxnHandler) replicateRawTxnSendingToNodes(cfx sdk.ClientOperator, nodeUrls []string, signedTx hexutil.Bytes) {
 	initialNodeName := rpcutil.Url2NodeName(cfx.GetNodeURL())
 
+	// fan out to full nodes concurrently for faster propagation
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
 	for _, url := range nodeUrls {
 		nodeName := rpcutil.Url2NodeName(url)
 		if strings.EqualFold(nodeName, initialNodeName) {
@@ -112,11 +168,16 @@ func (h *CfxTxnHandler) replicateRawTxnSendingToNodes(cfx sdk.ClientOperator, no
 			continue
 		}
 
-		_, err = c.(sdk.ClientOperator).SendRawTransaction(signedTx)
-		if err != nil && !utils.IsRPCJSONError(err) {
-			logrus.WithField("url", url).
-				WithError(err).
-				Info("Txn handler failed to replicate sending cfx raw transaction")
-		}
+		wg.Add(1)
+		go func(url string, c interface{}) {
+			defer wg.Done()
+
+			_, err := c.(sdk.ClientOperator).SendRawTransaction(signedTx)
+			if err != nil && !utils.IsRPCJSONError(err) {
+				logrus.WithField("url", url).
+					WithError(err).
+					Info("Txn handler failed to replicate sending cfx raw transaction")
+			}
+		}(url, c)
 	}
 }
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// max size in bytes of contract ABI to upload
+	maxContractAbiSize = 1 << 20
+)
+
+// EthAbiRegistryConfig represents the configuration of contract ABI registry.
+type EthAbiRegistryConfig struct {
+	Enabled bool
+	// max number of parsed contract ABIs cached in memory
+	CacheSize int `default:"1000"`
+	// expiration duration of cached contract ABIs, so that uploaded ABIs take effect on all instances
+	CacheTTL time.Duration `default:"1m"`
+	// JSON-RPC endpoint to manage contract ABIs, disabled if empty
+	AdminEndpoint string
+	// bearer token to authenticate admin requests, required if admin endpoint configured
+	AuthToken string
+}
+
+// EthDecodedEvent is the event decoded from event log with the contract ABI.
+type EthDecodedEvent struct {
+	Name      string                 `json:"name"`
+	Signature string                 `json:"signature"`
+	Args      map[string]interface{} `json:"args"`
+}
+
+// EthDecodedLog is the event log along with the decoded event if contract ABI is known.
+type EthDecodedLog struct {
+	Log     *web3Types.Log   `json:"log"`
+	Decoded *EthDecodedEvent `json:"decoded,omitempty"`
+}
+
+// EthAbiRegistry maintains contract ABIs uploaded by admin in database, which are used to decode
+// event logs for explorer-style consumers.
+type EthAbiRegistry struct {
+	conf  EthAbiRegistryConfig
+	store *mysql.AbiStore
+
+	// parsed contract ABIs (or nil if not found) keyed by lowercase contract address
+	cache *util.ExpirableLruCache
+}
+
+// MustNewEthAbiRegistryFromViper creates contract ABI registry, or nil if not enabled.
+func MustNewEthAbiRegistryFromViper(db *mysql.MysqlStore) *EthAbiRegistry {
+	var conf EthAbiRegistryConfig
+	viper.MustUnmarshalKey("ethrpc.abiRegistry", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	if db == nil {
+		logrus.Fatal("DB store required for contract ABI registry")
+	}
+
+	if len(conf.AdminEndpoint) > 0 && len(conf.AuthToken) == 0 {
+		logrus.Fatal("Auth token required for contract ABI registry admin endpoint")
+	}
+
+	return newEthAbiRegistry(conf, db.AbiStore)
+}
+
+func newEthAbiRegistry(conf EthAbiRegistryConfig, store *mysql.AbiStore) *EthAbiRegistry {
+	return &EthAbiRegistry{
+		conf:  conf,
+		store: store,
+		cache: util.NewExpirableLruCache(conf.CacheSize, conf.CacheTTL),
+	}
+}
+
+// Config returns the configuration of contract ABI registry.
+func (r *EthAbiRegistry) Config() EthAbiRegistryConfig {
+	return r.conf
+}
+
+// contractAbi returns the parsed contract ABI of the specified address, or nil if not found.
+func (r *EthAbiRegistry) contractAbi(addr common.Address) (*abi.ABI, error) {
+	key := strings.ToLower(addr.Hex())
+
+	val, err := r.cache.GetOrUpdate(key, func() (interface{}, error) {
+		entry, ok, err := r.store.GetContractAbi(key)
+		if err != nil || !ok {
+			return (*abi.ABI)(nil), err
+		}
+
+		parsed, err := abi.JSON(strings.NewReader(entry.Abi))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to parse ABI of contract %v", key)
+		}
+
+		return &parsed, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return val.(*abi.ABI), nil
+}
+
+// DecodeLogs decodes the event logs with the known contract ABIs. Event logs of unknown contracts
+// or events are returned without decoded event.
+func (r *EthAbiRegistry) DecodeLogs(logs []web3Types.Log) ([]EthDecodedLog, error) {
+	result := make([]EthDecodedLog, len(logs))
+
+	for i := range logs {
+		result[i].Log = &logs[i]
+
+		contractAbi, err := r.contractAbi(logs[i].Address)
+		if err != nil {
+			return nil, err
+		}
+
+		if contractAbi != nil {
+			result[i].Decoded = decodeEthLog(contractAbi, &logs[i])
+		}
+	}
+
+	return result, nil
+}
+
+// decodeEthLog decodes the event log with contract ABI, or returns nil if failed to decode.
+func decodeEthLog(contractAbi *abi.ABI, log *web3Types.Log) *EthDecodedEvent {
+	if len(log.Topics) == 0 { // anonymous event
+		return nil
+	}
+
+	event, err := contractAbi.EventByID(log.Topics[0])
+	if err != nil {
+		return nil
+	}
+
+	args := make(map[string]interface{})
+	if len(log.Data) > 0 {
+		if err := event.Inputs.UnpackIntoMap(args, log.Data); err != nil {
+			return nil
+		}
+	}
+
+	var indexed abi.Arguments
+	for _, arg := range event.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+
+	if err := abi.ParseTopicsIntoMap(args, indexed, log.Topics[1:]); err != nil {
+		return nil
+	}
+
+	for k, v := range args {
+		args[k] = normalizeAbiValue(v)
+	}
+
+	return &EthDecodedEvent{Name: event.Name, Signature: event.Sig, Args: args}
+}
+
+// normalizeAbiValue converts the decoded values into JSON friendly ones, e.g., big integers and
+// bytes in hex.
+func normalizeAbiValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case *big.Int:
+		return (*hexutil.Big)(val)
+	case []byte:
+		return hexutil.Bytes(val)
+	case [32]byte:
+		return common.Hash(val)
+	default:
+		return v
+	}
+}
+
+// EthAbiAdminAPI provides JSON-RPC methods to manage contract ABIs, which is served in namespace `abi`.
+type EthAbiAdminAPI struct {
+	registry *EthAbiRegistry
+}
+
+// NewEthAbiAdminAPI creates admin API of contract ABI registry.
+func NewEthAbiAdminAPI(registry *EthAbiRegistry) *EthAbiAdminAPI {
+	return &EthAbiAdminAPI{registry: registry}
+}
+
+// Upload adds or replaces the ABI (in JSON) of the specified contract.
+func (api *EthAbiAdminAPI) Upload(ctx context.Context, addr common.Address, name, abiJson string) error {
+	if len(abiJson) > maxContractAbiSize {
+		return errors.Errorf("ABI too large, exceeds the maximum allowed %v bytes", maxContractAbiSize)
+	}
+
+	if _, err := abi.JSON(strings.NewReader(abiJson)); err != nil {
+		return errors.WithMessage(err, "invalid ABI")
+	}
+
+	key := strings.ToLower(addr.Hex())
+	if err := api.registry.store.UpsertContractAbi(key, name, abiJson); err != nil {
+		return errors.WithMessage(err, "failed to upsert contract ABI")
+	}
+
+	api.registry.cache.Del(key)
+
+	return nil
+}
+
+// Get returns the ABI of the specified contract, or nil if not found.
+func (api *EthAbiAdminAPI) Get(ctx context.Context, addr common.Address) (*mysql.ContractAbi, error) {
+	entry, _, err := api.registry.store.GetContractAbi(strings.ToLower(addr.Hex()))
+	return entry, err
+}
+
+// List returns the uploaded contracts (without ABI content) in ascending order of ID since the
+// specified ID, at most 1000 contracts at a time.
+func (api *EthAbiAdminAPI) List(ctx context.Context, fromID uint32, limit int) ([]mysql.ContractAbi, error) {
+	return api.registry.store.ContractAbis(fromID, limit)
+}
+
+// Remove removes the ABI of the specified contract.
+func (api *EthAbiAdminAPI) Remove(ctx context.Context, addr common.Address) (bool, error) {
+	key := strings.ToLower(addr.Hex())
+
+	removed, err := api.registry.store.DeleteContractAbi(key)
+	if err != nil {
+		return false, err
+	}
+
+	api.registry.cache.Del(key)
+
+	return removed, nil
+}
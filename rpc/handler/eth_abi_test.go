@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const testErc20TransferAbi = `[{
+	"anonymous": false,
+	"type": "event",
+	"name": "Transfer",
+	"inputs": [
+		{"indexed": true, "name": "from", "type": "address"},
+		{"indexed": true, "name": "to", "type": "address"},
+		{"indexed": false, "name": "value", "type": "uint256"}
+	]
+}]`
+
+func TestDecodeEthLog(t *testing.T) {
+	contractAbi, err := abi.JSON(strings.NewReader(testErc20TransferAbi))
+	assert.NoError(t, err)
+
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	log := web3Types.Log{
+		Topics: []common.Hash{
+			contractAbi.Events["Transfer"].ID,
+			common.BytesToHash(from.Bytes()),
+			common.BytesToHash(to.Bytes()),
+		},
+		Data: common.BigToHash(big.NewInt(1000)).Bytes(),
+	}
+
+	decoded := decodeEthLog(&contractAbi, &log)
+	assert.NotNil(t, decoded)
+	assert.Equal(t, "Transfer", decoded.Name)
+	assert.Equal(t, "Transfer(address,address,uint256)", decoded.Signature)
+	assert.Equal(t, from, decoded.Args["from"])
+	assert.Equal(t, to, decoded.Args["to"])
+	assert.Equal(t, (*hexutil.Big)(big.NewInt(1000)), decoded.Args["value"])
+
+	// unknown event
+	log.Topics[0] = common.HexToHash("0x01")
+	assert.Nil(t, decodeEthLog(&contractAbi, &log))
+
+	// anonymous event
+	log.Topics = nil
+	assert.Nil(t, decodeEthLog(&contractAbi, &log))
+}
+
+func TestNormalizeAbiValue(t *testing.T) {
+	assert.Equal(t, hexutil.Bytes{0x01, 0x02}, normalizeAbiValue([]byte{0x01, 0x02}))
+	assert.Equal(t, common.HexToHash("0x01"), normalizeAbiValue([32]byte(common.HexToHash("0x01"))))
+	assert.Equal(t, uint8(1), normalizeAbiValue(uint8(1)))
+}
@@ -0,0 +1,283 @@
+package handler
+
+import (
+	"sync"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	logutil "github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errBlockCacheReorging = errors.New("chain reorg in progress")
+)
+
+// EthBlockCacheConfig is the settings of in-memory cache of the latest blocks.
+type EthBlockCacheConfig struct {
+	Enabled bool
+	// number of latest blocks to cache in memory
+	Size int `default:"64"`
+}
+
+// ethTxLocation is the location of transaction within the cached blocks.
+type ethTxLocation struct {
+	blockNum uint64
+	index    int
+}
+
+// EthBlockCache keeps the latest blocks with full transactions in memory keyed by both block hash
+// and number, which is fed by the chain head tracker and invalidated on chain reorg, so that the
+// highest volume `eth_getBlockByNumber`, `eth_getBlockByHash` and `eth_getTransactionByHash` of
+// recent blocks could be served without touching database or full node.
+type EthBlockCache struct {
+	conf EthBlockCacheConfig
+
+	mu     sync.RWMutex
+	blocks []*web3Types.Block // contiguous blocks in ascending order
+	hashes map[common.Hash]uint64
+	txns   map[common.Hash]ethTxLocation
+
+	etLogger *logutil.ErrorTolerantLogger
+}
+
+// MustNewEthBlockCacheFromViper creates block cache fed by head tracker, or nil if not enabled.
+func MustNewEthBlockCacheFromViper(headTracker *EthHeadTracker) *EthBlockCache {
+	var conf EthBlockCacheConfig
+	viper.MustUnmarshalKey("ethrpc.blockCache", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	if headTracker == nil {
+		logrus.Fatal("Head tracker is required for block cache")
+	}
+
+	if conf.Size <= 0 {
+		logrus.WithField("size", conf.Size).Fatal("Invalid block cache size")
+	}
+
+	c := newEthBlockCache(conf)
+	headTracker.Observe(func(latest *web3Types.Block, client *node.Web3goClient) {
+		err := c.advance(latest, client)
+		c.etLogger.Log(
+			logrus.WithField("latest", latest.Number), err, "Block cache failed to advance",
+		)
+	})
+
+	return c
+}
+
+func newEthBlockCache(conf EthBlockCacheConfig) *EthBlockCache {
+	return &EthBlockCache{
+		conf:     conf,
+		hashes:   make(map[common.Hash]uint64),
+		txns:     make(map[common.Hash]ethTxLocation),
+		etLogger: logutil.NewErrorTolerantLogger(logutil.DefaultETConfig),
+	}
+}
+
+// advance appends the new latest block and the missing blocks in between into cache, or evicts
+// the reorged blocks if the latest block is not continuous to cache.
+func (c *EthBlockCache) advance(latest *web3Types.Block, client *node.Web3goClient) error {
+	block, err := client.Eth.BlockByHash(latest.Hash, true)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get block")
+	}
+
+	// chain reorged during polling, and try again on next head
+	if block == nil {
+		return errBlockCacheReorging
+	}
+
+	// new blocks to append in ascending order
+	newBlocks := []*web3Types.Block{block}
+	reset := false
+
+	for {
+		head := newBlocks[0]
+
+		c.mu.RLock()
+		parent, ok := c.blockAt(head.Number.Uint64() - 1)
+		empty := len(c.blocks) == 0
+		var firstNum uint64
+		if !empty {
+			firstNum = c.blocks[0].Number.Uint64()
+		}
+		c.mu.RUnlock()
+
+		if ok && parent.Hash == head.ParentHash { // continuous to cache
+			break
+		}
+
+		// cache is empty or reorg too deep
+		if empty || head.Number.Uint64() <= firstNum || len(newBlocks) >= c.conf.Size {
+			reset = true
+			break
+		}
+
+		block, err := client.Eth.BlockByHash(head.ParentHash, true)
+		if err != nil {
+			return errors.WithMessage(err, "failed to get block")
+		}
+
+		if block == nil {
+			return errBlockCacheReorging
+		}
+
+		newBlocks = append([]*web3Types.Block{block}, newBlocks...)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if reset {
+		c.evict(0)
+	} else if len(c.blocks) > 0 { // evict the reorged blocks if any
+		c.evict(int(newBlocks[0].Number.Uint64() - c.blocks[0].Number.Uint64()))
+	}
+
+	for _, block := range newBlocks {
+		c.add(block)
+	}
+
+	if len(c.blocks) > c.conf.Size {
+		c.prune(len(c.blocks) - c.conf.Size)
+	}
+
+	metrics.Registry.RPC.Percentage("eth_blockCache", "reset").Mark(reset)
+
+	return nil
+}
+
+// add appends the block into cache, which requires write lock held.
+func (c *EthBlockCache) add(block *web3Types.Block) {
+	c.blocks = append(c.blocks, block)
+	c.hashes[block.Hash] = block.Number.Uint64()
+
+	for i, txHash := range ethBlockTxnHashes(block) {
+		c.txns[txHash] = ethTxLocation{blockNum: block.Number.Uint64(), index: i}
+	}
+}
+
+// evict removes the cached blocks since the specified position, which requires write lock held.
+func (c *EthBlockCache) evict(from int) {
+	for _, block := range c.blocks[from:] {
+		c.remove(block)
+	}
+
+	c.blocks = c.blocks[:from]
+}
+
+// prune removes the specified number of oldest blocks, which requires write lock held.
+func (c *EthBlockCache) prune(num int) {
+	for _, block := range c.blocks[:num] {
+		c.remove(block)
+	}
+
+	c.blocks = c.blocks[num:]
+}
+
+func (c *EthBlockCache) remove(block *web3Types.Block) {
+	delete(c.hashes, block.Hash)
+
+	for _, txHash := range ethBlockTxnHashes(block) {
+		// the same transaction may be re-packed into the new block after reorg
+		if loc, ok := c.txns[txHash]; ok && loc.blockNum == block.Number.Uint64() {
+			delete(c.txns, txHash)
+		}
+	}
+}
+
+// blockAt returns the cached block of the specified block number, which requires read lock held.
+func (c *EthBlockCache) blockAt(bn uint64) (*web3Types.Block, bool) {
+	if len(c.blocks) == 0 || bn < c.blocks[0].Number.Uint64() || bn > c.blocks[len(c.blocks)-1].Number.Uint64() {
+		return nil, false
+	}
+
+	return c.blocks[bn-c.blocks[0].Number.Uint64()], true
+}
+
+// BlockByNumber returns the cached block of the specified block number.
+func (c *EthBlockCache) BlockByNumber(bn uint64, fullTx bool) (*web3Types.Block, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	block, ok := c.blockAt(bn)
+	if !ok {
+		return nil, false
+	}
+
+	return ethBlockWithTxs(block, fullTx), true
+}
+
+// BlockByHash returns the cached block of the specified block hash.
+func (c *EthBlockCache) BlockByHash(hash common.Hash, fullTx bool) (*web3Types.Block, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	bn, ok := c.hashes[hash]
+	if !ok {
+		return nil, false
+	}
+
+	block, ok := c.blockAt(bn)
+	if !ok {
+		return nil, false
+	}
+
+	return ethBlockWithTxs(block, fullTx), true
+}
+
+// TransactionByHash returns the transaction of the cached blocks.
+func (c *EthBlockCache) TransactionByHash(hash common.Hash) (*web3Types.TransactionDetail, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	loc, ok := c.txns[hash]
+	if !ok {
+		return nil, false
+	}
+
+	block, ok := c.blockAt(loc.blockNum)
+	if !ok {
+		return nil, false
+	}
+
+	txns := block.Transactions.Transactions()
+	if loc.index >= len(txns) {
+		return nil, false
+	}
+
+	return &txns[loc.index], true
+}
+
+// ethBlockWithTxs returns the block with full transactions or only transaction hashes.
+func ethBlockWithTxs(block *web3Types.Block, fullTx bool) *web3Types.Block {
+	if fullTx {
+		return block
+	}
+
+	header := *block
+	header.Transactions = *web3Types.NewTxOrHashListByHashes(ethBlockTxnHashes(block))
+
+	return &header
+}
+
+func ethBlockTxnHashes(block *web3Types.Block) (txnHashes []common.Hash) {
+	if block.Transactions.Type() == web3Types.TXLIST_HASH {
+		return block.Transactions.Hashes()
+	}
+
+	txns := block.Transactions.Transactions()
+	for i := 0; i < len(txns); i++ {
+		txnHashes = append(txnHashes, txns[i].Hash)
+	}
+
+	return txnHashes
+}
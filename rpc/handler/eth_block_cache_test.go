@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEthCacheBlock(bn uint64, hash string, txHashes ...string) *web3Types.Block {
+	block := &web3Types.Block{Number: new(big.Int).SetUint64(bn), Hash: common.HexToHash(hash)}
+
+	var txns []web3Types.TransactionDetail
+	for _, txHash := range txHashes {
+		txns = append(txns, web3Types.TransactionDetail{Hash: common.HexToHash(txHash)})
+	}
+	block.Transactions = *web3Types.NewTxOrHashListByTxs(txns)
+
+	return block
+}
+
+func TestEthBlockCache(t *testing.T) {
+	c := newEthBlockCache(EthBlockCacheConfig{Size: 2})
+	c.add(newTestEthCacheBlock(10, "0x0a", "0xa1"))
+	c.add(newTestEthCacheBlock(11, "0x0b", "0xb1", "0xb2"))
+
+	block, ok := c.BlockByNumber(11, true)
+	assert.True(t, ok)
+	assert.Len(t, block.Transactions.Transactions(), 2)
+
+	block, ok = c.BlockByHash(common.HexToHash("0x0b"), false)
+	assert.True(t, ok)
+	assert.Equal(t, []common.Hash{common.HexToHash("0xb1"), common.HexToHash("0xb2")}, block.Transactions.Hashes())
+
+	tx, ok := c.TransactionByHash(common.HexToHash("0xb2"))
+	assert.True(t, ok)
+	assert.Equal(t, common.HexToHash("0xb2"), tx.Hash)
+
+	// reorged block evicted, and the re-packed transaction served from the new block
+	c.evict(1)
+	c.add(newTestEthCacheBlock(11, "0x0c", "0xb1"))
+
+	_, ok = c.BlockByHash(common.HexToHash("0x0b"), true)
+	assert.False(t, ok)
+	_, ok = c.TransactionByHash(common.HexToHash("0xb2"))
+	assert.False(t, ok)
+	_, ok = c.TransactionByHash(common.HexToHash("0xb1"))
+	assert.True(t, ok)
+
+	// oldest block pruned
+	c.add(newTestEthCacheBlock(12, "0x0d"))
+	c.prune(1)
+
+	_, ok = c.BlockByNumber(10, true)
+	assert.False(t, ok)
+	_, ok = c.TransactionByHash(common.HexToHash("0xa1"))
+	assert.False(t, ok)
+}
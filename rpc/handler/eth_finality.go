@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"math/big"
+
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/web3go"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errFinalityTagUnsupported = errors.New("finalized block tag not supported by upstream full node")
+)
+
+// FinalityConfig represents the configuration to resolve `safe` and `finalized` block tags.
+type FinalityConfig struct {
+	// Number of blocks behind `latest` as `safe` block, if not supported by upstream full node.
+	SafeDepth uint64 `default:"50"`
+	// Core space full node to query PoS finality data, if not supported by upstream full node.
+	PosNode string
+}
+
+// EthFinalityResolver resolves `safe` and `finalized` block tags to concrete block numbers.
+type EthFinalityResolver struct {
+	conf        FinalityConfig
+	headTracker *EthHeadTracker
+	posClient   *sdk.Client // core space client for PoS finality data, nil if not configured
+}
+
+func MustNewEthFinalityResolverFromViper(headTracker *EthHeadTracker) *EthFinalityResolver {
+	var conf FinalityConfig
+	viper.MustUnmarshalKey("ethrpc.finality", &conf)
+
+	r := &EthFinalityResolver{conf: conf, headTracker: headTracker}
+	if len(conf.PosNode) > 0 {
+		r.posClient = rpcutil.MustNewCfxClient(conf.PosNode)
+	}
+
+	return r
+}
+
+// IsFinalityTag checks if the block number is `safe` or `finalized` block tag.
+func IsFinalityTag(blockNum web3Types.BlockNumber) bool {
+	return blockNum == web3Types.SafeBlockNumber || blockNum == web3Types.FinalizedBlockNumber
+}
+
+// Resolve resolves `safe` or `finalized` block tag to concrete block number in order of head
+// tracker, upstream full node and PoS finality data. Other block numbers are returned directly.
+func (r *EthFinalityResolver) Resolve(
+	w3c *web3go.Client, blockNum web3Types.BlockNumber,
+) (web3Types.BlockNumber, error) {
+	if !IsFinalityTag(blockNum) {
+		return blockNum, nil
+	}
+
+	if r.headTracker != nil {
+		if header, ok := r.headTracker.Header(blockNum); ok {
+			return web3Types.BlockNumber(header.Number.Int64()), nil
+		}
+	}
+
+	block, err := w3c.Eth.BlockByNumber(blockNum, false)
+	if err == nil && block != nil {
+		return web3Types.BlockNumber(block.Number.Int64()), nil
+	}
+
+	logrus.WithField("blockNum", finalityTagText(blockNum)).
+		WithError(err).
+		Debug("Failed to resolve finality block tag from upstream full node")
+
+	if r.posClient != nil {
+		num, err := r.resolveByPos(blockNum)
+		if err == nil {
+			return num, nil
+		}
+
+		logrus.WithError(err).Debug("Failed to resolve finality block tag from PoS finality data")
+	}
+
+	if blockNum == web3Types.FinalizedBlockNumber {
+		return 0, errFinalityTagUnsupported
+	}
+
+	latest, err := w3c.Eth.BlockNumber()
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to get latest block number")
+	}
+
+	return safeBlockNumber(latest, r.conf.SafeDepth), nil
+}
+
+// resolveByPos resolves `finalized` block tag to the PoS finalized epoch, and `safe` block tag
+// to the latest confirmed epoch of core space.
+func (r *EthFinalityResolver) resolveByPos(blockNum web3Types.BlockNumber) (web3Types.BlockNumber, error) {
+	status, err := r.posClient.GetStatus()
+	if err != nil {
+		return 0, err
+	}
+
+	if blockNum == web3Types.FinalizedBlockNumber {
+		return web3Types.BlockNumber(status.LatestFinalized), nil
+	}
+
+	return web3Types.BlockNumber(status.LatestConfirmed), nil
+}
+
+// safeBlockNumber returns the block number of depth behind the latest block number.
+func safeBlockNumber(latest *big.Int, depth uint64) web3Types.BlockNumber {
+	if latest.Uint64() <= depth {
+		return 0
+	}
+
+	return web3Types.BlockNumber(latest.Uint64() - depth)
+}
+
+func finalityTagText(blockNum web3Types.BlockNumber) string {
+	text, _ := blockNum.MarshalText()
+	return string(text)
+}
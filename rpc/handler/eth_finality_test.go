@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/web3go"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFinalityUpstream is a fake evm space full node, which optionally supports finality tags.
+type testFinalityUpstream struct {
+	head      uint64
+	supported bool
+}
+
+func (u *testFinalityUpstream) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(u.head)
+}
+
+func (u *testFinalityUpstream) GetBlockByNumber(bn web3Types.BlockNumber, fullTx bool) (map[string]interface{}, error) {
+	number := uint64(bn)
+
+	switch bn {
+	case web3Types.SafeBlockNumber, web3Types.FinalizedBlockNumber:
+		if !u.supported {
+			return nil, errors.New("invalid block tag")
+		}
+
+		number = u.head - 10
+		if bn == web3Types.FinalizedBlockNumber {
+			number = u.head - 20
+		}
+	}
+
+	return map[string]interface{}{
+		"hash":         common.Hash{},
+		"parentHash":   common.Hash{},
+		"number":       hexutil.Uint64(number),
+		"difficulty":   "0x0",
+		"transactions": []common.Hash{},
+	}, nil
+}
+
+func newTestFinalityClient(t *testing.T, upstream *testFinalityUpstream) *web3go.Client {
+	srv := rpc.NewServer()
+	require.NoError(t, srv.RegisterName("eth", upstream))
+
+	httpSrv := httptest.NewServer(srv)
+	t.Cleanup(httpSrv.Close)
+
+	client, err := web3go.NewClient(httpSrv.URL)
+	require.NoError(t, err)
+
+	return client
+}
+
+func TestEthFinalityResolverNonFinalityTag(t *testing.T) {
+	r := &EthFinalityResolver{conf: FinalityConfig{SafeDepth: 50}}
+
+	// returned directly without requesting full node
+	for _, bn := range []web3Types.BlockNumber{web3Types.LatestBlockNumber, web3Types.PendingBlockNumber, 100} {
+		resolved, err := r.Resolve(nil, bn)
+		assert.NoError(t, err)
+		assert.Equal(t, bn, resolved)
+	}
+}
+
+func TestEthFinalityResolverHeadTracker(t *testing.T) {
+	tracker := &EthHeadTracker{headTracker: newHeadTracker(HeadTrackerConfig{MaxStaleness: time.Minute})}
+	tracker.set(ethHeadTag(web3Types.SafeBlockNumber), &web3Types.Block{Number: big.NewInt(990)})
+
+	r := &EthFinalityResolver{conf: FinalityConfig{SafeDepth: 50}, headTracker: tracker}
+
+	resolved, err := r.Resolve(nil, web3Types.SafeBlockNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, web3Types.BlockNumber(990), resolved)
+}
+
+func TestEthFinalityResolverUpstream(t *testing.T) {
+	w3c := newTestFinalityClient(t, &testFinalityUpstream{head: 1000, supported: true})
+	r := &EthFinalityResolver{conf: FinalityConfig{SafeDepth: 50}}
+
+	resolved, err := r.Resolve(w3c, web3Types.SafeBlockNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, web3Types.BlockNumber(990), resolved)
+
+	resolved, err = r.Resolve(w3c, web3Types.FinalizedBlockNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, web3Types.BlockNumber(980), resolved)
+}
+
+func TestEthFinalityResolverUnsupported(t *testing.T) {
+	w3c := newTestFinalityClient(t, &testFinalityUpstream{head: 1000})
+	r := &EthFinalityResolver{conf: FinalityConfig{SafeDepth: 50}}
+
+	// `safe` falls back to depth behind the latest block
+	resolved, err := r.Resolve(w3c, web3Types.SafeBlockNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, web3Types.BlockNumber(950), resolved)
+
+	resolved, err = r.Resolve(newTestFinalityClient(t, &testFinalityUpstream{head: 10}), web3Types.SafeBlockNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, web3Types.BlockNumber(0), resolved)
+
+	_, err = r.Resolve(w3c, web3Types.FinalizedBlockNumber)
+	assert.ErrorIs(t, err, errFinalityTagUnsupported)
+}
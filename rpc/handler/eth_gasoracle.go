@@ -0,0 +1,424 @@
+package handler
+
+import (
+	"context"
+	"math/big"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/confura/rpc/cfxbridge"
+	"github.com/Conflux-Chain/confura/rpc/ethbridge"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// expiration duration of cached block fees
+	gasOracleCacheTTL = 10 * time.Minute
+)
+
+var (
+	// errGasOracleMissed is returned if data is not available from store, in which case
+	// requests should be delegated to full node.
+	errGasOracleMissed = errors.New("gas oracle cache missed")
+)
+
+// GasOracleConfig represents the configuration of gas oracle.
+type GasOracleConfig struct {
+	// Whether to enable gas oracle.
+	Enabled bool
+	// Number of recent blocks to suggest priority fee.
+	Blocks uint64 `default:"20"`
+	// Percentile of recent priority fees to suggest.
+	Percentile float64 `default:"60"`
+	// Max number of block fees to cache.
+	CacheSize int `default:"1024"`
+	// Base fee adjustment parameters, which are the same as EIP-1559 by default.
+	BaseFeeChangeDenominator int64 `default:"8"`
+	ElasticityMultiplier     int64 `default:"2"`
+}
+
+// txnTip is the effective priority fee per gas paid by transaction.
+type txnTip struct {
+	tip     *big.Int
+	gasUsed uint64
+}
+
+// blockFees is the fee data of block to compute fee history.
+type blockFees struct {
+	number       uint64
+	hash         common.Hash
+	parentHash   common.Hash
+	baseFee      *big.Int
+	gasLimit     uint64
+	gasUsed      uint64
+	gasUsedRatio float64
+	tips         []txnTip // sorted by tip in ascending order
+}
+
+// gasSuggestion is the suggested priority fee and base fee of the next block of head block.
+type gasSuggestion struct {
+	number  uint64
+	hash    common.Hash
+	tip     *big.Int
+	baseFee *big.Int
+}
+
+// EthGasOracle computes fee history and gas price suggestion from recently synced blocks and
+// receipts in store, so as to offload these hot methods from full nodes.
+type EthGasOracle struct {
+	conf  *GasOracleConfig
+	store *mysql.MysqlStore
+	cache *util.ExpirableLruCache // block number => *blockFees
+
+	// gas price suggestion cached for the head block, which is recomputed once head changed
+	suggestion atomic.Pointer[gasSuggestion]
+}
+
+func MustNewEthGasOracleFromViper(store *mysql.MysqlStore) *EthGasOracle {
+	var conf GasOracleConfig
+	viper.MustUnmarshalKey("ethrpc.gasOracle", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	return NewEthGasOracle(&conf, store)
+}
+
+func NewEthGasOracle(conf *GasOracleConfig, store *mysql.MysqlStore) *EthGasOracle {
+	return &EthGasOracle{
+		conf:  conf,
+		store: store,
+		cache: util.NewExpirableLruCache(conf.CacheSize, gasOracleCacheTTL),
+	}
+}
+
+// FeeHistory returns the fee history of blocks in store. Note, `latest` block is regarded as
+// the latest block synced into store.
+func (o *EthGasOracle) FeeHistory(
+	ctx context.Context, blockCount uint64, lastBlock web3Types.BlockNumber, rewardPercentiles []float64,
+) (*web3Types.FeeHistory, error) {
+	last, err := o.resolveBlockNumber(lastBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	if blockCount == 0 {
+		return &web3Types.FeeHistory{OldestBlock: big.NewInt(0)}, nil
+	}
+
+	blockCount = min(blockCount, last+1)
+
+	fees, err := o.blockFeesRange(ctx, last+1-blockCount, last)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &web3Types.FeeHistory{
+		OldestBlock:  new(big.Int).SetUint64(fees[0].number),
+		BaseFee:      make([]*big.Int, 0, len(fees)+1),
+		GasUsedRatio: make([]float64, 0, len(fees)),
+	}
+
+	for _, bf := range fees {
+		history.BaseFee = append(history.BaseFee, bf.baseFee)
+		history.GasUsedRatio = append(history.GasUsedRatio, bf.gasUsedRatio)
+
+		if len(rewardPercentiles) > 0 {
+			history.Reward = append(history.Reward, bf.rewards(rewardPercentiles))
+		}
+	}
+
+	history.BaseFee = append(history.BaseFee, o.nextBaseFee(fees[len(fees)-1]))
+	return history, nil
+}
+
+// MaxPriorityFeePerGas suggests priority fee per gas from recent blocks in store.
+func (o *EthGasOracle) MaxPriorityFeePerGas(ctx context.Context) (*big.Int, error) {
+	tip, _, err := o.suggest(ctx)
+	return tip, err
+}
+
+// GasPrice suggests gas price by the base fee of next block and suggested priority fee.
+func (o *EthGasOracle) GasPrice(ctx context.Context) (*big.Int, error) {
+	tip, baseFee, err := o.suggest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).Add(baseFee, tip), nil
+}
+
+// suggest returns the suggested priority fee and base fee of next block.
+func (o *EthGasOracle) suggest(ctx context.Context) (tip, baseFee *big.Int, err error) {
+	last, err := o.resolveBlockNumber(web3Types.LatestBlockNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	head, err := o.blockFees(ctx, last)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if head, err = o.validateBlockFees(ctx, head); err != nil {
+		return nil, nil, err
+	}
+
+	if cached := o.suggestion.Load(); cached != nil && cached.number == last && cached.hash == head.hash {
+		return cached.tip, cached.baseFee, nil
+	}
+
+	count := min(o.conf.Blocks, last+1)
+
+	fees, err := o.blockFeesRange(ctx, last+1-count, last)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tips []*big.Int
+	for _, bf := range fees {
+		for _, t := range bf.tips {
+			tips = append(tips, t.tip)
+		}
+	}
+
+	// no transaction to suggest from
+	if len(tips) == 0 {
+		return nil, nil, errGasOracleMissed
+	}
+
+	slices.SortFunc(tips, func(a, b *big.Int) int { return a.Cmp(b) })
+	tip = tips[int(float64(len(tips)-1)*o.conf.Percentile/100)]
+
+	head = fees[len(fees)-1]
+	baseFee = o.nextBaseFee(head)
+
+	o.suggestion.Store(&gasSuggestion{number: head.number, hash: head.hash, tip: tip, baseFee: baseFee})
+
+	return tip, baseFee, nil
+}
+
+func (o *EthGasOracle) resolveBlockNumber(blockNum web3Types.BlockNumber) (uint64, error) {
+	// block number is the same as epoch number in evm space
+	maxBlock, ok, err := o.store.MaxEpoch()
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to get max block from store")
+	}
+
+	if !ok {
+		return 0, errGasOracleMissed
+	}
+
+	switch {
+	case blockNum == web3Types.LatestBlockNumber:
+		return maxBlock, nil
+	case blockNum < 0 || uint64(blockNum) > maxBlock: // other block tags or not synced yet
+		return 0, errGasOracleMissed
+	default:
+		return uint64(blockNum), nil
+	}
+}
+
+// blockFeesRange returns the fee data of continuous blocks in range [from, to], which are cached
+// by block number and validated by parent hash in case of chain reorg.
+func (o *EthGasOracle) blockFeesRange(ctx context.Context, from, to uint64) ([]*blockFees, error) {
+	fees := make([]*blockFees, 0, to-from+1)
+
+	for bn := from; bn <= to; bn++ {
+		bf, err := o.blockFees(ctx, bn)
+		if err != nil {
+			return nil, err
+		}
+
+		// the last block could not be validated by its child, so check against store directly
+		if bn == to {
+			if bf, err = o.validateBlockFees(ctx, bf); err != nil {
+				return nil, err
+			}
+		}
+
+		// chain reorg happened, purge the stale cache and retry later
+		if len(fees) > 0 && fees[len(fees)-1].hash != bf.parentHash {
+			for _, f := range fees {
+				o.cache.Del(f.number)
+			}
+
+			o.cache.Del(bn)
+			return nil, errGasOracleMissed
+		}
+
+		fees = append(fees, bf)
+	}
+
+	return fees, nil
+}
+
+func (o *EthGasOracle) blockFees(ctx context.Context, blockNum uint64) (*blockFees, error) {
+	if v, ok := o.cache.Get(blockNum); ok {
+		return v.(*blockFees), nil
+	}
+
+	bf, err := o.loadBlockFees(ctx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+
+	o.cache.Add(blockNum, bf)
+	return bf, nil
+}
+
+// validateBlockFees reloads the block fee data if the block hash mismatches with store.
+func (o *EthGasOracle) validateBlockFees(ctx context.Context, bf *blockFees) (*blockFees, error) {
+	summary, err := o.store.GetBlockSummaryByBlockNumber(ctx, bf.number)
+	if o.store.IsRecordNotFound(err) {
+		return nil, errGasOracleMissed
+	}
+
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get block summary %v from store", bf.number)
+	}
+
+	if ethbridge.ConvertBlockSummary(summary.CfxBlockSummary, summary.Extra).Hash == bf.hash {
+		return bf, nil
+	}
+
+	o.cache.Del(bf.number)
+	return o.blockFees(ctx, bf.number)
+}
+
+// loadBlockFees loads block fee data from block and receipts in store.
+func (o *EthGasOracle) loadBlockFees(ctx context.Context, blockNum uint64) (*blockFees, error) {
+	sblock, err := o.store.GetBlockByBlockNumber(ctx, blockNum)
+	if o.store.IsRecordNotFound(err) {
+		return nil, errGasOracleMissed
+	}
+
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get block %v from store", blockNum)
+	}
+
+	block := ethbridge.ConvertBlock(sblock.CfxBlock, sblock.Extra)
+	if block.BaseFeePerGas == nil { // block before EIP-1559 activated
+		return nil, errGasOracleMissed
+	}
+
+	bf := &blockFees{
+		number:     blockNum,
+		hash:       block.Hash,
+		parentHash: block.ParentHash,
+		baseFee:    block.BaseFeePerGas,
+		gasLimit:   block.GasLimit,
+		gasUsed:    block.GasUsed,
+	}
+
+	if block.GasLimit > 0 {
+		bf.gasUsedRatio = float64(block.GasUsed) / float64(block.GasLimit)
+	}
+
+	txns := block.Transactions.Transactions()
+	for i := range txns {
+		receipt, err := o.store.GetReceipt(ctx, cfxbridge.ConvertHash(txns[i].Hash))
+		if o.store.IsRecordNotFound(err) {
+			return nil, errGasOracleMissed
+		}
+
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to get receipt of txn %v from store", txns[i].Hash)
+		}
+
+		bf.tips = append(bf.tips, txnTip{
+			tip:     effectiveTip(&txns[i], block.BaseFeePerGas),
+			gasUsed: ethbridge.ConvertReceipt(receipt.CfxReceipt, receipt.Extra).GasUsed,
+		})
+	}
+
+	slices.SortStableFunc(bf.tips, func(a, b txnTip) int { return a.tip.Cmp(b.tip) })
+	return bf, nil
+}
+
+// effectiveTip returns the priority fee per gas actually paid by the transaction.
+func effectiveTip(txn *web3Types.TransactionDetail, baseFee *big.Int) *big.Int {
+	maxFee := txn.MaxFeePerGas
+	if maxFee == nil {
+		maxFee = txn.GasPrice
+	}
+
+	if maxFee == nil || maxFee.Cmp(baseFee) < 0 {
+		return big.NewInt(0)
+	}
+
+	tip := new(big.Int).Sub(maxFee, baseFee)
+	if txn.MaxPriorityFeePerGas != nil && txn.MaxPriorityFeePerGas.Cmp(tip) < 0 {
+		tip.Set(txn.MaxPriorityFeePerGas)
+	}
+
+	return tip
+}
+
+// rewards returns the priority fees at the percentiles weighted by gas used.
+func (bf *blockFees) rewards(percentiles []float64) []*big.Int {
+	rewards := make([]*big.Int, len(percentiles))
+
+	if len(bf.tips) == 0 {
+		for i := range rewards {
+			rewards[i] = big.NewInt(0)
+		}
+
+		return rewards
+	}
+
+	var txIndex int
+	sumGasUsed := bf.tips[0].gasUsed
+
+	for i, p := range percentiles {
+		threshold := uint64(float64(bf.gasUsed) * p / 100)
+		for sumGasUsed < threshold && txIndex < len(bf.tips)-1 {
+			txIndex++
+			sumGasUsed += bf.tips[txIndex].gasUsed
+		}
+
+		rewards[i] = bf.tips[txIndex].tip
+	}
+
+	return rewards
+}
+
+// nextBaseFee calculates the base fee of next block according to EIP-1559.
+func (o *EthGasOracle) nextBaseFee(parent *blockFees) *big.Int {
+	target := parent.gasLimit / uint64(o.conf.ElasticityMultiplier)
+	if target == 0 || parent.gasUsed == target {
+		return new(big.Int).Set(parent.baseFee)
+	}
+
+	var delta big.Int
+	if parent.gasUsed > target {
+		delta.SetUint64(parent.gasUsed - target)
+	} else {
+		delta.SetUint64(target - parent.gasUsed)
+	}
+
+	delta.Mul(&delta, parent.baseFee)
+	delta.Div(&delta, new(big.Int).SetUint64(target))
+	delta.Div(&delta, big.NewInt(o.conf.BaseFeeChangeDenominator))
+
+	if parent.gasUsed > target {
+		return new(big.Int).Add(parent.baseFee, bigMax(&delta, big.NewInt(1)))
+	}
+
+	return bigMax(new(big.Int).Sub(parent.baseFee, &delta), big.NewInt(0))
+}
+
+func bigMax(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"math/big"
+	"testing"
+
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockFeesRewards(t *testing.T) {
+	bf := &blockFees{
+		gasUsed: 100,
+		tips: []txnTip{
+			{tip: big.NewInt(1), gasUsed: 20},
+			{tip: big.NewInt(2), gasUsed: 30},
+			{tip: big.NewInt(3), gasUsed: 50},
+		},
+	}
+
+	rewards := bf.rewards([]float64{0, 20, 21, 50, 51, 100})
+	assert.Equal(t, []*big.Int{
+		big.NewInt(1), big.NewInt(1), big.NewInt(2), big.NewInt(2), big.NewInt(3), big.NewInt(3),
+	}, rewards)
+
+	// empty block
+	rewards = (&blockFees{}).rewards([]float64{50})
+	assert.Equal(t, []*big.Int{big.NewInt(0)}, rewards)
+}
+
+func TestEffectiveTip(t *testing.T) {
+	baseFee := big.NewInt(100)
+
+	// legacy transaction
+	tip := effectiveTip(&web3Types.TransactionDetail{GasPrice: big.NewInt(150)}, baseFee)
+	assert.Equal(t, big.NewInt(50), tip)
+
+	// dynamic fee transaction capped by max priority fee
+	tip = effectiveTip(&web3Types.TransactionDetail{
+		MaxFeePerGas: big.NewInt(150), MaxPriorityFeePerGas: big.NewInt(20),
+	}, baseFee)
+	assert.Equal(t, big.NewInt(20), tip)
+
+	// gas price lower than base fee
+	tip = effectiveTip(&web3Types.TransactionDetail{GasPrice: big.NewInt(50)}, baseFee)
+	assert.Equal(t, big.NewInt(0), tip)
+}
+
+func TestNextBaseFee(t *testing.T) {
+	o := &EthGasOracle{conf: &GasOracleConfig{BaseFeeChangeDenominator: 8, ElasticityMultiplier: 2}}
+	parent := &blockFees{baseFee: big.NewInt(800), gasLimit: 200}
+
+	parent.gasUsed = 100 // at target
+	assert.Equal(t, big.NewInt(800), o.nextBaseFee(parent))
+
+	parent.gasUsed = 200 // full block
+	assert.Equal(t, big.NewInt(900), o.nextBaseFee(parent))
+
+	parent.gasUsed = 0 // empty block
+	assert.Equal(t, big.NewInt(700), o.nextBaseFee(parent))
+}
@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// filter not found error code and message as full node, so that client would re-install filter
+	errLazyFilterNotFound = errors.New("filter not found")
+)
+
+// EthLazyFilterConfig is the settings of log filters with delegate filter created lazily.
+type EthLazyFilterConfig struct {
+	Enabled bool
+	// max number of log filters served lazily
+	MaxFilters int `default:"10000"`
+	// expiration duration of log filters since last polling
+	FilterTTL time.Duration `default:"5m"`
+}
+
+// ethStoreCoverage tells the max block number synced into store.
+type ethStoreCoverage interface {
+	MaxEpoch() (uint64, bool, error)
+}
+
+// ethLazyFilter is the log filter of bounded block range, whose delegate filter is created on
+// upstream only if near head blocks beyond store coverage required.
+type ethLazyFilter struct {
+	mu       sync.Mutex
+	crit     web3Types.FilterQuery
+	cursor   uint64  // the last block of which filter changes delivered
+	bridgeTo uint64  // the last block to query from store before polling delegate filter
+	delegate *rpc.ID // nil if delegate filter not created yet
+}
+
+// EthLazyFilterDelegate creates and polls the delegate filter on upstream, e.g., full node or
+// virtual filter service.
+type EthLazyFilterDelegate interface {
+	// BlockNumber returns the latest block number of upstream.
+	BlockNumber() (uint64, error)
+	// NewFilter creates the delegate filter on upstream.
+	NewFilter(crit *web3Types.FilterQuery) (*rpc.ID, error)
+	// GetFilterChanges polls the filter changes of delegate filter.
+	GetFilterChanges(fid rpc.ID) (*web3Types.FilterChanges, error)
+}
+
+// EthLazyLogFilters serves the filter changes of log filters within store coverage directly from
+// store, and creates the delegate filter on upstream only if/when near head data beyond store
+// coverage required, so as to save upstream resources for log filters of historical block range.
+type EthLazyLogFilters struct {
+	headTracker *EthHeadTracker
+	store       ethStoreCoverage
+
+	// log filters served lazily, keyed by filter ID
+	filters *util.ExpirableLruCache
+}
+
+// MustNewEthLazyLogFiltersFromViper creates lazy log filters backed by store, or nil if not enabled.
+func MustNewEthLazyLogFiltersFromViper(headTracker *EthHeadTracker, store *mysql.MysqlStore) *EthLazyLogFilters {
+	var conf EthLazyFilterConfig
+	viper.MustUnmarshalKey("ethrpc.lazyFilter", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	if headTracker == nil {
+		logrus.Fatal("Head tracker is required for lazy log filters")
+	}
+
+	return newEthLazyLogFilters(conf, headTracker, store)
+}
+
+func newEthLazyLogFilters(
+	conf EthLazyFilterConfig, headTracker *EthHeadTracker, store ethStoreCoverage,
+) *EthLazyLogFilters {
+	return &EthLazyLogFilters{
+		headTracker: headTracker,
+		store:       store,
+		filters:     util.NewExpirableLruCache(conf.MaxFilters, conf.FilterTTL),
+	}
+}
+
+// IsLazyFilter checks if the log filter criteria is of bounded block range, whose filter changes
+// could be served from store until near head blocks required.
+func IsLazyFilter(crit *web3Types.FilterQuery) bool {
+	return crit.BlockHash != nil || (crit.ToBlock != nil && *crit.ToBlock >= 0)
+}
+
+// NewFilter creates a log filter served lazily since the latest block, without any delegate filter
+// created on upstream.
+func (lf *EthLazyLogFilters) NewFilter(crit web3Types.FilterQuery) (rpc.ID, bool) {
+	latest, ok := lf.headTracker.BlockNumber()
+	if !ok { // head tracker not ready yet
+		return "", false
+	}
+
+	fid := rpc.NewID()
+	lf.filters.Add(fid, &ethLazyFilter{crit: crit, cursor: latest.ToInt().Uint64()})
+
+	return fid, true
+}
+
+// HasFilter checks if the log filter is served lazily.
+func (lf *EthLazyLogFilters) HasFilter(fid rpc.ID) bool {
+	_, ok := lf.filters.Get(fid)
+	return ok
+}
+
+// UninstallFilter removes the log filter served lazily, and returns the delegate filter ID if
+// created, which should be uninstalled from upstream as well.
+func (lf *EthLazyLogFilters) UninstallFilter(fid rpc.ID) (delegate *rpc.ID, ok bool) {
+	v, ok := lf.filters.Get(fid)
+	if !ok {
+		return nil, false
+	}
+
+	lf.filters.Del(fid)
+
+	f := v.(*ethLazyFilter)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.delegate, true
+}
+
+// FilterCrit returns a copy of the criteria of log filter served lazily.
+func (lf *EthLazyLogFilters) FilterCrit(fid rpc.ID) (*web3Types.FilterQuery, bool) {
+	v, ok := lf.filters.Get(fid)
+	if !ok {
+		return nil, false
+	}
+
+	crit := v.(*ethLazyFilter).crit
+	return &crit, true
+}
+
+// GetFilterChanges returns the event logs of log filter since last polling, which are queried by
+// `getLogs` from store if within store coverage. Otherwise, the delegate filter will be created on
+// upstream to poll filter changes thereafter, and the event logs in between are bridged from store.
+func (lf *EthLazyLogFilters) GetFilterChanges(
+	fid rpc.ID,
+	delegate EthLazyFilterDelegate,
+	getLogs func(fq *web3Types.FilterQuery) ([]web3Types.Log, error),
+) ([]web3Types.Log, error) {
+	v, ok := lf.filters.Get(fid)
+	if !ok {
+		return nil, errLazyFilterNotFound
+	}
+
+	// refresh expiration since last polling
+	lf.filters.Add(fid, v)
+
+	f := v.(*ethLazyFilter)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.delegate != nil {
+		return f.pollDelegate(delegate, getLogs)
+	}
+
+	// no more event logs for the block which is already mined
+	if f.crit.BlockHash != nil {
+		return []web3Types.Log{}, nil
+	}
+
+	latest, ok := lf.headTracker.BlockNumber()
+	if !ok {
+		return []web3Types.Log{}, nil
+	}
+
+	target := min(latest.ToInt().Uint64(), uint64(*f.crit.ToBlock))
+	if target <= f.cursor {
+		return []web3Types.Log{}, nil
+	}
+
+	maxBlock, ok, err := lf.store.MaxEpoch()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get max block from store")
+	}
+
+	if ok && target <= maxBlock {
+		logs, err := f.storeLogs(target, getLogs)
+		metrics.Registry.RPC.Percentage("eth_lazyFilter", "delegated").Mark(false)
+		return logs, err
+	}
+
+	// near head blocks beyond store coverage required, and read upstream head before delegate
+	// filter created, so that no event logs missed in between.
+	upstreamHead, err := delegate.BlockNumber()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get latest block number from upstream")
+	}
+
+	dfid, err := delegate.NewFilter(&f.crit)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create delegate filter")
+	}
+
+	metrics.Registry.RPC.Percentage("eth_lazyFilter", "delegated").Mark(true)
+
+	f.delegate = dfid
+	f.bridgeTo = min(upstreamHead, uint64(*f.crit.ToBlock))
+
+	return f.pollDelegate(delegate, getLogs)
+}
+
+// pollDelegate polls the filter changes of delegate filter, along with the event logs bridged
+// from store since last polling till delegate filter created.
+func (f *ethLazyFilter) pollDelegate(
+	delegate EthLazyFilterDelegate,
+	getLogs func(fq *web3Types.FilterQuery) ([]web3Types.Log, error),
+) ([]web3Types.Log, error) {
+	logs := []web3Types.Log{}
+
+	if f.bridgeTo > f.cursor {
+		bridged, err := f.storeLogs(f.bridgeTo, getLogs)
+		if err != nil {
+			return nil, err
+		}
+
+		logs = append(logs, bridged...)
+	}
+
+	changes, err := delegate.GetFilterChanges(*f.delegate)
+	if err != nil {
+		return nil, err
+	}
+
+	if changes != nil {
+		logs = append(logs, changes.Logs...)
+	}
+
+	return logs, nil
+}
+
+// storeLogs queries the matched event logs since the cursor till the specified block, and then
+// moves the cursor forward.
+func (f *ethLazyFilter) storeLogs(
+	toBlock uint64, getLogs func(fq *web3Types.FilterQuery) ([]web3Types.Log, error),
+) ([]web3Types.Log, error) {
+	fromBlock := f.cursor + 1
+	if f.crit.FromBlock != nil && *f.crit.FromBlock > 0 {
+		fromBlock = max(fromBlock, uint64(*f.crit.FromBlock))
+	}
+
+	if fromBlock > toBlock {
+		f.cursor = toBlock
+		return []web3Types.Log{}, nil
+	}
+
+	from, to := web3Types.BlockNumber(fromBlock), web3Types.BlockNumber(toBlock)
+	fq := web3Types.FilterQuery{
+		FromBlock: &from,
+		ToBlock:   &to,
+		Addresses: f.crit.Addresses,
+		Topics:    f.crit.Topics,
+	}
+
+	logs, err := getLogs(&fq)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get event logs from store")
+	}
+
+	f.cursor = toBlock
+
+	if logs == nil {
+		logs = []web3Types.Log{}
+	}
+
+	return logs, nil
+}
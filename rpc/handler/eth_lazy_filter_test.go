@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsLazyFilter(t *testing.T) {
+	latest, bn := web3Types.LatestBlockNumber, web3Types.BlockNumber(100)
+	blockHash := common.HexToHash("0x1")
+
+	assert.True(t, IsLazyFilter(&web3Types.FilterQuery{BlockHash: &blockHash}))
+	assert.True(t, IsLazyFilter(&web3Types.FilterQuery{ToBlock: &bn}))
+	assert.True(t, IsLazyFilter(&web3Types.FilterQuery{FromBlock: &latest, ToBlock: &bn}))
+
+	assert.False(t, IsLazyFilter(&web3Types.FilterQuery{}))
+	assert.False(t, IsLazyFilter(&web3Types.FilterQuery{FromBlock: &bn}))
+	assert.False(t, IsLazyFilter(&web3Types.FilterQuery{FromBlock: &bn, ToBlock: &latest}))
+}
+
+func TestEthLazyFilterStoreLogs(t *testing.T) {
+	from, to := web3Types.BlockNumber(105), web3Types.BlockNumber(200)
+	f := &ethLazyFilter{crit: web3Types.FilterQuery{FromBlock: &from, ToBlock: &to}, cursor: 100}
+
+	var queried []web3Types.FilterQuery
+	getLogs := func(fq *web3Types.FilterQuery) ([]web3Types.Log, error) {
+		queried = append(queried, *fq)
+		return nil, nil
+	}
+
+	// block range before `fromBlock` of filter
+	logs, err := f.storeLogs(103, getLogs)
+	assert.NoError(t, err)
+	assert.Empty(t, logs)
+	assert.NotNil(t, logs)
+	assert.Empty(t, queried)
+	assert.Equal(t, uint64(103), f.cursor)
+
+	// query since `fromBlock` of filter
+	_, err = f.storeLogs(110, getLogs)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(queried))
+	assert.Equal(t, web3Types.BlockNumber(105), *queried[0].FromBlock)
+	assert.Equal(t, web3Types.BlockNumber(110), *queried[0].ToBlock)
+	assert.Equal(t, uint64(110), f.cursor)
+
+	// query since cursor
+	_, err = f.storeLogs(120, getLogs)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(queried))
+	assert.Equal(t, web3Types.BlockNumber(111), *queried[1].FromBlock)
+	assert.Equal(t, web3Types.BlockNumber(120), *queried[1].ToBlock)
+	assert.Equal(t, uint64(120), f.cursor)
+}
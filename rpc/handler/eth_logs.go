@@ -7,8 +7,10 @@ import (
 
 	"github.com/Conflux-Chain/confura/rpc/ethbridge"
 	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/memory"
 	"github.com/Conflux-Chain/confura/store/mysql"
 	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util/logging"
 	"github.com/Conflux-Chain/confura/util/metrics"
 	"github.com/openweb3/web3go/client"
 	"github.com/openweb3/web3go/types"
@@ -17,7 +19,12 @@ import (
 
 // EthLogsApiHandler RPC handler to get evm space event logs from store or fullnode.
 type EthLogsApiHandler struct {
-	ms *mysql.MysqlStore
+	ms      *mysql.MysqlStore
+	planner *LogQueryPlanner // optional
+	// historical backend to query event logs prior to database, optional
+	federated *EthFederatedLogsHandler
+	// near-head blocks not persisted into database yet, optional
+	nearHeadStore *memory.NearHeadStore
 
 	networkId atomic.Value
 }
@@ -26,6 +33,26 @@ func NewEthLogsApiHandler(ms *mysql.MysqlStore) *EthLogsApiHandler {
 	return &EthLogsApiHandler{ms: ms}
 }
 
+// WithQueryPlanner enables to plan the execution of event logs query within database by cost.
+func (handler *EthLogsApiHandler) WithQueryPlanner(planner *LogQueryPlanner) *EthLogsApiHandler {
+	handler.planner = planner
+	return handler
+}
+
+// WithHistoricalBackend enables to federate event logs query prior to database (eg., pruned
+// already) to the historical backend rather than fullnode.
+func (handler *EthLogsApiHandler) WithHistoricalBackend(federated *EthFederatedLogsHandler) *EthLogsApiHandler {
+	handler.federated = federated
+	return handler
+}
+
+// WithNearHeadStore enables to query event logs of near-head blocks, which are not persisted into
+// database yet, from the in-memory store rather than fullnode.
+func (handler *EthLogsApiHandler) WithNearHeadStore(nhs *memory.NearHeadStore) *EthLogsApiHandler {
+	handler.nearHeadStore = nhs
+	return handler
+}
+
 func (handler *EthLogsApiHandler) GetLogs(
 	ctx context.Context,
 	eth *client.RpcEthClient,
@@ -71,19 +98,23 @@ func (handler *EthLogsApiHandler) getLogsReorgGuard(
 	delegatedRpcMethod string,
 ) ([]types.Log, bool, error) {
 	// Try to query event logs from database and fullnode.
-	dbFilter, fnFilter, err := handler.splitLogFilter(eth, filter)
+	splits, err := handler.splitLogFilter(eth, filter)
 	if err != nil {
 		return nil, false, err
 	}
 
+	dbFilter, fnFilters := splits.db, splits.fullnodeFilters()
+
 	if len(delegatedRpcMethod) > 0 {
-		metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/split/alldatabase").Mark(fnFilter == nil)
+		metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/split/alldatabase").Mark(len(fnFilters) == 0)
 		metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/split/allfullnode").Mark(dbFilter == nil)
-		metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/split/partial").Mark(dbFilter != nil && fnFilter != nil)
+		metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/split/partial").Mark(dbFilter != nil && len(fnFilters) > 0)
 
-		if blockRange, valid := calculateEthBlockRange(fnFilter); valid {
-			numBlocks := blockRange.To - blockRange.From + 1
-			metrics.Registry.RPC.LogFilterSplit(delegatedRpcMethod, "fullnode/blockRange").Update(int64(numBlocks))
+		for _, fnFilter := range fnFilters {
+			if blockRange, valid := calculateEthBlockRange(fnFilter); valid {
+				numBlocks := blockRange.To - blockRange.From + 1
+				metrics.Registry.RPC.LogFilterSplit(delegatedRpcMethod, "fullnode/blockRange").Update(int64(numBlocks))
+			}
 		}
 	}
 
@@ -91,56 +122,125 @@ func (handler *EthLogsApiHandler) getLogsReorgGuard(
 	var accumulator int
 
 	useBoundCheck := handler.RequiresBoundChecks(filter)
-	if dbFilter != nil {
-		if useBoundCheck {
-			// add db query timeout
-			var cancel context.CancelFunc
-			ctx, cancel = context.WithTimeout(ctx, store.TimeoutGetLogs)
-			defer cancel()
-		} else {
-			ctx = store.NewContextWithBoundChecksDisabled(ctx)
+
+	// query data prior to database from historical backend or fullnode, eg., pruned already
+	if splits.pruned != nil && handler.federated != nil {
+		if len(delegatedRpcMethod) > 0 {
+			metrics.Registry.RPC.Percentage(delegatedRpcMethod, "filter/split/federated").Mark(true)
 		}
 
-		// query data from database
-		dbLogs, err := handler.ms.GetLogs(ctx, *dbFilter)
+		histLogs, err := handler.federated.GetLogs(ctx, *splits.pruned)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if err := handler.accumulateLogs(filter, histLogs, &accumulator, useBoundCheck); err != nil {
+			return nil, false, err
+		}
+
+		logs = append(logs, histLogs...)
+	} else if splits.pruned != nil {
+		fnLogs, err := handler.getFullnodeLogs(ctx, eth, filter, splits.pruned, &accumulator, useBoundCheck)
 		if err != nil {
+			return nil, false, err
+		}
+
+		logs = append(logs, fnLogs...)
+	}
+
+	// plan the execution path within database by cost
+	if dbFilter != nil && handler.planner != nil {
+		plan, err := handler.planner.plan(ctx, *dbFilter, delegatedRpcMethod)
+		if err != nil {
+			return nil, false, err
+		}
+
+		// skip the blocks without any activity of the filtered contracts
+		dbFilter = &plan.filter
+
+		if plan.path == logScanPathNone {
+			dbFilter = nil
+		} else if plan.path == logScanPathFullnode { // cheaper to query from fullnode
+			dbRange := citypes.RangeUint64{From: dbFilter.BlockFrom, To: dbFilter.BlockTo}
+			fnFilter := newPartialEthLogFilter(filter, dbRange)
+
+			fnLogs, err := handler.getFullnodeLogs(ctx, eth, filter, fnFilter, &accumulator, useBoundCheck)
+			if err != nil {
+				return nil, false, err
+			}
+
+			logs = append(logs, fnLogs...)
+			dbFilter = nil
+		}
+	}
+
+	if dbFilter != nil && useBoundCheck {
+		// add db query timeout
+		dbCtx, cancel := context.WithTimeout(ctx, store.TimeoutGetLogs)
+		defer cancel()
+
+		// query data from database
+		dbLogs, err := handler.ms.GetLogs(dbCtx, *dbFilter)
+		if errors.Is(err, store.ErrEpochParked) {
+			fnLogs, err := handler.getParkedLogs(ctx, eth, filter, dbFilter, &accumulator, useBoundCheck)
+			if err != nil {
+				return nil, false, err
+			}
+
+			logs = append(logs, fnLogs...)
+			dbFilter, dbLogs = nil, nil
+		} else if err != nil {
 			// TODO ErrPrunedAlready
 			return nil, false, err
 		}
 
 		for _, v := range dbLogs {
-			if accumulator += len(v.Extra); useBoundCheck && uint64(accumulator) > maxGetLogsResponseBytes {
+			if accumulator += len(v.Extra); uint64(accumulator) > maxGetLogsResponseBytes.Load() {
 				return nil, false, handler.newSuggestedBodyBytesOversizedError(filter, v.BlockNumber)
 			}
 
 			cfxLog, ext := v.ToCfxLog()
 			logs = append(logs, *ethbridge.ConvertLog(cfxLog, ext))
 		}
-	}
+	} else if dbFilter != nil {
+		// iterate data from database chunk by chunk for unbounded queries, which could be huge
+		err := handler.ms.ForEachLog(ctx, *dbFilter, func(v *store.Log) error {
+			accumulator += len(v.Extra)
 
-	// query data from fullnode
-	if fnFilter != nil {
-		// check timeout before fullnode delegation
-		if err := checkTimeout(ctx); err != nil {
-			return nil, false, err
-		}
+			cfxLog, ext := v.ToCfxLog()
+			logs = append(logs, *ethbridge.ConvertLog(cfxLog, ext))
+			return nil
+		})
+
+		if errors.Is(err, store.ErrEpochParked) {
+			fnLogs, err := handler.getParkedLogs(ctx, eth, filter, dbFilter, &accumulator, useBoundCheck)
+			if err != nil {
+				return nil, false, err
+			}
 
-		// ensure fullnode delegation is rational
-		if err := handler.checkFnEthLogFilter(fnFilter); err != nil {
+			logs = append(logs, fnLogs...)
+			dbFilter = nil
+		} else if err != nil {
 			return nil, false, err
 		}
+	}
 
-		fnLogs, err := eth.Logs(*fnFilter)
+	// query recent data posterior to database from near-head store or fullnode
+	if splits.recent != nil {
+		recentLogs, hitNearHead, err := handler.getNearHeadLogs(ctx, eth, splits.recent, delegatedRpcMethod)
 		if err != nil {
 			return nil, false, err
 		}
 
-		for i := range fnLogs {
-			if accumulator += len(fnLogs[i].Data); useBoundCheck && uint64(accumulator) > maxGetLogsResponseBytes {
-				return nil, false, handler.newSuggestedBodyBytesOversizedError(filter, fnLogs[i].BlockNumber)
+		if !hitNearHead {
+			if recentLogs, err = handler.getFullnodeLogs(ctx, eth, filter, splits.recent, &accumulator, useBoundCheck); err != nil {
+				return nil, false, err
 			}
+		} else if err := handler.accumulateLogs(filter, recentLogs, &accumulator, useBoundCheck); err != nil {
+			return nil, false, err
 		}
-		logs = append(logs, fnLogs...)
+
+		logs = append(logs, recentLogs...)
 	}
 
 	// ensure result set never oversized
@@ -150,11 +250,11 @@ func (handler *EthLogsApiHandler) getLogsReorgGuard(
 	}
 
 	// Rare case: log context information for diagnostic purposes if the result exceeds limits.
-	if uint64(len(logs)) > store.MaxLogLimit || uint64(accumulator) > maxGetLogsResponseBytes {
-		logrus.WithFields(logrus.Fields{
+	if uint64(len(logs)) > store.MaxLogLimit || uint64(accumulator) > maxGetLogsResponseBytes.Load() {
+		logging.FromContext(ctx).WithFields(logrus.Fields{
 			"logFilter":         filter,
 			"databaseFilter":    dbFilter,
-			"fullnodeFilter":    fnFilter,
+			"fullnodeFilters":   fnFilters,
 			"boundCheckEnabled": useBoundCheck,
 			"resultSetCount":    len(logs),
 			"responseSizeBytes": uint64(accumulator),
@@ -164,97 +264,258 @@ func (handler *EthLogsApiHandler) getLogsReorgGuard(
 	return logs, dbFilter != nil, nil
 }
 
+// getNearHeadLogs queries event logs from the near-head memory store, or false if the block range
+// is not fully covered by the memory store.
+func (handler *EthLogsApiHandler) getNearHeadLogs(
+	ctx context.Context, eth *client.RpcEthClient, filter *types.FilterQuery, delegatedRpcMethod string,
+) ([]types.Log, bool, error) {
+	if handler.nearHeadStore == nil {
+		return nil, false, nil
+	}
+
+	blockRange, valid := calculateEthBlockRange(filter)
+	nearHeadRange, ok := handler.nearHeadStore.EpochRange()
+	hit := valid && ok && blockRange.From >= nearHeadRange.From && blockRange.To <= nearHeadRange.To
+
+	if len(delegatedRpcMethod) > 0 {
+		metrics.Registry.RPC.Percentage(delegatedRpcMethod, "nearHead").Mark(hit)
+	}
+
+	if !hit {
+		return nil, false, nil
+	}
+
+	networkId, err := handler.GetNetworkId(eth)
+	if err != nil {
+		return nil, false, err
+	}
+
+	slogs, err := handler.nearHeadStore.GetLogs(ctx, store.ParseEthLogFilter(blockRange.From, blockRange.To, filter, networkId))
+	if err != nil {
+		return nil, false, err
+	}
+
+	logs := make([]types.Log, 0, len(slogs))
+	for _, v := range slogs {
+		cfxLog, ext := v.ToCfxLog()
+		logs = append(logs, *ethbridge.ConvertLog(cfxLog, ext))
+	}
+
+	return logs, true, nil
+}
+
+// getFullnodeLogs queries event logs from fullnode, and accumulates the response size.
+// getParkedLogs queries event logs of the database filter from fullnode, since some blocks within
+// are parked into dead letter queue without event logs persisted.
+func (handler *EthLogsApiHandler) getParkedLogs(
+	ctx context.Context,
+	eth *client.RpcEthClient,
+	filter *types.FilterQuery,
+	dbFilter *store.LogFilter,
+	accumulator *int,
+	useBoundCheck bool,
+) ([]types.Log, error) {
+	dbRange := citypes.RangeUint64{From: dbFilter.BlockFrom, To: dbFilter.BlockTo}
+	fnFilter := newPartialEthLogFilter(filter, dbRange)
+
+	return handler.getFullnodeLogs(ctx, eth, filter, fnFilter, accumulator, useBoundCheck)
+}
+
+func (handler *EthLogsApiHandler) getFullnodeLogs(
+	ctx context.Context,
+	eth *client.RpcEthClient,
+	filter, fnFilter *types.FilterQuery,
+	accumulator *int,
+	useBoundCheck bool,
+) ([]types.Log, error) {
+	// check timeout before fullnode delegation
+	if err := checkTimeout(ctx); err != nil {
+		return nil, err
+	}
+
+	// ensure fullnode delegation is rational
+	if err := handler.checkFnEthLogFilter(fnFilter); err != nil {
+		return nil, err
+	}
+
+	fnLogs, err := eth.Logs(*fnFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := handler.accumulateLogs(filter, fnLogs, accumulator, useBoundCheck); err != nil {
+		return nil, err
+	}
+
+	return fnLogs, nil
+}
+
+// accumulateLogs accumulates the response size of event logs, and returns error if oversized.
+func (handler *EthLogsApiHandler) accumulateLogs(
+	filter *types.FilterQuery, logs []types.Log, accumulator *int, useBoundCheck bool,
+) error {
+	for i := range logs {
+		if *accumulator += len(logs[i].Data); useBoundCheck && uint64(*accumulator) > maxGetLogsResponseBytes.Load() {
+			return handler.newSuggestedBodyBytesOversizedError(filter, logs[i].BlockNumber)
+		}
+	}
+
+	return nil
+}
+
+// ethLogFilterSplits is the log filter split by the block range boundaries of database, so that
+// each part could be queried from the best data source and merged in order of block number.
+type ethLogFilterSplits struct {
+	pruned *types.FilterQuery // blocks prior to database (eg., pruned already) from fullnode
+	db     *store.LogFilter   // blocks within database
+	recent *types.FilterQuery // blocks posterior to database from fullnode
+}
+
+func (splits *ethLogFilterSplits) fullnodeFilters() (res []*types.FilterQuery) {
+	for _, fnFilter := range []*types.FilterQuery{splits.pruned, splits.recent} {
+		if fnFilter != nil {
+			res = append(res, fnFilter)
+		}
+	}
+
+	return res
+}
+
 func (handler *EthLogsApiHandler) splitLogFilter(
 	eth *client.RpcEthClient,
 	filter *types.FilterQuery,
-) (*store.LogFilter, *types.FilterQuery, error) {
+) (*ethLogFilterSplits, error) {
 	maxBlock, ok, err := handler.ms.MaxEpoch()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	if !ok {
-		return nil, filter, nil
+		return &ethLogFilterSplits{recent: filter}, nil
 	}
 
+	minBlock, ok, err := handler.ms.MinEpoch()
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return &ethLogFilterSplits{recent: filter}, nil
+	}
+
+	dbRange := citypes.RangeUint64{From: minBlock, To: maxBlock}
+
 	if filter.BlockHash != nil {
-		return handler.splitLogFilterByBlockHash(eth, filter, maxBlock)
+		return handler.splitLogFilterByBlockHash(eth, filter, dbRange)
 	}
 
-	return handler.splitLogFilterByBlockRange(eth, filter, maxBlock)
+	return handler.splitLogFilterByBlockRange(eth, filter, dbRange)
 }
 
 func (handler *EthLogsApiHandler) splitLogFilterByBlockHash(
 	eth *client.RpcEthClient,
 	filter *types.FilterQuery,
-	maxBlock uint64,
-) (*store.LogFilter, *types.FilterQuery, error) {
+	dbRange citypes.RangeUint64,
+) (*ethLogFilterSplits, error) {
 	block, err := eth.BlockByHash(*filter.BlockHash, false)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	if block == nil || block.Number == nil {
-		return nil, nil, errors.New("unknown block")
+		return nil, errors.New("unknown block")
 	}
 
 	bn := block.Number.Uint64()
 
-	if bn > maxBlock {
-		return nil, filter, nil
+	if bn > dbRange.To {
+		return &ethLogFilterSplits{recent: filter}, nil
+	}
+
+	if bn < dbRange.From {
+		return &ethLogFilterSplits{pruned: filter}, nil
 	}
 
 	networkId, err := handler.GetNetworkId(eth)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	dbFilter := store.ParseEthLogFilter(bn, bn, filter, networkId)
-	return &dbFilter, nil, err
+	return &ethLogFilterSplits{db: &dbFilter}, nil
 }
 
 func (handler *EthLogsApiHandler) splitLogFilterByBlockRange(
 	eth *client.RpcEthClient,
 	filter *types.FilterQuery,
-	maxBlock uint64,
-) (*store.LogFilter, *types.FilterQuery, error) {
+	dbRange citypes.RangeUint64,
+) (*ethLogFilterSplits, error) {
 	if filter.FromBlock == nil || *filter.FromBlock < 0 {
-		return nil, filter, nil
+		return &ethLogFilterSplits{recent: filter}, nil
 	}
 
 	if filter.ToBlock == nil || *filter.ToBlock < 0 {
-		return nil, filter, nil
+		return &ethLogFilterSplits{recent: filter}, nil
 	}
 
-	blockFrom, blockTo := uint64(*filter.FromBlock), uint64(*filter.ToBlock)
+	blockRange := citypes.RangeUint64{From: uint64(*filter.FromBlock), To: uint64(*filter.ToBlock)}
+	prunedRange, inDbRange, recentRange := splitEthBlockRange(blockRange, dbRange)
 
-	// no data in database
-	if blockFrom > maxBlock {
-		return nil, filter, nil
+	var splits ethLogFilterSplits
+
+	if prunedRange != nil {
+		splits.pruned = newPartialEthLogFilter(filter, *prunedRange)
 	}
 
-	networkId, err := handler.GetNetworkId(eth)
-	if err != nil {
-		return nil, nil, err
+	if recentRange != nil {
+		splits.recent = newPartialEthLogFilter(filter, *recentRange)
 	}
 
-	// all data in database
-	if blockTo <= maxBlock {
-		dbFilter := store.ParseEthLogFilter(blockFrom, blockTo, filter, networkId)
-		return &dbFilter, nil, nil
+	if inDbRange != nil {
+		networkId, err := handler.GetNetworkId(eth)
+		if err != nil {
+			return nil, err
+		}
+
+		dbFilter := store.ParseEthLogFilter(inDbRange.From, inDbRange.To, filter, networkId)
+		splits.db = &dbFilter
+	}
+
+	return &splits, nil
+}
+
+// splitEthBlockRange splits the block range by the block range boundaries of database into the parts
+// prior to, within and posterior to database respectively. Any part could be nil if not overlapped.
+func splitEthBlockRange(blockRange, dbRange citypes.RangeUint64) (pruned, inDb, recent *citypes.RangeUint64) {
+	if blockRange.From > blockRange.To {
+		return nil, nil, &blockRange
+	}
+
+	if blockRange.From < dbRange.From {
+		pruned = &citypes.RangeUint64{From: blockRange.From, To: min(blockRange.To, dbRange.From-1)}
 	}
 
-	// otherwise, partial data in databse
-	dbFilter := store.ParseEthLogFilter(blockFrom, maxBlock, filter, networkId)
-	fnBlockFrom := types.BlockNumber(maxBlock + 1)
-	fnFilter := types.FilterQuery{
-		FromBlock: &fnBlockFrom,
-		ToBlock:   filter.ToBlock,
+	if blockRange.To > dbRange.To {
+		recent = &citypes.RangeUint64{From: max(blockRange.From, dbRange.To+1), To: blockRange.To}
+	}
+
+	if from, to := max(blockRange.From, dbRange.From), min(blockRange.To, dbRange.To); from <= to {
+		inDb = &citypes.RangeUint64{From: from, To: to}
+	}
+
+	return pruned, inDb, recent
+}
+
+// newPartialEthLogFilter creates log filter with the same criteria but the specified block range.
+func newPartialEthLogFilter(filter *types.FilterQuery, blockRange citypes.RangeUint64) *types.FilterQuery {
+	fromBlock, toBlock := types.BlockNumber(blockRange.From), types.BlockNumber(blockRange.To)
+
+	return &types.FilterQuery{
+		FromBlock: &fromBlock,
+		ToBlock:   &toBlock,
 		Addresses: filter.Addresses,
 		Topics:    filter.Topics,
 	}
-
-	return &dbFilter, &fnFilter, nil
 }
 
 func (handler *EthLogsApiHandler) GetNetworkId(eth *client.RpcEthClient) (uint32, error) {
@@ -278,9 +539,9 @@ func (handler *EthLogsApiHandler) GetNetworkId(eth *client.RpcEthClient) (uint32
 // Note this function assumes the log filter is valid and normalized.
 func (handler *EthLogsApiHandler) checkFnEthLogFilter(filter *types.FilterQuery) error {
 	if blockRange, valid := calculateEthBlockRange(filter); valid {
-		numBlocks := blockRange.To - blockRange.From + 1
-		if numBlocks > uint64(store.MaxLogBlockRange) {
-			blockRange.To = blockRange.From + uint64(store.MaxLogBlockRange) - 1
+		numBlocks, maxBlocks := blockRange.To-blockRange.From+1, store.MaxLogBlockRange()
+		if numBlocks > maxBlocks {
+			blockRange.To = blockRange.From + maxBlocks - 1
 			suggestedRange := store.SuggestedBlockRange{RangeUint64: blockRange}
 			return store.NewSuggestedFilterQuerySetTooLargeError(&suggestedRange)
 		}
@@ -302,7 +563,7 @@ func (handler *EthLogsApiHandler) newSuggestedResultSetOversizedError(filter *ty
 }
 
 func (handler *EthLogsApiHandler) newSuggestedBodyBytesOversizedError(filter *types.FilterQuery, exceedingBlockNum uint64) error {
-	return handler.newSuggestedFilterOversizedError(errResponseBodySizeTooLarge, filter, exceedingBlockNum)
+	return handler.newSuggestedFilterOversizedError(errResponseBodySizeTooLarge(), filter, exceedingBlockNum)
 }
 
 func (handler *EthLogsApiHandler) newSuggestedFilterOversizedError(inner error, filter *types.FilterQuery, exceedingBlockNum uint64) error {
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/openweb3/web3go"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// EthFederatedLogsHandler RPC handler to federate evm space event log queries, whose block ranges
+// are prior to database (eg., pruned already), to some remote confura deployment as the historical
+// backend.
+type EthFederatedLogsHandler struct {
+	remote *web3go.Client
+}
+
+// MustNewEthFederatedLogsHandlerFromViper creates a federated logs handler if historical backend configured.
+func MustNewEthFederatedLogsHandlerFromViper() (*EthFederatedLogsHandler, bool) {
+	cfg := mustNewFederationConfigFromViper("ethrpc.federation")
+	if len(cfg.HistoricalBackend) == 0 {
+		return nil, false
+	}
+
+	// never inject node credentials into the remote confura endpoint
+	remote, err := rpcutil.NewEthClient(
+		cfg.HistoricalBackend, rpcutil.WithClientHookMetrics(true), rpcutil.WithClientExternal(true),
+	)
+	if err != nil {
+		logrus.WithField("url", cfg.HistoricalBackend).
+			WithError(err).
+			Fatal("Failed to create client for eth historical backend")
+	}
+
+	return &EthFederatedLogsHandler{remote: remote}, true
+}
+
+// GetLogs queries event logs from the historical backend, which will be canceled along with the
+// request context.
+func (h *EthFederatedLogsHandler) GetLogs(ctx context.Context, filter types.FilterQuery) ([]types.Log, error) {
+	logs, err := h.remote.WithContext(ctx).Eth.Logs(filter)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get logs from historical backend")
+	}
+
+	return logs, nil
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/memory"
+	citypes "github.com/Conflux-Chain/confura/types"
+	cfxtypes "github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitEthBlockRange(t *testing.T) {
+	dbRange := citypes.RangeUint64{From: 100, To: 200}
+
+	newRange := func(from, to uint64) *citypes.RangeUint64 {
+		return &citypes.RangeUint64{From: from, To: to}
+	}
+
+	testCases := []struct {
+		blockRange           citypes.RangeUint64
+		pruned, inDb, recent *citypes.RangeUint64
+	}{
+		{blockRange: *newRange(10, 50), pruned: newRange(10, 50)},
+		{blockRange: *newRange(120, 150), inDb: newRange(120, 150)},
+		{blockRange: *newRange(210, 250), recent: newRange(210, 250)},
+		{blockRange: *newRange(50, 150), pruned: newRange(50, 99), inDb: newRange(100, 150)},
+		{blockRange: *newRange(150, 250), inDb: newRange(150, 200), recent: newRange(201, 250)},
+		{blockRange: *newRange(50, 250), pruned: newRange(50, 99), inDb: newRange(100, 200), recent: newRange(201, 250)},
+		{blockRange: *newRange(100, 100), inDb: newRange(100, 100)},
+		{blockRange: *newRange(200, 201), inDb: newRange(200, 200), recent: newRange(201, 201)},
+	}
+
+	for _, tc := range testCases {
+		pruned, inDb, recent := splitEthBlockRange(tc.blockRange, dbRange)
+		assert.Equal(t, tc.pruned, pruned, "pruned range of %v", tc.blockRange)
+		assert.Equal(t, tc.inDb, inDb, "db range of %v", tc.blockRange)
+		assert.Equal(t, tc.recent, recent, "recent range of %v", tc.blockRange)
+	}
+}
+
+// newTestEthEpochData creates evm space block data converted to epoch data, of which the block has
+// one event log emitted by the specified contract.
+func newTestEthEpochData(bn uint64, contract common.Address) *store.EpochData {
+	blockHash := cfxtypes.Hash(fmt.Sprintf("0x%064x", bn))
+	txHash := cfxtypes.Hash(fmt.Sprintf("0x%064x", bn+1000))
+
+	block := &cfxtypes.Block{
+		BlockHeader: cfxtypes.BlockHeader{
+			Hash:        blockHash,
+			EpochNumber: cfxtypes.NewBigInt(bn),
+			BlockNumber: cfxtypes.NewBigInt(bn),
+		},
+		Transactions: []cfxtypes.Transaction{{Hash: txHash}},
+	}
+
+	return &store.EpochData{
+		Number: bn,
+		Blocks: []*cfxtypes.Block{block},
+		Receipts: map[cfxtypes.Hash]*cfxtypes.TransactionReceipt{
+			txHash: {
+				TransactionHash: txHash,
+				BlockHash:       blockHash,
+				Logs: []cfxtypes.Log{{
+					Address:          cfxaddress.MustNewFromCommon(contract, 71),
+					BlockHash:        &blockHash,
+					EpochNumber:      cfxtypes.NewBigInt(bn),
+					TransactionHash:  &txHash,
+					TransactionIndex: cfxtypes.NewBigInt(0),
+					LogIndex:         cfxtypes.NewBigInt(0),
+				}},
+			},
+		},
+	}
+}
+
+func TestEthLogsGetNearHeadLogs(t *testing.T) {
+	contract0, contract1 := common.HexToAddress("0x10"), common.HexToAddress("0x11")
+
+	nhs := memory.NewNearHeadStore()
+	assert.NoError(t, nhs.Pushn([]*store.EpochData{
+		newTestEthEpochData(10, contract0), newTestEthEpochData(11, contract1), newTestEthEpochData(12, contract0),
+	}))
+
+	handler := NewEthLogsApiHandler(nil)
+
+	// near-head store not enabled
+	fromBlock, toBlock := types.BlockNumber(10), types.BlockNumber(12)
+	_, ok, err := handler.getNearHeadLogs(context.Background(), nil, &types.FilterQuery{FromBlock: &fromBlock, ToBlock: &toBlock}, "")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	handler.WithNearHeadStore(nhs).networkId.Store(uint32(71))
+
+	// block range fully covered by near-head store
+	logs, ok, err := handler.getNearHeadLogs(context.Background(), nil, &types.FilterQuery{
+		FromBlock: &fromBlock, ToBlock: &toBlock, Addresses: []common.Address{contract0},
+	}, "")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Len(t, logs, 2)
+
+	for i, bn := range []uint64{10, 12} {
+		assert.Equal(t, contract0, logs[i].Address)
+		assert.Equal(t, bn, logs[i].BlockNumber)
+	}
+
+	// block range partially covered by near-head store
+	toBlock = types.BlockNumber(13)
+	_, ok, err = handler.getNearHeadLogs(context.Background(), nil, &types.FilterQuery{FromBlock: &fromBlock, ToBlock: &toBlock}, "")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// latest block tag not resolved
+	latestBlock := types.LatestBlockNumber
+	_, ok, err = handler.getNearHeadLogs(context.Background(), nil, &types.FilterQuery{FromBlock: &fromBlock, ToBlock: &latestBlock}, "")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -6,27 +6,53 @@ import (
 
 	"github.com/Conflux-Chain/confura/node"
 	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
 )
 
+var (
+	errEthStatePruned = errors.New("state pruned, and no archive node available for historical state")
+)
+
+// EthArchiveConfig represents the configuration to route historical state requests to archive nodes.
+type EthArchiveConfig struct {
+	// Number of the latest blocks of which state is retained by normal full nodes, and requests to
+	// older blocks are routed to archive nodes directly (requires head tracker). If zero, requests
+	// are routed to archive nodes only if state not available on normal full nodes.
+	StateRetention uint64
+}
+
 // EthStateHandler handles evm space state RPC method by redirecting requests to another
-// full state node if state is not available on normal full node.
+// full state node or archive node if state is not available on normal full node.
 type EthStateHandler struct {
 	cp *node.EthClientProvider
+
+	archive     EthArchiveConfig
+	headTracker *EthHeadTracker // used to detect historical state requests, nil if not enabled
 }
 
 func NewEthStateHandler(cp *node.EthClientProvider) *EthStateHandler {
 	return &EthStateHandler{cp: cp}
 }
 
+// MustNewEthStateHandlerFromViper creates state handler with historical state requests routed to
+// archive nodes automatically.
+func MustNewEthStateHandlerFromViper(cp *node.EthClientProvider, headTracker *EthHeadTracker) *EthStateHandler {
+	var conf EthArchiveConfig
+	viper.MustUnmarshalKey("ethrpc.archive", &conf)
+
+	return &EthStateHandler{cp: cp, archive: conf, headTracker: headTracker}
+}
+
 func (h *EthStateHandler) Balance(
 	ctx context.Context,
 	w3c *node.Web3goClient,
 	addr common.Address,
 	block *types.BlockNumberOrHash,
 ) (*big.Int, error) {
-	bal, err, usefs := h.doRequest(ctx, w3c, func(w3c *node.Web3goClient) (interface{}, error) {
+	bal, err, usefs := h.doStateRequest(ctx, w3c, block, func(w3c *node.Web3goClient) (interface{}, error) {
 		return w3c.Eth.Balance(addr, block)
 	})
 
@@ -45,7 +71,7 @@ func (h *EthStateHandler) TransactionCount(
 	addr common.Address,
 	blockNum *types.BlockNumberOrHash,
 ) (*big.Int, error) {
-	txnCnt, err, usefs := h.doRequest(ctx, w3c, func(w3c *node.Web3goClient) (interface{}, error) {
+	txnCnt, err, usefs := h.doStateRequest(ctx, w3c, blockNum, func(w3c *node.Web3goClient) (interface{}, error) {
 		return w3c.Eth.TransactionCount(addr, blockNum)
 	})
 
@@ -65,7 +91,7 @@ func (h *EthStateHandler) StorageAt(
 	location *big.Int,
 	block *types.BlockNumberOrHash,
 ) (common.Hash, error) {
-	storage, err, usefs := h.doRequest(ctx, w3c, func(w3c *node.Web3goClient) (interface{}, error) {
+	storage, err, usefs := h.doStateRequest(ctx, w3c, block, func(w3c *node.Web3goClient) (interface{}, error) {
 		return w3c.Eth.StorageAt(addr, location, block)
 	})
 
@@ -84,7 +110,7 @@ func (h *EthStateHandler) CodeAt(
 	addr common.Address,
 	blockNum *types.BlockNumberOrHash,
 ) ([]byte, error) {
-	code, err, usefs := h.doRequest(ctx, w3c, func(w3c *node.Web3goClient) (interface{}, error) {
+	code, err, usefs := h.doStateRequest(ctx, w3c, blockNum, func(w3c *node.Web3goClient) (interface{}, error) {
 		return w3c.Eth.CodeAt(addr, blockNum)
 	})
 
@@ -103,7 +129,7 @@ func (h *EthStateHandler) Call(
 	callRequest types.CallRequest,
 	blockNum *types.BlockNumberOrHash,
 ) ([]byte, error) {
-	result, err, usefs := h.doRequest(ctx, w3c, func(w3c *node.Web3goClient) (interface{}, error) {
+	result, err, usefs := h.doStateRequest(ctx, w3c, blockNum, func(w3c *node.Web3goClient) (interface{}, error) {
 		return w3c.Eth.Call(callRequest, blockNum)
 	})
 
@@ -122,7 +148,7 @@ func (h *EthStateHandler) EstimateGas(
 	callRequest types.CallRequest,
 	blockNum *types.BlockNumberOrHash,
 ) (*big.Int, error) {
-	est, err, usefs := h.doRequest(ctx, w3c, func(w3c *node.Web3goClient) (interface{}, error) {
+	est, err, usefs := h.doStateRequest(ctx, w3c, blockNum, func(w3c *node.Web3goClient) (interface{}, error) {
 		return w3c.Eth.EstimateGas(callRequest, blockNum)
 	})
 
@@ -276,10 +302,70 @@ func (h *EthStateHandler) doRequest(
 		return result, err, false
 	}
 
-	fsW3c, cperr := h.cp.GetClientByIP(ctx, node.GroupEthFullState)
+	fsW3c, cperr := h.cp.GetClientByAffinity(ctx, node.GroupEthFullState)
 	if cperr == nil {
 		result, err = clientFunc(fsW3c)
 	}
 
 	return result, err, true
 }
+
+// doStateRequest requests state of the specified block, which is routed to archive nodes directly
+// if beyond the state retention of normal full nodes, or if state not available on both normal and
+// full state nodes.
+func (h *EthStateHandler) doStateRequest(
+	ctx context.Context,
+	initW3c *node.Web3goClient,
+	block *types.BlockNumberOrHash,
+	clientFunc func(w3c *node.Web3goClient) (interface{}, error),
+) (interface{}, error, bool) {
+	if h.isHistoricalState(block) {
+		metrics.Registry.RPC.Percentage("eth_state", "archive").Mark(true)
+		return h.doArchiveRequest(ctx, clientFunc, nil)
+	}
+
+	result, err, usefs := h.doRequest(ctx, initW3c, clientFunc)
+	if !isStateNotAvailable(err) {
+		return result, err, usefs
+	}
+
+	metrics.Registry.RPC.Percentage("eth_state", "archive").Mark(true)
+	return h.doArchiveRequest(ctx, clientFunc, err)
+}
+
+// isHistoricalState checks if the block is beyond the state retention of normal full nodes.
+func (h *EthStateHandler) isHistoricalState(block *types.BlockNumberOrHash) bool {
+	if h.archive.StateRetention == 0 || h.headTracker == nil || block == nil {
+		return false
+	}
+
+	bn, ok := block.Number()
+	if !ok || bn < 0 { // block hash or block tag
+		return false
+	}
+
+	latest, ok := h.headTracker.BlockNumber()
+	if !ok {
+		return false
+	}
+
+	return uint64(bn)+h.archive.StateRetention < latest.ToInt().Uint64()
+}
+
+// doArchiveRequest requests state from archive nodes, and returns state pruned error if no archive
+// node available.
+func (h *EthStateHandler) doArchiveRequest(
+	ctx context.Context, clientFunc func(w3c *node.Web3goClient) (interface{}, error), cause error,
+) (interface{}, error, bool) {
+	archiveW3c, err := h.cp.GetClientByAffinity(ctx, node.GroupEthArchives)
+	if err == nil {
+		result, err := clientFunc(archiveW3c)
+		return result, err, true
+	}
+
+	if cause != nil {
+		return nil, errors.WithMessage(errEthStatePruned, cause.Error()), true
+	}
+
+	return nil, errEthStatePruned, true
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEthStateHandlerIsHistoricalState(t *testing.T) {
+	tracker := &EthHeadTracker{headTracker: newHeadTracker(HeadTrackerConfig{MaxStaleness: time.Minute})}
+	h := &EthStateHandler{archive: EthArchiveConfig{StateRetention: 100}, headTracker: tracker}
+
+	bnh := func(bn web3Types.BlockNumber) *web3Types.BlockNumberOrHash {
+		v := web3Types.BlockNumberOrHashWithNumber(bn)
+		return &v
+	}
+
+	// latest block not tracked yet
+	assert.False(t, h.isHistoricalState(bnh(1)))
+
+	tracker.set(ethHeadTag(web3Types.LatestBlockNumber), &web3Types.Block{Number: big.NewInt(1000)})
+	assert.True(t, h.isHistoricalState(bnh(899)))
+	assert.False(t, h.isHistoricalState(bnh(900)))
+	assert.False(t, h.isHistoricalState(bnh(web3Types.LatestBlockNumber)))
+	assert.False(t, h.isHistoricalState(nil))
+
+	// disabled
+	h.archive.StateRetention = 0
+	assert.False(t, h.isHistoricalState(bnh(1)))
+}
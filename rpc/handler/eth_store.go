@@ -7,6 +7,7 @@ import (
 	"github.com/Conflux-Chain/confura/rpc/ethbridge"
 	"github.com/Conflux-Chain/confura/store"
 	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/logging"
 	"github.com/ethereum/go-ethereum/common"
 	web3Types "github.com/openweb3/web3go/types"
 	"github.com/sirupsen/logrus"
@@ -25,7 +26,7 @@ func NewEthStoreHandler(store store.Readable, next *EthStoreHandler) *EthStoreHa
 func (h *EthStoreHandler) GetBlockByHash(ctx context.Context, blockHash common.Hash, includeTxs bool) (
 	block *web3Types.Block, err error,
 ) {
-	logger := logrus.WithFields(logrus.Fields{
+	logger := logging.FromContext(ctx).WithFields(logrus.Fields{
 		"blockHash": blockHash, "includeTxs": includeTxs,
 	})
 
@@ -61,7 +62,7 @@ func (h *EthStoreHandler) GetBlockByHash(ctx context.Context, blockHash common.H
 func (h *EthStoreHandler) GetBlockByNumber(ctx context.Context, blockNum *web3Types.BlockNumber, includeTxs bool) (
 	block *web3Types.Block, err error,
 ) {
-	logger := logrus.WithFields(logrus.Fields{
+	logger := logging.FromContext(ctx).WithFields(logrus.Fields{
 		"blockNum": *blockNum, "includeTxs": includeTxs,
 	})
 
@@ -112,7 +113,7 @@ func (h *EthStoreHandler) GetLogs(ctx context.Context, filter store.LogFilter) (
 		return logs, nil
 	}
 
-	logrus.WithError(err).Info("ethStoreHandler failed to get logs from store")
+	logging.FromContext(ctx).WithError(err).Info("ethStoreHandler failed to get logs from store")
 
 	if !util.IsInterfaceValNil(h.next) {
 		return h.next.GetLogs(ctx, filter)
@@ -150,3 +151,56 @@ func (h *EthStoreHandler) GetTransactionReceipt(ctx context.Context, txHash comm
 
 	return nil, err
 }
+
+// GetBlockReceipts returns receipts of the block by number or hash with a single query.
+func (h *EthStoreHandler) GetBlockReceipts(
+	ctx context.Context, blockNrOrHash *web3Types.BlockNumberOrHash,
+) ([]*web3Types.Receipt, error) {
+	rcptStore, ok := h.store.(store.EpochReceiptReadable)
+	if !ok || blockNrOrHash == nil {
+		return nil, store.ErrUnsupported
+	}
+
+	var blockNum uint64
+	if bn, ok := blockNrOrHash.Number(); ok {
+		if bn <= 0 { // block tag, eg., `latest`
+			return nil, store.ErrUnsupported
+		}
+
+		blockNum = uint64(bn)
+	} else {
+		blockHash, _ := blockNrOrHash.Hash()
+
+		sblocksum, err := h.store.GetBlockSummaryByHash(ctx, cfxbridge.ConvertHash(blockHash))
+		if err != nil {
+			return nil, err
+		}
+
+		if sblocksum.CfxBlockSummary.EpochNumber == nil {
+			return nil, store.ErrUnsupported
+		}
+
+		// block number is the same as epoch number in evm space
+		blockNum = sblocksum.CfxBlockSummary.EpochNumber.ToInt().Uint64()
+	}
+
+	srcpts, err := rcptStore.GetEpochReceipts(ctx, blockNum)
+	if err != nil {
+		logging.FromContext(ctx).WithField("blockNum", blockNum).
+			WithError(err).
+			Debug("ETH handler failed to handle GetBlockReceipts")
+
+		if !util.IsInterfaceValNil(h.next) {
+			return h.next.GetBlockReceipts(ctx, blockNrOrHash)
+		}
+
+		return nil, err
+	}
+
+	receipts := make([]*web3Types.Receipt, len(srcpts))
+	for i, srcpt := range srcpts {
+		receipts[i] = ethbridge.ConvertReceipt(srcpt.CfxReceipt, srcpt.Extra)
+	}
+
+	return receipts, nil
+}
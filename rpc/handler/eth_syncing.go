@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"sync"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	logutil "github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/sirupsen/logrus"
+)
+
+// EthSyncingConfig is the settings to serve `eth_syncing` from aggregated view.
+type EthSyncingConfig struct {
+	Enabled bool
+	// max number of blocks that confura sync lags behind the highest full node before reported
+	// as syncing, which only takes effect if store enabled
+	MaxSyncLag uint64 `default:"100"`
+}
+
+// EthSyncProgress is the `eth_syncing` result when syncing.
+type EthSyncProgress struct {
+	StartingBlock hexutil.Uint64 `json:"startingBlock"`
+	CurrentBlock  hexutil.Uint64 `json:"currentBlock"`
+	HighestBlock  hexutil.Uint64 `json:"highestBlock"`
+}
+
+// EthSyncingAggregator serves `eth_syncing` from the aggregated view of the highest block across
+// healthy full nodes and confura's own sync progress, rather than proxying to a random full node,
+// so that clients behind the gateway will see consistent and monotonic chain status.
+type EthSyncingAggregator struct {
+	conf  EthSyncingConfig
+	store ethStoreCoverage // nil if store not enabled
+
+	mu       sync.RWMutex
+	highest  uint64 // highest block number across full nodes
+	current  uint64 // max block number synced into store
+	starting uint64 // block number synced into store when began to lag behind
+	syncing  bool
+
+	etLogger *logutil.ErrorTolerantLogger
+}
+
+// MustNewEthSyncingAggregatorFromViper creates `eth_syncing` aggregator fed by head tracker, or nil
+// if not enabled.
+func MustNewEthSyncingAggregatorFromViper(headTracker *EthHeadTracker, store *mysql.MysqlStore) *EthSyncingAggregator {
+	var conf EthSyncingConfig
+	viper.MustUnmarshalKey("ethrpc.syncing", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	if headTracker == nil {
+		logrus.Fatal("Head tracker is required for eth_syncing aggregation")
+	}
+
+	var coverage ethStoreCoverage
+	if store != nil {
+		coverage = store
+	}
+
+	a := newEthSyncingAggregator(conf, coverage)
+	headTracker.Observe(func(latest *web3Types.Block, client *node.Web3goClient) {
+		err := a.update(latest.Number.Uint64())
+		a.etLogger.Log(
+			logrus.WithField("latest", latest.Number), err, "Syncing aggregator failed to update",
+		)
+	})
+
+	return a
+}
+
+func newEthSyncingAggregator(conf EthSyncingConfig, store ethStoreCoverage) *EthSyncingAggregator {
+	return &EthSyncingAggregator{
+		conf:     conf,
+		store:    store,
+		etLogger: logutil.NewErrorTolerantLogger(logutil.DefaultETConfig),
+	}
+}
+
+// update updates the aggregated view with the latest block number of the highest full node.
+func (a *EthSyncingAggregator) update(latest uint64) error {
+	var current uint64
+	var synced bool
+
+	if a.store != nil {
+		maxBlock, ok, err := a.store.MaxEpoch()
+		if err != nil {
+			return err
+		}
+
+		current, synced = maxBlock, ok
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// keep the highest block number monotonic across full nodes
+	if latest > a.highest {
+		a.highest = latest
+	}
+
+	if !synced || current+a.conf.MaxSyncLag >= a.highest {
+		a.syncing = false
+		return nil
+	}
+
+	if !a.syncing {
+		a.syncing, a.starting = true, current
+	}
+
+	a.current = current
+
+	return nil
+}
+
+// Syncing returns false if not syncing, or the sync progress otherwise, along with whether the
+// aggregated view is ready.
+func (a *EthSyncingAggregator) Syncing() (interface{}, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.highest == 0 {
+		return nil, false
+	}
+
+	if !a.syncing {
+		return false, true
+	}
+
+	return &EthSyncProgress{
+		StartingBlock: hexutil.Uint64(a.starting),
+		CurrentBlock:  hexutil.Uint64(a.current),
+		HighestBlock:  hexutil.Uint64(a.highest),
+	}, true
+}
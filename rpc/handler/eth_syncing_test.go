@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type testEthStoreCoverage uint64
+
+func (c *testEthStoreCoverage) MaxEpoch() (uint64, bool, error) {
+	return uint64(*c), true, nil
+}
+
+func TestEthSyncingAggregator(t *testing.T) {
+	synced := testEthStoreCoverage(100)
+	a := newEthSyncingAggregator(EthSyncingConfig{MaxSyncLag: 10}, &synced)
+
+	// not ready yet
+	_, ok := a.Syncing()
+	assert.False(t, ok)
+
+	assert.NoError(t, a.update(105))
+	result, ok := a.Syncing()
+	assert.True(t, ok)
+	assert.Equal(t, false, result)
+
+	// sync lags behind too much
+	assert.NoError(t, a.update(120))
+	synced = 105
+	assert.NoError(t, a.update(115)) // highest block kept monotonic
+
+	result, ok = a.Syncing()
+	assert.True(t, ok)
+	assert.Equal(t, &EthSyncProgress{
+		StartingBlock: hexutil.Uint64(100),
+		CurrentBlock:  hexutil.Uint64(105),
+		HighestBlock:  hexutil.Uint64(120),
+	}, result)
+
+	// caught up
+	synced = 118
+	assert.NoError(t, a.update(120))
+	result, _ = a.Syncing()
+	assert.Equal(t, false, result)
+}
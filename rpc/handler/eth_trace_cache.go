@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/openweb3/web3go"
+	"github.com/openweb3/web3go/types"
+	"github.com/sirupsen/logrus"
+)
+
+// TraceCacheConfig represents the configuration of trace result cache.
+type TraceCacheConfig struct {
+	// Whether to enable trace result cache.
+	Enabled bool
+	// Max size in bytes of JSON encoded trace result to cache, larger result will not be cached.
+	MaxSize int `default:"1048576"`
+}
+
+// EthTraceCache persists trace results by transaction hash in database, so that repeated trace
+// queries won't hammer archive nodes. Note, only traces of transactions in finalized blocks are
+// cached, since traces may change if the block is reorged.
+type EthTraceCache struct {
+	conf     TraceCacheConfig
+	store    *mysql.TraceResultStore
+	finality *EthFinalityResolver
+}
+
+func MustNewEthTraceCacheFromViper(db *mysql.MysqlStore, finality *EthFinalityResolver) *EthTraceCache {
+	var conf TraceCacheConfig
+	viper.MustUnmarshalKey("ethrpc.traceCache", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	return &EthTraceCache{
+		conf:     conf,
+		store:    mysql.MustNewTraceResultStore(db.DB()),
+		finality: finality,
+	}
+}
+
+// TraceTransaction returns the cached `trace_transaction` result, or fetches from full node.
+func (c *EthTraceCache) TraceTransaction(
+	w3c *web3go.Client, txHash common.Hash, fetch func() ([]types.LocalizedTrace, error),
+) ([]types.LocalizedTrace, error) {
+	blockOf := func(traces []types.LocalizedTrace) (uint64, bool) {
+		if len(traces) == 0 {
+			return 0, false
+		}
+
+		return traces[0].BlockNumber, true
+	}
+
+	return loadOrFetchTrace(c, w3c, txHash, "trace_transaction", fetch, blockOf)
+}
+
+// DebugTraceTransaction returns the cached `debug_traceTransaction` result with the same tracing
+// options, or fetches from full node.
+func (c *EthTraceCache) DebugTraceTransaction(
+	w3c *web3go.Client, txHash common.Hash, opts []*types.GethDebugTracingOptions, fetch func() (*types.GethTrace, error),
+) (*types.GethTrace, error) {
+	method := "debug_traceTransaction"
+
+	if len(opts) > 0 && opts[0] != nil {
+		encoded, err := json.Marshal(opts[0])
+		if err != nil {
+			return fetch()
+		}
+
+		// different tracing options result in different traces
+		method += ":" + crypto.Keccak256Hash(encoded).Hex()[2:18]
+	}
+
+	// geth trace doesn't contain block number, so query from the transaction instead
+	blockOf := func(*types.GethTrace) (uint64, bool) {
+		tx, err := w3c.Eth.TransactionByHash(txHash)
+		if err != nil || tx == nil || tx.BlockNumber == nil {
+			return 0, false
+		}
+
+		return tx.BlockNumber.Uint64(), true
+	}
+
+	return loadOrFetchTrace(c, w3c, txHash, method, fetch, blockOf)
+}
+
+func loadOrFetchTrace[T any](
+	c *EthTraceCache, w3c *web3go.Client, txHash common.Hash, method string,
+	fetch func() (T, error), blockOf func(T) (uint64, bool),
+) (T, error) {
+	logger := logrus.WithFields(logrus.Fields{"txHash": txHash, "method": method})
+
+	data, ok, err := c.store.GetTraceResult(txHash.Hex(), method)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get trace result from cache")
+	}
+
+	if ok {
+		var result T
+		if err := json.Unmarshal(data, &result); err == nil {
+			metrics.Registry.RPC.Percentage(method, "traceCache").Mark(true)
+			return result, nil
+		}
+
+		logger.WithError(err).Warn("Failed to decode cached trace result")
+	}
+
+	metrics.Registry.RPC.Percentage(method, "traceCache").Mark(false)
+
+	result, err := fetch()
+	if err != nil {
+		return result, err
+	}
+
+	if blockNum, ok := blockOf(result); ok && c.isFinalized(w3c, blockNum) {
+		c.add(txHash, method, result)
+	}
+
+	return result, nil
+}
+
+// isFinalized checks if the specified block is finalized, and returns false if the finalized block
+// could not be resolved.
+func (c *EthTraceCache) isFinalized(w3c *web3go.Client, blockNum uint64) bool {
+	finalized, err := c.finality.Resolve(w3c, types.FinalizedBlockNumber)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to resolve finalized block for trace cache")
+		return false
+	}
+
+	return finalized >= 0 && blockNum <= uint64(finalized)
+}
+
+// add caches the non-empty trace result within size limit.
+func (c *EthTraceCache) add(txHash common.Hash, method string, result interface{}) {
+	data, err := json.Marshal(result)
+	if err != nil || isEmptyTraceResult(data) {
+		// empty result for pending or non-existent transaction
+		return
+	}
+
+	logger := logrus.WithFields(logrus.Fields{
+		"txHash": txHash, "method": method, "size": len(data),
+	})
+
+	if len(data) > c.conf.MaxSize {
+		logger.Debug("Trace result too large to cache")
+		return
+	}
+
+	if _, err := c.store.AddTraceResult(txHash.Hex(), method, data); err != nil {
+		logger.WithError(err).Warn("Failed to add trace result into cache")
+	}
+}
+
+func isEmptyTraceResult(data []byte) bool {
+	switch string(data) {
+	case "null", "[]", "{}":
+		return true
+	default:
+		return false
+	}
+}
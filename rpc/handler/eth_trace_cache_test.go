@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsEmptyTraceResult(t *testing.T) {
+	assert.True(t, isEmptyTraceResult([]byte("null")))
+	assert.True(t, isEmptyTraceResult([]byte("[]")))
+	assert.True(t, isEmptyTraceResult([]byte("{}")))
+	assert.False(t, isEmptyTraceResult([]byte(`[{"type":"call"}]`)))
+}
+
+func TestEthTraceCacheIsFinalized(t *testing.T) {
+	tracker := &EthHeadTracker{headTracker: newHeadTracker(HeadTrackerConfig{MaxStaleness: time.Minute})}
+	tracker.set(ethHeadTag(web3Types.FinalizedBlockNumber), &web3Types.Block{Number: big.NewInt(100)})
+
+	cache := &EthTraceCache{finality: &EthFinalityResolver{headTracker: tracker}}
+
+	assert.True(t, cache.isFinalized(nil, 99))
+	assert.True(t, cache.isFinalized(nil, 100))
+	assert.False(t, cache.isFinalized(nil, 101))
+}
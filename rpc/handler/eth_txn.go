@@ -2,6 +2,7 @@ package handler
 
 import (
 	"strings"
+	"sync"
 
 	"github.com/Conflux-Chain/confura/node"
 	"github.com/Conflux-Chain/confura/util"
@@ -10,9 +11,11 @@ import (
 	"github.com/Conflux-Chain/go-conflux-util/viper"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/openweb3/go-rpc-provider/utils"
 	"github.com/openweb3/web3go"
+	"github.com/openweb3/web3go/types"
 	"github.com/sirupsen/logrus"
 )
 
@@ -22,6 +25,7 @@ type EthTxnHandler struct {
 	nclient  *rpc.Client         // node RPC client
 	clients  *util.ConcurrentMap // sdk clients: node name => RPC client
 	relayTxn bool                // whether to relay to other group nodes while sending txn
+	tracker  *PendingTxnTracker  // pending transaction tracker, nil if disabled
 }
 
 func MustNewEthTxnHandler(relayer relay.TxnRelayer) *EthTxnHandler {
@@ -32,7 +36,7 @@ func MustNewEthTxnHandler(relayer relay.TxnRelayer) *EthTxnHandler {
 	if nodeRpcUrl := node.Config().Router.EthNodeRPCURL; len(nodeRpcUrl) > 0 {
 		var err error
 
-		nodeRpcClient, err = rpc.DialHTTP(nodeRpcUrl)
+		nodeRpcClient, err = rpcutil.DialAdminHTTP(nodeRpcUrl, node.Config().AuthToken)
 		if err != nil {
 			logrus.WithField("nodeRpcUrl", nodeRpcUrl).
 				WithError(err).
@@ -45,15 +49,32 @@ func MustNewEthTxnHandler(relayer relay.TxnRelayer) *EthTxnHandler {
 		nclient:  nodeRpcClient,
 		clients:  &util.ConcurrentMap{},
 		relayTxn: cfg.RelayTxn,
+		tracker:  MustNewPendingTxnTrackerFromViper(),
 	}
 }
 
 func (h *EthTxnHandler) SendRawTxn(w3c *node.Web3goClient, group node.Group, signedTx hexutil.Bytes) (common.Hash, error) {
+	tx, tracked := h.decodeTrackedTxn(signedTx)
 	txHash, err := w3c.Eth.SendRawTransaction(signedTx)
 	if err != nil {
+		if tracked {
+			err = h.tracker.Diagnose(err, tx.sender, tx.nonce, func() (uint64, error) {
+				pending := types.BlockNumberOrHashWithNumber(types.PendingBlockNumber)
+				nonce, err := w3c.Eth.TransactionCount(common.HexToAddress(tx.sender), &pending)
+				if err != nil {
+					return 0, err
+				}
+				return nonce.Uint64(), nil
+			})
+		}
+
 		return txHash, err
 	}
 
+	if tracked {
+		h.tracker.Track(tx.sender, tx.nonce, tx.hash)
+	}
+
 	// relay transaction broadcasting asynchronously
 	if h.relayer != nil && !h.relayer.Relay(signedTx) {
 		logrus.Info("Txn relay pool is full, dropping transaction")
@@ -67,6 +88,25 @@ func (h *EthTxnHandler) SendRawTxn(w3c *node.Web3goClient, group node.Group, sig
 	return txHash, err
 }
 
+// decodeTrackedTxn decodes the raw transaction for pending transaction tracking if enabled.
+func (h *EthTxnHandler) decodeTrackedTxn(signedTx hexutil.Bytes) (txn pendingTxn, ok bool) {
+	if h.tracker == nil {
+		return txn, false
+	}
+
+	var tx ethtypes.Transaction
+	if err := tx.UnmarshalBinary(signedTx); err != nil {
+		return txn, false
+	}
+
+	sender, err := ethtypes.Sender(ethtypes.LatestSignerForChainID(tx.ChainId()), &tx)
+	if err != nil {
+		return txn, false
+	}
+
+	return pendingTxn{sender: sender.Hex(), nonce: tx.Nonce(), hash: tx.Hash().Hex()}, true
+}
+
 // replicateRawTxnSendingByGroup synchronously replicate raw txn sending to all full nodes of some specific group
 func (h *EthTxnHandler) replicateRawTxnSendingByGroup(w3c *node.Web3goClient, group node.Group, signedTx hexutil.Bytes) {
 	if h.nclient != nil { // fetch group nodes from node RPC
@@ -92,6 +132,10 @@ func (h *EthTxnHandler) replicateRawTxnSendingByGroup(w3c *node.Web3goClient, gr
 func (h *EthTxnHandler) replicateRawTxnSendingToNodes(w3c *node.Web3goClient, nodeUrls []string, signedTx hexutil.Bytes) {
 	initialNodeName := w3c.NodeName()
 
+	// fan out to full nodes concurrently for faster propagation
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
 	for _, url := range nodeUrls {
 		nodeName := rpcutil.Url2NodeName(url)
 		if strings.EqualFold(nodeName, initialNodeName) {
@@ -110,11 +154,16 @@ func (h *EthTxnHandler) replicateRawTxnSendingToNodes(w3c *node.Web3goClient, no
 			continue
 		}
 
-		_, err = c.(*web3go.Client).Eth.SendRawTransaction(signedTx)
-		if err != nil && !utils.IsRPCJSONError(err) {
-			logrus.WithField("url", url).
-				WithError(err).
-				Info("Txn handler failed to replicate sending evm raw transaction")
-		}
+		wg.Add(1)
+		go func(url string, c interface{}) {
+			defer wg.Done()
+
+			_, err := c.(*web3go.Client).Eth.SendRawTransaction(signedTx)
+			if err != nil && !utils.IsRPCJSONError(err) {
+				logrus.WithField("url", url).
+					WithError(err).
+					Info("Txn handler failed to replicate sending evm raw transaction")
+			}
+		}(url, c)
 	}
 }
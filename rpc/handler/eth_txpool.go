@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/node"
+	logutil "github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// timeout to sample txpool content from single full node
+	txpoolSampleTimeout = 5 * time.Second
+)
+
+// TxpoolConfig represents the configuration of txpool aggregator.
+type TxpoolConfig struct {
+	// Whether to enable txpool aggregator.
+	Enabled bool
+	// Interval to sample txpool content from full nodes.
+	Interval time.Duration `default:"3s"`
+}
+
+// TxpoolStatus is the number of pending and queued transactions in txpool.
+type TxpoolStatus struct {
+	Pending hexutil.Uint `json:"pending"`
+	Queued  hexutil.Uint `json:"queued"`
+}
+
+// txpoolTxn is the transaction in txpool, which only hash is concerned.
+type txpoolTxn struct {
+	Hash common.Hash `json:"hash"`
+}
+
+// txpoolContent is the result of `txpool_content`: status => sender => nonce => transaction.
+type txpoolContent struct {
+	Pending map[common.Address]map[string]txpoolTxn `json:"pending"`
+	Queued  map[common.Address]map[string]txpoolTxn `json:"queued"`
+}
+
+// txpoolKey identifies transaction in txpool by sender and nonce.
+type txpoolKey struct {
+	sender common.Address
+	nonce  string
+}
+
+// mergeTxpoolContents merges txpool contents sampled from multiple full nodes, in which pending
+// transactions take precedence over queued ones of the same sender and nonce.
+func mergeTxpoolContents(contents ...*txpoolContent) (pending, queued map[txpoolKey]common.Hash) {
+	pending = make(map[txpoolKey]common.Hash)
+	queued = make(map[txpoolKey]common.Hash)
+
+	for _, content := range contents {
+		for sender, txns := range content.Pending {
+			for nonce, txn := range txns {
+				key := txpoolKey{sender, nonce}
+				if _, ok := pending[key]; !ok {
+					pending[key] = txn.Hash
+				}
+			}
+		}
+	}
+
+	for _, content := range contents {
+		for sender, txns := range content.Queued {
+			for nonce, txn := range txns {
+				key := txpoolKey{sender, nonce}
+				if _, ok := pending[key]; ok {
+					continue
+				}
+
+				if _, ok := queued[key]; !ok {
+					queued[key] = txn.Hash
+				}
+			}
+		}
+	}
+
+	return pending, queued
+}
+
+// EthTxpoolAggregator periodically samples txpool content from all full nodes and merges them.
+type EthTxpoolAggregator struct {
+	conf           TxpoolConfig
+	clientProvider *node.EthClientProvider
+
+	mu      sync.RWMutex
+	status  *TxpoolStatus                   // aggregated status, nil if not sampled yet
+	pending map[common.Hash]struct{}        // aggregated pending transactions
+	subs    map[chan<- common.Hash]struct{} // subscribers of new pending transactions
+}
+
+func MustNewEthTxpoolAggregatorFromViper(cp *node.EthClientProvider) *EthTxpoolAggregator {
+	var conf TxpoolConfig
+	viper.MustUnmarshalKey("ethrpc.txpool", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	aggregator := &EthTxpoolAggregator{
+		conf:           conf,
+		clientProvider: cp,
+		pending:        make(map[common.Hash]struct{}),
+		subs:           make(map[chan<- common.Hash]struct{}),
+	}
+
+	go aggregator.run()
+	return aggregator
+}
+
+func (a *EthTxpoolAggregator) run() {
+	ticker := time.NewTicker(a.conf.Interval)
+	defer ticker.Stop()
+
+	etLogger := logutil.NewErrorTolerantLogger(logutil.DefaultETConfig)
+	for range ticker.C {
+		err := a.sample()
+		etLogger.Log(logrus.StandardLogger(), err, "Txpool aggregator failed to sample txpool")
+	}
+}
+
+// sample samples txpool content from all full nodes concurrently, and merges them.
+func (a *EthTxpoolAggregator) sample() error {
+	clients, err := a.clientProvider.GetClientsByGroup(node.GroupEthHttp)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	contents := make([]*txpoolContent, len(clients))
+
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), txpoolSampleTimeout)
+			defer cancel()
+
+			var content txpoolContent
+			if err := clients[i].Provider().CallContext(ctx, &content, "txpool_content"); err != nil {
+				logrus.WithField("nodeUrl", clients[i].URL).
+					WithError(err).
+					Debug("Txpool aggregator failed to sample txpool content")
+				return
+			}
+
+			contents[i] = &content
+		}(i)
+	}
+
+	wg.Wait()
+
+	var sampled []*txpoolContent
+	for _, content := range contents {
+		if content != nil {
+			sampled = append(sampled, content)
+		}
+	}
+
+	if len(sampled) == 0 {
+		return node.ErrClientUnavailable
+	}
+
+	a.update(mergeTxpoolContents(sampled...))
+	return nil
+}
+
+// update updates the aggregated txpool, and notifies subscribers of new pending transactions.
+func (a *EthTxpoolAggregator) update(pending, queued map[txpoolKey]common.Hash) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.status = &TxpoolStatus{
+		Pending: hexutil.Uint(len(pending)),
+		Queued:  hexutil.Uint(len(queued)),
+	}
+
+	newPending := make(map[common.Hash]struct{}, len(pending))
+	for _, hash := range pending {
+		newPending[hash] = struct{}{}
+
+		if _, ok := a.pending[hash]; ok {
+			continue
+		}
+
+		for ch := range a.subs {
+			select {
+			case ch <- hash:
+			default: // drop for slow subscriber
+			}
+		}
+	}
+
+	a.pending = newPending
+}
+
+// Status returns the aggregated txpool status, or false if not sampled yet.
+func (a *EthTxpoolAggregator) Status() (TxpoolStatus, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.status == nil {
+		return TxpoolStatus{}, false
+	}
+
+	return *a.status, true
+}
+
+// SubscribePendingTxns subscribes hashes of new pending transactions from the aggregated txpool.
+func (a *EthTxpoolAggregator) SubscribePendingTxns(ch chan<- common.Hash) (unsubscribe func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.subs[ch] = struct{}{}
+
+	return func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		delete(a.subs, ch)
+	}
+}
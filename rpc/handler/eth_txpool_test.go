@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testTxpoolSender1 = common.HexToAddress("0x01")
+	testTxpoolSender2 = common.HexToAddress("0x02")
+)
+
+func newTestTxpoolAggregator() *EthTxpoolAggregator {
+	return &EthTxpoolAggregator{
+		pending: make(map[common.Hash]struct{}),
+		subs:    make(map[chan<- common.Hash]struct{}),
+	}
+}
+
+func TestMergeTxpoolContentsPendingFirst(t *testing.T) {
+	hash := common.HexToHash("0x11")
+
+	// queued on one full node, while pending on another
+	content1 := &txpoolContent{
+		Queued: map[common.Address]map[string]txpoolTxn{testTxpoolSender1: {"5": {hash}}},
+	}
+	content2 := &txpoolContent{
+		Pending: map[common.Address]map[string]txpoolTxn{testTxpoolSender1: {"5": {hash}}},
+	}
+
+	pending, queued := mergeTxpoolContents(content1, content2)
+	assert.Equal(t, map[txpoolKey]common.Hash{{testTxpoolSender1, "5"}: hash}, pending)
+	assert.Empty(t, queued)
+}
+
+func TestMergeTxpoolContentsSameNonce(t *testing.T) {
+	hash1, hash2 := common.HexToHash("0x11"), common.HexToHash("0x12")
+	hash3 := common.HexToHash("0x13")
+
+	// replaced transaction of the same sender and nonce counted only once
+	content1 := &txpoolContent{
+		Pending: map[common.Address]map[string]txpoolTxn{testTxpoolSender1: {"1": {hash1}}},
+		Queued:  map[common.Address]map[string]txpoolTxn{testTxpoolSender2: {"9": {hash3}}},
+	}
+	content2 := &txpoolContent{
+		Pending: map[common.Address]map[string]txpoolTxn{testTxpoolSender1: {"1": {hash2}}},
+		Queued:  map[common.Address]map[string]txpoolTxn{testTxpoolSender2: {"9": {hash3}}},
+	}
+
+	pending, queued := mergeTxpoolContents(content1, content2)
+	assert.Equal(t, map[txpoolKey]common.Hash{{testTxpoolSender1, "1"}: hash1}, pending)
+	assert.Equal(t, map[txpoolKey]common.Hash{{testTxpoolSender2, "9"}: hash3}, queued)
+}
+
+func TestEthTxpoolAggregatorStatus(t *testing.T) {
+	aggregator := newTestTxpoolAggregator()
+
+	_, ok := aggregator.Status()
+	assert.False(t, ok)
+
+	aggregator.update(
+		map[txpoolKey]common.Hash{{testTxpoolSender1, "1"}: common.HexToHash("0x11")},
+		map[txpoolKey]common.Hash{{testTxpoolSender1, "3"}: common.HexToHash("0x13")},
+	)
+
+	status, ok := aggregator.Status()
+	assert.True(t, ok)
+	assert.Equal(t, TxpoolStatus{Pending: 1, Queued: 1}, status)
+}
+
+func TestEthTxpoolAggregatorNotifyNewPending(t *testing.T) {
+	aggregator := newTestTxpoolAggregator()
+	hash1, hash2 := common.HexToHash("0x11"), common.HexToHash("0x12")
+
+	ch := make(chan common.Hash, 10)
+	unsubscribe := aggregator.SubscribePendingTxns(ch)
+
+	aggregator.update(map[txpoolKey]common.Hash{{testTxpoolSender1, "1"}: hash1}, nil)
+	aggregator.update(map[txpoolKey]common.Hash{
+		{testTxpoolSender1, "1"}: hash1, {testTxpoolSender1, "2"}: hash2,
+	}, nil)
+
+	// hash1 not notified again
+	assert.Equal(t, []common.Hash{hash1, hash2}, []common.Hash{<-ch, <-ch})
+	assert.Empty(t, ch)
+
+	unsubscribe()
+	aggregator.update(map[txpoolKey]common.Hash{{testTxpoolSender1, "3"}: common.HexToHash("0x13")}, nil)
+	assert.Empty(t, ch)
+}
+
+func TestEthTxpoolAggregatorSlowSubscriber(t *testing.T) {
+	aggregator := newTestTxpoolAggregator()
+
+	ch := make(chan common.Hash, 1)
+	defer aggregator.SubscribePendingTxns(ch)()
+
+	// dropped rather than blocked once channel full
+	aggregator.update(map[txpoolKey]common.Hash{
+		{testTxpoolSender1, "1"}: common.HexToHash("0x11"),
+		{testTxpoolSender1, "2"}: common.HexToHash("0x12"),
+	}, nil)
+	assert.Len(t, ch, 1)
+}
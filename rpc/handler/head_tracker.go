@@ -0,0 +1,354 @@
+package handler
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/node"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	logutil "github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/sirupsen/logrus"
+)
+
+// HeadTrackerConfig represents the configuration of chain head tracker.
+type HeadTrackerConfig struct {
+	// Whether to enable chain head tracker.
+	Enabled bool
+	// Interval to poll chain heads from full nodes.
+	Interval time.Duration `default:"200ms"`
+	// Max staleness of tracked chain heads to serve, otherwise fall back to full node.
+	MaxStaleness time.Duration `default:"1s"`
+}
+
+// trackedHead is the chain head value with update time.
+type trackedHead struct {
+	value     interface{}
+	updatedAt time.Time
+}
+
+// headTracker maintains chain heads (block number or header) keyed by tag, eg., `latest`, with
+// freshness guarantee, so that the most frequent upstream round trips could be eliminated.
+type headTracker struct {
+	conf HeadTrackerConfig
+
+	mu    sync.RWMutex
+	heads map[string]trackedHead
+}
+
+func newHeadTracker(conf HeadTrackerConfig) *headTracker {
+	return &headTracker{conf: conf, heads: make(map[string]trackedHead)}
+}
+
+func (t *headTracker) set(tag string, value interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.heads[tag] = trackedHead{value: value, updatedAt: time.Now()}
+}
+
+// get returns the tracked chain head by tag if not stale.
+func (t *headTracker) get(tag string) (interface{}, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	head, ok := t.heads[tag]
+	if !ok || time.Since(head.updatedAt) > t.conf.MaxStaleness {
+		return nil, false
+	}
+
+	return head.value, true
+}
+
+// run polls chain heads periodically.
+func (t *headTracker) run(space string, poll func() error) {
+	ticker := time.NewTicker(t.conf.Interval)
+	defer ticker.Stop()
+
+	etLogger := logutil.NewErrorTolerantLogger(logutil.DefaultETConfig)
+	for range ticker.C {
+		err := poll()
+		etLogger.Log(
+			logrus.WithField("space", space), err, "Head tracker failed to poll chain heads",
+		)
+	}
+}
+
+// pollHighest polls from all clients concurrently, and returns the index of client with the
+// highest number, or -1 if all failed.
+func pollHighest[C, T any](clients []C, poll func(C) (T, uint64, error)) (best T, bestIdx int) {
+	var wg sync.WaitGroup
+	results := make([]T, len(clients))
+	numbers := make([]uint64, len(clients))
+	oks := make([]bool, len(clients))
+
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			res, num, err := poll(clients[i])
+			results[i], numbers[i], oks[i] = res, num, err == nil
+		}(i)
+	}
+
+	wg.Wait()
+
+	bestIdx = -1
+	for i := range clients {
+		if oks[i] && (bestIdx < 0 || numbers[i] > numbers[bestIdx]) {
+			bestIdx = i
+		}
+	}
+
+	if bestIdx >= 0 {
+		best = results[bestIdx]
+	}
+
+	return best, bestIdx
+}
+
+// ethHeadTag returns the tracked head tag of block number, eg., `latest`.
+func ethHeadTag(bn web3Types.BlockNumber) string {
+	return fmt.Sprintf("block:%d", bn)
+}
+
+// EthHeadObserver is notified once the latest block changed along with the client polled from.
+type EthHeadObserver func(latest *web3Types.Block, client *node.Web3goClient)
+
+// EthHeadTracker tracks the latest, safe and finalized block headers of evm space.
+type EthHeadTracker struct {
+	*headTracker
+	clientProvider *node.EthClientProvider
+	latestNum      uint64
+	latestHash     common.Hash
+	observers      []EthHeadObserver
+}
+
+func MustNewEthHeadTrackerFromViper(cp *node.EthClientProvider) *EthHeadTracker {
+	var conf HeadTrackerConfig
+	viper.MustUnmarshalKey("ethrpc.headTracker", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	t := &EthHeadTracker{headTracker: newHeadTracker(conf), clientProvider: cp}
+	go t.run("eth", t.poll)
+
+	return t
+}
+
+func (t *EthHeadTracker) poll() error {
+	clients, err := t.clientProvider.GetClientsByGroup(node.GroupEthHttp)
+	if err != nil {
+		return err
+	}
+
+	latest, idx := pollHighest(clients, func(w3c *node.Web3goClient) (*web3Types.Block, uint64, error) {
+		block, err := w3c.Eth.BlockByNumber(web3Types.LatestBlockNumber, false)
+		if err == nil && block == nil {
+			err = node.ErrClientUnavailable
+		}
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return block, block.Number.Uint64(), nil
+	})
+
+	if idx < 0 {
+		return node.ErrClientUnavailable
+	}
+
+	t.set(ethHeadTag(web3Types.LatestBlockNumber), latest)
+
+	if latest.Hash != t.latestHash {
+		t.latestHash = latest.Hash
+
+		for _, observe := range t.observers {
+			observe(latest, clients[idx])
+		}
+	}
+
+	if latest.Number.Uint64() == t.latestNum {
+		// refresh safe and finalized heads as well, which won't change if latest not changed
+		for _, bn := range []web3Types.BlockNumber{web3Types.SafeBlockNumber, web3Types.FinalizedBlockNumber} {
+			if head, ok := t.get(ethHeadTag(bn)); ok {
+				t.set(ethHeadTag(bn), head)
+			}
+		}
+
+		return nil
+	}
+
+	t.latestNum = latest.Number.Uint64()
+
+	for _, bn := range []web3Types.BlockNumber{web3Types.SafeBlockNumber, web3Types.FinalizedBlockNumber} {
+		block, err := clients[idx].Eth.BlockByNumber(bn, false)
+		if err != nil {
+			return err
+		}
+
+		if block != nil {
+			t.set(ethHeadTag(bn), block)
+		}
+	}
+
+	return nil
+}
+
+// Observe registers an observer to be notified of the latest block changes, which is not thread
+// safe and should be called during initialization.
+func (t *EthHeadTracker) Observe(observer EthHeadObserver) {
+	t.observers = append(t.observers, observer)
+}
+
+// BlockNumber returns the tracked latest block number.
+func (t *EthHeadTracker) BlockNumber() (*hexutil.Big, bool) {
+	block, ok := t.Header(web3Types.LatestBlockNumber)
+	if !ok {
+		return nil, false
+	}
+
+	return (*hexutil.Big)(block.Number), true
+}
+
+// Header returns the tracked block header (without transactions) of `latest`, `safe` or `finalized`.
+func (t *EthHeadTracker) Header(bn web3Types.BlockNumber) (*web3Types.Block, bool) {
+	head, ok := t.get(ethHeadTag(bn))
+	if !ok {
+		return nil, false
+	}
+
+	return head.(*web3Types.Block), true
+}
+
+// cfxTrackedEpochs are the core space epoch tags tracked.
+var cfxTrackedEpochs = []*types.Epoch{
+	types.EpochLatestMined,
+	types.EpochLatestState,
+	types.EpochLatestConfirmed,
+	types.EpochLatestCheckpoint,
+	types.EpochLatestFinalized,
+}
+
+// cfxStatusTag is the tracked head tag of chain status aggregated across full nodes.
+const cfxStatusTag = "status"
+
+// CfxHeadTracker tracks the chain status of the highest full node, the epoch numbers of all epoch
+// tags, and pivot block headers of `latest_mined` and `latest_state` epochs of core space.
+type CfxHeadTracker struct {
+	*headTracker
+	clientProvider *node.CfxClientProvider
+}
+
+func MustNewCfxHeadTrackerFromViper(cp *node.CfxClientProvider) *CfxHeadTracker {
+	var conf HeadTrackerConfig
+	viper.MustUnmarshalKey("rpc.headTracker", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	t := &CfxHeadTracker{headTracker: newHeadTracker(conf), clientProvider: cp}
+	go t.run("cfx", t.poll)
+
+	return t
+}
+
+func (t *CfxHeadTracker) poll() error {
+	clients, err := t.clientProvider.GetClientsByGroup(node.GroupCfxHttp)
+	if err != nil {
+		return err
+	}
+
+	status, idx := pollHighest(clients, func(cfx sdk.ClientOperator) (types.Status, uint64, error) {
+		status, err := cfx.GetStatus()
+		return status, uint64(status.EpochNumber), err
+	})
+
+	if idx < 0 {
+		return node.ErrClientUnavailable
+	}
+
+	// keep the aggregated chain status monotonic across full nodes
+	if prev, ok := t.get(cfxStatusTag); ok && prev.(types.Status).EpochNumber > status.EpochNumber {
+		status = prev.(types.Status)
+	}
+
+	t.set(cfxStatusTag, status)
+
+	epochs := []hexutil.Uint64{
+		status.EpochNumber,
+		status.LatestState,
+		status.LatestConfirmed,
+		status.LatestCheckpoint,
+		status.LatestFinalized,
+	}
+
+	for i, epoch := range cfxTrackedEpochs {
+		if prev, ok := t.get(epoch.String()); ok && prev.(hexutil.Uint64) == epochs[i] {
+			// refresh header as well if epoch not changed
+			if header, ok := t.get(epoch.String() + ":header"); ok {
+				t.set(epoch.String()+":header", header)
+			}
+		} else if i < 2 { // only pivot block headers of `latest_mined` and `latest_state` tracked
+			header, err := clients[idx].GetBlockSummaryByEpoch(types.NewEpochNumberUint64(uint64(epochs[i])))
+			if err != nil {
+				return err
+			}
+
+			if header != nil {
+				t.set(epoch.String()+":header", header)
+			}
+		}
+
+		t.set(epoch.String(), epochs[i])
+	}
+
+	return nil
+}
+
+// EpochNumber returns the tracked epoch number of epoch tag, which is `latest_mined` by default.
+func (t *CfxHeadTracker) EpochNumber(epoch *types.Epoch) (*hexutil.Big, bool) {
+	if epoch == nil {
+		epoch = types.EpochLatestMined
+	}
+
+	num, ok := t.get(epoch.String())
+	if !ok {
+		return nil, false
+	}
+
+	return (*hexutil.Big)(new(big.Int).SetUint64(uint64(num.(hexutil.Uint64)))), true
+}
+
+// Header returns the tracked pivot block header (without transactions) of `latest_mined` or
+// `latest_state` epoch.
+func (t *CfxHeadTracker) Header(epoch *types.Epoch) (*types.BlockSummary, bool) {
+	header, ok := t.get(epoch.String() + ":header")
+	if !ok {
+		return nil, false
+	}
+
+	return header.(*types.BlockSummary), true
+}
+
+// Status returns the tracked chain status of the highest full node, which is monotonic across
+// full nodes so that clients behind load balancer will not see epoch number going backwards.
+func (t *CfxHeadTracker) Status() (types.Status, bool) {
+	status, ok := t.get(cfxStatusTag)
+	if !ok {
+		return types.Status{}, false
+	}
+
+	return status.(types.Status), true
+}
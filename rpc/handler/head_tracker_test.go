@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeadTrackerStaleness(t *testing.T) {
+	tracker := newHeadTracker(HeadTrackerConfig{MaxStaleness: 50 * time.Millisecond})
+
+	_, ok := tracker.get("latest")
+	assert.False(t, ok)
+
+	tracker.set("latest", uint64(100))
+	v, ok := tracker.get("latest")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(100), v)
+
+	time.Sleep(100 * time.Millisecond)
+	_, ok = tracker.get("latest")
+	assert.False(t, ok)
+}
+
+func TestPollHighest(t *testing.T) {
+	heights := []uint64{10, 12, 15, 11}
+	best, idx := pollHighest(heights, func(h uint64) (uint64, uint64, error) {
+		if h == 15 { // the highest node failed
+			return 0, 0, errors.New("unavailable")
+		}
+
+		return h, h, nil
+	})
+	assert.Equal(t, 1, idx)
+	assert.Equal(t, uint64(12), best)
+
+	_, idx = pollHighest(heights, func(h uint64) (uint64, uint64, error) {
+		return 0, 0, errors.New("unavailable")
+	})
+	assert.Equal(t, -1, idx)
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// delegate event logs query to full node
+	logScanPathFullnode = "fullnode"
+	// no event logs at all as indicated by address blooms
+	logScanPathNone = "none"
+)
+
+var (
+	errLogQueryTooExpensive = errors.New("the query is too expensive, please narrow down your filter condition")
+
+	logScanPaths = []string{mysql.LogScanPathIndex, mysql.LogScanPathRange, logScanPathFullnode, logScanPathNone}
+)
+
+// logPlannerConfig is the cost based query planner settings for getLogs.
+type logPlannerConfig struct {
+	Enabled bool
+	// max cost allowed for a single query, otherwise rejected with a narrower range suggested
+	MaxCost uint64 `default:"1000000"`
+	// cost per row scanned from database
+	RowCost uint64 `default:"1"`
+	// cost per block scanned by full node
+	FullnodeBlockCost uint64 `default:"100"`
+}
+
+// LogQueryPlanner estimates the cost of getLogs from the index statistics of database, and picks
+// the cheapest execution path (index scan, range scan or full node), or rejects the query if too
+// expensive.
+type LogQueryPlanner struct {
+	conf logPlannerConfig
+	ms   *mysql.MysqlStore
+}
+
+// MustNewLogQueryPlannerFromViper creates log query planner, or nil if not enabled.
+func MustNewLogQueryPlannerFromViper(ms *mysql.MysqlStore) *LogQueryPlanner {
+	var conf logPlannerConfig
+	viper.MustUnmarshalKey("requestControl.logPlanner", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	if conf.MaxCost == 0 || conf.RowCost == 0 || conf.FullnodeBlockCost == 0 {
+		logrus.WithField("config", conf).Fatal("Invalid log query planner config, all costs must be positive")
+	}
+
+	return &LogQueryPlanner{conf: conf, ms: ms}
+}
+
+// logQueryPlan is the execution plan of getLogs query against database.
+type logQueryPlan struct {
+	path   string          // scan path
+	cost   uint64          // estimated cost
+	filter store.LogFilter // log filter with block range narrowed by address blooms
+}
+
+// plan plans the execution of the log filter within database.
+func (p *LogQueryPlanner) plan(ctx context.Context, filter store.LogFilter, delegatedRpcMethod string) (logQueryPlan, error) {
+	filter, ok, err := p.narrow(ctx, filter)
+	if err != nil {
+		return logQueryPlan{}, errors.WithMessage(err, "failed to narrow block range by address blooms")
+	}
+
+	if !ok {
+		plan := logQueryPlan{path: logScanPathNone, filter: filter}
+		p.markPlan(plan, delegatedRpcMethod)
+
+		return plan, nil
+	}
+
+	estimate, err := p.ms.EstimateLogs(ctx, filter)
+	if err != nil {
+		return logQueryPlan{}, errors.WithMessage(err, "failed to estimate getLogs cost")
+	}
+
+	plan := logQueryPlan{path: estimate.Path, cost: estimate.Rows * p.conf.RowCost, filter: filter}
+
+	// full node delegation is only rational within the max block range
+	if numBlocks := filter.BlockTo - filter.BlockFrom + 1; numBlocks <= store.MaxLogBlockRange() {
+		if fnCost := numBlocks * p.conf.FullnodeBlockCost; fnCost < plan.cost {
+			plan = logQueryPlan{path: logScanPathFullnode, cost: fnCost, filter: filter}
+		}
+	}
+
+	p.markPlan(plan, delegatedRpcMethod)
+
+	if plan.cost > p.conf.MaxCost {
+		return plan, p.newTooExpensiveError(filter, plan.cost)
+	}
+
+	return plan, nil
+}
+
+func (p *LogQueryPlanner) markPlan(plan logQueryPlan, delegatedRpcMethod string) {
+	if len(delegatedRpcMethod) == 0 {
+		return
+	}
+
+	for _, path := range logScanPaths {
+		metrics.Registry.RPC.Percentage(delegatedRpcMethod, "planner/"+path).Mark(plan.path == path)
+	}
+
+	metrics.Registry.RPC.LogQueryCost(delegatedRpcMethod).Update(int64(plan.cost))
+}
+
+// narrow narrows the block range of log filter to skip the epochs without any activity of the
+// filtered contracts by address blooms, or returns false if no event logs at all.
+func (p *LogQueryPlanner) narrow(ctx context.Context, filter store.LogFilter) (store.LogFilter, bool, error) {
+	contracts := filter.Contracts.ToSlice()
+	if len(contracts) == 0 {
+		return filter, true, nil
+	}
+
+	addrs := make([]common.Address, 0, len(contracts))
+	for _, contract := range contracts {
+		addr, err := cfxaddress.NewFromBase32(contract)
+		if err != nil {
+			return filter, true, nil
+		}
+
+		addrs = append(addrs, addr.MustGetCommonAddress())
+	}
+
+	bnRange := citypes.RangeUint64{From: filter.BlockFrom, To: filter.BlockTo}
+	bnRange, ok, err := p.ms.NarrowBlockRange(ctx, addrs, bnRange)
+	if err != nil || !ok {
+		return filter, ok, err
+	}
+
+	filter.BlockFrom, filter.BlockTo = bnRange.From, bnRange.To
+
+	return filter, true, nil
+}
+
+// newTooExpensiveError creates error with a narrower block range suggested proportionally to the
+// max cost, assuming event logs are evenly distributed.
+func (p *LogQueryPlanner) newTooExpensiveError(filter store.LogFilter, cost uint64) error {
+	numBlocks := filter.BlockTo - filter.BlockFrom + 1
+
+	suggested := uint64(float64(numBlocks) * float64(p.conf.MaxCost) / float64(cost))
+	if suggested == 0 || suggested >= numBlocks {
+		return errLogQueryTooExpensive
+	}
+
+	blockRange := store.NewSuggestedBlockRange(filter.BlockFrom, filter.BlockFrom+suggested-1, 0)
+	return store.NewSuggestedFilterOversizeError(errLogQueryTooExpensive, blockRange)
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogQueryPlannerTooExpensiveError(t *testing.T) {
+	planner := &LogQueryPlanner{conf: logPlannerConfig{MaxCost: 1000}}
+	filter := store.LogFilter{BlockFrom: 100, BlockTo: 199}
+
+	// narrower range suggested proportionally
+	err := planner.newTooExpensiveError(filter, 4000)
+
+	var valErr *store.SuggestedFilterOversizedError[store.SuggestedBlockRange]
+	assert.ErrorAs(t, err, &valErr)
+	assert.ErrorIs(t, err, errLogQueryTooExpensive)
+	assert.Equal(t, uint64(100), valErr.SuggestedRange.From)
+	assert.Equal(t, uint64(124), valErr.SuggestedRange.To)
+
+	// no range to suggest
+	err = planner.newTooExpensiveError(filter, 1_000_000)
+	assert.Equal(t, errLogQueryTooExpensive, err)
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+)
+
+// PendingTxnConfig represents the configuration of pending transaction tracker.
+type PendingTxnConfig struct {
+	// Whether to enable pending transaction tracker.
+	Enabled bool
+	// Max number of senders to track.
+	MaxSenders int `default:"100000"`
+	// How long a broadcast transaction is remembered.
+	TTL time.Duration `default:"10m"`
+}
+
+// pendingTxn is the decoded raw transaction to track.
+type pendingTxn struct {
+	sender string
+	nonce  uint64
+	hash   string
+}
+
+// trackedTxn is the recently broadcast transaction.
+type trackedTxn struct {
+	hash   string
+	sentAt time.Time
+}
+
+// senderTxns is the recently broadcast transactions of sender keyed by nonce.
+type senderTxns struct {
+	mu   sync.Mutex
+	txns map[uint64]trackedTxn
+}
+
+// PendingTxnTracker remembers recently broadcast transactions per sender, so as to diagnose nonce
+// errors. Note, transactions are always forwarded even if broadcast recently, since they may be
+// dropped from the transaction pool and rebroadcast by the sender.
+type PendingTxnTracker struct {
+	ttl     time.Duration
+	senders *lru.Cache // sender => *senderTxns
+}
+
+func MustNewPendingTxnTrackerFromViper() *PendingTxnTracker {
+	var conf PendingTxnConfig
+	viper.MustUnmarshalKey("relay.pendingTxn", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	return NewPendingTxnTracker(conf)
+}
+
+func NewPendingTxnTracker(conf PendingTxnConfig) *PendingTxnTracker {
+	senders, _ := lru.New(max(conf.MaxSenders, 1))
+	return &PendingTxnTracker{ttl: conf.TTL, senders: senders}
+}
+
+// Track remembers the successfully broadcast transaction, which replaces the one of the same
+// sender and nonce if any, e.g., rebroadcast or replaced by higher gas price.
+func (t *PendingTxnTracker) Track(sender string, nonce uint64, hash string) {
+	v, ok := t.senders.Get(sender)
+	if !ok {
+		v = &senderTxns{txns: make(map[uint64]trackedTxn)}
+		if prev, found, _ := t.senders.PeekOrAdd(sender, v); found { // added concurrently
+			v = prev
+		}
+	}
+
+	st := v.(*senderTxns)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := time.Now()
+	for n, txn := range st.txns { // purge expired transactions
+		if now.Sub(txn.sentAt) > t.ttl {
+			delete(st.txns, n)
+		}
+	}
+
+	st.txns[nonce] = trackedTxn{hash: hash, sentAt: now}
+}
+
+// pending returns the recently broadcast transaction of the sender with specified nonce.
+func (t *PendingTxnTracker) pending(sender string, nonce uint64) (trackedTxn, bool) {
+	v, ok := t.senders.Get(sender)
+	if !ok {
+		return trackedTxn{}, false
+	}
+
+	st := v.(*senderTxns)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	txn, ok := st.txns[nonce]
+	if !ok || time.Since(txn.sentAt) > t.ttl {
+		return trackedTxn{}, false
+	}
+
+	return txn, true
+}
+
+// Diagnose enriches the upstream error of sending transaction with nonce diagnostics, eg.,
+// "nonce too high, gap at N", while keeping the error code and data unchanged.
+func (t *PendingTxnTracker) Diagnose(
+	sendErr error, sender string, nonce uint64, nextNonce func() (uint64, error),
+) error {
+	var jsonErr *rpc.JsonError
+	if !errors.As(sendErr, &jsonErr) {
+		return sendErr
+	}
+
+	next, err := nextNonce()
+	if err != nil {
+		return sendErr
+	}
+
+	txn, _ := t.pending(sender, nonce)
+
+	diag := diagnoseNonce(nonce, next, txn.hash)
+	if len(diag) == 0 {
+		return sendErr
+	}
+
+	return &rpc.JsonError{
+		Code:    jsonErr.Code,
+		Message: fmt.Sprintf("%v (%v)", diag, jsonErr.Message),
+		Data:    jsonErr.Data,
+	}
+}
+
+// diagnoseNonce returns the diagnostic message of transaction nonce against the next nonce
+// of sender, or empty string if nothing wrong with the nonce.
+func diagnoseNonce(nonce, nextNonce uint64, pendingHash string) string {
+	switch {
+	case nonce < nextNonce:
+		return fmt.Sprintf("nonce too low, next nonce %v", nextNonce)
+	case nonce > nextNonce:
+		return fmt.Sprintf("nonce too high, gap at %v", nextNonce)
+	case len(pendingHash) > 0:
+		return fmt.Sprintf("nonce %v already used by pending transaction %v", nonce, pendingHash)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnoseNonce(t *testing.T) {
+	assert.Equal(t, "nonce too low, next nonce 5", diagnoseNonce(3, 5, ""))
+	assert.Equal(t, "nonce too high, gap at 5", diagnoseNonce(7, 5, ""))
+	assert.Equal(t, "nonce 5 already used by pending transaction 0x01", diagnoseNonce(5, 5, "0x01"))
+	assert.Empty(t, diagnoseNonce(5, 5, ""))
+}
+
+func TestPendingTxnTrackerTrack(t *testing.T) {
+	tracker := NewPendingTxnTracker(PendingTxnConfig{MaxSenders: 10, TTL: 50 * time.Millisecond})
+
+	_, ok := tracker.pending("0xa", 1)
+	assert.False(t, ok)
+
+	// rebroadcast tracked once
+	tracker.Track("0xa", 1, "0x01")
+	tracker.Track("0xa", 1, "0x01")
+
+	txn, ok := tracker.pending("0xa", 1)
+	assert.True(t, ok)
+	assert.Equal(t, "0x01", txn.hash)
+
+	// replaced by another transaction of the same nonce
+	tracker.Track("0xa", 1, "0x02")
+	txn, _ = tracker.pending("0xa", 1)
+	assert.Equal(t, "0x02", txn.hash)
+
+	_, ok = tracker.pending("0xb", 1)
+	assert.False(t, ok)
+
+	time.Sleep(100 * time.Millisecond)
+	_, ok = tracker.pending("0xa", 1)
+	assert.False(t, ok)
+}
+
+func TestPendingTxnTrackerDiagnose(t *testing.T) {
+	tracker := NewPendingTxnTracker(PendingTxnConfig{MaxSenders: 10, TTL: time.Minute})
+	nextNonce := func() (uint64, error) { return 5, nil }
+
+	// non RPC error unchanged
+	sendErr := errors.New("connection refused")
+	assert.Equal(t, sendErr, tracker.Diagnose(sendErr, "0xa", 7, nextNonce))
+
+	err := tracker.Diagnose(&rpc.JsonError{Code: -32000, Message: "invalid nonce"}, "0xa", 7, nextNonce)
+	assert.Equal(t, &rpc.JsonError{Code: -32000, Message: "nonce too high, gap at 5 (invalid nonce)"}, err)
+}
@@ -0,0 +1,231 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/util/logging"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// idempotent read-only RPC methods without side effects, which are allowed to hedge
+	cfxHedgeableMethods = []string{
+		"cfx_getStatus", "cfx_epochNumber", "cfx_getBestBlockHash",
+		"cfx_getBlockByHash", "cfx_getBlockByEpochNumber", "cfx_getBlockByBlockNumber",
+		"cfx_getBlocksByEpoch", "cfx_getTransactionByHash", "cfx_getTransactionReceipt",
+		"cfx_getBalance", "cfx_getCode", "cfx_getStorageAt",
+	}
+	ethHedgeableMethods = []string{
+		"eth_chainId", "eth_blockNumber", "eth_getBlockByNumber", "eth_getBlockByHash",
+		"eth_getTransactionByHash", "eth_getTransactionReceipt",
+		"eth_getBalance", "eth_getCode", "eth_getStorageAt",
+	}
+
+	hedgeableMethods = newHedgeableMethods(cfxHedgeableMethods, ethHedgeableMethods)
+)
+
+func newHedgeableMethods(methodLists ...[]string) map[string]bool {
+	methods := make(map[string]bool)
+	for _, list := range methodLists {
+		for _, method := range list {
+			methods[method] = true
+		}
+	}
+
+	return methods
+}
+
+// hedgeConfig represents the configuration of hedged requests, which fires a second request to
+// another full node after some delay, and returns the first success.
+type hedgeConfig struct {
+	Enabled bool
+	// delay before firing the hedged request
+	Delay time.Duration `default:"200ms"`
+	// hedged RPC methods, which must be in the allowlist of idempotent read-only methods
+	Methods []string
+
+	methodSet map[string]bool
+}
+
+func mustNewHedgeConfigFromViper(key string, defaultMethods []string) *hedgeConfig {
+	var conf hedgeConfig
+	viper.MustUnmarshalKey(key, &conf)
+	conf.init(defaultMethods)
+
+	return &conf
+}
+
+// init indexes the hedged RPC methods, and skips those not allowed to hedge.
+func (conf *hedgeConfig) init(defaultMethods []string) {
+	if len(conf.Methods) == 0 {
+		conf.Methods = defaultMethods
+	}
+
+	conf.methodSet = make(map[string]bool)
+	for _, method := range conf.Methods {
+		if !hedgeableMethods[method] {
+			logrus.WithField("method", method).Warn("RPC method not allowed to hedge skipped")
+			continue
+		}
+
+		conf.methodSet[method] = true
+	}
+}
+
+func (conf *hedgeConfig) hedged(method string) bool {
+	return conf != nil && conf.Enabled && conf.methodSet[method]
+}
+
+// rawCaller calls RPC method on full node, e.g., the provider of node client.
+type rawCaller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// hedgeMiddleware hedges idempotent read-only RPC requests to reduce tail latency caused by slow
+// full node, which must be hooked after the client middleware.
+func hedgeMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		var conf *hedgeConfig
+		if state, ok := serverStateFromContext(ctx); ok {
+			conf = state.hedging
+		}
+
+		if !conf.hedged(msg.Method) {
+			return next(ctx, msg)
+		}
+
+		var getAltClient func(ctx context.Context) (rawCaller, error)
+
+		switch p := ctx.Value(ctxKeyClientProvider).(type) {
+		case *node.CfxClientProvider:
+			getAltClient = func(ctx context.Context) (rawCaller, error) {
+				primary := GetCfxClientFromContext(ctx)
+				client, err := p.GetAlternativeClientByAffinity(ctx, primary.GetNodeURL(), GetClientGroupFromContext(ctx))
+				if err != nil {
+					return nil, err
+				}
+
+				cfx, ok := client.(*sdk.Client)
+				if !ok {
+					return nil, errors.New("alternative client unsupported for hedging")
+				}
+
+				return cfx.Provider(), nil
+			}
+		case *node.EthClientProvider:
+			getAltClient = func(ctx context.Context) (rawCaller, error) {
+				primary := GetEthClientFromContext(ctx)
+				client, err := p.GetAlternativeClientByAffinity(ctx, primary.URL, GetClientGroupFromContext(ctx))
+				if err != nil {
+					return nil, err
+				}
+
+				return client.Client.Provider(), nil
+			}
+		default:
+			return next(ctx, msg)
+		}
+
+		return hedge(ctx, msg, next, conf.Delay, getAltClient)
+	}
+}
+
+// hedge fires the hedged request to an alternative full node if the primary one does not respond
+// within the delay, and returns the first success or the primary response if both failed.
+//
+// Note, the primary request goes through the RPC handler only once, while the hedged request is
+// called on the alternative full node directly, since the call chain of RPC server is not safe to
+// run concurrently for the same request.
+func hedge(
+	ctx context.Context,
+	msg *rpc.JsonRpcMessage,
+	next rpc.HandleCallMsgFunc,
+	delay time.Duration,
+	getAltClient func(ctx context.Context) (rawCaller, error),
+) *rpc.JsonRpcMessage {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // cancel the slower request
+
+	primaryCh := make(chan *rpc.JsonRpcMessage, 1)
+	go func() { primaryCh <- next(ctx, msg) }()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case resp := <-primaryCh:
+		return resp
+	case <-timer.C:
+	}
+
+	altClient, err := getAltClient(ctx)
+	if err != nil {
+		logging.FromContext(ctx).WithField("method", msg.Method).
+			WithError(err).
+			Debug("No alternative full node available for hedged request")
+		return <-primaryCh
+	}
+
+	metrics.Registry.RPC.HedgedRequests(msg.Method).Mark(1)
+
+	hedgedCh := make(chan *rpc.JsonRpcMessage, 1)
+	go func() { hedgedCh <- callRaw(ctx, altClient, msg) }()
+
+	// return the first success, otherwise the primary response
+	select {
+	case resp := <-primaryCh:
+		if resp == nil || resp.Error == nil {
+			metrics.Registry.RPC.HedgedWins(msg.Method).Mark(false)
+			return resp
+		}
+
+		// wait for the hedged one
+		if hedgedResp := <-hedgedCh; hedgedResp.Error == nil {
+			metrics.Registry.RPC.HedgedWins(msg.Method).Mark(true)
+			return hedgedResp
+		}
+
+		return resp
+	case resp := <-hedgedCh:
+		if resp.Error == nil {
+			metrics.Registry.RPC.HedgedWins(msg.Method).Mark(true)
+			return resp
+		}
+
+		return <-primaryCh
+	}
+}
+
+// callRaw calls the RPC method with raw params on full node, and returns the response message.
+func callRaw(ctx context.Context, client rawCaller, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+	var params []json.RawMessage
+	if len(msg.Params) > 0 {
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return msg.ErrorResponse(err)
+		}
+	}
+
+	args := make([]interface{}, len(params))
+	for i := range params {
+		args[i] = params[i]
+	}
+
+	var result json.RawMessage
+	if err := client.CallContext(ctx, &result, msg.Method, args...); err != nil {
+		return msg.ErrorResponse(err)
+	}
+
+	if len(result) == 0 {
+		result = json.RawMessage("null")
+	}
+
+	return &rpc.JsonRpcMessage{Version: msg.Version, ID: msg.ID, Result: result}
+}
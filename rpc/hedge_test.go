@@ -0,0 +1,114 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestHedgedHandler returns a handler which responds slowly on the primary full node, and
+// records the number of calls and cancellations.
+func newTestHedgedHandler(calls, canceled *int32) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		atomic.AddInt32(calls, 1)
+
+		select {
+		case <-time.After(200 * time.Millisecond):
+			return &rpc.JsonRpcMessage{Result: json.RawMessage(`"primary"`)}
+		case <-ctx.Done():
+			atomic.AddInt32(canceled, 1)
+			return msg.ErrorResponse(ctx.Err())
+		}
+	}
+}
+
+// testRawCaller is an alternative full node, which records the called method and params.
+type testRawCaller struct {
+	method string
+	args   []interface{}
+}
+
+func (c *testRawCaller) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	c.method, c.args = method, args
+	*result.(*json.RawMessage) = json.RawMessage(`"alt"`)
+	return nil
+}
+
+func TestHedgeCancelSlower(t *testing.T) {
+	var calls, canceled int32
+	next := newTestHedgedHandler(&calls, &canceled)
+	alt := &testRawCaller{}
+
+	msg := &rpc.JsonRpcMessage{
+		Version: "2.0", ID: json.RawMessage("1"), Method: "eth_getBalance", Params: json.RawMessage(`["0x1","latest"]`),
+	}
+	resp := hedge(context.Background(), msg, next, 10*time.Millisecond,
+		func(context.Context) (rawCaller, error) { return alt, nil },
+	)
+
+	assert.Equal(t, json.RawMessage(`"alt"`), resp.Result)
+	assert.Equal(t, json.RawMessage("1"), resp.ID)
+
+	// handler called only once, while hedged request is sent to alternative full node directly
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, "eth_getBalance", alt.method)
+	assert.Equal(t, []interface{}{json.RawMessage(`"0x1"`), json.RawMessage(`"latest"`)}, alt.args)
+
+	// slower primary request canceled once hedged request succeeded
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&canceled) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestHedgeNoAlternative(t *testing.T) {
+	var calls, canceled int32
+	next := newTestHedgedHandler(&calls, &canceled)
+
+	resp := hedge(context.Background(), &rpc.JsonRpcMessage{Method: "eth_blockNumber"}, next, 10*time.Millisecond,
+		func(context.Context) (rawCaller, error) { return nil, node.ErrClientUnavailable },
+	)
+
+	// wait for primary response if no alternative full node
+	assert.Equal(t, json.RawMessage(`"primary"`), resp.Result)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&canceled))
+}
+
+func TestHedgeAllowlist(t *testing.T) {
+	conf := &hedgeConfig{
+		Enabled: true,
+		Delay:   10 * time.Millisecond,
+		Methods: []string{
+			"cfx_getStatus", "cfx_sendRawTransaction", "cfx_getNextNonce", "cfx_getFilterChanges", "trace_block",
+		},
+	}
+	conf.init(cfxHedgeableMethods)
+
+	assert.True(t, conf.hedged("cfx_getStatus"))
+	assert.False(t, conf.hedged("cfx_sendRawTransaction"))
+	assert.False(t, conf.hedged("cfx_getNextNonce"))
+	assert.False(t, conf.hedged("cfx_getFilterChanges"))
+	assert.False(t, conf.hedged("trace_block"))
+
+	// defaults to all the allowed methods
+	conf = &hedgeConfig{Enabled: true}
+	conf.init(ethHedgeableMethods)
+	assert.True(t, conf.hedged("eth_getBlockByHash"))
+	assert.False(t, conf.hedged("eth_getTransactionCount"))
+
+	var calls, canceled int32
+	handler := hedgeMiddleware(newTestHedgedHandler(&calls, &canceled))
+	ctx := context.WithValue(context.Background(), ctxKeyClientProvider, &node.CfxClientProvider{})
+	ctx = context.WithValue(ctx, ctxKeyServerState, &serverState{hedging: conf})
+
+	// slow transaction broadcast is sent only once
+	resp := handler(ctx, &rpc.JsonRpcMessage{Method: "eth_sendRawTransaction"})
+	assert.Equal(t, json.RawMessage(`"primary"`), resp.Result)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,63 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+const (
+	// max number of internal transactions to return per request
+	maxInternalTxsPerRequest = 100
+)
+
+var (
+	errInternalTxsUnavailable = errors.New("internal transactions not indexed")
+)
+
+// InternalTxStore is implemented by store which indexes internal transactions during sync.
+type InternalTxStore interface {
+	InternalTxsByAddress(addr common.Address, cursor uint64, limit int) ([]mysql.InternalTransaction, error)
+	InternalTxsByBlock(blockNumber uint64) ([]mysql.InternalTransaction, error)
+}
+
+// internalTxAPI provides extension RPCs to query the internal value transfers via contract calls
+// or creations, which are indexed from traces during sync and invisible to normal transactions.
+type internalTxAPI struct {
+	store InternalTxStore // nil if not indexed
+}
+
+// GetByAddress returns the internal transactions from or to the specified address in descending
+// order before the cursor ID if any, where the ID of the last returned internal transaction could
+// be used as cursor to query the next page.
+func (api *internalTxAPI) GetByAddress(
+	ctx context.Context, addr common.Address, cursor, limit *hexutil.Uint64,
+) ([]mysql.InternalTransaction, error) {
+	if api.store == nil {
+		return nil, errInternalTxsUnavailable
+	}
+
+	var c uint64
+	if cursor != nil {
+		c = uint64(*cursor)
+	}
+
+	l := maxInternalTxsPerRequest
+	if limit != nil && *limit > 0 && *limit < maxInternalTxsPerRequest {
+		l = int(*limit)
+	}
+
+	return api.store.InternalTxsByAddress(addr, c, l)
+}
+
+// GetByBlock returns the internal transactions of the specified block.
+func (api *internalTxAPI) GetByBlock(ctx context.Context, blockNumber hexutil.Uint64) ([]mysql.InternalTransaction, error) {
+	if api.store == nil {
+		return nil, errInternalTxsUnavailable
+	}
+
+	return api.store.InternalTxsByBlock(uint64(blockNumber))
+}
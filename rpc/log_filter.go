@@ -129,7 +129,7 @@ func ValidateEthLogFilter(flag LogFilterType, filter *web3Types.FilterQuery) err
 		return ErrInvalidLogFilterBlockRange
 	}
 
-	if len(filter.Addresses) > store.MaxLogFilterAddrCount {
+	if len(filter.Addresses) > store.MaxLogFilterAddrCount() {
 		// num of filter address bounded
 		return ErrExceedLogFilterAddrLimit(len(filter.Addresses))
 	}
@@ -140,7 +140,7 @@ func ValidateEthLogFilter(flag LogFilterType, filter *web3Types.FilterQuery) err
 	}
 
 	for i := range filter.Topics {
-		if len(filter.Topics[i]) > store.MaxLogFilterTopicCount {
+		if len(filter.Topics[i]) > store.MaxLogFilterTopicCount() {
 			// num of filter topics per dimension bounded
 			return ErrExceedLogFilterTopicLimit(len(filter.Topics[i]))
 		}
@@ -183,7 +183,7 @@ func NormalizeLogFilter(cfx sdk.ClientOperator, flag LogFilterType, filter *type
 func ValidateLogFilter(flag LogFilterType, filter *types.LogFilter) error {
 	switch {
 	case flag&LogFilterTypeBlockHash != 0: // validate block hash log filter
-		if len(filter.BlockHashes) > store.MaxLogBlockHashesSize {
+		if len(filter.BlockHashes) > store.MaxLogBlockHashesSize() {
 			return ErrExceedLogFilterBlockHashLimit(len(filter.BlockHashes))
 		}
 	case flag&LogFilterTypeBlockRange != 0: // validate block range log filter
@@ -211,7 +211,7 @@ func ValidateLogFilter(flag LogFilterType, filter *types.LogFilter) error {
 		}
 	}
 
-	if len(filter.Address) > store.MaxLogFilterAddrCount {
+	if len(filter.Address) > store.MaxLogFilterAddrCount() {
 		// num of filter address bounded
 		return ErrExceedLogFilterAddrLimit(len(filter.Address))
 	}
@@ -222,7 +222,7 @@ func ValidateLogFilter(flag LogFilterType, filter *types.LogFilter) error {
 	}
 
 	for i := range filter.Topics {
-		if len(filter.Topics[i]) > store.MaxLogFilterTopicCount {
+		if len(filter.Topics[i]) > store.MaxLogFilterTopicCount() {
 			// num of filter topics per dimension bounded
 			return ErrExceedLogFilterTopicLimit(len(filter.Topics[i]))
 		}
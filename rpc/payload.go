@@ -0,0 +1,264 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcErrors "github.com/Conflux-Chain/confura/util/rpc/errors"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+)
+
+const (
+	invalidRequestErrorCode   = rpcErrors.CodeInvalidRequest
+	responseTooLargeErrorCode = rpcErrors.CodeResponseTooLarge
+)
+
+var (
+	errEmptyBatch = errors.New("empty batch")
+)
+
+// payloadConfig represents the configuration to limit and validate JSON-RPC payloads, which are
+// unlimited if zero.
+type payloadConfig struct {
+	Enabled bool
+	// max size in bytes of HTTP request body
+	MaxBodySize int64 `default:"5242880"`
+	// max number of batch items
+	MaxBatchSize int `default:"1000"`
+	// max nesting depth of params
+	MaxParamsDepth int `default:"32"`
+	// max size in bytes of result, beyond which the response is replaced with error
+	MaxResponseSize int
+	// whether to reject requests with malformed JSON-RPC envelope, e.g., unknown members, missing
+	// method or non-structured params
+	Strict bool `default:"true"`
+}
+
+// payloadMiddleware limits the size of HTTP request body and the number of batch items, and
+// validates the JSON-RPC envelope before requests reach the RPC server.
+func payloadMiddleware(conf payloadConfig) handlers.Middleware {
+	return func(next http.Handler) http.Handler {
+		if !conf.Enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			space, _ := handlers.GetNamespaceFromContext(r.Context())
+
+			reader := r.Body
+			if conf.MaxBodySize > 0 {
+				reader = http.MaxBytesReader(w, r.Body, conf.MaxBodySize)
+			}
+
+			body, err := io.ReadAll(reader)
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					metrics.Registry.RPC.PayloadRejected(space, "body_size").Mark(1)
+					writePayloadError(w, http.StatusRequestEntityTooLarge, errors.Errorf(
+						"request body too large, max %v bytes allowed", conf.MaxBodySize,
+					))
+				} else {
+					writePayloadError(w, http.StatusBadRequest, err)
+				}
+
+				return
+			}
+
+			if err := conf.validate(body); err != nil {
+				metrics.Registry.RPC.PayloadRejected(space, "envelope").Mark(1)
+				writePayloadError(w, http.StatusBadRequest, err)
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// validate validates the number of batch items, and the JSON-RPC envelope if strict.
+func (conf *payloadConfig) validate(body []byte) error {
+	if !isBatch(body) {
+		if conf.Strict {
+			return validateEnvelope(body)
+		}
+
+		return nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return errors.WithMessage(err, "invalid batch")
+	}
+
+	if len(items) == 0 {
+		return errEmptyBatch
+	}
+
+	if conf.MaxBatchSize > 0 && len(items) > conf.MaxBatchSize {
+		return errors.Errorf("batch too large, max %v items allowed", conf.MaxBatchSize)
+	}
+
+	if !conf.Strict {
+		return nil
+	}
+
+	for i := range items {
+		if err := validateEnvelope(items[i]); err != nil {
+			return errors.WithMessagef(err, "invalid batch item #%v", i)
+		}
+	}
+
+	return nil
+}
+
+// validateEnvelope validates the JSON-RPC 2.0 request object strictly.
+func validateEnvelope(data []byte) error {
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return errors.New("request must be a JSON object")
+	}
+
+	for k, v := range envelope {
+		switch k {
+		case "jsonrpc":
+			if string(bytes.TrimSpace(v)) != `"2.0"` {
+				return errors.New(`"jsonrpc" must be exactly "2.0"`)
+			}
+		case "method":
+			var method string
+			if json.Unmarshal(v, &method) != nil || len(method) == 0 {
+				return errors.New(`"method" must be a non-empty string`)
+			}
+		case "id":
+			if !isJsonType(v, '"', 'n') && !isJsonNumber(v) {
+				return errors.New(`"id" must be a string, number or null`)
+			}
+		case "params":
+			if !isJsonType(v, '[', '{') {
+				return errors.New(`"params" must be an array or object`)
+			}
+		default:
+			return errors.Errorf("unknown member %q", k)
+		}
+	}
+
+	if _, ok := envelope["jsonrpc"]; !ok {
+		return errors.New(`missing "jsonrpc"`)
+	}
+
+	if _, ok := envelope["method"]; !ok {
+		return errors.New(`missing "method"`)
+	}
+
+	return nil
+}
+
+// isJsonType checks if the JSON value starts with any of the specified chars.
+func isJsonType(v json.RawMessage, firstChars ...byte) bool {
+	v = bytes.TrimSpace(v)
+	return len(v) > 0 && bytes.IndexByte(firstChars, v[0]) >= 0
+}
+
+func isJsonNumber(v json.RawMessage) bool {
+	_, err := strconv.ParseFloat(string(bytes.TrimSpace(v)), 64)
+	return err == nil
+}
+
+// jsonDepth returns the max nesting depth of arrays and objects in JSON value.
+func jsonDepth(data []byte) int {
+	var depth, maxDepth int
+	var inString, escaped bool
+
+	for _, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '[', '{':
+			depth++
+			maxDepth = max(maxDepth, depth)
+		case ']', '}':
+			depth--
+		}
+	}
+
+	return maxDepth
+}
+
+// writePayloadError writes JSON-RPC error response of invalid request.
+func writePayloadError(w http.ResponseWriter, status int, err error) {
+	data, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      nil,
+		"error":   map[string]interface{}{"code": invalidRequestErrorCode, "message": err.Error()},
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// payloadCallMiddleware limits the nesting depth of params and the size of response for both HTTP
+// and websocket requests.
+func payloadCallMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		state, ok := serverStateFromContext(ctx)
+		if !ok || !state.payload.Enabled {
+			return next(ctx, msg)
+		}
+
+		conf := state.payload
+		space, _ := handlers.GetNamespaceFromContext(ctx)
+
+		if conf.MaxParamsDepth > 0 && jsonDepth(msg.Params) > conf.MaxParamsDepth {
+			metrics.Registry.RPC.PayloadRejected(space, "params_depth").Mark(1)
+			return msg.ErrorResponse(&rpc.JsonError{
+				Code:    invalidRequestErrorCode,
+				Message: fmt.Sprintf("params nested too deep, max depth %v allowed", conf.MaxParamsDepth),
+			})
+		}
+
+		resp := next(ctx, msg)
+
+		if conf.MaxResponseSize > 0 && resp.Error == nil && len(resp.Result) > conf.MaxResponseSize {
+			metrics.Registry.RPC.PayloadRejected(space, "response_size").Mark(1)
+			return msg.ErrorResponse(&rpc.JsonError{
+				Code: responseTooLargeErrorCode,
+				Message: fmt.Sprintf(
+					"response too large (%v bytes), max %v bytes allowed, please narrow down the query",
+					len(resp.Result), conf.MaxResponseSize,
+				),
+			})
+		}
+
+		return resp
+	}
+}
@@ -0,0 +1,48 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayloadValidateEnvelope(t *testing.T) {
+	conf := payloadConfig{MaxBatchSize: 2, Strict: true}
+
+	assert.NoError(t, conf.validate([]byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`)))
+	assert.NoError(t, conf.validate([]byte(`{"jsonrpc":"2.0","id":"a","method":"eth_call","params":[{}]}`)))
+	assert.NoError(t, conf.validate([]byte(`{"jsonrpc":"2.0","method":"eth_call","params":{}}`)))
+
+	for _, body := range []string{
+		`[]`,
+		`"eth_call"`,
+		`{"id":1,"method":"eth_blockNumber"}`,
+		`{"jsonrpc":"1.0","id":1,"method":"eth_blockNumber"}`,
+		`{"jsonrpc":"2.0","id":1}`,
+		`{"jsonrpc":"2.0","id":1,"method":""}`,
+		`{"jsonrpc":"2.0","id":true,"method":"eth_blockNumber"}`,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_call","params":"0x1"}`,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_call","extra":1}`,
+		`[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},{"id":2}]`,
+	} {
+		assert.Error(t, conf.validate([]byte(body)), body)
+	}
+
+	// batch size
+	item := `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`
+	assert.NoError(t, conf.validate([]byte("["+item+","+item+"]")))
+	assert.Error(t, conf.validate([]byte("["+item+","+item+","+item+"]")))
+
+	// envelope not validated if not strict
+	conf.Strict = false
+	assert.NoError(t, conf.validate([]byte(`{"id":1,"method":"eth_call","extra":1}`)))
+}
+
+func TestJsonDepth(t *testing.T) {
+	assert.Equal(t, 0, jsonDepth([]byte(`"0x1"`)))
+	assert.Equal(t, 1, jsonDepth([]byte(`["0x1", "latest"]`)))
+	assert.Equal(t, 4, jsonDepth([]byte(`[{"topics":[["0x1","0x2"]]}]`)))
+
+	// brackets within strings ignored
+	assert.Equal(t, 1, jsonDepth([]byte(`["[[{\"[", "]]"]`)))
+}
@@ -0,0 +1,104 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/util"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/web3go"
+)
+
+// readYourWritesConfig represents the configuration of read-your-writes consistency for recently
+// sent transactions.
+type readYourWritesConfig struct {
+	Enabled bool
+	// max number of recently sent transactions to remember
+	MaxTxns int `default:"100000"`
+	// grace period to route reads of sent transaction to the accepting full node
+	GracePeriod time.Duration `default:"1m"`
+}
+
+// sentTxnRouter remembers the full nodes that accepted the recently sent raw transactions, so that
+// the transaction and receipt queries are routed to the accepting full node for a grace period.
+// Otherwise, clients may see "not found" right after a successful send due to node routing.
+type sentTxnRouter struct {
+	txns *util.ExpirableLruCache // lowercase txn hash => full node client not bound to request context
+}
+
+// mustNewSentTxnRouterFromViper creates sent transaction router, or nil if not enabled.
+func mustNewSentTxnRouterFromViper(key string) *sentTxnRouter {
+	var conf readYourWritesConfig
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	return newSentTxnRouter(conf)
+}
+
+func newSentTxnRouter(conf readYourWritesConfig) *sentTxnRouter {
+	return &sentTxnRouter{
+		txns: util.NewExpirableLruCache(conf.MaxTxns, conf.GracePeriod),
+	}
+}
+
+// track remembers the full node client of request context, which accepted the sent transaction.
+func (r *sentTxnRouter) track(ctx context.Context, txHash string) {
+	if r == nil {
+		return
+	}
+
+	if client := ctx.Value(ctxKeyClient); client != nil {
+		r.txns.Add(strings.ToLower(txHash), client)
+	}
+}
+
+// get returns the full node client that accepted the transaction within grace period if any.
+func (r *sentTxnRouter) get(txHash string) (interface{}, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	return r.txns.Get(strings.ToLower(txHash))
+}
+
+// cfxClient returns the core space client that accepted the transaction, which is bound to the
+// request context.
+func (r *sentTxnRouter) cfxClient(ctx context.Context, txHash string) (sdk.ClientOperator, bool) {
+	v, ok := r.get(txHash)
+	if !ok {
+		return nil, false
+	}
+
+	client, ok := v.(sdk.ClientOperator)
+	if !ok {
+		return nil, false
+	}
+
+	if cfx, ok := client.(*sdk.Client); ok {
+		return cfx.WithContext(ctx), true
+	}
+
+	return client, true
+}
+
+// ethClient returns the evm space client that accepted the transaction, which is bound to the
+// request context.
+func (r *sentTxnRouter) ethClient(ctx context.Context, txHash string) (*web3go.Client, bool) {
+	v, ok := r.get(txHash)
+	if !ok {
+		return nil, false
+	}
+
+	w3c, ok := v.(*node.Web3goClient)
+	if !ok {
+		return nil, false
+	}
+
+	return w3c.Client.WithContext(ctx), true
+}
@@ -0,0 +1,35 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSentTxnRouter(t *testing.T) {
+	// disabled
+	var router *sentTxnRouter
+	router.track(context.Background(), "0xabc")
+	_, ok := router.get("0xabc")
+	assert.False(t, ok)
+
+	router = newSentTxnRouter(readYourWritesConfig{MaxTxns: 10, GracePeriod: time.Minute})
+
+	// no client bound to request context
+	router.track(context.Background(), "0xabc")
+	_, ok = router.get("0xabc")
+	assert.False(t, ok)
+
+	ctx := context.WithValue(context.Background(), ctxKeyClient, "node1")
+	router.track(ctx, "0xABC")
+
+	client, ok := router.get("0xabc")
+	assert.True(t, ok)
+	assert.Equal(t, "node1", client)
+
+	// unexpected client type
+	_, ok = router.ethClient(context.Background(), "0xabc")
+	assert.False(t, ok)
+}
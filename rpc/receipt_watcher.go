@@ -0,0 +1,152 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/util/logging"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// interval to poll transaction receipt for watchers
+	receiptWatchInterval = time.Second
+	// max duration to watch transaction receipt
+	receiptWatchTimeout = 10 * time.Minute
+)
+
+// receiptPoller polls the transaction receipt, and returns nil if not executed or confirmed yet.
+type receiptPoller func() (interface{}, error)
+
+// receiptWatchError is notified instead of receipt if watcher terminated (eg., timeout), after
+// which no more notification will be sent, so that clients won't wait forever and could
+// unsubscribe then.
+type receiptWatchError struct {
+	Error string `json:"error"`
+}
+
+// watchReceipt creates a subscription which notifies only once when the transaction receipt
+// polled, so that clients could get rid of polling loops. If receipt not polled in time, an error
+// is notified instead.
+func watchReceipt(ctx context.Context, topic string, poll receiptPoller) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	release, err := rpcutil.AcquireWsSubscription(ctx)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	logger := logging.FromContext(ctx).WithFields(logrus.Fields{"rpcSubID": rpcSub.ID, "topic": topic})
+
+	go func() {
+		defer release()
+
+		ticker := time.NewTicker(receiptWatchInterval)
+		defer ticker.Stop()
+
+		timeout := time.NewTimer(receiptWatchTimeout)
+		defer timeout.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				receipt, err := poll()
+				if err != nil {
+					logger.WithError(err).Debug("Failed to poll receipt for watcher")
+					continue
+				}
+
+				if receipt != nil {
+					notifier.Notify(rpcSub.ID, receipt)
+					return
+				}
+
+			case <-timeout.C:
+				logger.Debug("Receipt watcher timeout")
+				notifier.Notify(rpcSub.ID, &receiptWatchError{
+					Error: fmt.Sprintf("receipt not available within %v", receiptWatchTimeout),
+				})
+				return
+
+			case err := <-rpcSub.Err(): // client unsubscribed or connection closed
+				logger.WithError(err).Debug("Receipt watcher subscription error")
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// TransactionReceipt creates a subscription that fires once the transaction executed, or confirmed
+// by the specified number of blocks if provided.
+func (api *ethAPI) TransactionReceipt(
+	ctx context.Context, txHash common.Hash, confirmations *hexutil.Uint64,
+) (*rpc.Subscription, error) {
+	eth, err := api.provider.GetClientByAffinity(ctx, node.GroupEthHttp)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
+	return watchReceipt(ctx, "eth_transactionReceipt", func() (interface{}, error) {
+		receipt, err := eth.Eth.TransactionReceipt(txHash)
+		if err != nil || receipt == nil {
+			return nil, err
+		}
+
+		if confirmations != nil && *confirmations > 0 {
+			latest, err := eth.Eth.BlockNumber()
+			if err != nil {
+				return nil, err
+			}
+
+			if latest.Uint64() < receipt.BlockNumber+uint64(*confirmations) {
+				return nil, nil
+			}
+		}
+
+		return receipt, nil
+	})
+}
+
+// TransactionReceipt creates a subscription that fires once the transaction executed, or the
+// executed epoch confirmed by the specified epoch tag (eg., `latest_confirmed`) if provided.
+func (api *cfxAPI) TransactionReceipt(
+	ctx context.Context, txHash types.Hash, confirmedBy *types.Epoch,
+) (*rpc.Subscription, error) {
+	cfx, err := api.provider.GetClientByAffinity(ctx, node.GroupCfxHttp)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
+	return watchReceipt(ctx, "cfx_transactionReceipt", func() (interface{}, error) {
+		receipt, err := cfx.GetTransactionReceipt(txHash)
+		if err != nil || receipt == nil || receipt.EpochNumber == nil {
+			return nil, err
+		}
+
+		if confirmedBy != nil {
+			epoch, err := cfx.GetEpochNumber(confirmedBy)
+			if err != nil {
+				return nil, err
+			}
+
+			if epoch.ToInt().Uint64() < uint64(*receipt.EpochNumber) {
+				return nil, nil
+			}
+		}
+
+		return receipt, nil
+	})
+}
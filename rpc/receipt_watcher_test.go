@@ -0,0 +1,91 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+// testReceiptService watches receipt which is available after the specified number of polls.
+type testReceiptService struct {
+	polls     int32
+	available int32
+}
+
+func (s *testReceiptService) Receipt(ctx context.Context) (*rpc.Subscription, error) {
+	return watchReceipt(ctx, "test_receipt", func() (interface{}, error) {
+		if atomic.AddInt32(&s.polls, 1) < s.available {
+			return nil, nil
+		}
+
+		return map[string]string{"status": "0x0"}, nil
+	})
+}
+
+func subscribeTestReceipt(t *testing.T, service *testReceiptService) (chan json.RawMessage, func()) {
+	srv := rpc.NewServer()
+	assert.NoError(t, srv.RegisterName("test", service))
+
+	client := rpc.DialInProc(srv)
+
+	ch := make(chan json.RawMessage, 2)
+	sub, err := client.Subscribe(context.Background(), "test", ch, "receipt")
+	assert.NoError(t, err)
+
+	return ch, func() {
+		sub.Unsubscribe()
+		client.Close()
+	}
+}
+
+func setTestReceiptWatchTiming(t *testing.T, interval, timeout time.Duration) {
+	originInterval, originTimeout := receiptWatchInterval, receiptWatchTimeout
+	receiptWatchInterval, receiptWatchTimeout = interval, timeout
+
+	t.Cleanup(func() {
+		receiptWatchInterval, receiptWatchTimeout = originInterval, originTimeout
+	})
+}
+
+func TestWatchReceipt(t *testing.T) {
+	setTestReceiptWatchTiming(t, 10*time.Millisecond, time.Minute)
+
+	ch, closeFn := subscribeTestReceipt(t, &testReceiptService{available: 3})
+	defer closeFn()
+
+	select {
+	case data := <-ch:
+		assert.JSONEq(t, `{"status":"0x0"}`, string(data))
+	case <-time.After(time.Second):
+		assert.Fail(t, "receipt not notified")
+	}
+
+	// notified only once
+	select {
+	case data := <-ch:
+		assert.Fail(t, "unexpected notification", string(data))
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWatchReceiptTimeout(t *testing.T) {
+	setTestReceiptWatchTiming(t, 10*time.Millisecond, 50*time.Millisecond)
+
+	ch, closeFn := subscribeTestReceipt(t, &testReceiptService{available: 1000})
+	defer closeFn()
+
+	// error notified once timeout
+	select {
+	case data := <-ch:
+		var res receiptWatchError
+		assert.NoError(t, json.Unmarshal(data, &res))
+		assert.NotEmpty(t, res.Error)
+	case <-time.After(time.Second):
+		assert.Fail(t, "timeout not notified")
+	}
+}
@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+const (
+	// max number of reorg events to return per request
+	maxReorgEventsPerRequest = 100
+)
+
+var (
+	errReorgEventsUnavailable = errors.New("reorg events not available without database")
+)
+
+// ReorgEventStore is implemented by store which records the reorg events detected during sync.
+type ReorgEventStore interface {
+	ReorgEvents(epochFrom, epochTo uint64, limit int) ([]mysql.ReorgEvent, error)
+}
+
+// reorgAPI provides extension RPCs to query the pivot chain switches (or chain reorgs for evm space)
+// detected during sync, so that users could check if any reorg affected the range of interest.
+type reorgAPI struct {
+	store ReorgEventStore // nil if database not available
+}
+
+// GetEvents returns the latest reorg events which reverted any epoch (or block for evm space)
+// within the specified range, in descending order of detection time.
+func (api *reorgAPI) GetEvents(ctx context.Context, from, to hexutil.Uint64) ([]mysql.ReorgEvent, error) {
+	if api.store == nil {
+		return nil, errReorgEventsUnavailable
+	}
+
+	return api.store.ReorgEvents(uint64(from), uint64(to), maxReorgEventsPerRequest)
+}
@@ -0,0 +1,359 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/redis"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/logging"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// cacheRule defines how to check if RPC response is immutable.
+type cacheRule struct {
+	// result field of the block (or epoch) number, if any, response is immutable only if finalized
+	numberField string
+	// param index of the block (or epoch) number, if any, response is immutable only if finalized
+	numberParam int
+}
+
+var (
+	alwaysImmutable = cacheRule{numberParam: -1}
+
+	// immutable RPC responses cached by default
+	cfxCacheRules = map[string]cacheRule{
+		"cfx_getBlockByHash":        {numberField: "epochNumber", numberParam: -1},
+		"cfx_getTransactionReceipt": {numberField: "epochNumber", numberParam: -1},
+		"cfx_getCode":               {numberParam: 1},
+	}
+	ethCacheRules = map[string]cacheRule{
+		"eth_chainId":               alwaysImmutable,
+		"net_version":               alwaysImmutable,
+		"eth_getBlockByHash":        {numberField: "number", numberParam: -1},
+		"eth_getTransactionByHash":  {numberField: "blockNumber", numberParam: -1},
+		"eth_getTransactionReceipt": {numberField: "blockNumber", numberParam: -1},
+		"eth_getCode":               {numberParam: 1},
+	}
+)
+
+// responseCacheConfig represents the configuration to cache immutable RPC responses.
+type responseCacheConfig struct {
+	Enabled bool
+	// max number of responses cached in memory
+	Size int `default:"10000"`
+	// time-to-live of cached responses
+	TTL time.Duration `default:"1h"`
+	// optional Redis as the second level cache shared by multiple instances
+	RedisUrl string
+	// expiration of the cached finalized block (or epoch) number
+	FinalizedExpiration time.Duration `default:"1s"`
+}
+
+// mustNewResponseCacheFromViper creates the response cache if enabled, otherwise returns nil.
+func mustNewResponseCacheFromViper(
+	key, space string, rules map[string]cacheRule, upstream cacheUpstream,
+) *responseCache {
+	var conf responseCacheConfig
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	return newResponseCache(space, conf, rules, upstream)
+}
+
+// cacheUpstream queries the full node of RPC request context to check immutability.
+type cacheUpstream interface {
+	// nodeName returns the name of full node
+	nodeName(ctx context.Context) string
+	// chainId returns the chain ID of full node
+	chainId(ctx context.Context) (uint64, error)
+	// finalizedNumber returns the finalized block (or epoch) number of full node
+	finalizedNumber(ctx context.Context) (uint64, error)
+}
+
+// finalizedNumber is the cached finalized block (or epoch) number.
+type finalizedNumber struct {
+	number    uint64
+	updatedAt time.Time
+}
+
+// responseCache caches immutable RPC responses keyed by chain ID, method and params, with
+// in-memory LRU and optional Redis as the second level cache.
+type responseCache struct {
+	space    string
+	conf     responseCacheConfig
+	rules    map[string]cacheRule
+	lru      *util.ExpirableLruCache // cache key => json.RawMessage
+	client   *goredis.Client
+	upstream cacheUpstream
+
+	// chain ID of full nodes keyed by node name, so that responses of different networks won't
+	// collide in the shared cache
+	chainIds sync.Map
+
+	// cached finalized block (or epoch) number keyed by chain ID
+	mu        sync.Mutex
+	finalized map[uint64]finalizedNumber
+}
+
+func newResponseCache(
+	space string,
+	conf responseCacheConfig,
+	rules map[string]cacheRule,
+	upstream cacheUpstream,
+) *responseCache {
+	cache := &responseCache{
+		space:     space,
+		conf:      conf,
+		rules:     rules,
+		lru:       util.NewExpirableLruCache(conf.Size, conf.TTL),
+		upstream:  upstream,
+		finalized: make(map[uint64]finalizedNumber),
+	}
+
+	if len(conf.RedisUrl) > 0 {
+		cache.client = redis.MustNewRedisClient(conf.RedisUrl)
+	}
+
+	return cache
+}
+
+// responseCacheMiddleware serves immutable RPC responses from cache, which must be hooked after
+// the client middleware.
+func responseCacheMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		state, ok := serverStateFromContext(ctx)
+		if !ok || state.respCache == nil {
+			return next(ctx, msg)
+		}
+
+		cache := state.respCache
+
+		rule, ok := cache.rules[msg.Method]
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		chainId, err := cache.chainId(ctx)
+		if err != nil {
+			logging.FromContext(ctx).WithError(err).Debug("Failed to get chain ID for RPC response cache")
+			return next(ctx, msg)
+		}
+
+		key := cache.key(chainId, msg)
+		if result, ok := cache.get(ctx, key); ok {
+			metrics.Registry.RPC.ResponseCacheHit(cache.space, msg.Method).Mark(true)
+			trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("responseCache.hit", true))
+			return &rpc.JsonRpcMessage{Version: msg.Version, ID: msg.ID, Result: result}
+		}
+
+		metrics.Registry.RPC.ResponseCacheHit(cache.space, msg.Method).Mark(false)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("responseCache.hit", false))
+
+		resp := next(ctx, msg)
+		if resp != nil && resp.Error == nil && cache.immutable(ctx, chainId, rule, msg, resp) {
+			cache.put(ctx, key, resp.Result)
+		}
+
+		return resp
+	}
+}
+
+func (c *responseCache) key(chainId uint64, msg *rpc.JsonRpcMessage) string {
+	var params bytes.Buffer
+	if err := json.Compact(&params, msg.Params); err != nil {
+		params.Write(msg.Params)
+	}
+
+	return "rpc:cache:" + c.space + ":" + strconv.FormatUint(chainId, 10) + ":" + msg.Method + ":" + params.String()
+}
+
+// chainId returns the chain ID of full node, which is cached since never changed.
+func (c *responseCache) chainId(ctx context.Context) (uint64, error) {
+	nodeName := c.upstream.nodeName(ctx)
+	if v, ok := c.chainIds.Load(nodeName); ok {
+		return v.(uint64), nil
+	}
+
+	chainId, err := c.upstream.chainId(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	c.chainIds.Store(nodeName, chainId)
+
+	return chainId, nil
+}
+
+func (c *responseCache) get(ctx context.Context, key string) (json.RawMessage, bool) {
+	if v, ok := c.lru.Get(key); ok {
+		return v.(json.RawMessage), true
+	}
+
+	if c.client == nil {
+		return nil, false
+	}
+
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if err != goredis.Nil {
+			logging.FromContext(ctx).WithError(err).Debug("Failed to get RPC response from redis cache")
+		}
+
+		return nil, false
+	}
+
+	c.lru.Add(key, json.RawMessage(data))
+
+	return data, true
+}
+
+func (c *responseCache) put(ctx context.Context, key string, result json.RawMessage) {
+	c.lru.Add(key, result)
+
+	if c.client == nil {
+		return
+	}
+
+	if err := c.client.Set(ctx, key, []byte(result), c.conf.TTL).Err(); err != nil {
+		logging.FromContext(ctx).WithError(err).Debug("Failed to set RPC response into redis cache")
+	}
+}
+
+// immutable checks if the RPC response is immutable by rule.
+func (c *responseCache) immutable(
+	ctx context.Context, chainId uint64, rule cacheRule, msg *rpc.JsonRpcMessage, resp *rpc.JsonRpcMessage,
+) bool {
+	if len(resp.Result) == 0 || string(resp.Result) == "null" {
+		return false
+	}
+
+	var numberVal *hexutil.Uint64
+
+	switch {
+	case len(rule.numberField) > 0:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(resp.Result, &fields); err != nil {
+			return false
+		}
+
+		if err := json.Unmarshal(fields[rule.numberField], &numberVal); err != nil {
+			return false
+		}
+	case rule.numberParam >= 0:
+		var params []json.RawMessage
+		if err := json.Unmarshal(msg.Params, &params); err != nil || len(params) <= rule.numberParam {
+			return false
+		}
+
+		// block (or epoch) tags or hashes are not regarded as immutable
+		if err := json.Unmarshal(params[rule.numberParam], &numberVal); err != nil {
+			return false
+		}
+	default:
+		return true
+	}
+
+	if numberVal == nil { // eg., pending transaction
+		return false
+	}
+
+	finalized, err := c.finalizedNumber(ctx, chainId)
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Debug("Failed to get finalized number for RPC response cache")
+		return false
+	}
+
+	return uint64(*numberVal) <= finalized
+}
+
+// finalizedNumber returns the finalized block (or epoch) number, which is cached for a while.
+// Note, the lock is not held when querying full node, so as not to block others.
+func (c *responseCache) finalizedNumber(ctx context.Context, chainId uint64) (uint64, error) {
+	c.mu.Lock()
+	cached, ok := c.finalized[chainId]
+	c.mu.Unlock()
+
+	if ok && time.Since(cached.updatedAt) < c.conf.FinalizedExpiration {
+		return cached.number, nil
+	}
+
+	number, err := c.upstream.finalizedNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	c.finalized[chainId] = finalizedNumber{number: number, updatedAt: time.Now()}
+	c.mu.Unlock()
+
+	return number, nil
+}
+
+type cfxCacheUpstream struct{}
+
+func (cfxCacheUpstream) nodeName(ctx context.Context) string {
+	return rpcutil.Url2NodeName(GetCfxClientFromContext(ctx).GetNodeURL())
+}
+
+func (cfxCacheUpstream) chainId(ctx context.Context) (uint64, error) {
+	status, err := GetCfxClientFromContext(ctx).GetStatus()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(status.ChainID), nil
+}
+
+func (cfxCacheUpstream) finalizedNumber(ctx context.Context) (uint64, error) {
+	epoch, err := GetCfxClientFromContext(ctx).GetEpochNumber(types.EpochLatestFinalized)
+	if err != nil {
+		return 0, err
+	}
+
+	return epoch.ToInt().Uint64(), nil
+}
+
+type ethCacheUpstream struct{}
+
+func (ethCacheUpstream) nodeName(ctx context.Context) string {
+	return rpcutil.Url2NodeName(GetEthClientFromContext(ctx).URL)
+}
+
+func (ethCacheUpstream) chainId(ctx context.Context) (uint64, error) {
+	chainId, err := GetEthClientFromContext(ctx).Eth.ChainId()
+	if err != nil {
+		return 0, err
+	}
+
+	if chainId == nil {
+		return 0, errors.New("chain ID unavailable")
+	}
+
+	return *chainId, nil
+}
+
+func (ethCacheUpstream) finalizedNumber(ctx context.Context) (uint64, error) {
+	var block struct {
+		Number hexutil.Uint64 `json:"number"`
+	}
+
+	err := GetEthClientFromContext(ctx).Provider().CallContext(ctx, &block, "eth_getBlockByNumber", "finalized", false)
+	return uint64(block.Number), err
+}
@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+// testCacheUpstream is a full node of the specified chain ID, which is keyed by node name in context.
+type testCacheUpstream struct {
+	chainIds  map[string]uint64
+	finalized map[string]uint64
+}
+
+func (u *testCacheUpstream) nodeName(ctx context.Context) string {
+	return ctx.Value(ctxKeyClient).(string)
+}
+
+func (u *testCacheUpstream) chainId(ctx context.Context) (uint64, error) {
+	return u.chainIds[u.nodeName(ctx)], nil
+}
+
+func (u *testCacheUpstream) finalizedNumber(ctx context.Context) (uint64, error) {
+	return u.finalized[u.nodeName(ctx)], nil
+}
+
+func TestResponseCacheImmutable(t *testing.T) {
+	upstream := &testCacheUpstream{
+		chainIds:  map[string]uint64{"node0": 1030},
+		finalized: map[string]uint64{"node0": 100},
+	}
+	cache := newResponseCache("eth", responseCacheConfig{Size: 10}, ethCacheRules, upstream)
+	ctx := context.WithValue(context.Background(), ctxKeyClient, "node0")
+
+	immutable := func(method, params, result string) bool {
+		msg := &rpc.JsonRpcMessage{Method: method, Params: json.RawMessage(params)}
+		resp := &rpc.JsonRpcMessage{Result: json.RawMessage(result)}
+		return cache.immutable(ctx, 1030, ethCacheRules[method], msg, resp)
+	}
+
+	assert.True(t, immutable("eth_chainId", `[]`, `"0x406"`))
+
+	// by result field
+	assert.True(t, immutable("eth_getTransactionReceipt", `["0x1"]`, `{"blockNumber":"0x64"}`))
+	assert.False(t, immutable("eth_getTransactionReceipt", `["0x1"]`, `{"blockNumber":"0x65"}`))
+	assert.False(t, immutable("eth_getTransactionByHash", `["0x1"]`, `{"blockNumber":null}`))
+	assert.False(t, immutable("eth_getTransactionReceipt", `["0x1"]`, `null`))
+
+	// by param
+	assert.True(t, immutable("eth_getCode", `["0xabc", "0x10"]`, `"0x"`))
+	assert.False(t, immutable("eth_getCode", `["0xabc", "latest"]`, `"0x"`))
+	assert.False(t, immutable("eth_getCode", `["0xabc"]`, `"0x"`))
+}
+
+func TestResponseCacheKeyedByChain(t *testing.T) {
+	upstream := &testCacheUpstream{
+		chainIds:  map[string]uint64{"mainnet": 1030, "testnet": 71},
+		finalized: map[string]uint64{"mainnet": 100, "testnet": 10},
+	}
+	cache := newResponseCache("eth", responseCacheConfig{Size: 10}, ethCacheRules, upstream)
+
+	mainnetCtx := context.WithValue(context.Background(), ctxKeyClient, "mainnet")
+	testnetCtx := context.WithValue(context.Background(), ctxKeyClient, "testnet")
+
+	mainnetChainId, err := cache.chainId(mainnetCtx)
+	assert.NoError(t, err)
+	testnetChainId, err := cache.chainId(testnetCtx)
+	assert.NoError(t, err)
+
+	// same request of different networks not collided
+	msg := &rpc.JsonRpcMessage{Method: "eth_chainId", Params: json.RawMessage(`[]`)}
+	assert.NotEqual(t, cache.key(mainnetChainId, msg), cache.key(testnetChainId, msg))
+
+	// finalized number cached per network
+	mainnetFinalized, err := cache.finalizedNumber(mainnetCtx, mainnetChainId)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), mainnetFinalized)
+
+	testnetFinalized, err := cache.finalizedNumber(testnetCtx, testnetChainId)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), testnetFinalized)
+}
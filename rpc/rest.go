@@ -0,0 +1,157 @@
+package rpc
+
+import (
+	"net/http"
+
+	"github.com/Conflux-Chain/confura/rpc/rest"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+)
+
+var (
+	hashParam = rest.Param{Name: "hash", In: rest.InPath, Type: rest.TypeString, Description: "Hash in hex format"}
+	addrParam = rest.Param{Name: "address", In: rest.InPath, Type: rest.TypeString, Description: "Account address"}
+	fullParam = rest.Param{
+		Name: "full", In: rest.InQuery, Type: rest.TypeBoolean, Default: "false",
+		Description: "Whether to return full transactions or only hashes",
+	}
+	topicsParam = rest.Param{
+		Name: "topics", In: rest.InQuery, Type: rest.TypeString,
+		Description: "Comma separated event signatures to match the first topic",
+	}
+
+	// core space REST routes
+	cfxRestRoutes = []rest.Route{
+		{
+			Path: "/blocks/{hash}", RpcMethod: "cfx_getBlockByHash", Summary: "Get block by hash",
+			Params:      []rest.Param{hashParam, fullParam},
+			BuildParams: buildBlockByHashParams,
+		},
+		{
+			Path: "/txs/{hash}", RpcMethod: "cfx_getTransactionByHash", Summary: "Get transaction by hash",
+			Params:      []rest.Param{hashParam},
+			BuildParams: buildHashParams,
+		},
+		{
+			Path: "/txs/{hash}/receipt", RpcMethod: "cfx_getTransactionReceipt", Summary: "Get transaction receipt",
+			Params:      []rest.Param{hashParam},
+			BuildParams: buildHashParams,
+		},
+		{
+			Path: "/accounts/{address}/balance", RpcMethod: "cfx_getBalance", Summary: "Get account balance",
+			Params: []rest.Param{addrParam, {
+				Name: "epoch", In: rest.InQuery, Type: rest.TypeString, Default: "latest_state",
+				Description: "Epoch number in hex format or epoch tag",
+			}},
+			BuildParams: func(vals rest.Values) ([]interface{}, error) {
+				return []interface{}{vals.String("address"), vals.String("epoch")}, nil
+			},
+		},
+		{
+			Path: "/accounts/{address}/logs", RpcMethod: "cfx_getLogs", Summary: "Get event logs of contract",
+			Params: []rest.Param{addrParam, {
+				Name: "fromEpoch", In: rest.InQuery, Type: rest.TypeString, Default: "latest_checkpoint",
+				Description: "Start epoch number in hex format or epoch tag",
+			}, {
+				Name: "toEpoch", In: rest.InQuery, Type: rest.TypeString, Default: "latest_state",
+				Description: "End epoch number in hex format or epoch tag",
+			}, topicsParam},
+			BuildParams: func(vals rest.Values) ([]interface{}, error) {
+				filter := map[string]interface{}{
+					"address":   []string{vals.String("address")},
+					"fromEpoch": vals.String("fromEpoch"),
+					"toEpoch":   vals.String("toEpoch"),
+				}
+
+				if topics := vals.Strings("topics"); len(topics) > 0 {
+					filter["topics"] = []interface{}{topics}
+				}
+
+				return []interface{}{filter}, nil
+			},
+		},
+	}
+
+	// EVM space REST routes
+	ethRestRoutes = []rest.Route{
+		{
+			Path: "/blocks/{hash}", RpcMethod: "eth_getBlockByHash", Summary: "Get block by hash",
+			Params:      []rest.Param{hashParam, fullParam},
+			BuildParams: buildBlockByHashParams,
+		},
+		{
+			Path: "/txs/{hash}", RpcMethod: "eth_getTransactionByHash", Summary: "Get transaction by hash",
+			Params:      []rest.Param{hashParam},
+			BuildParams: buildHashParams,
+		},
+		{
+			Path: "/txs/{hash}/receipt", RpcMethod: "eth_getTransactionReceipt", Summary: "Get transaction receipt",
+			Params:      []rest.Param{hashParam},
+			BuildParams: buildHashParams,
+		},
+		{
+			Path: "/accounts/{address}/balance", RpcMethod: "eth_getBalance", Summary: "Get account balance",
+			Params: []rest.Param{addrParam, {
+				Name: "block", In: rest.InQuery, Type: rest.TypeString, Default: "latest",
+				Description: "Block number in hex format or block tag",
+			}},
+			BuildParams: func(vals rest.Values) ([]interface{}, error) {
+				return []interface{}{vals.String("address"), vals.String("block")}, nil
+			},
+		},
+		{
+			Path: "/accounts/{address}/logs", RpcMethod: "eth_getLogs", Summary: "Get event logs of contract",
+			Params: []rest.Param{addrParam, {
+				Name: "fromBlock", In: rest.InQuery, Type: rest.TypeString, Default: "latest",
+				Description: "Start block number in hex format or block tag",
+			}, {
+				Name: "toBlock", In: rest.InQuery, Type: rest.TypeString, Default: "latest",
+				Description: "End block number in hex format or block tag",
+			}, topicsParam},
+			BuildParams: func(vals rest.Values) ([]interface{}, error) {
+				filter := map[string]interface{}{
+					"address":   []string{vals.String("address")},
+					"fromBlock": vals.String("fromBlock"),
+					"toBlock":   vals.String("toBlock"),
+				}
+
+				if topics := vals.Strings("topics"); len(topics) > 0 {
+					filter["topics"] = []interface{}{topics}
+				}
+
+				return []interface{}{filter}, nil
+			},
+		},
+	}
+)
+
+func buildHashParams(vals rest.Values) ([]interface{}, error) {
+	return []interface{}{vals.String("hash")}, nil
+}
+
+func buildBlockByHashParams(vals rest.Values) ([]interface{}, error) {
+	full, err := vals.Bool("full")
+	if err != nil {
+		return nil, err
+	}
+
+	return []interface{}{vals.String("hash"), full}, nil
+}
+
+// restConfig represents the configuration of REST gateway.
+type restConfig struct {
+	Enabled bool
+}
+
+// mustNewRestMiddlewareFromViper creates middleware to serve REST routes translated to JSON-RPC
+// methods if enabled, otherwise returns a pass-through middleware.
+func mustNewRestMiddlewareFromViper(key, title string, routes []rest.Route) handlers.Middleware {
+	var conf restConfig
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Enabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	return rest.Middleware(title, routes)
+}
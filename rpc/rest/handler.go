@@ -0,0 +1,188 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	rpcErrors "github.com/Conflux-Chain/confura/util/rpc/errors"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+)
+
+const (
+	// path of the generated OpenAPI specification relative to the version prefix
+	openapiPath = "/openapi.json"
+
+	// JSON-RPC error codes mapped to HTTP status codes
+	errCodeMethodNotFound = rpcErrors.CodeMethodNotFound
+	errCodeInvalidParams  = rpcErrors.CodeInvalidParams
+	errCodeRateLimited    = rpcErrors.CodeRateLimited
+	errCodeNotFound       = -32001
+)
+
+type jsonrpcRequest struct {
+	Version string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type jsonrpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *jsonrpcError   `json:"error"`
+}
+
+type jsonrpcError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Middleware serves GET requests of REST routes under the version prefix (optionally after
+// the access token, eg., `/<key>/v1/blocks/<hash>`), each of which is translated into JSON-RPC
+// request and handled by the next handler in the same way as JSON-RPC requests.
+func Middleware(title string, routes []Route) handlers.Middleware {
+	spec, _ := json.Marshal(OpenAPI(title, routes))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			prefix, segments, ok := splitVersionedPath(r.URL.Path)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if "/"+strings.Join(segments, "/") == openapiPath {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(spec)
+				return
+			}
+
+			for i := range routes {
+				if vals, ok := routes[i].match(segments); ok {
+					serveRoute(next, w, r, prefix, &routes[i], vals)
+					return
+				}
+			}
+
+			writeError(w, http.StatusNotFound, &jsonrpcError{Code: errCodeMethodNotFound, Message: "route not found"})
+		})
+	}
+}
+
+// splitVersionedPath splits the path into prefix (eg., access token) before the version
+// prefix and segments after it.
+func splitVersionedPath(path string) (prefix string, segments []string, ok bool) {
+	parts := splitPath(path)
+
+	for i := 0; i < len(parts) && i < 2; i++ {
+		if parts[i] == VersionPrefix {
+			return "/" + strings.Join(parts[:i], "/"), parts[i+1:], true
+		}
+	}
+
+	return "", nil, false
+}
+
+func serveRoute(next http.Handler, w http.ResponseWriter, r *http.Request, prefix string, route *Route, vals Values) {
+	route.parseQuery(vals, r.URL.Query().Get)
+
+	params, err := route.BuildParams(vals)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, &jsonrpcError{Code: errCodeInvalidParams, Message: err.Error()})
+		return
+	}
+
+	body, _ := json.Marshal(jsonrpcRequest{Version: "2.0", ID: 1, Method: route.RpcMethod, Params: params})
+
+	// translate into JSON-RPC request with the same access token and headers
+	rpcReq := r.Clone(r.Context())
+	rpcReq.Method = http.MethodPost
+	rpcReq.URL.Path, rpcReq.URL.RawPath, rpcReq.URL.RawQuery = prefix, "", ""
+	rpcReq.RequestURI = prefix
+	rpcReq.Body = io.NopCloser(bytes.NewReader(body))
+	rpcReq.ContentLength = int64(len(body))
+	rpcReq.Header.Set("Content-Type", "application/json")
+	rpcReq.Header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	rec := newRecorder()
+	next.ServeHTTP(rec, rpcReq)
+
+	var resp jsonrpcResponse
+	if err := json.Unmarshal(rec.body.Bytes(), &resp); err != nil {
+		status := rec.status
+		if status == http.StatusOK { // malformed JSON-RPC response
+			status = http.StatusBadGateway
+		}
+
+		writeError(w, status, &jsonrpcError{Code: rpcErrors.CodeInternal, Message: http.StatusText(status)})
+		return
+	}
+
+	switch {
+	case resp.Error != nil:
+		writeError(w, statusOf(resp.Error), resp.Error)
+	case len(resp.Result) == 0 || string(resp.Result) == "null":
+		writeError(w, http.StatusNotFound, &jsonrpcError{Code: errCodeNotFound, Message: "resource not found"})
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(resp.Result)
+	}
+}
+
+// statusOf maps JSON-RPC error to HTTP status code.
+func statusOf(err *jsonrpcError) int {
+	switch err.Code {
+	case errCodeInvalidParams:
+		return http.StatusBadRequest
+	case errCodeMethodNotFound: // eg., method not allowed by access control
+		return http.StatusForbidden
+	case errCodeRateLimited:
+		return http.StatusTooManyRequests
+	case rpcErrors.CodeUnauthorized:
+		return http.StatusUnauthorized
+	case rpcErrors.CodeIpForbidden, rpcErrors.CodeOriginForbidden:
+		return http.StatusForbidden
+	case rpcErrors.CodeInvalidRequest, rpcErrors.CodeRangeTooLarge, rpcErrors.CodeResponseTooLarge:
+		return http.StatusBadRequest
+	case rpcErrors.CodeFilterNotFound:
+		return http.StatusNotFound
+	case rpcErrors.CodeReorged:
+		return http.StatusConflict
+	case rpcErrors.CodeUpstreamUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err *jsonrpcError) {
+	data, _ := json.Marshal(map[string]interface{}{"error": err})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// recorder buffers the HTTP response of translated JSON-RPC request.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newRecorder() *recorder {
+	return &recorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (rec *recorder) Header() http.Header            { return rec.header }
+func (rec *recorder) Write(data []byte) (int, error) { return rec.body.Write(data) }
+func (rec *recorder) WriteHeader(statusCode int)     { rec.status = statusCode }
@@ -0,0 +1,95 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testRoutes = []Route{
+	{
+		Path:      "/blocks/{hash}",
+		RpcMethod: "eth_getBlockByHash",
+		Params: []Param{
+			{Name: "hash", In: InPath, Type: TypeString},
+			{Name: "full", In: InQuery, Type: TypeBoolean, Default: "false"},
+		},
+		BuildParams: func(vals Values) ([]interface{}, error) {
+			full, err := vals.Bool("full")
+			return []interface{}{vals.String("hash"), full}, err
+		},
+	},
+}
+
+func TestSplitVersionedPath(t *testing.T) {
+	prefix, segments, ok := splitVersionedPath("/v1/blocks/0x01")
+	assert.True(t, ok)
+	assert.Equal(t, "/", prefix)
+	assert.Equal(t, []string{"blocks", "0x01"}, segments)
+
+	prefix, segments, ok = splitVersionedPath("/key/v1/blocks/0x01")
+	assert.True(t, ok)
+	assert.Equal(t, "/key", prefix)
+	assert.Equal(t, []string{"blocks", "0x01"}, segments)
+
+	_, _, ok = splitVersionedPath("/key")
+	assert.False(t, ok)
+}
+
+func TestRouteMatch(t *testing.T) {
+	vals, ok := testRoutes[0].match([]string{"blocks", "0x01"})
+	assert.True(t, ok)
+	assert.Equal(t, "0x01", vals.String("hash"))
+
+	_, ok = testRoutes[0].match([]string{"txs", "0x01"})
+	assert.False(t, ok)
+}
+
+func TestMiddleware(t *testing.T) {
+	var rpcReq jsonrpcRequest
+	var rpcPath string
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rpcPath = r.URL.Path
+
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &rpcReq)
+
+		if rpcReq.Params[0] == "0x00" {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`))
+		} else {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"number":"0x1"}}`))
+		}
+	})
+
+	handler := Middleware("test", testRoutes)(next)
+
+	// translated to JSON-RPC request
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/key/v1/blocks/0x01?full=true", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"number":"0x1"}`, rec.Body.String())
+	assert.Equal(t, "/key", rpcPath)
+	assert.Equal(t, "eth_getBlockByHash", rpcReq.Method)
+	assert.Equal(t, []interface{}{"0x01", true}, rpcReq.Params)
+
+	// not found
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/blocks/0x00", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// invalid params
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/blocks/0x01?full=abc", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// OpenAPI spec
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"/v1/blocks/{hash}"`)
+}
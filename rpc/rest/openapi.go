@@ -0,0 +1,65 @@
+package rest
+
+import (
+	"strings"
+)
+
+// OpenAPI generates the OpenAPI 3.0 specification from route definitions.
+func OpenAPI(title string, routes []Route) map[string]interface{} {
+	paths := make(map[string]interface{})
+
+	for _, r := range routes {
+		var params []map[string]interface{}
+		for _, p := range r.Params {
+			param := map[string]interface{}{
+				"name":        p.Name,
+				"in":          p.In,
+				"required":    p.required(),
+				"description": p.Description,
+				"schema":      map[string]interface{}{"type": p.Type},
+			}
+
+			if len(p.Default) > 0 {
+				param["schema"].(map[string]interface{})["default"] = p.Default
+			}
+
+			params = append(params, param)
+		}
+
+		paths["/"+VersionPrefix+r.Path] = map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     r.Summary,
+				"operationId": r.RpcMethod,
+				"description": "Translated to JSON-RPC method `" + r.RpcMethod + "`.",
+				"parameters":  params,
+				"responses": map[string]interface{}{
+					"200": jsonResponse("JSON-RPC result"),
+					"400": jsonResponse("Invalid parameters"),
+					"404": jsonResponse("Not found"),
+					"429": jsonResponse("Rate limited"),
+					"500": jsonResponse("Internal error"),
+				},
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   title,
+			"version": strings.TrimPrefix(VersionPrefix, "v") + ".0.0",
+		},
+		"paths": paths,
+	}
+}
+
+func jsonResponse(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": map[string]interface{}{},
+			},
+		},
+	}
+}
@@ -0,0 +1,116 @@
+package rest
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// REST API version prefix
+	VersionPrefix = "v1"
+
+	// parameter locations
+	InPath  = "path"
+	InQuery = "query"
+
+	// parameter types
+	TypeString  = "string"
+	TypeBoolean = "boolean"
+)
+
+// Param is the REST route parameter.
+type Param struct {
+	Name        string
+	In          string // `path` or `query`
+	Type        string // `string` or `boolean`
+	Description string
+	Default     string // default value if not required
+}
+
+func (p Param) required() bool {
+	return p.In == InPath
+}
+
+// Values is the parsed parameter values by name.
+type Values map[string]string
+
+// String returns the string value of parameter.
+func (vals Values) String(name string) string {
+	return vals[name]
+}
+
+// Bool returns the boolean value of parameter.
+func (vals Values) Bool(name string) (bool, error) {
+	v, err := strconv.ParseBool(vals[name])
+	if err != nil {
+		return false, errors.Errorf("invalid boolean parameter %v", name)
+	}
+
+	return v, nil
+}
+
+// Strings returns the comma separated string values of parameter, or nil if empty.
+func (vals Values) Strings(name string) []string {
+	if len(vals[name]) == 0 {
+		return nil
+	}
+
+	return strings.Split(vals[name], ",")
+}
+
+// Route is the REST route translated to JSON-RPC method.
+type Route struct {
+	// path relative to the version prefix, eg., `/blocks/{hash}`
+	Path string
+	// JSON-RPC method translated to
+	RpcMethod string
+	Summary   string
+	Params    []Param
+	// builds JSON-RPC params from parsed parameter values
+	BuildParams func(vals Values) ([]interface{}, error)
+}
+
+// match matches the path segments, and returns the path parameter values if matched.
+func (r *Route) match(segments []string) (Values, bool) {
+	pattern := splitPath(r.Path)
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+
+	vals := make(Values)
+	for i, seg := range pattern {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			vals[seg[1:len(seg)-1]] = segments[i]
+		} else if seg != segments[i] {
+			return nil, false
+		}
+	}
+
+	return vals, true
+}
+
+// parseQuery fills the query parameter values or defaults if absent.
+func (r *Route) parseQuery(vals Values, query func(string) string) {
+	for _, p := range r.Params {
+		if p.In != InQuery {
+			continue
+		}
+
+		if v := query(p.Name); len(v) > 0 {
+			vals[p.Name] = v
+		} else {
+			vals[p.Name] = p.Default
+		}
+	}
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if len(path) == 0 {
+		return nil
+	}
+
+	return strings.Split(path, "/")
+}
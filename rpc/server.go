@@ -38,9 +38,15 @@ func MustNewNativeSpaceServer(
 		)
 	}
 
-	middleware := httpMiddleware("cfx", registry, clientProvider)
+	state := mustNewServerStateFromViper("cfx", "rpc")
+	middleware := httpMiddleware("cfx", registry, clientProvider, state)
 
-	return rpc.MustNewServer(nativeSpaceRpcServerName, exposedApis, middleware)
+	restMiddleware := mustNewRestMiddlewareFromViper("rpc.rest", "Confura Core Space REST API", cfxRestRoutes)
+
+	return rpc.MustNewServer(
+		nativeSpaceRpcServerName, exposedApis,
+		restMiddleware, middleware, payloadMiddleware(state.payload), batchMiddleware(state.batching),
+	)
 }
 
 // MustNewEvmSpaceServer new evm space RPC server by specifying router, and exposed modules.
@@ -66,9 +72,15 @@ func MustNewEvmSpaceServer(
 		)
 	}
 
-	middleware := httpMiddleware("eth", registry, clientProvider)
+	state := mustNewServerStateFromViper("eth", "ethrpc")
+	middleware := httpMiddleware("eth", registry, clientProvider, state)
+
+	restMiddleware := mustNewRestMiddlewareFromViper("ethrpc.rest", "Confura EVM Space REST API", ethRestRoutes)
 
-	return rpc.MustNewServer(evmSpaceRpcServerName, exposedApis, middleware)
+	return rpc.MustNewServer(
+		evmSpaceRpcServerName, exposedApis,
+		restMiddleware, middleware, payloadMiddleware(state.payload), batchMiddleware(state.batching),
+	)
 }
 
 type CfxBridgeServerConfig struct {
@@ -89,16 +101,17 @@ func MustNewNativeSpaceBridgeServer(registry *rate.Registry, config *CfxBridgeSe
 		logrus.WithError(err).Fatal("Failed to new CFX bridge RPC server with bad exposed modules")
 	}
 
-	middleware := httpMiddleware("cfxBridge", registry, nil)
+	middleware := httpMiddleware("cfxBridge", registry, nil, nil)
 	return rpc.MustNewServer(nativeSpaceBridgeRpcServerName, exposedApis, middleware)
 }
 
-// MustNewDebugServer new debug RPC server for internal debugging use.
-func MustNewDebugServer() *rpc.Server {
+// MustNewDebugServer new debug RPC server for internal debugging use, which is authenticated by
+// bearer token (and client certificate if admin TLS configured).
+func MustNewDebugServer(authToken string) *rpc.Server {
 	servedApis := make(map[string]interface{})
 	for _, api := range debugApis() {
 		servedApis[api.Namespace] = api.Service
 	}
 
-	return rpc.MustNewServer(debugRpcServerName, servedApis)
+	return rpc.MustNewAdminServer(debugRpcServerName, servedApis, rpc.MustNewBearerAuthMiddleware(authToken))
 }
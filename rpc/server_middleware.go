@@ -2,23 +2,90 @@ package rpc
 
 import (
 	"context"
+	"net"
 	"net/http"
+	"strings"
 
 	"github.com/Conflux-Chain/confura/node"
 	"github.com/Conflux-Chain/confura/rpc/handler"
+	"github.com/Conflux-Chain/confura/store/redis"
+	"github.com/Conflux-Chain/confura/util/acl"
 	"github.com/Conflux-Chain/confura/util/rate"
+	"github.com/Conflux-Chain/confura/util/reload"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	"github.com/Conflux-Chain/confura/util/rpc/handlers"
 	"github.com/Conflux-Chain/confura/util/rpc/middlewares"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
 	"github.com/openweb3/go-rpc-provider"
+	"github.com/sirupsen/logrus"
 )
 
 const (
 	ctxKeyClientProvider = handlers.CtxKey("Infura-RPC-Client-Provider")
 	ctxKeyClient         = handlers.CtxKey("Infura-RPC-Client")
 	ctxKeyClientGroup    = handlers.CtxKey("Infura-RPC-Client-Group")
+	ctxKeyServerState    = handlers.CtxKey("Infura-RPC-Server-State")
 )
 
+// serverState is the state of middlewares per RPC server, which is loaded from viper once server
+// created, so that extra networks served by the same instance (see `networks` in config file) have
+// their own settings and caches rather than sharing the process-wide ones.
+type serverState struct {
+	payload   payloadConfig
+	batching  batchConfig
+	respCache *responseCache // nil if disabled
+	hedging   *hedgeConfig
+}
+
+// trustedProxies are the reverse proxies allowed to forward client IP by `X-Forwarded-For` header,
+// which is used to restrict API keys by source IP.
+var trustedProxies []*net.IPNet
+
+func mustNewTrustedProxiesFromViper() []*net.IPNet {
+	var conf struct {
+		// IPs or CIDR ranges of trusted reverse proxies
+		TrustedProxies []string
+	}
+	viper.MustUnmarshalKey("rpc.keyRestriction", &conf)
+
+	nets, err := acl.ParseIpNets(strings.Join(conf.TrustedProxies, ","))
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid trusted proxies")
+	}
+
+	return nets
+}
+
+// mustNewServerStateFromViper loads the middleware state of RPC server for the specified space
+// (`cfx` or `eth`) from settings prefixed by the specified key, e.g. `rpc` or `ethrpc`.
+func mustNewServerStateFromViper(space, keyPrefix string) *serverState {
+	state := &serverState{}
+	viper.MustUnmarshalKey(keyPrefix+".payload", &state.payload)
+	viper.MustUnmarshalKey(keyPrefix+".batch", &state.batching)
+
+	switch space {
+	case "cfx":
+		state.respCache = mustNewResponseCacheFromViper(
+			keyPrefix+".responseCache", space, cfxCacheRules, cfxCacheUpstream{},
+		)
+		state.hedging = mustNewHedgeConfigFromViper(keyPrefix+".hedging", cfxHedgeableMethods)
+	case "eth":
+		state.respCache = mustNewResponseCacheFromViper(
+			keyPrefix+".responseCache", space, ethCacheRules, ethCacheUpstream{},
+		)
+		state.hedging = mustNewHedgeConfigFromViper(keyPrefix+".hedging", ethHedgeableMethods)
+	}
+
+	return state
+}
+
+// serverStateFromContext returns the middleware state of RPC server serving the request.
+func serverStateFromContext(ctx context.Context) (*serverState, bool) {
+	state, ok := ctx.Value(ctxKeyServerState).(*serverState)
+	return state, ok && state != nil
+}
+
 func MustInit() {
 	// init handler
 	handler.MustInitFromViper()
@@ -26,24 +93,47 @@ func MustInit() {
 	// init metrics
 	initMetrics()
 
+	trustedProxies = mustNewTrustedProxiesFromViper()
+
 	// Register middlewares for go-rpc-provider, which only supports static middlewares for RPC server.
 	// The following middlewares are executed in order.
 
+	// restore context values injected by HTTP middlewares for in-process calls
+	rpc.HookHandleCallMsg(rpcutil.InProcMiddleware)
+
+	// request ID for log correlation
+	rpc.HookHandleCallMsg(middlewares.RequestId)
+
 	// panic recovery
 	rpc.HookHandleCallMsg(middlewares.Recover)
 
+	// tracing
+	rpc.HookHandleCallMsg(middlewares.Tracing)
+
 	// anti-injection
 	rpc.HookHandleCallMsg(middlewares.AntiInjection)
 
+	// payload limits
+	rpc.HookHandleCallMsg(payloadCallMiddleware)
+
 	// auth
 	rpc.HookHandleCallMsg(middlewares.Auth())
 
 	// allow lists
+	rpc.HookHandleCallMsg(middlewares.KeyRestrictions)
 	rpc.HookHandleCallMsg(middlewares.Allowlists)
+	rpc.HookHandleCallMsg(middlewares.MethodAcl(mustNewMethodAclsFromViper()))
 
 	// rate limit
 	rpc.HookHandleCallMsg(middlewares.DailyMaxReqRateLimit)
 	rpc.HookHandleCallMsg(middlewares.QpsRateLimit)
+	rpc.HookHandleCallMsg(middlewares.TieredRateLimit(mustNewTieredLimitersFromViper()))
+
+	// request timeout
+	rpc.HookHandleCallMsg(middlewares.Timeout(mustNewTimeoutConfigsFromViper()))
+
+	// slow request recorder
+	rpc.HookHandleCallMsg(middlewares.SlowLog)
 
 	// metrics
 	rpc.HookHandleBatch(middlewares.MetricsBatch)
@@ -56,6 +146,16 @@ func MustInit() {
 	// cfx/eth client
 	rpc.HookHandleCallMsg(clientMiddleware)
 
+	// snapshot-consistent reads
+	mustInitSnapshotFromViper()
+	rpc.HookHandleCallMsg(snapshotMiddleware)
+
+	// immutable responses cache, which is created per RPC server
+	rpc.HookHandleCallMsg(responseCacheMiddleware)
+
+	// hedged requests for idempotent read-only methods, which is configured per RPC server
+	rpc.HookHandleCallMsg(hedgeMiddleware)
+
 	// uniform human-readable error message
 	rpc.HookHandleCallMsg(middlewares.UniformError)
 
@@ -66,7 +166,9 @@ func MustInit() {
 }
 
 // Inject values into context for static RPC call middlewares, e.g. rate limit
-func httpMiddleware(namespace string, registry *rate.Registry, clientProvider interface{}) handlers.Middleware {
+func httpMiddleware(
+	namespace string, registry *rate.Registry, clientProvider interface{}, state *serverState,
+) handlers.Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
@@ -79,9 +181,15 @@ func httpMiddleware(namespace string, registry *rate.Registry, clientProvider in
 				ctx = context.WithValue(ctx, handlers.CtxKeyAccessToken, token)
 			}
 
+			if token := handlers.GetBearerToken(r); len(token) > 0 { // optional, e.g., JWT
+				ctx = context.WithValue(ctx, handlers.CtxKeyBearerToken, token)
+			}
+
 			ctx = context.WithValue(ctx, handlers.CtxKeyReqOrigin, r.Header.Get("Origin"))
 			ctx = context.WithValue(ctx, handlers.CtxKeyUserAgent, r.Header.Get("User-Agent"))
 			ctx = context.WithValue(ctx, handlers.CtxKeyRealIP, handlers.GetIPAddress(r))
+			ctx = context.WithValue(ctx, handlers.CtxKeyClientIP, handlers.GetClientIP(r, trustedProxies))
+			ctx = snapshotHttpHeaders(ctx, r)
 
 			if registry != nil {
 				ctx = context.WithValue(ctx, handlers.CtxKeyRateRegistry, registry)
@@ -91,11 +199,82 @@ func httpMiddleware(namespace string, registry *rate.Registry, clientProvider in
 				ctx = context.WithValue(ctx, ctxKeyClientProvider, clientProvider)
 			}
 
+			if state != nil {
+				ctx = context.WithValue(ctx, ctxKeyServerState, state)
+			}
+
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// NewHttpContextMiddleware injects values into context for HTTP servers other than RPC servers,
+// e.g., GraphQL server, which authenticate and rate limit requests the same as RPC servers.
+func NewHttpContextMiddleware(namespace string, registry *rate.Registry) handlers.Middleware {
+	return httpMiddleware(namespace, registry, nil, nil)
+}
+
+// mustNewMethodAclsFromViper creates method-level access controls keyed by RPC namespace.
+func mustNewMethodAclsFromViper() map[string]*acl.MethodAcl {
+	acls := make(map[string]*acl.MethodAcl)
+
+	for space, key := range map[string]string{"cfx": "rpc.methodAcl", "eth": "ethrpc.methodAcl"} {
+		var conf acl.MethodAclConfig
+		viper.MustUnmarshalKey(key, &conf)
+
+		if len(conf.Allow) > 0 || len(conf.Deny) > 0 || len(conf.Tiers) > 0 {
+			acls[space] = acl.NewMethodAcl(conf)
+		}
+	}
+
+	return acls
+}
+
+// mustNewTimeoutConfigsFromViper creates per method request timeout settings keyed by RPC namespace.
+func mustNewTimeoutConfigsFromViper() map[string]*middlewares.TimeoutConfig {
+	confs := make(map[string]*middlewares.TimeoutConfig)
+
+	for space, key := range map[string]string{"cfx": "rpc.timeout", "eth": "ethrpc.timeout"} {
+		var conf middlewares.TimeoutConfig
+		viper.MustUnmarshalKey(key, &conf)
+
+		if conf.Default > 0 || len(conf.Methods) > 0 {
+			confs[space] = &conf
+		}
+	}
+
+	return confs
+}
+
+// mustNewTieredLimitersFromViper creates distributed rate limiters keyed by RPC namespace.
+func mustNewTieredLimitersFromViper() map[string]*rate.TieredLimiter {
+	limiters := make(map[string]*rate.TieredLimiter)
+
+	for space, key := range map[string]string{"cfx": "rpc.tieredRateLimit", "eth": "ethrpc.tieredRateLimit"} {
+		var conf rate.TieredLimitConfig
+		viper.MustUnmarshalKey(key, &conf)
+
+		if !conf.Enabled {
+			continue
+		}
+
+		if len(conf.RedisUrl) == 0 {
+			logrus.WithField("space", space).Fatal("Redis required for tiered rate limit")
+		}
+
+		if err := conf.Validate(); err != nil {
+			logrus.WithField("space", space).WithError(err).Fatal("Invalid tiered rate limit config")
+		}
+
+		limiter := rate.NewTieredLimiter(space, conf, redis.MustNewRedisClient(conf.RedisUrl))
+		reload.Register(key, conf, rate.TieredLimitConfig.Validate, limiter.SetLimits)
+
+		limiters[space] = limiter
+	}
+
+	return limiters
+}
+
 func clientMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
 		var client interface{}
@@ -125,16 +304,27 @@ func GetCfxClientProviderFromContext(ctx context.Context) *node.CfxClientProvide
 	return ctx.Value(ctxKeyClientProvider).(*node.CfxClientProvider)
 }
 
+// GetCfxClientFromContext returns the core space client bound to the request context, so that
+// the full node requests will be canceled along with the request context.
 func GetCfxClientFromContext(ctx context.Context) sdk.ClientOperator {
-	return ctx.Value(ctxKeyClient).(sdk.ClientOperator)
+	client := ctx.Value(ctxKeyClient).(sdk.ClientOperator)
+
+	if cfx, ok := client.(*sdk.Client); ok {
+		return cfx.WithContext(ctx)
+	}
+
+	return client
 }
 
 func GetEthClientProviderFromContext(ctx context.Context) *node.EthClientProvider {
 	return ctx.Value(ctxKeyClientProvider).(*node.EthClientProvider)
 }
 
+// GetEthClientFromContext returns the evm space client bound to the request context, so that
+// the full node requests will be canceled along with the request context.
 func GetEthClientFromContext(ctx context.Context) *node.Web3goClient {
-	return ctx.Value(ctxKeyClient).(*node.Web3goClient)
+	w3c := ctx.Value(ctxKeyClient).(*node.Web3goClient)
+	return &node.Web3goClient{Client: w3c.Client.WithContext(ctx), URL: w3c.URL}
 }
 
 func GetClientGroupFromContext(ctx context.Context) node.Group {
@@ -145,11 +335,18 @@ func getEthClientFromProviderWithContext(
 	ctx context.Context, rpcMethod string, p *node.EthClientProvider) (*node.Web3goClient, node.Group, error) {
 	grp := node.GroupEthHttp
 
+	if methodGrp, ok := node.EthMethodGroup(rpcMethod); ok { // routing policy by method
+		client, err := p.GetClientByAffinity(ctx, methodGrp)
+		return client, methodGrp, err
+	}
+
 	switch {
 	case rpcMethod == rpcMethodEthGetLogs:
 		grp = node.GroupEthLogs
 	case isEthFilterRpcMethod(rpcMethod):
 		grp = node.GroupEthFilter
+	case isEthTraceRpcMethod(rpcMethod) && len(node.EthUrlConfig()[node.GroupEthArchives].Nodes) > 0:
+		grp = node.GroupEthArchives
 	default:
 		if authId, ok := handlers.GetAuthIdFromContext(ctx); ok {
 			grp, ok := p.GetRouteGroup(authId)
@@ -160,7 +357,7 @@ func getEthClientFromProviderWithContext(
 		}
 	}
 
-	client, err := p.GetClientByIP(ctx, grp)
+	client, err := p.GetClientByAffinity(ctx, grp)
 	return client, grp, err
 }
 
@@ -168,6 +365,11 @@ func getCfxClientFromProviderWithContext(
 	ctx context.Context, rpcMethod string, p *node.CfxClientProvider) (sdk.ClientOperator, node.Group, error) {
 	grp := node.GroupCfxHttp
 
+	if methodGrp, ok := node.CfxMethodGroup(rpcMethod); ok { // routing policy by method
+		client, err := p.GetClientByAffinity(ctx, methodGrp)
+		return client, methodGrp, err
+	}
+
 	switch {
 	case rpcMethod == rpcMethodCfxGetLogs:
 		grp = node.GroupCfxLogs
@@ -183,6 +385,6 @@ func getCfxClientFromProviderWithContext(
 		}
 	}
 
-	client, err := p.GetClientByIP(ctx, grp)
+	client, err := p.GetClientByAffinity(ctx, grp)
 	return client, grp, err
 }
@@ -0,0 +1,511 @@
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/store/redis"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+)
+
+const (
+	// HTTP header of the pinned snapshot session ID returned by `snapshot_pin`
+	headerSnapshotId = "Snapshot-Id"
+	// HTTP header of the epoch (or block) number to read as of, in decimal or hex
+	headerSnapshotEpoch = "Snapshot-Epoch"
+
+	ctxKeySnapshotId    = handlers.CtxKey("Infura-Snapshot-ID")
+	ctxKeySnapshotEpoch = handlers.CtxKey("Infura-Snapshot-Epoch")
+)
+
+var (
+	errSnapshotDisabled = errors.New("snapshot reads not enabled")
+	errSnapshotNotFound = errors.New("snapshot session not found or expired")
+	errSnapshotPruned   = errors.New("snapshot epoch too old, whose state may be pruned")
+
+	// latest epoch (or block) tags pinned to the snapshot
+	cfxSnapshotLatestTags = map[string]bool{"latest_state": true, "latest_mined": true}
+	ethSnapshotLatestTags = map[string]bool{"latest": true}
+
+	// param index of the epoch (or block) number to pin, which is latest if omitted
+	cfxSnapshotMethods = map[string]int{
+		"cfx_epochNumber":               0,
+		"cfx_getBalance":                1,
+		"cfx_getStakingBalance":         1,
+		"cfx_getCollateralForStorage":   1,
+		"cfx_getAdmin":                  1,
+		"cfx_getCode":                   1,
+		"cfx_getStorageAt":              2,
+		"cfx_getStorageRoot":            1,
+		"cfx_getSponsorInfo":            1,
+		"cfx_getNextNonce":              1,
+		"cfx_getAccount":                1,
+		"cfx_call":                      1,
+		"cfx_estimateGasAndCollateral":  1,
+		"cfx_getDepositList":            1,
+		"cfx_getVoteList":               1,
+		"cfx_getInterestRate":           0,
+		"cfx_getAccumulateInterestRate": 0,
+		"cfx_getSupplyInfo":             0,
+		"cfx_getBlockByEpochNumber":     0,
+		"cfx_getBlocksByEpoch":          0,
+		"cfx_getEpochReceipts":          0,
+		"cfx_getCollateralInfo":         0,
+		"cfx_getParamsFromVote":         0,
+		"cfx_getPoSEconomics":           0,
+		"cfx_getFeeBurnt":               0,
+	}
+	ethSnapshotMethods = map[string]int{
+		"eth_getBalance":                          1,
+		"eth_getCode":                             1,
+		"eth_getStorageAt":                        2,
+		"eth_getTransactionCount":                 1,
+		"eth_call":                                1,
+		"eth_estimateGas":                         1,
+		"eth_getBlockByNumber":                    0,
+		"eth_getBlockTransactionCountByNumber":    0,
+		"eth_getTransactionByBlockNumberAndIndex": 0,
+		"eth_getBlockReceipts":                    0,
+	}
+
+	// log filters pinned to the snapshot
+	cfxSnapshotLogFilter = snapshotLogFilter{
+		method: rpcMethodCfxGetLogs, fromField: "fromEpoch", toField: "toEpoch", hashField: "blockHashes",
+	}
+	ethSnapshotLogFilter = snapshotLogFilter{
+		method: rpcMethodEthGetLogs, fromField: "fromBlock", toField: "toBlock", hashField: "blockHash",
+		fromLatestIfOmitted: true,
+	}
+
+	cfxSnapshots, ethSnapshots *snapshotSessions
+)
+
+// snapshotLogFilter defines the log filter of space to pin epoch (or block) range.
+type snapshotLogFilter struct {
+	method                        string
+	fromField, toField, hashField string
+	// whether the omitted from epoch (or block) defaults to the latest one
+	fromLatestIfOmitted bool
+}
+
+// snapshotConfig represents the configuration of snapshot-consistent reads, by which a sequence of
+// reads (e.g., balance, logs and storage) are answered as of the same epoch (or block) even while
+// the head advances, either pinned by session or specified by HTTP header.
+type snapshotConfig struct {
+	Enabled bool
+	// max number of pinned sessions in memory
+	MaxSessions int `default:"10000"`
+	// time-to-live of pinned sessions
+	TTL time.Duration `default:"10m"`
+	// optional Redis to share pinned sessions among multiple instances
+	RedisUrl string
+	// max number of epochs (or blocks) behind the latest one to read as of, since the state of
+	// older ones may be pruned by full nodes
+	MaxEpochLag uint64 `default:"1000"`
+}
+
+func mustInitSnapshotFromViper() {
+	var cfxConf, ethConf snapshotConfig
+	viper.MustUnmarshalKey("rpc.snapshot", &cfxConf)
+	viper.MustUnmarshalKey("ethrpc.snapshot", &ethConf)
+
+	if cfxConf.Enabled {
+		cfxSnapshots = newSnapshotSessions(
+			"cfx", cfxConf, mustNewSnapshotRedisClient(cfxConf),
+			cfxSnapshotMethods, cfxSnapshotLatestTags, cfxSnapshotLogFilter, cfxSnapshotLatest,
+		)
+	}
+
+	if ethConf.Enabled {
+		ethSnapshots = newSnapshotSessions(
+			"eth", ethConf, mustNewSnapshotRedisClient(ethConf),
+			ethSnapshotMethods, ethSnapshotLatestTags, ethSnapshotLogFilter, ethSnapshotLatest,
+		)
+	}
+}
+
+func mustNewSnapshotRedisClient(conf snapshotConfig) *goredis.Client {
+	if len(conf.RedisUrl) == 0 {
+		return nil
+	}
+
+	return redis.MustNewRedisClient(conf.RedisUrl)
+}
+
+// cfxSnapshotLatest returns the latest state epoch of full node in context.
+func cfxSnapshotLatest(ctx context.Context) (uint64, error) {
+	epoch, err := GetCfxClientFromContext(ctx).GetEpochNumber(types.EpochLatestState)
+	if err != nil {
+		return 0, err
+	}
+
+	return epoch.ToInt().Uint64(), nil
+}
+
+// ethSnapshotLatest returns the latest block of full node in context.
+func ethSnapshotLatest(ctx context.Context) (uint64, error) {
+	bn, err := GetEthClientFromContext(ctx).Eth.BlockNumber()
+	if err != nil {
+		return 0, err
+	}
+
+	return bn.Uint64(), nil
+}
+
+// snapshotSessions maintains the pinned snapshot sessions, and pins the epoch (or block) params of
+// read requests to the snapshot.
+//
+// Sessions are kept in memory unless Redis configured, in which case they are shared among
+// multiple instances behind load balancer.
+type snapshotSessions struct {
+	space      string
+	conf       snapshotConfig
+	methods    map[string]int
+	latestTags map[string]bool
+	logFilter  snapshotLogFilter
+	latest     func(ctx context.Context) (uint64, error)
+
+	sessions *util.ExpirableLruCache // session ID => pinned epoch (or block) number
+	client   *goredis.Client
+}
+
+func newSnapshotSessions(
+	space string,
+	conf snapshotConfig,
+	client *goredis.Client,
+	methods map[string]int,
+	latestTags map[string]bool,
+	logFilter snapshotLogFilter,
+	latest func(ctx context.Context) (uint64, error),
+) *snapshotSessions {
+	s := &snapshotSessions{
+		space:      space,
+		conf:       conf,
+		methods:    methods,
+		latestTags: latestTags,
+		logFilter:  logFilter,
+		latest:     latest,
+		client:     client,
+	}
+
+	if client == nil {
+		s.sessions = util.NewExpirableLruCache(conf.MaxSessions, conf.TTL)
+	}
+
+	return s
+}
+
+func (s *snapshotSessions) key(sessionId string) string {
+	return "rpc:snapshot:" + s.space + ":" + sessionId
+}
+
+// pin creates a session pinned to the specified epoch (or block) number.
+func (s *snapshotSessions) pin(ctx context.Context, epoch uint64) (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", errors.WithMessage(err, "failed to generate session ID")
+	}
+
+	sessionId := hexutil.Encode(id[:])
+
+	if s.client == nil {
+		s.sessions.Add(sessionId, epoch)
+		return sessionId, nil
+	}
+
+	if err := s.client.Set(ctx, s.key(sessionId), epoch, s.conf.TTL).Err(); err != nil {
+		return "", errors.WithMessage(err, "failed to save session into redis")
+	}
+
+	return sessionId, nil
+}
+
+// get returns the epoch (or block) number pinned by session.
+func (s *snapshotSessions) get(ctx context.Context, sessionId string) (uint64, bool, error) {
+	if s.client == nil {
+		v, ok := s.sessions.Get(sessionId)
+		if !ok {
+			return 0, false, nil
+		}
+
+		return v.(uint64), true, nil
+	}
+
+	epoch, err := s.client.Get(ctx, s.key(sessionId)).Uint64()
+	if err == goredis.Nil {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, errors.WithMessage(err, "failed to get session from redis")
+	}
+
+	return epoch, true, nil
+}
+
+// release removes the pinned session.
+func (s *snapshotSessions) release(ctx context.Context, sessionId string) (bool, error) {
+	if s.client == nil {
+		return s.sessions.Del(sessionId), nil
+	}
+
+	n, err := s.client.Del(ctx, s.key(sessionId)).Result()
+	if err != nil {
+		return false, errors.WithMessage(err, "failed to delete session from redis")
+	}
+
+	return n > 0, nil
+}
+
+// checkPruned rejects the epoch (or block) too far behind the latest one, whose state may be
+// pruned by full nodes already.
+func (s *snapshotSessions) checkPruned(ctx context.Context, epoch uint64) error {
+	latest, err := s.latest(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get latest epoch")
+	}
+
+	if epoch+s.conf.MaxEpochLag < latest {
+		return errSnapshotPruned
+	}
+
+	return nil
+}
+
+// snapshotHttpHeaders injects the snapshot HTTP headers into context if any.
+func snapshotHttpHeaders(ctx context.Context, r *http.Request) context.Context {
+	if sessionId := r.Header.Get(headerSnapshotId); len(sessionId) > 0 {
+		ctx = context.WithValue(ctx, ctxKeySnapshotId, sessionId)
+	}
+
+	if epoch := r.Header.Get(headerSnapshotEpoch); len(epoch) > 0 {
+		ctx = context.WithValue(ctx, ctxKeySnapshotEpoch, epoch)
+	}
+
+	return ctx
+}
+
+// snapshotEpoch returns the epoch (or block) number to read as of from context if any.
+func snapshotEpoch(ctx context.Context, s *snapshotSessions) (uint64, bool, error) {
+	if sessionId, ok := ctx.Value(ctxKeySnapshotId).(string); ok {
+		epoch, ok, err := s.get(ctx, sessionId)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if !ok {
+			return 0, false, errSnapshotNotFound
+		}
+
+		return epoch, true, nil
+	}
+
+	if val, ok := ctx.Value(ctxKeySnapshotEpoch).(string); ok {
+		epoch, err := parseSnapshotEpoch(val)
+		if err != nil {
+			return 0, false, errors.WithMessagef(err, "invalid %v header", headerSnapshotEpoch)
+		}
+
+		return epoch, true, nil
+	}
+
+	return 0, false, nil
+}
+
+// parseSnapshotEpoch parses the epoch (or block) number in decimal or hex.
+func parseSnapshotEpoch(val string) (uint64, error) {
+	if strings.HasPrefix(val, "0x") || strings.HasPrefix(val, "0X") {
+		return hexutil.DecodeUint64(strings.ToLower(val))
+	}
+
+	return strconv.ParseUint(val, 10, 64)
+}
+
+// snapshotMiddleware pins the latest epoch (or block) params of read requests to the snapshot
+// specified by HTTP headers, which must be hooked after the client middleware but before the
+// response cache middleware.
+func snapshotMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		var s *snapshotSessions
+
+		switch ctx.Value(ctxKeyClientProvider).(type) {
+		case *node.CfxClientProvider:
+			s = cfxSnapshots
+		case *node.EthClientProvider:
+			s = ethSnapshots
+		}
+
+		// snapshot session management requests are not pinned
+		if s == nil || strings.HasPrefix(msg.Method, "snapshot_") {
+			return next(ctx, msg)
+		}
+
+		epoch, ok, err := snapshotEpoch(ctx, s)
+		if err != nil {
+			return msg.ErrorResponse(err)
+		}
+
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		if err := s.checkPruned(ctx, epoch); err != nil {
+			return msg.ErrorResponse(err)
+		}
+
+		params, err := s.pinParams(msg.Method, msg.Params, epoch)
+		if err != nil {
+			return msg.ErrorResponse(err)
+		}
+
+		pinned := *msg
+		pinned.Params = params
+
+		return next(ctx, &pinned)
+	}
+}
+
+// pinParams replaces the omitted or latest epoch (or block) param of RPC method with the snapshot.
+func (s *snapshotSessions) pinParams(method string, rawParams json.RawMessage, epoch uint64) (json.RawMessage, error) {
+	index, ok := s.methods[method]
+	if !ok && method != s.logFilter.method {
+		return rawParams, nil
+	}
+
+	var params []json.RawMessage
+	if len(rawParams) > 0 {
+		if err := json.Unmarshal(rawParams, &params); err != nil {
+			return rawParams, nil // leave it to the RPC server to report invalid params
+		}
+	}
+
+	epochParam, _ := json.Marshal(hexutil.Uint64(epoch))
+
+	if !ok { // log filter
+		if len(params) == 0 {
+			return rawParams, nil
+		}
+
+		filter, err := s.pinLogFilter(params[0], epochParam)
+		if err != nil {
+			return nil, err
+		}
+
+		params[0] = filter
+	} else if index == len(params) {
+		params = append(params, epochParam)
+	} else if index < len(params) && s.isLatest(params[index]) {
+		params[index] = epochParam
+	}
+
+	return json.Marshal(params)
+}
+
+// pinLogFilter pins the omitted or latest epoch (or block) range of log filter to the snapshot.
+func (s *snapshotSessions) pinLogFilter(rawFilter, epochParam json.RawMessage) (json.RawMessage, error) {
+	var filter map[string]json.RawMessage
+	if err := json.Unmarshal(rawFilter, &filter); err != nil || filter == nil {
+		return rawFilter, nil
+	}
+
+	lf := &s.logFilter
+	if _, ok := filter[lf.hashField]; ok {
+		return rawFilter, nil
+	}
+
+	if to, ok := filter[lf.toField]; !ok || s.isLatest(to) {
+		filter[lf.toField] = epochParam
+	}
+
+	if from, ok := filter[lf.fromField]; (!ok && lf.fromLatestIfOmitted) || (ok && s.isLatest(from)) {
+		filter[lf.fromField] = epochParam
+	}
+
+	return json.Marshal(filter)
+}
+
+func (s *snapshotSessions) isLatest(param json.RawMessage) bool {
+	if string(param) == "null" {
+		return true
+	}
+
+	var tag string
+	if err := json.Unmarshal(param, &tag); err != nil {
+		return false
+	}
+
+	return s.latestTags[tag]
+}
+
+// SnapshotPin is the snapshot session pinned to an epoch (or block).
+type SnapshotPin struct {
+	Id       string         `json:"id"`
+	Epoch    hexutil.Uint64 `json:"epoch"`
+	ExpireAt time.Time      `json:"expireAt"`
+}
+
+// snapshotAPI provides extension RPCs to pin a session to the latest epoch (or block), so that
+// subsequent requests with `Snapshot-Id` HTTP header are answered as of the same epoch (or block).
+// Alternatively, clients could specify the epoch (or block) by `Snapshot-Epoch` HTTP header.
+type snapshotAPI struct {
+	sessions func() *snapshotSessions
+}
+
+func newCfxSnapshotAPI() *snapshotAPI {
+	return &snapshotAPI{
+		sessions: func() *snapshotSessions { return cfxSnapshots },
+	}
+}
+
+func newEthSnapshotAPI() *snapshotAPI {
+	return &snapshotAPI{
+		sessions: func() *snapshotSessions { return ethSnapshots },
+	}
+}
+
+// Pin creates a session pinned to the latest state epoch (or block), which expires after a while.
+func (api *snapshotAPI) Pin(ctx context.Context) (*SnapshotPin, error) {
+	s := api.sessions()
+	if s == nil {
+		return nil, errSnapshotDisabled
+	}
+
+	epoch, err := s.latest(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get latest epoch")
+	}
+
+	sessionId, err := s.pin(ctx, epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SnapshotPin{
+		Id:       sessionId,
+		Epoch:    hexutil.Uint64(epoch),
+		ExpireAt: time.Now().Add(s.conf.TTL),
+	}, nil
+}
+
+// Release releases the pinned session before expiration.
+func (api *snapshotAPI) Release(ctx context.Context, sessionId string) (bool, error) {
+	s := api.sessions()
+	if s == nil {
+		return false, errSnapshotDisabled
+	}
+
+	return s.release(ctx, sessionId)
+}
@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSnapshotSessions(client *goredis.Client, latest uint64) *snapshotSessions {
+	conf := snapshotConfig{MaxSessions: 10, TTL: time.Minute, MaxEpochLag: 10}
+
+	return newSnapshotSessions(
+		"eth", conf, client, ethSnapshotMethods, ethSnapshotLatestTags, ethSnapshotLogFilter,
+		func(ctx context.Context) (uint64, error) { return latest, nil },
+	)
+}
+
+func TestSnapshotPinParams(t *testing.T) {
+	s := newTestSnapshotSessions(nil, 100)
+
+	testCases := []struct {
+		method string
+		params string
+		expect string
+	}{
+		// omitted or latest block pinned
+		{"eth_getBalance", `["0x01"]`, `["0x01","0x64"]`},
+		{"eth_getBalance", `["0x01","latest"]`, `["0x01","0x64"]`},
+		// explicit block or other tags untouched
+		{"eth_getBalance", `["0x01","0x10"]`, `["0x01","0x10"]`},
+		{"eth_getBalance", `["0x01","pending"]`, `["0x01","pending"]`},
+		{"eth_getStorageAt", `["0x01","0x0",null]`, `["0x01","0x0","0x64"]`},
+		// methods without block param untouched
+		{"eth_getTransactionByHash", `["0x01"]`, `["0x01"]`},
+		// log filters
+		{"eth_getLogs", `[{"address":"0x01"}]`, `[{"address":"0x01","fromBlock":"0x64","toBlock":"0x64"}]`},
+		{"eth_getLogs", `[{"fromBlock":"0x10","toBlock":"latest"}]`, `[{"fromBlock":"0x10","toBlock":"0x64"}]`},
+		{"eth_getLogs", `[{"blockHash":"0x01"}]`, `[{"blockHash":"0x01"}]`},
+	}
+
+	for _, tc := range testCases {
+		params, err := s.pinParams(tc.method, json.RawMessage(tc.params), 100)
+		assert.NoError(t, err)
+		assert.JSONEq(t, tc.expect, string(params), tc.method)
+	}
+}
+
+func TestSnapshotSessionsShared(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	// sessions pinned by one instance are visible to others
+	s1, s2 := newTestSnapshotSessions(client, 100), newTestSnapshotSessions(client, 100)
+
+	sessionId, err := s1.pin(context.Background(), 100)
+	require.NoError(t, err)
+
+	epoch, ok, err := s2.get(context.Background(), sessionId)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(100), epoch)
+
+	released, err := s2.release(context.Background(), sessionId)
+	require.NoError(t, err)
+	assert.True(t, released)
+
+	_, ok, err = s1.get(context.Background(), sessionId)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// sessions expired
+	sessionId, err = s1.pin(context.Background(), 100)
+	require.NoError(t, err)
+
+	mr.FastForward(time.Minute)
+
+	_, ok, err = s2.get(context.Background(), sessionId)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestSnapshotCheckPruned(t *testing.T) {
+	s := newTestSnapshotSessions(nil, 100)
+
+	assert.NoError(t, s.checkPruned(context.Background(), 100))
+	assert.NoError(t, s.checkPruned(context.Background(), 90))
+	assert.ErrorIs(t, s.checkPruned(context.Background(), 89), errSnapshotPruned)
+}
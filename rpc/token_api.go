@@ -0,0 +1,146 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+const (
+	// max number of token transfers to return per request
+	maxTokenTransfersPerRequest = 100
+)
+
+var (
+	errTokenTransfersUnavailable = errors.New("token transfers not indexed")
+)
+
+// TokenTransferStore is implemented by store which indexes token transfers during sync.
+type TokenTransferStore interface {
+	TokenTransfersByToken(token common.Address, cursor uint64, limit int) ([]mysql.TokenTransfer, error)
+	TokenTransfersByHolder(holder common.Address, cursor uint64, limit int) ([]mysql.TokenTransfer, error)
+}
+
+// tokenTransferPage returns the cursor and page size of token transfers to query.
+func tokenTransferPage(cursor, limit *hexutil.Uint64) (uint64, int) {
+	var c uint64
+	if cursor != nil {
+		c = uint64(*cursor)
+	}
+
+	if limit == nil || *limit == 0 || *limit > maxTokenTransfersPerRequest {
+		return c, maxTokenTransfersPerRequest
+	}
+
+	return c, int(*limit)
+}
+
+// ethTokenAPI provides extension RPCs to query the ERC20, ERC721 and ERC1155 token transfers
+// indexed during sync in descending order, where the ID of the last returned transfer could be
+// used as cursor to query the next page.
+type ethTokenAPI struct {
+	store TokenTransferStore // nil if not indexed
+}
+
+// GetTransfersByToken returns the transfers of the specified token before the cursor ID if any.
+func (api *ethTokenAPI) GetTransfersByToken(
+	ctx context.Context, token common.Address, cursor, limit *hexutil.Uint64,
+) ([]mysql.TokenTransfer, error) {
+	if api.store == nil {
+		return nil, errTokenTransfersUnavailable
+	}
+
+	c, l := tokenTransferPage(cursor, limit)
+	return api.store.TokenTransfersByToken(token, c, l)
+}
+
+// GetTransfersByAddress returns the transfers from or to the specified address before the cursor
+// ID if any.
+func (api *ethTokenAPI) GetTransfersByAddress(
+	ctx context.Context, addr common.Address, cursor, limit *hexutil.Uint64,
+) ([]mysql.TokenTransfer, error) {
+	if api.store == nil {
+		return nil, errTokenTransfersUnavailable
+	}
+
+	c, l := tokenTransferPage(cursor, limit)
+	return api.store.TokenTransfersByHolder(addr, c, l)
+}
+
+// cfxTokenTransfer is the token transfer of core space with base32 addresses.
+type cfxTokenTransfer struct {
+	mysql.TokenTransfer
+	Token types.Address `json:"token"`
+	From  types.Address `json:"from"`
+	To    types.Address `json:"to"`
+}
+
+// cfxTokenAPI provides extension RPCs to query the CRC20, ERC721 and ERC1155 token transfers
+// indexed during sync in descending order, where the ID of the last returned transfer could be
+// used as cursor to query the next page.
+type cfxTokenAPI struct {
+	store TokenTransferStore // nil if not indexed
+}
+
+// GetTransfersByToken returns the transfers of the specified token before the cursor ID if any.
+func (api *cfxTokenAPI) GetTransfersByToken(
+	ctx context.Context, token types.Address, cursor, limit *hexutil.Uint64,
+) ([]cfxTokenTransfer, error) {
+	if api.store == nil {
+		return nil, errTokenTransfersUnavailable
+	}
+
+	c, l := tokenTransferPage(cursor, limit)
+	transfers, err := api.store.TokenTransfersByToken(token.MustGetCommonAddress(), c, l)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertCfxTokenTransfers(transfers, token.GetNetworkID())
+}
+
+// GetTransfersByAddress returns the transfers from or to the specified address before the cursor
+// ID if any.
+func (api *cfxTokenAPI) GetTransfersByAddress(
+	ctx context.Context, addr types.Address, cursor, limit *hexutil.Uint64,
+) ([]cfxTokenTransfer, error) {
+	if api.store == nil {
+		return nil, errTokenTransfersUnavailable
+	}
+
+	c, l := tokenTransferPage(cursor, limit)
+	transfers, err := api.store.TokenTransfersByHolder(addr.MustGetCommonAddress(), c, l)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertCfxTokenTransfers(transfers, addr.GetNetworkID())
+}
+
+func convertCfxTokenTransfers(transfers []mysql.TokenTransfer, networkId uint32) ([]cfxTokenTransfer, error) {
+	result := make([]cfxTokenTransfer, len(transfers))
+
+	for i := range transfers {
+		result[i].TokenTransfer = transfers[i]
+
+		var err error
+		if result[i].Token, err = cfxaddress.NewFromHex(transfers[i].Token, networkId); err != nil {
+			return nil, errors.WithMessage(err, "failed to convert token address")
+		}
+
+		if result[i].From, err = cfxaddress.NewFromHex(transfers[i].From, networkId); err != nil {
+			return nil, errors.WithMessage(err, "failed to convert from address")
+		}
+
+		if result[i].To, err = cfxaddress.NewFromHex(transfers[i].To, networkId); err != nil {
+			return nil, errors.WithMessage(err, "failed to convert to address")
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,179 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// max number of epochs to query from database at a time
+	exportBatchEpochs = 100
+)
+
+// Exporter dumps the indexed data (epoch to block mappings and event logs) of the specified epoch
+// range from database into compressed and checksummed segment files, which could be imported to
+// seed new deployments rather than re-sync from scratch.
+type Exporter struct {
+	space string
+	db    *mysql.MysqlStore
+	dir   string
+	// number of epochs per segment file
+	segmentEpochs uint64
+}
+
+// NewExporter creates snapshot exporter into the specified directory.
+func NewExporter(
+	space string, db *mysql.MysqlStore, disabler store.ChainDataDisabler, dir string, segmentEpochs uint64,
+) (*Exporter, error) {
+	if err := requireSnapshotSupported(disabler); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.WithMessage(err, "failed to create snapshot directory")
+	}
+
+	return &Exporter{
+		space: space, db: db, dir: dir, segmentEpochs: max(segmentEpochs, 1),
+	}, nil
+}
+
+// Export exports the specified epoch range into segment files, and returns the exported segments.
+func (e *Exporter) Export(ctx context.Context, epochFrom, epochTo uint64) ([]SegmentInfo, error) {
+	if epochFrom > epochTo {
+		return nil, errors.Errorf("invalid epoch range [%v, %v]", epochFrom, epochTo)
+	}
+
+	manifest, err := loadManifest(e.dir, e.space)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []SegmentInfo
+	for from := epochFrom; from <= epochTo; from += e.segmentEpochs {
+		select {
+		case <-ctx.Done():
+			return segments, ctx.Err()
+		default:
+		}
+
+		startTime := time.Now()
+
+		to := min(from+e.segmentEpochs-1, epochTo)
+		snapshots, err := e.snapshot(ctx, from, to)
+		if err != nil {
+			return segments, errors.WithMessagef(err, "failed to snapshot epochs [%v, %v]", from, to)
+		}
+
+		segment, err := writeSegment(e.dir, e.space, snapshots)
+		if err != nil {
+			return segments, err
+		}
+
+		if err := manifest.save(e.dir, segment); err != nil {
+			return segments, errors.WithMessage(err, "failed to save manifest")
+		}
+
+		segments = append(segments, segment)
+
+		logrus.WithFields(logrus.Fields{
+			"space":   e.space,
+			"segment": segment.File,
+			"elapsed": time.Since(startTime),
+		}).Info("Snapshot exporter exported segment")
+	}
+
+	return segments, nil
+}
+
+// snapshot loads the indexed data of the specified epoch range from database.
+func (e *Exporter) snapshot(ctx context.Context, epochFrom, epochTo uint64) ([]*EpochSnapshot, error) {
+	snapshots := make([]*EpochSnapshot, 0, epochTo-epochFrom+1)
+
+	for from := epochFrom; from <= epochTo; from += exportBatchEpochs {
+		to := min(from+exportBatchEpochs-1, epochTo)
+
+		batch, err := e.snapshotBatch(ctx, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, batch...)
+	}
+
+	return snapshots, nil
+}
+
+func (e *Exporter) snapshotBatch(ctx context.Context, epochFrom, epochTo uint64) ([]*EpochSnapshot, error) {
+	hashes, err := e.db.PivotHashes(epochFrom, epochTo)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get pivot hashes")
+	}
+
+	bnRanges, err := e.db.BlockRanges(epochFrom, epochTo)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get block ranges")
+	}
+
+	snapshots := make([]*EpochSnapshot, 0, epochTo-epochFrom+1)
+	epoch2Snapshots := make(map[uint64]*EpochSnapshot, epochTo-epochFrom+1)
+
+	for epochNo := epochFrom; epochNo <= epochTo; epochNo++ {
+		hash, ok1 := hashes[epochNo]
+		bnRange, ok2 := bnRanges[epochNo]
+		if !ok1 || !ok2 { // epoch pruned, missing or popped
+			return nil, errors.Errorf("epoch %v not found in database", epochNo)
+		}
+
+		snapshot := &EpochSnapshot{
+			Epoch: epochNo, BnMin: bnRange.From, BnMax: bnRange.To, PivotHash: hash,
+		}
+
+		snapshots = append(snapshots, snapshot)
+		epoch2Snapshots[epochNo] = snapshot
+	}
+
+	// event logs of the whole batch are exported all at once, so bound checks are unnecessary
+	logCtx, cancel := context.WithTimeout(store.NewContextWithBoundChecksDisabled(ctx), store.TimeoutGetLogs)
+	defer cancel()
+
+	logs, err := e.db.GetLogs(logCtx, store.LogFilter{
+		BlockFrom: snapshots[0].BnMin,
+		BlockTo:   snapshots[len(snapshots)-1].BnMax,
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get event logs")
+	}
+
+	for _, v := range logs {
+		snapshot, ok := epoch2Snapshots[v.Epoch]
+		if !ok { // should never happen
+			return nil, errors.Errorf("event log of unexpected epoch %v", v.Epoch)
+		}
+
+		log, ext := v.ToCfxLog()
+		snapshot.Logs = append(snapshot.Logs, &snapshotLog{BlockNumber: v.BlockNumber, Log: log, Ext: ext})
+	}
+
+	return snapshots, nil
+}
+
+// requireSnapshotSupported requires only the event logs are stored, since blocks, transactions and
+// receipts can't be rebuilt from snapshots.
+func requireSnapshotSupported(disabler store.ChainDataDisabler) error {
+	if disabler.IsChainLogDisabled() {
+		return errors.New("snapshot requires event logs to be stored")
+	}
+
+	if !disabler.IsChainBlockDisabled() || !disabler.IsChainTxnDisabled() || !disabler.IsChainReceiptDisabled() {
+		return errors.New("snapshot requires blocks, transactions and receipts not to be stored")
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package snapshot
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Importer loads the segment files of snapshot directory into database, which must be either empty
+// or continuous to the first epoch to import. Epochs already stored in database will be skipped.
+type Importer struct {
+	space string
+	db    *mysql.MysqlStore
+	dir   string
+	// max number of epochs to persist at a time
+	batchEpochs uint64
+}
+
+// NewImporter creates snapshot importer from the specified directory.
+func NewImporter(
+	space string, db *mysql.MysqlStore, disabler store.ChainDataDisabler, dir string, batchEpochs uint64,
+) (*Importer, error) {
+	if err := requireSnapshotSupported(disabler); err != nil {
+		return nil, err
+	}
+
+	return &Importer{
+		space: space, db: db, dir: dir, batchEpochs: max(batchEpochs, 1),
+	}, nil
+}
+
+// Import imports all the segment files listed in manifest, and returns the number of imported epochs.
+func (im *Importer) Import(ctx context.Context) (uint64, error) {
+	manifest, err := loadManifest(im.dir, im.space)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(manifest.Segments) == 0 {
+		return 0, errors.New("no segment found in manifest")
+	}
+
+	maxEpoch, ok, err := im.db.MaxEpoch()
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to get max epoch from database")
+	}
+
+	var numImported uint64
+	for _, segment := range manifest.Segments {
+		if ok && segment.EpochTo <= maxEpoch {
+			logrus.WithField("segment", segment.File).Info("Snapshot importer skipped already stored segment")
+			continue
+		}
+
+		startTime := time.Now()
+
+		var batch []*store.EpochData
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+
+			if err := im.db.Pushn(batch); err != nil {
+				return errors.WithMessagef(err, "failed to persist epochs [%v, %v]",
+					batch[0].Number, batch[len(batch)-1].Number,
+				)
+			}
+
+			numImported += uint64(len(batch))
+			batch = batch[:0]
+
+			return nil
+		}
+
+		err := readSegment(im.dir, segment, func(snapshot *EpochSnapshot) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
+			if ok && snapshot.Epoch <= maxEpoch {
+				return nil
+			}
+
+			batch = append(batch, snapshot.toEpochData())
+			if uint64(len(batch)) < im.batchEpochs {
+				return nil
+			}
+
+			return flush()
+		})
+
+		if err == nil {
+			err = flush()
+		}
+
+		if err != nil {
+			return numImported, errors.WithMessagef(err, "failed to import segment %v", segment.File)
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"space":   im.space,
+			"segment": segment.File,
+			"elapsed": time.Since(startTime),
+		}).Info("Snapshot importer imported segment")
+	}
+
+	return numImported, nil
+}
+
+// toEpochData rebuilds the epoch data from snapshot, which only contains blocks and transactions
+// that required to persist the epoch to block mapping and event logs. Besides, blocks and
+// transactions without any event log are omitted.
+func (s *EpochSnapshot) toEpochData() *store.EpochData {
+	bn2Logs := make(map[uint64][]*snapshotLog)
+	for _, v := range s.Logs {
+		bn2Logs[v.BlockNumber] = append(bn2Logs[v.BlockNumber], v)
+	}
+
+	// the first and pivot block are required for the spanning block range of epoch
+	bns := []uint64{s.BnMin}
+	for bn := range bn2Logs {
+		if bn != s.BnMin && bn != s.BnMax {
+			bns = append(bns, bn)
+		}
+	}
+
+	if s.BnMax != s.BnMin {
+		bns = append(bns, s.BnMax)
+	}
+
+	sort.Slice(bns, func(i, j int) bool { return bns[i] < bns[j] })
+
+	data := &store.EpochData{
+		Number:      s.Epoch,
+		Receipts:    make(map[types.Hash]*types.TransactionReceipt),
+		ReceiptExts: make(map[types.Hash]*store.ReceiptExtra),
+	}
+
+	executed := hexutil.Uint64(0)
+
+	for _, bn := range bns {
+		logs := bn2Logs[bn]
+		sort.Slice(logs, func(i, j int) bool {
+			return logs[i].Log.LogIndex.ToInt().Cmp(logs[j].Log.LogIndex.ToInt()) < 0
+		})
+
+		block := &types.Block{}
+		block.BlockNumber = types.NewBigInt(bn)
+		block.EpochNumber = types.NewBigInt(s.Epoch)
+
+		if bn == s.BnMax {
+			block.Hash = types.Hash(s.PivotHash)
+		} else if len(logs) > 0 && logs[0].Log.BlockHash != nil {
+			block.Hash = *logs[0].Log.BlockHash
+		}
+
+		for _, v := range logs {
+			var txHash types.Hash
+			if v.Log.TransactionHash != nil {
+				txHash = *v.Log.TransactionHash
+			}
+
+			receipt, ok := data.Receipts[txHash]
+			if !ok {
+				blockHash := block.Hash
+				block.Transactions = append(block.Transactions, types.Transaction{
+					Hash:      txHash,
+					BlockHash: &blockHash,
+					Status:    &executed,
+				})
+
+				receipt = &types.TransactionReceipt{
+					TransactionHash: txHash,
+					BlockHash:       blockHash,
+					OutcomeStatus:   executed,
+				}
+				data.Receipts[txHash] = receipt
+				data.ReceiptExts[txHash] = &store.ReceiptExtra{}
+			}
+
+			receipt.Logs = append(receipt.Logs, *v.Log)
+			data.ReceiptExts[txHash].LogExts = append(data.ReceiptExts[txHash].LogExts, v.Ext)
+		}
+
+		data.Blocks = append(data.Blocks, block)
+	}
+
+	return data
+}
@@ -0,0 +1,219 @@
+package snapshot
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// manifest file name within the snapshot directory
+	manifestFileName = "manifest.json"
+)
+
+var (
+	errChecksumMismatched = errors.New("segment checksum mismatched")
+)
+
+// EpochSnapshot is the indexed data of an epoch (or block for evm space) persisted in segment file.
+type EpochSnapshot struct {
+	Epoch     uint64         `json:"epoch"`
+	BnMin     uint64         `json:"bnMin"`
+	BnMax     uint64         `json:"bnMax"`
+	PivotHash string         `json:"pivotHash"`
+	Logs      []*snapshotLog `json:"logs,omitempty"`
+}
+
+type snapshotLog struct {
+	BlockNumber uint64          `json:"bn"`
+	Log         *types.Log      `json:"log"`
+	Ext         *store.LogExtra `json:"ext,omitempty"`
+}
+
+// SegmentInfo is the meta info of a segment file.
+type SegmentInfo struct {
+	File      string `json:"file"`
+	EpochFrom uint64 `json:"epochFrom"`
+	EpochTo   uint64 `json:"epochTo"`
+	// hex encoded SHA-256 checksum of the compressed segment file
+	Checksum string `json:"checksum"`
+}
+
+// Manifest describes the segment files of a snapshot directory.
+type Manifest struct {
+	Space    string        `json:"space"`
+	Segments []SegmentInfo `json:"segments"`
+}
+
+func segmentFileName(space string, epochFrom, epochTo uint64) string {
+	return fmt.Sprintf("%v-%v-%v.seg.gz", space, epochFrom, epochTo)
+}
+
+// loadManifest loads the manifest from the snapshot directory, or an empty one if not exists.
+func loadManifest(dir, space string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFileName))
+	if os.IsNotExist(err) {
+		return &Manifest{Space: space}, nil
+	}
+
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read manifest")
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, errors.WithMessage(err, "failed to unmarshal manifest")
+	}
+
+	if manifest.Space != space {
+		return nil, errors.Errorf("snapshot space mismatched, expect %v got %v", space, manifest.Space)
+	}
+
+	return &manifest, nil
+}
+
+// save adds or replaces the segment, and then saves the manifest into the snapshot directory.
+func (m *Manifest) save(dir string, segment SegmentInfo) error {
+	segments := []SegmentInfo{segment}
+	for _, v := range m.Segments {
+		if v.File != segment.File {
+			segments = append(segments, v)
+		}
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].EpochFrom < segments[j].EpochFrom
+	})
+	m.Segments = segments
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal manifest")
+	}
+
+	return writeFileAtomic(filepath.Join(dir, manifestFileName), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// writeSegment writes the epoch snapshots into a gzip compressed segment file of JSON lines.
+func writeSegment(dir, space string, snapshots []*EpochSnapshot) (SegmentInfo, error) {
+	segment := SegmentInfo{
+		File:      segmentFileName(space, snapshots[0].Epoch, snapshots[len(snapshots)-1].Epoch),
+		EpochFrom: snapshots[0].Epoch,
+		EpochTo:   snapshots[len(snapshots)-1].Epoch,
+	}
+
+	hasher := sha256.New()
+	err := writeFileAtomic(filepath.Join(dir, segment.File), func(w io.Writer) error {
+		gw := gzip.NewWriter(io.MultiWriter(w, hasher))
+		encoder := json.NewEncoder(gw)
+
+		for _, snapshot := range snapshots {
+			if err := encoder.Encode(snapshot); err != nil {
+				return err
+			}
+		}
+
+		return gw.Close()
+	})
+	if err != nil {
+		return segment, errors.WithMessage(err, "failed to write segment file")
+	}
+
+	segment.Checksum = hex.EncodeToString(hasher.Sum(nil))
+	return segment, nil
+}
+
+// readSegment verifies the checksum of segment file, and then reads the epoch snapshots one by one.
+func readSegment(dir string, segment SegmentInfo, onEpoch func(*EpochSnapshot) error) error {
+	path := filepath.Join(dir, segment.File)
+
+	if err := verifyChecksum(path, segment.Checksum); err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.WithMessage(err, "failed to open segment file")
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return errors.WithMessage(err, "failed to create gzip reader")
+	}
+	defer gr.Close()
+
+	decoder := json.NewDecoder(gr)
+	for {
+		var snapshot EpochSnapshot
+		if err := decoder.Decode(&snapshot); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.WithMessage(err, "failed to decode epoch snapshot")
+		}
+
+		if err := onEpoch(&snapshot); err != nil {
+			return err
+		}
+	}
+}
+
+func verifyChecksum(path, checksum string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.WithMessage(err, "failed to open segment file")
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return errors.WithMessage(err, "failed to read segment file")
+	}
+
+	if actual := hex.EncodeToString(hasher.Sum(nil)); actual != checksum {
+		return errors.WithMessagef(errChecksumMismatched, "expect %v got %v", checksum, actual)
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes file via a temporary file and renames it once succeeded, so that partially
+// written files are never observed.
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	tmpPath := path + ".tmp"
+
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(f)
+	if err = write(bw); err == nil {
+		err = bw.Flush()
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
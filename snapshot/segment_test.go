@@ -0,0 +1,99 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSnapshotLog(bn, logIndex uint64, blockHash, txHash types.Hash) *snapshotLog {
+	return &snapshotLog{
+		BlockNumber: bn,
+		Log: &types.Log{
+			Address:         cfxaddress.MustNewFromBase32("cfx:acckucyy5fhzknbxmeexwtaj3bxmeg25b2b50pta6v"),
+			BlockHash:       &blockHash,
+			TransactionHash: &txHash,
+			EpochNumber:     types.NewBigInt(10),
+			LogIndex:        types.NewBigInt(logIndex),
+		},
+	}
+}
+
+func TestSegmentReadWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	snapshots := []*EpochSnapshot{
+		{Epoch: 10, BnMin: 100, BnMax: 101, PivotHash: "0x01"},
+		{Epoch: 11, BnMin: 102, BnMax: 102, PivotHash: "0x02", Logs: []*snapshotLog{
+			newTestSnapshotLog(102, 0, "0x02", "0xaa"),
+		}},
+	}
+
+	segment, err := writeSegment(dir, "cfx", snapshots)
+	assert.NoError(t, err)
+	assert.Equal(t, "cfx-10-11.seg.gz", segment.File)
+	assert.Equal(t, uint64(10), segment.EpochFrom)
+	assert.Equal(t, uint64(11), segment.EpochTo)
+
+	manifest, err := loadManifest(dir, "cfx")
+	assert.NoError(t, err)
+	assert.NoError(t, manifest.save(dir, segment))
+
+	manifest, err = loadManifest(dir, "cfx")
+	assert.NoError(t, err)
+	assert.Equal(t, []SegmentInfo{segment}, manifest.Segments)
+
+	_, err = loadManifest(dir, "eth")
+	assert.Error(t, err)
+
+	var loaded []*EpochSnapshot
+	err = readSegment(dir, segment, func(s *EpochSnapshot) error {
+		loaded = append(loaded, s)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, loaded, 2)
+	assert.Equal(t, snapshots[0], loaded[0])
+	assert.Equal(t, "0xaa", loaded[1].Logs[0].Log.TransactionHash.String())
+
+	// corrupted segment file
+	path := filepath.Join(dir, segment.File)
+	assert.NoError(t, os.WriteFile(path, []byte("corrupted"), 0644))
+	err = readSegment(dir, segment, func(*EpochSnapshot) error { return nil })
+	assert.ErrorIs(t, err, errChecksumMismatched)
+}
+
+func TestEpochSnapshotToEpochData(t *testing.T) {
+	snapshot := &EpochSnapshot{
+		Epoch: 10, BnMin: 100, BnMax: 103, PivotHash: "0xff",
+		Logs: []*snapshotLog{
+			newTestSnapshotLog(103, 2, "0xff", "0xbb"),
+			newTestSnapshotLog(101, 0, "0x01", "0xaa"),
+			newTestSnapshotLog(103, 1, "0xff", "0xbb"),
+		},
+	}
+
+	data := snapshot.toEpochData()
+	assert.Equal(t, uint64(10), data.Number)
+
+	// first, pivot and blocks with event logs
+	assert.Len(t, data.Blocks, 3)
+	assert.Equal(t, uint64(100), data.Blocks[0].BlockNumber.ToInt().Uint64())
+	assert.Equal(t, types.Hash("0x01"), data.Blocks[1].Hash)
+	assert.Equal(t, types.Hash("0xff"), data.GetPivotBlock().Hash)
+	assert.Equal(t, uint64(103), data.GetPivotBlock().BlockNumber.ToInt().Uint64())
+
+	assert.Len(t, data.Blocks[0].Transactions, 0)
+	assert.Len(t, data.Blocks[1].Transactions, 1)
+	assert.Len(t, data.GetPivotBlock().Transactions, 1)
+
+	receipt := data.Receipts["0xbb"]
+	assert.Len(t, receipt.Logs, 2)
+	assert.Equal(t, uint64(1), receipt.Logs[0].LogIndex.ToInt().Uint64())
+	assert.Equal(t, uint64(2), receipt.Logs[1].LogIndex.ToInt().Uint64())
+	assert.Len(t, data.ReceiptExts["0xbb"].LogExts, 2)
+}
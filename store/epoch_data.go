@@ -8,6 +8,7 @@ import (
 	"github.com/Conflux-Chain/confura/util"
 	"github.com/Conflux-Chain/confura/util/blacklist"
 	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/confura/util/rpc"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
 	sdkerr "github.com/Conflux-Chain/go-conflux-sdk/types/errors"
@@ -48,6 +49,32 @@ func RequireContinuous(slice []*EpochData, currentEpoch uint64) error {
 	return nil
 }
 
+// RequireContinuousOrReplay checks if the epoch data slice is either continuous to the current
+// epoch, or replays some already stored epochs (eg., syncer restarts from some checkpoint after
+// crash recovery). If replayed, returns the stored epoch range since the first replayed epoch,
+// which should be replaced to keep the store continuous.
+//
+// Note, replay is accepted only if it covers the whole stored tail, so that stored epochs won't be
+// removed without being replaced, e.g., a stale writer replays some old epochs.
+func RequireContinuousOrReplay(slice []*EpochData, currentEpoch uint64) (citypes.RangeUint64, bool, error) {
+	if len(slice) == 0 || currentEpoch == citypes.EpochNumberNil || slice[0].Number > currentEpoch {
+		return citypes.EpochRangeNil, false, RequireContinuous(slice, currentEpoch)
+	}
+
+	// replayed epochs must be continuous by themselves
+	if err := RequireContinuous(slice, citypes.EpochNumberNil); err != nil {
+		return citypes.EpochRangeNil, false, err
+	}
+
+	if lastEpoch := slice[len(slice)-1].Number; lastEpoch < currentEpoch {
+		return citypes.EpochRangeNil, false, errors.WithMessagef(ErrContinousEpochRequired,
+			"Replayed epochs [%v, %v] not cover the stored tail until %v",
+			slice[0].Number, lastEpoch, currentEpoch)
+	}
+
+	return citypes.RangeUint64{From: slice[0].Number, To: currentEpoch}, true, nil
+}
+
 // EpochData wraps the blockchain data of an epoch.
 type EpochData struct {
 	Number   uint64         // epoch number
@@ -63,6 +90,22 @@ func (epoch *EpochData) GetPivotBlock() *types.Block {
 	return epoch.Blocks[len(epoch.Blocks)-1]
 }
 
+// Skeleton returns a copy of epoch data with block headers only, i.e., without transactions,
+// receipts and event logs, which keeps the store continuous if epoch data failed to persist.
+func (epoch *EpochData) Skeleton() *EpochData {
+	blocks := make([]*types.Block, 0, len(epoch.Blocks))
+	for _, block := range epoch.Blocks {
+		blocks = append(blocks, &types.Block{BlockHeader: block.BlockHeader})
+	}
+
+	return &EpochData{
+		Number:    epoch.Number,
+		Blocks:    blocks,
+		Receipts:  make(map[types.Hash]*types.TransactionReceipt),
+		BlockExts: epoch.BlockExts,
+	}
+}
+
 // IsContinuousTo checks if this epoch is continuous to the previous epoch.
 func (epoch *EpochData) IsContinuousTo(prev *EpochData) (continuous bool, desc string) {
 	lastPivot := prev.GetPivotBlock()
@@ -98,13 +141,45 @@ func QueryEpochData(cfx sdk.ClientOperator, epochNumber uint64, useBatch bool) (
 	startTime := time.Now()
 	defer metrics.Registry.Sync.QueryEpochData("cfx").UpdateSince(startTime)
 
-	data, err := queryEpochData(cfx, epochNumber, useBatch)
+	data, err := queryValidEpochData(cfx, epochNumber, useBatch)
 	metrics.Registry.Sync.QueryEpochDataAvailability("cfx").
 		Mark(err == nil || errors.Is(err, ErrEpochPivotSwitched))
 
 	return data, err
 }
 
+// queryValidEpochData queries epoch data and validates the structure before persistence, which
+// will be re-fetched if validation failed, e.g., inconsistent data returned by full node.
+func queryValidEpochData(cfx sdk.ClientOperator, epochNumber uint64, useBatch bool) (EpochData, error) {
+	for i := 0; ; i++ {
+		data, err := queryEpochData(cfx, epochNumber, useBatch)
+		if err != nil {
+			return data, err
+		}
+
+		err = data.Validate()
+		if err == nil {
+			return data, nil
+		}
+
+		nodeName := rpc.Url2NodeName(cfx.GetNodeURL())
+		metrics.Registry.Sync.EpochDataInvalid("cfx", nodeName).Mark(1)
+
+		logger := logrus.WithFields(logrus.Fields{
+			"epoch": epochNumber,
+			"node":  nodeName,
+			"retry": i,
+		}).WithError(err)
+
+		if i >= maxEpochDataRefetches {
+			logger.Error("Failed to validate epoch data from full node")
+			return emptyEpochData, err
+		}
+
+		logger.Warn("Failed to validate epoch data from full node, re-fetch again")
+	}
+}
+
 func queryEpochData(cfx sdk.ClientOperator, epochNumber uint64, useBatch bool) (EpochData, error) {
 	// Get epoch block hashes.
 	epoch := types.NewEpochNumberUint64(epochNumber)
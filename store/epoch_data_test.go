@@ -0,0 +1,99 @@
+package store
+
+import (
+	"testing"
+
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEpochDataSlice(epochFrom, epochTo uint64) []*EpochData {
+	var slice []*EpochData
+	for i := epochFrom; i <= epochTo; i++ {
+		slice = append(slice, &EpochData{Number: i})
+	}
+
+	return slice
+}
+
+func TestRequireContinuousOrReplay(t *testing.T) {
+	testCases := []struct {
+		name         string
+		slice        []*EpochData
+		currentEpoch uint64
+		expectReplay bool
+		expected     citypes.RangeUint64
+		expectErr    bool
+	}{
+		{
+			name:         "empty store",
+			slice:        newTestEpochDataSlice(10, 20),
+			currentEpoch: citypes.EpochNumberNil,
+		},
+		{
+			name:         "continuous",
+			slice:        newTestEpochDataSlice(10, 20),
+			currentEpoch: 9,
+		},
+		{
+			name:         "gap",
+			slice:        newTestEpochDataSlice(11, 20),
+			currentEpoch: 9,
+			expectErr:    true,
+		},
+		{
+			name:         "replay the last epoch after crash recovery",
+			slice:        newTestEpochDataSlice(9, 20),
+			currentEpoch: 9,
+			expectReplay: true,
+			expected:     citypes.RangeUint64{From: 9, To: 9},
+		},
+		{
+			name:         "replay from checkpoint beyond current epoch",
+			slice:        newTestEpochDataSlice(5, 20),
+			currentEpoch: 9,
+			expectReplay: true,
+			expected:     citypes.RangeUint64{From: 5, To: 9},
+		},
+		{
+			name:         "replay exactly the stored tail",
+			slice:        newTestEpochDataSlice(5, 9),
+			currentEpoch: 9,
+			expectReplay: true,
+			expected:     citypes.RangeUint64{From: 5, To: 9},
+		},
+		{
+			name:         "replay within stored epochs",
+			slice:        newTestEpochDataSlice(5, 7),
+			currentEpoch: 9,
+			expectErr:    true,
+		},
+		{
+			name:         "replay from genesis not cover the stored tail",
+			slice:        newTestEpochDataSlice(0, 3),
+			currentEpoch: 9,
+			expectErr:    true,
+		},
+		{
+			name:         "replay not continuous",
+			slice:        append(newTestEpochDataSlice(5, 7), newTestEpochDataSlice(9, 10)...),
+			currentEpoch: 9,
+			expectErr:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		replayed, isReplay, err := RequireContinuousOrReplay(tc.slice, tc.currentEpoch)
+		if tc.expectErr {
+			assert.Error(t, err, tc.name)
+			continue
+		}
+
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expectReplay, isReplay, tc.name)
+
+		if tc.expectReplay {
+			assert.Equal(t, tc.expected, replayed, tc.name)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/blacklist"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+const (
+	// max number of times to re-fetch epoch data once validation failed
+	maxEpochDataRefetches = 2
+)
+
+var (
+	ErrEpochDataInvalid = errors.New("invalid epoch data")
+)
+
+// Validate validates the structure of epoch data fetched from full node before persistence:
+//
+// 1. blocks belong to the epoch with unique block hash, and pivot block links to parent;
+// 2. receipts present for all executed transactions, and consistent with blocks;
+// 3. log indices contiguous within block and transaction, and consistent with receipts.
+func (epoch *EpochData) Validate() error {
+	if len(epoch.Blocks) == 0 {
+		return errors.WithMessage(ErrEpochDataInvalid, "no blocks")
+	}
+
+	blockHashes := make(map[types.Hash]bool, len(epoch.Blocks))
+	numExecutedTxs := 0
+
+	for _, block := range epoch.Blocks {
+		if block == nil {
+			return errors.WithMessage(ErrEpochDataInvalid, "block is nil")
+		}
+
+		if block.EpochNumber == nil || block.EpochNumber.ToInt().Uint64() != epoch.Number {
+			return errors.WithMessagef(ErrEpochDataInvalid, "block %v of another epoch", block.Hash)
+		}
+
+		if blockHashes[block.Hash] {
+			return errors.WithMessagef(ErrEpochDataInvalid, "duplicate block %v", block.Hash)
+		}
+
+		blockHashes[block.Hash] = true
+
+		n, err := epoch.validateBlockReceipts(block)
+		if err != nil {
+			return errors.WithMessagef(err, "invalid receipts of block %v", block.Hash)
+		}
+
+		numExecutedTxs += n
+	}
+
+	// parent of pivot block must be the pivot block of previous epoch
+	if pivot := epoch.GetPivotBlock(); epoch.Number > 0 {
+		if len(pivot.ParentHash) == 0 || blockHashes[pivot.ParentHash] {
+			return errors.WithMessagef(
+				ErrEpochDataInvalid, "pivot block %v not linked to parent %v", pivot.Hash, pivot.ParentHash,
+			)
+		}
+	}
+
+	if len(epoch.Receipts) != numExecutedTxs {
+		return errors.WithMessagef(
+			ErrEpochDataInvalid, "receipts mismatched, expect %v got %v", numExecutedTxs, len(epoch.Receipts),
+		)
+	}
+
+	return nil
+}
+
+// validateBlockReceipts validates the receipts and event logs of executed transactions in block,
+// and returns the number of executed transactions.
+func (epoch *EpochData) validateBlockReceipts(block *types.Block) (int, error) {
+	// event logs of blacklisted contracts are skipped, so log indices are not contiguous then
+	contiguous := !blacklist.Enabled()
+
+	var numExecutedTxs int
+	var nextLogIndex uint64
+
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+		if !util.IsTxExecutedInBlock(tx) {
+			continue
+		}
+
+		numExecutedTxs++
+
+		if *tx.BlockHash != block.Hash {
+			return 0, errors.WithMessagef(ErrEpochDataInvalid, "block hash mismatched for tx %v", tx.Hash)
+		}
+
+		receipt, ok := epoch.Receipts[tx.Hash]
+		if !ok || receipt == nil {
+			return 0, errors.WithMessagef(ErrEpochDataInvalid, "receipt missed for tx %v", tx.Hash)
+		}
+
+		if receipt.BlockHash != block.Hash || receipt.TransactionHash != tx.Hash ||
+			receipt.EpochNumber == nil || uint64(*receipt.EpochNumber) != epoch.Number {
+			return 0, errors.WithMessagef(ErrEpochDataInvalid, "receipt mismatched for tx %v", tx.Hash)
+		}
+
+		var nextTxLogIndex uint64
+		for j := range receipt.Logs {
+			log := &receipt.Logs[j]
+
+			if log.BlockHash == nil || *log.BlockHash != block.Hash ||
+				log.TransactionHash == nil || *log.TransactionHash != tx.Hash {
+				return 0, errors.WithMessagef(ErrEpochDataInvalid, "log #%v mismatched for tx %v", j, tx.Hash)
+			}
+
+			logIndex, ok1 := bigToUint64(log.LogIndex)
+			txLogIndex, ok2 := bigToUint64(log.TransactionLogIndex)
+			if !ok1 || !ok2 {
+				return 0, errors.WithMessagef(ErrEpochDataInvalid, "log #%v index missed for tx %v", j, tx.Hash)
+			}
+
+			if !isNextIndex(logIndex, nextLogIndex, contiguous) ||
+				!isNextIndex(txLogIndex, nextTxLogIndex, contiguous) {
+				return 0, errors.WithMessagef(
+					ErrEpochDataInvalid, "log #%v index not contiguous for tx %v", j, tx.Hash,
+				)
+			}
+
+			nextLogIndex, nextTxLogIndex = logIndex+1, txLogIndex+1
+		}
+	}
+
+	return numExecutedTxs, nil
+}
+
+// isNextIndex checks if the index is exactly the expected one if contiguous, otherwise not less
+// than the expected one.
+func isNextIndex(index, expected uint64, contiguous bool) bool {
+	if contiguous {
+		return index == expected
+	}
+
+	return index >= expected
+}
+
+func bigToUint64(v *hexutil.Big) (uint64, bool) {
+	if v == nil || !v.ToInt().IsUint64() {
+		return 0, false
+	}
+
+	return v.ToInt().Uint64(), true
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestValidEpochData() *EpochData {
+	blockHash, txHash := types.Hash("0xb1"), types.Hash("0xt1")
+	epochNum, status := hexutil.Uint64(10), hexutil.Uint64(0)
+
+	block := &types.Block{
+		BlockHeader: types.BlockHeader{
+			Hash:        blockHash,
+			ParentHash:  "0xb0",
+			EpochNumber: types.NewBigInt(10),
+		},
+		Transactions: []types.Transaction{{Hash: txHash, BlockHash: &blockHash, Status: &status}},
+	}
+
+	receipt := &types.TransactionReceipt{
+		TransactionHash: txHash,
+		BlockHash:       blockHash,
+		EpochNumber:     &epochNum,
+	}
+	for i := uint64(0); i < 2; i++ {
+		receipt.Logs = append(receipt.Logs, types.Log{
+			BlockHash:           &blockHash,
+			TransactionHash:     &txHash,
+			LogIndex:            types.NewBigInt(i),
+			TransactionLogIndex: types.NewBigInt(i),
+		})
+	}
+
+	return &EpochData{
+		Number:   10,
+		Blocks:   []*types.Block{block},
+		Receipts: map[types.Hash]*types.TransactionReceipt{txHash: receipt},
+	}
+}
+
+func TestEpochDataValidate(t *testing.T) {
+	assert.NoError(t, newTestValidEpochData().Validate())
+
+	testCases := []struct {
+		name   string
+		tamper func(data *EpochData)
+	}{
+		{"no blocks", func(data *EpochData) { data.Blocks = nil }},
+		{"block of another epoch", func(data *EpochData) { data.Blocks[0].EpochNumber = types.NewBigInt(11) }},
+		{"pivot parent missed", func(data *EpochData) { data.Blocks[0].ParentHash = "" }},
+		{"receipt missed", func(data *EpochData) { data.Receipts = nil }},
+		{"receipt block hash mismatched", func(data *EpochData) { data.Receipts["0xt1"].BlockHash = "0xb2" }},
+		{"log index not contiguous", func(data *EpochData) {
+			data.Receipts["0xt1"].Logs[1].LogIndex = types.NewBigInt(2)
+		}},
+		{"tx log index missed", func(data *EpochData) {
+			data.Receipts["0xt1"].Logs[0].TransactionLogIndex = nil
+		}},
+	}
+
+	for _, tc := range testCases {
+		data := newTestValidEpochData()
+		tc.tamper(data)
+		assert.ErrorIs(t, data.Validate(), ErrEpochDataInvalid, tc.name)
+	}
+}
@@ -162,6 +162,7 @@ type EthData struct {
 	Number   uint64                         // block number
 	Block    *types.Block                   // block body
 	Receipts map[common.Hash]*types.Receipt // receipts
+	Traces   []types.LocalizedTrace         // traces, only retrieved if internal transactions indexed
 }
 
 // IsContinuousTo checks if this block is continuous to the previous block.
@@ -183,6 +184,17 @@ func (current *EthData) IsContinuousTo(prev *EthData) (continuous bool, desc str
 	return true, ""
 }
 
+// QueryEthTraces retrieves the traces of the specified block, which is only available on archive
+// nodes with trace enabled.
+func QueryEthTraces(ctx context.Context, w3c *web3go.Client, blockHash common.Hash) ([]types.LocalizedTrace, error) {
+	traces, err := w3c.WithContext(ctx).Trace.Blocks(types.BlockNumberOrHashWithHash(blockHash, true))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get traces of block %v", blockHash)
+	}
+
+	return traces, nil
+}
+
 func GetBlockByBlockNumberOrHash(
 	ctx context.Context,
 	w3c *web3go.Client,
@@ -14,6 +14,8 @@ type BlockExtra struct {
 	Sha3Uncles      *common.Hash `json:"sha3Uncles,omitempty"`
 
 	TxnExts []*TransactionExtra `json:"-"`
+	// traces of ETH block, only retrieved if internal transactions indexed
+	Traces []web3Types.LocalizedTrace `json:"-"`
 }
 
 // custom transaction fields for extention
@@ -0,0 +1,346 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/sirupsen/logrus"
+)
+
+// Checkable is implemented by any store that supports health self-check.
+type Checkable interface {
+	// Ping checks the connectivity of store.
+	Ping() error
+	// ReplicationLag returns the replication lag if the store is a replica.
+	ReplicationLag() (time.Duration, bool, error)
+	// EpochGaps returns the number of missing epochs within the specified window of latest epochs.
+	EpochGaps(window uint64) (uint64, error)
+	// LastWrite returns the time of last successful write and the last write error if any.
+	LastWrite() (time.Time, error)
+}
+
+type Config struct {
+	Enabled bool
+	// HTTP endpoint to serve health status at path `/health`
+	Endpoint string `default:":22560"`
+	// Interval to run self-check
+	Interval time.Duration `default:"15s"`
+	// Max replication lag allowed if the store is a replica
+	MaxReplicationLag time.Duration `default:"1m"`
+	// Number of latest epochs to check continuity
+	GapCheckEpochs uint64 `default:"10000"`
+	// Max duration allowed without any successful write, which only applies to sync service
+	MaxWriteStaleness time.Duration `default:"5m"`
+	// Max number of epochs (or blocks) the store lags behind the chain head for readiness,
+	// disabled if zero
+	MaxSyncLag uint64
+}
+
+// Status is the self-check result of some store.
+type Status struct {
+	Healthy        bool       `json:"healthy"`
+	Errors         []string   `json:"errors,omitempty"`
+	ReplicationLag string     `json:"replicationLag,omitempty"`
+	EpochGaps      uint64     `json:"epochGaps"`
+	LastWriteAt    *time.Time `json:"lastWriteAt,omitempty"`
+	CheckedAt      time.Time  `json:"checkedAt"`
+}
+
+// Probe checks whether some dependency (eg., upstream full nodes) is available to serve requests.
+type Probe func() error
+
+// Readiness is the readiness check result of the instance.
+type Readiness struct {
+	Ready  bool              `json:"ready"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+func (s *Status) addError(msg string) {
+	s.Healthy = false
+	s.Errors = append(s.Errors, msg)
+}
+
+// Checker periodically validates the health of stores, and exposes the results via HTTP endpoint
+// and metrics, so that load balancers and operators could react to silent store degradation.
+type Checker struct {
+	config *Config
+	// whether to check recent write success, which only applies to sync service
+	checkWrites bool
+	startedAt   time.Time
+
+	mu          sync.RWMutex
+	stores      map[string]Checkable // store name => store
+	statuses    map[string]*Status   // store name => latest status
+	probes      map[string]Probe     // probe name => probe
+	probeErrors map[string]error     // probe name => latest probe error
+}
+
+// MustNewCheckerFromViper creates a store health checker if enabled.
+func MustNewCheckerFromViper(checkWrites bool) (*Checker, bool) {
+	var config Config
+	viper.MustUnmarshalKey("store.health", &config)
+
+	if !config.Enabled {
+		return nil, false
+	}
+
+	return &Checker{
+		config:      &config,
+		checkWrites: checkWrites,
+		startedAt:   time.Now(),
+		stores:      make(map[string]Checkable),
+		statuses:    make(map[string]*Status),
+		probes:      make(map[string]Probe),
+		probeErrors: make(map[string]error),
+	}, true
+}
+
+// Register registers store with the specified name to check.
+func (c *Checker) Register(name string, store Checkable) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stores[name] = store
+}
+
+// MaxSyncLag returns the max sync lag allowed for readiness, which is disabled if zero.
+func (c *Checker) MaxSyncLag() uint64 {
+	return c.config.MaxSyncLag
+}
+
+// RegisterProbe registers probe with the specified name for readiness check, which is regarded
+// as not ready until probed.
+func (c *Checker) RegisterProbe(name string, probe Probe) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.probes[name] = probe
+}
+
+// Run periodically runs self-check until context done. Be noted this function will block caller thread.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.checkAll()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Checker) checkAll() {
+	c.mu.RLock()
+	stores := make(map[string]Checkable, len(c.stores))
+	for name, store := range c.stores {
+		stores[name] = store
+	}
+	c.mu.RUnlock()
+
+	for name, store := range stores {
+		status := c.check(name, store)
+
+		if !status.Healthy {
+			logrus.WithFields(logrus.Fields{
+				"store":  name,
+				"status": status,
+			}).Warn("Store health check failed")
+		}
+
+		metrics.Registry.Store.Healthy(name).Update(boolToInt64(status.Healthy))
+		metrics.Registry.Store.EpochGaps(name).Update(int64(status.EpochGaps))
+
+		c.mu.Lock()
+		c.statuses[name] = status
+		c.mu.Unlock()
+	}
+
+	c.mu.RLock()
+	probes := make(map[string]Probe, len(c.probes))
+	for name, probe := range c.probes {
+		probes[name] = probe
+	}
+	c.mu.RUnlock()
+
+	for name, probe := range probes {
+		err := probe()
+		if err != nil {
+			logrus.WithField("probe", name).WithError(err).Warn("Readiness probe failed")
+		}
+
+		c.mu.Lock()
+		c.probeErrors[name] = err
+		c.mu.Unlock()
+	}
+}
+
+// Readiness returns whether the instance is ready to serve correct data, which requires all
+// stores healthy and all probes passed, and the instance not draining.
+func (c *Checker) Readiness() *Readiness {
+	res := Readiness{Ready: true, Errors: make(map[string]string)}
+	addError := func(name, msg string) {
+		res.Ready = false
+		res.Errors[name] = msg
+	}
+
+	if rpcutil.IsDraining() {
+		addError("shutdown", "draining")
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for name := range c.stores {
+		if status, ok := c.statuses[name]; !ok {
+			addError(name, "not checked yet")
+		} else if !status.Healthy {
+			addError(name, strings.Join(status.Errors, "; "))
+		}
+	}
+
+	for name := range c.probes {
+		if err, ok := c.probeErrors[name]; !ok {
+			addError(name, "not probed yet")
+		} else if err != nil {
+			addError(name, err.Error())
+		}
+	}
+
+	return &res
+}
+
+func (c *Checker) check(name string, store Checkable) *Status {
+	status := &Status{Healthy: true, CheckedAt: time.Now()}
+
+	// connectivity
+	if err := store.Ping(); err != nil {
+		status.addError("ping failed: " + err.Error())
+		return status
+	}
+
+	// replication lag
+	lag, isReplica, err := store.ReplicationLag()
+	switch {
+	case err != nil:
+		status.addError("failed to check replication: " + err.Error())
+	case isReplica:
+		status.ReplicationLag = lag.String()
+		metrics.Registry.Store.ReplicationLag(name).Update(lag.Milliseconds())
+
+		if lag > c.config.MaxReplicationLag {
+			status.addError("replication lag too large")
+		}
+	}
+
+	// epoch continuity
+	if gaps, err := store.EpochGaps(c.config.GapCheckEpochs); err != nil {
+		status.addError("failed to check epoch gaps: " + err.Error())
+	} else if status.EpochGaps = gaps; gaps > 0 {
+		status.addError("epoch gaps detected")
+	}
+
+	// recent writes
+	lastWriteAt, lastWriteErr := store.LastWrite()
+	if !lastWriteAt.IsZero() {
+		status.LastWriteAt = &lastWriteAt
+	}
+
+	if c.checkWrites && c.config.MaxWriteStaleness > 0 {
+		if lastWriteErr != nil {
+			status.addError("last write failed: " + lastWriteErr.Error())
+		}
+
+		lastActiveAt := lastWriteAt
+		if lastActiveAt.IsZero() {
+			lastActiveAt = c.startedAt
+		}
+
+		if time.Since(lastActiveAt) > c.config.MaxWriteStaleness {
+			status.addError("no successful write recently")
+		}
+	}
+
+	return status
+}
+
+// ServeHTTP responds with the latest status of all stores, with status code 503 if any unhealthy
+// or the instance is draining before shutdown.
+func (c *Checker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.RLock()
+	statuses := make(map[string]*Status, len(c.statuses))
+	healthy := !rpcutil.IsDraining()
+	for name, status := range c.statuses {
+		statuses[name] = status
+		healthy = healthy && status.Healthy
+	}
+	c.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	json.NewEncoder(w).Encode(statuses)
+}
+
+// serveLiveness responds OK as long as the instance is alive.
+func (c *Checker) serveLiveness(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+}
+
+// serveReadiness responds with the readiness check result, with status code 503 if not ready.
+func (c *Checker) serveReadiness(w http.ResponseWriter, r *http.Request) {
+	readiness := c.Readiness()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !readiness.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	json.NewEncoder(w).Encode(readiness)
+}
+
+// MustServe runs self-check and serves the health endpoint along with liveness (`/livez`) and
+// readiness (`/readyz`) probes in background.
+func (c *Checker) MustServe(ctx context.Context) {
+	mux := http.NewServeMux()
+	mux.Handle("/health", c)
+	mux.HandleFunc("/livez", c.serveLiveness)
+	mux.HandleFunc("/readyz", c.serveReadiness)
+
+	server := &http.Server{Addr: c.config.Endpoint, Handler: mux}
+
+	go c.Run(ctx)
+
+	go func() {
+		logrus.WithField("endpoint", c.config.Endpoint).Info("Store health endpoint started")
+
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logrus.WithError(err).Fatal("Failed to serve store health endpoint")
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+}
+
+func boolToInt64(v bool) int64 {
+	if v {
+		return 1
+	}
+
+	return 0
+}
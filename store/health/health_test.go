@@ -0,0 +1,52 @@
+package health
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type epochStore uint64
+
+func (s epochStore) MaxEpoch() (uint64, bool, error) {
+	return uint64(s), true, nil
+}
+
+func TestSyncLagProbe(t *testing.T) {
+	head := func() (uint64, error) { return 100, nil }
+
+	assert.NoError(t, NewSyncLagProbe(head, epochStore(90), 10)())
+	assert.Error(t, NewSyncLagProbe(head, epochStore(89), 10)())
+	// sync lag check disabled
+	assert.NoError(t, NewSyncLagProbe(head, nil, 0)())
+
+	unavailable := func() (uint64, error) { return 0, errors.New("connection refused") }
+	assert.Error(t, NewSyncLagProbe(unavailable, nil, 0)())
+}
+
+func TestReadiness(t *testing.T) {
+	c := &Checker{
+		config:      &Config{},
+		stores:      make(map[string]Checkable),
+		statuses:    make(map[string]*Status),
+		probes:      make(map[string]Probe),
+		probeErrors: make(map[string]error),
+	}
+
+	var probeErr error
+	c.RegisterProbe("node", func() error { return probeErr })
+
+	// not ready until probed
+	assert.False(t, c.Readiness().Ready)
+
+	c.checkAll()
+	assert.True(t, c.Readiness().Ready)
+
+	probeErr = errors.New("upstream full node unavailable")
+	c.checkAll()
+
+	readiness := c.Readiness()
+	assert.False(t, readiness.Ready)
+	assert.Equal(t, probeErr.Error(), readiness.Errors["node"])
+}
@@ -0,0 +1,40 @@
+package health
+
+import (
+	"github.com/pkg/errors"
+)
+
+// EpochStore is implemented by any store that syncs epochs (or blocks) from blockchain.
+type EpochStore interface {
+	MaxEpoch() (uint64, bool, error)
+}
+
+// NewSyncLagProbe creates a probe to check whether the upstream full nodes are available to get
+// the chain head, and the store lags behind the chain head no more than maxLag if not zero.
+func NewSyncLagProbe(head func() (uint64, error), store EpochStore, maxLag uint64) Probe {
+	return func() error {
+		headEpoch, err := head()
+		if err != nil {
+			return errors.WithMessage(err, "upstream full node unavailable")
+		}
+
+		if maxLag == 0 {
+			return nil
+		}
+
+		maxEpoch, ok, err := store.MaxEpoch()
+		if err != nil {
+			return errors.WithMessage(err, "failed to get max epoch from store")
+		}
+
+		if !ok {
+			return errors.New("no epoch synced yet")
+		}
+
+		if headEpoch > maxEpoch && headEpoch-maxEpoch > maxLag {
+			return errors.Errorf("sync lag %v exceeds threshold %v", headEpoch-maxEpoch, maxLag)
+		}
+
+		return nil
+	}
+}
@@ -3,9 +3,11 @@ package store
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util/reload"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
 	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
 	"github.com/Conflux-Chain/go-conflux-util/viper"
@@ -34,14 +36,31 @@ var ( // common errors
 	)
 )
 
-var ( // Log filter constants
-	MaxLogBlockHashesSize  int
-	MaxLogFilterAddrCount  int
-	MaxLogFilterTopicCount int
+// log filter restrictions, which are replaced as a whole on hot-reload
+var logFilterLimits atomic.Pointer[logFilterConfig]
 
-	MaxLogEpochRange uint64
-	MaxLogBlockRange uint64
-)
+func loadLogFilterLimits() logFilterConfig {
+	if lfc := logFilterLimits.Load(); lfc != nil {
+		return *lfc
+	}
+
+	return logFilterConfig{}
+}
+
+// MaxLogBlockHashesSize returns the max number of block hashes of log filter.
+func MaxLogBlockHashesSize() int { return loadLogFilterLimits().MaxBlockHashCount }
+
+// MaxLogFilterAddrCount returns the max number of contract addresses of log filter.
+func MaxLogFilterAddrCount() int { return loadLogFilterLimits().MaxAddressCount }
+
+// MaxLogFilterTopicCount returns the max number of topics per dimension of log filter.
+func MaxLogFilterTopicCount() int { return loadLogFilterLimits().MaxTopicCount }
+
+// MaxLogEpochRange returns the max epoch range of log filter to delegate to full node.
+func MaxLogEpochRange() uint64 { return loadLogFilterLimits().MaxSplitEpochRange }
+
+// MaxLogBlockRange returns the max block range of log filter to delegate to full node.
+func MaxLogBlockRange() uint64 { return loadLogFilterLimits().MaxSplitBlockRange }
 
 // Custom type for the context key to avoid collisions
 type contextKey string
@@ -133,24 +152,39 @@ func (e *SuggestedFilterOversizedError[T]) Unwrap() error {
 	return e.inner
 }
 
-func initLogFilter() {
-	var lfc struct {
-		MaxBlockHashCount int `default:"32"`
-		MaxAddressCount   int `default:"32"`
-		MaxTopicCount     int `default:"32"`
+// logFilterConfig is the hot-reloadable log filter restrictions.
+type logFilterConfig struct {
+	MaxBlockHashCount int `default:"32"`
+	MaxAddressCount   int `default:"32"`
+	MaxTopicCount     int `default:"32"`
 
-		MaxSplitEpochRange uint64 `default:"1000"`
-		MaxSplitBlockRange uint64 `default:"1000"`
+	MaxSplitEpochRange uint64 `default:"1000"`
+	MaxSplitBlockRange uint64 `default:"1000"`
+}
+
+func (lfc logFilterConfig) validate() error {
+	if lfc.MaxBlockHashCount <= 0 || lfc.MaxAddressCount <= 0 || lfc.MaxTopicCount <= 0 {
+		return errors.New("max block hash, address and topic count must be positive")
 	}
 
-	viper.MustUnmarshalKey("requestControl.logfilter", &lfc)
+	if lfc.MaxSplitEpochRange == 0 || lfc.MaxSplitBlockRange == 0 {
+		return errors.New("max split epoch and block range must be positive")
+	}
+
+	return nil
+}
+
+// apply applies the restrictions atomically, since they are read by RPC handlers concurrently.
+func (lfc logFilterConfig) apply() {
+	logFilterLimits.Store(&lfc)
+}
 
-	MaxLogBlockHashesSize = lfc.MaxBlockHashCount
-	MaxLogFilterAddrCount = lfc.MaxAddressCount
-	MaxLogFilterTopicCount = lfc.MaxTopicCount
+func initLogFilter() {
+	var lfc logFilterConfig
+	viper.MustUnmarshalKey("requestControl.logfilter", &lfc)
+	lfc.apply()
 
-	MaxLogEpochRange = lfc.MaxSplitEpochRange
-	MaxLogBlockRange = lfc.MaxSplitBlockRange
+	reload.Register("requestControl.logfilter", lfc, logFilterConfig.validate, logFilterConfig.apply)
 }
 
 type LogFilter struct {
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogFilterLimitsHotReload(t *testing.T) {
+	origin := logFilterLimits.Load()
+	t.Cleanup(func() { logFilterLimits.Store(origin) })
+
+	lfc := logFilterConfig{
+		MaxBlockHashCount: 1, MaxAddressCount: 1, MaxTopicCount: 1, MaxSplitEpochRange: 1, MaxSplitBlockRange: 1,
+	}
+	lfc.apply()
+
+	// restrictions read by RPC handlers while hot-reloaded, which should be race free (go test -race)
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 2; i < 100; i++ {
+			logFilterConfig{
+				MaxBlockHashCount:  i,
+				MaxAddressCount:    i,
+				MaxTopicCount:      i,
+				MaxSplitEpochRange: uint64(i),
+				MaxSplitBlockRange: uint64(i),
+			}.apply()
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 100; i++ {
+			assert.Positive(t, MaxLogBlockHashesSize())
+			assert.Positive(t, MaxLogFilterAddrCount())
+			assert.Positive(t, MaxLogFilterTopicCount())
+			assert.Positive(t, MaxLogEpochRange())
+			assert.Positive(t, MaxLogBlockRange())
+		}
+	}()
+
+	wg.Wait()
+
+	assert.Equal(t, 99, MaxLogBlockHashesSize())
+	assert.Equal(t, uint64(99), MaxLogBlockRange())
+}
@@ -0,0 +1,362 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/Conflux-Chain/confura/store"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+)
+
+// NearHeadStore is an in-memory store to cache the latest epoch data near the chain head, which
+// are not persisted into database yet due to high possibility of being reverted by pivot switch.
+type NearHeadStore struct {
+	mu sync.RWMutex
+
+	// epoch range of the cached epoch data
+	epochFrom, epochTo uint64
+	epochs             map[uint64]*store.EpochData
+
+	// indices for fast lookup
+	blocksByHash   map[types.Hash]*types.Block
+	blocksByNumber map[uint64]*types.Block
+	txs            map[types.Hash]*types.Transaction
+	receipts       map[types.Hash]*types.TransactionReceipt
+}
+
+// NewNearHeadStore creates an empty in-memory near-head store.
+func NewNearHeadStore() *NearHeadStore {
+	s := &NearHeadStore{}
+	s.reset()
+
+	return s
+}
+
+// Reset removes all the cached epoch data.
+func (s *NearHeadStore) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reset()
+}
+
+func (s *NearHeadStore) reset() {
+	s.epochFrom, s.epochTo = citypes.EpochNumberNil, citypes.EpochNumberNil
+	s.epochs = make(map[uint64]*store.EpochData)
+	s.blocksByHash = make(map[types.Hash]*types.Block)
+	s.blocksByNumber = make(map[uint64]*types.Block)
+	s.txs = make(map[types.Hash]*types.Transaction)
+	s.receipts = make(map[types.Hash]*types.TransactionReceipt)
+}
+
+// EpochRange returns the epoch range of cached epoch data, or false if empty.
+func (s *NearHeadStore) EpochRange() (citypes.RangeUint64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.epochs) == 0 {
+		return citypes.EpochRangeNil, false
+	}
+
+	return citypes.RangeUint64{From: s.epochFrom, To: s.epochTo}, true
+}
+
+// LatestEpochData returns the latest cached epoch data, or nil if empty.
+func (s *NearHeadStore) LatestEpochData() *store.EpochData {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.epochs[s.epochTo]
+}
+
+// BlockRange returns the block number range of the specified epoch, or false if not cached.
+func (s *NearHeadStore) BlockRange(epoch uint64) (citypes.RangeUint64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.epochs[epoch]
+	if !ok || data.Blocks[0].BlockNumber == nil || data.GetPivotBlock().BlockNumber == nil {
+		return citypes.RangeUint64{}, false
+	}
+
+	return citypes.RangeUint64{
+		From: data.Blocks[0].BlockNumber.ToInt().Uint64(),
+		To:   data.GetPivotBlock().BlockNumber.ToInt().Uint64(),
+	}, true
+}
+
+// Pushn appends continuous epoch data into the store.
+func (s *NearHeadStore) Pushn(dataSlice []*store.EpochData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := store.RequireContinuous(dataSlice, s.epochTo); err != nil {
+		return err
+	}
+
+	for _, data := range dataSlice {
+		if len(s.epochs) == 0 {
+			s.epochFrom = data.Number
+		}
+
+		s.epochTo = data.Number
+		s.epochs[data.Number] = data
+
+		for _, block := range data.Blocks {
+			s.blocksByHash[block.Hash] = block
+			if block.BlockNumber != nil {
+				s.blocksByNumber[block.BlockNumber.ToInt().Uint64()] = block
+			}
+
+			for i := range block.Transactions {
+				tx := &block.Transactions[i]
+				if receipt, ok := data.Receipts[tx.Hash]; ok && receipt.BlockHash == block.Hash {
+					s.txs[tx.Hash], s.receipts[tx.Hash] = tx, receipt
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Popn removes epoch data since the specified epoch (inclusive), e.g., due to pivot switch.
+func (s *NearHeadStore) Popn(epochFrom uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.epochs) == 0 || epochFrom > s.epochTo {
+		return
+	}
+
+	for epoch := max(epochFrom, s.epochFrom); epoch <= s.epochTo; epoch++ {
+		s.remove(epoch)
+	}
+
+	if epochFrom <= s.epochFrom {
+		s.reset()
+	} else {
+		s.epochTo = epochFrom - 1
+	}
+}
+
+// Dequeue removes epoch data until the specified epoch (inclusive), e.g., already persisted
+// into database.
+func (s *NearHeadStore) Dequeue(epochUntil uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.epochs) == 0 || epochUntil < s.epochFrom {
+		return
+	}
+
+	for epoch := s.epochFrom; epoch <= min(epochUntil, s.epochTo); epoch++ {
+		s.remove(epoch)
+	}
+
+	if epochUntil >= s.epochTo {
+		s.reset()
+	} else {
+		s.epochFrom = epochUntil + 1
+	}
+}
+
+func (s *NearHeadStore) remove(epoch uint64) {
+	data, ok := s.epochs[epoch]
+	if !ok {
+		return
+	}
+
+	for _, block := range data.Blocks {
+		delete(s.blocksByHash, block.Hash)
+		if block.BlockNumber != nil {
+			delete(s.blocksByNumber, block.BlockNumber.ToInt().Uint64())
+		}
+
+		for i := range block.Transactions {
+			if tx, ok := s.txs[block.Transactions[i].Hash]; ok && tx == &block.Transactions[i] {
+				delete(s.txs, tx.Hash)
+				delete(s.receipts, tx.Hash)
+			}
+		}
+	}
+
+	delete(s.epochs, epoch)
+}
+
+// implements `store.Readable` interface
+
+func (s *NearHeadStore) GetLogs(ctx context.Context, filter store.LogFilter) ([]*store.Log, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var logs []*store.Log
+	for epoch := s.epochFrom; len(s.epochs) > 0 && epoch <= s.epochTo; epoch++ {
+		data := s.epochs[epoch]
+
+		for _, block := range data.Blocks {
+			if block.BlockNumber == nil {
+				continue
+			}
+
+			bn := block.BlockNumber.ToInt().Uint64()
+			if bn < filter.BlockFrom || bn > filter.BlockTo {
+				continue
+			}
+
+			for _, tx := range block.Transactions {
+				receipt, ok := data.Receipts[tx.Hash]
+				if !ok || receipt.BlockHash != block.Hash {
+					continue
+				}
+
+				for i := range receipt.Logs {
+					if matchLogFilter(&receipt.Logs[i], &filter) {
+						logs = append(logs, store.ParseCfxLog(&receipt.Logs[i], 0, bn, nil))
+					}
+				}
+			}
+		}
+	}
+
+	if store.IsBoundChecksEnabled(ctx) && uint64(len(logs)) > store.MaxLogLimit {
+		return nil, store.ErrFilterResultSetTooLarge
+	}
+
+	return logs, nil
+}
+
+func matchLogFilter(log *types.Log, filter *store.LogFilter) bool {
+	if !filter.Contracts.IsNull() && !containsFold(filter.Contracts.ToSlice(), log.Address.String()) {
+		return false
+	}
+
+	for i := range filter.Topics {
+		if filter.Topics[i].IsNull() {
+			continue
+		}
+
+		if i >= len(log.Topics) || !containsFold(filter.Topics[i].ToSlice(), log.Topics[i].String()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *NearHeadStore) GetTransaction(ctx context.Context, txHash types.Hash) (*store.Transaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tx, ok := s.txs[txHash]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	return &store.Transaction{CfxTransaction: tx}, nil
+}
+
+func (s *NearHeadStore) GetReceipt(ctx context.Context, txHash types.Hash) (*store.TransactionReceipt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	receipt, ok := s.receipts[txHash]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	return &store.TransactionReceipt{CfxReceipt: receipt}, nil
+}
+
+func (s *NearHeadStore) GetBlocksByEpoch(ctx context.Context, epochNumber uint64) ([]types.Hash, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.epochs[epochNumber]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	hashes := make([]types.Hash, 0, len(data.Blocks))
+	for _, block := range data.Blocks {
+		hashes = append(hashes, block.Hash)
+	}
+
+	return hashes, nil
+}
+
+func (s *NearHeadStore) GetBlockByEpoch(ctx context.Context, epochNumber uint64) (*store.Block, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.epochs[epochNumber]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	return &store.Block{CfxBlock: data.GetPivotBlock()}, nil
+}
+
+func (s *NearHeadStore) GetBlockSummaryByEpoch(ctx context.Context, epochNumber uint64) (*store.BlockSummary, error) {
+	block, err := s.GetBlockByEpoch(ctx, epochNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return &store.BlockSummary{CfxBlockSummary: util.GetSummaryOfBlock(block.CfxBlock)}, nil
+}
+
+func (s *NearHeadStore) GetBlockByHash(ctx context.Context, blockHash types.Hash) (*store.Block, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	block, ok := s.blocksByHash[blockHash]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	return &store.Block{CfxBlock: block}, nil
+}
+
+func (s *NearHeadStore) GetBlockSummaryByHash(ctx context.Context, blockHash types.Hash) (*store.BlockSummary, error) {
+	block, err := s.GetBlockByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &store.BlockSummary{CfxBlockSummary: util.GetSummaryOfBlock(block.CfxBlock)}, nil
+}
+
+func (s *NearHeadStore) GetBlockByBlockNumber(ctx context.Context, blockNumber uint64) (*store.Block, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	block, ok := s.blocksByNumber[blockNumber]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	return &store.Block{CfxBlock: block}, nil
+}
+
+func (s *NearHeadStore) GetBlockSummaryByBlockNumber(ctx context.Context, blockNumber uint64) (*store.BlockSummary, error) {
+	block, err := s.GetBlockByBlockNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return &store.BlockSummary{CfxBlockSummary: util.GetSummaryOfBlock(block.CfxBlock)}, nil
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEpochData(epoch uint64) *store.EpochData {
+	blockHash := types.Hash(fmt.Sprintf("0x%064x", epoch))
+	txHash := types.Hash(fmt.Sprintf("0x%064x", epoch+1000))
+
+	block := &types.Block{
+		BlockHeader: types.BlockHeader{
+			Hash:        blockHash,
+			EpochNumber: types.NewBigInt(epoch),
+			BlockNumber: types.NewBigInt(epoch),
+		},
+		Transactions: []types.Transaction{{Hash: txHash}},
+	}
+
+	return &store.EpochData{
+		Number: epoch,
+		Blocks: []*types.Block{block},
+		Receipts: map[types.Hash]*types.TransactionReceipt{
+			txHash: {
+				TransactionHash: txHash,
+				BlockHash:       blockHash,
+				Logs: []types.Log{{
+					EpochNumber: types.NewBigInt(epoch),
+					LogIndex:    types.NewBigInt(0),
+				}},
+			},
+		},
+	}
+}
+
+func TestNearHeadStorePushAndEvict(t *testing.T) {
+	s := NewNearHeadStore()
+
+	_, ok := s.EpochRange()
+	assert.False(t, ok)
+
+	assert.NoError(t, s.Pushn([]*store.EpochData{newTestEpochData(10), newTestEpochData(11), newTestEpochData(12)}))
+	assert.Error(t, s.Pushn([]*store.EpochData{newTestEpochData(14)}))
+
+	epochRange, ok := s.EpochRange()
+	assert.True(t, ok)
+	assert.Equal(t, citypes.RangeUint64{From: 10, To: 12}, epochRange)
+
+	logs, err := s.GetLogs(context.Background(), store.LogFilter{BlockFrom: 11, BlockTo: 20})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 2)
+
+	// evict persisted epochs
+	s.Dequeue(10)
+	epochRange, _ = s.EpochRange()
+	assert.Equal(t, citypes.RangeUint64{From: 11, To: 12}, epochRange)
+
+	_, err = s.GetBlockByEpoch(context.Background(), 10)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	// revert due to pivot switch
+	s.Popn(12)
+	epochRange, _ = s.EpochRange()
+	assert.Equal(t, citypes.RangeUint64{From: 11, To: 11}, epochRange)
+
+	_, err = s.GetReceipt(context.Background(), types.Hash(fmt.Sprintf("0x%064x", 1012)))
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	receipt, err := s.GetReceipt(context.Background(), types.Hash(fmt.Sprintf("0x%064x", 1011)))
+	assert.NoError(t, err)
+	assert.NotNil(t, receipt.CfxReceipt)
+
+	s.Dequeue(11)
+	_, ok = s.EpochRange()
+	assert.False(t, ok)
+}
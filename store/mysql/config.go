@@ -26,6 +26,7 @@ var allModels = []interface{}{
 	&epochBlockMap{},
 	&bnPartition{},
 	&NodeRoute{},
+	&coldSegment{},
 	&dlock.Dlock{},
 }
 
@@ -48,6 +49,16 @@ type Config struct {
 	AddressIndexedLogPartitions uint32 `default:"100"`
 
 	MaxBnRangedArchiveLogPartitions uint32 `default:"5"`
+
+	// whether to index token transfers (ERC20/CRC20, ERC721 and ERC1155) during sync
+	TokenTransferIndexEnabled bool
+
+	// whether to index internal transactions from traces during sync, evm space only
+	InternalTxIndexEnabled bool
+
+	Tiering TieringConfig
+
+	AddressBloom AddressBloomConfig
 }
 
 func mustNewConfigFromViper(key string) *Config {
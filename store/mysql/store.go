@@ -8,16 +8,25 @@ import (
 
 	"github.com/Conflux-Chain/confura/store"
 	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util/logging"
 	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/confura/util/slowlog"
+	"github.com/Conflux-Chain/confura/util/tracing"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 	"gorm.io/gorm"
 )
 
 var (
-	_ store.Readable      = (*MysqlStore)(nil)
-	_ store.StackOperable = (*MysqlStore)(nil)
-	_ store.Configurable  = (*MysqlStore)(nil)
-	_ io.Closer           = (*MysqlStore)(nil)
+	_ store.Readable             = (*MysqlStore)(nil)
+	_ store.StackOperable        = (*MysqlStore)(nil)
+	_ store.Configurable         = (*MysqlStore)(nil)
+	_ store.AccountTxnReadable   = (*MysqlStore)(nil)
+	_ store.EpochReceiptReadable = (*MysqlStore)(nil)
+	_ store.LogPageReadable      = (*MysqlStore)(nil)
+	_ store.BlockRangeReadable   = (*MysqlStore)(nil)
+	_ io.Closer                  = (*MysqlStore)(nil)
 )
 
 type StoreOption struct {
@@ -35,6 +44,15 @@ type MysqlStore struct {
 	*RateLimitStore
 	*VirtualFilterLogStore
 	*NodeRouteStore
+	*checkpointStore
+	*reorgStore
+	*deadLetterStore
+	*WebhookStore
+	*UsageStore
+	*AbiStore
+	*TokenTransferStore
+	*InternalTxStore
+	*addressBloomStore
 	ls   *logStore
 	ails *AddressIndexedLogStore
 	bcls *bigContractLogStore
@@ -46,6 +64,14 @@ type MysqlStore struct {
 	disabler store.ChainDataDisabler
 	// store pruner
 	pruner *storePruner
+	// cold store for tiering, nil if disabled
+	cold *coldStore
+	// stats of recent writes for health check
+	writeStats writeStats
+	// statement to show replication status for health check
+	replication replicationStatus
+	// observers notified of committed epoch data changes
+	observers []store.EpochDataObserver
 }
 
 func mustNewStore(db *gorm.DB, config *Config, option StoreOption) *MysqlStore {
@@ -54,16 +80,30 @@ func mustNewStore(db *gorm.DB, config *Config, option StoreOption) *MysqlStore {
 	ebms := newEpochBlockMapStore(db, config)
 	ails := NewAddressIndexedLogStore(db, cs, config.AddressIndexedLogPartitions)
 
+	var cold *coldStore
+	if config.Tiering.Enabled {
+		cold = mustNewColdStore(db, &config.Tiering)
+	}
+
 	return &MysqlStore{
 		baseStore:             newBaseStore(db),
 		epochBlockMapStore:    ebms,
-		txStore:               newTxStore(db),
-		blockStore:            newBlockStore(db),
+		txStore:               newTxStore(db, cold),
+		blockStore:            newBlockStore(db, cold),
 		confStore:             newConfStore(db),
 		UserStore:             newUserStore(db),
-		RateLimitStore:        NewRateLimitStore(db),
+		RateLimitStore:        MustNewRateLimitStore(db),
 		VirtualFilterLogStore: NewVirtualFilterLogStore(db),
 		NodeRouteStore:        NewNodeRouteStore(db),
+		checkpointStore:       mustNewCheckpointStore(db),
+		reorgStore:            mustNewReorgStore(db),
+		deadLetterStore:       mustNewDeadLetterStore(db),
+		WebhookStore:          mustNewWebhookStore(db),
+		UsageStore:            mustNewUsageStore(db),
+		AbiStore:              mustNewAbiStore(db),
+		TokenTransferStore:    mustNewTokenTransferStore(db),
+		InternalTxStore:       mustNewInternalTxStore(db, config.InternalTxIndexEnabled),
+		addressBloomStore:     mustNewAddressBloomStore(db, config.AddressBloom),
 		ls:                    newLogStore(db, cs, ebms, pruner.newBnPartitionObsChan),
 		bcls:                  newBigContractLogStore(db, cs, ebms, ails, pruner.newBnPartitionObsChan),
 		ails:                  ails,
@@ -71,7 +111,28 @@ func mustNewStore(db *gorm.DB, config *Config, option StoreOption) *MysqlStore {
 		config:                config,
 		disabler:              option.Disabler,
 		pruner:                pruner,
+		cold:                  cold,
+	}
+}
+
+// GetEpochReceipts returns receipts of all executed transactions within the epoch.
+func (ms *MysqlStore) GetEpochReceipts(ctx context.Context, epochNumber uint64) ([]*store.TransactionReceipt, error) {
+	// make sure epoch synced, otherwise empty receipts are ambiguous
+	_, ok, err := ms.BlockRange(epochNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	// receipts of parked epoch not persisted
+	if err := ms.requireEpochNotParked(epochNumber); err != nil {
+		return nil, err
 	}
+
+	return ms.txStore.getEpochReceipts(ctx, epochNumber)
 }
 
 func (ms *MysqlStore) Push(data *store.EpochData) error {
@@ -88,6 +149,13 @@ func (ms *MysqlStore) PushnWithFinalizer(dataSlice []*store.EpochData, finalizer
 		return nil
 	}
 
+	err := ms.pushn(dataSlice, finalizer)
+	ms.writeStats.record(err)
+
+	return err
+}
+
+func (ms *MysqlStore) pushn(dataSlice []*store.EpochData, finalizer func(*gorm.DB) error) error {
 	storeMaxEpoch, ok, err := ms.MaxEpoch()
 	if err != nil {
 		return err
@@ -97,10 +165,24 @@ func (ms *MysqlStore) PushnWithFinalizer(dataSlice []*store.EpochData, finalizer
 		storeMaxEpoch = citypes.EpochNumberNil
 	}
 
-	if err := store.RequireContinuous(dataSlice, storeMaxEpoch); err != nil {
+	// replayed epochs (eg., after crash recovery) will be replaced to make writes idempotent
+	replayed, isReplay, err := store.RequireContinuousOrReplay(dataSlice, storeMaxEpoch)
+	if err != nil {
 		return err
 	}
 
+	var dataChanged bool
+	if isReplay {
+		if dataChanged, err = ms.isReplayChanged(dataSlice, replayed); err != nil {
+			return errors.WithMessage(err, "failed to check replayed epochs")
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"replayed":    replayed,
+			"dataChanged": dataChanged,
+		}).Info("Replaying already stored epochs into db")
+	}
+
 	startTime := time.Now()
 	defer metrics.Registry.Store.Push("mysql").UpdateSince(startTime)
 
@@ -136,7 +218,21 @@ func (ms *MysqlStore) PushnWithFinalizer(dataSlice []*store.EpochData, finalizer
 		return errors.New("failed to prepare epoch block map partition")
 	}
 
-	return ms.baseStore.db.Transaction(func(dbTx *gorm.DB) error {
+	err = ms.baseStore.db.Transaction(func(dbTx *gorm.DB) error {
+		if isReplay {
+			// remove the replayed epochs at first
+			if err := ms.removeWithTx(dbTx, replayed.From, replayed.To); err != nil {
+				return errors.WithMessage(err, "failed to remove replayed epochs")
+			}
+
+			// update reorg version if stored data changed
+			if dataChanged {
+				if err := ms.confStore.createOrUpdateReorgVersion(dbTx); err != nil {
+					return errors.WithMessage(err, "failed to update reorg version")
+				}
+			}
+		}
+
 		if !ms.disabler.IsChainBlockDisabled() {
 			// save blocks
 			if err := ms.blockStore.Add(dbTx, dataSlice); err != nil {
@@ -177,6 +273,20 @@ func (ms *MysqlStore) PushnWithFinalizer(dataSlice []*store.EpochData, finalizer
 			if err := ms.ls.Add(dbTx, dataSlice, logPartition); err != nil {
 				return errors.WithMessage(err, "failed to save event logs")
 			}
+
+			// save token transfers
+			if ms.config.TokenTransferIndexEnabled {
+				if err := ms.TokenTransferStore.add(dbTx, dataSlice); err != nil {
+					return errors.WithMessage(err, "failed to save token transfers")
+				}
+			}
+		}
+
+		// save internal transactions
+		if ms.config.InternalTxIndexEnabled {
+			if err := ms.InternalTxStore.add(dbTx, dataSlice); err != nil {
+				return errors.WithMessage(err, "failed to save internal transactions")
+			}
 		}
 
 		// save epoch to block mapping data
@@ -184,12 +294,81 @@ func (ms *MysqlStore) PushnWithFinalizer(dataSlice []*store.EpochData, finalizer
 			return errors.WithMessage(err, "failed to save epoch to block mapping data")
 		}
 
+		// save address blooms
+		if err := ms.addressBloomStore.add(dbTx, dataSlice); err != nil {
+			return errors.WithMessage(err, "failed to save address blooms")
+		}
+
+		// advance sync checkpoint along with epoch data
+		lastEpoch := dataSlice[len(dataSlice)-1]
+		err := ms.checkpointStore.saveCheckpoint(dbTx, lastEpoch.Number, lastEpoch.GetPivotBlock().Hash.String())
+		if err != nil {
+			return errors.WithMessage(err, "failed to save sync checkpoint")
+		}
+
 		if finalizer != nil {
 			return finalizer(dbTx)
 		}
 
 		return nil
 	})
+
+	if err == nil {
+		if isReplay && dataChanged {
+			ms.notifyEpochsPopped(replayed.From)
+		}
+
+		ms.notifyEpochsPushed(dataSlice)
+	}
+
+	return err
+}
+
+// IsTokenTransferIndexed returns whether token transfers are indexed during sync.
+func (ms *MysqlStore) IsTokenTransferIndexed() bool {
+	return ms.config.TokenTransferIndexEnabled
+}
+
+// IsInternalTxIndexed returns whether internal transactions are indexed during sync.
+func (ms *MysqlStore) IsInternalTxIndexed() bool {
+	return ms.config.InternalTxIndexEnabled
+}
+
+// AddEpochDataObserver adds an observer to be notified once epoch data committed into or
+// reverted from database, which is not thread safe and should be called during initialization.
+func (ms *MysqlStore) AddEpochDataObserver(observer store.EpochDataObserver) {
+	ms.observers = append(ms.observers, observer)
+}
+
+func (ms *MysqlStore) notifyEpochsPushed(dataSlice []*store.EpochData) {
+	for _, obs := range ms.observers {
+		obs.OnEpochsPushed(dataSlice)
+	}
+}
+
+func (ms *MysqlStore) notifyEpochsPopped(epochFrom uint64) {
+	for _, obs := range ms.observers {
+		obs.OnEpochsPopped(epochFrom)
+	}
+}
+
+// isReplayChanged checks if the replayed epochs differ from those stored, either because of
+// pivot chain switched or the stored epochs will be truncated by replay.
+func (ms *MysqlStore) isReplayChanged(
+	dataSlice []*store.EpochData, replayed citypes.RangeUint64,
+) (bool, error) {
+	pivotHash, ok, err := ms.PivotHash(replayed.To)
+	if err != nil || !ok {
+		return true, err
+	}
+
+	for _, data := range dataSlice {
+		if data.Number == replayed.To {
+			return data.GetPivotBlock().Hash.String() != pivotHash, nil
+		}
+	}
+
+	return true, nil
 }
 
 // Popn pops multiple epoch data from database.
@@ -211,62 +390,124 @@ func (ms *MysqlStore) PopnWithFinalizer(epochUntil uint64, finalizer func(*gorm.
 	startTime := time.Now()
 	defer metrics.Registry.Store.Pop("mysql").UpdateSince(startTime)
 
-	return ms.baseStore.db.Transaction(func(dbTx *gorm.DB) error {
-		if !ms.disabler.IsChainBlockDisabled() {
-			// remove blocks
-			if err := ms.blockStore.Remove(dbTx, epochUntil, maxEpoch); err != nil {
-				return errors.WithMessage(err, "failed to remove blocks")
-			}
+	err = ms.baseStore.db.Transaction(func(dbTx *gorm.DB) error {
+		if err := ms.removeWithTx(dbTx, epochUntil, maxEpoch); err != nil {
+			return err
 		}
 
-		skipTxn := ms.disabler.IsChainTxnDisabled()
-		skipRcpt := ms.disabler.IsChainReceiptDisabled()
-		if !skipRcpt || !skipTxn {
-			// remove transactions or receipts
-			if err := ms.txStore.Remove(dbTx, epochUntil, maxEpoch); err != nil {
-				return errors.WithMessage(err, "failed to remove transactions")
-			}
+		// rewind sync checkpoint along with epoch data
+		if err := ms.rewindCheckpoint(dbTx, epochUntil); err != nil {
+			return errors.WithMessage(err, "failed to rewind sync checkpoint")
 		}
 
-		if !ms.disabler.IsChainLogDisabled() {
-			// remove address indexed event logs
-			if ms.config.AddressIndexedLogEnabled {
-				if err := ms.ails.DeleteAddressIndexedLogs(dbTx, epochUntil, maxEpoch); err != nil {
-					return errors.WithMessage(err, "failed to remove address indexed event logs")
-				}
+		// pop is always due to pivot chain switch, update reorg version too
+		if err := ms.confStore.createOrUpdateReorgVersion(dbTx); err != nil {
+			return errors.WithMessage(err, "failed to update reorg version")
+		}
 
-				if err := ms.bcls.Popn(dbTx, epochUntil); err != nil {
-					return errors.WithMessage(err, "failed to remove big contract logs")
-				}
+		if finalizer != nil {
+			return finalizer(dbTx)
+		}
+
+		return nil
+	})
+
+	if err == nil {
+		ms.notifyEpochsPopped(epochUntil)
+	}
+
+	return err
+}
+
+// removeWithTx removes epoch data of the specified epoch range, which must be the latest epochs
+// in store, within the database transaction.
+func (ms *MysqlStore) removeWithTx(dbTx *gorm.DB, epochFrom, epochTo uint64) error {
+	if !ms.disabler.IsChainBlockDisabled() {
+		// remove blocks
+		if err := ms.blockStore.Remove(dbTx, epochFrom, epochTo); err != nil {
+			return errors.WithMessage(err, "failed to remove blocks")
+		}
+	}
+
+	skipTxn := ms.disabler.IsChainTxnDisabled()
+	skipRcpt := ms.disabler.IsChainReceiptDisabled()
+	if !skipRcpt || !skipTxn {
+		// remove transactions or receipts
+		if err := ms.txStore.Remove(dbTx, epochFrom, epochTo); err != nil {
+			return errors.WithMessage(err, "failed to remove transactions")
+		}
+	}
+
+	if !ms.disabler.IsChainLogDisabled() {
+		// remove address indexed event logs
+		if ms.config.AddressIndexedLogEnabled {
+			if err := ms.ails.DeleteAddressIndexedLogs(dbTx, epochFrom, epochTo); err != nil {
+				return errors.WithMessage(err, "failed to remove address indexed event logs")
 			}
 
-			// pop universal event logs
-			if err := ms.ls.Popn(dbTx, epochUntil); err != nil {
-				return errors.WithMessage(err, "failed to remove universal event logs")
+			if err := ms.bcls.Popn(dbTx, epochFrom); err != nil {
+				return errors.WithMessage(err, "failed to remove big contract logs")
 			}
 		}
 
-		// remove epoch to block mapping data
-		if err := ms.epochBlockMapStore.Remove(dbTx, epochUntil, maxEpoch); err != nil {
-			return errors.WithMessage(err, "failed to remove epoch to block mapping data")
+		// pop universal event logs
+		if err := ms.ls.Popn(dbTx, epochFrom); err != nil {
+			return errors.WithMessage(err, "failed to remove universal event logs")
 		}
 
-		// pop is always due to pivot chain switch, update reorg version too
-		if err := ms.confStore.createOrUpdateReorgVersion(dbTx); err != nil {
-			return errors.WithMessage(err, "failed to update reorg version")
+		// remove token transfers
+		if ms.config.TokenTransferIndexEnabled {
+			if err := ms.TokenTransferStore.remove(dbTx, epochFrom, epochTo); err != nil {
+				return errors.WithMessage(err, "failed to remove token transfers")
+			}
 		}
+	}
 
-		if finalizer != nil {
-			return finalizer(dbTx)
+	// remove internal transactions
+	if ms.config.InternalTxIndexEnabled {
+		if err := ms.InternalTxStore.remove(dbTx, epochFrom, epochTo); err != nil {
+			return errors.WithMessage(err, "failed to remove internal transactions")
 		}
+	}
 
-		return nil
-	})
+	// remove epoch to block mapping data
+	if err := ms.epochBlockMapStore.Remove(dbTx, epochFrom, epochTo); err != nil {
+		return errors.WithMessage(err, "failed to remove epoch to block mapping data")
+	}
+
+	// remove address blooms
+	if err := ms.addressBloomStore.remove(dbTx, epochFrom, epochTo); err != nil {
+		return errors.WithMessage(err, "failed to remove address blooms")
+	}
+
+	return nil
 }
 
-func (ms *MysqlStore) GetLogs(ctx context.Context, storeFilter store.LogFilter) ([]*store.Log, error) {
+func (ms *MysqlStore) GetLogs(ctx context.Context, storeFilter store.LogFilter) (logs []*store.Log, err error) {
 	startTime := time.Now()
 	defer metrics.Registry.Store.GetLogs().UpdateSince(startTime)
+	defer func() { slowlog.Observe(ctx, "store", time.Since(startTime)) }()
+
+	ctx, span := tracing.Start(ctx, "store/getLogs",
+		attribute.Int64("store.blockFrom", int64(storeFilter.BlockFrom)),
+		attribute.Int64("store.blockTo", int64(storeFilter.BlockTo)),
+	)
+	defer func() {
+		span.SetAttributes(attribute.Int("store.logs", len(logs)))
+		tracing.End(span, err)
+
+		logging.FromContext(ctx).WithFields(logrus.Fields{
+			"blockFrom": storeFilter.BlockFrom,
+			"blockTo":   storeFilter.BlockTo,
+			"logs":      len(logs),
+			"elapsed":   time.Since(startTime),
+		}).WithError(err).Debug("Store get logs from database")
+	}()
+
+	// event logs of parked epochs not persisted
+	if err := ms.requireBlocksNotParked(storeFilter.BlockFrom, storeFilter.BlockTo); err != nil {
+		return nil, err
+	}
 
 	contracts := storeFilter.Contracts.ToSlice()
 
@@ -354,6 +595,10 @@ func (ms *MysqlStore) GetLogs(ctx context.Context, storeFilter store.LogFilter)
 
 // Prune prune data from db store.
 func (ms *MysqlStore) Prune() {
+	if ms.cold != nil {
+		go ms.cold.scheduleOffload(ms.MaxEpoch)
+	}
+
 	go ms.pruner.schedulePrune(ms.config)
 }
 
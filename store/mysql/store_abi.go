@@ -0,0 +1,87 @@
+package mysql
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// max number of contract ABIs to return at a time
+	maxContractAbis = 1000
+)
+
+// ContractAbi is the ABI of contract uploaded by admin, which is used to decode event logs.
+type ContractAbi struct {
+	ID        uint32    `gorm:"primaryKey;autoIncrement" json:"id"`
+	Address   string    `gorm:"size:42;not null;uniqueIndex" json:"address"` // lowercase hex address
+	Name      string    `gorm:"size:128;not null" json:"name"`
+	Abi       string    `gorm:"type:mediumtext;not null" json:"abi"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (ContractAbi) TableName() string {
+	return "contract_abis"
+}
+
+// AbiStore persists contract ABIs to decode event logs.
+type AbiStore struct {
+	*baseStore
+}
+
+// mustNewAbiStore creates contract ABI store, and creates the table if absent.
+func mustNewAbiStore(db *gorm.DB) *AbiStore {
+	if !db.Migrator().HasTable(&ContractAbi{}) {
+		if err := db.Migrator().CreateTable(&ContractAbi{}); err != nil {
+			logrus.WithError(err).Fatal("Failed to create contract ABI table")
+		}
+	}
+
+	return &AbiStore{baseStore: newBaseStore(db)}
+}
+
+// UpsertContractAbi adds the contract ABI, or replaces it if already exists.
+func (as *AbiStore) UpsertContractAbi(address, name, abi string) error {
+	return as.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "address"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "abi", "updated_at"}),
+	}).Create(&ContractAbi{Address: address, Name: name, Abi: abi}).Error
+}
+
+// GetContractAbi returns the contract ABI of the specified address if any.
+func (as *AbiStore) GetContractAbi(address string) (*ContractAbi, bool, error) {
+	var entry ContractAbi
+
+	exists, err := as.exists(&entry, "address = ?", address)
+	if err != nil || !exists {
+		return nil, false, err
+	}
+
+	return &entry, true, nil
+}
+
+// ContractAbis returns the contract ABIs (without ABI content) in ascending order of ID since the
+// specified ID.
+func (as *AbiStore) ContractAbis(fromID uint32, limit int) ([]ContractAbi, error) {
+	if limit <= 0 || limit > maxContractAbis {
+		limit = maxContractAbis
+	}
+
+	var entries []ContractAbi
+	err := as.db.Omit("abi").
+		Where("id >= ?", fromID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&entries).Error
+
+	return entries, err
+}
+
+// DeleteContractAbi removes the contract ABI of the specified address.
+func (as *AbiStore) DeleteContractAbi(address string) (bool, error) {
+	res := as.db.Where("address = ?", address).Delete(&ContractAbi{})
+	return res.RowsAffected > 0, res.Error
+}
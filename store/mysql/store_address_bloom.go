@@ -0,0 +1,292 @@
+package mysql
+
+import (
+	"context"
+	"encoding/binary"
+	"math/bits"
+
+	"github.com/Conflux-Chain/confura/store"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const defaultBatchSizeAddressBloomInsert = 1000
+
+// AddressBloomConfig represents the configurations of per epoch bloom filters of involved addresses,
+// which are used to skip the epochs without any activity of some address.
+type AddressBloomConfig struct {
+	Enabled bool
+	// Max number of epochs to scan blooms at a time, beyond which no epoch will be skipped
+	MaxScanEpochs uint64 `default:"10000"`
+}
+
+// addressBloom is the bloom filter of addresses (senders, receivers and event log addresses)
+// involved in an epoch.
+type addressBloom struct {
+	Epoch uint64 `gorm:"primaryKey;autoIncrement:false"`
+	BnMin uint64 `gorm:"not null"`
+	BnMax uint64 `gorm:"not null;index"`
+	// sparse encoded bloom, see `encodeAddressBloom` for details
+	Bloom []byte `gorm:"type:varbinary(256);not null"`
+}
+
+func (addressBloom) TableName() string {
+	return "address_blooms"
+}
+
+// addressBloomStore persists the per epoch address blooms during sync.
+type addressBloomStore struct {
+	*baseStore
+	conf AddressBloomConfig
+}
+
+// mustNewAddressBloomStore creates address bloom store, and creates the table if enabled and absent.
+func mustNewAddressBloomStore(db *gorm.DB, conf AddressBloomConfig) *addressBloomStore {
+	if conf.Enabled && !db.Migrator().HasTable(&addressBloom{}) {
+		if err := db.Migrator().CreateTable(&addressBloom{}); err != nil {
+			logrus.WithError(err).Fatal("Failed to create address bloom table")
+		}
+	}
+
+	return &addressBloomStore{baseStore: newBaseStore(db), conf: conf}
+}
+
+// add saves the address blooms of epoch data within the database transaction.
+func (abs *addressBloomStore) add(dbTx *gorm.DB, dataSlice []*store.EpochData) error {
+	if !abs.conf.Enabled {
+		return nil
+	}
+
+	blooms := make([]*addressBloom, 0, len(dataSlice))
+	for _, data := range dataSlice {
+		blooms = append(blooms, newAddressBloom(data))
+	}
+
+	return dbTx.CreateInBatches(blooms, defaultBatchSizeAddressBloomInsert).Error
+}
+
+// remove removes the address blooms of the specified epoch range within the database transaction.
+func (abs *addressBloomStore) remove(dbTx *gorm.DB, epochFrom, epochTo uint64) error {
+	if !abs.conf.Enabled {
+		return nil
+	}
+
+	return dbTx.Where("epoch >= ? AND epoch <= ?", epochFrom, epochTo).Delete(&addressBloom{}).Error
+}
+
+// NarrowEpochRange narrows the epoch range to the first and last epochs that any of the addresses
+// may be involved in, or returns false if none involved at all. Note, the epoch range will be
+// returned as it is if blooms not available for all the epochs, or too many epochs to scan.
+func (abs *addressBloomStore) NarrowEpochRange(
+	ctx context.Context, addrs []common.Address, epochRange citypes.RangeUint64,
+) (citypes.RangeUint64, bool, error) {
+	numEpochs := epochRange.To - epochRange.From + 1
+	if !abs.conf.Enabled || len(addrs) == 0 || numEpochs > abs.conf.MaxScanEpochs {
+		return epochRange, true, nil
+	}
+
+	var blooms []addressBloom
+	err := abs.db.WithContext(ctx).
+		Where("epoch BETWEEN ? AND ?", epochRange.From, epochRange.To).
+		Order("epoch ASC").
+		Find(&blooms).Error
+	if err != nil {
+		return epochRange, false, err
+	}
+
+	// some epochs not covered by blooms, e.g., synced before enabled or not backfilled yet
+	if uint64(len(blooms)) != numEpochs {
+		return epochRange, true, nil
+	}
+
+	from, to, ok := matchAddressBlooms(blooms, addrs)
+	if !ok {
+		return epochRange, false, nil
+	}
+
+	return citypes.RangeUint64{From: blooms[from].Epoch, To: blooms[to].Epoch}, true, nil
+}
+
+// NarrowBlockRange narrows the block range to the blocks of the first and last epochs that any of
+// the addresses may be involved in, or returns false if none involved at all. Note, the block range
+// will be returned as it is if blooms not available for all the epochs, or too many epochs to scan.
+func (abs *addressBloomStore) NarrowBlockRange(
+	ctx context.Context, addrs []common.Address, bnRange citypes.RangeUint64,
+) (citypes.RangeUint64, bool, error) {
+	if !abs.conf.Enabled || len(addrs) == 0 {
+		return bnRange, true, nil
+	}
+
+	// epochs ending within the block range
+	var blooms []addressBloom
+	err := abs.db.WithContext(ctx).
+		Where("bn_max BETWEEN ? AND ?", bnRange.From, bnRange.To).
+		Order("bn_max ASC").
+		Limit(int(abs.conf.MaxScanEpochs) + 1).
+		Find(&blooms).Error
+	if err != nil {
+		return bnRange, false, err
+	}
+
+	if uint64(len(blooms)) > abs.conf.MaxScanEpochs {
+		return bnRange, true, nil
+	}
+
+	// epoch spanning the end of block range if any
+	var last addressBloom
+	exists, err := abs.exists(&last, "bn_max > ?", bnRange.To)
+	if err != nil {
+		return bnRange, false, err
+	}
+
+	if exists && last.BnMin <= bnRange.To {
+		blooms = append(blooms, last)
+	}
+
+	// blooms must cover the whole block range continuously
+	if len(blooms) == 0 || blooms[0].BnMin > bnRange.From || blooms[len(blooms)-1].BnMax < bnRange.To {
+		return bnRange, true, nil
+	}
+
+	for i := 1; i < len(blooms); i++ {
+		if blooms[i].Epoch != blooms[i-1].Epoch+1 {
+			return bnRange, true, nil
+		}
+	}
+
+	from, to, ok := matchAddressBlooms(blooms, addrs)
+	if !ok {
+		return bnRange, false, nil
+	}
+
+	return citypes.RangeUint64{
+		From: max(bnRange.From, blooms[from].BnMin),
+		To:   min(bnRange.To, blooms[to].BnMax),
+	}, true, nil
+}
+
+// matchAddressBlooms returns the positions of first and last blooms that may contain any address.
+func matchAddressBlooms(blooms []addressBloom, addrs []common.Address) (from, to int, ok bool) {
+	from = -1
+
+	for i := range blooms {
+		bloom := decodeAddressBloom(blooms[i].Bloom)
+
+		for _, addr := range addrs {
+			if ethTypes.BloomLookup(bloom, addr) {
+				if from < 0 {
+					from = i
+				}
+
+				to = i
+				break
+			}
+		}
+	}
+
+	return from, to, from >= 0
+}
+
+// newAddressBloom creates the bloom of senders, receivers and event log addresses of epoch data.
+func newAddressBloom(data *store.EpochData) *addressBloom {
+	var bloom ethTypes.Bloom
+
+	addAddr := func(addr *types.Address) {
+		if addr == nil {
+			return
+		}
+
+		if commonAddr, _, err := addr.ToCommon(); err == nil {
+			bloom.Add(commonAddr.Bytes())
+		}
+	}
+
+	for _, block := range data.Blocks {
+		for _, tx := range block.Transactions {
+			receipt := data.Receipts[tx.Hash]
+
+			// Skip transactions that unexecuted in block.
+			if receipt == nil || !util.IsTxExecutedInBlock(&tx) {
+				continue
+			}
+
+			addAddr(&tx.From)
+			addAddr(tx.To)
+			addAddr(receipt.ContractCreated)
+
+			for i := range receipt.Logs {
+				addAddr(&receipt.Logs[i].Address)
+			}
+		}
+	}
+
+	return &addressBloom{
+		Epoch: data.Number,
+		BnMin: data.Blocks[0].BlockNumber.ToInt().Uint64(),
+		BnMax: data.GetPivotBlock().BlockNumber.ToInt().Uint64(),
+		Bloom: encodeAddressBloom(bloom),
+	}
+}
+
+// encodeAddressBloom encodes the bloom compactly, which is mostly sparse since only a few addresses
+// involved in an epoch. The positions (uint16 in big endian) of set bits are encoded if less than
+// the raw bloom in size, otherwise the raw bloom is encoded.
+func encodeAddressBloom(bloom ethTypes.Bloom) []byte {
+	var numBits int
+	for _, b := range bloom {
+		numBits += bits.OnesCount8(b)
+	}
+
+	if numBits*2 >= ethTypes.BloomByteLength {
+		return bloom.Bytes()
+	}
+
+	encoded := make([]byte, 0, numBits*2)
+	for i, b := range bloom {
+		for j := 0; j < 8; j++ {
+			if b&(1<<j) != 0 {
+				encoded = binary.BigEndian.AppendUint16(encoded, uint16(i*8+j))
+			}
+		}
+	}
+
+	return encoded
+}
+
+// decodeAddressBloom decodes the bloom encoded by `encodeAddressBloom`.
+func decodeAddressBloom(encoded []byte) (bloom ethTypes.Bloom) {
+	if len(encoded) == ethTypes.BloomByteLength {
+		return ethTypes.BytesToBloom(encoded)
+	}
+
+	for i := 0; i+1 < len(encoded); i += 2 {
+		pos := binary.BigEndian.Uint16(encoded[i:])
+		bloom[pos/8] |= 1 << (pos % 8)
+	}
+
+	return bloom
+}
+
+// GetAccountTransactions returns the hashes of transactions sent from the specified account within
+// the epoch range, which skips the epochs without any activity of the account by address blooms.
+func (ms *MysqlStore) GetAccountTransactions(ctx context.Context, filter store.AccountTxnFilter) ([]types.Hash, error) {
+	epochRange := citypes.RangeUint64{From: filter.EpochFrom, To: filter.EpochTo}
+
+	epochRange, ok, err := ms.NarrowEpochRange(ctx, []common.Address{common.HexToAddress(filter.Address)}, epochRange)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return []types.Hash{}, nil
+	}
+
+	filter.EpochFrom, filter.EpochTo = epochRange.From, epochRange.To
+
+	return ms.txStore.GetAccountTransactions(ctx, filter)
+}
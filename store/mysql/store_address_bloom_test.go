@@ -0,0 +1,57 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeAddressBloom(t *testing.T) {
+	// empty bloom
+	var bloom ethTypes.Bloom
+	assert.Empty(t, encodeAddressBloom(bloom))
+	assert.Equal(t, bloom, decodeAddressBloom(encodeAddressBloom(bloom)))
+
+	// sparse bloom
+	addr := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	bloom.Add(addr.Bytes())
+
+	encoded := encodeAddressBloom(bloom)
+	assert.Less(t, len(encoded), ethTypes.BloomByteLength)
+	assert.Equal(t, bloom, decodeAddressBloom(encoded))
+
+	// dense bloom
+	for i := 0; i < 200; i++ {
+		bloom.Add(common.BytesToAddress([]byte{byte(i), 1}).Bytes())
+	}
+
+	encoded = encodeAddressBloom(bloom)
+	assert.Equal(t, ethTypes.BloomByteLength, len(encoded))
+	assert.Equal(t, bloom, decodeAddressBloom(encoded))
+}
+
+func TestMatchAddressBlooms(t *testing.T) {
+	addr1 := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	addr2 := common.HexToAddress("0x1000000000000000000000000000000000000002")
+
+	newBloom := func(epoch uint64, addrs ...common.Address) addressBloom {
+		var bloom ethTypes.Bloom
+		for _, addr := range addrs {
+			bloom.Add(addr.Bytes())
+		}
+
+		return addressBloom{Epoch: epoch, Bloom: encodeAddressBloom(bloom)}
+	}
+
+	blooms := []addressBloom{newBloom(1), newBloom(2, addr1), newBloom(3), newBloom(4, addr1), newBloom(5)}
+
+	from, to, ok := matchAddressBlooms(blooms, []common.Address{addr1})
+	assert.True(t, ok)
+	assert.Equal(t, 1, from)
+	assert.Equal(t, 3, to)
+
+	_, _, ok = matchAddressBlooms(blooms, []common.Address{addr2})
+	assert.False(t, ok)
+}
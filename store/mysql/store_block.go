@@ -69,20 +69,44 @@ func (block *block) parseBlockExtra() *store.BlockExtra {
 
 type blockStore struct {
 	db *gorm.DB
+	// cold store to read through offloaded raw data, nil if tiering disabled
+	cold *coldStore
 }
 
-func newBlockStore(db *gorm.DB) *blockStore {
+func newBlockStore(db *gorm.DB, cold *coldStore) *blockStore {
 	return &blockStore{
-		db: db,
+		db: db, cold: cold,
 	}
 }
 
-func (bs *blockStore) loadBlockSummary(whereClause string, args ...interface{}) (*store.BlockSummary, error) {
+func (bs *blockStore) loadBlockSummary(
+	ctx context.Context, whereClause string, args ...interface{},
+) (*store.BlockSummary, error) {
 	var blk block
 	if err := bs.db.Where(whereClause, args...).First(&blk).Error; err != nil {
 		return nil, err
 	}
 
+	return bs.toBlockSummary(ctx, &blk)
+}
+
+// toBlockSummary decodes the block summary from raw data, which is read through the cold store
+// if offloaded.
+func (bs *blockStore) toBlockSummary(ctx context.Context, blk *block) (*store.BlockSummary, error) {
+	// raw data offloaded to object storage
+	if blk.RawDataLen > 0 && len(blk.RawData) == 0 {
+		if bs.cold == nil {
+			return nil, errColdDataUnavailable
+		}
+
+		rawData, err := bs.cold.GetBlockRawData(ctx, blk.Epoch, blk.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		blk.RawData = rawData
+	}
+
 	var summary types.BlockSummary
 	util.MustUnmarshalRLP(blk.RawData, &summary)
 
@@ -123,7 +147,7 @@ func (bs *blockStore) GetBlockByEpoch(ctx context.Context, epochNumber uint64) (
 }
 
 func (bs *blockStore) GetBlockSummaryByEpoch(ctx context.Context, epochNumber uint64) (*store.BlockSummary, error) {
-	return bs.loadBlockSummary("epoch = ? AND pivot = true", epochNumber)
+	return bs.loadBlockSummary(ctx, "epoch = ? AND pivot = true", epochNumber)
 }
 
 func (bs *blockStore) GetBlockByHash(ctx context.Context, blockHash types.Hash) (*store.Block, error) {
@@ -132,7 +156,7 @@ func (bs *blockStore) GetBlockByHash(ctx context.Context, blockHash types.Hash)
 
 func (bs *blockStore) GetBlockSummaryByHash(ctx context.Context, blockHash types.Hash) (*store.BlockSummary, error) {
 	hash := blockHash.String()
-	return bs.loadBlockSummary("hash_id = ? AND hash = ?", util.GetShortIdOfHash(hash), hash)
+	return bs.loadBlockSummary(ctx, "hash_id = ? AND hash = ?", util.GetShortIdOfHash(hash), hash)
 }
 
 func (bs *blockStore) GetBlockByBlockNumber(ctx context.Context, blockNumber uint64) (*store.Block, error) {
@@ -140,7 +164,30 @@ func (bs *blockStore) GetBlockByBlockNumber(ctx context.Context, blockNumber uin
 }
 
 func (bs *blockStore) GetBlockSummaryByBlockNumber(ctx context.Context, blockNumber uint64) (*store.BlockSummary, error) {
-	return bs.loadBlockSummary("block_number = ?", blockNumber)
+	return bs.loadBlockSummary(ctx, "block_number = ?", blockNumber)
+}
+
+// GetBlockSummariesByBlockNumberRange loads blocks within the block number range in a single query.
+func (bs *blockStore) GetBlockSummariesByBlockNumberRange(
+	ctx context.Context, from, to uint64,
+) ([]*store.BlockSummary, error) {
+	var blocks []*block
+	err := bs.db.Where("block_number BETWEEN ? AND ?", from, to).Order("block_number ASC").Find(&blocks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]*store.BlockSummary, 0, len(blocks))
+	for _, blk := range blocks {
+		summary, err := bs.toBlockSummary(ctx, blk)
+		if err != nil {
+			return nil, err
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
 }
 
 // Add batch save epoch blocks into db store.
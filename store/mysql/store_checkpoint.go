@@ -0,0 +1,152 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	syncCheckpointName = "sync"
+)
+
+// syncCheckpoint records the highest durably committed epoch of sync, which is always updated
+// within the same database transaction as epoch data persistence.
+type syncCheckpoint struct {
+	Name      string `gorm:"primaryKey;size:32"`
+	Epoch     uint64 `gorm:"not null"`
+	PivotHash string `gorm:"size:66;not null"`
+	UpdatedAt time.Time
+}
+
+func (syncCheckpoint) TableName() string {
+	return "sync_checkpoints"
+}
+
+// checkpointStore persists the sync checkpoint to resume sync without duplicates or gaps.
+type checkpointStore struct {
+	*baseStore
+}
+
+// mustNewCheckpointStore creates sync checkpoint store, and creates the table if absent.
+func mustNewCheckpointStore(db *gorm.DB) *checkpointStore {
+	if !db.Migrator().HasTable(&syncCheckpoint{}) {
+		if err := db.Migrator().CreateTable(&syncCheckpoint{}); err != nil {
+			logrus.WithError(err).Fatal("Failed to create sync checkpoint table")
+		}
+	}
+
+	return &checkpointStore{baseStore: newBaseStore(db)}
+}
+
+// SyncCheckpoint returns the highest durably committed epoch of sync.
+func (cps *checkpointStore) SyncCheckpoint() (uint64, bool, error) {
+	var cp syncCheckpoint
+
+	existed, err := cps.exists(&cp, "name = ?", syncCheckpointName)
+	if err != nil || !existed {
+		return 0, false, err
+	}
+
+	return cp.Epoch, true, nil
+}
+
+// saveCheckpoint updates the sync checkpoint within the database transaction.
+func (cps *checkpointStore) saveCheckpoint(dbTx *gorm.DB, epoch uint64, pivotHash string) error {
+	return dbTx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&syncCheckpoint{
+		Name:      syncCheckpointName,
+		Epoch:     epoch,
+		PivotHash: pivotHash,
+	}).Error
+}
+
+// removeCheckpoint removes the sync checkpoint within the database transaction.
+func (cps *checkpointStore) removeCheckpoint(dbTx *gorm.DB) error {
+	return dbTx.Delete(&syncCheckpoint{}, "name = ?", syncCheckpointName).Error
+}
+
+// InitSyncCheckpoint aligns the sync checkpoint with the max epoch of store on startup.
+//
+// Note, since the checkpoint is always advanced or rewound within the same database transaction
+// as epoch data, there could never be partial writes beyond the checkpoint even if crashed at any
+// point, and sync simply resumes from the epoch right after the checkpoint. So nothing will be
+// trimmed here, and the checkpoint is only initialized for stores synced before checkpoint
+// introduced, or realigned if epochs were removed by manual operations.
+func (ms *MysqlStore) InitSyncCheckpoint() error {
+	return ms.baseStore.db.Transaction(func(dbTx *gorm.DB) error {
+		// lock the checkpoint against concurrent writes (e.g., by HA leader) during initialization
+		var cp syncCheckpoint
+		res := dbTx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("name = ?", syncCheckpointName).
+			Limit(1).
+			Find(&cp)
+		if res.Error != nil {
+			return errors.WithMessage(res.Error, "failed to get sync checkpoint")
+		}
+
+		var maxEpoch sql.NullInt64
+		if err := dbTx.Model(&epochBlockMap{}).Select("MAX(epoch)").Find(&maxEpoch).Error; err != nil {
+			return errors.WithMessage(err, "failed to get max epoch")
+		}
+
+		ok, hasEpoch := res.RowsAffected > 0, maxEpoch.Valid
+		checkpoint, storeMaxEpoch := cp.Epoch, uint64(maxEpoch.Int64)
+
+		logger := logrus.WithFields(logrus.Fields{
+			"checkpoint": checkpoint,
+			"maxEpoch":   storeMaxEpoch,
+		})
+
+		switch {
+		case !ok && !hasEpoch: // empty store
+			return nil
+		case !ok:
+			logger.Info("Sync checkpoint initialized with the max epoch of store")
+			return ms.syncCheckpointTo(dbTx, storeMaxEpoch)
+		case !hasEpoch:
+			logger.Warn("Sync checkpoint removed due to empty store")
+			return ms.checkpointStore.removeCheckpoint(dbTx)
+		case storeMaxEpoch != checkpoint:
+			// store modified out of sync (e.g., by manual operations)
+			logger.Warn("Sync checkpoint realigned with the max epoch of store")
+			return ms.syncCheckpointTo(dbTx, storeMaxEpoch)
+		default:
+			return nil
+		}
+	})
+}
+
+// syncCheckpointTo updates the sync checkpoint to the specified stored epoch within the
+// database transaction.
+func (ms *MysqlStore) syncCheckpointTo(dbTx *gorm.DB, epoch uint64) error {
+	var e2bmap epochBlockMap
+	if err := dbTx.Where("epoch = ?", epoch).Take(&e2bmap).Error; err != nil {
+		return errors.WithMessagef(err, "failed to get pivot hash of epoch %v", epoch)
+	}
+
+	return ms.checkpointStore.saveCheckpoint(dbTx, epoch, e2bmap.PivotHash)
+}
+
+// rewindCheckpoint rewinds the sync checkpoint to the epoch right before the popped epochs within
+// the database transaction, or removes the checkpoint if no epoch left.
+func (ms *MysqlStore) rewindCheckpoint(dbTx *gorm.DB, epochUntil uint64) error {
+	if epochUntil == 0 {
+		return ms.checkpointStore.removeCheckpoint(dbTx)
+	}
+
+	var e2bmap epochBlockMap
+	existed, err := ms.epochBlockMapStore.exists(&e2bmap, "epoch = ?", epochUntil-1)
+	if err != nil {
+		return err
+	}
+
+	if !existed {
+		return ms.checkpointStore.removeCheckpoint(dbTx)
+	}
+
+	return ms.checkpointStore.saveCheckpoint(dbTx, epochUntil-1, e2bmap.PivotHash)
+}
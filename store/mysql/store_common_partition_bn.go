@@ -219,6 +219,25 @@ func (bnps *bnPartitionedStore) searchOverlapPartitions(entity string, searchRan
 	return partitions, err
 }
 
+// coveringPartition finds the entity partition of which block number range covers the whole
+// search range, eg., to backfill entity data in the middle of partitions.
+func (bnps *bnPartitionedStore) coveringPartition(entity string, searchRange types.RangeUint64) (*bnPartition, bool, error) {
+	var partition bnPartition
+
+	err := bnps.db.Where("entity = ?", entity).
+		Where("bn_min <= ? AND bn_max >= ?", searchRange.From, searchRange.To).
+		First(&partition).Error
+	if bnps.IsRecordNotFound(err) {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &partition, true, nil
+}
+
 func errBnPartitionsPruned(srange, bnPartRange types.RangeUint64) error {
 	return errors.WithMessagef(store.ErrAlreadyPruned,
 		"range %v not contained in the inclusion range %v formed by all bnPartitions",
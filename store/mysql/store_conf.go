@@ -28,6 +28,10 @@ const (
 	// pre-defined node route group config key prefix
 	NodeRouteGroupConfKeyPrefix   = "noderoute.group."
 	nodeRouteGroupSqlMatchPattern = NodeRouteGroupConfKeyPrefix + "%"
+
+	// pre-defined config key prefix of full nodes discovered from config service
+	DiscoveredNodeGroupConfKeyPrefix   = "noderoute.discovered."
+	discoveredNodeGroupSqlMatchPattern = DiscoveredNodeGroupConfKeyPrefix + "%"
 )
 
 // configuration tables
@@ -306,7 +310,7 @@ func (cs *confStore) LoadNodeRouteGroups(inclusiveGroups ...string) (res map[str
 
 	// decode node route group from config item
 	for _, v := range cfgs {
-		grp, err := cs.decodeNodeRouteGroup(v)
+		grp, err := cs.decodeNodeRouteGroup(v, NodeRouteGroupConfKeyPrefix)
 		if err != nil {
 			logrus.WithField("cfg", v).WithError(err).Warn("Invalid node route config")
 			continue
@@ -318,9 +322,9 @@ func (cs *confStore) LoadNodeRouteGroups(inclusiveGroups ...string) (res map[str
 	return res, nil
 }
 
-func (cs *confStore) decodeNodeRouteGroup(cfg conf) (*NodeRouteGroup, error) {
+func (cs *confStore) decodeNodeRouteGroup(cfg conf, keyPrefix string) (*NodeRouteGroup, error) {
 	// eg., noderoute.group.cfxvip
-	name := cfg.Name[len(NodeRouteGroupConfKeyPrefix):]
+	name := cfg.Name[len(keyPrefix):]
 	if len(name) == 0 {
 		return nil, errors.New("route group name is too short")
 	}
@@ -334,3 +338,41 @@ func (cs *confStore) decodeNodeRouteGroup(cfg conf) (*NodeRouteGroup, error) {
 
 	return &grp, nil
 }
+
+// StoreDiscoveredNodeGroup persists full nodes of group discovered from config service, so that
+// they could be reconciled after restart.
+func (cs *confStore) StoreDiscoveredNodeGroup(grp *NodeRouteGroup) error {
+	cfgVal, err := json.Marshal(grp)
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal discovered node group")
+	}
+
+	return cs.StoreConfig(DiscoveredNodeGroupConfKeyPrefix+grp.Name, string(cfgVal))
+}
+
+func (cs *confStore) DelDiscoveredNodeGroup(group string) error {
+	_, err := cs.DeleteConfig(DiscoveredNodeGroupConfKeyPrefix + group)
+	return err
+}
+
+// LoadDiscoveredNodeGroups loads all the persisted groups of discovered full nodes.
+func (cs *confStore) LoadDiscoveredNodeGroups() (map[string]*NodeRouteGroup, error) {
+	var cfgs []conf
+	if err := cs.db.Where("name LIKE ?", discoveredNodeGroupSqlMatchPattern).Find(&cfgs).Error; err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]*NodeRouteGroup)
+
+	for _, v := range cfgs {
+		grp, err := cs.decodeNodeRouteGroup(v, DiscoveredNodeGroupConfKeyPrefix)
+		if err != nil {
+			logrus.WithField("cfg", v).WithError(err).Warn("Invalid discovered node group config")
+			continue
+		}
+
+		res[grp.Name] = grp
+	}
+
+	return res, nil
+}
@@ -191,6 +191,17 @@ func (cs *ContractStore) UpdateContractStats(
 	return dbTx.Model(&Contract{}).Where("id = ?", cid).Updates(updates).Error
 }
 
+// BackfillContractStats updates statistics of the specified contract for event logs backfilled
+// in past epochs, so that the latest updated epoch will never go backwards.
+func (cs *ContractStore) BackfillContractStats(dbTx *gorm.DB, cid uint64, countDelta int, epoch uint64) error {
+	updates := map[string]interface{}{
+		"log_count":            gorm.Expr("log_count + ?", countDelta),
+		"latest_updated_epoch": gorm.Expr("GREATEST(latest_updated_epoch, ?)", epoch),
+	}
+
+	return dbTx.Model(&Contract{}).Where("id = ?", cid).Updates(updates).Error
+}
+
 // enforceCache enforces to load contract cache from db with specified condition.
 func (cs *ContractStore) enforceCache(whereQuery string, args ...interface{}) (*Contract, bool, error) {
 	// Could improve when QPS is very high:
@@ -0,0 +1,263 @@
+package mysql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// max length of error message to persist
+	maxDeadLetterErrorLen = 2048
+
+	// max number of dead letter epochs to return at a time
+	maxDeadLetterEpochs = 1000
+
+	// expiration to reload the parked epochs, which are parked by sync service in another process
+	parkedEpochsExpiration = 5 * time.Second
+)
+
+// MySQL error numbers specific to the data persisted, rather than transient failures such as lost
+// connection or lock wait timeout.
+var mysqlDataErrors = map[uint16]bool{
+	1048: true, // ER_BAD_NULL_ERROR
+	1062: true, // ER_DUP_ENTRY
+	1153: true, // ER_NET_PACKET_TOO_LARGE
+	1264: true, // ER_WARN_DATA_OUT_OF_RANGE
+	1265: true, // WARN_DATA_TRUNCATED
+	1292: true, // ER_TRUNCATED_WRONG_VALUE
+	1366: true, // ER_TRUNCATED_WRONG_VALUE_FOR_FIELD
+	1406: true, // ER_DATA_TOO_LONG
+	1452: true, // ER_NO_REFERENCED_ROW_2
+	3140: true, // ER_INVALID_JSON_TEXT
+}
+
+// IsDataError checks if the error to persist epoch data is specific to the data, e.g., data too
+// long or constraint violation, which won't be recovered by retry.
+func IsDataError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlDataErrors[mysqlErr.Number]
+}
+
+// DeadLetterEpoch is the epoch (or block for evm space) failed to persist repeatedly during sync,
+// e.g., data too large or constraint violation, which is parked with only blocks persisted so that
+// sync could continue, and should be retried after a fix.
+type DeadLetterEpoch struct {
+	ID        uint64 `gorm:"primaryKey;autoIncrement" json:"id"`
+	Epoch     uint64 `gorm:"not null;uniqueIndex" json:"epoch"`
+	PivotHash string `gorm:"size:66;not null" json:"pivotHash"`
+	Error     string `gorm:"size:2048;not null" json:"error"`
+	// number of times parked, which is increased if failed to persist again after retried
+	Parked    int       `gorm:"not null;default:1" json:"parked"`
+	Resolved  bool      `gorm:"not null;index" json:"resolved"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (DeadLetterEpoch) TableName() string {
+	return "dead_letter_epochs"
+}
+
+// deadLetterStore persists the epochs failed to persist repeatedly during sync.
+type deadLetterStore struct {
+	*baseStore
+
+	// cached unresolved dead letter epochs => block ranges, since only block headers persisted
+	// for them and the chain data reads within should be rejected, so as to fall back to fullnode
+	parkedMu       sync.Mutex
+	parkedEpochs   map[uint64]*citypes.RangeUint64
+	parkedLoadedAt time.Time
+}
+
+// mustNewDeadLetterStore creates dead letter epoch store, and creates the table if absent.
+func mustNewDeadLetterStore(db *gorm.DB) *deadLetterStore {
+	if !db.Migrator().HasTable(&DeadLetterEpoch{}) {
+		if err := db.Migrator().CreateTable(&DeadLetterEpoch{}); err != nil {
+			logrus.WithError(err).Fatal("Failed to create dead letter epoch table")
+		}
+	}
+
+	return &deadLetterStore{baseStore: newBaseStore(db)}
+}
+
+// AddDeadLetterEpochWithTx parks the epoch within the database transaction, which is re-opened
+// with the latest error if already parked before.
+func (dls *deadLetterStore) AddDeadLetterEpochWithTx(dbTx *gorm.DB, epoch uint64, pivotHash string, cause error) error {
+	msg := cause.Error()
+	if len(msg) > maxDeadLetterErrorLen {
+		msg = msg[:maxDeadLetterErrorLen]
+	}
+
+	return dbTx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "epoch"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"pivot_hash": pivotHash,
+			"error":      msg,
+			"parked":     gorm.Expr("parked + 1"),
+			"resolved":   false,
+			"updated_at": time.Now(),
+		}),
+	}).Create(&DeadLetterEpoch{Epoch: epoch, PivotHash: pivotHash, Error: msg, Parked: 1}).Error
+}
+
+// DeadLetterEpochs returns the dead letter epochs in ascending order of epoch, and only the
+// unresolved ones unless `all` specified.
+func (dls *deadLetterStore) DeadLetterEpochs(all bool, limit int) ([]DeadLetterEpoch, error) {
+	if limit <= 0 || limit > maxDeadLetterEpochs {
+		limit = maxDeadLetterEpochs
+	}
+
+	db := dls.db
+	if !all {
+		db = db.Where("resolved = ?", false)
+	}
+
+	var entries []DeadLetterEpoch
+	err := db.Order("epoch ASC").Limit(limit).Find(&entries).Error
+
+	return entries, err
+}
+
+// ResolveDeadLetterEpoch marks the dead letter epoch as resolved once retried successfully.
+func (dls *deadLetterStore) ResolveDeadLetterEpoch(epoch uint64) error {
+	res := dls.db.Model(&DeadLetterEpoch{}).Where("epoch = ?", epoch).Update("resolved", true)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return errors.Errorf("dead letter epoch %v not found", epoch)
+	}
+
+	// reload parked epochs, so that the resolved epoch is served by store at once
+	dls.parkedMu.Lock()
+	dls.parkedEpochs = nil
+	dls.parkedMu.Unlock()
+
+	return nil
+}
+
+// loadParkedEpochs returns the unresolved dead letter epochs along with block ranges (nil if not
+// found), which are reloaded once expired.
+func (ms *MysqlStore) loadParkedEpochs() (map[uint64]*citypes.RangeUint64, error) {
+	dls := ms.deadLetterStore
+
+	dls.parkedMu.Lock()
+	parked, loadedAt := dls.parkedEpochs, dls.parkedLoadedAt
+	dls.parkedMu.Unlock()
+
+	if parked != nil && time.Since(loadedAt) < parkedEpochsExpiration {
+		return parked, nil
+	}
+
+	entries, err := dls.DeadLetterEpochs(false, 0)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load dead letter epochs")
+	}
+
+	parked = make(map[uint64]*citypes.RangeUint64, len(entries))
+	for _, entry := range entries {
+		blockRange, ok, err := ms.BlockRange(entry.Epoch)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to get block range of dead letter epoch %v", entry.Epoch)
+		}
+
+		parked[entry.Epoch] = nil
+		if ok {
+			parked[entry.Epoch] = &blockRange
+		}
+	}
+
+	dls.parkedMu.Lock()
+	dls.parkedEpochs, dls.parkedLoadedAt = parked, time.Now()
+	dls.parkedMu.Unlock()
+
+	return parked, nil
+}
+
+// requireEpochNotParked returns `ErrEpochParked` if the epoch is parked with block headers only.
+func (ms *MysqlStore) requireEpochNotParked(epoch uint64) error {
+	parked, err := ms.loadParkedEpochs()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := parked[epoch]; ok {
+		return errors.WithMessagef(store.ErrEpochParked, "epoch %v", epoch)
+	}
+
+	return nil
+}
+
+// requireBlocksNotParked returns `ErrEpochParked` if any block within the block range belongs to
+// epoch parked with block headers only.
+func (ms *MysqlStore) requireBlocksNotParked(blockFrom, blockTo uint64) error {
+	parked, err := ms.loadParkedEpochs()
+	if err != nil {
+		return err
+	}
+
+	for epoch, blockRange := range parked {
+		if blockRange != nil && blockRange.From <= blockTo && blockFrom <= blockRange.To {
+			return errors.WithMessagef(store.ErrEpochParked, "epoch %v", epoch)
+		}
+	}
+
+	return nil
+}
+
+// GetBlockSummaryByEpoch rejects the parked epoch, of which the transactions are not persisted.
+func (ms *MysqlStore) GetBlockSummaryByEpoch(ctx context.Context, epochNumber uint64) (*store.BlockSummary, error) {
+	if err := ms.requireEpochNotParked(epochNumber); err != nil {
+		return nil, err
+	}
+
+	return ms.blockStore.GetBlockSummaryByEpoch(ctx, epochNumber)
+}
+
+// GetBlockSummaryByHash rejects the block of parked epoch, of which the transactions are not persisted.
+func (ms *MysqlStore) GetBlockSummaryByHash(ctx context.Context, blockHash types.Hash) (*store.BlockSummary, error) {
+	summary, err := ms.blockStore.GetBlockSummaryByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if epoch := summary.CfxBlockSummary.EpochNumber; epoch != nil {
+		if err := ms.requireEpochNotParked(epoch.ToInt().Uint64()); err != nil {
+			return nil, err
+		}
+	}
+
+	return summary, nil
+}
+
+// GetBlockSummaryByBlockNumber rejects the block of parked epoch, of which the transactions are
+// not persisted.
+func (ms *MysqlStore) GetBlockSummaryByBlockNumber(ctx context.Context, blockNumber uint64) (*store.BlockSummary, error) {
+	if err := ms.requireBlocksNotParked(blockNumber, blockNumber); err != nil {
+		return nil, err
+	}
+
+	return ms.blockStore.GetBlockSummaryByBlockNumber(ctx, blockNumber)
+}
+
+// GetBlockSummariesByBlockNumberRange rejects the block range overlapped with any parked epoch, of
+// which the transactions are not persisted.
+func (ms *MysqlStore) GetBlockSummariesByBlockNumberRange(
+	ctx context.Context, from, to uint64,
+) ([]*store.BlockSummary, error) {
+	if err := ms.requireBlocksNotParked(from, to); err != nil {
+		return nil, err
+	}
+
+	return ms.blockStore.GetBlockSummariesByBlockNumberRange(ctx, from, to)
+}
@@ -0,0 +1,57 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDataError(t *testing.T) {
+	assert.True(t, IsDataError(&mysql.MySQLError{Number: 1406}))
+	assert.True(t, IsDataError(errors.WithMessage(&mysql.MySQLError{Number: 1062}, "failed to add txs")))
+
+	// transient errors
+	assert.False(t, IsDataError(&mysql.MySQLError{Number: 1205}))
+	assert.False(t, IsDataError(mysql.ErrInvalidConn))
+	assert.False(t, IsDataError(errors.New("connection refused")))
+}
+
+func TestParkedEpochsRejected(t *testing.T) {
+	// epoch 10 with blocks [100, 104] parked, and the block range of epoch 20 not found
+	ms := &MysqlStore{deadLetterStore: &deadLetterStore{
+		parkedEpochs: map[uint64]*citypes.RangeUint64{
+			10: {From: 100, To: 104},
+			20: nil,
+		},
+		parkedLoadedAt: time.Now(),
+	}}
+
+	assert.ErrorIs(t, ms.requireEpochNotParked(10), store.ErrEpochParked)
+	assert.ErrorIs(t, ms.requireEpochNotParked(20), store.ErrEpochParked)
+	assert.NoError(t, ms.requireEpochNotParked(11))
+
+	assert.ErrorIs(t, ms.requireBlocksNotParked(90, 100), store.ErrEpochParked)
+	assert.ErrorIs(t, ms.requireBlocksNotParked(102, 102), store.ErrEpochParked)
+	assert.ErrorIs(t, ms.requireBlocksNotParked(104, 200), store.ErrEpochParked)
+	assert.NoError(t, ms.requireBlocksNotParked(90, 99))
+	assert.NoError(t, ms.requireBlocksNotParked(105, 200))
+
+	// event logs and blocks of parked epochs are not served from database
+	_, err := ms.GetLogs(context.Background(), store.LogFilter{BlockFrom: 0, BlockTo: 1000})
+	assert.ErrorIs(t, err, store.ErrEpochParked)
+
+	err = ms.ForEachLog(context.Background(), store.LogFilter{BlockFrom: 0, BlockTo: 1000}, nil)
+	assert.ErrorIs(t, err, store.ErrEpochParked)
+
+	_, err = ms.GetBlockSummaryByEpoch(context.Background(), 10)
+	assert.ErrorIs(t, err, store.ErrEpochParked)
+
+	_, err = ms.GetBlockSummariesByBlockNumberRange(context.Background(), 0, 1000)
+	assert.ErrorIs(t, err, store.ErrEpochParked)
+}
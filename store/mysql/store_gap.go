@@ -0,0 +1,284 @@
+package mysql
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const (
+	// number of epochs to scan at a time for gap detection
+	gapScanBatchSize = 10_000
+)
+
+var (
+	errBackfillNotGap = errors.New("epochs to backfill are not missing within the store")
+)
+
+// FindEpochGaps scans the epoch to block mappings within the specified epoch range, and returns
+// the missing epoch ranges in ascending order, with at most `maxGaps` ranges if it's positive.
+//
+// Note, only missing epochs between the min and max epoch of the store are regarded as gaps.
+func (ms *MysqlStore) FindEpochGaps(epochFrom, epochTo uint64, maxGaps int) ([]citypes.RangeUint64, error) {
+	minEpoch, ok, err := ms.MinEpoch()
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	maxEpoch, ok, err := ms.MaxEpoch()
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	epochFrom, epochTo = max(epochFrom, minEpoch), min(epochTo, maxEpoch)
+
+	var gaps []citypes.RangeUint64
+	for start := epochFrom; start <= epochTo; start += gapScanBatchSize {
+		end := min(start+gapScanBatchSize-1, epochTo)
+
+		var count int64
+		err := ms.DB().Model(&epochBlockMap{}).
+			Where("epoch >= ? AND epoch <= ?", start, end).
+			Count(&count).Error
+		if err != nil {
+			return nil, err
+		}
+
+		// skip if no epoch missing
+		if uint64(count) == end-start+1 {
+			continue
+		}
+
+		var epochs []uint64
+		err = ms.DB().Model(&epochBlockMap{}).
+			Where("epoch >= ? AND epoch <= ?", start, end).
+			Order("epoch ASC").
+			Pluck("epoch", &epochs).Error
+		if err != nil {
+			return nil, err
+		}
+
+		gaps = mergeEpochGaps(gaps, collectEpochGaps(start, epochs, end))
+		if maxGaps > 0 && len(gaps) > maxGaps {
+			return gaps[:maxGaps], nil
+		}
+	}
+
+	return gaps, nil
+}
+
+// collectEpochGaps collects the missing epoch ranges within [start, end] from the ascending stored epochs.
+func collectEpochGaps(start uint64, epochs []uint64, end uint64) (gaps []citypes.RangeUint64) {
+	next := start
+	for _, epoch := range epochs {
+		if epoch > next {
+			gaps = append(gaps, citypes.RangeUint64{From: next, To: epoch - 1})
+		}
+
+		next = epoch + 1
+	}
+
+	if next <= end {
+		gaps = append(gaps, citypes.RangeUint64{From: next, To: end})
+	}
+
+	return gaps
+}
+
+// mergeEpochGaps appends the new gaps, in which the first one will be merged into the last one of
+// the old gaps if adjacent, since a gap may span multiple scan batches.
+func mergeEpochGaps(gaps, newGaps []citypes.RangeUint64) []citypes.RangeUint64 {
+	if len(gaps) > 0 && len(newGaps) > 0 && gaps[len(gaps)-1].To+1 == newGaps[0].From {
+		gaps[len(gaps)-1].To = newGaps[0].To
+		newGaps = newGaps[1:]
+	}
+
+	return append(gaps, newGaps...)
+}
+
+// Backfill saves the epoch data of missing epochs into db, which must be continuous and fall
+// strictly within the min and max epoch of the store. Unlike `Pushn`, the stored epochs after
+// the backfilled ones won't be touched.
+func (ms *MysqlStore) Backfill(dataSlice []*store.EpochData) error {
+	if len(dataSlice) == 0 {
+		return nil
+	}
+
+	err := ms.backfill(dataSlice)
+	ms.writeStats.record(err)
+
+	if err == nil {
+		ms.notifyEpochsPushed(dataSlice)
+	}
+
+	return err
+}
+
+func (ms *MysqlStore) backfill(dataSlice []*store.EpochData) error {
+	if err := store.RequireContinuous(dataSlice, citypes.EpochNumberNil); err != nil {
+		return err
+	}
+
+	epochFrom, epochTo := dataSlice[0].Number, dataSlice[len(dataSlice)-1].Number
+	if err := ms.requireEpochGap(epochFrom, epochTo); err != nil {
+		return err
+	}
+
+	if err := ms.requirePivotContinuous(dataSlice); err != nil {
+		return err
+	}
+
+	startTime := time.Now()
+	defer metrics.Registry.Store.Push("mysql").UpdateSince(startTime)
+
+	if err := ms.addBackfillContracts(dataSlice); err != nil {
+		return err
+	}
+
+	return ms.baseStore.db.Transaction(func(dbTx *gorm.DB) error {
+		return ms.backfillWithTx(dbTx, dataSlice)
+	})
+}
+
+// requirePivotContinuous checks if the epoch data is on the same pivot chain as the previous stored epoch.
+func (ms *MysqlStore) requirePivotContinuous(dataSlice []*store.EpochData) error {
+	epochFrom := dataSlice[0].Number
+
+	prevPivotHash, ok, err := ms.PivotHash(epochFrom - 1)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get pivot hash of previous epoch")
+	}
+
+	if !ok || !strings.EqualFold(prevPivotHash, dataSlice[0].GetPivotBlock().ParentHash.String()) {
+		return errors.WithMessagef(
+			store.ErrContinousEpochRequired, "pivot chain switched at epoch %v", epochFrom,
+		)
+	}
+
+	return nil
+}
+
+// addBackfillContracts adds the contracts of the backfilled event logs if address indexed.
+func (ms *MysqlStore) addBackfillContracts(dataSlice []*store.EpochData) error {
+	if ms.disabler.IsChainLogDisabled() || !ms.config.AddressIndexedLogEnabled {
+		return nil
+	}
+
+	// Note, even if failed to insert event logs afterward, no need to rollback the inserted contract records.
+	if _, err := ms.cs.AddContractByEpochData(dataSlice...); err != nil {
+		return errors.WithMessage(err, "failed to add contracts for specified epoch data slice")
+	}
+
+	return nil
+}
+
+// backfillWithTx saves the epoch data of missing epochs within the database transaction.
+func (ms *MysqlStore) backfillWithTx(dbTx *gorm.DB, dataSlice []*store.EpochData) error {
+	if !ms.disabler.IsChainBlockDisabled() {
+		if err := ms.blockStore.Add(dbTx, dataSlice); err != nil {
+			return errors.WithMessagef(err, "failed to save blocks")
+		}
+	}
+
+	skipTxn := ms.disabler.IsChainTxnDisabled()
+	skipRcpt := ms.disabler.IsChainReceiptDisabled()
+	if !skipRcpt || !skipTxn {
+		if err := ms.txStore.Add(dbTx, dataSlice, skipTxn, skipRcpt); err != nil {
+			return errors.WithMessage(err, "failed to save transactions")
+		}
+	}
+
+	if !ms.disabler.IsChainLogDisabled() {
+		if ms.config.AddressIndexedLogEnabled {
+			// save contract specified event logs into the existing big contract partitions
+			bigContractIds, err := ms.bcls.backfill(dbTx, dataSlice)
+			if err != nil {
+				return errors.WithMessage(err, "failed to save big contract logs")
+			}
+
+			// save address indexed event logs
+			for _, data := range dataSlice {
+				if err := ms.ails.BackfillAddressIndexedLogs(dbTx, data, bigContractIds); err != nil {
+					return errors.WithMessage(err, "failed to save address indexed event logs")
+				}
+			}
+		}
+
+		if err := ms.ls.backfill(dbTx, dataSlice); err != nil {
+			return errors.WithMessage(err, "failed to save event logs")
+		}
+
+		if ms.config.TokenTransferIndexEnabled {
+			if err := ms.TokenTransferStore.add(dbTx, dataSlice); err != nil {
+				return errors.WithMessage(err, "failed to save token transfers")
+			}
+		}
+	}
+
+	if ms.config.InternalTxIndexEnabled {
+		if err := ms.InternalTxStore.add(dbTx, dataSlice); err != nil {
+			return errors.WithMessage(err, "failed to save internal transactions")
+		}
+	}
+
+	if err := ms.epochBlockMapStore.Add(dbTx, dataSlice); err != nil {
+		return errors.WithMessage(err, "failed to save epoch to block mapping data")
+	}
+
+	if err := ms.addressBloomStore.add(dbTx, dataSlice); err != nil {
+		return errors.WithMessage(err, "failed to save address blooms")
+	}
+
+	return nil
+}
+
+// requireEpochGap checks if the specified epoch range is entirely missing, and falls strictly
+// within the min and max epoch of the store.
+func (ms *MysqlStore) requireEpochGap(epochFrom, epochTo uint64) error {
+	if err := ms.requireInnerEpochs(epochFrom, epochTo, errBackfillNotGap); err != nil {
+		return err
+	}
+
+	var count int64
+	err := ms.DB().Model(&epochBlockMap{}).
+		Where("epoch >= ? AND epoch <= ?", epochFrom, epochTo).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return errors.WithMessagef(errBackfillNotGap, "%v epochs already stored", count)
+	}
+
+	return nil
+}
+
+// requireInnerEpochs checks if the specified epoch range falls strictly within the min and max
+// epoch of the store, otherwise the specified error returned.
+func (ms *MysqlStore) requireInnerEpochs(epochFrom, epochTo uint64, outOfRangeErr error) error {
+	minEpoch, ok, err := ms.MinEpoch()
+	if err != nil {
+		return err
+	}
+
+	if !ok || epochFrom <= minEpoch {
+		return errors.WithMessagef(outOfRangeErr, "min epoch %v", minEpoch)
+	}
+
+	maxEpoch, ok, err := ms.MaxEpoch()
+	if err != nil {
+		return err
+	}
+
+	if !ok || epochTo >= maxEpoch {
+		return errors.WithMessagef(outOfRangeErr, "max epoch %v", maxEpoch)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package mysql
+
+import (
+	"testing"
+
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectEpochGaps(t *testing.T) {
+	assert.Empty(t, collectEpochGaps(10, []uint64{10, 11, 12}, 12))
+
+	assert.Equal(t, []citypes.RangeUint64{
+		{From: 11, To: 12},
+		{From: 15, To: 15},
+		{From: 17, To: 20},
+	}, collectEpochGaps(10, []uint64{10, 13, 14, 16}, 20))
+
+	// whole batch missing
+	assert.Equal(t, []citypes.RangeUint64{{From: 10, To: 20}}, collectEpochGaps(10, nil, 20))
+}
+
+func TestMergeEpochGaps(t *testing.T) {
+	gaps := []citypes.RangeUint64{{From: 1, To: 2}, {From: 8, To: 9}}
+
+	// adjacent gap spanning scan batches
+	merged := mergeEpochGaps(gaps, []citypes.RangeUint64{{From: 10, To: 12}, {From: 15, To: 15}})
+	assert.Equal(t, []citypes.RangeUint64{{From: 1, To: 2}, {From: 8, To: 12}, {From: 15, To: 15}}, merged)
+
+	// non-adjacent
+	merged = mergeEpochGaps(merged, []citypes.RangeUint64{{From: 17, To: 17}})
+	assert.Equal(t, citypes.RangeUint64{From: 17, To: 17}, merged[len(merged)-1])
+	assert.Len(t, merged, 4)
+}
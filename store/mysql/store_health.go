@@ -0,0 +1,224 @@
+package mysql
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// MySQL error number if lack of `REPLICATION CLIENT` (or `SUPER`) privilege
+	mysqlErrSpecificAccessDenied = 1227
+)
+
+// writeStats records the result of recent writes into store.
+type writeStats struct {
+	mu            sync.Mutex
+	lastSuccessAt time.Time
+	lastErr       error
+	lastErrAt     time.Time
+}
+
+func (ws *writeStats) record(err error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if err == nil {
+		ws.lastSuccessAt = time.Now()
+	} else {
+		ws.lastErr, ws.lastErrAt = err, time.Now()
+	}
+}
+
+// LastWrite returns the time of last successful write, and the last write error if it
+// occurred after that, which is used for health check.
+func (ms *MysqlStore) LastWrite() (lastSuccessAt time.Time, lastErr error) {
+	ms.writeStats.mu.Lock()
+	defer ms.writeStats.mu.Unlock()
+
+	if ms.writeStats.lastErrAt.After(ms.writeStats.lastSuccessAt) {
+		lastErr = ms.writeStats.lastErr
+	}
+
+	return ms.writeStats.lastSuccessAt, lastErr
+}
+
+// Ping checks the connectivity of database.
+func (ms *MysqlStore) Ping() error {
+	sqlDb, err := ms.DB().DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDb.Ping()
+}
+
+// replicationStatus resolves the statement to show replication status by server version.
+type replicationStatus struct {
+	mu        sync.Mutex
+	statement string // resolved statement, empty if not resolved yet
+	// to warn only once if lack of privilege
+	deniedOnce sync.Once
+}
+
+// resolve returns the statement to show replication status, which is resolved only once.
+func (rs *replicationStatus) resolve(db *gorm.DB) (string, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if len(rs.statement) > 0 {
+		return rs.statement, nil
+	}
+
+	var version string
+	if err := db.Raw("SELECT VERSION()").Scan(&version).Error; err != nil {
+		return "", err
+	}
+
+	rs.statement = replicaStatusStatement(version)
+	return rs.statement, nil
+}
+
+// replicaStatusStatement returns `SHOW REPLICA STATUS` for MySQL 8.0.22+ (or MariaDB 10.5.1+), which
+// deprecates `SHOW SLAVE STATUS`.
+func replicaStatusStatement(version string) string {
+	if isVersionAtLeast(version, "10.5.1", "8.0.22") {
+		return "SHOW REPLICA STATUS"
+	}
+
+	return "SHOW SLAVE STATUS"
+}
+
+// isVersionAtLeast checks whether the server version is at least the specified MariaDB or MySQL version.
+func isVersionAtLeast(version, mariadbVersion, mysqlVersion string) bool {
+	minVersion := mysqlVersion
+	if strings.Contains(strings.ToLower(version), "mariadb") {
+		minVersion = mariadbVersion
+	}
+
+	// strip suffix, e.g., `8.0.36-log` or `10.6.16-MariaDB`
+	if i := strings.IndexAny(version, "-+ "); i >= 0 {
+		version = version[:i]
+	}
+
+	current, required := strings.Split(version, "."), strings.Split(minVersion, ".")
+	for i := range required {
+		var cur uint64
+		if i < len(current) {
+			cur, _ = strconv.ParseUint(current[i], 10, 64)
+		}
+
+		req, _ := strconv.ParseUint(required[i], 10, 64)
+		if cur != req {
+			return cur > req
+		}
+	}
+
+	return true
+}
+
+// ReplicationLag returns the replication lag if the database is a replica. Note, lag is unknown
+// (as if not a replica) if lack of privilege to show replication status.
+func (ms *MysqlStore) ReplicationLag() (time.Duration, bool, error) {
+	statement, err := ms.replication.resolve(ms.DB())
+	if err != nil {
+		return 0, false, errors.WithMessage(err, "failed to get server version")
+	}
+
+	rows, err := ms.DB().Raw(statement).Rows()
+	if isAccessDeniedError(err) {
+		ms.replication.deniedOnce.Do(func() {
+			logrus.WithError(err).Warn("Replication lag unknown due to lack of privilege")
+		})
+
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() { // not a replica
+		return 0, false, rows.Err()
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, false, err
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	valuePtrs := make([]interface{}, len(columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return 0, false, err
+	}
+
+	for i, col := range columns {
+		if col != "Seconds_Behind_Master" && col != "Seconds_Behind_Source" {
+			continue
+		}
+
+		// NULL if replication stopped
+		if values[i] == nil {
+			return 0, true, errors.New("replication not running")
+		}
+
+		secs, err := strconv.ParseUint(string(values[i]), 10, 64)
+		if err != nil {
+			return 0, true, errors.WithMessage(err, "invalid replication lag")
+		}
+
+		return time.Duration(secs) * time.Second, true, nil
+	}
+
+	return 0, false, nil
+}
+
+func isAccessDeniedError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrSpecificAccessDenied
+}
+
+// EpochGaps returns the number of missing epochs within the specified window of latest epochs.
+func (ms *MysqlStore) EpochGaps(window uint64) (uint64, error) {
+	maxEpoch, ok, err := ms.MaxEpoch()
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	var epochFrom uint64
+	if maxEpoch >= window {
+		epochFrom = maxEpoch - window + 1
+	}
+
+	var result struct {
+		Cnt      uint64
+		MinEpoch uint64
+	}
+
+	err = ms.DB().Model(&epochBlockMap{}).
+		Select("COUNT(*) AS cnt, MIN(epoch) AS min_epoch").
+		Where("epoch >= ? AND epoch <= ?", epochFrom, maxEpoch).
+		Scan(&result).Error
+	if err != nil {
+		return 0, err
+	}
+
+	if result.Cnt == 0 {
+		return 0, nil
+	}
+
+	return maxEpoch - result.MinEpoch + 1 - result.Cnt, nil
+}
@@ -0,0 +1,32 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplicaStatusStatement(t *testing.T) {
+	for version, statement := range map[string]string{
+		"5.7.44-log":             "SHOW SLAVE STATUS",
+		"8.0.21":                 "SHOW SLAVE STATUS",
+		"8.0.22":                 "SHOW REPLICA STATUS",
+		"8.4.0-commercial":       "SHOW REPLICA STATUS",
+		"10.4.32-MariaDB":        "SHOW SLAVE STATUS",
+		"10.5.1-MariaDB-1:10.5":  "SHOW REPLICA STATUS",
+		"10.11.6-MariaDB-0+deb1": "SHOW REPLICA STATUS",
+	} {
+		assert.Equal(t, statement, replicaStatusStatement(version), version)
+	}
+}
+
+func TestIsAccessDeniedError(t *testing.T) {
+	err := &mysql.MySQLError{Number: mysqlErrSpecificAccessDenied, Message: "Access denied"}
+	assert.True(t, isAccessDeniedError(err))
+	assert.True(t, isAccessDeniedError(errors.WithMessage(err, "failed to show replica status")))
+
+	assert.False(t, isAccessDeniedError(&mysql.MySQLError{Number: 1064}))
+	assert.False(t, isAccessDeniedError(nil))
+}
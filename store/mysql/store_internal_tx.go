@@ -0,0 +1,271 @@
+package mysql
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/ethereum/go-ethereum/common"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultBatchSizeInternalTxInsert = 500
+
+	// max number of internal transactions to return at a time
+	maxInternalTxs = 1000
+)
+
+// Types of internal transactions.
+const (
+	InternalTxTypeCall   = "call"
+	InternalTxTypeCreate = "create"
+)
+
+// InternalTransaction is the value transfer via contract call or creation (but not the transaction
+// itself) recognized from the traces of evm space during sync.
+type InternalTransaction struct {
+	ID          uint64 `gorm:"primaryKey;autoIncrement" json:"id"`
+	Epoch       uint64 `gorm:"not null;index" json:"epoch"`
+	BlockNumber uint64 `gorm:"not null;index" json:"blockNumber"`
+	TxHash      string `gorm:"size:66;not null" json:"transactionHash"`
+	TxPosition  uint64 `gorm:"not null" json:"transactionPosition"`
+	TraceIndex  uint64 `gorm:"not null" json:"traceIndex"` // index of trace within the transaction
+	Type        string `gorm:"size:16;not null" json:"type"`
+	CallType    string `gorm:"size:16;not null;default:''" json:"callType,omitempty"`
+	From        string `gorm:"column:from_address;size:42;not null;index" json:"from"` // lowercase hex address
+	To          string `gorm:"column:to_address;size:42;not null;index" json:"to"`     // lowercase hex address
+	Value       string `gorm:"size:78;not null" json:"value"`                          // decimal
+}
+
+func (InternalTransaction) TableName() string {
+	return "internal_txs"
+}
+
+// InternalTxStore indexes internal transactions by address and block during sync.
+type InternalTxStore struct {
+	*baseStore
+}
+
+// mustNewInternalTxStore creates internal transaction store, and creates the table if enabled and absent.
+func mustNewInternalTxStore(db *gorm.DB, enabled bool) *InternalTxStore {
+	if enabled && !db.Migrator().HasTable(&InternalTransaction{}) {
+		if err := db.Migrator().CreateTable(&InternalTransaction{}); err != nil {
+			logrus.WithError(err).Fatal("Failed to create internal transaction table")
+		}
+	}
+
+	return &InternalTxStore{baseStore: newBaseStore(db)}
+}
+
+// add saves the internal transactions recognized from block traces of the epoch data within the
+// database transaction.
+func (its *InternalTxStore) add(dbTx *gorm.DB, dataSlice []*store.EpochData) error {
+	var internalTxs []*InternalTransaction
+
+	for _, data := range dataSlice {
+		for _, blockExt := range data.BlockExts {
+			if blockExt == nil {
+				continue
+			}
+
+			for _, itx := range parseInternalTxs(blockExt.Traces) {
+				itx.Epoch = data.Number
+				internalTxs = append(internalTxs, itx)
+			}
+		}
+	}
+
+	if len(internalTxs) == 0 {
+		return nil
+	}
+
+	return dbTx.CreateInBatches(internalTxs, defaultBatchSizeInternalTxInsert).Error
+}
+
+// remove removes the internal transactions of the specified epoch range within the database transaction.
+func (its *InternalTxStore) remove(dbTx *gorm.DB, epochFrom, epochTo uint64) error {
+	return dbTx.Where("epoch >= ? AND epoch <= ?", epochFrom, epochTo).Delete(&InternalTransaction{}).Error
+}
+
+// InternalTxsByAddress returns the internal transactions from or to the specified address in
+// descending order of ID before the cursor ID, or the latest ones if cursor is zero.
+func (its *InternalTxStore) InternalTxsByAddress(addr common.Address, cursor uint64, limit int) ([]InternalTransaction, error) {
+	hexAddr := strings.ToLower(addr.Hex())
+	limit = normalizeInternalTxLimit(limit)
+
+	// query separately rather than OR condition so as to make use of both indices
+	sent, err := its.internalTxs("from_address = ?", hexAddr, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	received, err := its.internalTxs("to_address = ?", hexAddr, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// merge and dedup self transfers
+	merged := make(map[uint64]InternalTransaction, len(sent)+len(received))
+	for _, itx := range append(sent, received...) {
+		merged[itx.ID] = itx
+	}
+
+	result := make([]InternalTransaction, 0, len(merged))
+	for _, itx := range merged {
+		result = append(result, itx)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID > result[j].ID })
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+// InternalTxsByBlock returns the internal transactions of the specified block in ascending order
+// of ID, at most 1000 internal transactions at a time.
+func (its *InternalTxStore) InternalTxsByBlock(blockNumber uint64) ([]InternalTransaction, error) {
+	var internalTxs []InternalTransaction
+	err := its.db.Where("block_number = ?", blockNumber).
+		Order("id ASC").
+		Limit(maxInternalTxs).
+		Find(&internalTxs).Error
+
+	return internalTxs, err
+}
+
+func (its *InternalTxStore) internalTxs(cond string, addr string, cursor uint64, limit int) ([]InternalTransaction, error) {
+	db := its.db.Where(cond, addr)
+	if cursor > 0 {
+		db = db.Where("id < ?", cursor)
+	}
+
+	var internalTxs []InternalTransaction
+	err := db.Order("id DESC").Limit(normalizeInternalTxLimit(limit)).Find(&internalTxs).Error
+
+	return internalTxs, err
+}
+
+func normalizeInternalTxLimit(limit int) int {
+	if limit <= 0 || limit > maxInternalTxs {
+		return maxInternalTxs
+	}
+
+	return limit
+}
+
+// parseInternalTxs recognizes the internal value transfers from the block traces, which are ordered
+// by transaction and in pre-order of call tree within transaction. The top level trace (transaction
+// itself), delegate or static calls, zero value transfers and those reverted along with any ancestor
+// are all excluded.
+func parseInternalTxs(traces []web3Types.LocalizedTrace) (result []*InternalTransaction) {
+	type frame struct {
+		pending uint // number of pending subtraces
+		failed  bool
+	}
+
+	var (
+		stack      []frame
+		lastTxHash common.Hash
+		traceIndex uint64
+	)
+
+	for i := range traces {
+		trace := &traces[i]
+
+		// e.g., block rewards
+		if trace.TransactionHash == nil {
+			continue
+		}
+
+		if *trace.TransactionHash != lastTxHash {
+			stack, lastTxHash, traceIndex = stack[:0], *trace.TransactionHash, 0
+		} else {
+			traceIndex++
+		}
+
+		// pop the traces whose subtraces all visited
+		for len(stack) > 0 && stack[len(stack)-1].pending == 0 {
+			stack = stack[:len(stack)-1]
+		}
+
+		topLevel, failed := len(stack) == 0, trace.Error != nil
+		if !topLevel {
+			stack[len(stack)-1].pending--
+			failed = failed || stack[len(stack)-1].failed
+		}
+
+		if trace.Subtraces > 0 {
+			stack = append(stack, frame{pending: trace.Subtraces, failed: failed})
+		}
+
+		if topLevel || failed {
+			continue
+		}
+
+		itx, ok := parseInternalTx(trace)
+		if !ok {
+			continue
+		}
+
+		itx.BlockNumber = trace.BlockNumber
+		itx.TxHash = trace.TransactionHash.Hex()
+		itx.TraceIndex = traceIndex
+		if trace.TransactionPosition != nil {
+			itx.TxPosition = uint64(*trace.TransactionPosition)
+		}
+
+		result = append(result, itx)
+	}
+
+	return result
+}
+
+// parseInternalTx recognizes the value transfer of call or create trace.
+func parseInternalTx(trace *web3Types.LocalizedTrace) (*InternalTransaction, bool) {
+	switch trace.Type {
+	case web3Types.TRACE_CALL:
+		call, ok := trace.Action.(web3Types.Call)
+		if !ok || call.Value == nil || call.Value.Sign() <= 0 {
+			return nil, false
+		}
+
+		// value of delegate call is inherited from the caller, and callcode transfers to the caller itself
+		callType := string(call.CallType)
+		if callType != InternalTxTypeCall {
+			return nil, false
+		}
+
+		return &InternalTransaction{
+			Type:     InternalTxTypeCall,
+			CallType: callType,
+			From:     strings.ToLower(call.From.Hex()),
+			To:       strings.ToLower(call.To.Hex()),
+			Value:    call.Value.String(),
+		}, true
+	case web3Types.TRACE_CREATE:
+		create, ok := trace.Action.(web3Types.Create)
+		if !ok || create.Value == nil || create.Value.Sign() <= 0 {
+			return nil, false
+		}
+
+		result, ok := trace.Result.(web3Types.CreateResult)
+		if !ok {
+			return nil, false
+		}
+
+		return &InternalTransaction{
+			Type:  InternalTxTypeCreate,
+			From:  strings.ToLower(create.From.Hex()),
+			To:    strings.ToLower(result.Address.Hex()),
+			Value: create.Value.String(),
+		}, true
+	default:
+		return nil, false
+	}
+}
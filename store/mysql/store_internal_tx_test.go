@@ -0,0 +1,69 @@
+package mysql
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInternalTxs(t *testing.T) {
+	tx1, tx2 := common.HexToHash("0x01"), common.HexToHash("0x02")
+	addr1 := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	addr2 := common.HexToAddress("0x1000000000000000000000000000000000000002")
+	reverted := "Reverted"
+
+	newCall := func(txHash common.Hash, callType string, value int64, subtraces uint) web3Types.LocalizedTrace {
+		return web3Types.LocalizedTrace{
+			Type:            web3Types.TRACE_CALL,
+			Action:          web3Types.Call{From: addr1, To: addr2, Value: big.NewInt(value), CallType: web3Types.CallType(callType)},
+			Subtraces:       subtraces,
+			TransactionHash: &txHash,
+			BlockNumber:     100,
+		}
+	}
+
+	failedCall := newCall(tx1, "call", 1, 1)
+	failedCall.Error = &reverted
+
+	traces := []web3Types.LocalizedTrace{
+		// transaction itself
+		newCall(tx1, "call", 5, 4),
+		// reverted along with its subtrace
+		failedCall,
+		newCall(tx1, "call", 2, 0),
+		// zero value
+		newCall(tx1, "call", 0, 0),
+		newCall(tx1, "call", 6, 0),
+		{
+			Type:            web3Types.TRACE_CREATE,
+			Action:          web3Types.Create{From: addr2, Value: big.NewInt(3)},
+			Result:          web3Types.CreateResult{Address: addr1},
+			TransactionHash: &tx1,
+			BlockNumber:     100,
+		},
+		// value of delegate call inherited from the transaction
+		newCall(tx2, "call", 4, 1),
+		newCall(tx2, "delegatecall", 4, 0),
+	}
+
+	result := parseInternalTxs(traces)
+	assert.Len(t, result, 2)
+
+	assert.Equal(t, InternalTxTypeCall, result[0].Type)
+	assert.Equal(t, "call", result[0].CallType)
+	assert.Equal(t, "0x1000000000000000000000000000000000000001", result[0].From)
+	assert.Equal(t, "0x1000000000000000000000000000000000000002", result[0].To)
+	assert.Equal(t, "6", result[0].Value)
+	assert.Equal(t, tx1.Hex(), result[0].TxHash)
+	assert.Equal(t, uint64(4), result[0].TraceIndex)
+	assert.Equal(t, uint64(100), result[0].BlockNumber)
+
+	assert.Equal(t, InternalTxTypeCreate, result[1].Type)
+	assert.Equal(t, "0x1000000000000000000000000000000000000002", result[1].From)
+	assert.Equal(t, "0x1000000000000000000000000000000000000001", result[1].To)
+	assert.Equal(t, "3", result[1].Value)
+	assert.Equal(t, uint64(5), result[1].TraceIndex)
+}
@@ -62,6 +62,50 @@ func (ls *logStore) preparePartition(dataSlice []*store.EpochData) (bnPartition,
 }
 
 func (ls *logStore) Add(dbTx *gorm.DB, dataSlice []*store.EpochData, logPartition bnPartition) error {
+	logs, err := ls.collectLogs(dataSlice)
+	if err != nil {
+		return err
+	}
+
+	// update block range for log partition router
+	bnMin := dataSlice[0].Blocks[0].BlockNumber.ToInt().Uint64()
+	bnMax := dataSlice[len(dataSlice)-1].GetPivotBlock().BlockNumber.ToInt().Uint64()
+
+	err = ls.expandBnRange(dbTx, bnPartitionedLogEntity, int(logPartition.Index), bnMin, bnMax)
+	if err != nil {
+		return errors.WithMessage(err, "failed to expand partition bn range")
+	}
+
+	return ls.addToPartition(dbTx, logs, logPartition)
+}
+
+// backfill adds event logs of the missing epochs into the partition which already covers the
+// block number range of these epochs.
+func (ls *logStore) backfill(dbTx *gorm.DB, dataSlice []*store.EpochData) error {
+	logs, err := ls.collectLogs(dataSlice)
+	if err != nil || len(logs) == 0 {
+		return err
+	}
+
+	bnRange := types.RangeUint64{
+		From: dataSlice[0].Blocks[0].BlockNumber.ToInt().Uint64(),
+		To:   dataSlice[len(dataSlice)-1].GetPivotBlock().BlockNumber.ToInt().Uint64(),
+	}
+
+	partition, ok, err := ls.coveringPartition(bnPartitionedLogEntity, bnRange)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get covering log partition")
+	}
+
+	if !ok {
+		return errors.Errorf("no log partition covers block range %v", bnRange)
+	}
+
+	return ls.addToPartition(dbTx, logs, *partition)
+}
+
+// collectLogs collects event logs of executed transactions from epoch data.
+func (ls *logStore) collectLogs(dataSlice []*store.EpochData) ([]*log, error) {
 	// containers to collect event logs for batch inserting
 	var logs []*log
 
@@ -85,7 +129,7 @@ func (ls *logStore) Add(dbTx *gorm.DB, dataSlice []*store.EpochData, logPartitio
 				for k, rlog := range receipt.Logs {
 					cid, _, err := ls.cs.AddContractIfAbsent(rlog.Address.MustGetBase32Address())
 					if err != nil {
-						return errors.WithMessage(err, "failed to add contract")
+						return nil, errors.WithMessage(err, "failed to add contract")
 					}
 
 					var logExt *store.LogExtra
@@ -100,21 +144,17 @@ func (ls *logStore) Add(dbTx *gorm.DB, dataSlice []*store.EpochData, logPartitio
 		}
 	}
 
-	// update block range for log partition router
-	bnMin := dataSlice[0].Blocks[0].BlockNumber.ToInt().Uint64()
-	bnMax := dataSlice[len(dataSlice)-1].GetPivotBlock().BlockNumber.ToInt().Uint64()
-
-	err := ls.expandBnRange(dbTx, bnPartitionedLogEntity, int(logPartition.Index), bnMin, bnMax)
-	if err != nil {
-		return errors.WithMessage(err, "failed to expand partition bn range")
-	}
+	return logs, nil
+}
 
+// addToPartition inserts event logs into the specified log partition.
+func (ls *logStore) addToPartition(dbTx *gorm.DB, logs []*log, logPartition bnPartition) error {
 	if len(logs) == 0 {
 		return nil
 	}
 
 	tblName := ls.getPartitionedTableName(&ls.model, logPartition.Index)
-	err = dbTx.Table(tblName).CreateInBatches(logs, defaultBatchSizeLogInsert).Error
+	err := dbTx.Table(tblName).CreateInBatches(logs, defaultBatchSizeLogInsert).Error
 	if err != nil {
 		return err
 	}
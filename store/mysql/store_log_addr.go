@@ -119,6 +119,21 @@ func (ls *AddressIndexedLogStore) convertToPartitionedLogs(
 
 // AddAddressIndexedLogs adds event logs of specified epoch (with that of big contract ignored) into different partitioned tables.
 func (ls *AddressIndexedLogStore) AddAddressIndexedLogs(dbTx *gorm.DB, data *store.EpochData, bigContractIds map[uint64]bool) error {
+	return ls.addAddressIndexedLogs(dbTx, data, bigContractIds, ls.cs.UpdateContractStats)
+}
+
+// BackfillAddressIndexedLogs adds event logs of specified past epoch (with that of big contract ignored)
+// into different partitioned tables.
+func (ls *AddressIndexedLogStore) BackfillAddressIndexedLogs(
+	dbTx *gorm.DB, data *store.EpochData, bigContractIds map[uint64]bool,
+) error {
+	return ls.addAddressIndexedLogs(dbTx, data, bigContractIds, ls.cs.BackfillContractStats)
+}
+
+func (ls *AddressIndexedLogStore) addAddressIndexedLogs(
+	dbTx *gorm.DB, data *store.EpochData, bigContractIds map[uint64]bool,
+	updateStats func(dbTx *gorm.DB, cid uint64, countDelta int, epoch uint64) error,
+) error {
 	// divide event logs into different partitions by address
 	partition2Logs, contract2LogCount, err := ls.convertToPartitionedLogs(data, bigContractIds)
 	if err != nil {
@@ -135,7 +150,7 @@ func (ls *AddressIndexedLogStore) AddAddressIndexedLogs(dbTx *gorm.DB, data *sto
 
 	for cid, logCount := range contract2LogCount {
 		// Update contract statistics (log count and lastest updated epoch).
-		if err := ls.cs.UpdateContractStats(dbTx, cid, logCount, data.Number); err != nil {
+		if err := updateStats(dbTx, cid, logCount, data.Number); err != nil {
 			return errors.WithMessage(err, "failed to update contract statistics")
 		}
 	}
@@ -204,6 +204,104 @@ func (bcls *bigContractLogStore) contractTabler(cid uint64) *contractLog {
 func (bcls *bigContractLogStore) Add(
 	dbTx *gorm.DB, dataSlice []*store.EpochData, contract2BnPartitions map[uint64]bnPartition,
 ) error {
+	contract2Logs, err := bcls.collectLogs(dataSlice, contract2BnPartitions)
+	if err != nil {
+		return err
+	}
+
+	bnMin := dataSlice[0].Blocks[0].BlockNumber.ToInt().Uint64()
+	bnMax := dataSlice[len(dataSlice)-1].GetPivotBlock().BlockNumber.ToInt().Uint64()
+
+	for cid, partition := range contract2BnPartitions {
+		clEntity, clTabler := bcls.contractEntity(cid), bcls.contractTabler(cid)
+
+		// update block range for contract log partition router
+		err := bcls.expandBnRange(dbTx, clEntity, int(partition.Index), bnMin, bnMax)
+		if err != nil {
+			return errors.WithMessage(err, "failed to expand partition bn range")
+		}
+
+		logs := contract2Logs[cid]
+		if len(logs) == 0 {
+			continue
+		}
+
+		if err := bcls.addToPartition(dbTx, clEntity, clTabler, logs, partition); err != nil {
+			return err
+		}
+
+		// Update contract statistics (log count and lastest updated epoch).
+		latestUpdateEpoch := logs[len(logs)-1].Epoch
+		if err := bcls.cs.UpdateContractStats(dbTx, cid, len(logs), latestUpdateEpoch); err != nil {
+			return errors.WithMessage(err, "failed to update contract statistics")
+		}
+	}
+
+	return nil
+}
+
+// backfill adds event logs of big contracts for the missing epochs into the contract log partitions
+// which already cover the block number range of these epochs, and returns the ids of these contracts.
+func (bcls *bigContractLogStore) backfill(dbTx *gorm.DB, dataSlice []*store.EpochData) (map[uint64]bool, error) {
+	bnRange := types.RangeUint64{
+		From: dataSlice[0].Blocks[0].BlockNumber.ToInt().Uint64(),
+		To:   dataSlice[len(dataSlice)-1].GetPivotBlock().BlockNumber.ToInt().Uint64(),
+	}
+
+	contract2BnPartitions := make(map[uint64]bnPartition)
+	for caddr := range extractUniqueContractAddresses(dataSlice...) {
+		cid, ok, err := bcls.cs.GetContractIdByAddress(caddr)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to get contract id by addr")
+		}
+
+		if !ok {
+			continue
+		}
+
+		// contract logs are still address indexed if no partition covers yet
+		partition, ok, err := bcls.coveringPartition(bcls.contractEntity(cid), bnRange)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to get covering contract log partition")
+		}
+
+		if ok {
+			contract2BnPartitions[cid] = *partition
+		}
+	}
+
+	contract2Logs, err := bcls.collectLogs(dataSlice, contract2BnPartitions)
+	if err != nil {
+		return nil, err
+	}
+
+	bigContractIds := make(map[uint64]bool, len(contract2BnPartitions))
+	for cid, partition := range contract2BnPartitions {
+		bigContractIds[cid] = true
+
+		logs := contract2Logs[cid]
+		if len(logs) == 0 {
+			continue
+		}
+
+		err := bcls.addToPartition(dbTx, bcls.contractEntity(cid), bcls.contractTabler(cid), logs, partition)
+		if err != nil {
+			return nil, err
+		}
+
+		latestUpdateEpoch := logs[len(logs)-1].Epoch
+		if err := bcls.cs.BackfillContractStats(dbTx, cid, len(logs), latestUpdateEpoch); err != nil {
+			return nil, errors.WithMessage(err, "failed to update contract statistics")
+		}
+	}
+
+	return bigContractIds, nil
+}
+
+// collectLogs collects event logs of the specified big contracts from epoch data.
+func (bcls *bigContractLogStore) collectLogs(
+	dataSlice []*store.EpochData, contract2BnPartitions map[uint64]bnPartition,
+) (map[uint64][]*contractLog, error) {
 	contract2Logs := make(map[uint64][]*contractLog, len(contract2BnPartitions))
 
 	for _, data := range dataSlice {
@@ -226,7 +324,7 @@ func (bcls *bigContractLogStore) Add(
 				for k, log := range receipt.Logs {
 					cid, _, err := bcls.cs.AddContractIfAbsent(log.Address.MustGetBase32Address())
 					if err != nil {
-						return errors.WithMessage(err, "failed to add contract")
+						return nil, errors.WithMessage(err, "failed to add contract")
 					}
 
 					// only collect big contract event logs
@@ -246,40 +344,22 @@ func (bcls *bigContractLogStore) Add(
 		}
 	}
 
-	bnMin := dataSlice[0].Blocks[0].BlockNumber.ToInt().Uint64()
-	bnMax := dataSlice[len(dataSlice)-1].GetPivotBlock().BlockNumber.ToInt().Uint64()
-
-	for cid, partition := range contract2BnPartitions {
-		clEntity, clTabler := bcls.contractEntity(cid), bcls.contractTabler(cid)
-
-		// update block range for contract log partition router
-		err := bcls.expandBnRange(dbTx, clEntity, int(partition.Index), bnMin, bnMax)
-		if err != nil {
-			return errors.WithMessage(err, "failed to expand partition bn range")
-		}
-
-		logs := contract2Logs[cid]
-		if len(logs) == 0 {
-			continue
-		}
-
-		tblName := bcls.getPartitionedTableName(clTabler, partition.Index)
-		err = dbTx.Table(tblName).CreateInBatches(logs, defaultBatchSizeLogInsert).Error
-		if err != nil {
-			return err
-		}
+	return contract2Logs, nil
+}
 
-		// update partition data count
-		err = bcls.deltaUpdateCount(dbTx, clEntity, int(partition.Index), len(logs))
-		if err != nil {
-			return errors.WithMessage(err, "failed to delta update partition size")
-		}
+// addToPartition inserts event logs of big contract into the specified contract log partition.
+func (bcls *bigContractLogStore) addToPartition(
+	dbTx *gorm.DB, clEntity string, clTabler *contractLog, logs []*contractLog, partition bnPartition,
+) error {
+	tblName := bcls.getPartitionedTableName(clTabler, partition.Index)
+	if err := dbTx.Table(tblName).CreateInBatches(logs, defaultBatchSizeLogInsert).Error; err != nil {
+		return err
+	}
 
-		// Update contract statistics (log count and lastest updated epoch).
-		latestUpdateEpoch := logs[len(logs)-1].Epoch
-		if err := bcls.cs.UpdateContractStats(dbTx, cid, len(logs), latestUpdateEpoch); err != nil {
-			return errors.WithMessage(err, "failed to update contract statistics")
-		}
+	// update partition data count
+	err := bcls.deltaUpdateCount(dbTx, clEntity, int(partition.Index), len(logs))
+	if err != nil {
+		return errors.WithMessage(err, "failed to delta update partition size")
 	}
 
 	return nil
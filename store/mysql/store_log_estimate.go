@@ -0,0 +1,125 @@
+package mysql
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/types"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// scan event logs from contract indexed tables
+	LogScanPathIndex = "index"
+	// scan event logs from block number ranged table
+	LogScanPathRange = "range"
+)
+
+// LogQueryEstimate is the estimated cost to query event logs from database.
+type LogQueryEstimate struct {
+	Path string // scan path of query execution
+	Rows uint64 // estimated number of rows to scan, ignoring topics
+}
+
+// EstimateLogs estimates the number of rows to scan for the specified log filter from the index
+// statistics, which is used to plan the getLogs query execution.
+func (ms *MysqlStore) EstimateLogs(ctx context.Context, storeFilter store.LogFilter) (LogQueryEstimate, error) {
+	bnRange := types.RangeUint64{From: storeFilter.BlockFrom, To: storeFilter.BlockTo}
+
+	contracts := storeFilter.Contracts.ToSlice()
+	if len(contracts) == 0 {
+		rows, err := ms.ls.estimateRows(bnPartitionedLogEntity, &log{}, bnRange)
+		return LogQueryEstimate{Path: LogScanPathRange, Rows: rows}, err
+	}
+
+	estimate := LogQueryEstimate{Path: LogScanPathIndex}
+	for _, addr := range contracts {
+		select {
+		case <-ctx.Done():
+			return estimate, store.ErrGetLogsTimeout
+		default:
+		}
+
+		cid, exists, err := ms.cs.GetContractIdByAddress(addr)
+		if err != nil {
+			return estimate, err
+		}
+
+		if !exists {
+			continue
+		}
+
+		isBigContract, err := ms.bcls.IsBigContract(cid)
+		if err != nil {
+			return estimate, err
+		}
+
+		var rows uint64
+		if isBigContract {
+			rows, err = ms.bcls.estimateRows(ms.bcls.contractEntity(cid), ms.bcls.contractTabler(cid), bnRange)
+		} else {
+			rows, err = ms.ails.estimateRows(cid, addr, bnRange)
+		}
+
+		if err != nil {
+			return estimate, err
+		}
+
+		estimate.Rows += rows
+	}
+
+	return estimate, nil
+}
+
+// estimateRows estimates the number of rows within the block number range across all partitions
+// of the specified entity.
+func (bnps *bnPartitionedStore) estimateRows(
+	entity string, tabler schema.Tabler, bnRange types.RangeUint64,
+) (uint64, error) {
+	partitions, _, err := bnps.searchPartitions(entity, bnRange)
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to search partitions")
+	}
+
+	var total uint64
+	for _, partition := range partitions {
+		filter := LogFilter{
+			TableName: bnps.getPartitionedTableName(tabler, partition.Index),
+			BlockFrom: bnRange.From,
+			BlockTo:   bnRange.To,
+		}
+
+		_, rows, err := filter.calculateQuerySetSize(bnps.db)
+		if err != nil {
+			return 0, err
+		}
+
+		total += rows
+	}
+
+	return total, nil
+}
+
+// estimateRows counts the number of rows of the contract within the block number range by index,
+// which is capped by the max query set size to bound the counting cost.
+func (ls *AddressIndexedLogStore) estimateRows(cid uint64, contract string, bnRange types.RangeUint64) (uint64, error) {
+	subQuery := ls.db.
+		Table(ls.GetPartitionedTableName(contract)).
+		Select("id").
+		Where("cid = ?", cid).
+		Where("bn BETWEEN ? AND ?", bnRange.From, bnRange.To).
+		Limit(maxLogQuerySetSize + 1)
+
+	var rows int64
+	if err := ls.db.Table("(?) AS t", subQuery).Count(&rows).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	return uint64(rows), nil
+}
@@ -0,0 +1,117 @@
+package mysql
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/pkg/errors"
+)
+
+const (
+	// initial number of blocks to fetch event logs from database at a time
+	forEachLogChunkBlocks = uint64(1000)
+	// max number of event logs to fetch from database at a time, and the number of blocks of the
+	// next chunk will be shrunk if exceeded
+	forEachLogChunkLogs = int(store.MaxLogLimit)
+)
+
+// errLogPageFilled is used to stop iteration once the log page filled.
+var errLogPageFilled = errors.New("log page filled")
+
+// ForEachLog iterates the event logs matched with the log filter in order of block number and log
+// index. Rather than materializing the full result set, event logs are fetched from database chunk
+// by chunk of block range, whose size adapts to the density of event logs, so that huge queries
+// won't spike memory. Iteration stops once the callback returns an error, which is returned as is.
+//
+// Note, bound checks are not applied to chunks, and the callback is responsible to restrict the
+// total size of result set if necessary.
+func (ms *MysqlStore) ForEachLog(ctx context.Context, storeFilter store.LogFilter, fn func(*store.Log) error) error {
+	// fail fast rather than partially iterated if any epoch parked
+	if err := ms.requireBlocksNotParked(storeFilter.BlockFrom, storeFilter.BlockTo); err != nil {
+		return err
+	}
+
+	ctx = store.NewContextWithBoundChecksDisabled(ctx)
+	chunkBlocks := forEachLogChunkBlocks
+
+	for from := storeFilter.BlockFrom; from <= storeFilter.BlockTo; {
+		// check timeout before query
+		select {
+		case <-ctx.Done():
+			return store.ErrGetLogsTimeout
+		default:
+		}
+
+		to := storeFilter.BlockTo
+		if to-from >= chunkBlocks {
+			to = from + chunkBlocks - 1
+		}
+
+		chunkFilter := storeFilter
+		chunkFilter.BlockFrom, chunkFilter.BlockTo = from, to
+
+		logs, err := ms.GetLogs(ctx, chunkFilter)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range logs {
+			if err := fn(v); err != nil {
+				return err
+			}
+		}
+
+		from = to + 1
+		chunkBlocks = nextForEachLogChunkBlocks(chunkBlocks, len(logs))
+
+		if to == storeFilter.BlockTo { // avoid overflow
+			break
+		}
+	}
+
+	return nil
+}
+
+// GetLogPage returns a page of event logs matched with the log filter. Event logs are iterated
+// chunk by chunk, and iteration stops as soon as the page filled, so that neither the skipped nor
+// the remaining event logs are materialized at once.
+func (ms *MysqlStore) GetLogPage(
+	ctx context.Context, storeFilter store.LogFilter, skip, limit uint64,
+) ([]*store.Log, error) {
+	var page []*store.Log
+	if limit == 0 {
+		return page, nil
+	}
+
+	var matched uint64
+	err := ms.ForEachLog(ctx, storeFilter, func(log *store.Log) error {
+		if matched++; matched <= skip {
+			return nil
+		}
+
+		if page = append(page, log); uint64(len(page)) >= limit {
+			return errLogPageFilled
+		}
+
+		return nil
+	})
+
+	if err != nil && err != errLogPageFilled {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// nextForEachLogChunkBlocks shrinks the number of blocks of the next chunk if the event logs of
+// the previous chunk are too dense, or grows it back if sparse.
+func nextForEachLogChunkBlocks(chunkBlocks uint64, numLogs int) uint64 {
+	switch {
+	case numLogs > forEachLogChunkLogs:
+		return max(chunkBlocks/2, 1)
+	case numLogs < forEachLogChunkLogs/4:
+		return min(chunkBlocks*2, forEachLogChunkBlocks)
+	default:
+		return chunkBlocks
+	}
+}
@@ -0,0 +1,20 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextForEachLogChunkBlocks(t *testing.T) {
+	// shrink if too dense
+	assert.Equal(t, uint64(500), nextForEachLogChunkBlocks(1000, forEachLogChunkLogs+1))
+	assert.Equal(t, uint64(1), nextForEachLogChunkBlocks(1, forEachLogChunkLogs+1))
+
+	// keep if moderate
+	assert.Equal(t, uint64(500), nextForEachLogChunkBlocks(500, forEachLogChunkLogs/2))
+
+	// grow back if sparse, bounded by the initial chunk size
+	assert.Equal(t, uint64(1000), nextForEachLogChunkBlocks(500, 0))
+	assert.Equal(t, forEachLogChunkBlocks, nextForEachLogChunkBlocks(forEachLogChunkBlocks, 0))
+}
@@ -114,6 +114,22 @@ func (e2bms *epochBlockMapStore) MaxEpoch() (uint64, bool, error) {
 	return uint64(maxEpoch.Int64), true, nil
 }
 
+// MinEpoch returns the min epoch within the map store.
+func (e2bms *epochBlockMapStore) MinEpoch() (uint64, bool, error) {
+	var minEpoch sql.NullInt64
+
+	db := e2bms.db.Model(&epochBlockMap{}).Select("MIN(epoch)")
+	if err := db.Find(&minEpoch).Error; err != nil {
+		return 0, false, err
+	}
+
+	if !minEpoch.Valid {
+		return 0, false, nil
+	}
+
+	return uint64(minEpoch.Int64), true, nil
+}
+
 // blockRange returns the spanning block range for the give epoch.
 func (e2bms *epochBlockMapStore) BlockRange(epoch uint64) (citypes.RangeUint64, bool, error) {
 	var e2bmap epochBlockMap
@@ -161,6 +177,44 @@ func (e2bms *epochBlockMapStore) PivotHash(epoch uint64) (string, bool, error) {
 	return e2bmap.PivotHash, existed, nil
 }
 
+// PivotHashes returns the pivot hashes of the stored epochs within the specified epoch range.
+func (e2bms *epochBlockMapStore) PivotHashes(epochFrom, epochTo uint64) (map[uint64]string, error) {
+	var e2bmaps []epochBlockMap
+
+	err := e2bms.db.Select("epoch", "pivot_hash").
+		Where("epoch >= ? AND epoch <= ?", epochFrom, epochTo).
+		Find(&e2bmaps).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[uint64]string, len(e2bmaps))
+	for _, v := range e2bmaps {
+		hashes[v.Epoch] = v.PivotHash
+	}
+
+	return hashes, nil
+}
+
+// BlockRanges returns the spanning block ranges of the stored epochs within the specified epoch range.
+func (e2bms *epochBlockMapStore) BlockRanges(epochFrom, epochTo uint64) (map[uint64]citypes.RangeUint64, error) {
+	var e2bmaps []epochBlockMap
+
+	err := e2bms.db.Select("epoch", "bn_min", "bn_max").
+		Where("epoch >= ? AND epoch <= ?", epochFrom, epochTo).
+		Find(&e2bmaps).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ranges := make(map[uint64]citypes.RangeUint64, len(e2bmaps))
+	for _, v := range e2bmaps {
+		ranges[v.Epoch] = citypes.RangeUint64{From: v.BnMin, To: v.BnMax}
+	}
+
+	return ranges, nil
+}
+
 // Add batch saves epoch to block mapping data to db store.
 func (e2bms *epochBlockMapStore) Add(dbTx *gorm.DB, dataSlice []*store.EpochData) error {
 	var mappings []*epochBlockMap
@@ -0,0 +1,239 @@
+package mysql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	errOverwriteOutOfRange = errors.New("epochs to overwrite are out of the store range")
+)
+
+// Overwrite re-persists the epoch data of the specified epochs, which must be continuous and fall
+// strictly within the min and max epoch of the store, e.g. to repair corrupted data. The stored data
+// of these epochs (if any) are removed and replaced within a single database transaction, while the
+// stored epochs out of range won't be touched.
+func (ms *MysqlStore) Overwrite(dataSlice []*store.EpochData) error {
+	if len(dataSlice) == 0 {
+		return nil
+	}
+
+	err := ms.overwrite(dataSlice)
+	ms.writeStats.record(err)
+
+	if err == nil {
+		ms.notifyEpochsPushed(dataSlice)
+	}
+
+	return err
+}
+
+func (ms *MysqlStore) overwrite(dataSlice []*store.EpochData) error {
+	if err := store.RequireContinuous(dataSlice, citypes.EpochNumberNil); err != nil {
+		return err
+	}
+
+	epochFrom, epochTo := dataSlice[0].Number, dataSlice[len(dataSlice)-1].Number
+	if err := ms.requireInnerEpochs(epochFrom, epochTo, errOverwriteOutOfRange); err != nil {
+		return err
+	}
+
+	if err := ms.requirePivotContinuous(dataSlice); err != nil {
+		return err
+	}
+
+	startTime := time.Now()
+	defer metrics.Registry.Store.Push("mysql").UpdateSince(startTime)
+
+	if err := ms.addBackfillContracts(dataSlice); err != nil {
+		return err
+	}
+
+	return ms.baseStore.db.Transaction(func(dbTx *gorm.DB) error {
+		if err := ms.removeRangeWithTx(dbTx, epochFrom, epochTo); err != nil {
+			return err
+		}
+
+		return ms.backfillWithTx(dbTx, dataSlice)
+	})
+}
+
+// removeRangeWithTx removes epoch data of the specified epoch range, which could be in the middle
+// of store, within the database transaction.
+func (ms *MysqlStore) removeRangeWithTx(dbTx *gorm.DB, epochFrom, epochTo uint64) error {
+	if !ms.disabler.IsChainBlockDisabled() {
+		if err := ms.blockStore.Remove(dbTx, epochFrom, epochTo); err != nil {
+			return errors.WithMessage(err, "failed to remove blocks")
+		}
+	}
+
+	skipTxn := ms.disabler.IsChainTxnDisabled()
+	skipRcpt := ms.disabler.IsChainReceiptDisabled()
+	if !skipRcpt || !skipTxn {
+		if err := ms.txStore.Remove(dbTx, epochFrom, epochTo); err != nil {
+			return errors.WithMessage(err, "failed to remove transactions")
+		}
+	}
+
+	if !ms.disabler.IsChainLogDisabled() {
+		bnRange, ok, err := ms.epochBlockMapStore.blockRangeOfEpochs(dbTx, epochFrom, epochTo)
+		if err != nil {
+			return errors.WithMessage(err, "failed to get block range of epochs")
+		}
+
+		if ok && ms.config.AddressIndexedLogEnabled {
+			if err := ms.ails.removeRange(dbTx, epochFrom, epochTo); err != nil {
+				return errors.WithMessage(err, "failed to remove address indexed event logs")
+			}
+
+			if err := ms.bcls.removeRange(dbTx, epochFrom, bnRange); err != nil {
+				return errors.WithMessage(err, "failed to remove big contract logs")
+			}
+		}
+
+		if ok {
+			if err := ms.ls.removeRange(dbTx, bnPartitionedLogEntity, &log{}, bnRange); err != nil {
+				return errors.WithMessage(err, "failed to remove universal event logs")
+			}
+		}
+
+		if ms.config.TokenTransferIndexEnabled {
+			if err := ms.TokenTransferStore.remove(dbTx, epochFrom, epochTo); err != nil {
+				return errors.WithMessage(err, "failed to remove token transfers")
+			}
+		}
+	}
+
+	if ms.config.InternalTxIndexEnabled {
+		if err := ms.InternalTxStore.remove(dbTx, epochFrom, epochTo); err != nil {
+			return errors.WithMessage(err, "failed to remove internal transactions")
+		}
+	}
+
+	if err := ms.epochBlockMapStore.Remove(dbTx, epochFrom, epochTo); err != nil {
+		return errors.WithMessage(err, "failed to remove epoch to block mapping data")
+	}
+
+	if err := ms.addressBloomStore.remove(dbTx, epochFrom, epochTo); err != nil {
+		return errors.WithMessage(err, "failed to remove address blooms")
+	}
+
+	return nil
+}
+
+// blockRangeOfEpochs returns the block number range of the stored epochs within the specified
+// epoch range within the database transaction, or false if none stored.
+func (e2bms *epochBlockMapStore) blockRangeOfEpochs(
+	dbTx *gorm.DB, epochFrom, epochTo uint64,
+) (citypes.RangeUint64, bool, error) {
+	var result struct {
+		BnMin *uint64
+		BnMax *uint64
+	}
+
+	err := dbTx.Model(&epochBlockMap{}).
+		Select("MIN(bn_min) AS bn_min, MAX(bn_max) AS bn_max").
+		Where("epoch >= ? AND epoch <= ?", epochFrom, epochTo).
+		Scan(&result).Error
+	if err != nil || result.BnMin == nil || result.BnMax == nil {
+		return citypes.RangeUint64{}, false, err
+	}
+
+	return citypes.RangeUint64{From: *result.BnMin, To: *result.BnMax}, true, nil
+}
+
+// removeRange removes the entity data within the block number range across all partitions, which
+// could be in the middle of partitions.
+func (bnps *bnPartitionedStore) removeRange(
+	dbTx *gorm.DB, entity string, model schema.Tabler, bnRange citypes.RangeUint64,
+) error {
+	partitions, err := bnps.searchOverlapPartitions(entity, bnRange)
+	if err != nil {
+		return errors.WithMessage(err, "failed to search partitions")
+	}
+
+	for _, partition := range partitions {
+		tblName := bnps.getPartitionedTableName(model, partition.Index)
+
+		res := dbTx.Table(tblName).Where("bn BETWEEN ? AND ?", bnRange.From, bnRange.To).Delete(model)
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			continue
+		}
+
+		// update partition data size
+		err := dbTx.Model(&bnPartition{}).
+			Where("id = ?", partition.ID).
+			UpdateColumn("count", gorm.Expr("GREATEST(0, CAST(count AS SIGNED) - ?)", res.RowsAffected)).
+			Error
+		if err != nil {
+			return errors.WithMessage(err, "failed to update partition size")
+		}
+	}
+
+	return nil
+}
+
+// removeRange removes the event logs of big contracts within the block number range, which could be
+// in the middle of partitions.
+func (bcls *bigContractLogStore) removeRange(dbTx *gorm.DB, epochFrom uint64, bnRange citypes.RangeUint64) error {
+	contracts, err := bcls.cs.GetUpdatedContractsSinceEpoch(epochFrom)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get updated contracts since start epoch")
+	}
+
+	for _, contract := range contracts {
+		err := bcls.bnPartitionedStore.removeRange(
+			dbTx, bcls.contractEntity(contract.ID), bcls.contractTabler(contract.ID), bnRange,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeRange removes the address indexed event logs within the epoch range, which could be in the
+// middle of store, so the latest updated epoch of contracts won't be changed.
+func (ls *AddressIndexedLogStore) removeRange(dbTx *gorm.DB, epochFrom, epochTo uint64) error {
+	contracts, err := ls.cs.GetUpdatedContractsSinceEpoch(epochFrom)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get updated contracts since start epoch")
+	}
+
+	for _, contract := range contracts {
+		partition := ls.getPartitionByAddress(contract.Address)
+		tableName := ls.getPartitionedTableName(&ls.model, partition)
+
+		sql := fmt.Sprintf("DELETE FROM %v WHERE cid = ? AND epoch BETWEEN ? AND ?", tableName)
+		res := dbTx.Exec(sql, contract.ID, epochFrom, epochTo)
+		if err := res.Error; err != nil {
+			return err
+		}
+
+		if res.RowsAffected == 0 {
+			continue
+		}
+
+		err := dbTx.Model(&Contract{}).
+			Where("id = ?", contract.ID).
+			UpdateColumn("log_count", gorm.Expr("GREATEST(0, CAST(log_count AS SIGNED) - ?)", res.RowsAffected)).
+			Error
+		if err != nil {
+			return errors.WithMessage(err, "failed to update contract statistics")
+		}
+	}
+
+	return nil
+}
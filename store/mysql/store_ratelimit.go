@@ -3,7 +3,9 @@ package mysql
 import (
 	"time"
 
+	"github.com/Conflux-Chain/confura/util/acl"
 	"github.com/Conflux-Chain/confura/util/rate"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
@@ -16,6 +18,8 @@ type RateLimit struct {
 	LimitKey  string `gorm:"unique;size:128;not null"` // limit key
 	Memo      string `gorm:"size:128"`                 // memo
 
+	RateLimitRestriction `gorm:"embedded"`
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -24,11 +28,34 @@ func (RateLimit) TableName() string {
 	return "ratelimits"
 }
 
+// RateLimitRestriction restricts the source IPs and HTTP origins that the limit key could be used
+// from, which are all comma separated and unrestricted if empty.
+type RateLimitRestriction struct {
+	// source IPs or CIDR ranges that the key could be used from
+	AllowedIps string `gorm:"size:1024"`
+	// source IPs or CIDR ranges that the key is not allowed to be used from
+	DeniedIps string `gorm:"size:1024"`
+	// HTTP origins that the key could be used from, with wildcard subdomain supported
+	AllowedOrigins string `gorm:"size:1024"`
+}
+
 type RateLimitStore struct {
 	*baseStore
 }
 
-func NewRateLimitStore(db *gorm.DB) *RateLimitStore {
+// MustNewRateLimitStore creates rate limit store, and adds the key restriction columns to the table
+// created before if absent.
+func MustNewRateLimitStore(db *gorm.DB) *RateLimitStore {
+	for _, field := range []string{"AllowedIps", "DeniedIps", "AllowedOrigins"} {
+		if !db.Migrator().HasTable(&RateLimit{}) || db.Migrator().HasColumn(&RateLimit{}, field) {
+			continue
+		}
+
+		if err := db.Migrator().AddColumn(&RateLimit{}, field); err != nil {
+			logrus.WithError(err).WithField("column", field).Fatal("Failed to add rate limit column")
+		}
+	}
+
 	return &RateLimitStore{
 		baseStore: newBaseStore(db),
 	}
@@ -40,18 +67,31 @@ func (rls *RateLimitStore) AddRateLimit(
 	limitType rate.LimitType,
 	limitKey string,
 	memo string,
+	restriction RateLimitRestriction,
 ) error {
 	ratelimit := &RateLimit{
-		SID:       sid,
-		AclID:     aclId,
-		LimitType: int(limitType),
-		LimitKey:  limitKey,
-		Memo:      memo,
+		SID:                  sid,
+		AclID:                aclId,
+		LimitType:            int(limitType),
+		LimitKey:             limitKey,
+		Memo:                 memo,
+		RateLimitRestriction: restriction,
 	}
 
 	return rls.db.Create(ratelimit).Error
 }
 
+// UpdateRateLimitRestriction updates the source IPs and origins restriction of the limit key.
+func (rls *RateLimitStore) UpdateRateLimitRestriction(limitKey string, restriction RateLimitRestriction) (bool, error) {
+	res := rls.db.Model(&RateLimit{}).Where("limit_key = ?", limitKey).Updates(map[string]interface{}{
+		"allowed_ips":     restriction.AllowedIps,
+		"denied_ips":      restriction.DeniedIps,
+		"allowed_origins": restriction.AllowedOrigins,
+	})
+
+	return res.RowsAffected > 0, res.Error
+}
+
 func (rls *RateLimitStore) DeleteRateLimit(limitKey string) (bool, error) {
 	res := rls.db.Delete(&RateLimit{}, "limit_key = ?", limitKey)
 	return res.RowsAffected > 0, res.Error
@@ -89,12 +129,25 @@ func (rls *RateLimitStore) LoadRateLimitKeyInfos(filter *rate.KeysetFilter) (res
 	}
 
 	for i := range ratelimits {
-		res = append(res, &rate.KeyInfo{
+		ki := &rate.KeyInfo{
 			Type:  rate.LimitType(ratelimits[i].LimitType),
 			Key:   ratelimits[i].LimitKey,
 			SID:   ratelimits[i].SID,
 			AclID: ratelimits[i].AclID,
-		})
+		}
+
+		restriction, err := acl.NewKeyRestriction(
+			ratelimits[i].AllowedIps, ratelimits[i].DeniedIps, ratelimits[i].AllowedOrigins,
+		)
+		if err != nil { // fail closed with all requests denied
+			logrus.WithError(err).WithField("limitKey", ki.Key).Warn("Malformed rate limit key restriction")
+		}
+
+		if !restriction.IsEmpty() {
+			ki.Restriction = restriction
+		}
+
+		res = append(res, ki)
 	}
 
 	return res, nil
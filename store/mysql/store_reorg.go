@@ -0,0 +1,100 @@
+package mysql
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// window to merge consecutive reverts into a single reorg event, since deep reorg is detected
+	// and reverted epoch by epoch during sync
+	reorgMergeWindow = time.Minute
+
+	// max number of reorg events to return at a time
+	maxReorgEvents = 1000
+)
+
+// ReorgEvent is a pivot chain switch (or chain reorg for evm space) detected during sync, which
+// reverted the synced epochs (or blocks for evm space) within [EpochFrom, EpochTo].
+type ReorgEvent struct {
+	ID        uint64 `gorm:"primaryKey;autoIncrement" json:"id"`
+	EpochFrom uint64 `gorm:"not null;index" json:"epochFrom"`
+	EpochTo   uint64 `gorm:"not null;index" json:"epochTo"`
+	Depth     uint64 `gorm:"not null" json:"depth"` // number of reverted epochs
+	// pivot hash of epoch `EpochFrom` before and after reorg, new one is empty if unknown
+	OldPivotHash string    `gorm:"size:66;not null" json:"oldPivotHash"`
+	NewPivotHash string    `gorm:"size:66;not null" json:"newPivotHash"`
+	DetectedAt   time.Time `gorm:"not null;index" json:"detectedAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+func (ReorgEvent) TableName() string {
+	return "reorg_events"
+}
+
+// reorgStore persists the reorg events detected during sync for data correctness investigation.
+type reorgStore struct {
+	*baseStore
+}
+
+// mustNewReorgStore creates reorg event store, and creates the table if absent.
+func mustNewReorgStore(db *gorm.DB) *reorgStore {
+	if !db.Migrator().HasTable(&ReorgEvent{}) {
+		if err := db.Migrator().CreateTable(&ReorgEvent{}); err != nil {
+			logrus.WithError(err).Fatal("Failed to create reorg event table")
+		}
+	}
+
+	return &reorgStore{baseStore: newBaseStore(db)}
+}
+
+// AddReorgEventWithTx records the reorg event within the database transaction, which is merged
+// into the latest one if continuously reverted shortly, e.g., deep reorg reverted epoch by epoch.
+func (rs *reorgStore) AddReorgEventWithTx(dbTx *gorm.DB, event *ReorgEvent) error {
+	event.Depth = event.EpochTo - event.EpochFrom + 1
+	if event.DetectedAt.IsZero() {
+		event.DetectedAt = time.Now()
+	}
+
+	var latest ReorgEvent
+	err := dbTx.Order("id DESC").Limit(1).Find(&latest).Error
+	if err != nil {
+		return errors.WithMessage(err, "failed to get the latest reorg event")
+	}
+
+	if latest.ID == 0 || latest.EpochFrom != event.EpochTo+1 ||
+		event.DetectedAt.Sub(latest.UpdatedAt) > reorgMergeWindow {
+		return dbTx.Create(event).Error
+	}
+
+	// deeper revert of the same reorg
+	latest.EpochFrom = event.EpochFrom
+	latest.Depth = latest.EpochTo - latest.EpochFrom + 1
+	latest.OldPivotHash = event.OldPivotHash
+	latest.NewPivotHash = event.NewPivotHash
+
+	return dbTx.Save(&latest).Error
+}
+
+// ReorgEvents returns the latest reorg events which reverted any epoch within the specified epoch
+// range, in descending order of detection time.
+func (rs *reorgStore) ReorgEvents(epochFrom, epochTo uint64, limit int) ([]ReorgEvent, error) {
+	if epochFrom > epochTo {
+		return nil, errors.Errorf("invalid epoch range [%v, %v]", epochFrom, epochTo)
+	}
+
+	if limit <= 0 || limit > maxReorgEvents {
+		limit = maxReorgEvents
+	}
+
+	var events []ReorgEvent
+	err := rs.db.Where("epoch_from <= ? AND epoch_to >= ?", epochTo, epochFrom).
+		Order("id DESC").
+		Limit(limit).
+		Find(&events).Error
+
+	return events, err
+}
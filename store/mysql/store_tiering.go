@@ -0,0 +1,422 @@
+package mysql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/objstore"
+	"github.com/Conflux-Chain/go-conflux-util/dlock"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/google/uuid"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// distributed lock to prevent multiple instances from offloading the same epochs concurrently
+	coldStoreLockKey = "tiering:offload"
+	// lease of the distributed lock, which is renewed before offloading each segment
+	coldStoreLockLease = 5 * time.Minute
+)
+
+var (
+	errColdDataUnavailable = errors.New("cold data unavailable")
+)
+
+// TieringConfig represents the hot/cold tiering configurations, by which raw data of
+// old epochs will be offloaded to object storage, while only index rows kept in MySQL.
+type TieringConfig struct {
+	Enabled bool
+	// Number of latest epochs whose raw data are kept in MySQL
+	HotEpochs uint64 `default:"1000000"`
+	// Number of epochs within each offloaded segment
+	SegmentEpochs uint64 `default:"1000"`
+	// Interval to check and offload old epochs
+	Interval time.Duration `default:"1m"`
+	// Max number of segments cached in memory for read-through
+	CacheSize int `default:"16"`
+	// Object storage to hold offloaded segments
+	ObjectStore objstore.Config
+}
+
+func (config *TieringConfig) validate() error {
+	if config.HotEpochs == 0 {
+		return errors.New("hot epochs must be positive")
+	}
+
+	if config.SegmentEpochs == 0 {
+		return errors.New("segment epochs must be positive")
+	}
+
+	if config.Interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+
+	return nil
+}
+
+// coldSegment indexes the segment object of some epoch range offloaded to object storage.
+type coldSegment struct {
+	ID        uint64
+	EpochFrom uint64 `gorm:"not null;unique"`
+	EpochTo   uint64 `gorm:"not null;index"`
+	ObjectKey string `gorm:"size:128;not null"`
+	Size      uint64 `gorm:"not null"` // compressed size in bytes
+	CreatedAt time.Time
+}
+
+func (coldSegment) TableName() string {
+	return "cold_segments"
+}
+
+// segmentBlock is the raw data of a block within segment.
+type segmentBlock struct {
+	Hash    string
+	RawData []byte
+}
+
+// segmentTx is the raw data of a transaction and its receipt within segment.
+type segmentTx struct {
+	Hash           string
+	TxRawData      []byte
+	ReceiptRawData []byte
+}
+
+// segmentData is the RLP encoded content of segment before compression.
+type segmentData struct {
+	Blocks []segmentBlock
+	Txs    []segmentTx
+}
+
+func encodeSegment(data *segmentData) ([]byte, error) {
+	encoded, err := rlp.EncodeToBytes(data)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to encode RLP")
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+
+	if _, err := w.Write(encoded); err != nil {
+		return nil, errors.WithMessage(err, "failed to compress")
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, errors.WithMessage(err, "failed to close compressor")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decodeSegment(compressed []byte) (*segmentData, error) {
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create decompressor")
+	}
+	defer r.Close()
+
+	encoded, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to decompress")
+	}
+
+	var data segmentData
+	if err := rlp.DecodeBytes(encoded, &data); err != nil {
+		return nil, errors.WithMessage(err, "failed to decode RLP")
+	}
+
+	return &data, nil
+}
+
+// coldSegmentCache is the in-memory lookup table of a decoded segment.
+type coldSegmentCache struct {
+	blocks map[string][]byte
+	txs    map[string]*segmentTx
+}
+
+func newColdSegmentCache(data *segmentData) *coldSegmentCache {
+	cache := &coldSegmentCache{
+		blocks: make(map[string][]byte, len(data.Blocks)),
+		txs:    make(map[string]*segmentTx, len(data.Txs)),
+	}
+
+	for i := range data.Blocks {
+		cache.blocks[data.Blocks[i].Hash] = data.Blocks[i].RawData
+	}
+
+	for i := range data.Txs {
+		cache.txs[data.Txs[i].Hash] = &data.Txs[i]
+	}
+
+	return cache
+}
+
+// coldStore offloads raw data of old epochs to object storage and provides read-through
+// for the offloaded data.
+type coldStore struct {
+	db       *gorm.DB
+	config   *TieringConfig
+	objStore objstore.ObjectStore
+	// segment ID => *coldSegmentCache
+	cache *lru.Cache
+	// distributed lock to offload old epochs exclusively
+	lockMan    *dlock.LockManager
+	lockIntent *dlock.LockIntent
+}
+
+func mustNewColdStore(db *gorm.DB, config *TieringConfig) *coldStore {
+	if err := config.validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid tiering config")
+	}
+
+	objStore, err := objstore.NewS3Store(&config.ObjectStore)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create object store for tiering")
+	}
+
+	if !db.Migrator().HasTable(&coldSegment{}) {
+		if err := db.Migrator().CreateTable(&coldSegment{}); err != nil {
+			logrus.WithError(err).Fatal("Failed to create cold segment table")
+		}
+	}
+
+	cache, _ := lru.New(max(config.CacheSize, 1))
+
+	return &coldStore{
+		db:         db,
+		config:     config,
+		objStore:   objStore,
+		cache:      cache,
+		lockMan:    dlock.NewLockManager(dlock.NewMySQLBackend(db)),
+		lockIntent: dlock.NewLockIntent(coldStoreLockKey, uuid.NewString(), coldStoreLockLease),
+	}
+}
+
+func (cs *coldStore) loadSegment(ctx context.Context, epoch uint64) (*coldSegmentCache, error) {
+	var seg coldSegment
+	err := cs.db.WithContext(ctx).
+		Where("epoch_from <= ? AND epoch_to >= ?", epoch, epoch).
+		First(&seg).Error
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get segment of epoch %v", epoch)
+	}
+
+	if v, ok := cs.cache.Get(seg.ID); ok {
+		return v.(*coldSegmentCache), nil
+	}
+
+	compressed, err := cs.objStore.Get(ctx, seg.ObjectKey)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to download segment %v", seg.ObjectKey)
+	}
+
+	data, err := decodeSegment(compressed)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to decode segment %v", seg.ObjectKey)
+	}
+
+	segCache := newColdSegmentCache(data)
+	cs.cache.Add(seg.ID, segCache)
+
+	return segCache, nil
+}
+
+// GetBlockRawData reads through the offloaded raw data of block.
+func (cs *coldStore) GetBlockRawData(ctx context.Context, epoch uint64, hash string) ([]byte, error) {
+	seg, err := cs.loadSegment(ctx, epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	if rawData, ok := seg.blocks[hash]; ok {
+		return rawData, nil
+	}
+
+	return nil, errColdDataUnavailable
+}
+
+// GetTxRawData reads through the offloaded raw data of transaction and receipt.
+func (cs *coldStore) GetTxRawData(ctx context.Context, epoch uint64, hash string) (*segmentTx, error) {
+	seg, err := cs.loadSegment(ctx, epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	if tx, ok := seg.txs[hash]; ok {
+		return tx, nil
+	}
+
+	return nil, errColdDataUnavailable
+}
+
+// nextEpochToOffload returns the first epoch which is not offloaded yet.
+func (cs *coldStore) nextEpochToOffload() (uint64, bool, error) {
+	var lastSeg coldSegment
+	err := cs.db.Order("epoch_to DESC").First(&lastSeg).Error
+	if err == nil {
+		return lastSeg.EpochTo + 1, true, nil
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, err
+	}
+
+	var minEpoch *uint64
+	if err := cs.db.Model(&block{}).Select("MIN(epoch)").Scan(&minEpoch).Error; err != nil {
+		return 0, false, err
+	}
+
+	if minEpoch == nil {
+		return 0, false, nil
+	}
+
+	return *minEpoch, true, nil
+}
+
+// offload moves raw data of the specified epoch range to object storage.
+func (cs *coldStore) offload(epochFrom, epochTo uint64) (*coldSegment, error) {
+	var data segmentData
+
+	err := cs.db.Model(&block{}).
+		Select("hash, raw_data").
+		Where("epoch >= ? AND epoch <= ?", epochFrom, epochTo).
+		Scan(&data.Blocks).Error
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load blocks")
+	}
+
+	err = cs.db.Model(&transaction{}).
+		Select("hash, tx_raw_data, receipt_raw_data").
+		Where("epoch >= ? AND epoch <= ?", epochFrom, epochTo).
+		Scan(&data.Txs).Error
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load transactions")
+	}
+
+	compressed, err := encodeSegment(&data)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to encode segment")
+	}
+
+	seg := &coldSegment{
+		EpochFrom: epochFrom,
+		EpochTo:   epochTo,
+		ObjectKey: fmt.Sprintf("%020d-%020d.seg.gz", epochFrom, epochTo),
+		Size:      uint64(len(compressed)),
+	}
+
+	if err := cs.objStore.Put(context.Background(), seg.ObjectKey, compressed); err != nil {
+		return nil, errors.WithMessage(err, "failed to upload segment")
+	}
+
+	// keep index rows but clear the raw data, which will be read through from object storage
+	err = cs.db.Transaction(func(dbTx *gorm.DB) error {
+		if err := dbTx.Create(seg).Error; err != nil {
+			return errors.WithMessage(err, "failed to create segment index")
+		}
+
+		err := dbTx.Model(&block{}).
+			Where("epoch >= ? AND epoch <= ?", epochFrom, epochTo).
+			Update("raw_data", []byte{}).Error
+		if err != nil {
+			return errors.WithMessage(err, "failed to clear block raw data")
+		}
+
+		err = dbTx.Model(&transaction{}).
+			Where("epoch >= ? AND epoch <= ?", epochFrom, epochTo).
+			Updates(map[string]interface{}{"tx_raw_data": nil, "receipt_raw_data": nil}).Error
+		if err != nil {
+			return errors.WithMessage(err, "failed to clear transaction raw data")
+		}
+
+		return nil
+	})
+
+	return seg, err
+}
+
+// offloadOldEpochs offloads all the epochs older than the hot threshold segment by segment.
+func (cs *coldStore) offloadOldEpochs(maxEpoch uint64) error {
+	if maxEpoch < cs.config.HotEpochs {
+		return nil
+	}
+
+	threshold := maxEpoch - cs.config.HotEpochs
+
+	locked := false
+	defer func() {
+		if locked {
+			cs.lockMan.Release(context.Background(), cs.lockIntent)
+		}
+	}()
+
+	for {
+		// acquire (or renew) the lock before offloading each segment, so that the same epochs
+		// won't be offloaded by multiple instances sharing the database
+		err := cs.lockMan.Acquire(context.Background(), cs.lockIntent)
+		if errors.Is(err, dlock.ErrLockAcquisitionFailed) {
+			logrus.Debug("Cold store offloading skipped due to lock held by another instance")
+			return nil
+		}
+
+		if err != nil {
+			return errors.WithMessage(err, "failed to acquire offload lock")
+		}
+
+		locked = true
+
+		epochFrom, ok, err := cs.nextEpochToOffload()
+		if err != nil || !ok {
+			return err
+		}
+
+		epochTo := epochFrom + cs.config.SegmentEpochs - 1
+		if epochTo > threshold {
+			return nil
+		}
+
+		start := time.Now()
+
+		seg, err := cs.offload(epochFrom, epochTo)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to offload epochs [%v, %v]", epochFrom, epochTo)
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"epochFrom": epochFrom,
+			"epochTo":   epochTo,
+			"objectKey": seg.ObjectKey,
+			"size":      seg.Size,
+			"elapsed":   time.Since(start),
+		}).Info("Cold segment offloaded to object storage")
+	}
+}
+
+// scheduleOffload periodically offloads old epochs to object storage. Be noted this function
+// will block caller thread.
+func (cs *coldStore) scheduleOffload(maxEpochFunc func() (uint64, bool, error)) {
+	ticker := time.NewTicker(cs.config.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		maxEpoch, ok, err := maxEpochFunc()
+		if err != nil {
+			logrus.WithError(err).Error("Failed to get max epoch for tiering")
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		if err := cs.offloadOldEpochs(maxEpoch); err != nil {
+			logrus.WithError(err).Error("Failed to offload old epochs to object storage")
+		}
+	}
+}
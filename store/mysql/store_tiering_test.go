@@ -0,0 +1,46 @@
+package mysql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodeSegment(t *testing.T) {
+	data := &segmentData{
+		Blocks: []segmentBlock{
+			{Hash: "0x01", RawData: []byte{1, 2, 3}},
+			{Hash: "0x02", RawData: []byte{4, 5, 6}},
+		},
+		Txs: []segmentTx{
+			{Hash: "0x03", TxRawData: []byte{7}, ReceiptRawData: []byte{8, 9}},
+			{Hash: "0x04", ReceiptRawData: []byte{10}},
+		},
+	}
+
+	compressed, err := encodeSegment(data)
+	assert.NoError(t, err)
+
+	decoded, err := decodeSegment(compressed)
+	assert.NoError(t, err)
+
+	cache := newColdSegmentCache(decoded)
+	assert.Equal(t, []byte{4, 5, 6}, cache.blocks["0x02"])
+	assert.Equal(t, []byte{8, 9}, cache.txs["0x03"].ReceiptRawData)
+	assert.Empty(t, cache.txs["0x04"].TxRawData)
+	assert.Nil(t, cache.txs["0x05"])
+}
+
+func TestTieringConfigValidate(t *testing.T) {
+	config := TieringConfig{HotEpochs: 100, SegmentEpochs: 10, Interval: time.Minute}
+	assert.NoError(t, config.validate())
+
+	for _, invalid := range []TieringConfig{
+		{HotEpochs: 0, SegmentEpochs: 10, Interval: time.Minute},
+		{HotEpochs: 100, SegmentEpochs: 0, Interval: time.Minute},
+		{HotEpochs: 100, SegmentEpochs: 10, Interval: 0},
+	} {
+		assert.Error(t, invalid.validate())
+	}
+}
@@ -0,0 +1,220 @@
+package mysql
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultBatchSizeTokenTransferInsert = 500
+
+	// max number of token transfers to return at a time
+	maxTokenTransfers = 1000
+)
+
+// Token standards of transfer events, where CRC20 of core space shares the same event as ERC20.
+const (
+	TokenStandardErc20   = "erc20"
+	TokenStandardErc721  = "erc721"
+	TokenStandardErc1155 = "erc1155"
+)
+
+const (
+	// Transfer(address indexed from, address indexed to, uint256 value) for ERC20 and
+	// Transfer(address indexed from, address indexed to, uint256 indexed tokenId) for ERC721
+	topicTokenTransfer = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	// TransferSingle(address indexed operator, address indexed from, address indexed to, uint256 id, uint256 value)
+	topicTokenTransferSingle = "0xc3d58168c5ae7397731d063d5bbf3d657854427343f4c083240f7aacaa2d0f62"
+)
+
+// TokenTransfer is the token transfer event recognized from event logs during sync.
+type TokenTransfer struct {
+	ID          uint64 `gorm:"primaryKey;autoIncrement" json:"id"`
+	Epoch       uint64 `gorm:"not null;index" json:"epoch"`
+	BlockNumber uint64 `gorm:"not null" json:"blockNumber"`
+	TxHash      string `gorm:"size:66;not null" json:"transactionHash"`
+	LogIndex    uint64 `gorm:"not null" json:"logIndex"`
+	Standard    string `gorm:"size:16;not null" json:"standard"`
+	Token       string `gorm:"size:42;not null;index" json:"token"`                    // lowercase hex address
+	From        string `gorm:"column:from_address;size:42;not null;index" json:"from"` // lowercase hex address
+	To          string `gorm:"column:to_address;size:42;not null;index" json:"to"`     // lowercase hex address
+	TokenId     string `gorm:"size:78;not null;default:''" json:"tokenId,omitempty"`   // decimal, empty for ERC20
+	Value       string `gorm:"size:78;not null" json:"value"`                          // decimal
+}
+
+func (TokenTransfer) TableName() string {
+	return "token_transfers"
+}
+
+// TokenTransferStore indexes token transfers by token and holder during sync.
+type TokenTransferStore struct {
+	*baseStore
+}
+
+// mustNewTokenTransferStore creates token transfer store, and creates the table if absent.
+func mustNewTokenTransferStore(db *gorm.DB) *TokenTransferStore {
+	if !db.Migrator().HasTable(&TokenTransfer{}) {
+		if err := db.Migrator().CreateTable(&TokenTransfer{}); err != nil {
+			logrus.WithError(err).Fatal("Failed to create token transfer table")
+		}
+	}
+
+	return &TokenTransferStore{baseStore: newBaseStore(db)}
+}
+
+// add saves the token transfers recognized from event logs of the epoch data within the database transaction.
+func (tts *TokenTransferStore) add(dbTx *gorm.DB, dataSlice []*store.EpochData) error {
+	var transfers []*TokenTransfer
+
+	for _, data := range dataSlice {
+		for _, block := range data.Blocks {
+			bn := block.BlockNumber.ToInt().Uint64()
+
+			for _, tx := range block.Transactions {
+				receipt := data.Receipts[tx.Hash]
+
+				// Skip transactions that unexecuted in block.
+				if receipt == nil || !util.IsTxExecutedInBlock(&tx) {
+					continue
+				}
+
+				for k := range receipt.Logs {
+					if transfer, ok := parseTokenTransfer(&receipt.Logs[k]); ok {
+						transfer.Epoch = data.Number
+						transfer.BlockNumber = bn
+						transfer.TxHash = tx.Hash.String()
+
+						transfers = append(transfers, transfer)
+					}
+				}
+			}
+		}
+	}
+
+	if len(transfers) == 0 {
+		return nil
+	}
+
+	return dbTx.CreateInBatches(transfers, defaultBatchSizeTokenTransferInsert).Error
+}
+
+// remove removes the token transfers of the specified epoch range within the database transaction.
+func (tts *TokenTransferStore) remove(dbTx *gorm.DB, epochFrom, epochTo uint64) error {
+	return dbTx.Where("epoch >= ? AND epoch <= ?", epochFrom, epochTo).Delete(&TokenTransfer{}).Error
+}
+
+// TokenTransfersByToken returns the transfers of the specified token in descending order of ID
+// before the cursor ID, or the latest ones if cursor is zero.
+func (tts *TokenTransferStore) TokenTransfersByToken(token common.Address, cursor uint64, limit int) ([]TokenTransfer, error) {
+	return tts.tokenTransfers("token = ?", strings.ToLower(token.Hex()), cursor, limit)
+}
+
+// TokenTransfersByHolder returns the transfers from or to the specified address in descending
+// order of ID before the cursor ID, or the latest ones if cursor is zero.
+func (tts *TokenTransferStore) TokenTransfersByHolder(holder common.Address, cursor uint64, limit int) ([]TokenTransfer, error) {
+	addr := strings.ToLower(holder.Hex())
+	limit = normalizeTokenTransferLimit(limit)
+
+	// query separately rather than OR condition so as to make use of both indices
+	sent, err := tts.tokenTransfers("from_address = ?", addr, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	received, err := tts.tokenTransfers("to_address = ?", addr, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// merge and dedup self transfers
+	merged := make(map[uint64]TokenTransfer, len(sent)+len(received))
+	for _, transfer := range append(sent, received...) {
+		merged[transfer.ID] = transfer
+	}
+
+	result := make([]TokenTransfer, 0, len(merged))
+	for _, transfer := range merged {
+		result = append(result, transfer)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID > result[j].ID })
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+func (tts *TokenTransferStore) tokenTransfers(cond string, addr string, cursor uint64, limit int) ([]TokenTransfer, error) {
+	db := tts.db.Where(cond, addr)
+	if cursor > 0 {
+		db = db.Where("id < ?", cursor)
+	}
+
+	var transfers []TokenTransfer
+	err := db.Order("id DESC").Limit(normalizeTokenTransferLimit(limit)).Find(&transfers).Error
+
+	return transfers, err
+}
+
+func normalizeTokenTransferLimit(limit int) int {
+	if limit <= 0 || limit > maxTokenTransfers {
+		return maxTokenTransfers
+	}
+
+	return limit
+}
+
+// parseTokenTransfer recognizes the ERC20 (or CRC20), ERC721 or ERC1155 single transfer from the event log.
+func parseTokenTransfer(log *types.Log) (*TokenTransfer, bool) {
+	if len(log.Topics) == 0 {
+		return nil, false
+	}
+
+	transfer := TokenTransfer{
+		Token: strings.ToLower(log.Address.GetHexAddress()),
+	}
+
+	if log.LogIndex != nil {
+		transfer.LogIndex = log.LogIndex.ToInt().Uint64()
+	}
+
+	switch topic0 := strings.ToLower(log.Topics[0].String()); {
+	case topic0 == topicTokenTransfer && len(log.Topics) == 3 && len(log.Data) == 32:
+		transfer.Standard = TokenStandardErc20
+		transfer.From = topicToHexAddress(log.Topics[1])
+		transfer.To = topicToHexAddress(log.Topics[2])
+		transfer.Value = new(big.Int).SetBytes(log.Data).String()
+	case topic0 == topicTokenTransfer && len(log.Topics) == 4 && len(log.Data) == 0:
+		transfer.Standard = TokenStandardErc721
+		transfer.From = topicToHexAddress(log.Topics[1])
+		transfer.To = topicToHexAddress(log.Topics[2])
+		transfer.TokenId = common.HexToHash(log.Topics[3].String()).Big().String()
+		transfer.Value = "1"
+	case topic0 == topicTokenTransferSingle && len(log.Topics) == 4 && len(log.Data) == 64:
+		transfer.Standard = TokenStandardErc1155
+		transfer.From = topicToHexAddress(log.Topics[2])
+		transfer.To = topicToHexAddress(log.Topics[3])
+		transfer.TokenId = new(big.Int).SetBytes(log.Data[:32]).String()
+		transfer.Value = new(big.Int).SetBytes(log.Data[32:]).String()
+	default:
+		return nil, false
+	}
+
+	return &transfer, true
+}
+
+// topicToHexAddress converts the indexed address argument in topic into lowercase hex address.
+func topicToHexAddress(topic types.Hash) string {
+	addr := common.BytesToAddress(common.HexToHash(topic.String()).Bytes())
+	return strings.ToLower(addr.Hex())
+}
@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTokenTransfer(t *testing.T) {
+	token := cfxaddress.MustNewFromHex("0x8d7df9316faa0586e175b5e6d03c6bda76e3d950", 1029)
+	operator := types.Hash(common.BytesToHash(common.HexToAddress("0x1000000000000000000000000000000000000003").Bytes()).Hex())
+	from := types.Hash(common.BytesToHash(common.HexToAddress("0x1000000000000000000000000000000000000001").Bytes()).Hex())
+	to := types.Hash(common.BytesToHash(common.HexToAddress("0x1000000000000000000000000000000000000002").Bytes()).Hex())
+
+	// ERC20 or CRC20
+	transfer, ok := parseTokenTransfer(&types.Log{
+		Address: token,
+		Topics:  []types.Hash{topicTokenTransfer, from, to},
+		Data:    common.BigToHash(big.NewInt(100)).Bytes(),
+	})
+	assert.True(t, ok)
+	assert.Equal(t, TokenStandardErc20, transfer.Standard)
+	assert.Equal(t, "0x8d7df9316faa0586e175b5e6d03c6bda76e3d950", transfer.Token)
+	assert.Equal(t, "0x1000000000000000000000000000000000000001", transfer.From)
+	assert.Equal(t, "0x1000000000000000000000000000000000000002", transfer.To)
+	assert.Equal(t, "100", transfer.Value)
+	assert.Empty(t, transfer.TokenId)
+
+	// ERC721
+	transfer, ok = parseTokenTransfer(&types.Log{
+		Address: token,
+		Topics:  []types.Hash{topicTokenTransfer, from, to, types.Hash(common.BigToHash(big.NewInt(7)).Hex())},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, TokenStandardErc721, transfer.Standard)
+	assert.Equal(t, "7", transfer.TokenId)
+	assert.Equal(t, "1", transfer.Value)
+
+	// ERC1155
+	transfer, ok = parseTokenTransfer(&types.Log{
+		Address: token,
+		Topics:  []types.Hash{topicTokenTransferSingle, operator, from, to},
+		Data:    append(common.BigToHash(big.NewInt(7)).Bytes(), common.BigToHash(big.NewInt(5)).Bytes()...),
+	})
+	assert.True(t, ok)
+	assert.Equal(t, TokenStandardErc1155, transfer.Standard)
+	assert.Equal(t, "0x1000000000000000000000000000000000000001", transfer.From)
+	assert.Equal(t, "0x1000000000000000000000000000000000000002", transfer.To)
+	assert.Equal(t, "7", transfer.TokenId)
+	assert.Equal(t, "5", transfer.Value)
+
+	// unrecognized event or malformed data
+	_, ok = parseTokenTransfer(&types.Log{Address: token, Topics: []types.Hash{topicTokenTransfer, from, to}})
+	assert.False(t, ok)
+
+	_, ok = parseTokenTransfer(&types.Log{Address: token, Topics: []types.Hash{from}})
+	assert.False(t, ok)
+}
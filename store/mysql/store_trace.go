@@ -0,0 +1,88 @@
+package mysql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// traceResult caches the trace result of some transaction, which is immutable once executed.
+type traceResult struct {
+	ID        uint64
+	TxHash    string `gorm:"size:66;not null;uniqueIndex:idx_tx_method,priority:1"`
+	Method    string `gorm:"size:128;not null;uniqueIndex:idx_tx_method,priority:2"` // RPC method with options digest
+	Data      []byte `gorm:"type:MEDIUMBLOB;not null"`                               // gzip compressed JSON result
+	CreatedAt time.Time
+}
+
+func (traceResult) TableName() string {
+	return "trace_results"
+}
+
+// TraceResultStore persists trace results of transactions with compression.
+type TraceResultStore struct {
+	*baseStore
+}
+
+// MustNewTraceResultStore creates trace result store, and creates the table if absent.
+func MustNewTraceResultStore(db *gorm.DB) *TraceResultStore {
+	if !db.Migrator().HasTable(&traceResult{}) {
+		if err := db.Migrator().CreateTable(&traceResult{}); err != nil {
+			logrus.WithError(err).Fatal("Failed to create trace result table")
+		}
+	}
+
+	return &TraceResultStore{baseStore: newBaseStore(db)}
+}
+
+// GetTraceResult returns the decompressed trace result of the transaction by RPC method.
+func (trs *TraceResultStore) GetTraceResult(txHash, method string) ([]byte, bool, error) {
+	var res traceResult
+
+	exists, err := trs.exists(&res, "tx_hash = ? AND method = ?", txHash, method)
+	if err != nil || !exists {
+		return nil, false, err
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(res.Data))
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "failed to create decompressor")
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "failed to decompress")
+	}
+
+	return data, true, nil
+}
+
+// AddTraceResult compresses and persists the trace result of the transaction by RPC method,
+// and returns the compressed size.
+func (trs *TraceResultStore) AddTraceResult(txHash, method string, data []byte) (int, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+
+	if _, err := w.Write(data); err != nil {
+		return 0, errors.WithMessage(err, "failed to compress")
+	}
+
+	if err := w.Close(); err != nil {
+		return 0, errors.WithMessage(err, "failed to close compressor")
+	}
+
+	err := trs.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&traceResult{
+		TxHash: txHash,
+		Method: method,
+		Data:   buf.Bytes(),
+	}).Error
+
+	return buf.Len(), err
+}
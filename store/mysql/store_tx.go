@@ -3,6 +3,7 @@ package mysql
 import (
 	"context"
 	"math/big"
+	"strings"
 
 	"github.com/Conflux-Chain/confura/store"
 	"github.com/Conflux-Chain/confura/util"
@@ -15,9 +16,10 @@ const defaultBatchSizeTxnInsert = 500
 
 type transaction struct {
 	ID                uint64
-	Epoch             uint64 `gorm:"not null;index"`
+	Epoch             uint64 `gorm:"not null;index;index:idx_from_epoch,priority:2"`
 	HashId            uint64 `gorm:"not null;index"` // as an index, number is better than long string
 	Hash              string `gorm:"size:66;not null"`
+	From              string `gorm:"size:42;not null;default:'';index:idx_from_epoch,priority:1"` // sender hex address
 	TxRawData         []byte `gorm:"type:MEDIUMBLOB"`
 	TxRawDataLen      uint64 `gorm:"not null"`
 	ReceiptRawData    []byte `gorm:"type:MEDIUMBLOB"`
@@ -38,6 +40,7 @@ func newTx(
 	result := &transaction{
 		Epoch: uint64(*receipt.EpochNumber),
 		Hash:  tx.Hash.String(),
+		From:  strings.ToLower(tx.From.GetHexAddress()),
 	}
 
 	if !skipTx {
@@ -93,15 +96,17 @@ func (tx *transaction) parseTxReceiptExtra() *store.ReceiptExtra {
 
 type txStore struct {
 	db *gorm.DB
+	// cold store to read through offloaded raw data, nil if tiering disabled
+	cold *coldStore
 }
 
-func newTxStore(db *gorm.DB) *txStore {
+func newTxStore(db *gorm.DB, cold *coldStore) *txStore {
 	return &txStore{
-		db: db,
+		db: db, cold: cold,
 	}
 }
 
-func (ts *txStore) loadTx(txHash types.Hash) (*transaction, error) {
+func (ts *txStore) loadTx(ctx context.Context, txHash types.Hash) (*transaction, error) {
 	hashId := util.GetShortIdOfHash(txHash.String())
 
 	var tx transaction
@@ -109,11 +114,34 @@ func (ts *txStore) loadTx(txHash types.Hash) (*transaction, error) {
 		return nil, err
 	}
 
+	if err := ts.loadColdRawData(ctx, &tx); err != nil {
+		return nil, err
+	}
+
 	return &tx, nil
 }
 
+// loadColdRawData loads raw data from cold store if offloaded to object storage.
+func (ts *txStore) loadColdRawData(ctx context.Context, tx *transaction) error {
+	if (tx.TxRawDataLen == 0 || len(tx.TxRawData) > 0) && (tx.ReceiptRawDataLen == 0 || len(tx.ReceiptRawData) > 0) {
+		return nil
+	}
+
+	if ts.cold == nil {
+		return errColdDataUnavailable
+	}
+
+	coldTx, err := ts.cold.GetTxRawData(ctx, tx.Epoch, tx.Hash)
+	if err != nil {
+		return err
+	}
+
+	tx.TxRawData, tx.ReceiptRawData = coldTx.TxRawData, coldTx.ReceiptRawData
+	return nil
+}
+
 func (ts *txStore) GetTransaction(ctx context.Context, txHash types.Hash) (*store.Transaction, error) {
-	tx, err := ts.loadTx(txHash)
+	tx, err := ts.loadTx(ctx, txHash)
 	if err != nil {
 		return nil, err
 	}
@@ -127,7 +155,7 @@ func (ts *txStore) GetTransaction(ctx context.Context, txHash types.Hash) (*stor
 }
 
 func (ts *txStore) GetReceipt(ctx context.Context, txHash types.Hash) (*store.TransactionReceipt, error) {
-	tx, err := ts.loadTx(txHash)
+	tx, err := ts.loadTx(ctx, txHash)
 	if err != nil {
 		return nil, err
 	}
@@ -142,6 +170,66 @@ func (ts *txStore) GetReceipt(ctx context.Context, txHash types.Hash) (*store.Tr
 	}, nil
 }
 
+// getEpochReceipts returns receipts of all executed transactions within the epoch in insertion
+// order, which is the same as execution order, by a single query.
+func (ts *txStore) getEpochReceipts(ctx context.Context, epochNumber uint64) ([]*store.TransactionReceipt, error) {
+	var txs []*transaction
+
+	err := ts.db.WithContext(ctx).
+		Where("epoch = ?", epochNumber).
+		Order("id ASC").
+		Find(&txs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	receipts := make([]*store.TransactionReceipt, 0, len(txs))
+	for _, tx := range txs {
+		if tx.ReceiptRawDataLen == 0 { // receipt not persisted
+			return nil, store.ErrUnsupported
+		}
+
+		if err := ts.loadColdRawData(ctx, tx); err != nil {
+			return nil, err
+		}
+
+		var receipt types.TransactionReceipt
+		util.MustUnmarshalRLP(tx.ReceiptRawData, &receipt)
+
+		receipts = append(receipts, &store.TransactionReceipt{
+			CfxReceipt: &receipt, Extra: tx.parseTxReceiptExtra(),
+		})
+	}
+
+	return receipts, nil
+}
+
+// GetAccountTransactions returns the hashes of transactions sent from the specified account
+// within the epoch range, which is served by the index of (from, epoch).
+func (ts *txStore) GetAccountTransactions(ctx context.Context, filter store.AccountTxnFilter) ([]types.Hash, error) {
+	var hashes []string
+
+	err := ts.db.WithContext(ctx).
+		Model(&transaction{}).
+		Where("`from` = ? AND epoch BETWEEN ? AND ?",
+			strings.ToLower(filter.Address), filter.EpochFrom, filter.EpochTo,
+		).
+		Order("epoch ASC, id ASC").
+		Offset(int(filter.Skip)).
+		Limit(int(filter.Limit)).
+		Pluck("hash", &hashes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	txHashes := make([]types.Hash, len(hashes))
+	for i := range hashes {
+		txHashes[i] = types.Hash(hashes[i])
+	}
+
+	return txHashes, nil
+}
+
 // Add batch save epoch transactions into db store.
 func (ts *txStore) Add(dbTx *gorm.DB, dataSlice []*store.EpochData, skipTx, skipRcpt bool) error {
 	if skipTx && skipRcpt {
@@ -0,0 +1,113 @@
+package mysql
+
+import (
+	"github.com/Conflux-Chain/confura/util/usage"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ApiKeyUsage is the usage rollup of an API key for some RPC method within a minute bucket.
+type ApiKeyUsage struct {
+	ID          uint64
+	Bucket      int64  `gorm:"not null;uniqueIndex:uidx_key_bucket_method,priority:2;index:idx_bucket"`
+	ApiKey      string `gorm:"size:128;not null;uniqueIndex:uidx_key_bucket_method,priority:1"`
+	Method      string `gorm:"size:64;not null;uniqueIndex:uidx_key_bucket_method,priority:3"`
+	Calls       uint64 `gorm:"not null"`
+	Errors      uint64 `gorm:"not null"`
+	RateLimited uint64 `gorm:"not null"`
+}
+
+func (ApiKeyUsage) TableName() string {
+	return "api_key_usages"
+}
+
+// UsageStore persists the usage rollups per API key.
+type UsageStore struct {
+	*baseStore
+}
+
+// mustNewUsageStore creates usage store, and creates the table if absent.
+func mustNewUsageStore(db *gorm.DB) *UsageStore {
+	if !db.Migrator().HasTable(&ApiKeyUsage{}) {
+		if err := db.Migrator().CreateTable(&ApiKeyUsage{}); err != nil {
+			logrus.WithError(err).Fatal("Failed to create api key usage table")
+		}
+	}
+
+	return &UsageStore{baseStore: newBaseStore(db)}
+}
+
+// AddApiKeyUsages implements the `usage.Sink` interface to accumulate the usage rollups.
+func (us *UsageStore) AddApiKeyUsages(rollups []usage.Rollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	models := make([]*ApiKeyUsage, 0, len(rollups))
+	for _, r := range rollups {
+		models = append(models, &ApiKeyUsage{
+			Bucket:      r.Bucket,
+			ApiKey:      r.ApiKey,
+			Method:      r.Method,
+			Calls:       r.Calls,
+			Errors:      r.Errors,
+			RateLimited: r.RateLimited,
+		})
+	}
+
+	return us.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "api_key"}, {Name: "bucket"}, {Name: "method"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"calls":        gorm.Expr("calls + VALUES(calls)"),
+			"errors":       gorm.Expr("errors + VALUES(errors)"),
+			"rate_limited": gorm.Expr("rate_limited + VALUES(rate_limited)"),
+		}),
+	}).CreateInBatches(models, 500).Error
+}
+
+// PruneApiKeyUsages implements the `usage.Sink` interface to remove the expired usage rollups.
+func (us *UsageStore) PruneApiKeyUsages(before int64) (int64, error) {
+	res := us.db.Where("bucket < ?", before).Delete(&ApiKeyUsage{})
+	return res.RowsAffected, res.Error
+}
+
+// ApiKeyUsageSeries implements the `usage.Querier` interface to return the usage series of API
+// key in time range [from, to) aggregated by step in seconds.
+func (us *UsageStore) ApiKeyUsageSeries(apiKey string, from, to, step int64) ([]usage.SeriesPoint, error) {
+	var points []usage.SeriesPoint
+
+	err := us.db.Model(&ApiKeyUsage{}).
+		Select(
+			"bucket - MOD(bucket - ?, ?) AS time, SUM(calls) AS calls, SUM(errors) AS errors, "+
+				"SUM(rate_limited) AS rate_limited", from, step,
+		).
+		Where("api_key = ? AND bucket >= ? AND bucket < ?", apiKey, from, to).
+		Group("time").
+		Order("time").
+		Scan(&points).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return points, nil
+}
+
+// ApiKeyTopMethods implements the `usage.Querier` interface to return the most called methods of
+// API key in time range [from, to).
+func (us *UsageStore) ApiKeyTopMethods(apiKey string, from, to int64, limit int) ([]usage.MethodUsage, error) {
+	var methods []usage.MethodUsage
+
+	err := us.db.Model(&ApiKeyUsage{}).
+		Select("method, SUM(calls) AS calls, SUM(errors) AS errors, SUM(rate_limited) AS rate_limited").
+		Where("api_key = ? AND bucket >= ? AND bucket < ?", apiKey, from, to).
+		Group("method").
+		Order("calls DESC").
+		Limit(limit).
+		Scan(&methods).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return methods, nil
+}
@@ -0,0 +1,163 @@
+package mysql
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// webhook delivery status
+const (
+	WebhookDeliveryPending uint8 = iota
+	WebhookDeliveryDelivered
+	WebhookDeliveryFailed
+)
+
+// Webhook is a registered URL to be notified of event logs matched with the filter.
+type Webhook struct {
+	ID     uint32
+	Url    string `gorm:"size:256;not null"`
+	Secret string `gorm:"size:128;not null"` // key to sign payload with HMAC-SHA256
+	Filter string `gorm:"type:text;not null"`
+	// the last epoch matched for delivery
+	Cursor     uint64 `gorm:"not null"`
+	CursorHash string `gorm:"size:66;not null"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// WebhookDelivery is a persisted payload to deliver to webhook at least once.
+type WebhookDelivery struct {
+	ID            uint64
+	WebhookID     uint32    `gorm:"not null;index:idx_webhook_status,priority:1"`
+	Payload       string    `gorm:"type:mediumtext;not null"`
+	Status        uint8     `gorm:"not null;index:idx_webhook_status,priority:2"`
+	NextAttemptAt time.Time `gorm:"not null"`
+	Attempts      uint32    `gorm:"not null"`
+	LastError     string    `gorm:"size:256"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookStore persists webhooks along with the deliveries.
+type WebhookStore struct {
+	*baseStore
+}
+
+// mustNewWebhookStore creates webhook store, and creates the tables if absent.
+func mustNewWebhookStore(db *gorm.DB) *WebhookStore {
+	for _, model := range []interface{}{&Webhook{}, &WebhookDelivery{}} {
+		if db.Migrator().HasTable(model) {
+			continue
+		}
+
+		if err := db.Migrator().CreateTable(model); err != nil {
+			logrus.WithError(err).Fatal("Failed to create webhook tables")
+		}
+	}
+
+	return &WebhookStore{baseStore: newBaseStore(db)}
+}
+
+// AddWebhook registers a new webhook.
+func (whs *WebhookStore) AddWebhook(webhook *Webhook) error {
+	return whs.db.Create(webhook).Error
+}
+
+// DeleteWebhook removes the webhook along with its deliveries.
+func (whs *WebhookStore) DeleteWebhook(id uint32) (bool, error) {
+	var deleted bool
+
+	err := whs.db.Transaction(func(dbTx *gorm.DB) error {
+		res := dbTx.Delete(&Webhook{}, "id = ?", id)
+		if res.Error != nil {
+			return res.Error
+		}
+
+		deleted = res.RowsAffected > 0
+
+		return dbTx.Delete(&WebhookDelivery{}, "webhook_id = ?", id).Error
+	})
+
+	return deleted, err
+}
+
+// LoadWebhooks loads all the registered webhooks.
+func (whs *WebhookStore) LoadWebhooks() ([]*Webhook, error) {
+	var webhooks []*Webhook
+	if err := whs.db.Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// AdvanceWebhook saves the deliveries and advances the webhook cursor atomically, so that no
+// matched payload will be lost. Returns false if the webhook cursor changed concurrently (e.g.,
+// by another sync instance) or webhook removed, in which case nothing will be saved.
+func (whs *WebhookStore) AdvanceWebhook(
+	webhook *Webhook, cursor uint64, cursorHash string, deliveries ...*WebhookDelivery,
+) (advanced bool, err error) {
+	err = whs.db.Transaction(func(dbTx *gorm.DB) error {
+		res := dbTx.Model(&Webhook{}).
+			Where("id = ? AND cursor = ? AND cursor_hash = ?", webhook.ID, webhook.Cursor, webhook.CursorHash).
+			Updates(map[string]interface{}{
+				"cursor":      cursor,
+				"cursor_hash": cursorHash,
+			})
+		if res.Error != nil {
+			return errors.WithMessage(res.Error, "failed to update webhook cursor")
+		}
+
+		if res.RowsAffected == 0 {
+			return nil
+		}
+
+		if len(deliveries) > 0 {
+			if err := dbTx.Create(deliveries).Error; err != nil {
+				return errors.WithMessage(err, "failed to save webhook deliveries")
+			}
+		}
+
+		advanced = true
+		return nil
+	})
+
+	if err != nil || !advanced {
+		return false, err
+	}
+
+	webhook.Cursor, webhook.CursorHash = cursor, cursorHash
+
+	return true, nil
+}
+
+// PendingWebhookDeliveries returns the oldest pending deliveries of the specified webhook.
+func (whs *WebhookStore) PendingWebhookDeliveries(webhookId uint32, limit int) ([]*WebhookDelivery, error) {
+	var deliveries []*WebhookDelivery
+
+	err := whs.db.Where("webhook_id = ? AND status = ?", webhookId, WebhookDeliveryPending).
+		Order("id").
+		Limit(limit).
+		Find(&deliveries).Error
+
+	return deliveries, err
+}
+
+// UpdateWebhookDelivery updates the delivery status after attempt.
+func (whs *WebhookStore) UpdateWebhookDelivery(delivery *WebhookDelivery) error {
+	return whs.db.Model(delivery).Select("status", "next_attempt_at", "attempts", "last_error").
+		Updates(delivery).Error
+}
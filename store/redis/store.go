@@ -47,9 +47,12 @@ func MustNewRedisStoreFromViper(disabler store.ChainDataDisabler) (*RedisStore,
 	logrus.WithField("config", rsconf).Debug("Creating redis store from viper config")
 
 	rdb := MustNewRedisClient(rsconf.Url)
-	ctx := context.Background()
+	return NewRedisStore(rdb, rsconf.CacheTime, disabler), true
+}
 
-	return &RedisStore{rdb: rdb, ctx: ctx, cacheTime: rsconf.CacheTime, disabler: disabler}, true
+// NewRedisStore creates a redis store with the specified redis client.
+func NewRedisStore(rdb *redis.Client, cacheTime time.Duration, disabler store.ChainDataDisabler) *RedisStore {
+	return &RedisStore{rdb: rdb, ctx: context.Background(), cacheTime: cacheTime, disabler: disabler}
 }
 
 func MustNewRedisClient(url string) *redis.Client {
@@ -213,8 +216,9 @@ func (rs *RedisStore) Pushn(dataSlice []*store.EpochData) error {
 		return errors.WithMessage(err, "failed to get global epoch range from redis")
 	}
 
-	// ensure continous epoch
-	if err := store.RequireContinuous(dataSlice, lastEpoch); err != nil {
+	// ensure continous epoch, or replayed epochs (eg., after crash recovery)
+	replayed, isReplay, err := store.RequireContinuousOrReplay(dataSlice, lastEpoch)
+	if err != nil {
 		return err
 	}
 
@@ -223,11 +227,36 @@ func (rs *RedisStore) Pushn(dataSlice []*store.EpochData) error {
 		watchKeys = append(watchKeys, getEpochBlocksCacheKey(data.Number))
 	}
 
+	if isReplay { // replayed epochs are popped within the same transaction to make writes idempotent
+		watchKeys = append(watchKeys, getEpochBlocksCacheKeys(replayed.From, replayed.To)...)
+		lastEpoch = replayed.From - 1
+	}
+
 	return rs.execWithTx(func(tx *redis.Tx) error {
 		txOpHistory := store.EpochDataOpAffects{NumAlters: store.EpochDataOpNumAlters{}}
 
-		// Operation is commited only if the watched keys remain unchanged.
+		var unlinkKeys []string
+		if isReplay {
+			var removeOpHistory store.EpochDataOpNumAlters
+			unlinkKeys, removeOpHistory, err = rs.loadRemoveKeys(
+				tx, replayed.From, replayed.To, store.EpochRemoveAll, store.EpochOpPop,
+			)
+			if err != nil {
+				return errors.WithMessage(err, "failed to load replayed epochs to pop")
+			}
+
+			txOpHistory.Merge(removeOpHistory)
+		}
+
+		// Operation is commited only if the watched keys remain unchanged. Besides, replayed
+		// epochs are popped and pushed atomically within MULTI/EXEC.
 		_, err = tx.TxPipelined(rs.ctx, func(pipe redis.Pipeliner) error {
+			if len(unlinkKeys) > 0 {
+				if err := pipe.Unlink(rs.ctx, unlinkKeys...).Err(); err != nil {
+					return err
+				}
+			}
+
 			for _, data := range dataSlice {
 				opHistory, err := rs.putOneWithTx(pipe, data)
 				if err != nil {
@@ -470,124 +499,149 @@ func (rs *RedisStore) remove(epochFrom, epochTo uint64, option store.EpochRemove
 	startTime := time.Now()
 	defer metrics.Registry.Store.Pop("redis").UpdateSince(startTime)
 
-	numEpochs := epochTo - epochFrom + 1
-	watchKeys := make([]string, 0, numEpochs)
+	return rs.execWithTx(func(tx *redis.Tx) error {
+		unlinkKeys, removeOpHistory, err := rs.loadRemoveKeys(tx, epochFrom, epochTo, option, rmOpType)
+		if err != nil {
+			return err
+		}
 
+		// Operation is commited only if the watched keys remain unchanged.
+		_, err = tx.TxPipelined(rs.ctx, func(pipe redis.Pipeliner) error {
+			return rs.removeWithPipe(pipe, unlinkKeys, removeOpHistory, epochFrom, epochTo, rmOpType)
+		})
+
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"epochFrom": epochFrom, "epochTo": epochTo,
+				"rmOption": option, "rmOpType": rmOpType,
+			}).WithError(err).Info(
+				"Failed to remove epoch data from reids store with pipeline unlink",
+			)
+		}
+
+		return err
+
+	}, getEpochBlocksCacheKeys(epochFrom, epochTo)...)
+}
+
+func getEpochBlocksCacheKeys(epochFrom, epochTo uint64) []string {
+	cacheKeys := make([]string, 0, epochTo-epochFrom+1)
 	for i := epochFrom; i <= epochTo; i++ {
-		watchKeys = append(watchKeys, getEpochBlocksCacheKey(i))
+		cacheKeys = append(cacheKeys, getEpochBlocksCacheKey(i))
 	}
 
+	return cacheKeys
+}
+
+// loadRemoveKeys loads the cache keys to unlink for the epoch range removal within the watched
+// transaction, along with the affected number of epoch data.
+func (rs *RedisStore) loadRemoveKeys(
+	tx *redis.Tx, epochFrom, epochTo uint64, option store.EpochRemoveOption, rmOpType store.EpochOpType,
+) ([]string, store.EpochDataOpNumAlters, error) {
 	removeOpHistory := store.EpochDataOpAffects{NumAlters: store.EpochDataOpNumAlters{}}
+	unlinkKeys := make([]string, 0, 100)
 
-	return rs.execWithTx(func(tx *redis.Tx) error {
-		unlinkKeys := make([]string, 0, 100)
+	for i := epochFrom; i <= epochTo; i++ {
+		opHistory := store.EpochDataOpNumAlters{}
 
-		for i := epochFrom; i <= epochTo; i++ {
-			opHistory := store.EpochDataOpNumAlters{}
+		epochNo := i
+		if rmOpType == store.EpochOpPop { // pop from back to front
+			epochNo = epochFrom + (epochTo - i)
+		}
 
-			epochNo := i
-			if rmOpType == store.EpochOpPop { // pop from back to front
-				epochNo = epochFrom + (epochTo - i)
-			}
+		epbCacheKey := getEpochBlocksCacheKey(epochNo)
+		ebtCacheKey := getEpochTxsCacheKey(epochNo)
 
-			epbCacheKey := getEpochBlocksCacheKey(epochNo)
-			ebtCacheKey := getEpochTxsCacheKey(epochNo)
+		// Remove blocks
+		if option&store.EpochRemoveBlock != 0 {
+			// Load epoch blocks mapping
+			blockHashes, err := loadEpochBlocks(rs.ctx, tx, epochNo)
+			if err != nil && !rs.IsRecordNotFound(err) {
+				return nil, nil, errors.WithMessage(err, "failed to load epoch to blocks mapping")
+			}
 
-			// Remove blocks
-			if option&store.EpochRemoveBlock != 0 {
-				// Load epoch blocks mapping
-				blockHashes, err := loadEpochBlocks(rs.ctx, tx, epochNo)
-				if err != nil && !rs.IsRecordNotFound(err) {
-					return errors.WithMessage(err, "failed to load epoch to blocks mapping")
+			if err == nil {
+				cacheKeys := make([]string, 0, len(blockHashes)+1)
+				for _, blockHash := range blockHashes {
+					cacheKeys = append(cacheKeys, getBlockCacheKey(blockHash))
 				}
 
-				if err == nil {
-					cacheKeys := make([]string, 0, len(blockHashes)+1)
-					for _, blockHash := range blockHashes {
-						cacheKeys = append(cacheKeys, getBlockCacheKey(blockHash))
-					}
+				opHistory[store.EpochBlock] = int64(-len(blockHashes))
+				cacheKeys = append(cacheKeys, epbCacheKey)
 
-					opHistory[store.EpochBlock] = int64(-len(blockHashes))
-					cacheKeys = append(cacheKeys, epbCacheKey)
+				unlinkKeys = append(unlinkKeys, cacheKeys...)
+			}
+		}
 
-					unlinkKeys = append(unlinkKeys, cacheKeys...)
-				}
+		// Remove transactions
+		if option&store.EpochRemoveTransaction != 0 {
+			// Load epoch transactions mapping
+			txHashes, err := loadEpochTxs(rs.ctx, tx, epochNo, 0, -1)
+			if err != nil && !rs.IsRecordNotFound(err) {
+				return nil, nil, errors.WithMessage(err, "failed to load epoch to txs mapping")
 			}
 
-			// Remove transactions
-			if option&store.EpochRemoveTransaction != 0 {
-				// Load epoch transactions mapping
-				txHashes, err := loadEpochTxs(rs.ctx, tx, epochNo, 0, -1)
-				if err != nil && !rs.IsRecordNotFound(err) {
-					return errors.WithMessage(err, "failed to load epoch to txs mapping")
+			if err == nil {
+				cacheKeys := make([]string, 0, len(txHashes)*2+1)
+				for _, txHash := range txHashes {
+					cacheKeys = append(cacheKeys, getTxCacheKey(txHash), getTxReceiptCacheKey(txHash))
 				}
 
-				if err == nil {
-					cacheKeys := make([]string, 0, len(txHashes)*2+1)
-					for _, txHash := range txHashes {
-						cacheKeys = append(cacheKeys, getTxCacheKey(txHash), getTxReceiptCacheKey(txHash))
-					}
+				opHistory[store.EpochTransaction] = int64(-len(txHashes))
+				cacheKeys = append(cacheKeys, ebtCacheKey)
 
-					opHistory[store.EpochTransaction] = int64(-len(txHashes))
-					cacheKeys = append(cacheKeys, ebtCacheKey)
-
-					unlinkKeys = append(unlinkKeys, cacheKeys...)
-				}
+				unlinkKeys = append(unlinkKeys, cacheKeys...)
 			}
-
-			// TODO remove logs
-
-			removeOpHistory.Merge(opHistory)
 		}
 
-		// Operation is commited only if the watched keys remain unchanged.
-		_, err := tx.TxPipelined(rs.ctx, func(pipe redis.Pipeliner) error {
-			// unlink epoch cache keys
-			if err := pipe.Unlink(rs.ctx, unlinkKeys...).Err(); err != nil {
-				return err
-			}
+		// TODO remove logs
 
-			// update epoch data count
-			if err := rs.updateEpochDataCount(pipe, removeOpHistory.NumAlters); err != nil {
-				return errors.WithMessage(err, "failed to update statistics on remove")
-			}
+		removeOpHistory.Merge(opHistory)
+	}
 
-			if rmOpType == store.EpochOpPop {
-				// update max of epoch range for pop operation
-				err := rs.updateEpochRangeMax(pipe, epochFrom-1)
-				return errors.WithMessage(err, "failed to update epoch range on remove")
-			}
+	return unlinkKeys, removeOpHistory.NumAlters, nil
+}
 
-			var edt store.EpochDataType
-			switch rmOpType {
-			case store.EpochOpDequeueBlock:
-				edt = store.EpochBlock
-			case store.EpochOpDequeueTx:
-				edt = store.EpochTransaction
-			case store.EpochOpDequeueLog:
-				edt = store.EpochLog
-			default:
-				logrus.
-					WithField("removeOperationType", rmOpType).
-					Fatal("Invalid remove operation type for redis store")
-			}
+// removeWithPipe unlinks the loaded cache keys and updates the statistics and epoch range of the
+// epoch range removal within the transaction pipeline.
+func (rs *RedisStore) removeWithPipe(
+	pipe redis.Pipeliner, unlinkKeys []string, removeOpHistory store.EpochDataOpNumAlters,
+	epochFrom, epochTo uint64, rmOpType store.EpochOpType,
+) error {
+	// unlink epoch cache keys
+	if len(unlinkKeys) > 0 {
+		if err := pipe.Unlink(rs.ctx, unlinkKeys...).Err(); err != nil {
+			return err
+		}
+	}
 
-			// update min of epoch range for dequeue operation
-			return rs.updateEpochRangeMin(pipe, epochTo+1, edt)
-		})
+	// update epoch data count
+	if err := rs.updateEpochDataCount(pipe, removeOpHistory); err != nil {
+		return errors.WithMessage(err, "failed to update statistics on remove")
+	}
 
-		if err != nil {
-			logrus.WithFields(logrus.Fields{
-				"epochFrom": epochFrom, "epochTo": epochTo,
-				"rmOption": option, "rmOpType": rmOpType,
-			}).WithError(err).Info(
-				"Failed to remove epoch data from reids store with pipeline unlink",
-			)
-		}
+	if rmOpType == store.EpochOpPop {
+		// update max of epoch range for pop operation
+		err := rs.updateEpochRangeMax(pipe, epochFrom-1)
+		return errors.WithMessage(err, "failed to update epoch range on remove")
+	}
 
-		return err
+	var edt store.EpochDataType
+	switch rmOpType {
+	case store.EpochOpDequeueBlock:
+		edt = store.EpochBlock
+	case store.EpochOpDequeueTx:
+		edt = store.EpochTransaction
+	case store.EpochOpDequeueLog:
+		edt = store.EpochLog
+	default:
+		logrus.
+			WithField("removeOperationType", rmOpType).
+			Fatal("Invalid remove operation type for redis store")
+	}
 
-	}, watchKeys...)
+	// update min of epoch range for dequeue operation
+	return rs.updateEpochRangeMin(pipe, epochTo+1, edt)
 }
 
 func (rs *RedisStore) dequeueEpochRangeData(rt store.EpochDataType, epochUntil uint64) error {
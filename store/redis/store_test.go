@@ -0,0 +1,88 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDisabler stores blocks only.
+type testDisabler struct{}
+
+func (testDisabler) IsChainBlockDisabled() bool   { return false }
+func (testDisabler) IsChainTxnDisabled() bool     { return true }
+func (testDisabler) IsChainReceiptDisabled() bool { return true }
+func (testDisabler) IsChainLogDisabled() bool     { return true }
+
+func (testDisabler) IsDisabledForType(edt store.EpochDataType) bool {
+	return edt != store.EpochBlock && edt != store.EpochDataNil
+}
+
+func newTestRedisStore(t *testing.T) *RedisStore {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	return &RedisStore{rdb: rdb, ctx: context.Background(), cacheTime: time.Hour, disabler: testDisabler{}}
+}
+
+// newTestEpochDataSlice creates epoch data with pivot block only, whose hash is distinguished by
+// the specified version.
+func newTestEpochDataSlice(epochFrom, epochTo uint64, version int) []*store.EpochData {
+	var slice []*store.EpochData
+	for i := epochFrom; i <= epochTo; i++ {
+		block := &types.Block{
+			BlockHeader: types.BlockHeader{
+				Hash:        types.Hash(fmt.Sprintf("0x%062x%02x", i, version)),
+				EpochNumber: types.NewBigInt(i),
+				BlockNumber: types.NewBigInt(i),
+			},
+		}
+
+		slice = append(slice, &store.EpochData{Number: i, Blocks: []*types.Block{block}})
+	}
+
+	return slice
+}
+
+func assertPivotBlock(t *testing.T, rs *RedisStore, epoch uint64, version int) {
+	hashes, err := rs.GetBlocksByEpoch(context.Background(), epoch)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Hash{types.Hash(fmt.Sprintf("0x%062x%02x", epoch, version))}, hashes)
+}
+
+func TestRedisStorePushnReplay(t *testing.T) {
+	rs := newTestRedisStore(t)
+
+	assert.NoError(t, rs.Pushn(newTestEpochDataSlice(10, 12, 0)))
+
+	// syncer restarts from checkpoint after crash, and replays the stored tail atomically
+	assert.NoError(t, rs.Pushn(newTestEpochDataSlice(11, 13, 1)))
+
+	minEpoch, maxEpoch, err := rs.GetGlobalEpochRange()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), minEpoch)
+	assert.Equal(t, uint64(13), maxEpoch)
+
+	assertPivotBlock(t, rs, 10, 0)
+	assertPivotBlock(t, rs, 11, 1)
+	assertPivotBlock(t, rs, 13, 1)
+
+	numBlocks, err := rs.GetNumBlocks()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), numBlocks)
+
+	// replay not covering the stored tail is rejected, and store remains unchanged
+	assert.Error(t, rs.Pushn(newTestEpochDataSlice(11, 12, 2)))
+
+	_, maxEpoch, err = rs.GetGlobalEpochRange()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(13), maxEpoch)
+	assertPivotBlock(t, rs, 12, 1)
+}
@@ -52,6 +52,44 @@ type Readable interface {
 	GetBlockSummaryByBlockNumber(ctx context.Context, blockNumber uint64) (*BlockSummary, error)
 }
 
+// AccountTxnFilter is used to query transaction hashes sent from some account within an epoch range.
+type AccountTxnFilter struct {
+	Address   string // sender address in hex format
+	EpochFrom uint64 // inclusive
+	EpochTo   uint64 // inclusive
+	Skip      uint64 // number of matched transactions to skip for pagination
+	Limit     uint64 // max number of transactions to return
+}
+
+// AccountTxnReadable is implemented by any store that indexes transactions by sender.
+type AccountTxnReadable interface {
+	// GetAccountTransactions returns the hashes of transactions sent from the specified account
+	// in ascending order of epoch.
+	GetAccountTransactions(ctx context.Context, filter AccountTxnFilter) ([]types.Hash, error)
+}
+
+// EpochReceiptReadable is implemented by any store that could read receipts of a whole epoch at once.
+type EpochReceiptReadable interface {
+	// GetEpochReceipts returns receipts of all executed transactions within the epoch in execution
+	// order, or ErrNotFound if the epoch not synced yet.
+	GetEpochReceipts(ctx context.Context, epochNumber uint64) ([]*TransactionReceipt, error)
+}
+
+// LogPageReadable is implemented by any store that could read a page of event logs without
+// materializing the full result set.
+type LogPageReadable interface {
+	// GetLogPage returns at most `limit` event logs matched with the filter after skipping the
+	// first `skip` ones, in order of block number and log index.
+	GetLogPage(ctx context.Context, filter LogFilter, skip, limit uint64) ([]*Log, error)
+}
+
+// BlockRangeReadable is implemented by any store that could read blocks of a range at once.
+type BlockRangeReadable interface {
+	// GetBlockSummariesByBlockNumberRange returns summaries of blocks within the block number
+	// range [from, to] in ascending order, and blocks not synced yet are absent.
+	GetBlockSummariesByBlockNumberRange(ctx context.Context, from, to uint64) ([]*BlockSummary, error)
+}
+
 type Configurable interface {
 	// LoadConfig load configurations with specified names
 	LoadConfig(confNames ...string) (map[string]interface{}, error)
@@ -68,6 +106,14 @@ type StackOperable interface {
 	Popn(epochUntil uint64) error
 }
 
+// EpochDataObserver is notified once epoch data committed into or reverted from store.
+type EpochDataObserver interface {
+	// OnEpochsPushed is called after epoch data persisted into store.
+	OnEpochsPushed(dataSlice []*EpochData)
+	// OnEpochsPopped is called after epochs since the specified epoch (inclusive) reverted from store.
+	OnEpochsPopped(epochFrom uint64)
+}
+
 // Store is implemented by any object that persist blockchain data, especially for event logs.
 type Store interface {
 	Readable
@@ -49,6 +49,7 @@ var (
 	ErrAlreadyPruned          = errors.New("data already pruned")
 	ErrChainReorged           = errors.New("chain re-orged")
 	ErrLeaderRenewal          = errors.New("leadership renewal failure")
+	ErrEpochParked            = errors.New("epoch parked into dead letter queue")
 
 	// operationable epoch data types
 	OpEpochDataTypes = []EpochDataType{
@@ -0,0 +1,168 @@
+package sync
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	citypes "github.com/Conflux-Chain/confura/types"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/web3go"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// RangeBackfiller re-fetches the epoch data within the specified epoch range from full node, and
+// re-persists them into store, e.g. to repair corrupted or missing data without touching the live
+// syncer. Note, only epochs strictly within the min and max epoch of the store could be backfilled.
+type RangeBackfiller struct {
+	space string
+	db    *mysql.MysqlStore
+	// max number of epochs to persist at a time
+	maxEpochs uint64
+	// queries epoch data from full node
+	query epochDataQuerier
+}
+
+// MustNewCfxRangeBackfiller creates core space range backfiller.
+func MustNewCfxRangeBackfiller(cfx *sdk.Client, db *mysql.MysqlStore, maxEpochs uint64) *RangeBackfiller {
+	return &RangeBackfiller{
+		space:     "cfx",
+		db:        db,
+		maxEpochs: max(maxEpochs, 1),
+		query:     mustNewCfxEpochDataQuerier(cfx),
+	}
+}
+
+// MustNewEthRangeBackfiller creates evm space range backfiller.
+func MustNewEthRangeBackfiller(w3c *web3go.Client, db *mysql.MysqlStore, maxEpochs uint64) *RangeBackfiller {
+	return &RangeBackfiller{
+		space:     "eth",
+		db:        db,
+		maxEpochs: max(maxEpochs, 1),
+		query:     mustNewEthEpochDataQuerier(w3c, db.IsInternalTxIndexed()),
+	}
+}
+
+// epochDataQuerier queries epoch data of the specified epoch (or block for evm space) from full node.
+type epochDataQuerier func(epochNo uint64) (*store.EpochData, error)
+
+func mustNewCfxEpochDataQuerier(cfx *sdk.Client) epochDataQuerier {
+	var syncConf syncConfig
+	viperutil.MustUnmarshalKey("sync", &syncConf)
+
+	return func(epochNo uint64) (*store.EpochData, error) {
+		data, err := store.QueryEpochData(cfx, epochNo, syncConf.UseBatch)
+		return &data, err
+	}
+}
+
+func mustNewEthEpochDataQuerier(w3c *web3go.Client, withTraces bool) epochDataQuerier {
+	chainId, err := w3c.Eth.ChainId()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to get chain ID from eth space")
+	}
+
+	var ethConf syncEthConfig
+	viperutil.MustUnmarshalKey("sync.eth", &ethConf)
+
+	traceW3c := mustNewEthTraceClient(&ethConf)
+	if traceW3c == nil {
+		traceW3c = w3c
+	}
+
+	return func(blockNo uint64) (*store.EpochData, error) {
+		data, err := store.QueryEthData(context.Background(), w3c, blockNo)
+		if err != nil {
+			return nil, err
+		}
+
+		if withTraces {
+			if data.Traces, err = store.QueryEthTraces(context.Background(), traceW3c, data.Block.Hash); err != nil {
+				return nil, err
+			}
+		}
+
+		return convertEthToEpochData(data, uint32(*chainId)), nil
+	}
+}
+
+// Backfill backfills the epochs within the specified epoch range, and returns the number of
+// backfilled epochs. Only missing epochs are backfilled by default, otherwise all the epochs within
+// range will be re-fetched and overwritten if `force` specified.
+func (rb *RangeBackfiller) Backfill(ctx context.Context, epochFrom, epochTo uint64, force bool) (uint64, error) {
+	if epochFrom > epochTo {
+		return 0, errors.Errorf("invalid epoch range [%v, %v]", epochFrom, epochTo)
+	}
+
+	ranges := []citypes.RangeUint64{{From: epochFrom, To: epochTo}}
+	persist := rb.db.Overwrite
+
+	if !force {
+		gaps, err := rb.db.FindEpochGaps(epochFrom, epochTo, 0)
+		if err != nil {
+			return 0, errors.WithMessage(err, "failed to find epoch gaps")
+		}
+
+		ranges, persist = gaps, rb.db.Backfill
+	}
+
+	var numBackfilled uint64
+	for _, r := range ranges {
+		for from := r.From; from <= r.To; from += rb.maxEpochs {
+			select {
+			case <-ctx.Done():
+				return numBackfilled, ctx.Err()
+			default:
+			}
+
+			to := min(from+rb.maxEpochs-1, r.To)
+			if err := rb.backfillOnce(from, to, persist); err != nil {
+				return numBackfilled, err
+			}
+
+			numBackfilled += to - from + 1
+		}
+	}
+
+	return numBackfilled, nil
+}
+
+func (rb *RangeBackfiller) backfillOnce(
+	epochFrom, epochTo uint64, persist func(dataSlice []*store.EpochData) error,
+) error {
+	startTime := time.Now()
+
+	dataSlice := make([]*store.EpochData, 0, epochTo-epochFrom+1)
+	for epochNo := epochFrom; epochNo <= epochTo; epochNo++ {
+		data, err := rb.query(epochNo)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to query epoch data for epoch %v", epochNo)
+		}
+
+		if len(dataSlice) > 0 {
+			if continuous, desc := data.IsContinuousTo(dataSlice[len(dataSlice)-1]); !continuous {
+				return errors.WithMessage(store.ErrContinousEpochRequired, desc)
+			}
+		}
+
+		dataSlice = append(dataSlice, data)
+	}
+
+	if err := persist(dataSlice); err != nil {
+		return errors.WithMessagef(err, "failed to backfill epochs %v", citypes.RangeUint64{
+			From: epochFrom, To: epochTo,
+		})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"space":     rb.space,
+		"epochFrom": epochFrom,
+		"epochTo":   epochTo,
+		"elapsed":   time.Since(startTime),
+	}).Info("Range backfiller backfilled epochs")
+
+	return nil
+}
@@ -5,6 +5,8 @@ import "time"
 type config struct {
 	// list of Conflux fullnodes to accelerate catching up until the latest stable epoch
 	CfxPool []string
+	// number of workers per fullnode to fetch epoch data concurrently
+	WorkersPerNode int `default:"1"`
 	// threshold for num of db rows per batch persistence
 	DbRowsThreshold int `default:"2500"`
 	// max number of db rows collected before persistence
@@ -15,6 +17,8 @@ type config struct {
 	Benchmark bool
 	// boost mode
 	Boost boostConfig
+	// adaptive throttling per db write latency
+	Throttle throttleConfig
 }
 
 // boostConfig holds the configuration parameters for boost catch-up mode.
@@ -10,6 +10,7 @@ import (
 	"github.com/Conflux-Chain/confura/store/mysql"
 	"github.com/Conflux-Chain/confura/sync/election"
 	"github.com/Conflux-Chain/confura/sync/monitor"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	logutil "github.com/Conflux-Chain/go-conflux-util/log"
 	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
@@ -41,6 +42,8 @@ type Syncer struct {
 	epochFrom uint64
 	// configuration for boost mode
 	boostConf boostConfig
+	// adaptive throttler per db write latency
+	throttler *throttler
 }
 
 // functional options for syncer
@@ -76,6 +79,12 @@ func WithBoostConfig(config boostConfig) SyncOption {
 	}
 }
 
+func WithThrottler(throttler *throttler) SyncOption {
+	return func(s *Syncer) {
+		s.throttler = throttler
+	}
+}
+
 func MustNewSyncer(
 	cfxClients []*sdk.Client,
 	db *mysql.MysqlStore,
@@ -86,11 +95,15 @@ func MustNewSyncer(
 	var conf config
 	viperutil.MustUnmarshalKey("sync.catchup", &conf)
 
+	throttler := newThrottler(conf.Throttle)
+
 	var workers []*worker
-	for i, nodeUrl := range conf.CfxPool { // initialize workers
-		name := fmt.Sprintf("CUWorker#%v", i)
-		worker := mustNewWorker(name, nodeUrl, conf.WorkerChanSize)
-		workers = append(workers, worker)
+	for _, nodeUrl := range conf.CfxPool { // initialize workers
+		for i := 0; i < max(conf.WorkersPerNode, 1); i++ {
+			name := fmt.Sprintf("CUWorker#%v", len(workers))
+			worker := mustNewWorker(name, nodeUrl, conf.WorkerChanSize, throttler)
+			workers = append(workers, worker)
+		}
 	}
 
 	var newOpts []SyncOption
@@ -100,6 +113,7 @@ func MustNewSyncer(
 		WithWorkers(workers),
 		WithBenchmark(conf.Benchmark),
 		WithBoostConfig(conf.Boost),
+		WithThrottler(throttler),
 	)
 
 	return newSyncer(cfxClients, db, elm, monitor, epochFrom, append(newOpts, opts...)...)
@@ -119,6 +133,7 @@ func newSyncer(
 		monitor:        monitor,
 		epochFrom:      epochFrom,
 		minBatchDbRows: 1500,
+		throttler:      newThrottler(throttleConfig{}),
 	}
 	for _, opt := range opts {
 		opt(syncer)
@@ -227,7 +242,7 @@ func (s *Syncer) doSync(ctx context.Context, bmarker *benchmarker, start, end ui
 }
 
 func (s *Syncer) fetchResult(ctx context.Context, start, end uint64, bmarker *benchmarker) error {
-	var epochData *store.EpochData
+	var epochData, prevEpochData *store.EpochData
 	var state persistState
 
 	for eno := start; eno <= end; {
@@ -236,6 +251,14 @@ func (s *Syncer) fetchResult(ctx context.Context, start, end uint64, bmarker *be
 
 			select {
 			case <-ctx.Done():
+				// persist the collected epochs on shutdown to checkpoint the sync progress
+				flushCtx, cancel := context.WithTimeout(context.Background(), rpcutil.DefaultShutdownTimeout)
+				defer cancel()
+
+				if err := s.persist(flushCtx, &state, bmarker); err != nil {
+					return errors.WithMessage(err, "failed to persist collected epochs on shutdown")
+				}
+
 				return ctx.Err()
 			case epochData = <-w.Data():
 				if bmarker != nil {
@@ -248,6 +271,16 @@ func (s *Syncer) fetchResult(ctx context.Context, start, end uint64, bmarker *be
 				s.monitor.Update(eno)
 			}
 
+			// validate pivot linkage, since epoch data are fetched from different full nodes
+			if prevEpochData != nil {
+				if continuous, desc := epochData.IsContinuousTo(prevEpochData); !continuous {
+					return errors.WithMessagef(
+						store.ErrContinousEpochRequired, "pivot linkage broken from worker %v: %v", w.name, desc,
+					)
+				}
+			}
+			prevEpochData = epochData
+
 			epochDbRows, storeDbRows := state.update(epochData)
 
 			logrus.WithFields(logrus.Fields{
@@ -317,6 +350,15 @@ func (s *Syncer) persist(ctx context.Context, state *persistState, bmarker *benc
 		return errors.WithMessage(err, "failed to push db store")
 	}
 
+	latency := time.Since(start)
+	if delay := s.throttler.update(latency); delay > 0 {
+		logrus.WithFields(logrus.Fields{
+			"numEpochs": numEpochs,
+			"latency":   latency,
+			"delay":     delay,
+		}).Debug("Catch-up syncer throttled workers due to slow db write")
+	}
+
 	if bmarker != nil {
 		bmarker.metricPersistDb(start, state)
 	}
@@ -0,0 +1,65 @@
+package catchup
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// min delay once throttling started
+	minThrottleDelay = 10 * time.Millisecond
+)
+
+// throttleConfig holds the configuration parameters for adaptive throttling.
+type throttleConfig struct {
+	// target db write latency per batch persistence, throttling disabled if 0
+	TargetLatency time.Duration
+	// max delay for workers before fetching each epoch
+	MaxDelay time.Duration `default:"1s"`
+}
+
+// throttler adaptively throttles workers to fetch epoch data per db write latency, so as not to
+// overwhelm the database during catch-up. The delay will be doubled if db write latency exceeds
+// the target, and halved otherwise.
+type throttler struct {
+	conf  throttleConfig
+	delay atomic.Int64 // current delay before fetching each epoch
+}
+
+func newThrottler(conf throttleConfig) *throttler {
+	return &throttler{conf: conf}
+}
+
+// update adjusts the throttle delay with the latest db write latency.
+func (t *throttler) update(latency time.Duration) time.Duration {
+	if t.conf.TargetLatency <= 0 {
+		return 0
+	}
+
+	delay := time.Duration(t.delay.Load())
+	if latency > t.conf.TargetLatency {
+		delay = min(max(2*delay, minThrottleDelay), t.conf.MaxDelay)
+	} else if delay /= 2; delay < minThrottleDelay {
+		delay = 0
+	}
+
+	t.delay.Store(int64(delay))
+	return delay
+}
+
+// wait blocks for the current throttle delay or until context canceled.
+func (t *throttler) wait(ctx context.Context) {
+	delay := time.Duration(t.delay.Load())
+	if delay <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,27 @@
+package catchup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottlerUpdate(t *testing.T) {
+	// disabled
+	th := newThrottler(throttleConfig{MaxDelay: time.Second})
+	assert.Equal(t, time.Duration(0), th.update(time.Hour))
+
+	th = newThrottler(throttleConfig{TargetLatency: time.Second, MaxDelay: 50 * time.Millisecond})
+
+	// slow down if db write latency exceeds the target
+	assert.Equal(t, minThrottleDelay, th.update(2*time.Second))
+	assert.Equal(t, 2*minThrottleDelay, th.update(2*time.Second))
+	assert.Equal(t, 4*minThrottleDelay, th.update(2*time.Second))
+	assert.Equal(t, 50*time.Millisecond, th.update(2*time.Second))
+
+	// speed up if db write latency recovered
+	assert.Equal(t, 25*time.Millisecond, th.update(time.Millisecond))
+	assert.Equal(t, 12500*time.Microsecond, th.update(time.Millisecond))
+	assert.Equal(t, time.Duration(0), th.update(time.Millisecond))
+}
@@ -19,13 +19,16 @@ type worker struct {
 	resultChan chan *store.EpochData
 	// conflux sdk client delegated to fetch epoch data
 	cfx sdk.ClientOperator
+	// throttler to slow down fetching per db write latency
+	throttler *throttler
 }
 
-func mustNewWorker(name, nodeUrl string, chanSize int) *worker {
+func mustNewWorker(name, nodeUrl string, chanSize int, throttler *throttler) *worker {
 	return &worker{
 		name:       name,
 		resultChan: make(chan *store.EpochData, chanSize),
-		cfx:        rpc.MustNewCfxClient(nodeUrl),
+		cfx:        rpc.MustNewCfxClient(nodeUrl, rpc.WithClientSyncThrottle(true)),
+		throttler:  throttler,
 	}
 }
 
@@ -38,6 +41,8 @@ func (w *worker) Sync(ctx context.Context, wg *sync.WaitGroup, epochFrom, epochT
 		case <-ctx.Done():
 			return
 		default:
+			w.throttler.wait(ctx)
+
 			epochData, err := w.fetchEpoch(eno)
 			etLogger.Log(
 				logrus.WithFields(logrus.Fields{
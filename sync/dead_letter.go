@@ -0,0 +1,153 @@
+package sync
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// deadLetterConfig is the settings to park the epochs failed to persist repeatedly.
+type deadLetterConfig struct {
+	Enabled bool
+	// number of consecutive failures to persist the same epoch before parked
+	MaxFailures int `default:"3"`
+}
+
+// epochDataPusher persists the epoch data slice into store with an extra finalizer.
+type epochDataPusher func(dataSlice []*store.EpochData, finalizer func(*gorm.DB) error) error
+
+// deadLetterQueue parks the epoch failed to persist repeatedly due to data specific errors (e.g.,
+// data too large or constraint violation) into the dead letter table with the error, and persists
+// the epoch with block headers only instead, so that sync could continue rather than get stuck.
+// Chain data reads within parked epochs are rejected by store, and thus served by fullnode. The
+// parked epochs could be retried by admin command after a fix.
+type deadLetterQueue struct {
+	conf  deadLetterConfig
+	space string
+	db    *mysql.MysqlStore
+
+	failedEpoch uint64 // the first epoch of the last failed batch
+	failures    int    // number of consecutive failures to persist the batch
+}
+
+func mustNewDeadLetterQueueFromViper(space string, db *mysql.MysqlStore) *deadLetterQueue {
+	key := "sync.deadLetter"
+	if space == "eth" {
+		key = "sync.eth.deadLetter"
+	}
+
+	var conf deadLetterConfig
+	viperutil.MustUnmarshalKey(key, &conf)
+
+	return &deadLetterQueue{conf: conf, space: space, db: db}
+}
+
+// push persists the epoch data slice, and returns the number of epochs persisted. Once failed to
+// persist repeatedly, the epochs will be persisted one by one to locate the offending epoch, which
+// will be parked into the dead letter table.
+func (q *deadLetterQueue) push(dataSlice []*store.EpochData, pusher epochDataPusher) (int, error) {
+	err := pusher(dataSlice, nil)
+	if err == nil {
+		q.failures = 0
+		return len(dataSlice), nil
+	}
+
+	if !q.conf.Enabled || errors.Is(err, store.ErrLeaderRenewal) {
+		return 0, err
+	}
+
+	if dataSlice[0].Number != q.failedEpoch {
+		q.failedEpoch, q.failures = dataSlice[0].Number, 0
+	}
+
+	if q.failures++; q.failures < q.conf.MaxFailures {
+		return 0, err
+	}
+
+	for i, data := range dataSlice {
+		err := pusher([]*store.EpochData{data}, nil)
+		if err == nil {
+			continue
+		}
+
+		// transient errors (e.g., lost connection or lock wait timeout) are retried rather than parked
+		if !mysql.IsDataError(err) {
+			return i, err
+		}
+
+		if err := q.park(data, err, pusher); err != nil {
+			return i, err
+		}
+
+		q.failures = 0
+		return i + 1, nil
+	}
+
+	// e.g., the batch is too large to persist at a time
+	q.failures = 0
+	return len(dataSlice), nil
+}
+
+// park persists the epoch with block headers only, along with the dead letter entry within the same
+// database transaction.
+func (q *deadLetterQueue) park(data *store.EpochData, cause error, pusher epochDataPusher) error {
+	pivotHash := string(data.GetPivotBlock().Hash)
+
+	err := pusher([]*store.EpochData{data.Skeleton()}, func(dbTx *gorm.DB) error {
+		return q.db.AddDeadLetterEpochWithTx(dbTx, data.Number, pivotHash, cause)
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "failed to park epoch %v into dead letter queue", data.Number)
+	}
+
+	metrics.Registry.Sync.DeadLetterEpochs(q.space).Mark(1)
+
+	logrus.WithFields(logrus.Fields{
+		"space":     q.space,
+		"epoch":     data.Number,
+		"pivotHash": pivotHash,
+	}).WithError(cause).Error("Parked epoch into dead letter queue due to failed to persist repeatedly")
+
+	return nil
+}
+
+// RetryDeadLetterEpochs re-fetches and overwrites the unresolved dead letter epochs by backfiller,
+// and marks them resolved once succeeded. Returns the number of resolved epochs.
+func RetryDeadLetterEpochs(ctx context.Context, rb *RangeBackfiller) (int, error) {
+	entries, err := rb.db.DeadLetterEpochs(false, 0)
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to get dead letter epochs")
+	}
+
+	var numResolved int
+	for _, entry := range entries {
+		logger := logrus.WithFields(logrus.Fields{
+			"space": rb.space,
+			"epoch": entry.Epoch,
+		})
+
+		if _, err := rb.Backfill(ctx, entry.Epoch, entry.Epoch, true); err != nil {
+			if ctx.Err() != nil {
+				return numResolved, ctx.Err()
+			}
+
+			logger.WithError(err).Warn("Failed to retry dead letter epoch")
+			continue
+		}
+
+		if err := rb.db.ResolveDeadLetterEpoch(entry.Epoch); err != nil {
+			return numResolved, errors.WithMessagef(err, "failed to resolve dead letter epoch %v", entry.Epoch)
+		}
+
+		numResolved++
+		logger.Info("Succeeded to retry dead letter epoch")
+	}
+
+	return numResolved, nil
+}
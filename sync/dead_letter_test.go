@@ -0,0 +1,105 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func newTestDeadLetterEpochs(epochFrom, epochTo uint64) []*store.EpochData {
+	var slice []*store.EpochData
+	for i := epochFrom; i <= epochTo; i++ {
+		block := &types.Block{Transactions: []types.Transaction{{}}}
+		slice = append(slice, &store.EpochData{Number: i, Blocks: []*types.Block{block}})
+	}
+
+	return slice
+}
+
+func TestDeadLetterQueuePush(t *testing.T) {
+	errTooLarge := &mysql.MySQLError{Number: 1406, Message: "data too long"}
+
+	var pushed []uint64
+	var skeletons []uint64
+	pusher := func(dataSlice []*store.EpochData, finalizer func(*gorm.DB) error) error {
+		for _, data := range dataSlice {
+			if data.Number == 12 && len(data.Blocks[0].Transactions) > 0 {
+				return errTooLarge
+			}
+		}
+
+		for _, data := range dataSlice {
+			if len(data.Blocks[0].Transactions) == 0 {
+				skeletons = append(skeletons, data.Number)
+			}
+
+			pushed = append(pushed, data.Number)
+		}
+
+		return nil
+	}
+
+	q := &deadLetterQueue{conf: deadLetterConfig{Enabled: true, MaxFailures: 2}, space: "cfx"}
+	dataSlice := newTestDeadLetterEpochs(10, 14)
+
+	// failed until max failures reached
+	n, err := q.push(dataSlice, pusher)
+	assert.ErrorIs(t, err, errTooLarge)
+	assert.Equal(t, 0, n)
+	assert.Empty(t, pushed)
+
+	// the offending epoch parked, along with the epochs before persisted
+	n, err = q.push(dataSlice, pusher)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []uint64{10, 11, 12}, pushed)
+	assert.Equal(t, []uint64{12}, skeletons)
+	assert.Equal(t, 0, q.failures)
+
+	// succeeded to push the rest
+	n, err = q.push(dataSlice[n:], pusher)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func TestDeadLetterQueueDisabled(t *testing.T) {
+	errTooLarge := &mysql.MySQLError{Number: 1406, Message: "data too long"}
+	pusher := func(dataSlice []*store.EpochData, finalizer func(*gorm.DB) error) error {
+		return errTooLarge
+	}
+
+	q := &deadLetterQueue{conf: deadLetterConfig{MaxFailures: 1}, space: "cfx"}
+	for i := 0; i < 3; i++ {
+		n, err := q.push(newTestDeadLetterEpochs(10, 12), pusher)
+		assert.ErrorIs(t, err, errTooLarge)
+		assert.Equal(t, 0, n)
+	}
+}
+
+func TestDeadLetterQueueTransientError(t *testing.T) {
+	errLockTimeout := &mysql.MySQLError{Number: 1205, Message: "lock wait timeout exceeded"}
+
+	var parked bool
+	pusher := func(dataSlice []*store.EpochData, finalizer func(*gorm.DB) error) error {
+		if finalizer != nil {
+			parked = true
+			return nil
+		}
+
+		return errLockTimeout
+	}
+
+	// transient errors are never parked even if failed repeatedly
+	q := &deadLetterQueue{conf: deadLetterConfig{Enabled: true, MaxFailures: 1}, space: "cfx"}
+	for i := 0; i < 3; i++ {
+		n, err := q.push(newTestDeadLetterEpochs(10, 12), pusher)
+		assert.ErrorIs(t, err, errLockTimeout)
+		assert.Equal(t, 0, n)
+	}
+
+	assert.False(t, parked)
+}
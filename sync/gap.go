@@ -0,0 +1,229 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	citypes "github.com/Conflux-Chain/confura/types"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// GapBackfillConfig represents the configuration to detect and backfill missing epochs in store.
+type GapBackfillConfig struct {
+	Enabled bool
+	// interval to detect and backfill epoch gaps
+	Interval time.Duration `default:"1m"`
+	// number of latest epochs to detect gaps, 0 means all epochs in store
+	Window uint64 `default:"100000"`
+	// max number of gaps to backfill at a time
+	MaxGaps int `default:"10"`
+	// max number of epochs to persist at a time
+	MaxEpochs uint64 `default:"10"`
+	// JSON-RPC endpoint to trigger gap detection and backfill manually, disabled if empty
+	AdminEndpoint string
+	// bearer token to authenticate admin requests, required if admin endpoint configured
+	AuthToken string
+}
+
+// GapBackfiller periodically detects missing epochs in store (e.g., after crashes), and re-fetches
+// these epochs from full node to backfill, so that the store is always gap-free.
+type GapBackfiller struct {
+	conf     *GapBackfillConfig
+	useBatch bool
+	cfx      *sdk.Client
+	db       *mysql.MysqlStore
+	// serializes backfills between ticker and admin trigger
+	mu sync.Mutex
+}
+
+// MustNewGapBackfillerFromViper creates an instance of GapBackfiller, or nil if disabled.
+func MustNewGapBackfillerFromViper(cfxClients []*sdk.Client, db *mysql.MysqlStore) *GapBackfiller {
+	var conf GapBackfillConfig
+	viperutil.MustUnmarshalKey("sync.gapBackfill", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	if len(cfxClients) == 0 {
+		logrus.Fatal("No sdk client provided for gap backfill")
+	}
+
+	if len(conf.AdminEndpoint) > 0 && len(conf.AuthToken) == 0 {
+		logrus.Fatal("Auth token required for sync admin endpoint")
+	}
+
+	var syncConf syncConfig
+	viperutil.MustUnmarshalKey("sync", &syncConf)
+
+	return &GapBackfiller{
+		conf:     &conf,
+		useBatch: syncConf.UseBatch,
+		cfx:      cfxClients[0],
+		db:       db,
+	}
+}
+
+// Run starts to detect and backfill epoch gaps periodically, along with the admin endpoint if configured.
+func (gb *GapBackfiller) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if len(gb.conf.AdminEndpoint) > 0 {
+		server := rpcutil.MustNewAdminServer("sync_admin", map[string]interface{}{
+			"sync": &gapAdminAPI{gb: gb},
+		}, rpcutil.MustNewBearerAuthMiddleware(gb.conf.AuthToken))
+		go server.MustServeGraceful(ctx, wg, gb.conf.AdminEndpoint, rpcutil.ProtocolHttp)
+	}
+
+	wg.Add(1)
+	defer wg.Done()
+
+	ticker := time.NewTicker(gb.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Gap backfiller shutdown ok")
+			return
+		case <-ticker.C:
+			if err := gb.doTicker(); err != nil {
+				logrus.WithError(err).Error("Gap backfiller failed to backfill epoch gaps")
+			}
+		}
+	}
+}
+
+func (gb *GapBackfiller) doTicker() error {
+	maxEpoch, ok, err := gb.db.MaxEpoch()
+	if err != nil || !ok {
+		return err
+	}
+
+	var epochFrom uint64
+	if gb.conf.Window > 0 && maxEpoch >= gb.conf.Window {
+		epochFrom = maxEpoch - gb.conf.Window + 1
+	}
+
+	gaps, err := gb.Gaps(epochFrom, maxEpoch)
+	if err != nil {
+		return err
+	}
+
+	var numMissing uint64
+	for _, gap := range gaps {
+		numMissing += gap.To - gap.From + 1
+	}
+	metrics.Registry.Sync.EpochGaps("cfx").Update(int64(numMissing))
+
+	for _, gap := range gaps {
+		if _, err := gb.Backfill(gap.From, gap.To); err != nil {
+			return errors.WithMessagef(err, "failed to backfill epoch gap %v", gap)
+		}
+	}
+
+	return nil
+}
+
+// Gaps returns the missing epoch ranges within the specified epoch range.
+func (gb *GapBackfiller) Gaps(epochFrom, epochTo uint64) ([]citypes.RangeUint64, error) {
+	gaps, err := gb.db.FindEpochGaps(epochFrom, epochTo, gb.conf.MaxGaps)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to find epoch gaps")
+	}
+
+	return gaps, nil
+}
+
+// Backfill re-fetches the missing epochs within the specified epoch range from full node and
+// persists them into store, and returns the number of backfilled epochs.
+func (gb *GapBackfiller) Backfill(epochFrom, epochTo uint64) (uint64, error) {
+	gb.mu.Lock()
+	defer gb.mu.Unlock()
+
+	gaps, err := gb.db.FindEpochGaps(epochFrom, epochTo, 0)
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to find epoch gaps")
+	}
+
+	var numBackfilled uint64
+	for _, gap := range gaps {
+		for from := gap.From; from <= gap.To; from += gb.conf.MaxEpochs {
+			to := min(from+gb.conf.MaxEpochs-1, gap.To)
+
+			if err := gb.backfillOnce(from, to); err != nil {
+				return numBackfilled, err
+			}
+
+			numBackfilled += to - from + 1
+		}
+	}
+
+	return numBackfilled, nil
+}
+
+func (gb *GapBackfiller) backfillOnce(epochFrom, epochTo uint64) (err error) {
+	startTime := time.Now()
+	defer func() {
+		metrics.Registry.Sync.BackfillOnce("cfx", err).UpdateSince(startTime)
+	}()
+
+	dataSlice := make([]*store.EpochData, 0, epochTo-epochFrom+1)
+	for epochNo := epochFrom; epochNo <= epochTo; epochNo++ {
+		data, err := store.QueryEpochData(gb.cfx, epochNo, gb.useBatch)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to query epoch data for epoch %v", epochNo)
+		}
+
+		if len(dataSlice) > 0 {
+			if continuous, desc := data.IsContinuousTo(dataSlice[len(dataSlice)-1]); !continuous {
+				return errors.WithMessage(store.ErrContinousEpochRequired, desc)
+			}
+		}
+
+		dataSlice = append(dataSlice, &data)
+	}
+
+	if err := gb.db.Backfill(dataSlice); err != nil {
+		return errors.WithMessagef(err, "failed to backfill epochs %v", citypes.RangeUint64{
+			From: epochFrom, To: epochTo,
+		})
+	}
+
+	metrics.Registry.Sync.BackfillEpochs("cfx").Mark(int64(len(dataSlice)))
+
+	logrus.WithFields(logrus.Fields{
+		"epochFrom": epochFrom,
+		"epochTo":   epochTo,
+	}).Info("Gap backfiller backfilled missing epochs")
+
+	return nil
+}
+
+// gapAdminAPI provides admin RPCs to detect and backfill epoch gaps manually.
+type gapAdminAPI struct {
+	gb *GapBackfiller
+}
+
+// Gaps returns the missing epoch ranges within the specified epoch range.
+func (api *gapAdminAPI) Gaps(ctx context.Context, epochFrom, epochTo uint64) ([]citypes.RangeUint64, error) {
+	return api.gb.Gaps(epochFrom, epochTo)
+}
+
+// Backfill backfills the missing epochs within the specified epoch range, and returns
+// the number of backfilled epochs.
+func (api *gapAdminAPI) Backfill(ctx context.Context, epochFrom, epochTo uint64) (uint64, error) {
+	return api.gb.Backfill(epochFrom, epochTo)
+}
+
+// Reorgs returns the latest pivot switches recorded during sync which reverted any epoch within
+// the specified epoch range.
+func (api *gapAdminAPI) Reorgs(ctx context.Context, epochFrom, epochTo uint64) ([]mysql.ReorgEvent, error) {
+	return api.gb.db.ReorgEvents(epochFrom, epochTo, 0)
+}
@@ -0,0 +1,267 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/memory"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	logutil "github.com/Conflux-Chain/go-conflux-util/log"
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/web3go"
+	ethtypes "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// NearHeadConfig represents the configuration of the near-head in-memory window.
+type NearHeadConfig struct {
+	Enabled bool
+	// interval to sync the near-head epochs
+	Interval time.Duration `default:"1s"`
+	// max number of epochs cached in memory
+	MaxEpochs uint64 `default:"100"`
+	// whether to use `epoch_getEpochReceipts` to batch get receipts
+	UseBatch bool
+}
+
+// nearHeadSource is the full node to sync near-head epochs from.
+type nearHeadSource interface {
+	// latestEpoch returns the latest epoch (or block for evm space) that could be synced.
+	latestEpoch() (uint64, error)
+	// queryEpochData returns the epoch data, or `store.ErrEpochPivotSwitched` if reorged during query.
+	queryEpochData(epochNo uint64) (*store.EpochData, error)
+}
+
+// cfxNearHeadSource syncs near-head epochs from core space full node.
+type cfxNearHeadSource struct {
+	cfx      sdk.ClientOperator
+	useBatch bool
+}
+
+func (src *cfxNearHeadSource) latestEpoch() (uint64, error) {
+	head, err := src.cfx.GetEpochNumber(types.EpochLatestConfirmed)
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to query the latest confirmed epoch number")
+	}
+
+	return head.ToInt().Uint64(), nil
+}
+
+func (src *cfxNearHeadSource) queryEpochData(epochNo uint64) (*store.EpochData, error) {
+	data, err := store.QueryEpochData(src.cfx, epochNo, src.useBatch)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// ethNearHeadSource syncs near-head blocks from evm space full node, which are converted to epoch
+// data as evm space sync does.
+type ethNearHeadSource struct {
+	w3c     *web3go.Client
+	chainId uint32
+}
+
+func (src *ethNearHeadSource) latestEpoch() (uint64, error) {
+	block, err := src.w3c.Eth.BlockByNumber(ethtypes.SafeBlockNumber, false)
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to query the latest safe block")
+	}
+
+	if block == nil || block.Number == nil {
+		return 0, errors.New("invalid latest safe block (must not be nil)")
+	}
+
+	return block.Number.Uint64(), nil
+}
+
+func (src *ethNearHeadSource) queryEpochData(blockNo uint64) (*store.EpochData, error) {
+	data, err := store.QueryEthData(context.Background(), src.w3c, blockNo)
+	if errors.Is(err, store.ErrChainReorged) {
+		return nil, store.ErrEpochPivotSwitched
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return convertEthToEpochData(data, src.chainId), nil
+}
+
+// NearHeadSyncer syncs the latest epochs that are not persisted into database yet (e.g., due to
+// confirmation depth) into memory, so that recent queries could still be answered from the
+// in-memory window merged with database.
+type NearHeadSyncer struct {
+	conf *NearHeadConfig
+	src  nearHeadSource
+	db   *mysql.MysqlStore
+	nhs  *memory.NearHeadStore
+}
+
+// MustNewNearHeadSyncerFromViper creates an instance of NearHeadSyncer for core space, or nil if
+// disabled.
+func MustNewNearHeadSyncerFromViper(cfx sdk.ClientOperator, db *mysql.MysqlStore) *NearHeadSyncer {
+	conf, ok := mustLoadNearHeadConfig()
+	if !ok {
+		return nil
+	}
+
+	return newNearHeadSyncer(conf, &cfxNearHeadSource{cfx: cfx, useBatch: conf.UseBatch}, db)
+}
+
+// MustNewEthNearHeadSyncerFromViper creates an instance of NearHeadSyncer for evm space, or nil if
+// disabled.
+func MustNewEthNearHeadSyncerFromViper(w3c *web3go.Client, db *mysql.MysqlStore) *NearHeadSyncer {
+	conf, ok := mustLoadNearHeadConfig()
+	if !ok {
+		return nil
+	}
+
+	chainId, err := w3c.Eth.ChainId()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to get chain ID from eth space for near head syncer")
+	}
+
+	return newNearHeadSyncer(conf, &ethNearHeadSource{w3c: w3c, chainId: uint32(*chainId)}, db)
+}
+
+func mustLoadNearHeadConfig() (*NearHeadConfig, bool) {
+	var conf NearHeadConfig
+	viperutil.MustUnmarshalKey("sync.nearHead", &conf)
+
+	return &conf, conf.Enabled
+}
+
+func newNearHeadSyncer(conf *NearHeadConfig, src nearHeadSource, db *mysql.MysqlStore) *NearHeadSyncer {
+	return &NearHeadSyncer{
+		conf: conf,
+		src:  src,
+		db:   db,
+		nhs:  memory.NewNearHeadStore(),
+	}
+}
+
+// Store returns the in-memory store of near-head epochs.
+func (syncer *NearHeadSyncer) Store() *memory.NearHeadStore {
+	return syncer.nhs
+}
+
+// Sync starts to sync near-head epochs into memory.
+func (syncer *NearHeadSyncer) Sync(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+
+	ticker := time.NewTicker(syncer.conf.Interval)
+	defer ticker.Stop()
+
+	etLogger := logutil.NewErrorTolerantLogger(logutil.DefaultETConfig)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Near head syncer shutdown ok")
+			return
+		case <-ticker.C:
+			err := syncer.syncOnce()
+			etLogger.Log(logrus.StandardLogger(), err, "Near head syncer failed to sync epoch data")
+		}
+	}
+}
+
+func (syncer *NearHeadSyncer) syncOnce() error {
+	// evict epochs already persisted into database
+	maxDbEpoch, hasDbEpoch, err := syncer.db.MaxEpoch()
+	if err != nil {
+		return errors.WithMessage(err, "failed to get max epoch from db")
+	}
+
+	if hasDbEpoch {
+		syncer.nhs.Dequeue(maxDbEpoch)
+
+		if err := syncer.ensureContinuousToDb(maxDbEpoch); err != nil {
+			return err
+		}
+	}
+
+	headEpoch, err := syncer.src.latestEpoch()
+	if err != nil {
+		return err
+	}
+
+	epochFrom, ok := syncer.nextEpoch(maxDbEpoch, hasDbEpoch)
+	if !ok || epochFrom > headEpoch {
+		return nil
+	}
+
+	// always keep the latest epochs within the window
+	if headEpoch-epochFrom+1 > syncer.conf.MaxEpochs {
+		syncer.nhs.Reset()
+		epochFrom = headEpoch - syncer.conf.MaxEpochs + 1
+	}
+
+	prev := syncer.nhs.LatestEpochData()
+
+	var dataSlice []*store.EpochData
+	for epochNo := epochFrom; epochNo <= headEpoch; epochNo++ {
+		data, err := syncer.src.queryEpochData(epochNo)
+		if errors.Is(err, store.ErrEpochPivotSwitched) {
+			break
+		}
+
+		if err != nil {
+			return errors.WithMessagef(err, "failed to query epoch data for epoch %v", epochNo)
+		}
+
+		if prev != nil {
+			if continuous, _ := data.IsContinuousTo(prev); !continuous {
+				if len(dataSlice) == 0 { // pivot switched, revert the latest epoch and retry later
+					syncer.nhs.Popn(prev.Number)
+				}
+
+				break
+			}
+		}
+
+		dataSlice = append(dataSlice, data)
+		prev = data
+	}
+
+	return syncer.nhs.Pushn(dataSlice)
+}
+
+// nextEpoch returns the next epoch to sync into memory window, or false if nothing synced into
+// database yet.
+func (syncer *NearHeadSyncer) nextEpoch(maxDbEpoch uint64, hasDbEpoch bool) (uint64, bool) {
+	if epochRange, ok := syncer.nhs.EpochRange(); ok {
+		return epochRange.To + 1, true
+	}
+
+	return maxDbEpoch + 1, hasDbEpoch
+}
+
+// ensureContinuousToDb resets the memory window if not continuous to the max epoch of database
+// any more (e.g., epochs re-synced into database due to pivot switch).
+func (syncer *NearHeadSyncer) ensureContinuousToDb(maxDbEpoch uint64) error {
+	epochRange, ok := syncer.nhs.EpochRange()
+	if !ok || epochRange.From != maxDbEpoch+1 {
+		return nil
+	}
+
+	pivotHash, _, err := syncer.db.PivotHash(maxDbEpoch)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get pivot hash from db")
+	}
+
+	block, err := syncer.nhs.GetBlockByEpoch(context.Background(), epochRange.From)
+	if err != nil || block.CfxBlock.ParentHash != types.Hash(pivotHash) {
+		syncer.nhs.Reset()
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package publish
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+)
+
+// SchemaVersion is the version of published event schema, which will be bumped on any
+// incompatible change.
+const SchemaVersion = 1
+
+// topic suffixes for different kinds of chain data
+const (
+	TopicBlocks       = "blocks"
+	TopicTransactions = "transactions"
+	TopicReceipts     = "receipts"
+	TopicLogs         = "logs"
+	TopicReverts      = "reverts"
+)
+
+// Message is a keyed message to publish, where the key is used for partitioning if supported.
+type Message struct {
+	Key   string
+	Value json.RawMessage
+}
+
+// Header is the common header of all published events.
+type Header struct {
+	Version int    `json:"version"`
+	Space   string `json:"space"` // "cfx" or "eth"
+	Epoch   uint64 `json:"epoch"` // epoch number for core space, or block number for evm space
+}
+
+// BlockEvent is published for each synced block.
+type BlockEvent struct {
+	Header
+	Block *types.BlockSummary `json:"block"`
+	Extra *store.BlockExtra   `json:"extra,omitempty"`
+}
+
+// TransactionEvent is published for each synced transaction executed in the block.
+type TransactionEvent struct {
+	Header
+	Transaction *types.Transaction      `json:"transaction"`
+	Extra       *store.TransactionExtra `json:"extra,omitempty"`
+}
+
+// ReceiptEvent is published for each synced transaction receipt.
+type ReceiptEvent struct {
+	Header
+	Receipt *types.TransactionReceipt `json:"receipt"`
+}
+
+// LogEvent is published for each synced event log.
+type LogEvent struct {
+	Header
+	Log   *types.Log      `json:"log"`
+	Extra *store.LogExtra `json:"extra,omitempty"`
+}
+
+// RevertEvent is published once epochs since `Epoch` (inclusive) reverted due to chain reorg,
+// and all the previously published chain data of these epochs should be discarded.
+type RevertEvent struct {
+	Header
+}
+
+// topicMessages is a group of messages to publish to the same topic.
+type topicMessages struct {
+	topic    string
+	messages []Message
+}
+
+// buildEpochMessages builds messages of the synced epoch data grouped by topics in publishing order.
+func buildEpochMessages(space string, data *store.EpochData) []topicMessages {
+	header := Header{Version: SchemaVersion, Space: space, Epoch: data.Number}
+
+	var blocks, txs, receipts, logs []Message
+	for i, block := range data.Blocks {
+		var blockExt *store.BlockExtra
+		if i < len(data.BlockExts) {
+			blockExt = data.BlockExts[i]
+		}
+
+		blocks = append(blocks, Message{
+			Key: block.Hash.String(),
+			Value: util.MustMarshalJson(BlockEvent{
+				Header: header, Block: util.GetSummaryOfBlock(block), Extra: blockExt,
+			}),
+		})
+
+		for j := range block.Transactions {
+			tx := &block.Transactions[j]
+
+			// only transactions executed in this block are synced
+			receipt, ok := data.Receipts[tx.Hash]
+			if !ok || receipt.BlockHash != block.Hash {
+				continue
+			}
+
+			var txExt *store.TransactionExtra
+			if blockExt != nil && j < len(blockExt.TxnExts) {
+				txExt = blockExt.TxnExts[j]
+			}
+
+			txs = append(txs, Message{
+				Key:   tx.Hash.String(),
+				Value: util.MustMarshalJson(TransactionEvent{Header: header, Transaction: tx, Extra: txExt}),
+			})
+
+			receipts = append(receipts, Message{
+				Key:   tx.Hash.String(),
+				Value: util.MustMarshalJson(ReceiptEvent{Header: header, Receipt: receipt}),
+			})
+
+			var logExts []*store.LogExtra
+			if rcptExt, ok := data.ReceiptExts[tx.Hash]; ok && rcptExt != nil {
+				logExts = rcptExt.LogExts
+			}
+
+			for k := range receipt.Logs {
+				var logExt *store.LogExtra
+				if k < len(logExts) {
+					logExt = logExts[k]
+				}
+
+				logs = append(logs, Message{
+					Key:   tx.Hash.String() + "-" + strconv.Itoa(k),
+					Value: util.MustMarshalJson(LogEvent{Header: header, Log: &receipt.Logs[k], Extra: logExt}),
+				})
+			}
+		}
+	}
+
+	return []topicMessages{
+		{topic: TopicBlocks, messages: blocks},
+		{topic: TopicTransactions, messages: txs},
+		{topic: TopicReceipts, messages: receipts},
+		{topic: TopicLogs, messages: logs},
+	}
+}
+
+// buildRevertMessages builds messages of the reverted epochs.
+func buildRevertMessages(space string, epochFrom uint64) []topicMessages {
+	header := Header{Version: SchemaVersion, Space: space, Epoch: epochFrom}
+
+	return []topicMessages{{
+		topic: TopicReverts,
+		messages: []Message{{
+			Key:   strconv.FormatUint(epochFrom, 10),
+			Value: util.MustMarshalJson(RevertEvent{Header: header}),
+		}},
+	}}
+}
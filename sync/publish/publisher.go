@@ -0,0 +1,201 @@
+package publish
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	BrokerKafka = "kafka"
+	BrokerNats  = "nats"
+)
+
+// Config represents the configuration to publish synced chain data to message broker.
+type Config struct {
+	Enabled bool
+	// message broker, either "kafka" (via REST proxy) or "nats"
+	Broker string `default:"nats"`
+	// broker url, e.g., nats://127.0.0.1:4222 (or tls://token@127.0.0.1:4222) for NATS, or
+	// http://127.0.0.1:8082 for Kafka REST proxy
+	Url string
+	// prefix of topics (or subjects), with full topic as "<prefix>.<space>.<kind>"
+	TopicPrefix string `default:"confura"`
+	// capacity of queue to buffer events before publishing
+	QueueSize int `default:"1000"`
+	// timeout to publish messages once
+	Timeout time.Duration `default:"5s"`
+	// interval to retry on publish failure
+	RetryInterval time.Duration `default:"1s"`
+	// timeout to flush the queued events on shutdown
+	FlushTimeout time.Duration `default:"10s"`
+}
+
+// Broker publishes messages to some topic of message broker.
+type Broker interface {
+	Publish(ctx context.Context, topic string, messages []Message) error
+	Close() error
+}
+
+// Publisher emits newly synced chain data and reorg revert events to message broker in the
+// order of sync, so that downstream indexers could consume the firehose instead of polling RPC.
+//
+// Note, events are buffered in memory after epochs persisted, and retried until acknowledged by
+// broker, so that events are delivered at least once as long as the process keeps running, and
+// downstream should dedupe by key. However, events queued but not published yet will be lost if
+// process crashed, so downstream should re-sync the missing epochs from RPC on any epoch gap.
+type Publisher struct {
+	conf   *Config
+	space  string
+	broker Broker
+	queue  chan []topicMessages
+	// closed once publisher stopped, so that sync won't be blocked on shutdown
+	closed chan struct{}
+}
+
+// MustNewPublisherFromViper creates an instance of Publisher for the specified space, or nil
+// if disabled.
+func MustNewPublisherFromViper(space string) *Publisher {
+	var conf Config
+	viperutil.MustUnmarshalKey("sync.publish", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	var broker Broker
+	var err error
+
+	switch conf.Broker {
+	case BrokerKafka:
+		broker, err = newKafkaBroker(conf.Url, conf.Timeout)
+	case BrokerNats:
+		broker, err = newNatsBroker(conf.Url, conf.Timeout)
+	default:
+		err = errors.Errorf("unsupported broker %v", conf.Broker)
+	}
+
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create chain data publisher")
+	}
+
+	return NewPublisher(&conf, space, broker)
+}
+
+func NewPublisher(conf *Config, space string, broker Broker) *Publisher {
+	return &Publisher{
+		conf:   conf,
+		space:  space,
+		broker: broker,
+		queue:  make(chan []topicMessages, conf.QueueSize),
+		closed: make(chan struct{}),
+	}
+}
+
+// implements `store.EpochDataObserver` interface
+
+func (p *Publisher) OnEpochsPushed(dataSlice []*store.EpochData) {
+	for _, data := range dataSlice {
+		p.enqueue(buildEpochMessages(p.space, data))
+	}
+}
+
+func (p *Publisher) OnEpochsPopped(epochFrom uint64) {
+	p.enqueue(buildRevertMessages(p.space, epochFrom))
+}
+
+// enqueue blocks until the messages queued, which slows down sync if the broker is lagging behind.
+func (p *Publisher) enqueue(groups []topicMessages) {
+	select {
+	case p.queue <- groups:
+	case <-p.closed:
+	}
+}
+
+// Run starts to publish the queued events until context canceled.
+func (p *Publisher) Run(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+
+	defer p.broker.Close()
+	defer close(p.closed)
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.flush()
+			logrus.Info("Chain data publisher shutdown ok")
+			return
+		case groups := <-p.queue:
+			if !p.publishWithRetry(ctx, groups) {
+				return
+			}
+		}
+	}
+}
+
+// flush publishes the queued events on shutdown until timeout, so that events of the persisted
+// epochs won't be lost.
+func (p *Publisher) flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.conf.FlushTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case groups := <-p.queue:
+			if !p.publishWithRetry(ctx, groups) {
+				logrus.WithField("numQueued", len(p.queue)+1).
+					Warn("Chain data publisher failed to flush queued events on shutdown")
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// publishWithRetry publishes the grouped messages until succeeded or context canceled.
+func (p *Publisher) publishWithRetry(ctx context.Context, groups []topicMessages) bool {
+	for _, g := range groups {
+		if len(g.messages) == 0 {
+			continue
+		}
+
+		topic := p.topic(g.topic)
+		for {
+			err := p.publish(ctx, topic, g.messages)
+			if err == nil {
+				break
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"topic":       topic,
+				"numMessages": len(g.messages),
+			}).WithError(err).Warn("Chain data publisher failed to publish messages")
+
+			select {
+			case <-ctx.Done():
+				return false
+			case <-time.After(p.conf.RetryInterval):
+			}
+		}
+	}
+
+	return true
+}
+
+func (p *Publisher) publish(ctx context.Context, topic string, messages []Message) error {
+	ctx, cancel := context.WithTimeout(ctx, p.conf.Timeout)
+	defer cancel()
+
+	return p.broker.Publish(ctx, topic, messages)
+}
+
+func (p *Publisher) topic(kind string) string {
+	return p.conf.TopicPrefix + "." + p.space + "." + kind
+}
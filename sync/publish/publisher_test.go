@@ -0,0 +1,241 @@
+package publish
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryBroker struct {
+	mu     sync.Mutex
+	topics []string
+	done   chan struct{}
+}
+
+func (b *memoryBroker) Publish(ctx context.Context, topic string, messages []Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for range messages {
+		b.topics = append(b.topics, topic)
+	}
+
+	if topic == "test.cfx."+TopicReverts {
+		close(b.done)
+	}
+
+	return nil
+}
+
+func (b *memoryBroker) Close() error { return nil }
+
+func newTestEpochData() *store.EpochData {
+	blockHash := types.Hash("0x0000000000000000000000000000000000000000000000000000000000000001")
+	txHash := types.Hash("0x0000000000000000000000000000000000000000000000000000000000000002")
+
+	return &store.EpochData{
+		Number: 10,
+		Blocks: []*types.Block{{
+			BlockHeader:  types.BlockHeader{Hash: blockHash},
+			Transactions: []types.Transaction{{Hash: txHash}, {Hash: "0x03"}},
+		}},
+		Receipts: map[types.Hash]*types.TransactionReceipt{
+			txHash: {TransactionHash: txHash, BlockHash: blockHash, Logs: []types.Log{{}, {}}},
+		},
+	}
+}
+
+func TestPublisherInOrder(t *testing.T) {
+	broker := &memoryBroker{done: make(chan struct{})}
+	p := NewPublisher(&Config{TopicPrefix: "test", QueueSize: 10, Timeout: time.Second}, "cfx", broker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	go p.Run(ctx, &wg)
+
+	p.OnEpochsPushed([]*store.EpochData{newTestEpochData()})
+	p.OnEpochsPopped(10)
+
+	<-broker.done
+	cancel()
+	wg.Wait()
+
+	assert.Equal(t, []string{
+		"test.cfx.blocks",
+		"test.cfx.transactions", // unexecuted transaction skipped
+		"test.cfx.receipts",
+		"test.cfx.logs", "test.cfx.logs",
+		"test.cfx.reverts",
+	}, broker.topics)
+}
+
+func TestPublisherFlushOnShutdown(t *testing.T) {
+	broker := &memoryBroker{done: make(chan struct{})}
+	p := NewPublisher(&Config{TopicPrefix: "test", QueueSize: 10, Timeout: time.Second, FlushTimeout: time.Second}, "cfx", broker)
+
+	p.OnEpochsPushed([]*store.EpochData{newTestEpochData()})
+	p.OnEpochsPopped(10)
+
+	// queued events are published even if already shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var wg sync.WaitGroup
+	p.Run(ctx, &wg)
+
+	assert.Equal(t, 6, len(broker.topics))
+	assert.Equal(t, "test.cfx.reverts", broker.topics[5])
+}
+
+// startTestNatsServer starts a fake NATS server, which sends the specified server info on handshake
+// and then handles client requests line by line.
+func startTestNatsServer(t *testing.T, info string, handle func(line string, conn net.Conn)) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("loopback listener unavailable")
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				conn.Write([]byte("INFO " + info + "\r\n"))
+
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+
+					handle(strings.TrimSpace(line), conn)
+				}
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestNatsBrokerPublish(t *testing.T) {
+	var mu sync.Mutex
+	var lines []string
+
+	addr := startTestNatsServer(t, "{}", func(line string, conn net.Conn) {
+		mu.Lock()
+		lines = append(lines, line)
+		mu.Unlock()
+
+		if line == "PING" {
+			conn.Write([]byte("PONG\r\n"))
+		}
+	})
+
+	b, err := newNatsBroker("nats://"+addr, time.Second)
+	assert.NoError(t, err)
+	defer b.Close()
+
+	value, _ := json.Marshal(RevertEvent{Header{Version: SchemaVersion, Space: "cfx", Epoch: 10}})
+	assert.NoError(t, b.Publish(context.Background(), "test.cfx.reverts", []Message{{Key: "10", Value: value}}))
+
+	// published once acknowledged by PONG
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Contains(t, lines, fmt.Sprintf("PUB test.cfx.reverts %d", len(value)))
+	assert.Contains(t, lines, string(value))
+	assert.Equal(t, "PING", lines[len(lines)-1])
+}
+
+func TestNatsBrokerAuthToken(t *testing.T) {
+	connected := make(chan string, 1)
+	addr := startTestNatsServer(t, `{"auth_required":true}`, func(line string, conn net.Conn) {
+		if strings.HasPrefix(line, "CONNECT ") {
+			var option struct {
+				AuthToken string `json:"auth_token"`
+			}
+			json.Unmarshal([]byte(strings.TrimPrefix(line, "CONNECT ")), &option)
+			connected <- option.AuthToken
+		}
+
+		if line == "PING" {
+			conn.Write([]byte("PONG\r\n"))
+		}
+	})
+
+	b, err := newNatsBroker("nats://secret@"+addr, time.Second)
+	assert.NoError(t, err)
+	defer b.Close()
+
+	assert.NoError(t, b.Publish(context.Background(), "test", []Message{{Value: []byte("{}")}}))
+	assert.Equal(t, "secret", <-connected)
+}
+
+func TestNatsBrokerPublishError(t *testing.T) {
+	addr := startTestNatsServer(t, "{}", func(line string, conn net.Conn) {
+		switch {
+		case strings.HasPrefix(line, "PUB "):
+			conn.Write([]byte("-ERR 'Unknown Protocol Operation'\r\n"))
+		case line == "PING":
+			conn.Write([]byte("PONG\r\n"))
+		}
+	})
+
+	b, err := newNatsBroker("nats://"+addr, time.Second)
+	assert.NoError(t, err)
+	defer b.Close()
+
+	// not acknowledged by server
+	assert.Error(t, b.Publish(context.Background(), "test", []Message{{Value: []byte("{}")}}))
+}
+
+func TestNatsBrokerReconnecting(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("loopback listener unavailable")
+	}
+
+	// server unavailable
+	addr := l.Addr().String()
+	l.Close()
+
+	b, err := newNatsBroker("nats://"+addr, 100*time.Millisecond)
+	assert.NoError(t, err)
+	defer b.Close()
+
+	// not acknowledged until reconnected
+	assert.Error(t, b.Publish(context.Background(), "test", []Message{{Value: []byte("{}")}}))
+}
+
+func TestKafkaBrokerPartialFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":1,"error_code":null,"error":null},` +
+			`{"partition":null,"offset":null,"error_code":50002,"error":"Kafka error"}]}`))
+	}))
+	defer srv.Close()
+
+	b, err := newKafkaBroker(srv.URL, time.Second)
+	assert.NoError(t, err)
+
+	err = b.Publish(context.Background(), "test", []Message{{Value: []byte("{}")}, {Value: []byte("{}")}})
+	assert.ErrorContains(t, err, "Kafka error")
+}
@@ -34,7 +34,10 @@ type syncConfig struct {
 	FromEpoch uint64 `default:"0"`
 	MaxEpochs uint64 `default:"10"`
 	UseBatch  bool   `default:"false"`
-	Sub       syncSubConfig
+	// number of epochs behind the latest confirmed epoch to persist, so that the store only
+	// contains epochs unlikely to be reverted
+	Confirmations uint64
+	Sub           syncSubConfig
 }
 
 type syncSubConfig struct {
@@ -65,6 +68,8 @@ type DatabaseSyncer struct {
 	elm election.LeaderManager
 	// sync monitor
 	monitor *monitor.Monitor
+	// dead letter queue for epochs failed to persist repeatedly
+	dlq *deadLetterQueue
 }
 
 // MustNewDatabaseSyncer creates an instance of DatabaseSyncer to sync blockchain data.
@@ -103,6 +108,7 @@ func MustNewDatabaseSyncer(cfxClients []*sdk.Client, db *mysql.MysqlStore) *Data
 		monitor:             monitor,
 		epochPivotWin:       newEpochPivotWindow(syncPivotInfoWinCapacity),
 		elm:                 election.MustNewLeaderManagerFromViper(dlm, "sync.cfx"),
+		dlq:                 mustNewDeadLetterQueueFromViper("cfx", db),
 	}
 	monitor.SetObserver(syncer)
 
@@ -117,6 +123,11 @@ func MustNewDatabaseSyncer(cfxClients []*sdk.Client, db *mysql.MysqlStore) *Data
 		syncer.onLeadershipChanged(ctx, lm, false)
 	})
 
+	// Align sync checkpoint with store, from which sync resumes without duplicates or gaps
+	if err := db.InitSyncCheckpoint(); err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize sync checkpoint")
+	}
+
 	// Ensure epoch data validity in database
 	if err := ensureStoreEpochDataOk(cfxClients[0], db); err != nil {
 		logrus.WithError(err).Fatal("Db sync failed to ensure epoch data validity in db")
@@ -214,8 +225,8 @@ func (syncer *DatabaseSyncer) syncOnce(ctx context.Context) (bool, error) {
 		return false, errors.WithMessage(err, "failed to load last sync epoch")
 	}
 
-	maxEpochTo := epoch.ToInt().Uint64()
-	if syncer.epochFrom > maxEpochTo { // cached up to the latest confirmed epoch?
+	maxEpochTo, ok := behindHead(epoch.ToInt().Uint64(), syncer.conf.Confirmations)
+	if !ok || syncer.epochFrom > maxEpochTo { // cached up to the latest confirmed epoch?
 		logrus.WithField("epochRange", citypes.RangeUint64{
 			From: syncer.epochFrom,
 			To:   maxEpochTo,
@@ -265,7 +276,9 @@ func (syncer *DatabaseSyncer) syncOnce(ctx context.Context) (bool, error) {
 					"latestPivotHash":  latestPivotHash,
 				}).Warn("Db syncer popping latest epoch from db store due to parent hash mismatched")
 
-				if err := syncer.pivotSwitchRevert(ctx, latestStoreEpochNo); err != nil {
+				if err := syncer.pivotSwitchRevert(
+					ctx, latestStoreEpochNo, string(latestPivotHash), string(data.GetPivotBlock().ParentHash),
+				); err != nil {
 					eplogger.WithError(err).Error(
 						"Db syncer failed to pop latest epoch from db store due to parent hash mismatched",
 					)
@@ -302,9 +315,8 @@ func (syncer *DatabaseSyncer) syncOnce(ctx context.Context) (bool, error) {
 		return false, nil
 	}
 
-	err = syncer.db.PushnWithFinalizer(epochDataSlice, func(d *gorm.DB) error {
-		return syncer.elm.Extend(ctx)
-	})
+	numPushed, err := syncer.dlq.push(epochDataSlice, syncer.pusher(ctx))
+	epochDataSlice = epochDataSlice[:numPushed]
 
 	if err != nil {
 		if errors.Is(err, store.ErrLeaderRenewal) {
@@ -341,6 +353,23 @@ func (syncer *DatabaseSyncer) syncOnce(ctx context.Context) (bool, error) {
 	return false, nil
 }
 
+// pusher persists epoch data into db, which extends leadership within the database transaction.
+func (syncer *DatabaseSyncer) pusher(ctx context.Context) epochDataPusher {
+	return func(dataSlice []*store.EpochData, finalizer func(*gorm.DB) error) error {
+		return syncer.db.PushnWithFinalizer(dataSlice, func(dbTx *gorm.DB) error {
+			if err := syncer.elm.Extend(ctx); err != nil {
+				return err
+			}
+
+			if finalizer != nil {
+				return finalizer(dbTx)
+			}
+
+			return nil
+		})
+	}
+}
+
 func (syncer *DatabaseSyncer) doTicker(ctx context.Context, ticker *time.Timer) error {
 	logrus.Debug("DB sync ticking")
 
@@ -370,7 +399,21 @@ func (syncer *DatabaseSyncer) nextEpochTo(maxEpochTo uint64) (uint64, uint64) {
 	return epochTo, syncSize
 }
 
-func (syncer *DatabaseSyncer) pivotSwitchRevert(ctx context.Context, revertTo uint64) error {
+// behindHead returns the head number behind the specified number of confirmations, or false if
+// the chain is not long enough.
+func behindHead(head, confirmations uint64) (uint64, bool) {
+	if head < confirmations {
+		return 0, false
+	}
+
+	return head - confirmations, true
+}
+
+// pivotSwitchRevert reverts the epoch data since the specified epoch, and records the reorg event
+// along with the pivot hash of `revertTo` before and after pivot switch.
+func (syncer *DatabaseSyncer) pivotSwitchRevert(
+	ctx context.Context, revertTo uint64, oldPivotHash, newPivotHash string,
+) error {
 	if revertTo == 0 {
 		return errors.New("genesis epoch must not be reverted")
 	}
@@ -389,8 +432,19 @@ func (syncer *DatabaseSyncer) pivotSwitchRevert(ctx context.Context, revertTo ui
 
 	logger.Info("Db syncer reverting epoch data due to pivot chain switch")
 
+	reorg := mysql.ReorgEvent{
+		EpochFrom:    revertTo,
+		EpochTo:      syncer.latestStoreEpoch(),
+		OldPivotHash: oldPivotHash,
+		NewPivotHash: newPivotHash,
+	}
+
 	// remove epoch data from database due to pivot switch
 	err := syncer.db.PopnWithFinalizer(revertTo, func(tx *gorm.DB) error {
+		if err := syncer.db.AddReorgEventWithTx(tx, &reorg); err != nil {
+			return errors.WithMessage(err, "failed to add reorg event")
+		}
+
 		return syncer.elm.Extend(ctx)
 	})
 
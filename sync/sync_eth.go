@@ -13,6 +13,7 @@ import (
 	"github.com/Conflux-Chain/confura/sync/monitor"
 	"github.com/Conflux-Chain/confura/util"
 	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	cfxtypes "github.com/Conflux-Chain/go-conflux-sdk/types"
 	"github.com/Conflux-Chain/go-conflux-util/dlock"
 	logutil "github.com/Conflux-Chain/go-conflux-util/log"
@@ -27,6 +28,11 @@ import (
 type syncEthConfig struct {
 	FromBlock uint64 `default:"1"`
 	MaxBlocks uint64 `default:"10"`
+	// number of blocks behind the latest safe block to persist
+	Confirmations uint64
+	// archive node with trace enabled to retrieve traces for internal transactions indexing,
+	// defaults to the sync nodes
+	TraceNode string
 }
 
 // EthSyncer is used to synchronize evm space blockchain data into db store.
@@ -36,6 +42,8 @@ type EthSyncer struct {
 	w3cs []*web3go.Client
 	// Selected web3go client index
 	w3cIdx atomic.Uint32
+	// web3go client to retrieve traces, nil to use the selected sync client
+	traceW3c *web3go.Client
 	// EVM space chain id
 	chainId uint32
 	// db store
@@ -54,6 +62,8 @@ type EthSyncer struct {
 	elm election.LeaderManager
 	// sync monitor
 	monitor *monitor.Monitor
+	// dead letter queue for blocks failed to persist repeatedly
+	dlq *deadLetterQueue
 }
 
 // MustNewEthSyncer creates an instance of EthSyncer to sync Conflux EVM space chaindata.
@@ -89,6 +99,7 @@ func MustNewEthSyncer(ethClients []*web3go.Client, db *mysql.MysqlStore) *EthSyn
 	syncer := &EthSyncer{
 		conf:                &ethConf,
 		w3cs:                ethClients,
+		traceW3c:            mustNewEthTraceClient(&ethConf),
 		chainId:             uint32(*ethChainId),
 		db:                  db,
 		maxSyncBlocks:       ethConf.MaxBlocks,
@@ -97,6 +108,7 @@ func MustNewEthSyncer(ethClients []*web3go.Client, db *mysql.MysqlStore) *EthSyn
 		monitor:             monitor,
 		epochPivotWin:       newEpochPivotWindow(syncPivotInfoWinCapacity),
 		elm:                 election.MustNewLeaderManagerFromViper(dlm, "sync.eth"),
+		dlq:                 mustNewDeadLetterQueueFromViper("eth", db),
 	}
 	monitor.SetObserver(syncer)
 
@@ -111,6 +123,11 @@ func MustNewEthSyncer(ethClients []*web3go.Client, db *mysql.MysqlStore) *EthSyn
 		syncer.onLeadershipChanged(ctx, lm, false)
 	})
 
+	// Align sync checkpoint with store, from which sync resumes without duplicates or gaps
+	if err := db.InitSyncCheckpoint(); err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize sync checkpoint")
+	}
+
 	// Load last sync block information
 	syncer.mustLoadLastSyncBlock()
 
@@ -180,7 +197,10 @@ func (syncer *EthSyncer) syncOnce(ctx context.Context) (bool, error) {
 		return false, errors.WithMessage(err, "failed to query the latest block number")
 	}
 
-	recentBlockNo := latestBlock.Number.Uint64()
+	recentBlockNo, ok := behindHead(latestBlock.Number.Uint64(), syncer.conf.Confirmations)
+	if !ok {
+		return true, nil
+	}
 
 	// Load latest sync block from database
 	if err := syncer.loadLastSyncBlock(); err != nil {
@@ -226,6 +246,17 @@ func (syncer *EthSyncer) syncOnce(ctx context.Context) (bool, error) {
 			return false, errors.WithMessagef(err, "failed to query eth data for block %v", blockNo)
 		}
 
+		if syncer.db.IsInternalTxIndexed() {
+			traceW3c := w3c
+			if syncer.traceW3c != nil {
+				traceW3c = syncer.traceW3c
+			}
+
+			if data.Traces, err = store.QueryEthTraces(ctx, traceW3c, data.Block.Hash); err != nil {
+				return false, errors.WithMessagef(err, "failed to query eth traces for block %v", blockNo)
+			}
+		}
+
 		if i == 0 { // the first block must be continuous to the latest block in db store
 			latestBlockHash, err := syncer.getStoreLatestBlockHash()
 			if err != nil {
@@ -236,9 +267,10 @@ func (syncer *EthSyncer) syncOnce(ctx context.Context) (bool, error) {
 			}
 
 			if len(latestBlockHash) > 0 && data.Block.ParentHash.Hex() != latestBlockHash {
-				if err := syncer.reorgRevert(ctx, syncer.latestStoreBlock()); err != nil {
-					parentBlockHash := data.Block.ParentHash.Hex()
-
+				parentBlockHash := data.Block.ParentHash.Hex()
+				if err := syncer.reorgRevert(
+					ctx, syncer.latestStoreBlock(), latestBlockHash, parentBlockHash,
+				); err != nil {
 					blogger.WithFields(logrus.Fields{
 						"parentBlockHash": parentBlockHash,
 						"latestBlockHash": latestBlockHash,
@@ -288,9 +320,8 @@ func (syncer *EthSyncer) syncOnce(ctx context.Context) (bool, error) {
 		epochDataSlice = append(epochDataSlice, epochData)
 	}
 
-	err = syncer.db.PushnWithFinalizer(epochDataSlice, func(d *gorm.DB) error {
-		return syncer.elm.Extend(ctx)
-	})
+	numPushed, err := syncer.dlq.push(epochDataSlice, syncer.pusher(ctx))
+	ethDataSlice = ethDataSlice[:numPushed]
 
 	if err != nil {
 		if errors.Is(err, store.ErrLeaderRenewal) {
@@ -328,7 +359,26 @@ func (syncer *EthSyncer) syncOnce(ctx context.Context) (bool, error) {
 	return false, nil
 }
 
-func (syncer *EthSyncer) reorgRevert(ctx context.Context, revertTo uint64) error {
+// pusher persists block data into db, which extends leadership within the database transaction.
+func (syncer *EthSyncer) pusher(ctx context.Context) epochDataPusher {
+	return func(dataSlice []*store.EpochData, finalizer func(*gorm.DB) error) error {
+		return syncer.db.PushnWithFinalizer(dataSlice, func(dbTx *gorm.DB) error {
+			if err := syncer.elm.Extend(ctx); err != nil {
+				return err
+			}
+
+			if finalizer != nil {
+				return finalizer(dbTx)
+			}
+
+			return nil
+		})
+	}
+}
+
+// reorgRevert reverts the block data since the specified block, and records the reorg event along
+// with the block hash of `revertTo` before and after reorg.
+func (syncer *EthSyncer) reorgRevert(ctx context.Context, revertTo uint64, oldHash, newHash string) error {
 	if revertTo == 0 {
 		return errors.New("genesis block must not be reverted")
 	}
@@ -344,8 +394,19 @@ func (syncer *EthSyncer) reorgRevert(ctx context.Context, revertTo uint64) error
 		return nil
 	}
 
+	reorg := mysql.ReorgEvent{
+		EpochFrom:    revertTo,
+		EpochTo:      syncer.latestStoreBlock(),
+		OldPivotHash: oldHash,
+		NewPivotHash: newHash,
+	}
+
 	// remove block data from database due to chain re-org
 	err := syncer.db.PopnWithFinalizer(revertTo, func(d *gorm.DB) error {
+		if err := syncer.db.AddReorgEventWithTx(d, &reorg); err != nil {
+			return errors.WithMessage(err, "failed to add reorg event")
+		}
+
 		return syncer.elm.Extend(ctx)
 	})
 
@@ -417,20 +478,26 @@ func (syncer *EthSyncer) getStoreLatestBlockHash() (string, error) {
 // convertToEpochData converts evm space block data to core space epoch data. This is used to bridge
 // eth block data with epoch data to reuse code logic eg., db store logic.
 func (syncer *EthSyncer) convertToEpochData(ethData *store.EthData) *store.EpochData {
+	return convertEthToEpochData(ethData, syncer.chainId)
+}
+
+// convertEthToEpochData converts evm space block data to core space epoch data of the specified chain.
+func convertEthToEpochData(ethData *store.EthData, chainId uint32) *store.EpochData {
 	epochData := &store.EpochData{
 		Number:      ethData.Number,
 		Receipts:    make(map[cfxtypes.Hash]*cfxtypes.TransactionReceipt),
 		ReceiptExts: make(map[cfxtypes.Hash]*store.ReceiptExtra),
 	}
 
-	pivotBlock := cfxbridge.ConvertBlock(ethData.Block, syncer.chainId)
+	pivotBlock := cfxbridge.ConvertBlock(ethData.Block, chainId)
 	epochData.Blocks = []*cfxtypes.Block{pivotBlock}
 
 	blockExt := store.ExtractEthBlockExt(ethData.Block)
+	blockExt.Traces = ethData.Traces
 	epochData.BlockExts = []*store.BlockExtra{blockExt}
 
 	for txh, rcpt := range ethData.Receipts {
-		txRcpt := cfxbridge.ConvertReceipt(rcpt, syncer.chainId)
+		txRcpt := cfxbridge.ConvertReceipt(rcpt, chainId)
 		txHash := cfxbridge.ConvertHash(txh)
 
 		epochData.Receipts[txHash] = txRcpt
@@ -454,6 +521,16 @@ func (syncer *EthSyncer) convertToEpochData(ethData *store.EthData) *store.Epoch
 	return epochData
 }
 
+// mustNewEthTraceClient creates the web3go client to retrieve traces for internal transactions
+// indexing, or nil if not configured.
+func mustNewEthTraceClient(conf *syncEthConfig) *web3go.Client {
+	if len(conf.TraceNode) == 0 {
+		return nil
+	}
+
+	return rpcutil.MustNewEthClient(conf.TraceNode)
+}
+
 func (syncer *EthSyncer) latestStoreBlock() uint64 {
 	if syncer.fromBlock > 0 {
 		return syncer.fromBlock - 1
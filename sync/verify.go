@@ -0,0 +1,298 @@
+package sync
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/openweb3/web3go"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// EpochMismatch is the inconsistency of an epoch between store and full node.
+type EpochMismatch struct {
+	Epoch    uint64
+	Field    string // e.g. pivotHash, blockHashes, txCount, receiptsRoot, logCount
+	Store    interface{}
+	Upstream interface{}
+}
+
+// EpochVerifier samples the epochs (or blocks for evm space) within store, and compares them with
+// the ones from full node, e.g. to make sure the event logs served from store are trustworthy.
+type EpochVerifier struct {
+	space    string
+	db       *mysql.MysqlStore
+	disabler store.ChainDataDisabler
+	// queries epoch data from full node
+	query epochDataQuerier
+}
+
+// MustNewCfxEpochVerifier creates core space epoch verifier.
+func MustNewCfxEpochVerifier(cfx *sdk.Client, db *mysql.MysqlStore) *EpochVerifier {
+	return &EpochVerifier{
+		space:    "cfx",
+		db:       db,
+		disabler: store.StoreConfig(),
+		query:    mustNewCfxEpochDataQuerier(cfx),
+	}
+}
+
+// MustNewEthEpochVerifier creates evm space epoch verifier.
+func MustNewEthEpochVerifier(w3c *web3go.Client, db *mysql.MysqlStore) *EpochVerifier {
+	return &EpochVerifier{
+		space:    "eth",
+		db:       db,
+		disabler: store.EthStoreConfig(),
+		query:    mustNewEthEpochDataQuerier(w3c, false),
+	}
+}
+
+// Verify verifies the randomly sampled epochs within the specified epoch range, and returns the
+// sampled epochs along with the mismatches found. All epochs within range will be verified if the
+// number of samples is zero or not less than the range size.
+func (v *EpochVerifier) Verify(
+	ctx context.Context, epochFrom, epochTo, samples uint64,
+) (sampled []uint64, mismatches []EpochMismatch, err error) {
+	if epochFrom > epochTo {
+		return nil, nil, errors.Errorf("invalid epoch range [%v, %v]", epochFrom, epochTo)
+	}
+
+	sampled = sampleEpochs(epochFrom, epochTo, samples)
+
+	for _, epochNo := range sampled {
+		select {
+		case <-ctx.Done():
+			return sampled, mismatches, ctx.Err()
+		default:
+		}
+
+		epochMismatches, err := v.verifyEpoch(ctx, epochNo)
+		if err != nil {
+			return sampled, mismatches, errors.WithMessagef(err, "failed to verify epoch %v", epochNo)
+		}
+
+		for _, m := range epochMismatches {
+			logrus.WithFields(logrus.Fields{
+				"space":    v.space,
+				"epoch":    m.Epoch,
+				"field":    m.Field,
+				"store":    m.Store,
+				"upstream": m.Upstream,
+			}).Warn("Epoch verifier found mismatch between store and full node")
+		}
+
+		mismatches = append(mismatches, epochMismatches...)
+	}
+
+	if len(mismatches) > 0 {
+		v.reportReorgs(epochFrom, epochTo)
+	}
+
+	return sampled, mismatches, nil
+}
+
+// reportReorgs reports the reorg events recorded within the epoch range, which helps to tell if
+// the mismatches are caused by reorgs.
+func (v *EpochVerifier) reportReorgs(epochFrom, epochTo uint64) {
+	events, err := v.db.ReorgEvents(epochFrom, epochTo, 0)
+	if err != nil {
+		logrus.WithError(err).Info("Epoch verifier failed to get reorg events")
+		return
+	}
+
+	for _, event := range events {
+		logrus.WithFields(logrus.Fields{
+			"space": v.space,
+			"reorg": event,
+		}).Warn("Epoch verifier found reorg event within range")
+	}
+}
+
+func (v *EpochVerifier) verifyEpoch(ctx context.Context, epochNo uint64) ([]EpochMismatch, error) {
+	data, err := v.query(epochNo)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to query epoch data")
+	}
+
+	pivotBlock := data.GetPivotBlock()
+	mismatch := func(field string, storeVal, upstreamVal interface{}) EpochMismatch {
+		return EpochMismatch{Epoch: epochNo, Field: field, Store: storeVal, Upstream: upstreamVal}
+	}
+
+	pivotHash, ok, err := v.db.PivotHash(epochNo)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get pivot hash")
+	}
+
+	if !ok {
+		return []EpochMismatch{mismatch("pivotHash", nil, pivotBlock.Hash.String())}, nil
+	}
+
+	// other fields are meaningless to compare once pivot switched
+	if !strings.EqualFold(pivotHash, pivotBlock.Hash.String()) {
+		return []EpochMismatch{mismatch("pivotHash", pivotHash, pivotBlock.Hash.String())}, nil
+	}
+
+	var mismatches []EpochMismatch
+
+	bnRange, _, err := v.db.BlockRange(epochNo)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get block range")
+	}
+
+	upstreamBnRange := [2]uint64{
+		data.Blocks[0].BlockNumber.ToInt().Uint64(), pivotBlock.BlockNumber.ToInt().Uint64(),
+	}
+	if storeBnRange := [2]uint64{bnRange.From, bnRange.To}; storeBnRange != upstreamBnRange {
+		mismatches = append(mismatches, mismatch("blockRange", storeBnRange, upstreamBnRange))
+	}
+
+	if !v.disabler.IsChainBlockDisabled() {
+		blockMismatches, err := v.verifyBlocks(ctx, data, mismatch)
+		if err != nil {
+			return nil, err
+		}
+
+		mismatches = append(mismatches, blockMismatches...)
+	}
+
+	numTxs, numLogs := countExecuted(data)
+
+	if !v.disabler.IsChainReceiptDisabled() {
+		receipts, err := v.db.GetEpochReceipts(ctx, epochNo)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to get epoch receipts")
+		}
+
+		if len(receipts) != numTxs {
+			mismatches = append(mismatches, mismatch("txCount", len(receipts), numTxs))
+		}
+	}
+
+	if !v.disabler.IsChainLogDisabled() {
+		// event logs within an epoch won't be too many, so bound checks are unnecessary
+		logCtx, cancel := context.WithTimeout(store.NewContextWithBoundChecksDisabled(ctx), store.TimeoutGetLogs)
+		defer cancel()
+
+		logs, err := v.db.GetLogs(logCtx, store.LogFilter{BlockFrom: bnRange.From, BlockTo: bnRange.To})
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to get event logs")
+		}
+
+		if len(logs) != numLogs {
+			mismatches = append(mismatches, mismatch("logCount", len(logs), numLogs))
+		}
+	}
+
+	return mismatches, nil
+}
+
+// verifyBlocks compares the block hashes of epoch and the receipts root of pivot block.
+func (v *EpochVerifier) verifyBlocks(
+	ctx context.Context,
+	data *store.EpochData,
+	mismatch func(field string, storeVal, upstreamVal interface{}) EpochMismatch,
+) ([]EpochMismatch, error) {
+	storeHashes, err := v.db.GetBlocksByEpoch(ctx, data.Number)
+	if err != nil && !v.db.IsRecordNotFound(err) {
+		return nil, errors.WithMessage(err, "failed to get epoch blocks")
+	}
+
+	upstreamHashes := make([]types.Hash, 0, len(data.Blocks))
+	for _, block := range data.Blocks {
+		upstreamHashes = append(upstreamHashes, block.Hash)
+	}
+
+	var mismatches []EpochMismatch
+	if !equalHashSet(storeHashes, upstreamHashes) {
+		mismatches = append(mismatches, mismatch("blockHashes", storeHashes, upstreamHashes))
+	}
+
+	pivotBlock := data.GetPivotBlock()
+
+	summary, err := v.db.GetBlockSummaryByEpoch(ctx, data.Number)
+	if v.db.IsRecordNotFound(err) {
+		return append(mismatches, mismatch("receiptsRoot", nil, pivotBlock.DeferredReceiptsRoot)), nil
+	}
+
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get pivot block summary")
+	}
+
+	if storeRoot := summary.CfxBlockSummary.DeferredReceiptsRoot; storeRoot != pivotBlock.DeferredReceiptsRoot {
+		mismatches = append(mismatches, mismatch("receiptsRoot", storeRoot, pivotBlock.DeferredReceiptsRoot))
+	}
+
+	return mismatches, nil
+}
+
+// countExecuted counts the executed transactions and event logs within the epoch.
+func countExecuted(data *store.EpochData) (numTxs, numLogs int) {
+	for _, block := range data.Blocks {
+		for i := range block.Transactions {
+			receipt := data.Receipts[block.Transactions[i].Hash]
+			if receipt == nil || !util.IsTxExecutedInBlock(&block.Transactions[i]) {
+				continue
+			}
+
+			numTxs++
+			numLogs += len(receipt.Logs)
+		}
+	}
+
+	return numTxs, numLogs
+}
+
+func equalHashSet(hashes1, hashes2 []types.Hash) bool {
+	if len(hashes1) != len(hashes2) {
+		return false
+	}
+
+	set := make(map[string]bool, len(hashes1))
+	for _, h := range hashes1 {
+		set[strings.ToLower(h.String())] = true
+	}
+
+	for _, h := range hashes2 {
+		if !set[strings.ToLower(h.String())] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sampleEpochs randomly samples the specified number of distinct epochs within range in ascending
+// order, or all epochs within range if the number of samples is zero or not less than range size.
+func sampleEpochs(epochFrom, epochTo, samples uint64) []uint64 {
+	size := epochTo - epochFrom + 1
+
+	if samples == 0 || samples >= size {
+		result := make([]uint64, 0, size)
+		for epochNo := epochFrom; epochNo <= epochTo; epochNo++ {
+			result = append(result, epochNo)
+		}
+
+		return result
+	}
+
+	picked := make(map[uint64]bool, samples)
+	for uint64(len(picked)) < samples {
+		picked[epochFrom+uint64(rand.Int63n(int64(size)))] = true
+	}
+
+	result := make([]uint64, 0, samples)
+	for epochNo := range picked {
+		result = append(result, epochNo)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
@@ -0,0 +1,27 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampleEpochs(t *testing.T) {
+	// all epochs within range
+	assert.Equal(t, []uint64{3, 4, 5}, sampleEpochs(3, 5, 0))
+	assert.Equal(t, []uint64{3, 4, 5}, sampleEpochs(3, 5, 3))
+	assert.Equal(t, []uint64{3, 4, 5}, sampleEpochs(3, 5, 10))
+
+	// randomly sampled
+	sampled := sampleEpochs(100, 1000, 10)
+	assert.Len(t, sampled, 10)
+
+	for i, epochNo := range sampled {
+		assert.GreaterOrEqual(t, epochNo, uint64(100))
+		assert.LessOrEqual(t, epochNo, uint64(1000))
+
+		if i > 0 {
+			assert.Greater(t, epochNo, sampled[i-1])
+		}
+	}
+}
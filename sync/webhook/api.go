@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/pkg/errors"
+)
+
+// RegisterResult is the result of webhook registration, of which the secret is used to verify
+// the payload signature.
+type RegisterResult struct {
+	ID     uint32 `json:"id"`
+	Secret string `json:"secret"`
+}
+
+// WebhookInfo is the registered webhook without secret.
+type WebhookInfo struct {
+	ID     uint32          `json:"id"`
+	Url    string          `json:"url"`
+	Filter json.RawMessage `json:"filter"`
+	Cursor uint64          `json:"cursor"`
+}
+
+// adminAPI provides JSON-RPC methods to manage webhooks.
+type adminAPI struct {
+	d *Dispatcher
+}
+
+// Register registers a webhook with log filter (addresses and topics only), which will be notified
+// of the event logs matched since the latest synced epoch.
+func (api *adminAPI) Register(ctx context.Context, rawUrl string, filter json.RawMessage) (*RegisterResult, error) {
+	if err := validateTargetUrl(ctx, rawUrl, api.d.conf.AllowPrivateTargets); err != nil {
+		return nil, err
+	}
+
+	normalized, err := api.d.parser.validate(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, ok, err := api.d.db.MaxEpoch()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get max epoch")
+	}
+
+	if !ok {
+		return nil, errors.New("no epoch synced yet")
+	}
+
+	cursorHash, ok, err := api.d.db.PivotHash(cursor)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get pivot hash")
+	}
+
+	if !ok {
+		return nil, errors.Errorf("epoch %v not found in store", cursor)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, errors.WithMessage(err, "failed to generate secret")
+	}
+
+	webhook := mysql.Webhook{
+		Url:        rawUrl,
+		Secret:     hex.EncodeToString(secret),
+		Filter:     normalized,
+		Cursor:     cursor,
+		CursorHash: cursorHash,
+	}
+
+	if err := api.d.db.AddWebhook(&webhook); err != nil {
+		return nil, errors.WithMessage(err, "failed to add webhook")
+	}
+
+	return &RegisterResult{ID: webhook.ID, Secret: webhook.Secret}, nil
+}
+
+// List returns all the registered webhooks.
+func (api *adminAPI) List(ctx context.Context) ([]WebhookInfo, error) {
+	webhooks, err := api.d.db.LoadWebhooks()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]WebhookInfo, 0, len(webhooks))
+	for _, wh := range webhooks {
+		result = append(result, WebhookInfo{
+			ID:     wh.ID,
+			Url:    wh.Url,
+			Filter: json.RawMessage(wh.Filter),
+			Cursor: wh.Cursor,
+		})
+	}
+
+	return result, nil
+}
+
+// Remove removes the webhook along with the pending deliveries.
+func (api *adminAPI) Remove(ctx context.Context, id uint32) (bool, error) {
+	return api.d.db.DeleteWebhook(id)
+}
@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"time"
+
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+)
+
+// Config represents the configuration to notify webhooks of matched event logs.
+type Config struct {
+	Enabled bool
+	// interval to match event logs and deliver payloads
+	Interval time.Duration `default:"1s"`
+	// max number of epochs to match for each webhook at a time
+	MaxEpochs uint64 `default:"100"`
+	// max number of pending deliveries to attempt for each webhook at a time
+	MaxDeliveries int `default:"10"`
+	// timeout to POST payload once
+	Timeout time.Duration `default:"5s"`
+	// max number of attempts before delivery marked as failed
+	MaxAttempts uint32 `default:"10"`
+	// backoff to retry on delivery failure, which doubles for each attempt
+	RetryBackoff time.Duration `default:"5s"`
+	// max backoff to retry on delivery failure
+	MaxRetryBackoff time.Duration `default:"1h"`
+	// max number of epochs to rewind webhook cursor on reorg
+	ReorgDepth uint64 `default:"100"`
+	// JSON-RPC endpoint to register and remove webhooks, disabled if empty
+	AdminEndpoint string
+	// bearer token to authenticate admin requests, required if admin endpoint configured
+	AuthToken string
+	// whether to allow webhooks of private, loopback or link-local addresses, e.g., for internal
+	// deployment only
+	AllowPrivateTargets bool
+}
+
+// mustNewConfigFromViper loads webhook config of the specified space, e.g., `sync.webhook`
+// for core space and `sync.eth.webhook` for evm space.
+func mustNewConfigFromViper(space string) *Config {
+	key := "sync.webhook"
+	if space == SpaceEth {
+		key = "sync.eth.webhook"
+	}
+
+	var conf Config
+	viperutil.MustUnmarshalKey(key, &conf)
+
+	return &conf
+}
+
+// backoff returns the delay to retry after the specified number of failed attempts.
+func (conf *Config) backoff(attempts uint32) time.Duration {
+	delay := conf.RetryBackoff
+	for i := uint32(1); i < attempts && delay < conf.MaxRetryBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, conf.MaxRetryBackoff)
+}
@@ -0,0 +1,482 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/rpc/ethbridge"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/openweb3/web3go"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	PayloadTypeLogs   = "logs"
+	PayloadTypeRevert = "revert"
+
+	HeaderSignature = "X-Confura-Signature"
+	HeaderDelivery  = "X-Confura-Delivery"
+	HeaderTimestamp = "X-Confura-Timestamp"
+)
+
+// Payload is the JSON body POSTed to webhook.
+type Payload struct {
+	Type      string `json:"type"`
+	WebhookID uint32 `json:"webhookId"`
+	Space     string `json:"space"`
+	// epoch range of matched event logs, or the first reverted epoch for revert payload, in
+	// which case all the event logs delivered since this epoch (inclusive) were reverted.
+	EpochFrom uint64      `json:"epochFrom"`
+	EpochTo   uint64      `json:"epochTo,omitempty"`
+	Logs      interface{} `json:"logs,omitempty"`
+}
+
+// Dispatcher matches the newly synced event logs against the registered webhooks, and POSTs the
+// matched logs along with reorg reverts to webhooks with retries.
+//
+// Note, payloads are persisted into database along with the webhook cursor atomically before
+// delivery, so that they are delivered at least once and in order for each webhook.
+type Dispatcher struct {
+	conf   *Config
+	space  string
+	db     *mysql.MysqlStore
+	parser filterParser
+	client *http.Client
+
+	// the lowest epoch reverted from store but not handled yet
+	mu       sync.Mutex
+	reverted uint64
+}
+
+// MustNewDispatcherFromViper creates an instance of Dispatcher, or nil if disabled. Evm space
+// clients are required for evm space only, which is core space if not provided.
+func MustNewDispatcherFromViper(db *mysql.MysqlStore, ethClients []*web3go.Client) *Dispatcher {
+	space := SpaceCfx
+	if ethClients != nil {
+		space = SpaceEth
+	}
+
+	conf := mustNewConfigFromViper(space)
+	if !conf.Enabled {
+		return nil
+	}
+
+	if len(conf.AdminEndpoint) > 0 && len(conf.AuthToken) == 0 {
+		logrus.Fatal("Auth token required for webhook admin endpoint")
+	}
+
+	var networkId uint32
+	if space == SpaceEth {
+		if len(ethClients) == 0 {
+			logrus.Fatal("No eth client provided for webhook dispatcher")
+		}
+
+		chainId, err := ethClients[0].Eth.ChainId()
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to get chain id for webhook dispatcher")
+		}
+
+		networkId = uint32(*chainId)
+	}
+
+	return NewDispatcher(conf, db, space, networkId)
+}
+
+func NewDispatcher(conf *Config, db *mysql.MysqlStore, space string, networkId uint32) *Dispatcher {
+	return &Dispatcher{
+		conf:     conf,
+		space:    space,
+		db:       db,
+		parser:   filterParser{space: space, networkId: networkId},
+		client:   newTargetClient(conf.Timeout, conf.AllowPrivateTargets),
+		reverted: math.MaxUint64,
+	}
+}
+
+// implements `store.EpochDataObserver` interface
+
+func (d *Dispatcher) OnEpochsPushed(dataSlice []*store.EpochData) {}
+
+func (d *Dispatcher) OnEpochsPopped(epochFrom uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.reverted = min(d.reverted, epochFrom)
+}
+
+func (d *Dispatcher) takeReverted() uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	reverted := d.reverted
+	d.reverted = math.MaxUint64
+
+	return reverted
+}
+
+// Run starts to match and deliver payloads periodically, along with the admin endpoint if configured.
+func (d *Dispatcher) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if len(d.conf.AdminEndpoint) > 0 {
+		server := rpcutil.MustNewAdminServer("webhook_admin", map[string]interface{}{
+			"webhook": &adminAPI{d: d},
+		}, rpcutil.MustNewBearerAuthMiddleware(d.conf.AuthToken))
+		go server.MustServeGraceful(ctx, wg, d.conf.AdminEndpoint, rpcutil.ProtocolHttp)
+	}
+
+	wg.Add(1)
+	defer wg.Done()
+
+	ticker := time.NewTicker(d.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.WithField("space", d.space).Info("Webhook dispatcher shutdown ok")
+			return
+		case <-ticker.C:
+			if err := d.doTicker(ctx); err != nil {
+				logrus.WithField("space", d.space).WithError(err).Error("Webhook dispatcher failed to dispatch")
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) doTicker(ctx context.Context) error {
+	webhooks, err := d.db.LoadWebhooks()
+	if err != nil {
+		return errors.WithMessage(err, "failed to load webhooks")
+	}
+
+	if err := d.matchAll(webhooks); err != nil {
+		return err
+	}
+
+	// a broken webhook never blocks deliveries of the others
+	for _, wh := range webhooks {
+		if err := d.deliver(ctx, wh); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"space":   d.space,
+				"webhook": wh.ID,
+			}).WithError(err).Warn("Webhook dispatcher failed to deliver webhook")
+		}
+	}
+
+	return nil
+}
+
+// matchAll rewinds and matches all webhooks, and skips the failed ones, which will be retried on
+// next round, so that a broken webhook (e.g., invalid filter) never blocks the others.
+func (d *Dispatcher) matchAll(webhooks []*mysql.Webhook) error {
+	maxEpoch, ok, err := d.db.MaxEpoch()
+	if err != nil || !ok {
+		return errors.WithMessage(err, "failed to get max epoch")
+	}
+
+	reverted := d.takeReverted()
+
+	var rewindFailed bool
+	for _, wh := range webhooks {
+		logger := logrus.WithFields(logrus.Fields{"space": d.space, "webhook": wh.ID})
+
+		if err := d.rewind(wh, reverted); err != nil {
+			// never match before rewound
+			logger.WithError(err).Warn("Webhook dispatcher failed to rewind webhook")
+			rewindFailed = true
+			continue
+		}
+
+		if err := d.match(wh, maxEpoch); err != nil {
+			logger.WithError(err).Warn("Webhook dispatcher failed to match webhook")
+		}
+	}
+
+	// handle the reverts again on next round, which is idempotent for the rewound webhooks
+	if rewindFailed && reverted != math.MaxUint64 {
+		d.OnEpochsPopped(reverted)
+	}
+
+	return nil
+}
+
+// rewind rewinds the webhook cursor upon reorg, and enqueues a revert payload.
+func (d *Dispatcher) rewind(wh *mysql.Webhook, reverted uint64) error {
+	revertFrom := reverted
+	if revertFrom > wh.Cursor {
+		// reverts may be missed during restart, so check the cursor hash as well
+		hash, ok, err := d.db.PivotHash(wh.Cursor)
+		if err != nil {
+			return errors.WithMessage(err, "failed to get pivot hash")
+		}
+
+		if ok && strings.EqualFold(hash, wh.CursorHash) {
+			return nil
+		}
+
+		if !ok { // cursor epoch popped, or pruned
+			minEpoch, ok, err := d.db.MinEpoch()
+			if err != nil {
+				return errors.WithMessage(err, "failed to get min epoch")
+			}
+
+			if !ok || wh.Cursor < minEpoch {
+				return errors.Errorf("cursor epoch %v unavailable in store", wh.Cursor)
+			}
+		}
+
+		revertFrom = wh.Cursor - min(d.conf.ReorgDepth, wh.Cursor) + 1
+	}
+
+	revertFrom = max(revertFrom, 1)
+
+	cursor := revertFrom - 1
+	cursorHash, ok, err := d.db.PivotHash(cursor)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to get pivot hash of epoch %v", cursor)
+	}
+
+	if !ok {
+		return errors.Errorf("epoch %v not found in store", cursor)
+	}
+
+	delivery, err := d.newDelivery(&Payload{
+		Type:      PayloadTypeRevert,
+		WebhookID: wh.ID,
+		Space:     d.space,
+		EpochFrom: revertFrom,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := d.db.AdvanceWebhook(wh, cursor, cursorHash, delivery); err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"webhook":    wh.ID,
+		"revertFrom": revertFrom,
+	}).Info("Webhook dispatcher rewound cursor due to reorg")
+
+	return nil
+}
+
+// match matches event logs since the webhook cursor, and enqueues the payload if any log matched.
+func (d *Dispatcher) match(wh *mysql.Webhook, maxEpoch uint64) error {
+	epochFrom := wh.Cursor + 1
+	if epochFrom > maxEpoch {
+		return nil
+	}
+
+	epochTo := min(maxEpoch, epochFrom+d.conf.MaxEpochs-1)
+
+	fromRange, ok, err := d.db.BlockRange(epochFrom)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to get block range of epoch %v", epochFrom)
+	}
+
+	if !ok {
+		return errors.Errorf("epoch %v not found in store", epochFrom)
+	}
+
+	toRange, ok, err := d.db.BlockRange(epochTo)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to get block range of epoch %v", epochTo)
+	}
+
+	if !ok {
+		return errors.Errorf("epoch %v not found in store", epochTo)
+	}
+
+	toHash, ok, err := d.db.PivotHash(epochTo)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to get pivot hash of epoch %v", epochTo)
+	}
+
+	if !ok {
+		return errors.Errorf("epoch %v not found in store", epochTo)
+	}
+
+	filter, err := d.parser.parse(wh.Filter, fromRange.From, toRange.To)
+	if err != nil {
+		return errors.WithMessage(err, "invalid webhook filter")
+	}
+
+	ctx, cancel := context.WithTimeout(store.NewContextWithBoundChecksDisabled(context.Background()), store.TimeoutGetLogs)
+	defer cancel()
+
+	slogs, err := d.db.GetLogs(ctx, filter)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get event logs")
+	}
+
+	// epochs popped and re-synced between queries, and will be rewound on next round
+	if hash, ok, err := d.db.PivotHash(epochTo); err != nil || !ok || !strings.EqualFold(hash, toHash) {
+		return err
+	}
+
+	var deliveries []*mysql.WebhookDelivery
+	if len(slogs) > 0 {
+		delivery, err := d.newDelivery(&Payload{
+			Type:      PayloadTypeLogs,
+			WebhookID: wh.ID,
+			Space:     d.space,
+			EpochFrom: epochFrom,
+			EpochTo:   epochTo,
+			Logs:      d.convertLogs(slogs),
+		})
+		if err != nil {
+			return err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	_, err = d.db.AdvanceWebhook(wh, epochTo, toHash, deliveries...)
+	return err
+}
+
+func (d *Dispatcher) convertLogs(slogs []*store.Log) interface{} {
+	if d.space == SpaceEth {
+		logs := make([]interface{}, 0, len(slogs))
+		for _, slog := range slogs {
+			logs = append(logs, ethbridge.ConvertLog(slog.ToCfxLog()))
+		}
+
+		return logs
+	}
+
+	logs := make([]interface{}, 0, len(slogs))
+	for _, slog := range slogs {
+		log, _ := slog.ToCfxLog()
+		logs = append(logs, log)
+	}
+
+	return logs
+}
+
+func (d *Dispatcher) newDelivery(payload *Payload) (*mysql.WebhookDelivery, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to marshal webhook payload")
+	}
+
+	return &mysql.WebhookDelivery{
+		WebhookID:     payload.WebhookID,
+		Payload:       string(data),
+		Status:        mysql.WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// deliver POSTs the pending payloads to webhook in order, and stops at the first one failed or
+// not due yet, so that payloads won't be delivered out of order.
+func (d *Dispatcher) deliver(ctx context.Context, wh *mysql.Webhook) error {
+	deliveries, err := d.db.PendingWebhookDeliveries(wh.ID, d.conf.MaxDeliveries)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil || delivery.NextAttemptAt.After(time.Now()) {
+			return nil
+		}
+
+		postErr := d.post(ctx, wh, delivery)
+
+		delivery.Attempts++
+		switch {
+		case postErr == nil:
+			delivery.Status = mysql.WebhookDeliveryDelivered
+			delivery.LastError = ""
+		case delivery.Attempts >= d.conf.MaxAttempts:
+			delivery.Status = mysql.WebhookDeliveryFailed
+			delivery.LastError = truncate(postErr.Error(), 256)
+		default:
+			delivery.NextAttemptAt = time.Now().Add(d.conf.backoff(delivery.Attempts))
+			delivery.LastError = truncate(postErr.Error(), 256)
+		}
+
+		if err := d.db.UpdateWebhookDelivery(delivery); err != nil {
+			return err
+		}
+
+		if postErr != nil {
+			logrus.WithFields(logrus.Fields{
+				"webhook":  wh.ID,
+				"delivery": delivery.ID,
+				"attempts": delivery.Attempts,
+			}).WithError(postErr).Debug("Webhook dispatcher failed to deliver payload")
+
+			if delivery.Status == mysql.WebhookDeliveryPending {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) post(ctx context.Context, wh *mysql.Webhook, delivery *mysql.WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(ctx, d.conf.Timeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.Url, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithMessage(err, "failed to create request")
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(wh.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 128))
+		return errors.Errorf("unexpected response (%v): %s", resp.Status, msg)
+	}
+
+	return nil
+}
+
+// Sign returns the signature of payload, which is the hex encoded HMAC-SHA256 of
+// `<timestamp>.<body>` with webhook secret as key, prefixed with `sha256=`.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return s[:n]
+}
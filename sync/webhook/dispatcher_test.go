@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t,
+		"sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163",
+		Sign("secret", "1700000000", []byte("{}")),
+	)
+}
+
+func TestBackoff(t *testing.T) {
+	conf := Config{RetryBackoff: time.Second, MaxRetryBackoff: 10 * time.Second}
+
+	assert.Equal(t, time.Second, conf.backoff(1))
+	assert.Equal(t, 2*time.Second, conf.backoff(2))
+	assert.Equal(t, 8*time.Second, conf.backoff(4))
+	assert.Equal(t, 10*time.Second, conf.backoff(5))
+	assert.Equal(t, 10*time.Second, conf.backoff(100))
+}
+
+func TestFilterParser(t *testing.T) {
+	p := filterParser{space: SpaceEth, networkId: 1030}
+
+	normalized, err := p.validate(json.RawMessage(`{
+		"address": "0x0000000000000000000000000000000000000001",
+		"topics": [null, ["0x0000000000000000000000000000000000000000000000000000000000000002"]],
+		"fromBlock": "0x1"
+	}`))
+	assert.NoError(t, err)
+
+	filter, err := p.parse(normalized, 10, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), filter.BlockFrom)
+	assert.Equal(t, uint64(20), filter.BlockTo)
+	assert.Equal(t, 1, filter.Contracts.Count())
+	assert.Equal(t, 2, len(filter.Topics))
+
+	_, err = p.validate(json.RawMessage(`{"address": "invalid"}`))
+	assert.Error(t, err)
+}
+
+func TestValidateTargetUrl(t *testing.T) {
+	ctx := context.Background()
+
+	for _, rawUrl := range []string{
+		"ftp://8.8.8.8/hook",
+		"http:///hook",
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://10.0.0.1/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"http://[fe80::1]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		assert.Error(t, validateTargetUrl(ctx, rawUrl, false), rawUrl)
+	}
+
+	assert.NoError(t, validateTargetUrl(ctx, "https://8.8.8.8/hook", false))
+
+	// private targets allowed
+	assert.NoError(t, validateTargetUrl(ctx, "http://127.0.0.1:8080/hook", true))
+}
+
+func TestTargetClientRejectsPrivateTarget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// rejected at dial time, e.g., resolved to loopback address after registration
+	_, err := newTargetClient(time.Second, false).Post(srv.URL, "application/json", nil)
+	assert.ErrorIs(t, err, errPrivateTarget)
+
+	resp, err := newTargetClient(time.Second, true).Post(srv.URL, "application/json", nil)
+	assert.NoError(t, err)
+	resp.Body.Close()
+}
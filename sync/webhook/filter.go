@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"encoding/json"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	SpaceCfx = "cfx"
+	SpaceEth = "eth"
+)
+
+// filterParser parses webhook filter in the RPC format of some space, e.g., `types.LogFilter` for
+// core space and `web3Types.FilterQuery` for evm space, of which only addresses and topics are used.
+type filterParser struct {
+	space     string
+	networkId uint32 // evm space only
+}
+
+// validate validates the raw filter, and returns the normalized one to persist.
+func (p *filterParser) validate(raw json.RawMessage) (string, error) {
+	var v interface{}
+
+	switch p.space {
+	case SpaceCfx:
+		var filter types.LogFilter
+		if err := json.Unmarshal(raw, &filter); err != nil {
+			return "", errors.WithMessage(err, "invalid log filter")
+		}
+		v = logFilterCfx{Address: filter.Address, Topics: filter.Topics}
+	default:
+		var filter web3Types.FilterQuery
+		if err := json.Unmarshal(raw, &filter); err != nil {
+			return "", errors.WithMessage(err, "invalid log filter")
+		}
+		v = logFilterEth{Addresses: filter.Addresses, Topics: filter.Topics}
+	}
+
+	normalized, err := json.Marshal(v)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed to marshal log filter")
+	}
+
+	return string(normalized), nil
+}
+
+// parse parses the persisted filter into store log filter for the specified block range.
+func (p *filterParser) parse(filter string, blockFrom, blockTo uint64) (store.LogFilter, error) {
+	switch p.space {
+	case SpaceCfx:
+		var f types.LogFilter
+		if err := json.Unmarshal([]byte(filter), &f); err != nil {
+			return store.LogFilter{}, err
+		}
+
+		return store.ParseCfxLogFilter(blockFrom, blockTo, &f), nil
+	default:
+		var f web3Types.FilterQuery
+		if err := json.Unmarshal([]byte(filter), &f); err != nil {
+			return store.LogFilter{}, err
+		}
+
+		return store.ParseEthLogFilter(blockFrom, blockTo, &f, p.networkId), nil
+	}
+}
+
+type logFilterCfx struct {
+	Address []types.Address `json:"address,omitempty"`
+	Topics  [][]types.Hash  `json:"topics,omitempty"`
+}
+
+type logFilterEth struct {
+	Addresses []common.Address `json:"address,omitempty"`
+	Topics    [][]common.Hash  `json:"topics,omitempty"`
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var errPrivateTarget = errors.New("private, loopback or link-local address not allowed")
+
+// isPublicIP checks if the IP address is publicly routable, so that webhooks won't be abused to
+// request internal services (SSRF), e.g., cloud metadata service at 169.254.169.254.
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
+
+// validateTargetUrl validates the webhook url, of which the host must be resolved to public
+// addresses only unless private targets allowed.
+func validateTargetUrl(ctx context.Context, rawUrl string, allowPrivate bool) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Hostname()) == 0 {
+		return errors.Errorf("invalid webhook url %v", rawUrl)
+	}
+
+	if allowPrivate {
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return errors.WithMessagef(err, "failed to resolve webhook host %v", u.Hostname())
+	}
+
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return errors.WithMessagef(errPrivateTarget, "webhook host %v resolved to %v", u.Hostname(), addr.IP)
+		}
+	}
+
+	return nil
+}
+
+// newTargetClient creates HTTP client to POST payloads, which rejects private targets at dial time
+// unless allowed, since the host might be resolved to other addresses since registration (e.g.,
+// DNS rebinding), or redirected to.
+func newTargetClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return errors.WithMessagef(errPrivateTarget, "dial %v", address)
+			}
+
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // dial webhook host directly, so that target address is always checked
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,48 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/rpc/handler"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	cisync "github.com/Conflux-Chain/confura/sync"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfillForce(t *testing.T) {
+	mc := MustStartMySQL(t)
+	ms := MustNewMysqlStore(t, mc, "confura_it_backfill", func(config *mysql.Config, _ *mysql.StoreOption) {
+		// address blooms are keyed by epoch, and must be removed before overwritten
+		config.AddressBloom.Enabled = true
+	})
+	node := MustStartFakeFullnode(t, 30)
+
+	cfx, err := sdk.NewClient(node.URL())
+	require.NoError(t, err)
+	defer cfx.Close()
+
+	require.NoError(t, ms.Pushn(queryEpochs(t, cfx, 0, 30)))
+
+	// all the epochs within range are re-fetched and overwritten, even if already stored
+	backfiller := cisync.MustNewCfxRangeBackfiller(cfx, ms, 4)
+	for i := 0; i < 2; i++ {
+		numBackfilled, err := backfiller.Backfill(context.Background(), 5, 15, true)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(11), numBackfilled)
+	}
+
+	// nothing to backfill without force
+	numBackfilled, err := backfiller.Backfill(context.Background(), 5, 15, false)
+	require.NoError(t, err)
+	assert.Zero(t, numBackfilled)
+
+	h := handler.NewCfxCommonStoreHandler("db", ms, nil)
+	for epoch := uint64(0); epoch <= 30; epoch++ {
+		assertBlockServed(t, h, node, epoch)
+	}
+}
@@ -0,0 +1,63 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	cisync "github.com/Conflux-Chain/confura/sync"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRetryDeadLetterEpochs(t *testing.T) {
+	mc := MustStartMySQL(t)
+	ms := MustNewMysqlStore(t, mc, "confura_it_dead_letter", func(config *mysql.Config, _ *mysql.StoreOption) {
+		// address blooms are written for parked skeletons as well
+		config.AddressBloom.Enabled = true
+	})
+	node := MustStartFakeFullnode(t, 30)
+
+	cfx, err := sdk.NewClient(node.URL())
+	require.NoError(t, err)
+	defer cfx.Close()
+
+	// park epoch 10 with block headers only, as the dead letter queue does during sync
+	require.NoError(t, ms.Pushn(queryEpochs(t, cfx, 0, 9)))
+
+	parked := queryEpochs(t, cfx, 10, 10)[0]
+	err = ms.PushnWithFinalizer([]*store.EpochData{parked.Skeleton()}, func(dbTx *gorm.DB) error {
+		pivotHash := string(parked.GetPivotBlock().Hash)
+		return ms.AddDeadLetterEpochWithTx(dbTx, parked.Number, pivotHash, errors.New("data too long"))
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, ms.Pushn(queryEpochs(t, cfx, 11, 30)))
+
+	_, err = ms.GetEpochReceipts(context.Background(), 10)
+	assert.ErrorIs(t, err, store.ErrEpochParked)
+
+	// retried by overwriting the skeleton
+	backfiller := cisync.MustNewCfxRangeBackfiller(cfx, ms, 4)
+	numResolved, err := cisync.RetryDeadLetterEpochs(context.Background(), backfiller)
+	require.NoError(t, err)
+	assert.Equal(t, 1, numResolved)
+
+	entries, err := ms.DeadLetterEpochs(false, 0)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	_, err = ms.GetEpochReceipts(context.Background(), 10)
+	assert.NoError(t, err)
+
+	// nothing to retry any more
+	numResolved, err = cisync.RetryDeadLetterEpochs(context.Background(), backfiller)
+	require.NoError(t, err)
+	assert.Zero(t, numResolved)
+}
@@ -0,0 +1,93 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"net"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Container is a throwaway docker container for integration tests.
+type Container struct {
+	ID       string
+	Image    string
+	HostAddr string // host address mapped to the exposed container port
+}
+
+// StartContainer starts a docker container of the specified image in background, and maps the
+// exposed container port to some random port on localhost.
+func StartContainer(image string, port string, env ...string) (*Container, error) {
+	args := []string{"run", "-d", "--rm", "-p", "127.0.0.1::" + port}
+	for _, e := range env {
+		args = append(args, "-e", e)
+	}
+	args = append(args, image)
+
+	id, err := docker(args...)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to run container %v", image)
+	}
+
+	c := &Container{ID: id, Image: image}
+
+	// eg., 127.0.0.1:49153
+	addr, err := docker("port", id, port)
+	if err != nil {
+		c.Close()
+		return nil, errors.WithMessage(err, "failed to get mapped container port")
+	}
+
+	c.HostAddr = strings.Split(addr, "\n")[0]
+	return c, nil
+}
+
+// WaitReady waits until the probe succeeds or timeout.
+func (c *Container) WaitReady(timeout time.Duration, probe func() error) (err error) {
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		if err = probe(); err == nil {
+			return nil
+		}
+
+		time.Sleep(time.Second)
+	}
+
+	return errors.WithMessagef(err, "container %v not ready in %v", c.Image, timeout)
+}
+
+// WaitPortReady waits until the mapped host port accepts TCP connections.
+func (c *Container) WaitPortReady(timeout time.Duration) error {
+	return c.WaitReady(timeout, func() error {
+		conn, err := net.DialTimeout("tcp", c.HostAddr, time.Second)
+		if err == nil {
+			conn.Close()
+		}
+
+		return err
+	})
+}
+
+// Close removes the container forcibly.
+func (c *Container) Close() error {
+	_, err := docker("rm", "-f", c.ID)
+	return err
+}
+
+func docker(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", errors.WithMessage(err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,107 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/test/simulator"
+	"github.com/go-redis/redis/v8"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/mcuadros/go-defaults"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	mysqlImage = "mysql:8"
+	redisImage = "redis:7-alpine"
+
+	// max duration to wait for containers ready
+	containerReadyTimeout = 2 * time.Minute
+)
+
+// MustStartMySQL starts a throwaway MySQL container, which will be removed once test completed.
+func MustStartMySQL(t *testing.T) *Container {
+	c, err := StartContainer(mysqlImage, "3306/tcp", "MYSQL_ROOT_PASSWORD=root")
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+
+	dsn := fmt.Sprintf("root:root@tcp(%v)/", c.HostAddr)
+	err = c.WaitReady(containerReadyTimeout, func() error {
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		return db.Ping()
+	})
+	require.NoError(t, err)
+
+	return c
+}
+
+// StoreOption customizes the store config and option before opened.
+type StoreOption func(config *mysql.Config, option *mysql.StoreOption)
+
+// MustNewMysqlStore creates a core space store against the MySQL container with the specified database.
+func MustNewMysqlStore(t *testing.T, c *Container, database string, options ...StoreOption) *mysql.MysqlStore {
+	var config mysql.Config
+	defaults.SetDefaults(&config)
+
+	config.Enabled = true
+	config.Host = c.HostAddr
+	config.Username, config.Password = "root", "root"
+	config.Database = database
+
+	option := mysql.StoreOption{Disabler: store.StoreConfig()}
+	for _, o := range options {
+		o(&config, &option)
+	}
+
+	ms := config.MustOpenOrCreate(option)
+	t.Cleanup(func() { ms.Close() })
+
+	return ms
+}
+
+// MustStartRedis starts a throwaway Redis container, and returns the redis URL.
+func MustStartRedis(t *testing.T) (*Container, string) {
+	c, err := StartContainer(redisImage, "6379/tcp")
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+
+	url := fmt.Sprintf("redis://%v/0", c.HostAddr)
+	opt, err := redis.ParseURL(url)
+	require.NoError(t, err)
+
+	err = c.WaitReady(containerReadyTimeout, func() error {
+		client := redis.NewClient(opt)
+		defer client.Close()
+
+		return client.Ping(context.Background()).Err()
+	})
+	require.NoError(t, err)
+
+	return c, url
+}
+
+// MustStartFakeFullnode starts a simulated full node with the specified number of epochs mined.
+func MustStartFakeFullnode(t *testing.T, epochs int) *simulator.Node {
+	node := simulator.NewNode(simulator.Config{})
+	t.Cleanup(node.Close)
+
+	node.Mine(epochs)
+	return node
+}
+
+// WaitUntil waits until the condition satisfied or test failed due to timeout.
+func WaitUntil(t *testing.T, timeout time.Duration, cond func() bool) {
+	require.Eventually(t, cond, timeout, 100*time.Millisecond)
+}
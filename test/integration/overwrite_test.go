@@ -0,0 +1,136 @@
+//go:build integration
+
+package integration
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testToken        = cfxaddress.MustNewFromHex("0x8d7df9316faa0586e175b5e6d03c6bda76e3d950", 1029)
+	testSender       = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	testReceiver     = common.HexToAddress("0x1000000000000000000000000000000000000002")
+	testTransferHash = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+)
+
+// logsOnlyDisabler persists event logs and the derived indices only, so that epoch data could be
+// assembled without full transactions and receipts.
+type logsOnlyDisabler struct{}
+
+func (logsOnlyDisabler) IsChainBlockDisabled() bool   { return true }
+func (logsOnlyDisabler) IsChainTxnDisabled() bool     { return true }
+func (logsOnlyDisabler) IsChainReceiptDisabled() bool { return true }
+func (logsOnlyDisabler) IsChainLogDisabled() bool     { return false }
+
+func (logsOnlyDisabler) IsDisabledForType(edt store.EpochDataType) bool {
+	return edt != store.EpochLog && edt != store.EpochDataNil
+}
+
+// withIndices enables token transfer, internal transaction and address bloom indices.
+func withIndices(config *mysql.Config, option *mysql.StoreOption) {
+	config.TokenTransferIndexEnabled = true
+	config.InternalTxIndexEnabled = true
+	config.AddressBloom.Enabled = true
+
+	option.Disabler = logsOnlyDisabler{}
+}
+
+func queryEpochs(t *testing.T, cfx *sdk.Client, from, to uint64) (slice []*store.EpochData) {
+	for i := from; i <= to; i++ {
+		data, err := store.QueryEpochData(cfx, i, false)
+		require.NoError(t, err)
+		slice = append(slice, &data)
+	}
+
+	return slice
+}
+
+// withTransfer adds a transaction into the pivot block, which emits a token transfer event and
+// makes an internal transaction.
+func withTransfer(data *store.EpochData) *store.EpochData {
+	pivot := data.GetPivotBlock()
+	txHash := types.Hash(common.BigToHash(new(big.Int).SetUint64(data.Number + 1)).Hex())
+	status := hexutil.Uint64(0)
+
+	epochNum := hexutil.Uint64(data.Number)
+	from := cfxaddress.MustNewFromCommon(testSender, 1029)
+
+	pivot.Transactions = append(pivot.Transactions, types.Transaction{
+		Hash: txHash, BlockHash: &pivot.Hash, Status: &status, From: from,
+	})
+
+	data.Receipts = map[types.Hash]*types.TransactionReceipt{
+		txHash: {TransactionHash: txHash, BlockHash: pivot.Hash, EpochNumber: &epochNum, From: from, Logs: []types.Log{{
+			Address: testToken,
+			Topics: []types.Hash{
+				types.Hash(testTransferHash.Hex()),
+				types.Hash(common.BytesToHash(testSender.Bytes()).Hex()),
+				types.Hash(common.BytesToHash(testReceiver.Bytes()).Hex()),
+			},
+			Data:            common.BigToHash(big.NewInt(100)).Bytes(),
+			EpochNumber:     types.NewBigInt(data.Number),
+			LogIndex:        types.NewBigInt(0),
+			TransactionHash: &txHash,
+		}}},
+	}
+
+	ethTxHash := common.HexToHash(txHash.String())
+	newCall := func(value int64, subtraces uint) web3Types.LocalizedTrace {
+		return web3Types.LocalizedTrace{
+			Type:            web3Types.TRACE_CALL,
+			Action:          web3Types.Call{From: testSender, To: testReceiver, Value: big.NewInt(value), CallType: "call"},
+			Subtraces:       subtraces,
+			TransactionHash: &ethTxHash,
+			BlockNumber:     pivot.BlockNumber.ToInt().Uint64(),
+		}
+	}
+
+	data.BlockExts = make([]*store.BlockExtra, len(data.Blocks))
+	data.BlockExts[len(data.Blocks)-1] = &store.BlockExtra{
+		Traces: []web3Types.LocalizedTrace{newCall(5, 1), newCall(6, 0)},
+	}
+
+	return data
+}
+
+func TestOverwriteIndices(t *testing.T) {
+	mc := MustStartMySQL(t)
+	ms := MustNewMysqlStore(t, mc, "confura_it_overwrite", withIndices)
+	node := MustStartFakeFullnode(t, 30)
+
+	cfx, err := sdk.NewClient(node.URL())
+	require.NoError(t, err)
+	defer cfx.Close()
+
+	require.NoError(t, ms.Pushn(queryEpochs(t, cfx, 0, 30)))
+
+	// overwrite the same epoch twice, e.g., repaired again after the first attempt
+	for i := 0; i < 2; i++ {
+		require.NoError(t, ms.Overwrite([]*store.EpochData{withTransfer(queryEpochs(t, cfx, 10, 10)[0])}))
+
+		transfers, err := ms.TokenTransfersByHolder(testReceiver, 0, 100)
+		require.NoError(t, err)
+		assert.Len(t, transfers, 1)
+
+		internalTxs, err := ms.InternalTxsByAddress(testReceiver, 0, 100)
+		require.NoError(t, err)
+		assert.Len(t, internalTxs, 1)
+	}
+
+	maxEpoch, ok, err := ms.MaxEpoch()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, uint64(30), maxEpoch)
+}
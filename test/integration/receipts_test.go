@@ -0,0 +1,51 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/rpc/handler"
+	"github.com/Conflux-Chain/confura/store"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEpochReceiptsFromStore(t *testing.T) {
+	mc := MustStartMySQL(t)
+	ms := MustNewMysqlStore(t, mc, "confura_it_receipts")
+	node := MustStartFakeFullnode(t, 10)
+
+	cfx, err := sdk.NewClient(node.URL())
+	require.NoError(t, err)
+	defer cfx.Close()
+
+	epochs := queryEpochs(t, cfx, 0, 10)
+	epochs[5] = withTransfer(epochs[5])
+	require.NoError(t, ms.Pushn(epochs))
+
+	h := handler.NewCfxCommonStoreHandler("db", ms, nil)
+
+	// receipts grouped by block in the order of blocks within epoch
+	receipts, err := h.GetEpochReceipts(context.Background(), 5)
+	require.NoError(t, err)
+	require.Len(t, receipts, len(epochs[5].Blocks))
+
+	pivotReceipts := receipts[len(receipts)-1]
+	require.Len(t, pivotReceipts, 1)
+	assert.Equal(t, epochs[5].GetPivotBlock().Transactions[0].Hash, pivotReceipts[0].TransactionHash)
+	assert.Len(t, pivotReceipts[0].Logs, 1)
+
+	// epoch without any transaction
+	receipts, err = h.GetEpochReceipts(context.Background(), 4)
+	require.NoError(t, err)
+	for _, blockReceipts := range receipts {
+		assert.Empty(t, blockReceipts)
+	}
+
+	// epoch not synced yet
+	_, err = ms.GetEpochReceipts(context.Background(), 11)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
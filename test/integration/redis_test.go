@@ -0,0 +1,63 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/redis"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mustNewRedisStore creates a redis store against the Redis container.
+func mustNewRedisStore(t *testing.T) *redis.RedisStore {
+	_, url := MustStartRedis(t)
+
+	rs := redis.NewRedisStore(redis.MustNewRedisClient(url), time.Hour, store.StoreConfig())
+	t.Cleanup(func() { rs.Close() })
+
+	return rs
+}
+
+func requireRedisEpochRange(t *testing.T, rs *redis.RedisStore, minEpoch, maxEpoch uint64) {
+	from, to, err := rs.GetGlobalEpochRange()
+	require.NoError(t, err)
+	assert.Equal(t, minEpoch, from)
+	assert.Equal(t, maxEpoch, to)
+}
+
+func TestRedisStorePushPop(t *testing.T) {
+	rs := mustNewRedisStore(t)
+	node := MustStartFakeFullnode(t, 20)
+
+	cfx, err := sdk.NewClient(node.URL())
+	require.NoError(t, err)
+	defer cfx.Close()
+
+	require.NoError(t, rs.Pushn(queryEpochs(t, cfx, 0, 20)))
+	requireRedisEpochRange(t, rs, 0, 20)
+
+	// replay the tail epochs, e.g., after crash recovery
+	require.NoError(t, rs.Pushn(queryEpochs(t, cfx, 15, 20)))
+	requireRedisEpochRange(t, rs, 0, 20)
+
+	// replayed epochs must cover the stored tail
+	assert.Error(t, rs.Push(queryEpochs(t, cfx, 10, 10)[0]))
+
+	epoch := queryEpochs(t, cfx, 5, 5)[0]
+	blocksum, err := rs.GetBlockSummaryByEpoch(context.Background(), 5)
+	require.NoError(t, err)
+	assert.Equal(t, epoch.GetPivotBlock().Hash, blocksum.CfxBlockSummary.Hash)
+
+	// pop epochs, e.g., due to chain reorg
+	require.NoError(t, rs.Popn(10))
+	requireRedisEpochRange(t, rs, 0, 9)
+
+	_, err = rs.GetBlockSummaryByEpoch(context.Background(), 10)
+	assert.True(t, rs.IsRecordNotFound(err))
+}
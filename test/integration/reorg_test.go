@@ -0,0 +1,69 @@
+//go:build integration
+
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestReorgEvents(t *testing.T) {
+	mc := MustStartMySQL(t)
+	ms := MustNewMysqlStore(t, mc, "confura_it_reorg")
+	node := MustStartFakeFullnode(t, 30)
+
+	cfx, err := sdk.NewClient(node.URL())
+	require.NoError(t, err)
+	defer cfx.Close()
+
+	require.NoError(t, ms.Pushn(queryEpochs(t, cfx, 0, 30)))
+
+	revert := func(event mysql.ReorgEvent) {
+		require.NoError(t, ms.PopnWithFinalizer(event.EpochFrom, func(tx *gorm.DB) error {
+			return ms.AddReorgEventWithTx(tx, &event)
+		}))
+	}
+
+	// deep reorg reverted epoch by epoch is merged into a single event
+	revert(mysql.ReorgEvent{EpochFrom: 30, EpochTo: 30, OldPivotHash: "0x30", NewPivotHash: "0x30b"})
+	revert(mysql.ReorgEvent{EpochFrom: 29, EpochTo: 29, OldPivotHash: "0x29", NewPivotHash: "0x29b"})
+
+	// reverted again after a while
+	revert(mysql.ReorgEvent{EpochFrom: 20, EpochTo: 28, DetectedAt: time.Now().Add(2 * time.Minute)})
+
+	events, err := ms.ReorgEvents(0, 100, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, uint64(20), events[0].EpochFrom)
+	assert.Equal(t, uint64(9), events[0].Depth)
+
+	assert.Equal(t, uint64(29), events[1].EpochFrom)
+	assert.Equal(t, uint64(30), events[1].EpochTo)
+	assert.Equal(t, uint64(2), events[1].Depth)
+	assert.Equal(t, "0x29", events[1].OldPivotHash)
+
+	// events overlapped with the epoch range only
+	events, err = ms.ReorgEvents(30, 40, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, uint64(29), events[0].EpochFrom)
+
+	events, err = ms.ReorgEvents(0, 19, 0)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	_, err = ms.ReorgEvents(10, 0, 0)
+	assert.Error(t, err)
+
+	maxEpoch, ok, err := ms.MaxEpoch()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, uint64(19), maxEpoch)
+}
@@ -0,0 +1,220 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/rpc/handler"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/store/mysql"
+	cisync "github.com/Conflux-Chain/confura/sync"
+	"github.com/Conflux-Chain/confura/test/simulator"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const syncTimeout = time.Minute
+
+// Run with `go test -tags integration ./test/integration/...`, which requires docker installed.
+
+func mustStartDbSyncer(t *testing.T, node *simulator.Node, ms *mysql.MysqlStore) *sdk.Client {
+	cfx, err := sdk.NewClient(node.URL())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+
+	syncer := cisync.MustNewDatabaseSyncer([]*sdk.Client{cfx}, ms)
+	go syncer.Sync(ctx, &wg)
+
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+		cfx.Close()
+	})
+
+	return cfx
+}
+
+// waitSynced waits until the latest pivot chain of fake full node synchronized into store.
+func waitSynced(t *testing.T, node *simulator.Node, ms *mysql.MysqlStore) {
+	WaitUntil(t, syncTimeout, func() bool {
+		latestEpoch := node.LatestEpoch()
+
+		maxEpoch, ok, err := ms.MaxEpoch()
+		if err != nil || !ok || maxEpoch != latestEpoch {
+			return false
+		}
+
+		pivotBlock, _ := node.PivotBlock(latestEpoch)
+		pivotHash, ok, err := ms.PivotHash(latestEpoch)
+		return err == nil && ok && pivotHash == pivotBlock.Hash.String()
+	})
+}
+
+func assertBlockServed(t *testing.T, h *handler.CfxStoreHandler, node *simulator.Node, epoch uint64) {
+	pivotBlock, ok := node.PivotBlock(epoch)
+	require.True(t, ok)
+
+	res, err := h.GetBlockByHash(context.Background(), pivotBlock.Hash, false)
+	require.NoError(t, err)
+
+	blockSummary := res.(*store.BlockSummary).CfxBlockSummary
+	assert.Equal(t, pivotBlock.Hash, blockSummary.Hash)
+	assert.Equal(t, pivotBlock.ParentHash, blockSummary.ParentHash)
+	assert.Equal(t, epoch, blockSummary.EpochNumber.ToInt().Uint64())
+}
+
+func TestSyncStoreServe(t *testing.T) {
+	mc := MustStartMySQL(t)
+	ms := MustNewMysqlStore(t, mc, "confura_it_sync")
+	node := MustStartFakeFullnode(t, 50)
+
+	mustStartDbSyncer(t, node, ms)
+	waitSynced(t, node, ms)
+
+	h := handler.NewCfxCommonStoreHandler("db", ms, nil)
+	for _, epoch := range []uint64{1, 25, node.LatestEpoch()} {
+		assertBlockServed(t, h, node, epoch)
+	}
+
+	// keep syncing new epochs
+	node.Mine(20)
+	waitSynced(t, node, ms)
+	assertBlockServed(t, h, node, node.LatestEpoch())
+}
+
+func TestSyncPivotSwitch(t *testing.T) {
+	mc := MustStartMySQL(t)
+	ms := MustNewMysqlStore(t, mc, "confura_it_reorg")
+	node := MustStartFakeFullnode(t, 50)
+
+	mustStartDbSyncer(t, node, ms)
+	waitSynced(t, node, ms)
+
+	reverted, _ := node.PivotBlock(45)
+
+	// switch pivot chain since epoch 40 and extend the new pivot chain
+	node.Reorg(40)
+	node.Mine(5)
+	waitSynced(t, node, ms)
+
+	h := handler.NewCfxCommonStoreHandler("db", ms, nil)
+	for epoch := uint64(39); epoch <= node.LatestEpoch(); epoch++ {
+		assertBlockServed(t, h, node, epoch)
+	}
+
+	// reverted block should be removed from store
+	_, err := h.GetBlockByHash(context.Background(), reverted.Hash, false)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestReplayEpochsAfterCrash(t *testing.T) {
+	mc := MustStartMySQL(t)
+	ms := MustNewMysqlStore(t, mc, "confura_it_replay")
+	node := MustStartFakeFullnode(t, 30)
+
+	cfx, err := sdk.NewClient(node.URL())
+	require.NoError(t, err)
+	defer cfx.Close()
+
+	queryEpochs := func(from, to uint64) (slice []*store.EpochData) {
+		for i := from; i <= to; i++ {
+			data, err := store.QueryEpochData(cfx, i, false)
+			require.NoError(t, err)
+			slice = append(slice, &data)
+		}
+		return slice
+	}
+
+	require.NoError(t, ms.Pushn(queryEpochs(0, 20)))
+
+	// replay the already stored epochs as if the syncer restarted from some checkpoint
+	require.NoError(t, ms.Pushn(queryEpochs(15, 30)))
+
+	maxEpoch, ok, err := ms.MaxEpoch()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, uint64(30), maxEpoch)
+
+	// replay again should be idempotent
+	require.NoError(t, ms.Pushn(queryEpochs(30, 30)))
+
+	h := handler.NewCfxCommonStoreHandler("db", ms, nil)
+	for epoch := uint64(0); epoch <= 30; epoch++ {
+		assertBlockServed(t, h, node, epoch)
+	}
+}
+
+func TestSyncCheckpointExactlyOnce(t *testing.T) {
+	mc := MustStartMySQL(t)
+	ms := MustNewMysqlStore(t, mc, "confura_it_checkpoint")
+	node := MustStartFakeFullnode(t, 30)
+
+	cfx, err := sdk.NewClient(node.URL())
+	require.NoError(t, err)
+	defer cfx.Close()
+
+	queryEpochs := func(from, to uint64) (slice []*store.EpochData) {
+		for i := from; i <= to; i++ {
+			data, err := store.QueryEpochData(cfx, i, false)
+			require.NoError(t, err)
+			slice = append(slice, &data)
+		}
+		return slice
+	}
+
+	assertCheckpoint := func(expected uint64) {
+		checkpoint, ok, err := ms.SyncCheckpoint()
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, expected, checkpoint)
+
+		maxEpoch, ok, err := ms.MaxEpoch()
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, checkpoint, maxEpoch)
+	}
+
+	require.NoError(t, ms.Pushn(queryEpochs(0, 20)))
+	assertCheckpoint(20)
+
+	// crash before committed, neither epoch data nor checkpoint persisted
+	err = ms.PushnWithFinalizer(queryEpochs(21, 25), func(*gorm.DB) error {
+		return errors.New("crashed")
+	})
+	require.Error(t, err)
+	assertCheckpoint(20)
+
+	_, err = ms.GetBlocksByEpoch(context.Background(), 21)
+	assert.True(t, ms.IsRecordNotFound(err))
+
+	// checkpoint rewound along with popped epochs
+	require.NoError(t, ms.Popn(18))
+	assertCheckpoint(17)
+
+	// initialization on startup won't change anything
+	require.NoError(t, ms.InitSyncCheckpoint())
+	assertCheckpoint(17)
+
+	// sync resumes right after the checkpoint, and each epoch is stored exactly once
+	mustStartDbSyncer(t, node, ms)
+	waitSynced(t, node, ms)
+	assertCheckpoint(node.LatestEpoch())
+
+	for epoch := uint64(0); epoch <= node.LatestEpoch(); epoch++ {
+		hashes, err := ms.GetBlocksByEpoch(context.Background(), epoch)
+		require.NoError(t, err)
+
+		pivotBlock, _ := node.PivotBlock(epoch)
+		assert.Equal(t, []types.Hash{pivotBlock.Hash}, hashes)
+	}
+}
@@ -0,0 +1,67 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/sync/webhook"
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/mcuadros/go-defaults"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookDispatcherSkipsBrokenWebhook(t *testing.T) {
+	mc := MustStartMySQL(t)
+	ms := MustNewMysqlStore(t, mc, "confura_it_webhook")
+	node := MustStartFakeFullnode(t, 30)
+
+	cfx, err := sdk.NewClient(node.URL())
+	require.NoError(t, err)
+	defer cfx.Close()
+
+	require.NoError(t, ms.Pushn(queryEpochs(t, cfx, 0, 30)))
+
+	// webhook of malformed filter registered before validation, which always fails to match
+	broken := &mysql.Webhook{Url: "http://127.0.0.1:1/broken", Filter: "not json"}
+	require.NoError(t, ms.AddWebhook(broken))
+
+	healthy := &mysql.Webhook{Url: "http://127.0.0.1:1/healthy", Filter: "{}"}
+	require.NoError(t, ms.AddWebhook(healthy))
+
+	var conf webhook.Config
+	defaults.SetDefaults(&conf)
+	conf.Interval = 100 * time.Millisecond
+	conf.AllowPrivateTargets = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	go webhook.NewDispatcher(&conf, ms, webhook.SpaceCfx, 0).Run(ctx, &wg)
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	// the broken webhook never blocks the healthy one
+	cursors := func() map[uint32]uint64 {
+		webhooks, err := ms.LoadWebhooks()
+		require.NoError(t, err)
+
+		res := make(map[uint32]uint64)
+		for _, wh := range webhooks {
+			res[wh.ID] = wh.Cursor
+		}
+
+		return res
+	}
+
+	WaitUntil(t, 10*time.Second, func() bool {
+		return cursors()[healthy.ID] == 30
+	})
+	assert.Equal(t, uint64(0), cursors()[broken.ID])
+}
@@ -0,0 +1,153 @@
+package simulator
+
+import (
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+)
+
+// cfxAPI the core space RPC methods required by sync service and virtual filter.
+type cfxAPI struct {
+	n *Node
+}
+
+func (api *cfxAPI) GetStatus() (types.Status, error) {
+	api.n.mu.RLock()
+	defer api.n.mu.RUnlock()
+
+	latest := uint64(len(api.n.pivots) - 1)
+
+	return types.Status{
+		BestHash:        api.n.pivots[latest].Hash,
+		ChainID:         hexutil.Uint64(api.n.conf.ChainId),
+		NetworkID:       hexutil.Uint64(api.n.conf.NetworkId),
+		EpochNumber:     hexutil.Uint64(latest),
+		BlockNumber:     hexutil.Uint64(latest),
+		LatestConfirmed: hexutil.Uint64(latest),
+		LatestState:     hexutil.Uint64(latest),
+	}, nil
+}
+
+func (api *cfxAPI) EpochNumber(epoch *types.Epoch) (*hexutil.Big, error) {
+	api.n.mu.RLock()
+	defer api.n.mu.RUnlock()
+
+	num, err := api.n.resolveEpoch(epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.NewBigInt(num), nil
+}
+
+func (api *cfxAPI) GetBlocksByEpoch(epoch *types.Epoch) ([]types.Hash, error) {
+	api.n.mu.RLock()
+	defer api.n.mu.RUnlock()
+
+	num, err := api.n.resolveEpoch(epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	return []types.Hash{api.n.pivots[num].Hash}, nil
+}
+
+func (api *cfxAPI) GetBlockByHash(blockHash types.Hash, includeTxs bool) (interface{}, error) {
+	api.n.mu.RLock()
+	defer api.n.mu.RUnlock()
+
+	block, ok := api.n.blocks[blockHash]
+	if !ok {
+		return nil, nil
+	}
+
+	return encodeCfxBlock(block, includeTxs), nil
+}
+
+func (api *cfxAPI) GetBlockByEpochNumber(epoch *types.Epoch, includeTxs bool) (interface{}, error) {
+	api.n.mu.RLock()
+	defer api.n.mu.RUnlock()
+
+	num, err := api.n.resolveEpoch(epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeCfxBlock(api.n.pivots[num], includeTxs), nil
+}
+
+func (api *cfxAPI) GetBlockByHashWithPivotAssumption(
+	blockHash, pivotHash types.Hash, epoch hexutil.Uint64,
+) (*types.Block, error) {
+	api.n.mu.RLock()
+	defer api.n.mu.RUnlock()
+
+	if uint64(epoch) >= uint64(len(api.n.pivots)) {
+		return nil, errBlockNotFound
+	}
+
+	if api.n.pivots[epoch].Hash != pivotHash {
+		return nil, errPivotAssumption
+	}
+
+	block, ok := api.n.blocks[blockHash]
+	if !ok {
+		return nil, errBlockNotFound
+	}
+
+	return block, nil
+}
+
+func (api *cfxAPI) GetEpochReceipts(epochOrPivotHash string) ([][]types.TransactionReceipt, error) {
+	// blocks are always empty
+	return [][]types.TransactionReceipt{{}}, nil
+}
+
+func (api *cfxAPI) GetLogs(filter types.LogFilter) ([]types.Log, error) {
+	// blocks are always empty
+	return []types.Log{}, nil
+}
+
+func (api *cfxAPI) NewBlockFilter() rpc.ID {
+	return api.n.newFilter(filterTypeBlock)
+}
+
+func (api *cfxAPI) NewPendingTransactionFilter() rpc.ID {
+	return api.n.newFilter(filterTypePendingTxn)
+}
+
+func (api *cfxAPI) NewFilter(crit types.LogFilter) rpc.ID {
+	return api.n.newFilter(filterTypeLog)
+}
+
+func (api *cfxAPI) UninstallFilter(id rpc.ID) bool {
+	return api.n.filters.uninstall(id)
+}
+
+func (api *cfxAPI) GetFilterChanges(id rpc.ID) (*types.CfxFilterChanges, error) {
+	typ, hashes, err := api.n.filterChanges(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if typ == filterTypeLog {
+		return &types.CfxFilterChanges{Type: "log", Logs: []*types.SubscriptionLog{}}, nil
+	}
+
+	if hashes == nil {
+		hashes = []types.Hash{}
+	}
+
+	return &types.CfxFilterChanges{Type: "hash", Hashes: hashes}, nil
+}
+
+func encodeCfxBlock(block *types.Block, includeTxs bool) interface{} {
+	if includeTxs {
+		return block
+	}
+
+	return &types.BlockSummary{
+		BlockHeader:  block.BlockHeader,
+		Transactions: []types.Hash{},
+	}
+}
@@ -0,0 +1,247 @@
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+const (
+	// AnyMethod matches all the RPC methods when scripting faults.
+	AnyMethod = "*"
+
+	// InjectedErrorCode is the JSON-RPC error code of injected failures.
+	InjectedErrorCode = -32000
+	// InjectedErrorMessage is the JSON-RPC error message of injected failures.
+	InjectedErrorMessage = "injected failure by simulator"
+)
+
+// jsonrpcMessage is the minimal JSON-RPC message to inspect requests and build error responses.
+type jsonrpcMessage struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Error   *jsonrpcError   `json:"error,omitempty"`
+}
+
+type jsonrpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Chaos is a set of scriptable faults injected into the JSON-RPC requests of the simulated full
+// node, e.g. service outage, latency and (partial) failures for specific RPC methods.
+type Chaos struct {
+	mu        sync.Mutex
+	down      bool                     // responds 503 for all requests if down
+	latencies map[string]time.Duration // method => injected latency
+	failRates map[string]float64       // method => failure rate in [0, 1]
+	failNext  map[string]int           // method => number of following requests to fail
+	calls     map[string]int           // method => number of received requests
+	rand      *rand.Rand
+}
+
+func newChaos() *Chaos {
+	c := &Chaos{rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	c.Reset()
+
+	return c
+}
+
+// Reset clears all the scripted faults and statistics.
+func (c *Chaos) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.down = false
+	c.latencies = make(map[string]time.Duration)
+	c.failRates = make(map[string]float64)
+	c.failNext = make(map[string]int)
+	c.calls = make(map[string]int)
+}
+
+// SetDown simulates the full node outage, in which case HTTP 503 will be responded for all requests.
+func (c *Chaos) SetDown(down bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.down = down
+}
+
+// SetLatency injects latency for the specified RPC method or `AnyMethod`.
+func (c *Chaos) SetLatency(method string, latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.latencies[method] = latency
+}
+
+// SetFailureRate fails the specified RPC method or `AnyMethod` randomly with the given rate in [0, 1].
+func (c *Chaos) SetFailureRate(method string, rate float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failRates[method] = rate
+}
+
+// FailNext fails the following specified number of requests for the RPC method or `AnyMethod`.
+func (c *Chaos) FailNext(method string, times int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failNext[method] = times
+}
+
+// Calls returns the number of received requests for the specified RPC method or `AnyMethod`.
+func (c *Chaos) Calls(method string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.calls[method]
+}
+
+// inspect records the RPC request, and returns the injected latency and whether to fail it.
+func (c *Chaos) inspect(method string) (latency time.Duration, fail bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls[method]++
+	c.calls[AnyMethod]++
+
+	for _, key := range []string{method, AnyMethod} {
+		if d, ok := c.latencies[key]; ok && d > latency {
+			latency = d
+		}
+
+		if c.failNext[key] > 0 {
+			c.failNext[key]--
+			fail = true
+		}
+
+		if rate, ok := c.failRates[key]; ok && c.rand.Float64() < rate {
+			fail = true
+		}
+	}
+
+	return latency, fail
+}
+
+func (c *Chaos) isDown() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.down
+}
+
+// wrap returns an HTTP handler to inject faults before serving JSON-RPC requests. For batch
+// requests, only the failed ones will be responded with error, and others are served normally.
+func (c *Chaos) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.isDown() {
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var msgs []*jsonrpcMessage
+		isBatch := len(bytes.TrimSpace(body)) > 0 && bytes.TrimSpace(body)[0] == '['
+
+		if isBatch {
+			err = json.Unmarshal(body, &msgs)
+		} else {
+			var msg jsonrpcMessage
+			err = json.Unmarshal(body, &msg)
+			msgs = append(msgs, &msg)
+		}
+
+		if err != nil { // let the RPC server handle the malformed request
+			c.serve(next, w, r, body)
+			return
+		}
+
+		var maxLatency time.Duration
+		var passed []*jsonrpcMessage
+		var failed []*jsonrpcMessage
+
+		for _, msg := range msgs {
+			latency, fail := c.inspect(msg.Method)
+			if latency > maxLatency {
+				maxLatency = latency
+			}
+
+			if fail {
+				failed = append(failed, newInjectedError(msg))
+			} else {
+				passed = append(passed, msg)
+			}
+		}
+
+		time.Sleep(maxLatency)
+
+		if len(failed) == 0 {
+			c.serve(next, w, r, body)
+			return
+		}
+
+		if !isBatch {
+			writeJSON(w, failed[0])
+			return
+		}
+
+		responses := make([]json.RawMessage, 0, len(msgs))
+		if len(passed) > 0 {
+			data, _ := json.Marshal(passed)
+
+			recorder := httptest.NewRecorder()
+			c.serve(next, recorder, r, data)
+
+			var served []json.RawMessage
+			if err := json.Unmarshal(recorder.Body.Bytes(), &served); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			responses = append(responses, served...)
+		}
+
+		for _, msg := range failed {
+			data, _ := json.Marshal(msg)
+			responses = append(responses, data)
+		}
+
+		writeJSON(w, responses)
+	})
+}
+
+func (c *Chaos) serve(next http.Handler, w http.ResponseWriter, r *http.Request, body []byte) {
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	next.ServeHTTP(w, r)
+}
+
+func newInjectedError(req *jsonrpcMessage) *jsonrpcMessage {
+	return &jsonrpcMessage{
+		Version: "2.0",
+		ID:      req.ID,
+		Error: &jsonrpcError{
+			Code:    InjectedErrorCode,
+			Message: InjectedErrorMessage,
+		},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
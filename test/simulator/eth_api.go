@@ -0,0 +1,158 @@
+package simulator
+
+import (
+	"strconv"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+	web3Types "github.com/openweb3/web3go/types"
+)
+
+var (
+	ethEmptyHash  = common.Hash{}
+	ethEmptyBloom = hexutil.Bytes(make([]byte, 256))
+)
+
+// ethBlock is the eSpace block in JSON-RPC format, which is converted from the core space pivot block.
+type ethBlock struct {
+	Number           *hexutil.Big   `json:"number"`
+	Hash             common.Hash    `json:"hash"`
+	ParentHash       common.Hash    `json:"parentHash"`
+	Nonce            hexutil.Bytes  `json:"nonce"`
+	Sha3Uncles       common.Hash    `json:"sha3Uncles"`
+	LogsBloom        hexutil.Bytes  `json:"logsBloom"`
+	TransactionsRoot common.Hash    `json:"transactionsRoot"`
+	StateRoot        common.Hash    `json:"stateRoot"`
+	ReceiptsRoot     common.Hash    `json:"receiptsRoot"`
+	Miner            common.Address `json:"miner"`
+	Difficulty       *hexutil.Big   `json:"difficulty"`
+	TotalDifficulty  *hexutil.Big   `json:"totalDifficulty"`
+	ExtraData        hexutil.Bytes  `json:"extraData"`
+	Size             *hexutil.Big   `json:"size"`
+	GasLimit         *hexutil.Big   `json:"gasLimit"`
+	GasUsed          *hexutil.Big   `json:"gasUsed"`
+	Timestamp        *hexutil.Big   `json:"timestamp"`
+	Transactions     []common.Hash  `json:"transactions"`
+	Uncles           []common.Hash  `json:"uncles"`
+	BaseFeePerGas    *hexutil.Big   `json:"baseFeePerGas"`
+	MixHash          common.Hash    `json:"mixHash"`
+}
+
+func newEthBlock(block *types.Block) *ethBlock {
+	return &ethBlock{
+		Number:           block.EpochNumber,
+		Hash:             common.HexToHash(block.Hash.String()),
+		ParentHash:       common.HexToHash(block.ParentHash.String()),
+		Nonce:            hexutil.Bytes(make([]byte, 8)),
+		Sha3Uncles:       ethEmptyHash,
+		LogsBloom:        ethEmptyBloom,
+		TransactionsRoot: ethEmptyHash,
+		StateRoot:        ethEmptyHash,
+		ReceiptsRoot:     ethEmptyHash,
+		Miner:            common.HexToAddress("0x1000000000000000000000000000000000000000"),
+		Difficulty:       block.Difficulty,
+		TotalDifficulty:  block.Difficulty,
+		ExtraData:        hexutil.Bytes{},
+		Size:             block.Size,
+		GasLimit:         block.GasLimit,
+		GasUsed:          block.GasUsed,
+		Timestamp:        block.Timestamp,
+		Transactions:     []common.Hash{},
+		Uncles:           []common.Hash{},
+		BaseFeePerGas:    types.NewBigInt(1),
+		MixHash:          ethEmptyHash,
+	}
+}
+
+// ethAPI the eSpace RPC methods, which shares the same simulated chain with core space.
+type ethAPI struct {
+	n *Node
+}
+
+func (api *ethAPI) ChainId() hexutil.Uint64 {
+	return hexutil.Uint64(api.n.conf.ChainId)
+}
+
+func (api *ethAPI) BlockNumber() *hexutil.Big {
+	return types.NewBigInt(api.n.LatestEpoch())
+}
+
+func (api *ethAPI) GetBlockByNumber(blockNum web3Types.BlockNumber, fullTx bool) (*ethBlock, error) {
+	api.n.mu.RLock()
+	defer api.n.mu.RUnlock()
+
+	latest := uint64(len(api.n.pivots) - 1)
+
+	switch {
+	case blockNum == web3Types.EarliestBlockNumber:
+		return newEthBlock(api.n.pivots[0]), nil
+	case blockNum < 0: // all the other block tags are regarded as the latest block
+		return newEthBlock(api.n.pivots[latest]), nil
+	case uint64(blockNum) > latest:
+		return nil, nil
+	default:
+		return newEthBlock(api.n.pivots[blockNum]), nil
+	}
+}
+
+func (api *ethAPI) GetBlockByHash(blockHash common.Hash, fullTx bool) (*ethBlock, error) {
+	api.n.mu.RLock()
+	defer api.n.mu.RUnlock()
+
+	block, ok := api.n.blocks[types.Hash(blockHash.Hex())]
+	if !ok {
+		return nil, nil
+	}
+
+	return newEthBlock(block), nil
+}
+
+func (api *ethAPI) GetLogs(fq web3Types.FilterQuery) ([]web3Types.Log, error) {
+	// blocks are always empty
+	return []web3Types.Log{}, nil
+}
+
+func (api *ethAPI) NewBlockFilter() rpc.ID {
+	return api.n.newFilter(filterTypeBlock)
+}
+
+func (api *ethAPI) NewPendingTransactionFilter() rpc.ID {
+	return api.n.newFilter(filterTypePendingTxn)
+}
+
+func (api *ethAPI) NewFilter(fq web3Types.FilterQuery) rpc.ID {
+	return api.n.newFilter(filterTypeLog)
+}
+
+func (api *ethAPI) UninstallFilter(id rpc.ID) bool {
+	return api.n.filters.uninstall(id)
+}
+
+func (api *ethAPI) GetFilterChanges(id rpc.ID) (interface{}, error) {
+	typ, hashes, err := api.n.filterChanges(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if typ == filterTypeLog {
+		return []web3Types.Log{}, nil
+	}
+
+	ethHashes := make([]common.Hash, 0, len(hashes))
+	for _, h := range hashes {
+		ethHashes = append(ethHashes, common.HexToHash(h.String()))
+	}
+
+	return ethHashes, nil
+}
+
+// netAPI the net RPC methods.
+type netAPI struct {
+	n *Node
+}
+
+func (api *netAPI) Version() string {
+	return strconv.FormatUint(uint64(api.n.conf.ChainId), 10)
+}
@@ -0,0 +1,121 @@
+package simulator
+
+import (
+	"sync"
+	"time"
+
+	"github.com/openweb3/go-rpc-provider"
+)
+
+type filterType int
+
+const (
+	filterTypeBlock filterType = iota
+	filterTypePendingTxn
+	filterTypeLog
+)
+
+// filter is a simulated filter installed on the full node.
+type filter struct {
+	typ      filterType
+	cursor   uint64    // next epoch to poll changes from
+	lastPoll time.Time // used for expiry
+}
+
+// filterManager manages the installed filters, which will be expired if not polled within TTL.
+type filterManager struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	filters map[rpc.ID]*filter
+}
+
+func newFilterManager(ttl time.Duration) *filterManager {
+	return &filterManager{
+		ttl:     ttl,
+		filters: make(map[rpc.ID]*filter),
+	}
+}
+
+func (fm *filterManager) install(typ filterType, cursor uint64) rpc.ID {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	id := rpc.NewID()
+	fm.filters[id] = &filter{typ: typ, cursor: cursor, lastPoll: time.Now()}
+
+	return id
+}
+
+func (fm *filterManager) uninstall(id rpc.ID) bool {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	if _, ok := fm.filters[id]; !ok {
+		return false
+	}
+
+	delete(fm.filters, id)
+	return true
+}
+
+// poll executes the callback with the specified filter, or returns error if filter not found or expired.
+func (fm *filterManager) poll(id rpc.ID, callback func(f *filter)) error {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	fm.gc()
+
+	f, ok := fm.filters[id]
+	if !ok {
+		return errFilterNotFound
+	}
+
+	f.lastPoll = time.Now()
+	callback(f)
+
+	return nil
+}
+
+// rewind moves back the cursor of all filters due to pivot chain switched since the specified epoch.
+func (fm *filterManager) rewind(epochFrom uint64) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	for _, f := range fm.filters {
+		if f.cursor > epochFrom {
+			f.cursor = epochFrom
+		}
+	}
+}
+
+func (fm *filterManager) clear() int {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	num := len(fm.filters)
+	fm.filters = make(map[rpc.ID]*filter)
+
+	return num
+}
+
+func (fm *filterManager) size() int {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	fm.gc()
+
+	return len(fm.filters)
+}
+
+// gc removes the expired filters, and requires the lock held by caller.
+func (fm *filterManager) gc() {
+	if fm.ttl == 0 {
+		return
+	}
+
+	for id, f := range fm.filters {
+		if time.Since(f.lastPoll) > fm.ttl {
+			delete(fm.filters, id)
+		}
+	}
+}
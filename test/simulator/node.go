@@ -0,0 +1,254 @@
+// Package simulator provides an in-memory fake full node, which serves both core space and eSpace
+// JSON-RPC over HTTP with scriptable behaviors, e.g. latency injection, pivot chain reorg, filter
+// expiry and partial failures, so that virtual filter, node manager and sync logic could be tested
+// without real full nodes.
+package simulator
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultNetworkId = 1234
+	DefaultChainId   = 1234
+)
+
+var (
+	errBlockNotFound     = errors.New("block not found")
+	errEpochNotFound     = errors.New("specified epoch number is greater than the latest epoch")
+	errPivotAssumption   = errors.New("pivot chain assumption failed")
+	errFilterNotFound    = errors.New("filter not found")
+	blockGasLimit        = types.NewBigInt(30_000_000)
+	blockGasUsed         = types.NewBigInt(0)
+	blockDifficulty      = types.NewBigInt(1)
+	blockTimestampOffset = uint64(1_600_000_000)
+)
+
+// Config is the configuration of the simulated full node.
+type Config struct {
+	NetworkId uint32        // core space network ID, `DefaultNetworkId` if not specified
+	ChainId   uint32        // chain ID for both core space and eSpace, `DefaultChainId` if not specified
+	FilterTTL time.Duration // filters not polled within TTL will be expired, never expire if zero
+}
+
+// Node simulates a full node in memory with one (empty) block per epoch, which is shared by both
+// core space and eSpace. Note, it is not a real blockchain, but only for test purpose.
+type Node struct {
+	mu      sync.RWMutex
+	conf    Config
+	miner   cfxaddress.Address
+	pivots  []*types.Block              // epoch number => pivot block
+	blocks  map[types.Hash]*types.Block // block hash => block, including reverted ones
+	forks   uint64                      // number of pivot chain switches to generate different block hashes
+	filters *filterManager              // installed filters
+	chaos   *Chaos                      // scriptable faults
+
+	server *httptest.Server
+}
+
+// NewNode creates a simulated full node with genesis block, and serves JSON-RPC over HTTP.
+func NewNode(conf Config) *Node {
+	if conf.NetworkId == 0 {
+		conf.NetworkId = DefaultNetworkId
+	}
+
+	if conf.ChainId == 0 {
+		conf.ChainId = DefaultChainId
+	}
+
+	node := &Node{
+		conf:    conf,
+		miner:   cfxaddress.MustNewFromHex("0x1000000000000000000000000000000000000000", conf.NetworkId),
+		blocks:  make(map[types.Hash]*types.Block),
+		filters: newFilterManager(conf.FilterTTL),
+		chaos:   newChaos(),
+	}
+	node.Mine(1) // genesis
+
+	handler := rpc.NewServer()
+	if err := handler.RegisterName("cfx", &cfxAPI{node}); err != nil {
+		panic(errors.WithMessage(err, "failed to register simulated cfx RPC service"))
+	}
+
+	if err := handler.RegisterName("eth", &ethAPI{node}); err != nil {
+		panic(errors.WithMessage(err, "failed to register simulated eth RPC service"))
+	}
+
+	if err := handler.RegisterName("net", &netAPI{node}); err != nil {
+		panic(errors.WithMessage(err, "failed to register simulated net RPC service"))
+	}
+
+	node.server = httptest.NewServer(node.chaos.wrap(handler))
+	return node
+}
+
+// URL returns the HTTP endpoint of the simulated full node.
+func (n *Node) URL() string {
+	return n.server.URL
+}
+
+// Close stops the simulated full node.
+func (n *Node) Close() {
+	n.server.Close()
+}
+
+// Chaos returns the scriptable faults of the simulated full node.
+func (n *Node) Chaos() *Chaos {
+	return n.chaos
+}
+
+// Mine appends the specified number of epochs on the pivot chain.
+func (n *Node) Mine(epochs int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for i := 0; i < epochs; i++ {
+		n.mineOne()
+	}
+}
+
+// Reorg switches the pivot chain since the specified epoch, and re-mines the same number of
+// epochs on the new pivot chain.
+func (n *Node) Reorg(epochFrom uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if epochFrom == 0 || epochFrom >= uint64(len(n.pivots)) {
+		return
+	}
+
+	reverted := uint64(len(n.pivots)) - epochFrom
+	n.pivots = n.pivots[:epochFrom]
+	n.forks++
+	n.filters.rewind(epochFrom)
+
+	for i := uint64(0); i < reverted; i++ {
+		n.mineOne()
+	}
+}
+
+// ExpireFilters removes all the installed filters as if they were expired on the full node, and
+// returns the number of removed filters.
+func (n *Node) ExpireFilters() int {
+	return n.filters.clear()
+}
+
+// NumFilters returns the number of installed filters that are not expired yet.
+func (n *Node) NumFilters() int {
+	return n.filters.size()
+}
+
+// LatestEpoch returns the latest epoch number on the pivot chain.
+func (n *Node) LatestEpoch() uint64 {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	return uint64(len(n.pivots) - 1)
+}
+
+// PivotBlock returns the pivot block of the specified epoch.
+func (n *Node) PivotBlock(epoch uint64) (*types.Block, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if epoch >= uint64(len(n.pivots)) {
+		return nil, false
+	}
+
+	return n.pivots[epoch], true
+}
+
+func (n *Node) mineOne() {
+	epoch := uint64(len(n.pivots))
+
+	var parentHash types.Hash
+	if epoch > 0 {
+		parentHash = n.pivots[epoch-1].Hash
+	}
+
+	seed := fmt.Sprintf("%v-%v-%v", n.conf.NetworkId, n.forks, epoch)
+	hash := types.Hash(crypto.Keccak256Hash([]byte(seed)).Hex())
+
+	block := &types.Block{
+		BlockHeader: types.BlockHeader{
+			Hash:          hash,
+			ParentHash:    parentHash,
+			Height:        types.NewBigInt(epoch),
+			Miner:         n.miner,
+			EpochNumber:   types.NewBigInt(epoch),
+			BlockNumber:   types.NewBigInt(epoch),
+			GasLimit:      blockGasLimit,
+			GasUsed:       blockGasUsed,
+			Timestamp:     types.NewBigInt(blockTimestampOffset + epoch),
+			Difficulty:    blockDifficulty,
+			PowQuality:    blockDifficulty,
+			RefereeHashes: []types.Hash{},
+			Nonce:         types.NewBigInt(0),
+			Size:          types.NewBigInt(0),
+		},
+		Transactions: []types.Transaction{},
+	}
+
+	n.pivots = append(n.pivots, block)
+	n.blocks[hash] = block
+}
+
+// newFilter installs a filter of the specified type, which polls changes since the next epoch.
+func (n *Node) newFilter(typ filterType) rpc.ID {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	return n.filters.install(typ, uint64(len(n.pivots)))
+}
+
+// filterChanges returns the pivot block hashes since last poll for block filter. Note, the simulated
+// blocks are always empty, so there are no changes for log and pending transaction filters.
+func (n *Node) filterChanges(id rpc.ID) (typ filterType, hashes []types.Hash, err error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	err = n.filters.poll(id, func(f *filter) {
+		typ = f.typ
+		if f.typ != filterTypeBlock {
+			return
+		}
+
+		for ; f.cursor < uint64(len(n.pivots)); f.cursor++ {
+			hashes = append(hashes, n.pivots[f.cursor].Hash)
+		}
+	})
+
+	return typ, hashes, err
+}
+
+func (n *Node) resolveEpoch(epoch *types.Epoch) (uint64, error) {
+	latest := uint64(len(n.pivots) - 1)
+
+	if epoch == nil {
+		return latest, nil
+	}
+
+	if epoch.Equals(types.EpochEarliest) {
+		return 0, nil
+	}
+
+	num, ok := epoch.ToInt()
+	if !ok { // all the other epoch tags are regarded as the latest epoch
+		return latest, nil
+	}
+
+	if num.Uint64() > latest {
+		return 0, errEpochNotFound
+	}
+
+	return num.Uint64(), nil
+}
@@ -0,0 +1,109 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustNewClient(t *testing.T, conf Config) (*Node, *sdk.Client) {
+	node := NewNode(conf)
+	t.Cleanup(node.Close)
+
+	cfx, err := sdk.NewClient(node.URL())
+	require.NoError(t, err)
+	t.Cleanup(func() { cfx.Close() })
+
+	return node, cfx
+}
+
+func TestNodeMineAndReorg(t *testing.T) {
+	node, cfx := mustNewClient(t, Config{})
+	node.Mine(10)
+
+	epoch, err := cfx.GetEpochNumber()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), epoch.ToInt().Uint64())
+
+	reverted, _ := node.PivotBlock(8)
+	node.Reorg(5)
+
+	pivot, _ := node.PivotBlock(8)
+	assert.NotEqual(t, reverted.Hash, pivot.Hash)
+	assert.Equal(t, uint64(10), node.LatestEpoch())
+}
+
+func TestNodeBlockFilter(t *testing.T) {
+	node, cfx := mustNewClient(t, Config{})
+
+	fid, err := cfx.Filter().NewBlockFilter()
+	require.NoError(t, err)
+
+	node.Mine(3)
+	changes, err := cfx.Filter().GetFilterChanges(*fid)
+	require.NoError(t, err)
+	assert.Len(t, changes.Hashes, 3)
+
+	// new pivot blocks will be polled again after reorg
+	node.Reorg(2)
+	changes, err = cfx.Filter().GetFilterChanges(*fid)
+	require.NoError(t, err)
+	assert.Len(t, changes.Hashes, 2)
+
+	assert.Equal(t, 1, node.ExpireFilters())
+	_, err = cfx.Filter().GetFilterChanges(*fid)
+	assert.Error(t, err)
+}
+
+func TestNodeFilterTTL(t *testing.T) {
+	node, cfx := mustNewClient(t, Config{FilterTTL: 50 * time.Millisecond})
+
+	_, err := cfx.Filter().NewBlockFilter()
+	require.NoError(t, err)
+	assert.Equal(t, 1, node.NumFilters())
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 0, node.NumFilters())
+}
+
+func TestChaosFailures(t *testing.T) {
+	node, cfx := mustNewClient(t, Config{})
+
+	node.Chaos().FailNext("cfx_epochNumber", 2)
+	for i := 0; i < 2; i++ {
+		_, err := cfx.GetEpochNumber()
+		assert.ErrorContains(t, err, InjectedErrorMessage)
+	}
+
+	_, err := cfx.GetEpochNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, node.Chaos().Calls("cfx_epochNumber"))
+
+	// other methods are not affected
+	node.Chaos().SetFailureRate("cfx_getStatus", 1)
+	_, err = cfx.GetStatus()
+	assert.Error(t, err)
+	_, err = cfx.GetEpochNumber()
+	assert.NoError(t, err)
+
+	node.Chaos().SetDown(true)
+	_, err = cfx.GetEpochNumber()
+	assert.Error(t, err)
+
+	node.Chaos().Reset()
+	_, err = cfx.GetStatus()
+	assert.NoError(t, err)
+}
+
+func TestChaosLatency(t *testing.T) {
+	node, cfx := mustNewClient(t, Config{})
+	node.Chaos().SetLatency(AnyMethod, 100*time.Millisecond)
+
+	start := time.Now()
+	_, err := cfx.GetEpochNumber()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
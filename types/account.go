@@ -0,0 +1,26 @@
+package types
+
+import (
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// AccountTxnsPaging specifies the epoch range and pagination to query account transactions.
+type AccountTxnsPaging struct {
+	// Epoch range to query transactions, which defaults to all the synchronized epochs if not specified.
+	FromEpoch *hexutil.Uint64 `json:"fromEpoch,omitempty"`
+	ToEpoch   *hexutil.Uint64 `json:"toEpoch,omitempty"`
+	// Number of transactions to skip from the beginning of the result set, which is capped to
+	// avoid deep offsets.
+	Skip *hexutil.Uint64 `json:"skip,omitempty"`
+	// Max number of transactions to return, which must be positive.
+	Limit *hexutil.Uint64 `json:"limit,omitempty"`
+}
+
+// AccountTransactions paginated transaction hashes sent from some account.
+type AccountTransactions struct {
+	Hashes []types.Hash `json:"hashes"`
+	// Number of transactions to skip for the next page, or nil if there is no more page or the
+	// maximum skip reached.
+	NextSkip *hexutil.Uint64 `json:"nextSkip,omitempty"`
+}
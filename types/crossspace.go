@@ -0,0 +1,157 @@
+package types
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// CrossSpaceCallContract is the hex address of core space internal contract `CrossSpaceCall`.
+	CrossSpaceCallContract = "0x0888000000000000000000000000000000000006"
+
+	CrossSpaceCallTypeCall     = "call"
+	CrossSpaceCallTypeCreate   = "create"
+	CrossSpaceCallTypeWithdraw = "withdraw"
+)
+
+var (
+	crossSpaceTopicCall     = crypto.Keccak256Hash([]byte("Call(bytes20,bytes20,uint256,uint256,bytes)"))
+	crossSpaceTopicCreate   = crypto.Keccak256Hash([]byte("Create(bytes20,bytes20,uint256,uint256,bytes)"))
+	crossSpaceTopicWithdraw = crypto.Keccak256Hash([]byte("Withdraw(bytes20,address,uint256,uint256)"))
+	crossSpaceTopicOutcome  = crypto.Keccak256Hash([]byte("Outcome(bool)"))
+
+	abiTypeUint256, _ = abi.NewType("uint256", "", nil)
+	abiTypeBytes, _   = abi.NewType("bytes", "", nil)
+	abiTypeBool, _    = abi.NewType("bool", "", nil)
+
+	// non-indexed arguments of `Call` and `Create` events: value, nonce and data
+	crossSpaceCallArgs = abi.Arguments{{Type: abiTypeUint256}, {Type: abiTypeUint256}, {Type: abiTypeBytes}}
+	// non-indexed arguments of `Withdraw` event: value and nonce
+	crossSpaceWithdrawArgs = abi.Arguments{{Type: abiTypeUint256}, {Type: abiTypeUint256}}
+	// non-indexed arguments of `Outcome` event: success
+	crossSpaceOutcomeArgs = abi.Arguments{{Type: abiTypeBool}}
+)
+
+// CrossSpaceCall is the cross space call between core space and eSpace, which is decoded from
+// event logs of core space internal contract `CrossSpaceCall`.
+type CrossSpaceCall struct {
+	// Type of cross space call, `call`, `create` or `withdraw`.
+	Type string `json:"type"`
+	// Core space address, which is the sender for `call` and `create`, or receiver for `withdraw`.
+	CoreAddress cfxaddress.Address `json:"coreAddress"`
+	// eSpace address, which is the receiver for `call` and `create`, or sender for `withdraw`.
+	EvmAddress common.Address `json:"evmAddress"`
+	Value      *hexutil.Big   `json:"value"`
+	Nonce      *hexutil.Big   `json:"nonce"`
+	Data       hexutil.Bytes  `json:"data,omitempty"`
+	// Outcome of the cross space call, nil if outcome event not found.
+	Success *bool `json:"success,omitempty"`
+
+	EpochNumber     *hexutil.Big `json:"epochNumber,omitempty"`
+	BlockHash       *types.Hash  `json:"blockHash,omitempty"`
+	TransactionHash *types.Hash  `json:"transactionHash,omitempty"`
+	LogIndex        *hexutil.Big `json:"logIndex,omitempty"`
+}
+
+// IsCrossSpaceCallLog checks if the event log is emitted by internal contract `CrossSpaceCall`.
+func IsCrossSpaceCallLog(log *types.Log) bool {
+	return strings.EqualFold(log.Address.GetHexAddress(), CrossSpaceCallContract)
+}
+
+// DecodeCrossSpaceCalls decodes cross space calls from the event logs in order, in which the
+// `Outcome` event will be attached to the preceding cross space call of the same transaction.
+func DecodeCrossSpaceCalls(logs []types.Log) []*CrossSpaceCall {
+	var calls []*CrossSpaceCall
+	var pending *CrossSpaceCall // cross space call which awaits outcome
+
+	for i := range logs {
+		log := &logs[i]
+		if !IsCrossSpaceCallLog(log) || len(log.Topics) == 0 {
+			continue
+		}
+
+		if *log.Topics[0].ToCommonHash() == crossSpaceTopicOutcome {
+			if pending != nil && sameTxHash(pending.TransactionHash, log.TransactionHash) {
+				if values, err := crossSpaceOutcomeArgs.Unpack(log.Data); err == nil {
+					success := values[0].(bool)
+					pending.Success = &success
+				}
+			}
+
+			pending = nil
+			continue
+		}
+
+		if call, ok := decodeCrossSpaceCall(log); ok {
+			calls = append(calls, call)
+			pending = call
+		}
+	}
+
+	return calls
+}
+
+func decodeCrossSpaceCall(log *types.Log) (*CrossSpaceCall, bool) {
+	if len(log.Topics) != 3 {
+		return nil, false
+	}
+
+	topic0 := *log.Topics[0].ToCommonHash()
+	topic1, topic2 := *log.Topics[1].ToCommonHash(), *log.Topics[2].ToCommonHash()
+
+	call := CrossSpaceCall{
+		EpochNumber:     log.EpochNumber,
+		BlockHash:       log.BlockHash,
+		TransactionHash: log.TransactionHash,
+		LogIndex:        log.LogIndex,
+	}
+
+	var coreAddr []byte
+	var values []interface{}
+	var err error
+
+	switch topic0 {
+	case crossSpaceTopicCall, crossSpaceTopicCreate:
+		call.Type = CrossSpaceCallTypeCall
+		if topic0 == crossSpaceTopicCreate {
+			call.Type = CrossSpaceCallTypeCreate
+		}
+
+		// indexed `bytes20` is left aligned
+		coreAddr, call.EvmAddress = topic1[:20], common.BytesToAddress(topic2[:20])
+		if values, err = crossSpaceCallArgs.Unpack(log.Data); err == nil {
+			call.Data = values[2].([]byte)
+		}
+	case crossSpaceTopicWithdraw:
+		call.Type = CrossSpaceCallTypeWithdraw
+		// indexed `address` is right aligned
+		coreAddr, call.EvmAddress = topic2[12:], common.BytesToAddress(topic1[:20])
+		values, err = crossSpaceWithdrawArgs.Unpack(log.Data)
+	default:
+		return nil, false
+	}
+
+	if err != nil {
+		return nil, false
+	}
+
+	if call.CoreAddress, err = cfxaddress.NewFromBytes(coreAddr, log.Address.GetNetworkID()); err != nil {
+		return nil, false
+	}
+
+	call.Value = (*hexutil.Big)(values[0].(*big.Int))
+	call.Nonce = (*hexutil.Big)(values[1].(*big.Int))
+
+	return &call, true
+}
+
+func sameTxHash(h1, h2 *types.Hash) bool {
+	return h1 == nil || h2 == nil || *h1 == *h2
+}
@@ -0,0 +1,53 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeCrossSpaceCalls(t *testing.T) {
+	contract := cfxaddress.MustNewFromHex(CrossSpaceCallContract, 1029)
+	coreAddr := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	evmAddr := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	txHash := types.Hash(common.HexToHash("0x01").Hex())
+
+	data, err := crossSpaceCallArgs.Pack(big.NewInt(100), big.NewInt(1), []byte{0xab})
+	assert.NoError(t, err)
+
+	outcome, err := crossSpaceOutcomeArgs.Pack(true)
+	assert.NoError(t, err)
+
+	logs := []types.Log{
+		{
+			Address: contract,
+			Topics: []types.Hash{
+				types.Hash(crossSpaceTopicCall.Hex()),
+				types.Hash(common.BytesToHash(common.RightPadBytes(coreAddr.Bytes(), 32)).Hex()),
+				types.Hash(common.BytesToHash(common.RightPadBytes(evmAddr.Bytes(), 32)).Hex()),
+			},
+			Data:            data,
+			TransactionHash: &txHash,
+		},
+		{
+			Address:         contract,
+			Topics:          []types.Hash{types.Hash(crossSpaceTopicOutcome.Hex())},
+			Data:            outcome,
+			TransactionHash: &txHash,
+		},
+	}
+
+	calls := DecodeCrossSpaceCalls(logs)
+	assert.Len(t, calls, 1)
+	assert.Equal(t, CrossSpaceCallTypeCall, calls[0].Type)
+	assert.Equal(t, coreAddr, calls[0].CoreAddress.MustGetCommonAddress())
+	assert.Equal(t, evmAddr, calls[0].EvmAddress)
+	assert.Equal(t, int64(100), calls[0].Value.ToInt().Int64())
+	assert.Equal(t, int64(1), calls[0].Nonce.ToInt().Int64())
+	assert.Equal(t, []byte{0xab}, []byte(calls[0].Data))
+	assert.True(t, *calls[0].Success)
+}
@@ -0,0 +1,51 @@
+package types
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/web3go/types"
+)
+
+// BlockFilterOption confura extension parameter to negotiate the encoding of block filter changes.
+type BlockFilterOption struct {
+	// Whether to return block header summaries rather than only block hashes for filter changes.
+	IncludeHeaders bool `json:"includeHeaders,omitempty"`
+}
+
+// FilterExtMode confura extension mode of block or pending transaction filter, which is persisted
+// along with the virtual filter so as to be shared among all the RPC proxy instances.
+type FilterExtMode string
+
+const (
+	FilterExtModeNone         FilterExtMode = ""             // standard filter changes
+	FilterExtModeBlockHeaders FilterExtMode = "blockHeaders" // block header summaries of block filter
+	FilterExtModeFullTx       FilterExtMode = "fullTx"       // full transactions of pending transaction filter
+)
+
+// BlockHeaderSummary summary of block header returned for block filter changes in extended mode.
+type BlockHeaderSummary struct {
+	Hash          common.Hash    `json:"hash"`
+	ParentHash    common.Hash    `json:"parentHash"`
+	Number        *hexutil.Big   `json:"number"`
+	Timestamp     hexutil.Uint64 `json:"timestamp"`
+	Miner         common.Address `json:"miner"`
+	GasLimit      hexutil.Uint64 `json:"gasLimit"`
+	GasUsed       hexutil.Uint64 `json:"gasUsed"`
+	BaseFeePerGas *hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	TxCount       hexutil.Uint64 `json:"transactionCount"`
+}
+
+// NewBlockHeaderSummary creates header summary from the specified block.
+func NewBlockHeaderSummary(block *types.Block) *BlockHeaderSummary {
+	return &BlockHeaderSummary{
+		Hash:          block.Hash,
+		ParentHash:    block.ParentHash,
+		Number:        (*hexutil.Big)(block.Number),
+		Timestamp:     hexutil.Uint64(block.Timestamp),
+		Miner:         block.Miner,
+		GasLimit:      hexutil.Uint64(block.GasLimit),
+		GasUsed:       hexutil.Uint64(block.GasUsed),
+		BaseFeePerGas: (*hexutil.Big)(block.BaseFeePerGas),
+		TxCount:       hexutil.Uint64(len(block.Transactions.Hashes())),
+	}
+}
@@ -0,0 +1,128 @@
+package acl
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Conflux-Chain/confura/util"
+)
+
+// MethodRules is the allow and deny lists of RPC methods, which support wildcard pattern
+// (eg., `debug_*`).
+type MethodRules struct {
+	// allowed methods, all methods allowed if empty
+	Allow []string
+	// denied methods, which take precedence over the allowed methods
+	Deny []string
+}
+
+// MethodAclConfig represents the configuration of method-level access control for RPC server.
+type MethodAclConfig struct {
+	// method rules for all listeners of the RPC server
+	MethodRules `mapstructure:",squash"`
+	// method rules per listener (`http`, `ws`, `unix` or `inproc`)
+	Listeners map[string]MethodRules
+	// method rules per API key tier (rate limit strategy name, eg., `default` for anonymous users)
+	Tiers map[string]MethodRules
+}
+
+// MethodNotAllowedError is returned if RPC method not allowed for the listener or API key tier.
+type MethodNotAllowedError struct {
+	Method   string `json:"method"`
+	Listener string `json:"listener,omitempty"` // empty if not rejected by listener rules
+	Tier     string `json:"tier,omitempty"`     // empty if not rejected by tier rules
+}
+
+func (e *MethodNotAllowedError) Error() string {
+	switch {
+	case len(e.Listener) > 0:
+		return "method " + e.Method + " not allowed for listener " + e.Listener
+	case len(e.Tier) > 0:
+		return "method " + e.Method + " not allowed for tier " + e.Tier
+	default:
+		return "method " + e.Method + " not allowed"
+	}
+}
+
+type methodMatcher struct {
+	allow []*regexp.Regexp
+	deny  []*regexp.Regexp
+}
+
+func newMethodMatcher(rules MethodRules) *methodMatcher {
+	return &methodMatcher{
+		allow: compileWildcards(rules.Allow),
+		deny:  compileWildcards(rules.Deny),
+	}
+}
+
+func compileWildcards(patterns []string) (res []*regexp.Regexp) {
+	for _, p := range patterns {
+		res = append(res, regexp.MustCompile(util.WildCardToRegexp(p)))
+	}
+
+	return res
+}
+
+func (m *methodMatcher) allowed(method string) bool {
+	for _, r := range m.deny {
+		if r.MatchString(method) {
+			return false
+		}
+	}
+
+	if len(m.allow) == 0 {
+		return true
+	}
+
+	for _, r := range m.allow {
+		if r.MatchString(method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MethodAcl enforces method-level access control for RPC server, listeners and API key tiers.
+type MethodAcl struct {
+	server    *methodMatcher
+	listeners map[string]*methodMatcher
+	tiers     map[string]*methodMatcher
+}
+
+func NewMethodAcl(conf MethodAclConfig) *MethodAcl {
+	acl := &MethodAcl{
+		server:    newMethodMatcher(conf.MethodRules),
+		listeners: make(map[string]*methodMatcher),
+		tiers:     make(map[string]*methodMatcher),
+	}
+
+	for listener, rules := range conf.Listeners {
+		acl.listeners[strings.ToLower(listener)] = newMethodMatcher(rules)
+	}
+
+	for tier, rules := range conf.Tiers {
+		acl.tiers[tier] = newMethodMatcher(rules)
+	}
+
+	return acl
+}
+
+// Check checks if the RPC method is allowed by the server rules, listener rules and then the API
+// key tier rules.
+func (acl *MethodAcl) Check(method, listener, tier string) error {
+	if !acl.server.allowed(method) {
+		return &MethodNotAllowedError{Method: method}
+	}
+
+	if m, ok := acl.listeners[listener]; ok && !m.allowed(method) {
+		return &MethodNotAllowedError{Method: method, Listener: listener}
+	}
+
+	if m, ok := acl.tiers[tier]; ok && !m.allowed(method) {
+		return &MethodNotAllowedError{Method: method, Tier: tier}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package acl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodAcl(t *testing.T) {
+	acl := NewMethodAcl(MethodAclConfig{
+		MethodRules: MethodRules{Deny: []string{"debug_*"}},
+		Listeners: map[string]MethodRules{
+			"ws": {Deny: []string{"trace_filter"}},
+		},
+		Tiers: map[string]MethodRules{
+			"default": {Deny: []string{"trace_*", "txpool_*"}},
+			"vip1":    {Allow: []string{"eth_*", "trace_block"}},
+		},
+	})
+
+	// denied by server
+	err := acl.Check("debug_traceTransaction", "http", "vip1")
+	assert.Equal(t, &MethodNotAllowedError{Method: "debug_traceTransaction"}, err)
+
+	// denied by listener
+	err = acl.Check("trace_filter", "ws", "vip2")
+	assert.Equal(t, &MethodNotAllowedError{Method: "trace_filter", Listener: "ws"}, err)
+	assert.NoError(t, acl.Check("trace_filter", "http", "vip2"))
+
+	// denied by tier
+	err = acl.Check("trace_block", "http", "default")
+	assert.Equal(t, &MethodNotAllowedError{Method: "trace_block", Tier: "default"}, err)
+	assert.NoError(t, acl.Check("eth_call", "http", "default"))
+
+	// allowed by tier
+	assert.NoError(t, acl.Check("trace_block", "http", "vip1"))
+	assert.Error(t, acl.Check("trace_filter", "http", "vip1"))
+
+	// no rules for tier
+	assert.NoError(t, acl.Check("txpool_status", "unix", "vip2"))
+}
@@ -0,0 +1,146 @@
+package acl
+
+import (
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrIpForbidden     = errors.New("source IP not allowed for the API key")
+	ErrOriginForbidden = errors.New("request origin not allowed for the API key")
+)
+
+// KeyRestriction restricts the source IPs and HTTP origins that an API key could be used from, so
+// that keys leaked into frontend code can't be abused from arbitrary origins.
+type KeyRestriction struct {
+	allowedNets    []*net.IPNet
+	deniedNets     []*net.IPNet
+	allowedOrigins []*regexp.Regexp
+
+	// deny all requests due to malformed restriction
+	denyAll bool
+}
+
+// NewKeyRestriction creates key restriction from comma separated lists of IPs or CIDR ranges, and
+// origins which support wildcard subdomain patterns, e.g., `https://*.example.com`.
+//
+// Note, the returned restriction denies all requests if any rule is malformed along with error,
+// so that misconfigured keys fail closed.
+func NewKeyRestriction(allowedIps, deniedIps, allowedOrigins string) (*KeyRestriction, error) {
+	r := &KeyRestriction{}
+
+	var err error
+	if r.allowedNets, err = ParseIpNets(allowedIps); err != nil {
+		return &KeyRestriction{denyAll: true}, errors.WithMessage(err, "invalid allowed IPs")
+	}
+
+	if r.deniedNets, err = ParseIpNets(deniedIps); err != nil {
+		return &KeyRestriction{denyAll: true}, errors.WithMessage(err, "invalid denied IPs")
+	}
+
+	for _, origin := range splitRules(allowedOrigins) {
+		regp, err := regexp.Compile(util.WildCardToRegexp(strings.ToLower(origin)))
+		if err != nil {
+			return &KeyRestriction{denyAll: true}, errors.WithMessagef(err, "invalid allowed origin %v", origin)
+		}
+
+		r.allowedOrigins = append(r.allowedOrigins, regp)
+	}
+
+	return r, nil
+}
+
+// IsEmpty checks if no restriction rule specified.
+func (r *KeyRestriction) IsEmpty() bool {
+	return !r.denyAll && len(r.allowedNets) == 0 && len(r.deniedNets) == 0 && len(r.allowedOrigins) == 0
+}
+
+// Check checks if the request from the source IP and origin is allowed, and denied IPs take
+// precedence over allowed ones. Requests without origin are rejected if allowed origins specified.
+func (r *KeyRestriction) Check(ip, origin string) error {
+	if r.denyAll {
+		return ErrIpForbidden
+	}
+
+	if len(r.allowedNets) > 0 || len(r.deniedNets) > 0 {
+		srcIp := net.ParseIP(ip)
+		if srcIp == nil || containsIp(r.deniedNets, srcIp) {
+			return ErrIpForbidden
+		}
+
+		if len(r.allowedNets) > 0 && !containsIp(r.allowedNets, srcIp) {
+			return ErrIpForbidden
+		}
+	}
+
+	if len(r.allowedOrigins) > 0 {
+		origin = strings.ToLower(origin)
+
+		for _, regp := range r.allowedOrigins {
+			if regp.MatchString(origin) {
+				return nil
+			}
+		}
+
+		return ErrOriginForbidden
+	}
+
+	return nil
+}
+
+func containsIp(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseIpNets parses comma separated IPs or CIDR ranges.
+func ParseIpNets(rules string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+
+	for _, rule := range splitRules(rules) {
+		if !strings.Contains(rule, "/") { // single IP
+			ip := net.ParseIP(rule)
+			if ip == nil {
+				return nil, errors.Errorf("invalid IP %v", rule)
+			}
+
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(rule)
+		if err != nil {
+			return nil, errors.Errorf("invalid CIDR %v", rule)
+		}
+
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
+
+func splitRules(rules string) []string {
+	var result []string
+
+	for _, rule := range strings.Split(rules, ",") {
+		if rule = strings.TrimSpace(rule); len(rule) > 0 {
+			result = append(result, rule)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,50 @@
+package acl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRestrictionIp(t *testing.T) {
+	r, err := NewKeyRestriction("10.0.0.0/8, 192.168.1.1, 2001:db8::/32", "10.1.0.0/16", "")
+	assert.NoError(t, err)
+
+	assert.NoError(t, r.Check("10.0.0.1", ""))
+	assert.NoError(t, r.Check("192.168.1.1", ""))
+	assert.NoError(t, r.Check("2001:db8::1", ""))
+
+	// denied takes precedence
+	assert.Equal(t, ErrIpForbidden, r.Check("10.1.2.3", ""))
+
+	// not allowed
+	assert.Equal(t, ErrIpForbidden, r.Check("192.168.1.2", ""))
+	assert.Equal(t, ErrIpForbidden, r.Check("invalid", ""))
+
+	// deny list only
+	r, err = NewKeyRestriction("", "1.2.3.4", "")
+	assert.NoError(t, err)
+	assert.NoError(t, r.Check("1.2.3.5", ""))
+	assert.Equal(t, ErrIpForbidden, r.Check("1.2.3.4", ""))
+}
+
+func TestKeyRestrictionOrigin(t *testing.T) {
+	r, err := NewKeyRestriction("", "", "https://*.example.com,https://app.io")
+	assert.NoError(t, err)
+
+	assert.NoError(t, r.Check("1.2.3.4", "https://www.Example.com"))
+	assert.NoError(t, r.Check("1.2.3.4", "https://app.io"))
+	assert.Equal(t, ErrOriginForbidden, r.Check("1.2.3.4", "https://evil.io"))
+	assert.Equal(t, ErrOriginForbidden, r.Check("1.2.3.4", ""))
+}
+
+func TestKeyRestrictionMalformed(t *testing.T) {
+	r, err := NewKeyRestriction("10.0.0.0/33", "", "")
+	assert.Error(t, err)
+	assert.False(t, r.IsEmpty())
+	assert.Equal(t, ErrIpForbidden, r.Check("10.0.0.1", ""))
+
+	r, err = NewKeyRestriction(" , ", "", "")
+	assert.NoError(t, err)
+	assert.True(t, r.IsEmpty())
+}
@@ -43,6 +43,11 @@ func MustInit() {
 	}
 }
 
+// Enabled checks if any contract address blacklisted.
+func Enabled() bool {
+	return len(blacklistedAddressSet) > 0
+}
+
 // Check if address blacklisted or not for specific epoch height.
 func IsAddressBlacklisted(addr *cfxaddress.Address, epochs ...uint64) bool {
 	if len(blacklistedAddressSet) == 0 {
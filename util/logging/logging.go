@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	FormatText = "text"
+	FormatJson = "json"
+
+	// HTTP header to pass request ID, which is also forwarded to full nodes.
+	HeaderRequestId = "X-Request-Id"
+
+	// log field of request ID
+	FieldRequestId = "reqId"
+
+	// max length of request ID provided by clients
+	maxRequestIdLen = 64
+)
+
+type requestIdCtxKey struct{}
+
+// MustInitFromViper sets up the log output format, which should be called after logging initialized.
+func MustInitFromViper() {
+	var conf struct {
+		// available formats are `text` and `json`
+		Format string `default:"text"`
+		// whether to report caller (function and file) for each log
+		ReportCaller bool
+	}
+
+	viper.MustUnmarshalKey("log", &conf)
+
+	switch strings.ToLower(conf.Format) {
+	case FormatText:
+	case FormatJson:
+		logrus.SetFormatter(&logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano})
+	default:
+		logrus.WithField("format", conf.Format).Fatal("Unsupported log format")
+	}
+
+	logrus.SetReportCaller(conf.ReportCaller)
+}
+
+// NewRequestId generates a random request ID.
+func NewRequestId() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// SanitizeRequestId returns the request ID provided by client if valid.
+func SanitizeRequestId(id string) (string, bool) {
+	if len(id) == 0 || len(id) > maxRequestIdLen {
+		return "", false
+	}
+
+	for _, c := range id {
+		if c != '-' && c != '_' && c != '.' && c != ':' &&
+			(c < '0' || c > '9') && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return "", false
+		}
+	}
+
+	return id, true
+}
+
+// NewContext returns a new context with the request ID, which will be logged along with any
+// logger created by `FromContext`.
+func NewContext(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdCtxKey{}, requestId)
+}
+
+// RequestIdFromContext returns the request ID in context if any.
+func RequestIdFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+
+	id, ok := ctx.Value(requestIdCtxKey{}).(string)
+	return id, ok
+}
+
+// FromContext returns a logger with request ID in context if any, so that logs of different
+// components for the same request could be correlated.
+func FromContext(ctx context.Context) *logrus.Entry {
+	if id, ok := RequestIdFromContext(ctx); ok {
+		return logrus.WithField(FieldRequestId, id)
+	}
+
+	return logrus.NewEntry(logrus.StandardLogger())
+}
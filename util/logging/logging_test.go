@@ -0,0 +1,29 @@
+package logging
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeRequestId(t *testing.T) {
+	for _, id := range []string{"abc", "0123-4567_89.ab:cd", strings.Repeat("a", maxRequestIdLen)} {
+		v, ok := SanitizeRequestId(id)
+		assert.True(t, ok)
+		assert.Equal(t, id, v)
+	}
+
+	for _, id := range []string{"", "a b", "a\nb", `a"b`, strings.Repeat("a", maxRequestIdLen+1)} {
+		_, ok := SanitizeRequestId(id)
+		assert.False(t, ok)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	assert.NotContains(t, FromContext(context.Background()).Data, FieldRequestId)
+
+	ctx := NewContext(context.Background(), "req1")
+	assert.Equal(t, "req1", FromContext(ctx).Data[FieldRequestId])
+}
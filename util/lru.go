@@ -93,6 +93,14 @@ func (c *ExpirableLruCache) GetOrUpdate(key interface{}, updateFunc func() (inte
 	return v, nil
 }
 
+// Del removes the provided key from the cache. Returns true if the key was contained.
+func (c *ExpirableLruCache) Del(key interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Remove(key)
+}
+
 // GetWithoutExp looks up a key's value from the cache without expiration action.
 func (c *ExpirableLruCache) GetWithoutExp(key interface{}) (v interface{}, expired, found bool) {
 	return c.get(key)
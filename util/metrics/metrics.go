@@ -1,10 +1,15 @@
 package metrics
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/slo"
 	metricUtil "github.com/Conflux-Chain/go-conflux-util/metrics"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/openweb3/go-rpc-provider/utils"
@@ -61,12 +66,86 @@ func (*RpcMetrics) UpdateDuration(space, method string, err error, start time.Ti
 		metricUtil.GetOrRegisterTimer("infura/rpc/duration/all/%v", space).UpdateSince(start)
 		metricUtil.GetOrRegisterTimer("infura/rpc/duration/%v/%v", space, method).UpdateSince(start)
 	}
+
+	// Track SLO against the same success criteria, where RPC errors are caused by clients.
+	slo.Observe(method, isNilErr || isRpcErr, time.Since(start))
 }
 
 func (*RpcMetrics) ResponseSize(space, method string) metrics.Histogram {
 	return metricUtil.GetOrRegisterHistogram("infura/rpc/response/size/%v/%v", space, method)
 }
 
+// CompressionSavedBytes is the number of response bytes saved by compression of RPC server.
+func (*RpcMetrics) CompressionSavedBytes(server, encoding string) metrics.Meter {
+	return metricUtil.GetOrRegisterMeter("infura/rpc/compression/%v/%v/saved", server, encoding)
+}
+
+// CompressionRatio is the percentage of compressed size against the original response size.
+func (*RpcMetrics) CompressionRatio(server, encoding string) metrics.Histogram {
+	return metricUtil.GetOrRegisterHistogram("infura/rpc/compression/%v/%v/ratio", server, encoding)
+}
+
+// TieredRateLimited is the percentage of requests rejected by distributed rate limit of tier.
+func (*RpcMetrics) TieredRateLimited(space, tier string) metricUtil.Percentage {
+	return metricUtil.GetOrRegisterTimeWindowPercentageDefault(0, "infura/rpc/ratelimit/%v/%v/limited", space, tier)
+}
+
+// RPC metrics - API key usage
+
+// max number of API key and method pairs to collect metrics, beyond which methods of new pairs
+// are merged as `others` to bound the cardinality of metrics
+const maxApiKeyMethodSeries = 10000
+
+var (
+	apiKeyMethodSeries    sync.Map // API key ID and method pair => struct{}
+	numApiKeyMethodSeries atomic.Int64
+)
+
+// ApiKeyId returns the truncated hash of API key to label metrics, so that API key, which is
+// a credential, won't be leaked to metrics backends.
+func ApiKeyId(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:8])
+}
+
+// apiKeyMethod returns the method to label metrics of API key, or `others` if too many
+// API key and method pairs collected.
+func apiKeyMethod(keyId, method string) string {
+	series := keyId + "/" + method
+	if _, ok := apiKeyMethodSeries.Load(series); ok {
+		return method
+	}
+
+	if numApiKeyMethodSeries.Add(1) > maxApiKeyMethodSeries {
+		numApiKeyMethodSeries.Add(-1)
+		return "others"
+	}
+
+	if _, loaded := apiKeyMethodSeries.LoadOrStore(series, struct{}{}); loaded {
+		numApiKeyMethodSeries.Add(-1)
+	}
+
+	return method
+}
+
+// ApiKeyRequests is the QPS and latency of RPC requests authenticated by API key.
+func (*RpcMetrics) ApiKeyRequests(space, key string) metrics.Timer {
+	return metricUtil.GetOrRegisterTimer("infura/rpc/apikey/%v/%v/requests", space, ApiKeyId(key))
+}
+
+// ApiKeyMethodRequests is the rate of RPC requests per method authenticated by API key.
+func (*RpcMetrics) ApiKeyMethodRequests(space, key, method string) metrics.Meter {
+	keyId := ApiKeyId(key)
+	return metricUtil.GetOrRegisterMeter(
+		"infura/rpc/apikey/%v/%v/method/%v", space, keyId, apiKeyMethod(keyId, method),
+	)
+}
+
+// ApiKeyErrorRate is the error rate of RPC requests authenticated by API key.
+func (*RpcMetrics) ApiKeyErrorRate(space, key string) metricUtil.Percentage {
+	return metricUtil.GetOrRegisterTimeWindowPercentageDefault(0, "infura/rpc/apikey/%v/%v/rate/error", space, ApiKeyId(key))
+}
+
 // RPC metrics - inputs
 
 func (*RpcMetrics) InputEpoch(method, epoch string) metricUtil.Percentage {
@@ -95,8 +174,42 @@ func (*RpcMetrics) LogFilterSplit(method, name string) metrics.Histogram {
 	return metricUtil.GetOrRegisterHistogram("infura/rpc/handler/%v/filter/split/%v", method, name)
 }
 
+// LogQueryCost is the estimated cost of getLogs query planned by cost.
+func (*RpcMetrics) LogQueryCost(method string) metrics.Histogram {
+	return metricUtil.GetOrRegisterHistogram("infura/rpc/handler/%v/planner/cost", method)
+}
+
+// Timeouts is the number of requests timed out per RPC method.
+func (*RpcMetrics) Timeouts(space, method string) metrics.Meter {
+	return metricUtil.GetOrRegisterMeter("infura/rpc/timeout/%v/%v", space, method)
+}
+
+// Cancellations is the number of requests canceled by client (e.g. disconnected) per RPC method.
+func (*RpcMetrics) Cancellations(space, method string) metrics.Meter {
+	return metricUtil.GetOrRegisterMeter("infura/rpc/cancel/%v/%v", space, method)
+}
+
+// PayloadRejected is the number of requests rejected due to payload limits or malformed envelope.
+func (*RpcMetrics) PayloadRejected(space, reason string) metrics.Meter {
+	return metricUtil.GetOrRegisterMeter("infura/rpc/payload/rejected/%v/%v", space, reason)
+}
+
 // PRC metrics - percentages
 
+func (*RpcMetrics) HedgedRequests(method string) metrics.Meter {
+	return metricUtil.GetOrRegisterMeter("infura/rpc/hedge/requests/%v", method)
+}
+
+// ResponseCacheHit is the hit rate of immutable RPC responses cache.
+func (*RpcMetrics) ResponseCacheHit(space, method string) metricUtil.Percentage {
+	return metricUtil.GetOrRegisterTimeWindowPercentageDefault(0, "infura/rpc/responseCache/%v/hit/%v", space, method)
+}
+
+// HedgedWins is the percentage of hedged requests that responded earlier than the primary ones.
+func (*RpcMetrics) HedgedWins(method string) metricUtil.Percentage {
+	return metricUtil.GetOrRegisterTimeWindowPercentageDefault(0, "infura/rpc/hedge/wins/%v", method)
+}
+
 func (*RpcMetrics) Percentage(method, name string) metricUtil.Percentage {
 	return metricUtil.GetOrRegisterTimeWindowPercentageDefault(0, "infura/rpc/percentage/%v/%v", method, name)
 }
@@ -126,6 +239,32 @@ func (*RpcMetrics) FullnodeErrorRate(node ...string) metricUtil.Percentage {
 	return metricUtil.GetOrRegisterTimeWindowPercentageDefault(0, "infura/rpc/fullnode/rate/error/%v", node[0])
 }
 
+// FullnodeQueueDepth is the number of requests queued for in-flight slots of full node.
+func (*RpcMetrics) FullnodeQueueDepth(space, node string) metrics.Gauge {
+	return metricUtil.GetOrRegisterGauge("infura/rpc/fullnode/%v/%v/queue/depth", node, space)
+}
+
+// FullnodeShed is the rate of requests shed due to too many requests queued for full node.
+func (*RpcMetrics) FullnodeShed(space, node string) metrics.Meter {
+	return metricUtil.GetOrRegisterMeter("infura/rpc/fullnode/%v/%v/queue/shed", node, space)
+}
+
+// FullnodeSyncThrottled is the rate of sync requests backed off due to full node overloaded.
+func (*RpcMetrics) FullnodeSyncThrottled(space, node string) metrics.Meter {
+	return metricUtil.GetOrRegisterMeter("infura/rpc/fullnode/%v/%v/budget/throttled", node, space)
+}
+
+// FullnodeSyncDelay is the back-off delay in milliseconds for sync requests to full node.
+func (*RpcMetrics) FullnodeSyncDelay(space, node string) metrics.Gauge {
+	return metricUtil.GetOrRegisterGauge("infura/rpc/fullnode/%v/%v/budget/delay", node, space)
+}
+
+// FullnodeErrors is the rate of errors from full node by method and category, e.g., timeout,
+// connection refused, rate limited, invalid response or filter not found.
+func (*RpcMetrics) FullnodeErrors(space, node, method, category string) metrics.Meter {
+	return metricUtil.GetOrRegisterMeter("infura/rpc/fullnode/%v/%v/errors/%v/%v", node, space, category, method)
+}
+
 func (*RpcMetrics) FullnodeNonRpcErrorRate(node ...string) metricUtil.Percentage {
 	if len(node) == 0 {
 		return metricUtil.GetOrRegisterTimeWindowPercentageDefault(0, "infura/rpc/fullnode/rate/nonRpcErr")
@@ -153,6 +292,11 @@ func (*SyncMetrics) QueryEpochData(space string) metrics.Timer {
 	return metricUtil.GetOrRegisterTimer("infura/sync/%v/fullnode", space)
 }
 
+// EpochDataInvalid is the rate of epoch data fetched from full node failed to validate.
+func (*SyncMetrics) EpochDataInvalid(space, node string) metrics.Meter {
+	return metricUtil.GetOrRegisterMeter("infura/sync/%v/fullnode/%v/invalid", space, node)
+}
+
 func (*SyncMetrics) BoostQueryEpochData(space string) metrics.Timer {
 	return metricUtil.GetOrRegisterTimer("infura/sync/boost/%v/fullnode", space)
 }
@@ -169,6 +313,27 @@ func (*SyncMetrics) BoostQueryEpochDataAvailability(space string) metricUtil.Per
 	return metricUtil.GetOrRegisterTimeWindowPercentageDefault(0, "infura/sync/boost/%v/fullnode/availability", space)
 }
 
+// DeadLetterEpochs is the rate of epochs parked into dead letter queue due to failed to persist.
+func (*SyncMetrics) DeadLetterEpochs(space string) metrics.Meter {
+	return metricUtil.GetOrRegisterMeter("infura/sync/%v/deadletter/epochs", space)
+}
+
+func (*SyncMetrics) EpochGaps(space string) metrics.Gauge {
+	return metricUtil.GetOrRegisterGauge("infura/sync/%v/gaps/epochs", space)
+}
+
+func (*SyncMetrics) BackfillEpochs(space string) metrics.Meter {
+	return metricUtil.GetOrRegisterMeter("infura/sync/%v/gaps/backfill/epochs", space)
+}
+
+func (*SyncMetrics) BackfillOnce(space string, err error) metrics.Timer {
+	if util.IsInterfaceValNil(err) {
+		return metricUtil.GetOrRegisterTimer("infura/sync/%v/gaps/backfill/success", space)
+	}
+
+	return metricUtil.GetOrRegisterTimer("infura/sync/%v/gaps/backfill/failure", space)
+}
+
 // Store metrics
 type StoreMetrics struct{}
 
@@ -184,6 +349,18 @@ func (*StoreMetrics) GetLogs() metrics.Timer {
 	return metricUtil.GetOrRegisterTimer("infura/store/mysql/getlogs")
 }
 
+func (*StoreMetrics) Healthy(storeName string) metrics.Gauge {
+	return metricUtil.GetOrRegisterGauge("infura/store/%v/health/healthy", storeName)
+}
+
+func (*StoreMetrics) ReplicationLag(storeName string) metrics.Gauge {
+	return metricUtil.GetOrRegisterGauge("infura/store/%v/health/replicationLag", storeName)
+}
+
+func (*StoreMetrics) EpochGaps(storeName string) metrics.Gauge {
+	return metricUtil.GetOrRegisterGauge("infura/store/%v/health/epochGaps", storeName)
+}
+
 // Node manager metrics
 type NodeManagerMetrics struct{}
 
@@ -210,6 +387,24 @@ func (*PubSubMetrics) InputLogFilter(space string) metricUtil.Percentage {
 	return metricUtil.GetOrRegisterTimeWindowPercentageDefault(0, "infura/pubsub/%v/input/logFilter", space)
 }
 
+// LogsStreamChunkSize is the number of event logs per chunk streamed by `logsStream` subscription.
+func (*PubSubMetrics) LogsStreamChunkSize(space string) metrics.Histogram {
+	return metricUtil.GetOrRegisterHistogram("infura/pubsub/%v/logsStream/chunkSize", space)
+}
+
+// LogsReplayed is the number of event logs replayed by `logs` subscription resumed from block.
+func (*PubSubMetrics) LogsReplayed(space string) metrics.Histogram {
+	return metricUtil.GetOrRegisterHistogram("infura/pubsub/%v/logs/replayed", space)
+}
+
+func (*PubSubMetrics) WsConnections(server string) metrics.Gauge {
+	return metricUtil.GetOrRegisterGauge("infura/pubsub/ws/%v/connections", server)
+}
+
+func (*PubSubMetrics) WsClosed(server, reason string) metrics.Meter {
+	return metricUtil.GetOrRegisterMeter("infura/pubsub/ws/%v/closed/%v", server, reason)
+}
+
 // Virtual filter metrics
 type VirtualFilterMetrics struct{}
 
@@ -237,11 +432,40 @@ func (*VirtualFilterMetrics) QueryFilterChanges(space, node, store string) metri
 	return metricUtil.GetOrRegisterTimer("infura/virtualFilter/%v/query/%v/filterChanges/%v", space, node, store)
 }
 
+func (*VirtualFilterMetrics) ClientPoolSize(space string) metrics.Gauge {
+	return metricUtil.GetOrRegisterGauge("infura/virtualFilter/%v/clientPool/size", space)
+}
+
+func (*VirtualFilterMetrics) ClientPoolEvictions(space string) metrics.Meter {
+	return metricUtil.GetOrRegisterMeter("infura/virtualFilter/%v/clientPool/evictions", space)
+}
+
+// UpstreamOrphaned is the rate of orphaned delegate filters cleaned up on full node.
+func (*VirtualFilterMetrics) UpstreamOrphaned(space, node string) metrics.Meter {
+	return metricUtil.GetOrRegisterMeter("infura/virtualFilter/%v/upstream/orphaned/%v", space, node)
+}
+
+// UpstreamLeaked is the rate of delegate filters failed to uninstall on full node after retries.
+func (*VirtualFilterMetrics) UpstreamLeaked(space, node string) metrics.Meter {
+	return metricUtil.GetOrRegisterMeter("infura/virtualFilter/%v/upstream/leaked/%v", space, node)
+}
+
+// StoreFallbacks is the rate of log filters polled from database due to filter API disabled on full node.
+func (*VirtualFilterMetrics) StoreFallbacks(space, node string) metrics.Meter {
+	return metricUtil.GetOrRegisterMeter("infura/virtualFilter/%v/storeFallback/%v", space, node)
+}
+
 func (*VirtualFilterMetrics) StoreQueryPercentage(space string, node, store string) metricUtil.Percentage {
 	metricName := fmt.Sprintf("infura/virtualFilter/%v/percentage/query/%v/filterChanges/%v", space, node, store)
 	return metricUtil.GetOrRegisterTimeWindowPercentageDefault(0, metricName)
 }
 
+// CoalescedHitPercentage is the percentage of changed blocks whose matched event logs are
+// multiplexed from the coalesced filter group rather than filtered again.
+func (*VirtualFilterMetrics) CoalescedHitPercentage(space, node string) metricUtil.Percentage {
+	return metricUtil.GetOrRegisterTimeWindowPercentageDefault(0, "infura/virtualFilter/%v/percentage/coalesced/%v", space, node)
+}
+
 // Client metrics
 
 type ClientMetrics struct{}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiKeyId(t *testing.T) {
+	key := "1AbCdEfGhIjKlMnOpQrStUvWxYz23456789"
+
+	keyId := ApiKeyId(key)
+	assert.Len(t, keyId, 16)
+	assert.Equal(t, keyId, ApiKeyId(key))
+	assert.NotEqual(t, keyId, ApiKeyId(key+"0"))
+
+	// API key never embedded in metric names
+	Registry.RPC.ApiKeyRequests("cfx", key)
+	for name := range GetAll() {
+		assert.False(t, strings.Contains(name, key), name)
+	}
+}
+
+func TestApiKeyMethodBounded(t *testing.T) {
+	numSeries := int(numApiKeyMethodSeries.Load())
+	for i := numSeries; i < maxApiKeyMethodSeries; i++ {
+		assert.Equal(t, "cfx_epochNumber", apiKeyMethod(fmt.Sprintf("key%v", i), "cfx_epochNumber"))
+	}
+
+	// collected pairs are kept
+	assert.Equal(t, "cfx_epochNumber", apiKeyMethod(fmt.Sprintf("key%v", numSeries), "cfx_epochNumber"))
+
+	// new pairs are merged
+	assert.Equal(t, "others", apiKeyMethod("newKey", "cfx_epochNumber"))
+}
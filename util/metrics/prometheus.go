@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/metrics/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// MustInitPrometheus starts an HTTP server to export metrics at `/metrics` for Prometheus if
+// enabled, which should be called after metrics initialized.
+func MustInitPrometheus() {
+	var config struct {
+		Enabled      bool
+		HttpEndpoint string `default:":9100"`
+	}
+
+	viper.MustUnmarshalKey("metrics.prometheus", &config)
+	if !config.Enabled {
+		return
+	}
+
+	if !metrics.Enabled {
+		logrus.Warn("Prometheus exporter enabled but metrics not collected")
+	}
+
+	l, err := net.Listen("tcp", config.HttpEndpoint)
+	if err != nil {
+		logrus.WithError(err).
+			WithField("endpoint", config.HttpEndpoint).
+			Fatal("Failed to listen http endpoint for Prometheus exporter")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", prometheus.Handler(metrics.DefaultRegistry))
+
+	go func() {
+		logrus.WithField("endpoint", config.HttpEndpoint).
+			Info("Start to export metrics for Prometheus...")
+
+		defer l.Close()
+		http.Serve(l, mux)
+	}()
+}
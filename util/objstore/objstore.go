@@ -0,0 +1,40 @@
+package objstore
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrObjectNotFound = errors.New("object not found")
+)
+
+// ObjectStore is a minimal abstraction of key/value object storage such as S3 or MinIO.
+type ObjectStore interface {
+	// Put uploads the object data under the specified key, overwriting any existing one.
+	Put(ctx context.Context, key string, data []byte) error
+	// Get downloads the object data of the specified key, or `ErrObjectNotFound` if not existed.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the object of the specified key.
+	Delete(ctx context.Context, key string) error
+}
+
+// Config represents the S3 compatible object storage configurations.
+type Config struct {
+	Endpoint  string // eg., https://s3.us-east-1.amazonaws.com or http://127.0.0.1:9000
+	Region    string `default:"us-east-1"`
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// Key prefix of all the objects within the bucket
+	Prefix string
+}
+
+// MustNewConfigFromViper creates an instance of Config from Viper with the specified key or panic on error.
+func MustNewConfigFromViper(key string) *Config {
+	var cfg Config
+	viper.MustUnmarshalKey(key, &cfg)
+	return &cfg
+}
@@ -0,0 +1,105 @@
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"path"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/pkg/errors"
+)
+
+const (
+	s3ErrCodeNoSuchKey = "NoSuchKey"
+)
+
+var (
+	_ ObjectStore = (*S3Store)(nil)
+)
+
+// S3Store is an S3 compatible object store client backed by MinIO SDK. Objects are addressed in
+// path style (eg., `{endpoint}/{bucket}/{key}`) to be compatible with MinIO.
+type S3Store struct {
+	config *Config
+	client *minio.Client
+}
+
+func NewS3Store(config *Config) (*S3Store, error) {
+	if len(config.Bucket) == 0 {
+		return nil, errors.New("bucket not specified")
+	}
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid endpoint")
+	}
+
+	if len(endpoint.Scheme) == 0 || len(endpoint.Host) == 0 {
+		return nil, errors.Errorf("invalid endpoint %v", config.Endpoint)
+	}
+
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure:       endpoint.Scheme == "https",
+		Region:       config.Region,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create S3 client")
+	}
+
+	return &S3Store{config: config, client: client}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObject(
+		ctx, s.config.Bucket, s.objectName(key), bytes.NewReader(data), int64(len(data)),
+		minio.PutObjectOptions{ContentType: "application/octet-stream"},
+	)
+	if err != nil {
+		return errors.WithMessage(err, "failed to put object")
+	}
+
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	obj, err := s.client.GetObject(ctx, s.config.Bucket, s.objectName(key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s.wrapError(err, "failed to get object")
+	}
+	defer obj.Close()
+
+	// object is lazily requested on the first read
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, s.wrapError(err, "failed to read object")
+	}
+
+	return data, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	err := s.client.RemoveObject(ctx, s.config.Bucket, s.objectName(key), minio.RemoveObjectOptions{})
+	if err != nil {
+		return errors.WithMessage(err, "failed to remove object")
+	}
+
+	return nil
+}
+
+func (s *S3Store) objectName(key string) string {
+	return path.Join(s.config.Prefix, key)
+}
+
+// wrapError converts the missing object error into `ErrObjectNotFound`.
+func (s *S3Store) wrapError(err error, message string) error {
+	if minio.ToErrorResponse(err).Code == s3ErrCodeNoSuchKey {
+		return ErrObjectNotFound
+	}
+
+	return errors.WithMessage(err, message)
+}
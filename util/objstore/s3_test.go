@@ -0,0 +1,19 @@
+package objstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewS3Store(t *testing.T) {
+	_, err := NewS3Store(&Config{Endpoint: "http://127.0.0.1:9000"})
+	assert.Error(t, err)
+
+	_, err = NewS3Store(&Config{Endpoint: "127.0.0.1:9000", Bucket: "confura"})
+	assert.Error(t, err)
+
+	s, err := NewS3Store(&Config{Endpoint: "http://127.0.0.1:9000", Bucket: "confura", Prefix: "cfx/"})
+	assert.NoError(t, err)
+	assert.Equal(t, "cfx/1-2.seg.gz", s.objectName("1-2.seg.gz"))
+}
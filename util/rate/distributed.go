@@ -0,0 +1,168 @@
+package rate
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// rate limit tiers
+	TierIp     = "ip"
+	TierKey    = "key"
+	TierGlobal = "global"
+)
+
+var (
+	errTierRateLimited = errors.New("too many requests")
+
+	// tokenBucketScript refills and takes a token from the bucket atomically, using Redis server
+	// time as the clock so that all instances share the same time source.
+	//
+	// KEYS[1]: bucket key
+	// ARGV[1]: refill rate (tokens per second)
+	// ARGV[2]: burst (bucket capacity)
+	//
+	// Returns 1 if token taken, otherwise 0.
+	tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local now = redis.call('TIME')
+local nowMs = tonumber(now[1]) * 1000 + math.floor(tonumber(now[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = nowMs
+end
+
+tokens = math.min(burst, tokens + math.max(0, nowMs - ts) * rate / 1000)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', nowMs)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+
+return allowed
+`)
+)
+
+// TieredLimitConfig represents the configuration of distributed rate limit at IP, API key
+// and instance-global levels, backed by token buckets in Redis.
+type TieredLimitConfig struct {
+	Enabled bool
+	// Redis shared by all instances to hold the token buckets
+	RedisUrl string
+	// key prefix of token buckets in Redis
+	KeyPrefix string `default:"ratelimit"`
+	// limit per IP address for requests without valid API key
+	IP TokenBucketOption
+	// limit per valid API key
+	Key TokenBucketOption
+	// limit of all requests across instances
+	Global TokenBucketOption
+}
+
+// TieredLimiter limits requests at IP, API key and global levels with token buckets in Redis,
+// so that limits hold across horizontally scaled instances.
+type TieredLimiter struct {
+	conf   TieredLimitConfig
+	space  string
+	client *redis.Client
+	mu     sync.RWMutex
+}
+
+func NewTieredLimiter(space string, conf TieredLimitConfig, client *redis.Client) *TieredLimiter {
+	return &TieredLimiter{conf: conf, space: space, client: client}
+}
+
+// Validate validates the limit options of all tiers.
+func (conf TieredLimitConfig) Validate() error {
+	for tier, opt := range map[string]TokenBucketOption{TierIp: conf.IP, TierKey: conf.Key, TierGlobal: conf.Global} {
+		if opt.Rate < 0 || opt.Burst < 0 {
+			return errors.Errorf("negative rate or burst for %v tier", tier)
+		}
+	}
+
+	return nil
+}
+
+// SetLimits updates the limit options of all tiers, while the Redis settings will not take
+// effect until restarted.
+func (l *TieredLimiter) SetLimits(conf TieredLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.conf.IP, l.conf.Key, l.conf.Global = conf.IP, conf.Key, conf.Global
+}
+
+// Limit takes a token from the API key bucket if API key provided, otherwise from the IP bucket,
+// and then from the global bucket, so that requests rejected per principal won't drain the global
+// budget shared by all.
+//
+// Note, API key must have been validated against the key store, otherwise the IP limit could be
+// bypassed by random keys. Besides, requests are not limited if Redis is unavailable to keep the
+// service available.
+func (l *TieredLimiter) Limit(ctx context.Context, ip, key string) error {
+	l.mu.RLock()
+	conf := l.conf
+	l.mu.RUnlock()
+
+	if len(key) > 0 {
+		if err := l.take(ctx, TierKey, key, conf.Key); err != nil {
+			return err
+		}
+	} else if len(ip) > 0 {
+		if err := l.take(ctx, TierIp, ip, conf.IP); err != nil {
+			return err
+		}
+	}
+
+	return l.take(ctx, TierGlobal, "", conf.Global)
+}
+
+func (l *TieredLimiter) take(ctx context.Context, tier, id string, opt TokenBucketOption) error {
+	if opt.Rate <= 0 || opt.Burst <= 0 { // tier disabled
+		return nil
+	}
+
+	allowed, err := tokenBucketScript.Run(
+		ctx, l.client, []string{l.bucketKey(tier, id)}, float64(opt.Rate), opt.Burst,
+	).Int()
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"space": l.space,
+			"tier":  tier,
+		}).WithError(err).Debug("Failed to take token from distributed rate limit bucket")
+		return nil
+	}
+
+	metrics.Registry.RPC.TieredRateLimited(l.space, tier).Mark(allowed == 0)
+
+	if allowed == 0 {
+		return errors.WithMessagef(errTierRateLimited, "%v rate limit exceeded", tier)
+	}
+
+	return nil
+}
+
+// bucketKey returns the Redis key of token bucket, eg., `ratelimit:cfx:key:<api_key>`.
+func (l *TieredLimiter) bucketKey(tier, id string) string {
+	key := l.conf.KeyPrefix + ":" + l.space + ":" + tier
+	if len(id) > 0 {
+		key += ":" + id
+	}
+
+	return key
+}
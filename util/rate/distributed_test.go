@@ -0,0 +1,71 @@
+package rate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTieredLimiterBucketKey(t *testing.T) {
+	limiter := NewTieredLimiter("cfx", TieredLimitConfig{KeyPrefix: "ratelimit"}, nil)
+
+	assert.Equal(t, "ratelimit:cfx:global", limiter.bucketKey(TierGlobal, ""))
+	assert.Equal(t, "ratelimit:cfx:key:abc", limiter.bucketKey(TierKey, "abc"))
+	assert.Equal(t, "ratelimit:cfx:ip:127.0.0.1", limiter.bucketKey(TierIp, "127.0.0.1"))
+}
+
+func TestTieredLimiterDisabledTiers(t *testing.T) {
+	// no token bucket taken from Redis if all tiers disabled
+	limiter := NewTieredLimiter("eth", TieredLimitConfig{}, nil)
+	assert.NoError(t, limiter.Limit(context.Background(), "127.0.0.1", "abc"))
+}
+
+// newTestTieredLimiter creates a limiter backed by miniredis, whose buckets are barely refilled
+// during test.
+func newTestTieredLimiter(t *testing.T, ipBurst, keyBurst, globalBurst int) *TieredLimiter {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	return NewTieredLimiter("cfx", TieredLimitConfig{
+		KeyPrefix: "ratelimit",
+		IP:        TokenBucketOption{Rate: 0.001, Burst: ipBurst},
+		Key:       TokenBucketOption{Rate: 0.001, Burst: keyBurst},
+		Global:    TokenBucketOption{Rate: 0.001, Burst: globalBurst},
+	}, client)
+}
+
+func TestTieredLimiterPerPrincipal(t *testing.T) {
+	limiter := newTestTieredLimiter(t, 1, 2, 100)
+	ctx := context.Background()
+
+	// limited per IP without API key
+	assert.NoError(t, limiter.Limit(ctx, "10.0.0.1", ""))
+	assert.Error(t, limiter.Limit(ctx, "10.0.0.1", ""))
+	assert.NoError(t, limiter.Limit(ctx, "10.0.0.2", ""))
+
+	// limited per API key regardless of IP
+	assert.NoError(t, limiter.Limit(ctx, "10.0.0.1", "key1"))
+	assert.NoError(t, limiter.Limit(ctx, "10.0.0.2", "key1"))
+	assert.Error(t, limiter.Limit(ctx, "10.0.0.3", "key1"))
+}
+
+func TestTieredLimiterGlobalNotDrained(t *testing.T) {
+	limiter := newTestTieredLimiter(t, 1, 1, 2)
+	ctx := context.Background()
+
+	assert.NoError(t, limiter.Limit(ctx, "10.0.0.1", ""))
+
+	// requests rejected per IP won't take global tokens
+	for i := 0; i < 5; i++ {
+		assert.Error(t, limiter.Limit(ctx, "10.0.0.1", ""))
+	}
+
+	assert.NoError(t, limiter.Limit(ctx, "10.0.0.2", ""))
+
+	// global budget exhausted
+	err := limiter.Limit(ctx, "10.0.0.3", "")
+	assert.ErrorContains(t, err, TierGlobal)
+}
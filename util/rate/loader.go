@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/acl"
 	"github.com/sirupsen/logrus"
 )
 
@@ -18,6 +19,9 @@ type KeyInfo struct {
 	AclID uint32    // bound allowlist ID
 	Key   string    // limit key
 	Type  LimitType // limit type
+
+	// restriction of source IPs and origins, nil if unrestricted
+	Restriction *acl.KeyRestriction
 }
 
 type KeysetFilter struct {
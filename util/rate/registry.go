@@ -62,6 +62,57 @@ func NewRegistry(kloader *KeyLoader, valFactory acl.ValidatorFactory) *Registry
 	return m
 }
 
+// Tier returns the tier (rate limit strategy name) of the API key from context, or the
+// default strategy name if not authenticated.
+func (r *Registry) Tier(ctx context.Context) string {
+	authId, ok := handlers.GetAuthIdFromContext(ctx)
+	if !ok {
+		return DefaultStrategy
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if js, ok := handlers.JwtStatusFromContext(ctx); ok {
+		if stg, ok := r.strategies[js.Tier]; ok {
+			return stg.Name
+		}
+	} else if vip, ok := handlers.VipStatusFromContext(ctx); ok {
+		if stg, ok := r.getVipStrategy(vip.Tier); ok {
+			return stg.Name
+		}
+	} else if ki, ok := r.kloader.Load(authId); ok && ki != nil {
+		if stg, ok := r.id2Strategies[ki.SID]; ok {
+			return stg.Name
+		}
+	}
+
+	return DefaultStrategy
+}
+
+// VerifiedAuthId returns the auth ID (e.g., API key) from context if verified by JWT, VIP status
+// or the key store.
+func (r *Registry) VerifiedAuthId(ctx context.Context) (string, bool) {
+	authId, ok := handlers.GetAuthIdFromContext(ctx)
+	if !ok || len(authId) == 0 {
+		return "", false
+	}
+
+	if _, ok := handlers.JwtStatusFromContext(ctx); ok {
+		return authId, true
+	}
+
+	if _, ok := handlers.VipStatusFromContext(ctx); ok {
+		return authId, true
+	}
+
+	if ki, ok := r.kloader.Load(authId); ok && ki != nil {
+		return authId, true
+	}
+
+	return "", false
+}
+
 // implements `http.LimiterFactory`
 
 func (r *Registry) GetGroupAndKey(
@@ -74,6 +125,11 @@ func (r *Registry) GetGroupAndKey(
 		return r.genDefaultGroupAndKey(ctx, resource)
 	}
 
+	if js, ok := handlers.JwtStatusFromContext(ctx); ok {
+		// use strategy mapped from JWT claims
+		return r.genJwtGroupAndKey(ctx, resource, js)
+	}
+
 	if vip, ok := handlers.VipStatusFromContext(ctx); ok {
 		// use vip strategy with corresponding tier
 		return r.genVipGroupAndKey(ctx, resource, authId, vip)
@@ -156,6 +212,32 @@ func (r *Registry) genVipGroupAndKey(
 	return stg.Name, key, nil
 }
 
+func (r *Registry) genJwtGroupAndKey(
+	ctx context.Context,
+	resource string,
+	js *handlers.JwtStatus,
+) (group, key string, err error) {
+	r.mu.Lock()
+	stg, ok := r.strategies[js.Tier]
+	r.mu.Unlock()
+
+	if !ok { // use default strategy for unknown tier
+		logrus.WithFields(logrus.Fields{
+			"resource":  resource,
+			"jwtStatus": js,
+		}).Debug("Rate limit strategy of JWT tier not found")
+		return r.genDefaultGroupAndKey(ctx, resource)
+	}
+
+	if _, ok := stg.LimitOptions[resource]; !ok {
+		// limit rule not defined
+		return
+	}
+
+	key = fmt.Sprintf("key:%v", js.ID)
+	return stg.Name, key, nil
+}
+
 func (r *Registry) genKeyInfoGroupAndKey(
 	ctx context.Context,
 	resource, limitKey string,
@@ -47,6 +47,15 @@ func (r *aclRegistry) assignValidator(ctx context.Context) (acl.Validator, bool)
 		return r.getDefaultValidator()
 	}
 
+	if js, ok := handlers.JwtStatusFromContext(ctx); ok {
+		// use allowlist named after the tier mapped from JWT claims
+		if v, ok := r.getNamedValidator(js.Tier); ok {
+			return v, true
+		}
+
+		return r.getDefaultValidator()
+	}
+
 	if vs, ok := handlers.VipStatusFromContext(ctx); ok {
 		// use VIP allowlsit with corresponding tier
 		return r.getVipValidator(vs)
@@ -78,6 +87,20 @@ func (r *aclRegistry) getVipValidator(vip *handlers.VipStatus) (acl.Validator, b
 	return nil, false
 }
 
+func (r *aclRegistry) getNamedValidator(name string) (acl.Validator, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, al := range r.allowlists {
+		if strings.EqualFold(al.Name, name) {
+			v, ok := r.validators[al.ID]
+			return v, ok
+		}
+	}
+
+	return nil, false
+}
+
 func (r *aclRegistry) getDefaultValidator() (acl.Validator, bool) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -1,8 +1,7 @@
 package rate
 
 import (
-	"math/rand"
-	"time"
+	"crypto/rand"
 
 	"github.com/btcsuite/btcutil/base58"
 	"github.com/pkg/errors"
@@ -12,17 +11,19 @@ const (
 	LimitKeyLength = 32
 )
 
+// GenerateRandomLimitKey generates a random limit key (API key) of the specified limit type,
+// which is encoded in the first character of the key.
 func GenerateRandomLimitKey(limitType LimitType) (string, error) {
-	data := make([]byte, LimitKeyLength)
-
-	source := rand.NewSource(time.Now().UnixNano())
-	r := rand.New(source)
-	r.Read(data)
-
 	if limitType != LimitTypeByKey && limitType != LimitTypeByIp {
 		return "", errors.New("invalid limit type")
 	}
 
+	// use cryptographically secure random source so that API keys are unpredictable
+	data := make([]byte, LimitKeyLength)
+	if _, err := rand.Read(data); err != nil {
+		return "", errors.WithMessage(err, "failed to read random bytes")
+	}
+
 	secretb := []byte(base58.Encode(data))
 	secretb[0] = '0' + uint8(limitType)
 
@@ -31,3 +31,15 @@ func TestGenerateRandomLimitKey(t *testing.T) {
 	_, err = GenerateRandomLimitKey(-1)
 	assert.NotNil(t, err)
 }
+
+func TestGenerateRandomLimitKeyUnique(t *testing.T) {
+	keys := make(map[string]bool)
+
+	for i := 0; i < 100; i++ {
+		limitKey, err := GenerateRandomLimitKey(LimitTypeByKey)
+		assert.Nil(t, err)
+		assert.False(t, keys[limitKey])
+
+		keys[limitKey] = true
+	}
+}
@@ -5,13 +5,10 @@ import (
 	"strings"
 )
 
-// WildCardToRegexp converts a wildcard pattern to a regular expression pattern.
+// WildCardToRegexp converts a wildcard pattern to a regular expression pattern, in which only `*`
+// is expanded to match any characters, while the others are matched literally.
 func WildCardToRegexp(pattern string) string {
 	components := strings.Split(pattern, "*")
-	if len(components) == 1 {
-		// if len is 1, there are no *'s, return exact match pattern
-		return "^" + pattern + "$"
-	}
 
 	var result strings.Builder
 	for i, literal := range components {
@@ -0,0 +1,33 @@
+package util
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWildCardToRegexp(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		input   string
+		matched bool
+	}{
+		{"https://app.example.com", "https://app.example.com", true},
+		{"https://app.example.com", "https://app-example.com", false},
+		{"https://app.example.com", "https://app.example.com.evil.com", false},
+		{"https://*.example.com", "https://app.example.com", true},
+		{"https://*.example.com", "https://app.example-com", false},
+		{"https://*.example.com", "https://example.com", false},
+		{"cfx_get(Balance)", "cfx_get(Balance)", true},
+		{"cfx_get(Balance)", "cfx_getBalance", false},
+		{"cfx_*", "cfx_getBalance", true},
+		{"cfx_*", "eth_getBalance", false},
+		{"*", "anything", true},
+	}
+
+	for _, tc := range testCases {
+		regp := regexp.MustCompile(WildCardToRegexp(tc.pattern))
+		assert.Equal(t, tc.matched, regp.MatchString(tc.input), "pattern %v, input %v", tc.pattern, tc.input)
+	}
+}
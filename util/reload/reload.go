@@ -0,0 +1,136 @@
+package reload
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	spfviper "github.com/spf13/viper"
+)
+
+var (
+	mu      sync.Mutex
+	entries []tunable
+)
+
+// tunable is a hot-reloadable config item.
+type tunable interface {
+	// load loads the latest setting from viper along with the optional overrides, and returns
+	// true if changed.
+	load(overrides *spfviper.Viper) (bool, error)
+	// commit applies the loaded setting.
+	commit(source string)
+	// discard drops the loaded setting.
+	discard()
+}
+
+// entry is a tunable config item unmarshalled from viper key.
+type entry[T any] struct {
+	key      string
+	current  T
+	pending  T
+	validate func(T) error
+	apply    func(T)
+}
+
+// Register registers a tunable config item by viper key, which will be validated and applied
+// without restart once changed.
+//
+// Note, the validate func is optional, and apply func will be called in the config watcher
+// goroutine, which should be safe for concurrent access.
+func Register[T any](key string, current T, validate func(T) error, apply func(T)) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	entries = append(entries, &entry[T]{
+		key:      key,
+		current:  current,
+		validate: validate,
+		apply:    apply,
+	})
+}
+
+func (e *entry[T]) load(overrides *spfviper.Viper) (bool, error) {
+	var latest T
+	if err := viper.UnmarshalKey(e.key, &latest); err != nil {
+		return false, errors.WithMessagef(err, "failed to unmarshal %v", e.key)
+	}
+
+	// overrides only the fields present, and keeps the others as is
+	if overrides != nil {
+		if sub := overrides.Sub(e.key); sub != nil {
+			if err := sub.Unmarshal(&latest); err != nil {
+				return false, errors.WithMessagef(err, "failed to unmarshal overrides of %v", e.key)
+			}
+		}
+	}
+
+	if reflect.DeepEqual(latest, e.current) {
+		return false, nil
+	}
+
+	if e.validate != nil {
+		if err := e.validate(latest); err != nil {
+			return false, errors.WithMessagef(err, "invalid %v", e.key)
+		}
+	}
+
+	e.pending = latest
+	return true, nil
+}
+
+func (e *entry[T]) commit(source string) {
+	old := e.current
+	e.apply(e.pending)
+	e.current, e.pending = e.pending, *new(T)
+
+	// audit log
+	logrus.WithFields(logrus.Fields{
+		"source": source,
+		"key":    e.key,
+		"old":    fmt.Sprintf("%+v", old),
+		"new":    fmt.Sprintf("%+v", e.current),
+	}).Info("Config change applied")
+}
+
+func (e *entry[T]) discard() {
+	e.pending = *new(T)
+}
+
+// Reload reloads all the registered config items from viper, and applies the changed ones only
+// if all of them are valid, so that config changes take effect as a whole.
+func Reload(source string) error {
+	return reload(source, nil)
+}
+
+// reload reloads all the registered config items from viper along with the optional overrides,
+// which are kept apart from the global viper so as not to be changed concurrently.
+func reload(source string, overrides *spfviper.Viper) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var changed []tunable
+	for _, e := range entries {
+		ok, err := e.load(overrides)
+		if err != nil {
+			for _, c := range changed {
+				c.discard()
+			}
+
+			return err
+		}
+
+		if ok {
+			changed = append(changed, e)
+		}
+	}
+
+	for _, c := range changed {
+		c.commit(source)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package reload
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type testLimits struct {
+	MaxCount int `default:"10"`
+}
+
+func TestReload(t *testing.T) {
+	defer func() { entries = nil }()
+
+	validate := func(c testLimits) error {
+		if c.MaxCount <= 0 {
+			return errors.New("max count must be positive")
+		}
+		return nil
+	}
+
+	var applied1, applied2 []int
+	Register("test.limits1", testLimits{MaxCount: 10}, validate, func(c testLimits) {
+		applied1 = append(applied1, c.MaxCount)
+	})
+	Register("test.limits2", testLimits{MaxCount: 10}, validate, func(c testLimits) {
+		applied2 = append(applied2, c.MaxCount)
+	})
+
+	// unchanged
+	assert.NoError(t, Reload("test"))
+	assert.Empty(t, applied1)
+
+	viper.Set("test.limits1.maxCount", 20)
+	assert.NoError(t, Reload("test"))
+	assert.Equal(t, []int{20}, applied1)
+	assert.Empty(t, applied2)
+
+	// not applied as a whole if any invalid
+	viper.Set("test.limits1.maxCount", 30)
+	viper.Set("test.limits2.maxCount", -1)
+	assert.Error(t, Reload("test"))
+	assert.Equal(t, []int{20}, applied1)
+	assert.Empty(t, applied2)
+
+	viper.Set("test.limits2.maxCount", 5)
+	assert.NoError(t, Reload("test"))
+	assert.Equal(t, []int{20, 30}, applied1)
+	assert.Equal(t, []int{5}, applied2)
+}
+
+func TestRemoteWatcherPoll(t *testing.T) {
+	defer func() { entries = nil }()
+
+	var applied []int
+	Register("test.remote", testLimits{MaxCount: 10}, nil, func(c testLimits) {
+		applied = append(applied, c.MaxCount)
+	})
+
+	value := "test:\n  remote:\n    maxCount: 20\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/kv/confura/config", r.URL.Path)
+		io.WriteString(w, value)
+	}))
+	defer server.Close()
+
+	w := newRemoteWatcher(config{Source: SourceConsul, Endpoint: server.URL, Key: "confura/config"})
+	assert.NoError(t, w.poll())
+	assert.Equal(t, []int{20}, applied)
+
+	// overrides are not merged into the global viper
+	assert.False(t, viper.IsSet("test.remote.maxCount"))
+
+	// unchanged overrides
+	assert.NoError(t, w.poll())
+	assert.Equal(t, []int{20}, applied)
+
+	value = "test:\n  remote:\n    maxCount: 30\n"
+	assert.NoError(t, w.poll())
+	assert.Equal(t, []int{20, 30}, applied)
+}
@@ -0,0 +1,188 @@
+package reload
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	SourceFile   = "file"
+	SourceEtcd   = "etcd"
+	SourceConsul = "consul"
+)
+
+type config struct {
+	Enabled bool
+	// config source to watch, available sources are `file`, `etcd` and `consul`
+	Source string `default:"file"`
+	// HTTP endpoint of etcd (v3 JSON gateway) or consul
+	Endpoint string
+	// key of the YAML config overrides in etcd or consul
+	Key string `default:"confura/config"`
+	// interval to poll config overrides from etcd or consul
+	Interval time.Duration `default:"10s"`
+}
+
+// MustInitFromViper starts to watch config changes if enabled, and hot-reloads the registered
+// tunable config items.
+func MustInitFromViper() {
+	var conf config
+	viperutil.MustUnmarshalKey("reload", &conf)
+
+	if !conf.Enabled {
+		return
+	}
+
+	switch strings.ToLower(conf.Source) {
+	case SourceFile:
+		viper.OnConfigChange(func(e fsnotify.Event) {
+			reloadAndLog(SourceFile)
+		})
+		viper.WatchConfig()
+	case SourceEtcd, SourceConsul:
+		if len(conf.Endpoint) == 0 {
+			logrus.WithField("source", conf.Source).Fatal("Endpoint required to watch remote config")
+		}
+
+		go newRemoteWatcher(conf).run()
+	default:
+		logrus.WithField("source", conf.Source).Fatal("Unsupported config reload source")
+	}
+
+	logrus.WithField("source", conf.Source).Info("Config watcher started for hot-reload")
+}
+
+func reloadAndLog(source string) {
+	if err := Reload(source); err != nil {
+		logrus.WithField("source", source).WithError(err).Error("Failed to hot-reload config")
+	}
+}
+
+// remoteWatcher polls YAML config overrides from etcd or consul, which take precedence over the
+// config file settings and environment variables.
+//
+// Note, the watch APIs of etcd and consul are not used, so changes are only detected on the next
+// poll interval.
+type remoteWatcher struct {
+	conf   config
+	client *http.Client
+	last   []byte
+}
+
+func newRemoteWatcher(conf config) *remoteWatcher {
+	return &remoteWatcher{
+		conf:   conf,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (w *remoteWatcher) run() {
+	ticker := time.NewTicker(w.conf.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := w.poll(); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"source":   w.conf.Source,
+				"endpoint": w.conf.Endpoint,
+				"key":      w.conf.Key,
+			}).WithError(err).Warn("Failed to poll remote config")
+		}
+	}
+}
+
+func (w *remoteWatcher) poll() error {
+	var data []byte
+	var err error
+
+	if strings.ToLower(w.conf.Source) == SourceEtcd {
+		data, err = w.fetchEtcd()
+	} else {
+		data, err = w.fetchConsul()
+	}
+
+	if err != nil || len(data) == 0 || bytes.Equal(data, w.last) {
+		return err
+	}
+
+	// parse into a new viper instance rather than merging into the global one, which is read
+	// concurrently by others
+	overrides := viper.New()
+	overrides.SetConfigType("yaml")
+	if err := overrides.ReadConfig(bytes.NewReader(data)); err != nil {
+		return errors.WithMessage(err, "failed to parse config")
+	}
+
+	w.last = data
+	if err := reload(w.conf.Source, overrides); err != nil {
+		logrus.WithField("source", w.conf.Source).WithError(err).Error("Failed to hot-reload config")
+	}
+
+	return nil
+}
+
+// fetchEtcd gets value by key via etcd v3 JSON gateway.
+func (w *remoteWatcher) fetchEtcd() ([]byte, error) {
+	reqBody, _ := json.Marshal(map[string]string{
+		"key": base64.StdEncoding.EncodeToString([]byte(w.conf.Key)),
+	})
+
+	url := strings.TrimSuffix(w.conf.Endpoint, "/") + "/v3/kv/range"
+	resp, err := w.client.Post(url, "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+
+	var result struct {
+		Kvs []struct {
+			Value string `json:"value"`
+		} `json:"kvs"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, errors.WithMessage(err, "failed to decode response")
+	}
+
+	if len(result.Kvs) == 0 {
+		return nil, nil
+	}
+
+	return base64.StdEncoding.DecodeString(result.Kvs[0].Value)
+}
+
+// fetchConsul gets raw value by key via consul KV HTTP API.
+func (w *remoteWatcher) fetchConsul() ([]byte, error) {
+	url := fmt.Sprintf("%v/v1/kv/%v?raw", strings.TrimSuffix(w.conf.Endpoint, "/"), w.conf.Key)
+	resp, err := w.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
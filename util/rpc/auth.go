@@ -0,0 +1,92 @@
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+)
+
+const bearerAuthScheme = "Bearer "
+
+// MustNewBearerAuthMiddleware creates HTTP middleware to authenticate requests of internal RPC
+// services (eg., virtual filter service) by the shared bearer token, which could be literal, or
+// sourced from environment variable with `env:` prefix or file with `file:` prefix.
+func MustNewBearerAuthMiddleware(token string) handlers.Middleware {
+	secret := MustResolveBearerToken(token)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth := r.Header.Get(fasthttp.HeaderAuthorization)
+			provided, ok := strings.CutPrefix(auth, bearerAuthScheme)
+
+			if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(secret)) != 1 {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// MustResolveBearerToken resolves the shared bearer token, which could be literal, or sourced from
+// environment variable with `env:` prefix or file with `file:` prefix.
+func MustResolveBearerToken(token string) string {
+	secret, err := resolveSecret(token)
+	if err != nil || len(secret) == 0 {
+		logrus.WithError(err).Fatal("Failed to resolve bearer auth token")
+	}
+
+	return secret
+}
+
+// MustRegisterBearerCredential registers the shared bearer token of internal RPC service, which
+// will be injected into HTTP requests to the service URL. Note, the token resolves in the same way
+// as `MustNewBearerAuthMiddleware`.
+func MustRegisterBearerCredential(rawUrl, token string) {
+	secret := MustResolveBearerToken(token)
+
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		logrus.WithError(err).WithField("url", rawUrl).Fatal("Failed to parse internal RPC service url")
+	}
+
+	credStore.registerInternalUrl(u.Host, credential{
+		header: fasthttp.HeaderAuthorization,
+		value:  bearerAuthScheme + secret,
+	})
+}
+
+// DialAdminHTTP creates RPC client to the admin endpoint (e.g., node manager RPC), which is
+// authenticated by the shared bearer token, along with the client certificate for mutual TLS if
+// configured in `rpc.adminTls.client`.
+func DialAdminHTTP(rawUrl, token string) (*gethrpc.Client, error) {
+	var conf adminTlsConfig
+	viper.MustUnmarshalKey("rpc.adminTls", &conf)
+
+	tlsConf, err := newAdminClientTLSConfig(&conf)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create admin client TLS config")
+	}
+
+	opts := []gethrpc.ClientOption{
+		gethrpc.WithHeader(fasthttp.HeaderAuthorization, bearerAuthScheme+MustResolveBearerToken(token)),
+	}
+
+	if tlsConf != nil {
+		opts = append(opts, gethrpc.WithHTTPClient(&http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsConf},
+		}))
+	}
+
+	return gethrpc.DialOptions(context.Background(), rawUrl, opts...)
+}
@@ -0,0 +1,47 @@
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestBearerAuthMiddleware(t *testing.T) {
+	t.Setenv("CONFURA_TEST_VF_TOKEN", "secret")
+
+	handler := MustNewBearerAuthMiddleware("env:CONFURA_TEST_VF_TOKEN")(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+
+	serve := func(auth string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if len(auth) > 0 {
+			req.Header.Set(fasthttp.HeaderAuthorization, auth)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("Bearer secret"))
+	assert.Equal(t, http.StatusUnauthorized, serve(""))
+	assert.Equal(t, http.StatusUnauthorized, serve("Bearer wrong"))
+	assert.Equal(t, http.StatusUnauthorized, serve("secret"))
+}
+
+func TestRegisterBearerCredential(t *testing.T) {
+	MustRegisterBearerCredential("http://vfilter.internal:48545", "secret")
+
+	// injected into requests to the internal service only
+	cred, ok := credStore.get("vfilter.internal:48545")
+	assert.True(t, ok)
+	assert.Equal(t, "Bearer secret", cred.value)
+
+	_, ok = credStore.get("vfilter.internal:48546")
+	assert.False(t, ok)
+}
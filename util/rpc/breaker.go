@@ -0,0 +1,162 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/openweb3/go-rpc-provider/utils"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrUpstreamUnavailable is returned to fast fail when circuit breaker of full node is open.
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+
+	// node breakers shared by all clients of the same full node: space/node name => *nodeBreaker
+	nodeBreakers sync.Map
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// nodeBreaker is a circuit breaker for full node, which trips on continuous IO errors or timeouts,
+// and lets only one probing request through while half-open.
+type nodeBreaker struct {
+	mu  sync.Mutex
+	cfg circuitBreakerConfig
+
+	state     breakerState
+	failures  int       // number of continuous failures
+	firstFail time.Time // time of the first continuous failure
+	openedAt  time.Time // time when circuit breaker opened
+	probing   bool      // whether any probing request in flight while half-open
+	nodeName  string
+	nowFunc   func() time.Time
+}
+
+func newNodeBreaker(nodeName string, cfg circuitBreakerConfig) *nodeBreaker {
+	return &nodeBreaker{nodeName: nodeName, cfg: cfg, nowFunc: time.Now}
+}
+
+func loadOrNewNodeBreaker(space, nodeName string, cfg circuitBreakerConfig) *nodeBreaker {
+	v, _ := nodeBreakers.LoadOrStore(space+"/"+nodeName, newNodeBreaker(nodeName, cfg))
+	return v.(*nodeBreaker)
+}
+
+// allow checks if request is allowed to send to full node.
+func (b *nodeBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerOpen && b.nowFunc().Sub(b.openedAt) >= b.cfg.OpenColdTime {
+		b.transit(breakerHalfOpen)
+	}
+
+	switch b.state {
+	case breakerOpen:
+		return errors.WithMessagef(ErrUpstreamUnavailable, "circuit breaker open for full node %v", b.nodeName)
+	case breakerHalfOpen:
+		if b.probing { // only one probing request allowed
+			return errors.WithMessagef(ErrUpstreamUnavailable, "circuit breaker half-open for full node %v", b.nodeName)
+		}
+
+		b.probing = true
+	}
+
+	return nil
+}
+
+// report reports the request result to full node.
+func (b *nodeBreaker) report(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.nowFunc()
+
+	if errors.Is(err, context.Canceled) { // canceled by client, neither success nor failure
+		b.probing = false
+		return
+	}
+
+	if !isUpstreamFailure(err) {
+		b.failures = 0
+		if b.state == breakerHalfOpen {
+			b.probing = false
+			b.transit(breakerClosed)
+		}
+
+		return
+	}
+
+	switch b.state {
+	case breakerHalfOpen: // probing failed
+		b.probing = false
+		b.openedAt = now
+		b.transit(breakerOpen)
+	case breakerClosed:
+		if b.failures == 0 || now.Sub(b.firstFail) > b.cfg.FailTimeWindow {
+			b.failures, b.firstFail = 0, now
+		}
+
+		if b.failures++; b.failures >= b.cfg.MaxFail {
+			b.failures = 0
+			b.openedAt = now
+			b.transit(breakerOpen)
+		}
+	}
+}
+
+func (b *nodeBreaker) transit(to breakerState) {
+	if from := b.state; from != to {
+		b.state = to
+
+		logrus.WithFields(logrus.Fields{
+			"node": b.nodeName, "from": from, "to": to,
+		}).Info("Full node circuit breaker state changed")
+	}
+}
+
+// isUpstreamFailure checks if the error is due to full node unavailable, eg., IO error or timeout.
+// Note, JSON-RPC errors responded by full node, canceled requests and requests shed by concurrency
+// limit are not regarded as failure.
+func isUpstreamFailure(err error) bool {
+	return err != nil && !utils.IsRPCJSONError(err) &&
+		!errors.Is(err, context.Canceled) && !errors.Is(err, ErrUpstreamOverloaded)
+}
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerClosed:
+		return "closed"
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// middlewareCircuitBreaker fast fails RPC requests to full node when circuit breaker open.
+func middlewareCircuitBreaker(breaker *nodeBreaker) providers.CallContextMiddleware {
+	return func(handler providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			if err := breaker.allow(); err != nil {
+				return err
+			}
+
+			err := handler(ctx, result, method, args...)
+			breaker.report(err)
+
+			return err
+		}
+	}
+}
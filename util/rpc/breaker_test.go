@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var errTestIO = errors.New("connection refused")
+
+func newTestNodeBreaker() (*nodeBreaker, *time.Time) {
+	now := time.Now()
+	b := newNodeBreaker("node", circuitBreakerConfig{
+		Enabled:        true,
+		MaxFail:        3,
+		FailTimeWindow: time.Second,
+		OpenColdTime:   10 * time.Second,
+	})
+	b.nowFunc = func() time.Time { return now }
+
+	return b, &now
+}
+
+func TestNodeBreakerTrip(t *testing.T) {
+	b, _ := newTestNodeBreaker()
+
+	for i := 0; i < 2; i++ {
+		b.report(errTestIO)
+	}
+	assert.NoError(t, b.allow())
+
+	b.report(errTestIO)
+	assert.Equal(t, breakerOpen, b.state)
+	assert.ErrorIs(t, b.allow(), ErrUpstreamUnavailable)
+}
+
+func TestNodeBreakerNonFailures(t *testing.T) {
+	b, _ := newTestNodeBreaker()
+
+	for i := 0; i < 5; i++ {
+		b.report(context.Canceled)
+		b.report(ErrUpstreamOverloaded)
+	}
+	assert.Equal(t, breakerClosed, b.state)
+
+	// success resets continuous failures
+	b.report(errTestIO)
+	b.report(errTestIO)
+	b.report(nil)
+	b.report(errTestIO)
+	assert.Equal(t, breakerClosed, b.state)
+}
+
+func TestNodeBreakerFailTimeWindow(t *testing.T) {
+	b, now := newTestNodeBreaker()
+
+	for i := 0; i < 3; i++ {
+		b.report(errTestIO)
+		*now = now.Add(600 * time.Millisecond)
+	}
+	assert.Equal(t, breakerClosed, b.state)
+}
+
+func TestNodeBreakerHalfOpen(t *testing.T) {
+	b, now := newTestNodeBreaker()
+	for i := 0; i < 3; i++ {
+		b.report(errTestIO)
+	}
+
+	// still open within cold time
+	*now = now.Add(9 * time.Second)
+	assert.ErrorIs(t, b.allow(), ErrUpstreamUnavailable)
+
+	// only one probing request allowed
+	*now = now.Add(time.Second)
+	assert.NoError(t, b.allow())
+	assert.Equal(t, breakerHalfOpen, b.state)
+	assert.ErrorIs(t, b.allow(), ErrUpstreamUnavailable)
+
+	// reopened once probing failed
+	b.report(errTestIO)
+	assert.Equal(t, breakerOpen, b.state)
+	assert.ErrorIs(t, b.allow(), ErrUpstreamUnavailable)
+
+	// canceled probing lets another one through
+	*now = now.Add(10 * time.Second)
+	assert.NoError(t, b.allow())
+	b.report(context.Canceled)
+	assert.NoError(t, b.allow())
+
+	// closed once probing succeeded
+	b.report(nil)
+	assert.Equal(t, breakerClosed, b.state)
+	assert.NoError(t, b.allow())
+	assert.NoError(t, b.allow())
+}
+
+func TestMiddlewareCircuitBreaker(t *testing.T) {
+	b, _ := newTestNodeBreaker()
+
+	var calls int
+	handler := middlewareCircuitBreaker(b)(func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+		calls++
+		return errTestIO
+	})
+
+	for i := 0; i < 3; i++ {
+		assert.ErrorIs(t, handler(context.Background(), nil, "cfx_epochNumber"), errTestIO)
+	}
+
+	// fast fail without requesting full node
+	assert.ErrorIs(t, handler(context.Background(), nil, "cfx_epochNumber"), ErrUpstreamUnavailable)
+	assert.Equal(t, 3, calls)
+}
@@ -0,0 +1,194 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcErrors "github.com/Conflux-Chain/confura/util/rpc/errors"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/pkg/errors"
+)
+
+const (
+	// min back-off delay once sync throttled
+	minSyncThrottleDelay = 10 * time.Millisecond
+
+	// weight of the latest latency sample for the moving average
+	budgetLatencyEwmaWeight = 0.2
+
+	// window to count requests against the QPS budget
+	budgetWindow = time.Second
+)
+
+var (
+	// node budgets shared by all clients of the same full node: space/node name => *nodeBudget
+	nodeBudgets sync.Map
+)
+
+type upstreamBudgetConfig struct {
+	Enabled bool
+	// max number of requests per second to full node from both RPC proxy and sync, where the RPC
+	// proxy is never throttled but sync waits for the remaining budget, unlimited if 0
+	MaxQps int
+	// target moving average latency of full node, beyond which sync backs off
+	TargetLatency time.Duration `default:"500ms"`
+	// max back-off delay for sync before each request
+	MaxDelay time.Duration `default:"5s"`
+}
+
+// nodeBudget is the upstream budget of full node shared by the live RPC proxy and the syncers,
+// which throttles sync requests adaptively per load feedback of full node, e.g., high latency or
+// rate limited, so that catch-up sync will not starve the production traffic.
+type nodeBudget struct {
+	cfg      upstreamBudgetConfig
+	nodeName string
+	space    string
+
+	mu          sync.Mutex
+	windowStart time.Time // start time of the current QPS window
+	used        int       // number of requests within the current QPS window
+	latency     float64   // moving average latency in nanoseconds
+
+	delay atomic.Int64 // back-off delay for sync before each request
+}
+
+func newNodeBudget(space, nodeName string, cfg upstreamBudgetConfig) *nodeBudget {
+	return &nodeBudget{cfg: cfg, nodeName: nodeName, space: space}
+}
+
+func loadOrNewNodeBudget(space, nodeName string, cfg upstreamBudgetConfig) *nodeBudget {
+	v, _ := nodeBudgets.LoadOrStore(space+"/"+nodeName, newNodeBudget(space, nodeName, cfg))
+	return v.(*nodeBudget)
+}
+
+// consume consumes the budget for live request, which is never blocked.
+func (b *nodeBudget) consume() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rollWindow(time.Now())
+	b.used++
+}
+
+// acquire waits for the back-off delay and the remaining budget for sync request, or until
+// context canceled.
+func (b *nodeBudget) acquire(ctx context.Context) error {
+	if delay := time.Duration(b.delay.Load()); delay > 0 {
+		metrics.Registry.RPC.FullnodeSyncThrottled(b.space, b.nodeName).Mark(1)
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+
+	for {
+		wait, ok := b.tryAcquire(time.Now())
+		if ok {
+			return nil
+		}
+
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// tryAcquire consumes the budget if any remained within the current QPS window, otherwise returns
+// the duration to wait for the next window.
+func (b *nodeBudget) tryAcquire(now time.Time) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rollWindow(now)
+
+	if b.cfg.MaxQps > 0 && b.used >= b.cfg.MaxQps {
+		return b.windowStart.Add(budgetWindow).Sub(now), false
+	}
+
+	b.used++
+	return 0, true
+}
+
+// rollWindow starts a new QPS window if the current one elapsed, which requires lock held.
+func (b *nodeBudget) rollWindow(now time.Time) {
+	if now.Sub(b.windowStart) >= budgetWindow {
+		b.windowStart, b.used = now, 0
+	}
+}
+
+// feedback adjusts the back-off delay for sync with the latency and error of any request to full
+// node. The delay will be doubled if full node overloaded, and decreased gradually otherwise.
+func (b *nodeBudget) feedback(latency time.Duration, err error) time.Duration {
+	b.mu.Lock()
+	if b.latency == 0 {
+		b.latency = float64(latency)
+	} else {
+		b.latency += budgetLatencyEwmaWeight * (float64(latency) - b.latency)
+	}
+	avgLatency := time.Duration(b.latency)
+	b.mu.Unlock()
+
+	overloaded := isUpstreamOverloaded(err) || avgLatency > b.cfg.TargetLatency
+
+	delay := time.Duration(b.delay.Load())
+	if overloaded {
+		delay = min(max(2*delay, minSyncThrottleDelay), b.cfg.MaxDelay)
+	} else if delay -= delay / 8; delay < minSyncThrottleDelay {
+		delay = 0
+	}
+
+	b.delay.Store(int64(delay))
+	metrics.Registry.RPC.FullnodeSyncDelay(b.space, b.nodeName).Update(delay.Milliseconds())
+
+	return delay
+}
+
+// isUpstreamOverloaded checks if the error indicates that full node is overloaded, e.g., rate
+// limited or shed load.
+func isUpstreamOverloaded(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, ErrUpstreamOverloaded) {
+		return true
+	}
+
+	code, ok := rpcErrors.Code(err)
+	return ok && (code == rpcErrors.CodeRateLimited || code == rpcErrors.CodeUpstreamUnavailable)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// middlewareBudget accounts requests against the upstream budget of full node, and feeds back the
+// load of full node. Requests from syncers wait for the budget, while live requests never do.
+func middlewareBudget(budget *nodeBudget, sync bool) providers.CallContextMiddleware {
+	return func(handler providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			if !sync {
+				budget.consume()
+			} else if err := budget.acquire(ctx); err != nil {
+				return err
+			}
+
+			start := time.Now()
+			err := handler(ctx, result, method, args...)
+			budget.feedback(time.Since(start), err)
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeBudgetQps(t *testing.T) {
+	b := newNodeBudget("cfx", "node", upstreamBudgetConfig{Enabled: true, MaxQps: 2})
+
+	now := time.Now()
+	b.rollWindow(now)
+
+	// live requests are never throttled but consume the budget
+	b.consume()
+	_, ok := b.tryAcquire(now)
+	assert.True(t, ok)
+
+	wait, ok := b.tryAcquire(now.Add(100 * time.Millisecond))
+	assert.False(t, ok)
+	assert.Equal(t, 900*time.Millisecond, wait)
+
+	// budget renewed in the next window
+	_, ok = b.tryAcquire(now.Add(budgetWindow))
+	assert.True(t, ok)
+}
+
+func TestNodeBudgetFeedback(t *testing.T) {
+	b := newNodeBudget("cfx", "node", upstreamBudgetConfig{
+		Enabled:       true,
+		TargetLatency: 100 * time.Millisecond,
+		MaxDelay:      50 * time.Millisecond,
+	})
+
+	assert.Equal(t, time.Duration(0), b.feedback(10*time.Millisecond, nil))
+
+	// back off if rate limited
+	assert.Equal(t, minSyncThrottleDelay, b.feedback(10*time.Millisecond, errors.New("429 Too Many Requests")))
+	assert.Equal(t, 2*minSyncThrottleDelay, b.feedback(10*time.Millisecond, ErrUpstreamOverloaded))
+
+	// back off if latency too high, up to max delay
+	for i := 0; i < 20; i++ {
+		b.feedback(time.Second, nil)
+	}
+	assert.Equal(t, 50*time.Millisecond, time.Duration(b.delay.Load()))
+
+	// recover gradually once full node healthy
+	for i := 0; i < 100; i++ {
+		b.feedback(time.Millisecond, nil)
+	}
+	assert.Equal(t, time.Duration(0), time.Duration(b.delay.Load()))
+
+	// sync acquires without delay
+	assert.NoError(t, b.acquire(context.Background()))
+}
@@ -7,11 +7,13 @@ import (
 	"time"
 
 	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/mcuadros/go-defaults"
 	"github.com/openweb3/go-rpc-provider/utils"
 	"github.com/openweb3/web3go"
 	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
@@ -27,6 +29,8 @@ type EthCacheConfig struct {
 	PriceExpiration         time.Duration `default:"3s"`
 	CallCacheExpiration     time.Duration `default:"1s"`
 	CallCacheSize           int           `default:"128"`
+	HeaderCacheExpiration   time.Duration `default:"1m"`
+	HeaderCacheSize         int           `default:"1024"`
 }
 
 // newEthCacheConfig returns a EthCacheConfig with default values.
@@ -51,6 +55,7 @@ type EthCache struct {
 	priceCache         *expiryCache
 	blockNumberCache   *nodeExpiryCaches
 	callCache          *keyExpiryLruCaches
+	headerCache        *keyExpiryLruCaches
 }
 
 func newEthCache(cfg EthCacheConfig) *EthCache {
@@ -61,6 +66,7 @@ func newEthCache(cfg EthCacheConfig) *EthCache {
 		priceCache:         newExpiryCache(cfg.PriceExpiration),
 		blockNumberCache:   newNodeExpiryCaches(cfg.BlockNumberExpiration),
 		callCache:          newKeyExpiryLruCaches(cfg.CallCacheExpiration, cfg.CallCacheSize),
+		headerCache:        newKeyExpiryLruCaches(cfg.HeaderCacheExpiration, cfg.HeaderCacheSize),
 	}
 }
 
@@ -156,6 +162,43 @@ func (cache *EthCache) GetBlockNumberWithFunc(nodeName string, rawGetter func()
 	return val.(*hexutil.Big), loaded, nil
 }
 
+// GetBlockHeader returns the block (without transaction details) of the specified block hash.
+func (cache *EthCache) GetBlockHeader(client *web3go.Client, blockHash common.Hash) (*types.Block, bool, error) {
+	return cache.GetBlockHeaderWithFunc(blockHash, func() (interface{}, error) {
+		block, err := client.Eth.BlockByHash(blockHash, false)
+		if err != nil {
+			return nil, err
+		}
+
+		if block == nil {
+			return nil, errors.Errorf("block %v not found", blockHash)
+		}
+
+		return block, nil
+	})
+}
+
+// PeekBlockHeader returns the cached block (without transaction details) of the specified block
+// hash if any, which won't load from full node on missed.
+func (cache *EthCache) PeekBlockHeader(blockHash common.Hash) (*types.Block, bool) {
+	val, ok := cache.headerCache.get(blockHash.Hex())
+	if !ok {
+		return nil, false
+	}
+
+	return val.(*types.Block), true
+}
+
+func (cache *EthCache) GetBlockHeaderWithFunc(blockHash common.Hash, rawGetter func() (interface{}, error)) (*types.Block, bool, error) {
+	val, loaded, err := cache.headerCache.getOrUpdate(blockHash.Hex(), func() (interface{}, error) {
+		return rawGetter()
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return val.(*types.Block), loaded, nil
+}
+
 // RPCResult represents the result of an RPC call,
 // containing the response data or a potential JSON-RPC error.
 type RPCResult struct {
@@ -118,3 +118,13 @@ func (caches *keyExpiryLruCaches) getOrUpdate(cacheKey string, updateFunc func()
 
 	return val.(*expiryCache).getOrUpdate(updateFunc)
 }
+
+// get returns the cached value if not expired, without updating on missed.
+func (caches *keyExpiryLruCaches) get(cacheKey string) (interface{}, bool) {
+	val, ok := caches.key2Caches.Get(cacheKey)
+	if !ok {
+		return nil, false
+	}
+
+	return val.(*expiryCache).get()
+}
@@ -29,6 +29,22 @@ func (o *cfxClientOption) SetMaxConnsPerHost(maxConns int) {
 	o.MaxConnectionPerHost = maxConns
 }
 
+// providerOption returns the provider option the same as SDK generates.
+func (o *cfxClientOption) providerOption() providers.Option {
+	option := providers.Option{
+		RetryCount:           o.RetryCount,
+		RetryInterval:        o.RetryInterval,
+		RequestTimeout:       o.RequestTimeout,
+		MaxConnectionPerHost: o.MaxConnectionPerHost,
+	}
+
+	if o.CircuitBreakerOption != nil {
+		option.CircuitBreaker = providers.NewDefaultCircuitBreaker(*o.CircuitBreakerOption)
+	}
+
+	return option
+}
+
 func (o *cfxClientOption) SetCircuitBreaker(maxFail int, failTimeWindow, openColdTime time.Duration) {
 	o.CircuitBreakerOption = &providers.DefaultCircuitBreakerOption{
 		MaxFail:        maxFail,
@@ -63,19 +79,32 @@ func NewCfxClient(url string, options ...ClientOption) (*sdk.Client, error) {
 		},
 	}
 
-	if cbConf := cfxClientCfg.CircuitBreaker; cbConf.Enabled {
-		opt.SetCircuitBreaker(cbConf.MaxFail, cbConf.FailTimeWindow, cbConf.OpenColdTime)
-	}
-
 	for _, o := range options {
 		o(opt)
 	}
 
-	cfx, err := sdk.NewClient(url, *opt.ClientOption)
+	// credentials will be injected into HTTP requests by hook, or embedded in the dial URL for WebSocket
+	dialUrl, url, err := opt.registerUrl(url)
+	if err != nil {
+		return nil, err
+	}
+
+	cfx, err := sdk.NewClient(dialUrl, *opt.ClientOption)
 	if err != nil {
 		return cfx, err
 	}
 
+	if opt.maxIdleConnDuration > 0 {
+		p, err := newHttpProvider(dialUrl, opt.providerOption(), opt.maxIdleConnDuration)
+		if err != nil {
+			return nil, err
+		}
+
+		if p != nil {
+			cfx.MiddlewarableProvider = p
+		}
+	}
+
 	hookFlag := MiddlewareHookAll
 	if !opt.hookMetrics {
 		hookFlag ^= MiddlewareHookLogMetrics
@@ -83,6 +112,9 @@ func NewCfxClient(url string, options ...ClientOption) (*sdk.Client, error) {
 	if !opt.hookCache {
 		hookFlag ^= MiddlewareHookCache
 	}
+	if !opt.syncThrottle {
+		hookFlag ^= MiddlewareHookSyncThrottle
+	}
 	HookMiddlewares(cfx.Provider(), url, "cfx", hookFlag)
 
 	return cfx, nil
@@ -65,19 +65,32 @@ func NewEthClient(url string, options ...ClientOption) (*web3go.Client, error) {
 		},
 	}
 
-	if cbConfig := ethClientCfg.CircuitBreaker; cbConfig.Enabled {
-		opt.SetCircuitBreaker(cbConfig.MaxFail, cbConfig.FailTimeWindow, cbConfig.OpenColdTime)
-	}
-
 	for _, o := range options {
 		o(&opt)
 	}
 
-	eth, err := web3go.NewClientWithOption(url, opt.ClientOption)
+	// credentials will be injected into HTTP requests by hook, or embedded in the dial URL for WebSocket
+	dialUrl, url, err := opt.registerUrl(url)
+	if err != nil {
+		return nil, err
+	}
+
+	eth, err := web3go.NewClientWithOption(dialUrl, opt.ClientOption)
 	if err != nil {
 		return eth, err
 	}
 
+	if opt.maxIdleConnDuration > 0 {
+		p, err := newHttpProvider(dialUrl, opt.Option, opt.maxIdleConnDuration)
+		if err != nil {
+			return nil, err
+		}
+
+		if p != nil {
+			eth.SetProvider(p)
+		}
+	}
+
 	hookFlag := MiddlewareHookAll
 	if !opt.hookMetrics {
 		hookFlag ^= MiddlewareHookLogMetrics
@@ -85,6 +98,9 @@ func NewEthClient(url string, options ...ClientOption) (*web3go.Client, error) {
 	if !opt.hookCache {
 		hookFlag ^= MiddlewareHookCache
 	}
+	if !opt.syncThrottle {
+		hookFlag ^= MiddlewareHookSyncThrottle
+	}
 	HookMiddlewares(eth.Provider(), url, "eth", hookFlag)
 
 	return eth, nil
@@ -7,9 +7,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Conflux-Chain/confura/util/logging"
 	"github.com/Conflux-Chain/confura/util/metrics"
 	"github.com/Conflux-Chain/confura/util/rpc/cache"
 	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/confura/util/slowlog"
+	"github.com/Conflux-Chain/confura/util/tracing"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
 	"github.com/openweb3/go-rpc-provider"
 	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
@@ -18,6 +21,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type cacheHandlerCtxKey struct{}
@@ -101,10 +106,38 @@ func HookRedirectHttpHeader() {
 			}
 		}
 
+		if id, ok := logging.RequestIdFromContext(ctx); ok {
+			req.Header.Set(logging.HeaderRequestId, id)
+		}
+
+		// propagate trace context to full node
+		tracing.Inject(ctx, fasthttpHeaderCarrier{&req.Header})
+
 		return nil
 	})
 }
 
+// fasthttpHeaderCarrier adapts fasthttp request header to propagate trace context.
+type fasthttpHeaderCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+func (c fasthttpHeaderCarrier) Get(key string) string {
+	return string(c.header.Peek(key))
+}
+
+func (c fasthttpHeaderCarrier) Set(key, value string) {
+	c.header.Set(key, value)
+}
+
+func (c fasthttpHeaderCarrier) Keys() (keys []string) {
+	c.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+
+	return keys
+}
+
 func Url2NodeName(url string) string {
 	nodeName := strings.ToLower(url)
 	nodeName = strings.TrimPrefix(nodeName, "http://")
@@ -132,16 +165,45 @@ const (
 
 	// MiddlewareHookCache enables cache middleware hook.
 	MiddlewareHookCache
+
+	// MiddlewareHookCircuitBreaker enables circuit breaker middleware hook if configured.
+	MiddlewareHookCircuitBreaker
+
+	// MiddlewareHookConcurrencyLimit enables concurrency limit middleware hook if configured.
+	MiddlewareHookConcurrencyLimit
+
+	// MiddlewareHookTracing enables tracing middleware hook.
+	MiddlewareHookTracing
+
+	// MiddlewareHookBudget enables upstream budget middleware hook if configured.
+	MiddlewareHookBudget
+
+	// MiddlewareHookSyncThrottle throttles requests as sync against upstream budget, which must be
+	// specified explicitly and is not enabled by default.
+	MiddlewareHookSyncThrottle
+
+	// MiddlewareHookPlugins enables the registered client plugins if any.
+	MiddlewareHookPlugins
 )
 
 func HookMiddlewares(provider *providers.MiddlewarableProvider, url, space string, flags ...MiddlewareHookFlag) {
 	nodeName := Url2NodeName(url)
 
-	flag := MiddlewareHookAll
+	flag := MiddlewareHookAll &^ MiddlewareHookSyncThrottle
 	if len(flags) > 0 {
 		flag = flags[0]
 	}
 
+	// hooked at first to be the outermost middleware, so that cache hit could also be traced
+	if flag&MiddlewareHookTracing != 0 {
+		provider.HookCallContext(middlewareTracing(nodeName, space))
+	}
+
+	// hooked outside cache, so that plugins could mutate requests before cached
+	if plugins := registeredClientPlugins(); flag&MiddlewareHookPlugins != 0 && len(plugins) > 0 {
+		provider.HookCallContext(middlewarePlugins(nodeName, space, plugins))
+	}
+
 	if flag&MiddlewareHookCache != 0 {
 		provider.HookCallContext(middlewareCache(nodeName, space))
 	}
@@ -153,6 +215,49 @@ func HookMiddlewares(provider *providers.MiddlewarableProvider, url, space strin
 	if flag&MiddlewareHookLogMetrics != 0 {
 		provider.HookCallContext(middlewareMetrics(nodeName, space))
 	}
+
+	// hooked outside circuit breaker and concurrency limit, so that sync requests will not occupy
+	// in-flight slots while backing off
+	if bConf := budgetConfig(space); flag&MiddlewareHookBudget != 0 && bConf.Enabled {
+		budget := loadOrNewNodeBudget(space, nodeName, bConf)
+		provider.HookCallContext(middlewareBudget(budget, flag&MiddlewareHookSyncThrottle != 0))
+	}
+
+	// hooked at last to be the innermost middleware, so that cache hit will not be blocked
+	if cbConf := breakerConfig(space); flag&MiddlewareHookCircuitBreaker != 0 && cbConf.Enabled {
+		breaker := loadOrNewNodeBreaker(space, nodeName, cbConf)
+		provider.HookCallContext(middlewareCircuitBreaker(breaker))
+	}
+
+	// hooked inside circuit breaker, so that requests will not be queued when circuit breaker open
+	if clConf := concurrencyConfig(space); flag&MiddlewareHookConcurrencyLimit != 0 && clConf.Enabled {
+		limiter := loadOrNewNodeLimiter(space, nodeName, clConf)
+		provider.HookCallContext(middlewareConcurrencyLimit(limiter))
+	}
+}
+
+func breakerConfig(space string) circuitBreakerConfig {
+	if space == "eth" {
+		return ethClientCfg.CircuitBreaker
+	}
+
+	return cfxClientCfg.CircuitBreaker
+}
+
+func budgetConfig(space string) upstreamBudgetConfig {
+	if space == "eth" {
+		return ethClientCfg.Budget
+	}
+
+	return cfxClientCfg.Budget
+}
+
+func concurrencyConfig(space string) concurrencyLimitConfig {
+	if space == "eth" {
+		return ethClientCfg.Concurrency
+	}
+
+	return cfxClientCfg.Concurrency
 }
 
 func middlewareMetrics(fullnode, space string) providers.CallContextMiddleware {
@@ -163,6 +268,7 @@ func middlewareMetrics(fullnode, space string) providers.CallContextMiddleware {
 			err := handler(ctx, result, method, args...)
 
 			metrics.Registry.RPC.FullnodeQps(fullnode, space, method, err).UpdateSince(start)
+			slowlog.Observe(ctx, "fullnode", time.Since(start))
 
 			// overall error rate for each full node
 			metrics.Registry.RPC.FullnodeErrorRate().Mark(err != nil)
@@ -171,6 +277,29 @@ func middlewareMetrics(fullnode, space string) providers.CallContextMiddleware {
 			metrics.Registry.RPC.FullnodeNonRpcErrorRate().Mark(nonRpcErr)
 			metrics.Registry.RPC.FullnodeNonRpcErrorRate(fullnode).Mark(nonRpcErr)
 
+			// error taxonomy for each full node and method
+			if err != nil {
+				category := classifyUpstreamError(err)
+				metrics.Registry.RPC.FullnodeErrors(space, fullnode, method, category).Mark(1)
+			}
+
+			return err
+		}
+	}
+}
+
+func middlewareTracing(fullnode, space string) providers.CallContextMiddleware {
+	return func(handler providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			ctx, span := tracing.Start(ctx, "fullnode/"+method,
+				attribute.String("rpc.method", method),
+				attribute.String("rpc.space", space),
+				attribute.String("fullnode", fullnode),
+			)
+
+			err := handler(ctx, result, method, args...)
+			tracing.End(span, err)
+
 			return err
 		}
 	}
@@ -183,7 +312,7 @@ func middlewareLog(fullnode, space string) providers.CallContextMiddleware {
 				return handler(ctx, result, method, args...)
 			}
 
-			logger := logrus.WithFields(logrus.Fields{
+			logger := logging.FromContext(ctx).WithFields(logrus.Fields{
 				"fullnode": fullnode,
 				"space":    space,
 				"method":   method,
@@ -232,6 +361,7 @@ func middlewareCache(fullnode, space string) providers.CallContextMiddleware {
 				return err
 			}
 			metrics.Registry.Client.CacheHit(method).Mark(loaded)
+			trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("cache.hit", loaded))
 			return processCacheResult(result, val)
 		}
 	}
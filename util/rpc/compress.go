@@ -0,0 +1,318 @@
+package rpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/andybalholm/brotli"
+)
+
+// Supported content encodings of HTTP response.
+const (
+	encodingGzip   = "gzip"
+	encodingBrotli = "br"
+)
+
+// compressionConfig represents the configuration to compress large responses, which is negotiated
+// by `Accept-Encoding` for HTTP and `permessage-deflate` extension for websocket.
+type compressionConfig struct {
+	Enabled bool
+	// min size in bytes of response to compress, smaller ones are not worth the CPU cost
+	MinSize int `default:"1024"`
+	// RPC methods whose responses are never compressed over HTTP, e.g., latency sensitive ones
+	ExcludedMethods []string
+	// compression level of gzip (1~9) and brotli (0~11)
+	GzipLevel   int `default:"5"`
+	BrotliLevel int `default:"4"`
+}
+
+func mustNewCompressionConfigFromViper() *compressionConfig {
+	var conf compressionConfig
+	viper.MustUnmarshalKey("rpc.compression", &conf)
+
+	return &conf
+}
+
+// compressHandler compresses HTTP responses in the content encoding negotiated with client.
+type compressHandler struct {
+	name     string
+	conf     *compressionConfig
+	excluded map[string]bool
+	next     http.Handler
+
+	gzPool sync.Pool // *gzip.Writer
+	brPool sync.Pool // *brotli.Writer
+}
+
+func newCompressHandler(name string, conf *compressionConfig, next http.Handler) http.Handler {
+	if !conf.Enabled {
+		return next
+	}
+
+	h := &compressHandler{
+		name:     name,
+		conf:     conf,
+		excluded: make(map[string]bool, len(conf.ExcludedMethods)),
+		next:     next,
+	}
+
+	for _, method := range conf.ExcludedMethods {
+		h.excluded[method] = true
+	}
+
+	h.gzPool.New = func() interface{} {
+		w, err := gzip.NewWriterLevel(io.Discard, conf.GzipLevel)
+		if err != nil {
+			w = gzip.NewWriter(io.Discard)
+		}
+
+		return w
+	}
+
+	h.brPool.New = func() interface{} {
+		return brotli.NewWriterLevel(io.Discard, conf.BrotliLevel)
+	}
+
+	return h
+}
+
+func (h *compressHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+	if len(encoding) == 0 || h.isExcluded(r) {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	cw := &compressResponseWriter{
+		ResponseWriter: w,
+		h:              h,
+		encoding:       encoding,
+		status:         http.StatusOK,
+	}
+	defer cw.close()
+
+	h.next.ServeHTTP(cw, r)
+}
+
+// isExcluded checks if any RPC method of request (or batch) is excluded from compression.
+func (h *compressHandler) isExcluded(r *http.Request) bool {
+	if len(h.excluded) == 0 || r.Method != http.MethodPost || r.Body == nil {
+		return false
+	}
+
+	body, err := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+
+	for _, method := range requestMethods(body) {
+		if h.excluded[method] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requestMethods returns the RPC methods of JSON-RPC request or batch.
+func requestMethods(body []byte) []string {
+	type request struct {
+		Method string `json:"method"`
+	}
+
+	body = bytes.TrimSpace(body)
+
+	if len(body) > 0 && body[0] == '[' {
+		var batch []request
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return nil
+		}
+
+		methods := make([]string, 0, len(batch))
+		for _, v := range batch {
+			methods = append(methods, v.Method)
+		}
+
+		return methods
+	}
+
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil
+	}
+
+	return []string{req.Method}
+}
+
+// negotiateEncoding returns the preferred content encoding accepted by client, brotli over gzip,
+// or empty string if none accepted.
+func negotiateEncoding(acceptEncoding string) string {
+	var gzipAccepted, brotliAccepted bool
+
+	for _, v := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(v, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+
+		// encoding explicitly refused by `q=0`
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				continue
+			}
+		}
+
+		switch coding {
+		case encodingBrotli:
+			brotliAccepted = true
+		case encodingGzip, "*":
+			gzipAccepted = true
+		}
+	}
+
+	switch {
+	case brotliAccepted:
+		return encodingBrotli
+	case gzipAccepted:
+		return encodingGzip
+	default:
+		return ""
+	}
+}
+
+// compressResponseWriter buffers the response until the min size reached, and then compresses
+// the rest on the fly. Otherwise, the buffered response is written uncompressed.
+type compressResponseWriter struct {
+	http.ResponseWriter
+
+	h        *compressHandler
+	encoding string
+	status   int
+
+	wroteHeader bool
+	buf         []byte         // buffered response before compression started
+	encoder     io.WriteCloser // nil if compression not started
+	counter     countingWriter // counts the compressed bytes
+	rawSize     int            // size of uncompressed response
+	release     func()         // returns the encoder to pool
+}
+
+func (w *compressResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = status, true
+	}
+}
+
+func (w *compressResponseWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	w.rawSize += len(p)
+
+	if w.encoder != nil {
+		return w.encoder.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) < w.h.conf.MinSize {
+		return len(p), nil
+	}
+
+	// response already encoded by the next handler
+	if len(w.Header().Get("Content-Encoding")) > 0 {
+		if err := w.flushRaw(); err != nil {
+			return 0, err
+		}
+
+		return len(p), nil
+	}
+
+	if err := w.startCompression(); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (w *compressResponseWriter) startCompression() error {
+	header := w.Header()
+	header.Set("Content-Encoding", w.encoding)
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.counter.w = w.ResponseWriter
+
+	switch w.encoding {
+	case encodingBrotli:
+		bw := w.h.brPool.Get().(*brotli.Writer)
+		bw.Reset(&w.counter)
+		w.encoder, w.release = bw, func() { w.h.brPool.Put(bw) }
+	default:
+		gw := w.h.gzPool.Get().(*gzip.Writer)
+		gw.Reset(&w.counter)
+		w.encoder, w.release = gw, func() { w.h.gzPool.Put(gw) }
+	}
+
+	buf := w.buf
+	w.buf = nil
+
+	_, err := w.encoder.Write(buf)
+	return err
+}
+
+// flushRaw writes the buffered response uncompressed, and passes through the rest.
+func (w *compressResponseWriter) flushRaw() error {
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	w.encoder = nopWriteCloser{w.ResponseWriter}
+
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// close flushes the compressed or buffered response, and updates metrics of bytes saved.
+func (w *compressResponseWriter) close() {
+	if w.encoder == nil {
+		if w.wroteHeader {
+			w.flushRaw()
+		}
+
+		return
+	}
+
+	w.encoder.Close()
+
+	if w.release == nil { // not compressed
+		return
+	}
+
+	w.release()
+
+	metrics.Registry.RPC.CompressionSavedBytes(w.h.name, w.encoding).Mark(int64(w.rawSize - w.counter.n))
+	metrics.Registry.RPC.CompressionRatio(w.h.name, w.encoding).Update(int64(w.counter.n * 100 / max(w.rawSize, 1)))
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
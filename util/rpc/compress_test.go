@@ -0,0 +1,77 @@
+package rpc
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	assert.Equal(t, "", negotiateEncoding(""))
+	assert.Equal(t, "", negotiateEncoding("identity"))
+	assert.Equal(t, encodingGzip, negotiateEncoding("gzip, deflate"))
+	assert.Equal(t, encodingGzip, negotiateEncoding("*"))
+	assert.Equal(t, encodingBrotli, negotiateEncoding("gzip, deflate, br"))
+	assert.Equal(t, encodingGzip, negotiateEncoding("gzip;q=0.8, br;q=0"))
+	assert.Equal(t, "", negotiateEncoding("gzip;q=0"))
+}
+
+func TestRequestMethods(t *testing.T) {
+	assert.Equal(t, []string{"eth_getLogs"}, requestMethods([]byte(`{"method":"eth_getLogs"}`)))
+	assert.Equal(t, []string{"eth_chainId", "eth_blockNumber"}, requestMethods([]byte(
+		` [{"method":"eth_chainId"},{"method":"eth_blockNumber"}]`,
+	)))
+	assert.Nil(t, requestMethods([]byte(`invalid`)))
+}
+
+func serveCompressed(conf *compressionConfig, respSize int, body string) *httptest.ResponseRecorder {
+	handler := newCompressHandler("test", conf, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(strings.Repeat("a", respSize)))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	return recorder
+}
+
+func TestCompressHandler(t *testing.T) {
+	conf := &compressionConfig{
+		Enabled:         true,
+		MinSize:         100,
+		ExcludedMethods: []string{"eth_chainId"},
+		GzipLevel:       5,
+	}
+
+	// small response not compressed
+	recorder := serveCompressed(conf, 50, `{"method":"eth_getLogs"}`)
+	assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, strings.Repeat("a", 50), recorder.Body.String())
+
+	// excluded method not compressed
+	recorder = serveCompressed(conf, 500, `[{"method":"eth_getLogs"},{"method":"eth_chainId"}]`)
+	assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, 500, recorder.Body.Len())
+
+	// large response compressed
+	recorder = serveCompressed(conf, 500, `{"method":"eth_getLogs"}`)
+	assert.Equal(t, encodingGzip, recorder.Header().Get("Content-Encoding"))
+	assert.Less(t, recorder.Body.Len(), 500)
+
+	reader, err := gzip.NewReader(recorder.Body)
+	assert.NoError(t, err)
+
+	data, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("a", 500), string(data))
+}
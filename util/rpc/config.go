@@ -1,9 +1,14 @@
 package rpc
 
 import (
+	"net/url"
 	"time"
 
 	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/mcuadros/go-defaults"
+	"github.com/openweb3/go-rpc-provider"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/valyala/fasthttp"
 )
 
 var (
@@ -25,6 +30,8 @@ type clientConfig struct {
 	RequestTimeout  time.Duration `default:"3s"`
 	MaxConnsPerHost int           `default:"1024"`
 	CircuitBreaker  circuitBreakerConfig
+	Concurrency     concurrencyLimitConfig
+	Budget          upstreamBudgetConfig
 }
 
 type ClientOptioner interface {
@@ -32,14 +39,49 @@ type ClientOptioner interface {
 	SetRetryInterval(retryInterval time.Duration)
 	SetRequestTimeout(reqTimeout time.Duration)
 	SetMaxConnsPerHost(maxConns int)
+	SetMaxIdleConnDuration(d time.Duration)
+	SetNodeGroup(group string)
+	SetExternal(external bool)
 	SetHookMetrics(hook bool)
 	SetHookCache(hook bool)
+	SetSyncThrottle(throttle bool)
 	SetCircuitBreaker(maxFail int, failTimeWindow, openColdTime time.Duration)
 }
 
 type baseClientOption struct {
-	hookMetrics bool
-	hookCache   bool
+	hookMetrics  bool
+	hookCache    bool
+	syncThrottle bool
+
+	// duration to close idle keep-alive HTTP connections, which uses the default if not specified
+	maxIdleConnDuration time.Duration
+
+	// node group of the full node to scope credentials, empty if not managed by node manager
+	nodeGroup string
+	// whether the upstream is not full node, which never injects node credentials
+	external bool
+}
+
+func (o *baseClientOption) SetMaxIdleConnDuration(d time.Duration) {
+	o.maxIdleConnDuration = d
+}
+
+func (o *baseClientOption) SetNodeGroup(group string) {
+	o.nodeGroup = group
+}
+
+func (o *baseClientOption) SetExternal(external bool) {
+	o.external = external
+}
+
+// registerUrl registers the upstream URL to inject credentials, and returns the URL to dial along
+// with the URL without user info.
+func (o *baseClientOption) registerUrl(rawUrl string) (dialUrl, nodeUrl string, err error) {
+	if o.external {
+		return credStore.registerExternalUrl(rawUrl)
+	}
+
+	return credStore.registerUrl(rawUrl, o.nodeGroup)
 }
 
 func (o *baseClientOption) SetHookMetrics(hook bool) {
@@ -50,6 +92,10 @@ func (o *baseClientOption) SetHookCache(hook bool) {
 	o.hookCache = hook
 }
 
+func (o *baseClientOption) SetSyncThrottle(throttle bool) {
+	o.syncThrottle = throttle
+}
+
 type ClientOption func(opt ClientOptioner)
 
 func WithClientRetryCount(retry int) ClientOption {
@@ -76,6 +122,30 @@ func WithClientMaxConnsPerHost(maxConns int) ClientOption {
 	}
 }
 
+// WithClientMaxIdleConnDuration closes the idle keep-alive HTTP connections after the specified
+// duration.
+func WithClientMaxIdleConnDuration(d time.Duration) ClientOption {
+	return func(opt ClientOptioner) {
+		opt.SetMaxIdleConnDuration(d)
+	}
+}
+
+// WithClientNodeGroup specifies the node group of the full node, so that credentials scoped to
+// node groups could be injected.
+func WithClientNodeGroup(group string) ClientOption {
+	return func(opt ClientOptioner) {
+		opt.SetNodeGroup(group)
+	}
+}
+
+// WithClientExternal specifies the upstream is not a full node (e.g., federated historical backend),
+// so that node credentials will never be injected.
+func WithClientExternal(external bool) ClientOption {
+	return func(opt ClientOptioner) {
+		opt.SetExternal(external)
+	}
+}
+
 func WithClientHookMetrics(hook bool) ClientOption {
 	return func(opt ClientOptioner) {
 		opt.SetHookMetrics(hook)
@@ -88,6 +158,14 @@ func WithClientHookCache(hook bool) ClientOption {
 	}
 }
 
+// WithClientSyncThrottle throttles requests of sync client against the upstream budget shared
+// with the live RPC proxy.
+func WithClientSyncThrottle(throttle bool) ClientOption {
+	return func(opt ClientOptioner) {
+		opt.SetSyncThrottle(throttle)
+	}
+}
+
 func WithCircuitBreaker(maxFail int, failTimeWindow, openColdTime time.Duration) ClientOption {
 	return func(opt ClientOptioner) {
 		opt.SetCircuitBreaker(maxFail, failTimeWindow, openColdTime)
@@ -97,4 +175,38 @@ func WithCircuitBreaker(maxFail int, failTimeWindow, openColdTime time.Duration)
 func MustInit() {
 	viper.MustUnmarshalKey("cfx", &cfxClientCfg)
 	viper.MustUnmarshalKey("eth", &ethClientCfg)
+
+	mustInitCredentials()
+}
+
+// newHttpProvider creates the provider of HTTP(S) full node with idle connection timeout, or nil
+// if not a HTTP(S) URL.
+func newHttpProvider(
+	rawUrl string, option providers.Option, maxIdleConnDuration time.Duration,
+) (*providers.MiddlewarableProvider, error) {
+	if u, err := url.Parse(rawUrl); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, nil
+	}
+
+	defaults.SetDefaults(&option)
+
+	client := &fasthttp.Client{
+		MaxConnsPerHost:     option.MaxConnectionPerHost,
+		MaxIdleConnDuration: maxIdleConnDuration,
+	}
+
+	p, err := rpc.DialHTTPWithClient(rawUrl, client)
+	if err != nil {
+		return nil, err
+	}
+
+	mp := providers.NewMiddlewarableProvider(p)
+	if option.CircuitBreaker != nil {
+		mp = providers.NewCircuitBreakerProvider(mp, option.CircuitBreaker)
+	}
+
+	mp = providers.NewTimeoutableProvider(mp, option.RequestTimeout)
+	mp = providers.NewRetriableProvider(mp, option.RetryCount, option.RetryInterval)
+
+	return mp, nil
 }
@@ -0,0 +1,143 @@
+package rpc
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/rs/cors"
+	"github.com/sirupsen/logrus"
+)
+
+// corsConfig represents the CORS configuration of HTTP and WebSocket listeners, so that browser
+// dapps could access RPC servers directly without an extra reverse proxy.
+type corsConfig struct {
+	// whether to disable CORS, which is enabled for all origins by default
+	Disabled bool
+	// allowed origins, which may contain a wildcard, e.g., `https://*.example.com`
+	AllowedOrigins []string `default:"[*]"`
+	AllowedMethods []string `default:"[POST,GET]"`
+	AllowedHeaders []string `default:"[*]"`
+	// response headers exposed to browser, e.g., `X-Request-Id`
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// how long the results of preflight request could be cached by browser
+	MaxAge time.Duration `default:"10m"`
+}
+
+type corsConfigs struct {
+	corsConfig `mapstructure:",squash"`
+	// overrides of non-empty configurations keyed by RPC server name, e.g., `core_space_rpc`
+	Servers map[string]corsConfig
+}
+
+// mustNewCorsConfigFromViper creates the CORS configuration of the specified RPC server, or nil
+// if disabled.
+func mustNewCorsConfigFromViper(name string) *corsConfig {
+	var confs corsConfigs
+	viper.MustUnmarshalKey("rpc.cors", &confs)
+
+	conf := confs.corsConfig
+	if override, ok := confs.Servers[strings.ToLower(name)]; ok {
+		conf.merge(&override)
+	}
+
+	if conf.Disabled {
+		return nil
+	}
+
+	if len(conf.AllowedOrigins) == 0 {
+		logrus.WithField("name", name).Fatal("No allowed origin configured for CORS")
+	}
+
+	return &conf
+}
+
+// merge overrides the non-empty configurations.
+func (conf *corsConfig) merge(override *corsConfig) {
+	if override.Disabled {
+		conf.Disabled = true
+	}
+
+	if len(override.AllowedOrigins) > 0 {
+		conf.AllowedOrigins = override.AllowedOrigins
+	}
+
+	if len(override.AllowedMethods) > 0 {
+		conf.AllowedMethods = override.AllowedMethods
+	}
+
+	if len(override.AllowedHeaders) > 0 {
+		conf.AllowedHeaders = override.AllowedHeaders
+	}
+
+	if len(override.ExposedHeaders) > 0 {
+		conf.ExposedHeaders = override.ExposedHeaders
+	}
+
+	if override.AllowCredentials {
+		conf.AllowCredentials = true
+	}
+
+	if override.MaxAge > 0 {
+		conf.MaxAge = override.MaxAge
+	}
+}
+
+// isOriginAllowed checks if the origin matches any allowed origin, which may contain a wildcard.
+func (conf *corsConfig) isOriginAllowed(origin string) bool {
+	origin = strings.ToLower(origin)
+
+	for _, allowed := range conf.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+
+		prefix, suffix, ok := strings.Cut(allowed, "*")
+		if ok && len(origin) >= len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newCorsHandler handles CORS preflight and actual requests, or disables CORS if not configured.
+func newCorsHandler(srv http.Handler, conf *corsConfig) http.Handler {
+	if conf == nil {
+		return srv
+	}
+
+	c := cors.New(cors.Options{
+		AllowedOrigins:   conf.AllowedOrigins,
+		AllowedMethods:   conf.AllowedMethods,
+		AllowedHeaders:   conf.AllowedHeaders,
+		ExposedHeaders:   conf.ExposedHeaders,
+		AllowCredentials: conf.AllowCredentials,
+		MaxAge:           int(conf.MaxAge.Seconds()),
+	})
+
+	return c.Handler(srv)
+}
+
+// newWsOriginHandler rejects WebSocket handshakes from browsers of origins not allowed, or allows
+// all origins if CORS disabled.
+func newWsOriginHandler(srv http.Handler, conf *corsConfig) http.Handler {
+	if conf == nil {
+		return srv
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// non-browser clients may not specify origin
+		if origin := r.Header.Get("Origin"); len(origin) > 0 && !conf.isOriginAllowed(origin) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+
+		srv.ServeHTTP(w, r)
+	})
+}
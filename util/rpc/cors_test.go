@@ -0,0 +1,41 @@
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorsOriginAllowed(t *testing.T) {
+	conf := corsConfig{AllowedOrigins: []string{"https://app.example.org", "https://*.example.com"}}
+
+	assert.True(t, conf.isOriginAllowed("https://app.example.org"))
+	assert.True(t, conf.isOriginAllowed("https://APP.example.org"))
+	assert.True(t, conf.isOriginAllowed("https://dapp.example.com"))
+	assert.False(t, conf.isOriginAllowed("https://example.com"))
+	assert.False(t, conf.isOriginAllowed("http://dapp.example.com"))
+	assert.False(t, conf.isOriginAllowed("https://evil.org"))
+
+	conf.AllowedOrigins = []string{"*"}
+	assert.True(t, conf.isOriginAllowed("https://evil.org"))
+}
+
+func TestCorsConfigMerge(t *testing.T) {
+	conf := corsConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"POST", "GET"},
+		MaxAge:         10 * time.Minute,
+	}
+
+	conf.merge(&corsConfig{AllowedOrigins: []string{"https://*.example.com"}, AllowCredentials: true})
+
+	assert.Equal(t, []string{"https://*.example.com"}, conf.AllowedOrigins)
+	assert.Equal(t, []string{"POST", "GET"}, conf.AllowedMethods)
+	assert.True(t, conf.AllowCredentials)
+	assert.Equal(t, 10*time.Minute, conf.MaxAge)
+	assert.False(t, conf.Disabled)
+
+	conf.merge(&corsConfig{Disabled: true})
+	assert.True(t, conf.Disabled)
+}
@@ -0,0 +1,419 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// secret value prefixes to source credentials from
+	secretPrefixEnv  = "env:"
+	secretPrefixFile = "file:"
+
+	// wildcard host to match all upstream node endpoints
+	credentialHostAny = "*"
+)
+
+var (
+	credStore = newCredentialStore()
+
+	errWsTokenCredential = errors.New(
+		"token credential not supported for WebSocket endpoint, please use basic auth instead",
+	)
+)
+
+// credentialEntryConfig represents the auth credentials of some upstream node endpoints.
+//
+// Secret values (token, username and password) could be literal, or sourced from environment
+// variable with `env:` prefix or file (eg., mounted k8s secret) with `file:` prefix.
+type credentialEntryConfig struct {
+	// Node endpoint hosts (with port if any) to inject credentials into, `*` for all
+	Hosts []string
+	// Node groups (eg., `cfxhttp`, `ethws`) to inject credentials into, empty for all node
+	// endpoints including those not managed by node manager (eg., sync clients)
+	Groups []string
+	// Bearer auth token
+	Token string
+	// Custom header to carry token other than `Authorization` with bearer scheme
+	Header string
+	// Basic auth username and password
+	Username string
+	Password string
+}
+
+type credentialConfig struct {
+	// Interval to reload credentials from secrets for rotation
+	ReloadInterval time.Duration `default:"1m"`
+	Entries        []credentialEntryConfig
+}
+
+// credential is the resolved HTTP header to inject into upstream requests.
+type credential struct {
+	header string
+	value  string
+	// basic auth user info, which is used to dial WebSocket endpoints
+	user *url.Userinfo
+}
+
+// equal checks if the same credential is injected.
+func (c credential) equal(other credential) bool {
+	return c.header == other.header && c.value == other.value
+}
+
+// credentialEntry is the resolved credential along with the node endpoints to apply.
+type credentialEntry struct {
+	hosts  map[string]struct{}
+	groups map[string]struct{}
+	cred   credential
+}
+
+func newCredentialEntry(c *credentialEntryConfig) (*credentialEntry, error) {
+	cred, err := c.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &credentialEntry{
+		hosts:  make(map[string]struct{}),
+		groups: make(map[string]struct{}),
+		cred:   cred,
+	}
+
+	for _, host := range c.Hosts {
+		entry.hosts[strings.ToLower(host)] = struct{}{}
+	}
+
+	for _, grp := range c.Groups {
+		entry.groups[strings.ToLower(grp)] = struct{}{}
+	}
+
+	return entry, nil
+}
+
+// matches checks if the credential applies to the node endpoint host of the specified groups.
+func (e *credentialEntry) matches(host string, groups map[string]struct{}) bool {
+	if _, ok := e.hosts[host]; !ok {
+		return false
+	}
+
+	if len(e.groups) == 0 {
+		return true
+	}
+
+	for grp := range groups {
+		if _, ok := e.groups[grp]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *credentialEntryConfig) resolve() (cred credential, err error) {
+	if len(c.Token) > 0 {
+		token, err := resolveSecret(c.Token)
+		if err != nil {
+			return cred, errors.WithMessage(err, "failed to resolve token")
+		}
+
+		if len(c.Header) > 0 {
+			return credential{header: c.Header, value: token}, nil
+		}
+
+		return credential{header: fasthttp.HeaderAuthorization, value: "Bearer " + token}, nil
+	}
+
+	username, err := resolveSecret(c.Username)
+	if err != nil {
+		return cred, errors.WithMessage(err, "failed to resolve username")
+	}
+
+	password, err := resolveSecret(c.Password)
+	if err != nil {
+		return cred, errors.WithMessage(err, "failed to resolve password")
+	}
+
+	return newBasicAuthCredential(username, password), nil
+}
+
+func newBasicAuthCredential(username, password string) credential {
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return credential{
+		header: fasthttp.HeaderAuthorization,
+		value:  "Basic " + auth,
+		user:   url.UserPassword(username, password),
+	}
+}
+
+func resolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, secretPrefixEnv):
+		name := strings.TrimPrefix(value, secretPrefixEnv)
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", errors.Errorf("environment variable %v not found", name)
+		}
+		return secret, nil
+	case strings.HasPrefix(value, secretPrefixFile):
+		data, err := os.ReadFile(strings.TrimPrefix(value, secretPrefixFile))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	default:
+		return value, nil
+	}
+}
+
+// credentialStore holds the credentials of upstream node endpoints, which will be injected
+// into HTTP requests right before sending. Credentials are rotated by atomic replacement, so
+// that in-flight requests will never be affected and there's no need to recreate clients.
+//
+// Note, the hook to inject credentials is global for all HTTP clients, so credentials are scoped
+// to the registered node endpoints only and never leaked to any other host (e.g., webhook receivers
+// or federated historical backend).
+type credentialStore struct {
+	mu sync.RWMutex
+	// hosts of upstream node endpoints registered by node clients => node groups if any
+	nodeHosts map[string]map[string]struct{}
+	// hosts of WebSocket node endpoints, whose credentials are applied at dial time
+	wsHosts map[string]struct{}
+	// host => credential parsed from URL user info or registered for internal services
+	urlCreds map[string]credential
+	// credentials resolved from configured secrets, which only apply to node endpoints
+	entries []*credentialEntry
+	// observers notified once credentials of WebSocket node endpoints rotated
+	wsObservers []func(host string)
+}
+
+func newCredentialStore() *credentialStore {
+	return &credentialStore{
+		nodeHosts: make(map[string]map[string]struct{}),
+		wsHosts:   make(map[string]struct{}),
+		urlCreds:  make(map[string]credential),
+	}
+}
+
+// registerUrl registers the upstream node endpoint of the specified node group (empty if not
+// managed by node manager) to inject credentials into, and returns the URL to dial along with
+// the URL without user info to avoid leaking credential in logs or metrics.
+//
+// WebSocket connections are not sent via the HTTP hook, so the basic auth credential is embedded
+// into the URL to dial instead, which is applied at handshake only. Therefore, token credentials
+// are not supported for WebSocket endpoints, and clients should be re-dialed once rotated (see
+// `OnWsCredentialRotated`).
+func (cs *credentialStore) registerUrl(rawUrl, group string) (dialUrl, nodeUrl string, err error) {
+	return cs.register(rawUrl, group, true)
+}
+
+// registerExternalUrl registers the upstream endpoint other than full node (e.g., federated
+// historical backend), which only applies the credential of URL user info if any.
+func (cs *credentialStore) registerExternalUrl(rawUrl string) (dialUrl, nodeUrl string, err error) {
+	return cs.register(rawUrl, "", false)
+}
+
+func (cs *credentialStore) register(rawUrl, group string, node bool) (dialUrl, nodeUrl string, err error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl, rawUrl, nil
+	}
+
+	host := strings.ToLower(u.Host)
+	isWs := u.Scheme == "ws" || u.Scheme == "wss"
+
+	cs.mu.Lock()
+	if node {
+		if _, ok := cs.nodeHosts[host]; !ok {
+			cs.nodeHosts[host] = make(map[string]struct{})
+		}
+
+		if len(group) > 0 {
+			cs.nodeHosts[host][strings.ToLower(group)] = struct{}{}
+		}
+
+		if isWs {
+			cs.wsHosts[host] = struct{}{}
+		}
+	}
+
+	if u.User != nil {
+		password, _ := u.User.Password()
+		cs.urlCreds[host] = newBasicAuthCredential(u.User.Username(), password)
+	}
+	cs.mu.Unlock()
+
+	u.User = nil
+	nodeUrl = u.String()
+
+	if !isWs {
+		return nodeUrl, nodeUrl, nil
+	}
+
+	cred, ok := cs.get(host)
+	if !ok {
+		return nodeUrl, nodeUrl, nil
+	}
+
+	if cred.user == nil {
+		return "", "", errWsTokenCredential
+	}
+
+	u.User = cred.user
+	return u.String(), nodeUrl, nil
+}
+
+// registerInternalUrl registers the credential of internal RPC service endpoint.
+func (cs *credentialStore) registerInternalUrl(host string, cred credential) {
+	cs.mu.Lock()
+	cs.urlCreds[strings.ToLower(host)] = cred
+	cs.mu.Unlock()
+}
+
+// reload resolves all the configured credentials from secrets, and notifies observers of the
+// WebSocket node endpoints whose credentials rotated.
+func (cs *credentialStore) reload(entries []credentialEntryConfig) error {
+	var resolved []*credentialEntry
+
+	for i := range entries {
+		entry, err := newCredentialEntry(&entries[i])
+		if err != nil {
+			return errors.WithMessagef(err, "failed to resolve credential #%v", i)
+		}
+
+		resolved = append(resolved, entry)
+	}
+
+	cs.mu.Lock()
+	prevWsCreds := cs.wsCredsLocked()
+	cs.entries = resolved
+
+	var rotated []string
+	for host := range cs.wsHosts {
+		prev, prevOk := prevWsCreds[host]
+		cred, ok := cs.getLocked(host)
+
+		if prevOk != ok || !prev.equal(cred) {
+			rotated = append(rotated, host)
+		}
+	}
+
+	observers := cs.wsObservers
+	cs.mu.Unlock()
+
+	for _, host := range rotated {
+		for _, observer := range observers {
+			observer(host)
+		}
+	}
+
+	return nil
+}
+
+// wsCredsLocked returns the credentials of all registered WebSocket node endpoints.
+func (cs *credentialStore) wsCredsLocked() map[string]credential {
+	creds := make(map[string]credential)
+	for host := range cs.wsHosts {
+		if cred, ok := cs.getLocked(host); ok {
+			creds[host] = cred
+		}
+	}
+
+	return creds
+}
+
+// onWsRotated registers observer to re-dial WebSocket node endpoint once credential rotated.
+func (cs *credentialStore) onWsRotated(observer func(host string)) {
+	cs.mu.Lock()
+	cs.wsObservers = append(cs.wsObservers, observer)
+	cs.mu.Unlock()
+}
+
+func (cs *credentialStore) get(host string) (credential, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	return cs.getLocked(strings.ToLower(host))
+}
+
+func (cs *credentialStore) getLocked(host string) (credential, bool) {
+	groups, isNode := cs.nodeHosts[host]
+
+	if isNode {
+		// configured credentials take precedence over that of URL user info
+		for _, entry := range cs.entries {
+			if entry.matches(host, groups) {
+				return entry.cred, true
+			}
+		}
+	}
+
+	if cred, ok := cs.urlCreds[host]; ok {
+		return cred, true
+	}
+
+	// wildcard credential never applies to any unregistered host
+	if !isNode {
+		return credential{}, false
+	}
+
+	for _, entry := range cs.entries {
+		if entry.matches(credentialHostAny, groups) {
+			return entry.cred, true
+		}
+	}
+
+	return credential{}, false
+}
+
+// inject injects credential into the HTTP request to upstream node endpoint if any.
+func (cs *credentialStore) inject(ctx context.Context, req *fasthttp.Request) error {
+	if cred, ok := cs.get(string(req.URI().Host())); ok {
+		req.Header.Set(cred.header, cred.value)
+	}
+
+	return nil
+}
+
+// scheduleReload periodically reloads credentials from secrets for rotation.
+func (cs *credentialStore) scheduleReload(config *credentialConfig) {
+	ticker := time.NewTicker(config.ReloadInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := cs.reload(config.Entries); err != nil {
+			logrus.WithError(err).Error("Failed to reload upstream node credentials")
+		}
+	}
+}
+
+func mustInitCredentials() {
+	var config credentialConfig
+	viper.MustUnmarshalKey("nodeCredentials", &config)
+
+	if err := credStore.reload(config.Entries); err != nil {
+		logrus.WithError(err).Fatal("Failed to load upstream node credentials")
+	}
+
+	if len(config.Entries) > 0 && config.ReloadInterval > 0 {
+		go credStore.scheduleReload(&config)
+	}
+
+	rpc.RegisterBeforeSendHttp(credStore.inject)
+}
+
+// OnWsCredentialRotated registers observer to be notified with the host of WebSocket node endpoint
+// once its credential rotated, since credential is applied at dial time only.
+func OnWsCredentialRotated(observer func(host string)) {
+	credStore.onWsRotated(observer)
+}
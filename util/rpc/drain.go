@@ -0,0 +1,33 @@
+package rpc
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// draining indicates whether the instance is draining before shutdown, so that load balancers
+// could deregister it while in-flight requests are still served.
+var draining atomic.Bool
+
+// StartDraining marks the instance as draining, during which readiness check fails and clients
+// are asked to close keep-alive connections.
+func StartDraining() {
+	draining.Store(true)
+}
+
+// IsDraining returns whether the instance is draining before shutdown.
+func IsDraining() bool {
+	return draining.Load()
+}
+
+// newDrainHandler asks clients to close keep-alive connections when draining, so that new
+// requests will be routed to other instances.
+func newDrainHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if IsDraining() {
+			w.Header().Set("Connection", "close")
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,106 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+
+	rpcErrors "github.com/Conflux-Chain/confura/util/rpc/errors"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/go-rpc-provider/utils"
+	"github.com/pkg/errors"
+)
+
+// Upstream error categories, which are used as metrics label to tell how the full node is failing.
+const (
+	upstreamErrTimeout         = "timeout"
+	upstreamErrConnRefused     = "connectionRefused"
+	upstreamErrRateLimited     = "rateLimited"
+	upstreamErrUnavailable     = "unavailable"
+	upstreamErrInvalidResponse = "invalidResponse"
+	upstreamErrFilterNotFound  = "filterNotFound"
+	upstreamErrMethodNotFound  = "methodNotFound"
+	upstreamErrCanceled        = "canceled"
+	upstreamErrRpc             = "rpc"
+	upstreamErrIo              = "io"
+	upstreamErrOther           = "other"
+)
+
+// classifyUpstreamError classifies the error from upstream full node into category, so that operators
+// could immediately see how the full node is failing.
+func classifyUpstreamError(err error) string {
+	// uniformed error codes take precedence, which are mapped from both error chain and message
+	if code, ok := rpcErrors.Code(err); ok {
+		switch code {
+		case rpcErrors.CodeRateLimited:
+			return upstreamErrRateLimited
+		case rpcErrors.CodeUpstreamUnavailable:
+			return upstreamErrUnavailable
+		case rpcErrors.CodeFilterNotFound:
+			return upstreamErrFilterNotFound
+		case rpcErrors.CodeMethodNotFound:
+			return upstreamErrMethodNotFound
+		}
+	}
+
+	// rejected by circuit breaker or concurrency limit without requesting the full node
+	if errors.Is(err, ErrUpstreamUnavailable) || errors.Is(err, ErrUpstreamOverloaded) {
+		return upstreamErrUnavailable
+	}
+
+	if utils.IsRPCJSONError(err) {
+		if rpcErr, ok := errors.Cause(err).(rpc.Error); ok && rpcErr.ErrorCode() == rpcErrors.CodeMethodNotFound {
+			return upstreamErrMethodNotFound
+		}
+
+		return upstreamErrRpc
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return upstreamErrCanceled
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return upstreamErrTimeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return upstreamErrTimeout
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return upstreamErrConnRefused
+	}
+
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return upstreamErrInvalidResponse
+	}
+
+	// errors from upstream are not always wrapped, e.g., formatted by http client
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "timeout") || strings.Contains(msg, "deadline exceeded"):
+		return upstreamErrTimeout
+	case strings.Contains(msg, "connection refused"):
+		return upstreamErrConnRefused
+	case strings.Contains(msg, "invalid character"),
+		strings.Contains(msg, "unexpected end of json input"),
+		strings.Contains(msg, "cannot unmarshal"):
+		return upstreamErrInvalidResponse
+	case strings.Contains(msg, "connection reset"),
+		strings.Contains(msg, "broken pipe"),
+		strings.Contains(msg, "eof"),
+		strings.Contains(msg, "no such host"):
+		return upstreamErrIo
+	}
+
+	return upstreamErrOther
+}
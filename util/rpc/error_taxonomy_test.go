@@ -0,0 +1,35 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyUpstreamError(t *testing.T) {
+	testCases := []struct {
+		err      error
+		category string
+	}{
+		{errors.WithMessage(context.DeadlineExceeded, "failed to call"), upstreamErrTimeout},
+		{errors.New("Post \"http://node\": net/http: request canceled (Client.Timeout exceeded)"), upstreamErrTimeout},
+		{errors.WithMessage(syscall.ECONNREFUSED, "dial tcp"), upstreamErrConnRefused},
+		{errors.New("dial tcp 127.0.0.1:12537: connect: connection refused"), upstreamErrConnRefused},
+		{errors.New("429 Too Many Requests"), upstreamErrRateLimited},
+		{errors.New("502 Bad Gateway"), upstreamErrUnavailable},
+		{errors.WithMessage(ErrUpstreamOverloaded, "node"), upstreamErrUnavailable},
+		{json.Unmarshal([]byte("<html>"), &struct{}{}), upstreamErrInvalidResponse},
+		{errors.New("filter not found"), upstreamErrFilterNotFound},
+		{errors.New("unexpected EOF"), upstreamErrIo},
+		{context.Canceled, upstreamErrCanceled},
+		{errors.New("unknown"), upstreamErrOther},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.category, classifyUpstreamError(tc.err), tc.err.Error())
+	}
+}
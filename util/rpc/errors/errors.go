@@ -0,0 +1,150 @@
+// Package errors defines the uniform JSON-RPC error codes of Confura, into which heterogeneous
+// errors from upstream full nodes, stores and middlewares are mapped, so that SDK users could
+// handle failures programmatically regardless of where the failure comes from.
+//
+// Note, the error message is kept as it is, and only the error code is uniformed.
+package errors
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/openweb3/go-rpc-provider"
+)
+
+// Standard JSON-RPC 2.0 error codes.
+const (
+	// Request is not a valid JSON-RPC request, e.g., malformed envelope or params nested too deep.
+	CodeInvalidRequest = -32600
+	// Method not found, or not allowed by access control.
+	CodeMethodNotFound = -32601
+	// Invalid method params, e.g., malformed log filter.
+	CodeInvalidParams = -32602
+	// Internal error, including request timed out or canceled.
+	CodeInternal = -32603
+)
+
+// Confura specific error codes.
+const (
+	// Invalid or expired bearer token.
+	CodeUnauthorized = -32001
+	// Source IP address not allowed for the API key.
+	CodeIpForbidden = -32002
+	// HTTP origin not allowed for the API key.
+	CodeOriginForbidden = -32003
+	// Rate limited by Confura or upstream full node, retry later with backoff.
+	CodeRateLimited = -32005
+	// Response too large, narrow down the query.
+	CodeResponseTooLarge = -32008
+	// No upstream full node available, or the full node is overloaded, retry later.
+	CodeUpstreamUnavailable = -32010
+	// Filter not found, e.g., expired or uninstalled, which should be re-installed.
+	CodeFilterNotFound = -32011
+	// Query range or result set too large, narrow down the query (e.g., block range).
+	CodeRangeTooLarge = -32012
+	// Data changed due to chain reorg during the query, retry the query.
+	CodeReorged = -32013
+)
+
+// rule maps errors into the uniform error code, either by error chain or error message.
+type rule struct {
+	code     int
+	errs     []error
+	patterns []string // lowercase substrings of error message
+}
+
+func (r *rule) match(err error, msg string) bool {
+	for _, e := range r.errs {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+
+	for _, pattern := range r.patterns {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	mu    sync.RWMutex
+	rules []rule
+)
+
+func init() {
+	RegisterPatterns(CodeUpstreamUnavailable, "502 bad gateway", "503 service unavailable", "504 gateway timeout")
+	RegisterPatterns(CodeRateLimited, "429 too many requests")
+	RegisterPatterns(CodeFilterNotFound, "filter not found")
+}
+
+// Register maps the specified errors (and the errors wrapping them) into the uniform error code.
+func Register(code int, errs ...error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	rules = append(rules, rule{code: code, errs: errs})
+}
+
+// RegisterPatterns maps the errors with message containing any of the specified patterns (case
+// insensitive) into the uniform error code, which is mainly for errors from upstream full nodes.
+func RegisterPatterns(code int, patterns ...string) {
+	lowered := make([]string, 0, len(patterns))
+	for _, v := range patterns {
+		lowered = append(lowered, strings.ToLower(v))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	rules = append(rules, rule{code: code, patterns: lowered})
+}
+
+// Code returns the uniform error code of the specified error in order of registration, or false
+// if not registered.
+func Code(err error) (int, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	msg := strings.ToLower(err.Error())
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for i := range rules {
+		if rules[i].match(err, msg) {
+			return rules[i].code, true
+		}
+	}
+
+	return 0, false
+}
+
+// Uniform maps the JSON-RPC error into uniform error code with message and data unchanged, and
+// returns false if not mapped or already uniformed.
+func Uniform(jsErr *rpc.JsonError) (*rpc.JsonError, bool) {
+	if jsErr == nil {
+		return nil, false
+	}
+
+	// match the original error in process if any, e.g., store errors
+	err := error(jsErr)
+	if inner := jsErr.Inner(); inner != nil {
+		err = inner
+	}
+
+	code, ok := Code(err)
+	if !ok || code == jsErr.Code {
+		return jsErr, false
+	}
+
+	return &rpc.JsonError{
+		Code:    code,
+		Message: jsErr.Message,
+		Data:    jsErr.Data,
+	}, true
+}
@@ -0,0 +1,51 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCode(t *testing.T) {
+	errTest := errors.New("test reorged")
+	Register(CodeReorged, errTest)
+
+	code, ok := Code(fmt.Errorf("failed to query: %w", errTest))
+	assert.True(t, ok)
+	assert.Equal(t, CodeReorged, code)
+
+	code, ok = Code(errors.New("Filter Not Found"))
+	assert.True(t, ok)
+	assert.Equal(t, CodeFilterNotFound, code)
+
+	code, ok = Code(errors.New("502 Bad Gateway"))
+	assert.True(t, ok)
+	assert.Equal(t, CodeUpstreamUnavailable, code)
+
+	_, ok = Code(errors.New("unknown error"))
+	assert.False(t, ok)
+
+	_, ok = Code(nil)
+	assert.False(t, ok)
+}
+
+func TestUniform(t *testing.T) {
+	jsErr := &rpc.JsonError{Code: -32000, Message: "filter not found", Data: "data"}
+
+	uniformed, ok := Uniform(jsErr)
+	assert.True(t, ok)
+	assert.Equal(t, CodeFilterNotFound, uniformed.Code)
+	assert.Equal(t, jsErr.Message, uniformed.Message)
+	assert.Equal(t, jsErr.Data, uniformed.Data)
+
+	// already uniformed
+	_, ok = Uniform(uniformed)
+	assert.False(t, ok)
+
+	// not mapped
+	_, ok = Uniform(&rpc.JsonError{Code: -32000, Message: "execution reverted"})
+	assert.False(t, ok)
+}
@@ -11,12 +11,16 @@ type CtxKey string
 
 const (
 	CtxKeyNamespace = CtxKey("Infura-Namespace")
+	CtxKeyListener  = CtxKey("Infura-Listener")
 
 	CtxKeyRateRegistry = CtxKey("Infura-Rate-Limit-Registry")
 	CtxKeyAuthId       = CtxKey("Infura-Auth-ID")
+	CtxKeyJwtStatus    = CtxKey("Infura-JWT-Status")
 
 	CtxKeyRealIP      = CtxKey("Infura-Real-IP")
+	CtxKeyClientIP    = CtxKey("Infura-Client-IP")
 	CtxKeyAccessToken = CtxKey("Infura-Access-Token")
+	CtxKeyBearerToken = CtxKey("Infura-Bearer-Token")
 	CtxKeyReqOrigin   = CtxKey("Infura-Req-Origin")
 	CtxKeyUserAgent   = CtxKey("Infura-User-Agent")
 )
@@ -25,3 +29,9 @@ func GetNamespaceFromContext(ctx context.Context) (string, bool) {
 	namespace, ok := ctx.Value(CtxKeyNamespace).(string)
 	return namespace, ok
 }
+
+// GetListenerFromContext returns the listener (e.g., `http` or `ws`) which accepted the request.
+func GetListenerFromContext(ctx context.Context) (string, bool) {
+	listener, ok := ctx.Value(CtxKeyListener).(string)
+	return listener, ok
+}
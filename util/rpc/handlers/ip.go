@@ -94,11 +94,59 @@ func GetIPAddress(r *http.Request) string {
 	return r.RemoteAddr
 }
 
+// GetClientIP returns the IP address of the remote peer, unless the peer is a trusted proxy, in
+// which case the rightmost address not trusted within the `X-Forwarded-For` header is returned.
+// Unlike `GetIPAddress`, the result can't be spoofed by clients via forwarded headers.
+func GetClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	peer := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+
+	if !isTrustedProxy(trustedProxies, net.ParseIP(peer)) {
+		return peer
+	}
+
+	addresses := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(addresses) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(addresses[i])
+		if len(ip) == 0 {
+			continue
+		}
+
+		if !isTrustedProxy(trustedProxies, net.ParseIP(ip)) {
+			return ip
+		}
+	}
+
+	return peer
+}
+
+func isTrustedProxy(trustedProxies []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func GetIPAddressFromContext(ctx context.Context) (string, bool) {
 	val, ok := ctx.Value(CtxKeyRealIP).(string)
 	return val, ok
 }
 
+// GetClientIPFromContext returns the client IP resolved against trusted proxies.
+func GetClientIPFromContext(ctx context.Context) (string, bool) {
+	val, ok := ctx.Value(CtxKeyClientIP).(string)
+	return val, ok
+}
+
 func GetAccessToken(r *http.Request) string {
 	if r == nil || r.URL == nil {
 		return ""
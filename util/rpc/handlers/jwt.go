@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+const bearerAuthScheme = "Bearer "
+
+// JwtStatus is the principal authenticated by JWT bearer token.
+type JwtStatus struct {
+	ID   string // auth ID derived from subject claim
+	Tier string // rate limit tier mapped from tier claim
+}
+
+// GetBearerToken returns the bearer token from the HTTP `Authorization` header if any.
+func GetBearerToken(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), bearerAuthScheme)
+	if !ok {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+func GetBearerTokenFromContext(ctx context.Context) (string, bool) {
+	val, ok := ctx.Value(CtxKeyBearerToken).(string)
+	return val, ok
+}
+
+// JwtStatusFromContext returns the JWT status from context if authenticated by JWT bearer token.
+func JwtStatusFromContext(ctx context.Context) (*JwtStatus, bool) {
+	js, ok := ctx.Value(CtxKeyJwtStatus).(*JwtStatus)
+	return js, ok && js != nil
+}
@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrUpstreamOverloaded is returned to shed load when too many requests in flight or queued
+	// for full node.
+	ErrUpstreamOverloaded = errors.New("upstream overloaded")
+
+	// node limiters shared by all clients of the same full node: space/node name => *nodeLimiter
+	nodeLimiters sync.Map
+)
+
+type concurrencyLimitConfig struct {
+	Enabled      bool
+	MaxInFlight  int           `default:"256"`
+	QueueSize    int           `default:"64"`
+	QueueTimeout time.Duration `default:"1s"`
+}
+
+// nodeLimiter limits the number of in-flight requests to full node, with a bounded queue
+// for requests waiting for the in-flight slots. Requests are shed if queue is full or
+// timed out while queuing.
+type nodeLimiter struct {
+	cfg      concurrencyLimitConfig
+	nodeName string
+	space    string
+
+	slots  chan struct{} // in-flight slots
+	queued int32         // number of queued requests
+}
+
+func newNodeLimiter(space, nodeName string, cfg concurrencyLimitConfig) *nodeLimiter {
+	return &nodeLimiter{
+		cfg:      cfg,
+		nodeName: nodeName,
+		space:    space,
+		slots:    make(chan struct{}, cfg.MaxInFlight),
+	}
+}
+
+func loadOrNewNodeLimiter(space, nodeName string, cfg concurrencyLimitConfig) *nodeLimiter {
+	v, _ := nodeLimiters.LoadOrStore(space+"/"+nodeName, newNodeLimiter(space, nodeName, cfg))
+	return v.(*nodeLimiter)
+}
+
+// acquire acquires an in-flight slot, waiting in queue if all slots occupied.
+func (l *nodeLimiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if queued := atomic.AddInt32(&l.queued, 1); int(queued) > l.cfg.QueueSize {
+		atomic.AddInt32(&l.queued, -1)
+		return l.shed("queue full")
+	}
+
+	l.updateQueueDepth()
+
+	defer func() {
+		atomic.AddInt32(&l.queued, -1)
+		l.updateQueueDepth()
+	}()
+
+	timer := time.NewTimer(l.cfg.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return l.shed("queue timeout")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release releases the acquired in-flight slot.
+func (l *nodeLimiter) release() {
+	<-l.slots
+}
+
+func (l *nodeLimiter) shed(reason string) error {
+	metrics.Registry.RPC.FullnodeShed(l.space, l.nodeName).Mark(1)
+	return errors.WithMessagef(ErrUpstreamOverloaded, "%v for full node %v", reason, l.nodeName)
+}
+
+func (l *nodeLimiter) updateQueueDepth() {
+	queued := atomic.LoadInt32(&l.queued)
+	metrics.Registry.RPC.FullnodeQueueDepth(l.space, l.nodeName).Update(int64(queued))
+}
+
+// middlewareConcurrencyLimit limits in-flight RPC requests to full node, and sheds load
+// if too many requests queued.
+func middlewareConcurrencyLimit(limiter *nodeLimiter) providers.CallContextMiddleware {
+	return func(handler providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			if err := limiter.acquire(ctx); err != nil {
+				return err
+			}
+			defer limiter.release()
+
+			return handler(ctx, result, method, args...)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeLimiter(t *testing.T) {
+	l := newNodeLimiter("cfx", "node", concurrencyLimitConfig{
+		Enabled:      true,
+		MaxInFlight:  2,
+		QueueSize:    1,
+		QueueTimeout: 100 * time.Millisecond,
+	})
+
+	ctx := context.Background()
+	assert.NoError(t, l.acquire(ctx))
+	assert.NoError(t, l.acquire(ctx))
+
+	// queued until timeout
+	assert.ErrorIs(t, l.acquire(ctx), ErrUpstreamOverloaded)
+
+	// shed immediately if queue full
+	done := make(chan error)
+	go func() { done <- l.acquire(ctx) }()
+	time.Sleep(20 * time.Millisecond)
+	assert.ErrorIs(t, l.acquire(ctx), ErrUpstreamOverloaded)
+
+	// queued request acquires slot once released
+	l.release()
+	assert.NoError(t, <-done)
+
+	// canceled while queuing
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, l.acquire(cctx), context.Canceled)
+
+	assert.False(t, isUpstreamFailure(ErrUpstreamOverloaded))
+}
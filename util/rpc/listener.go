@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+)
+
+// listener names to tell requests apart, e.g., for method-level access control
+const (
+	ListenerHttp   = "http"
+	ListenerWS     = "ws"
+	ListenerUnix   = "unix"
+	ListenerInProc = "inproc"
+)
+
+// listenerName returns the name of listener which serves the endpoint in protocol.
+func listenerName(endpoint string, protocol Protocol) string {
+	if _, ok := parseUnixEndpoint(endpoint); ok {
+		return ListenerUnix
+	}
+
+	if protocol == ProtocolWS {
+		return ListenerWS
+	}
+
+	return ListenerHttp
+}
+
+// namedListener names the accepted connections, so that requests could be told apart by listener
+// even if the same handler is shared by multiple listeners.
+type namedListener struct {
+	net.Listener
+	name string
+}
+
+func (l *namedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return &namedConn{Conn: conn, name: l.name}, nil
+}
+
+type namedConn struct {
+	net.Conn
+	name string
+}
+
+// listenerConnContext injects the listener name of connection into request context, which is
+// used as `http.Server.ConnContext`.
+func listenerConnContext(ctx context.Context, conn net.Conn) context.Context {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+
+	if nc, ok := conn.(*namedConn); ok {
+		return context.WithValue(ctx, handlers.CtxKeyListener, nc.name)
+	}
+
+	return ctx
+}
@@ -0,0 +1,31 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenerName(t *testing.T) {
+	assert.Equal(t, ListenerHttp, listenerName(":22537", ProtocolHttp))
+	assert.Equal(t, ListenerWS, listenerName(":22535", ProtocolWS))
+	assert.Equal(t, ListenerUnix, listenerName("unix:///var/run/confura/cfx.sock", ProtocolHttp))
+}
+
+func TestListenerConnContext(t *testing.T) {
+	conn, peer := net.Pipe()
+	defer conn.Close()
+	defer peer.Close()
+
+	ctx := listenerConnContext(context.Background(), &namedConn{Conn: conn, name: ListenerWS})
+	listener, ok := handlers.GetListenerFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, ListenerWS, listener)
+
+	// not accepted by named listener
+	_, ok = handlers.GetListenerFromContext(listenerConnContext(context.Background(), conn))
+	assert.False(t, ok)
+}
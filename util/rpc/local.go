@@ -0,0 +1,224 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// prefix of endpoint to serve on Unix domain socket, e.g., `unix:///var/run/confura/cfx.sock`
+	unixEndpointPrefix = "unix://"
+	// allows co-located processes of the same group to connect
+	unixSocketFileMode = 0660
+)
+
+// parseUnixEndpoint returns the socket file path if endpoint prefixed with `unix://`.
+func parseUnixEndpoint(endpoint string) (string, bool) {
+	return strings.CutPrefix(endpoint, unixEndpointPrefix)
+}
+
+// listen listens on the TCP endpoint, or Unix domain socket if endpoint prefixed with `unix://`.
+func listen(endpoint string) (net.Listener, error) {
+	path, ok := parseUnixEndpoint(endpoint)
+	if !ok {
+		return net.Listen("tcp", endpoint)
+	}
+
+	// remove the stale socket file left by unclean shutdown, unless still served by others
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, errors.Errorf("unix socket %v already in use", path)
+		}
+
+		if err := os.Remove(path); err != nil {
+			return nil, errors.WithMessage(err, "failed to remove stale unix socket")
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, unixSocketFileMode); err != nil {
+		listener.Close()
+		return nil, errors.WithMessage(err, "failed to change mode of unix socket")
+	}
+
+	return listener, nil
+}
+
+// DialUnix creates a JSON-RPC client that connects to the RPC server served over HTTP on Unix
+// domain socket, along with optional access token.
+func DialUnix(endpoint, accessToken string) (*rpc.Client, error) {
+	path, _ := parseUnixEndpoint(endpoint)
+
+	client := &fasthttp.Client{
+		Dial: func(string) (net.Conn, error) {
+			return net.Dial("unix", path)
+		},
+	}
+
+	return rpc.DialHTTPWithClient(localRpcUrl("unix", accessToken), client)
+}
+
+// InProcClient is a JSON-RPC client dialed in process, see `Server.DialInProc` for more details.
+type InProcClient struct {
+	*rpc.Client
+	conn net.Conn
+}
+
+// Close closes the client along with the in-process connection.
+func (c *InProcClient) Close() {
+	c.Client.Close()
+	c.conn.Close()
+}
+
+// DialInProc creates a JSON-RPC client that dispatches calls to the RPC handler of server directly
+// via an in-memory pipe, along with optional access token. It skips the TCP and HTTP overhead for
+// embedded clients, e.g., co-located indexers.
+//
+// Since the HTTP middlewares are skipped as well, the context values they inject (e.g., namespace,
+// access token and rate limit registry) are prepared once upon dialing and restored for each call
+// by `InProcMiddleware`, so that calls still go through the RPC middlewares such as authentication,
+// rate limit and metrics.
+func (s *Server) DialInProc(accessToken string) (*InProcClient, error) {
+	ctx, err := s.inProcContext(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	p1, p2 := net.Pipe()
+
+	conn := &inProcConn{
+		Conn:   p1,
+		id:     fmt.Sprintf("%v#%v", ListenerInProc, inProcConnSeq.Add(1)),
+		server: s,
+		ctx:    ctx,
+	}
+	inProcConns.Store(conn.id, conn)
+
+	go s.handler.ServeCodec(rpc.NewCodec(conn), 0)
+
+	client, err := rpc.DialIO(context.Background(), p2, p2)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &InProcClient{Client: client, conn: p2}, nil
+}
+
+// inProcContext returns the context values injected by HTTP middlewares of server for in-process
+// calls, e.g., namespace and access token.
+func (s *Server) inProcContext(accessToken string) (context.Context, error) {
+	var ctx context.Context
+
+	var handler http.Handler = http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})
+
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		handler = s.middlewares[i](handler)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, localRpcUrl(ListenerInProc, accessToken), nil)
+	req = req.WithContext(context.WithValue(req.Context(), handlers.CtxKeyListener, ListenerInProc))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	if ctx == nil { // rejected by middlewares, e.g., authentication
+		return nil, errors.Errorf("in-process dial rejected with status %v", recorder.Code)
+	}
+
+	return ctx, nil
+}
+
+// Close closes all the connections dialed in process by `DialInProc`, so that clients dialed in
+// process will fail afterwards.
+func (s *Server) Close() error {
+	inProcConns.Range(func(_, value interface{}) bool {
+		if conn := value.(*inProcConn); conn.server == s {
+			conn.Close()
+		}
+
+		return true
+	})
+
+	return nil
+}
+
+var (
+	// in-process connections keyed by the remote address, which is unique per connection
+	inProcConns   sync.Map
+	inProcConnSeq atomic.Uint64
+)
+
+// inProcConn is the server side of in-process connection, whose remote address identifies the
+// connection, so that the context values prepared upon dialing could be restored for each call.
+type inProcConn struct {
+	net.Conn
+	id     string
+	server *Server
+	ctx    context.Context
+}
+
+// RemoteAddr implements the `rpc.ConnRemoteAddr` interface.
+func (c *inProcConn) RemoteAddr() string {
+	return c.id
+}
+
+func (c *inProcConn) Close() error {
+	inProcConns.Delete(c.id)
+	return c.Conn.Close()
+}
+
+// InProcMiddleware restores the context values prepared upon dialing for in-process calls, which
+// should be hooked before any other RPC middlewares that depend on them.
+func InProcMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		if remote, ok := ctx.Value("remote").(string); ok {
+			if conn, ok := inProcConns.Load(remote); ok {
+				ctx = &inProcValueContext{Context: ctx, values: conn.(*inProcConn).ctx}
+			}
+		}
+
+		return next(ctx, msg)
+	}
+}
+
+// inProcValueContext looks up values prepared upon dialing first, and then the parent context of
+// RPC connection, which is still used for cancellation.
+type inProcValueContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c *inProcValueContext) Value(key interface{}) interface{} {
+	if val := c.values.Value(key); val != nil {
+		return val
+	}
+
+	return c.Context.Value(key)
+}
+
+// localRpcUrl returns the RPC url of local transports, where the access token is appended after
+// the root path if specified.
+func localRpcUrl(host, accessToken string) string {
+	return "http://" + host + "/" + url.PathEscape(accessToken)
+}
@@ -0,0 +1,112 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echoService struct{}
+
+func (echoService) Echo(s string) string { return s }
+
+func (echoService) Context(ctx context.Context) []string {
+	namespace, _ := handlers.GetNamespaceFromContext(ctx)
+	listener, _ := handlers.GetListenerFromContext(ctx)
+	token, _ := handlers.GetAccessTokenFromContext(ctx)
+
+	return []string{namespace, listener, token}
+}
+
+func newEchoServer(t *testing.T, middlewares ...handlers.Middleware) *Server {
+	handler := rpc.NewServer()
+	require.NoError(t, handler.RegisterName("test", echoService{}))
+
+	return &Server{
+		name:        "test",
+		servers:     map[Protocol]*http.Server{ProtocolHttp: {Handler: handler}},
+		handler:     handler,
+		middlewares: middlewares,
+	}
+}
+
+func TestDialUnix(t *testing.T) {
+	endpoint := unixEndpointPrefix + filepath.Join(t.TempDir(), "test.sock")
+
+	listener, err := listen(endpoint)
+	require.NoError(t, err)
+
+	// socket already in use
+	_, err = listen(endpoint)
+	assert.Error(t, err)
+
+	go http.Serve(listener, newEchoServer(t).servers[ProtocolHttp].Handler)
+	defer listener.Close()
+
+	client, err := DialUnix(endpoint, "")
+	require.NoError(t, err)
+	defer client.Close()
+
+	var result string
+	assert.NoError(t, client.Call(&result, "test_echo", "hello"))
+	assert.Equal(t, "hello", result)
+}
+
+func TestDialInProc(t *testing.T) {
+	rpc.HookHandleCallMsg(InProcMiddleware)
+
+	server := newEchoServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), handlers.CtxKeyNamespace, "test")
+			if token := handlers.GetAccessToken(r); len(token) > 0 {
+				ctx = context.WithValue(ctx, handlers.CtxKeyAccessToken, token)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+
+	for i := 0; i < 2; i++ {
+		client, err := server.DialInProc("token")
+		require.NoError(t, err)
+
+		var result string
+		assert.NoError(t, client.Call(&result, "test_echo", "hello"))
+		assert.Equal(t, "hello", result)
+
+		// context values injected by HTTP middlewares are restored
+		var values []string
+		assert.NoError(t, client.Call(&values, "test_context"))
+		assert.Equal(t, []string{"test", ListenerInProc, "token"}, values)
+
+		client.Close()
+	}
+
+	client, err := server.DialInProc("")
+	require.NoError(t, err)
+	defer client.Close()
+
+	// fails once in-process connections closed
+	assert.NoError(t, server.Close())
+	assert.NoError(t, server.Close())
+
+	var result string
+	assert.Error(t, client.Call(&result, "test_echo", "hello"))
+}
+
+func TestDialInProcRejected(t *testing.T) {
+	server := newEchoServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		})
+	})
+
+	_, err := server.DialInProc("")
+	assert.Error(t, err)
+}
@@ -5,11 +5,18 @@ import (
 
 	"github.com/Conflux-Chain/confura/util/acl"
 	"github.com/Conflux-Chain/confura/util/rate"
+	rpcErrors "github.com/Conflux-Chain/confura/util/rpc/errors"
 	"github.com/Conflux-Chain/confura/util/rpc/handlers"
 	"github.com/openweb3/go-rpc-provider"
 	"github.com/pkg/errors"
 )
 
+const (
+	methodNotAllowedErrorCode = rpcErrors.CodeMethodNotFound
+	ipForbiddenErrorCode      = rpcErrors.CodeIpForbidden
+	originForbiddenErrorCode  = rpcErrors.CodeOriginForbidden
+)
+
 func Allowlists(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
 		registry, ok := ctx.Value(handlers.CtxKeyRateRegistry).(*rate.Registry)
@@ -39,3 +46,71 @@ func Allowlists(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 func errAllowlistsForbidden(err error) error {
 	return errors.WithMessage(err, "access forbidden by allowlists")
 }
+
+// KeyRestrictions rejects requests of API keys from the source IPs or origins not allowed. Note,
+// source IP is resolved against trusted proxies rather than forwarded headers set by clients.
+func KeyRestrictions(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		ki, ok := rate.SVipStatusFromContext(ctx)
+		if !ok || ki.Restriction == nil {
+			return next(ctx, msg)
+		}
+
+		ip, _ := handlers.GetClientIPFromContext(ctx)
+		origin, _ := handlers.GetRequestOriginFromContext(ctx)
+
+		if err := ki.Restriction.Check(ip, origin); err != nil {
+			return msg.ErrorResponse(errKeyRestricted(err))
+		}
+
+		return next(ctx, msg)
+	}
+}
+
+func errKeyRestricted(err error) error {
+	code := ipForbiddenErrorCode
+	if errors.Is(err, acl.ErrOriginForbidden) {
+		code = originForbiddenErrorCode
+	}
+
+	return &rpc.JsonError{
+		Code:    code,
+		Message: err.Error(),
+	}
+}
+
+// MethodAcl rejects RPC methods not allowed for the RPC server (keyed by RPC namespace), the
+// listener or the API key tier, with structured "method not allowed" errors.
+func MethodAcl(acls map[string]*acl.MethodAcl) rpc.HandleCallMsgMiddleware {
+	return func(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+		return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+			space, _ := handlers.GetNamespaceFromContext(ctx)
+
+			methodAcl, ok := acls[space]
+			if !ok {
+				return next(ctx, msg)
+			}
+
+			tier := rate.DefaultStrategy
+			if registry, ok := ctx.Value(handlers.CtxKeyRateRegistry).(*rate.Registry); ok {
+				tier = registry.Tier(ctx)
+			}
+
+			listener, _ := handlers.GetListenerFromContext(ctx)
+
+			if err := methodAcl.Check(msg.Method, listener, tier); err != nil {
+				return msg.ErrorResponse(errMethodNotAllowed(err))
+			}
+
+			return next(ctx, msg)
+		}
+	}
+}
+
+func errMethodNotAllowed(err error) error {
+	return &rpc.JsonError{
+		Code:    methodNotAllowedErrorCode,
+		Message: err.Error(),
+		Data:    err,
+	}
+}
@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/util/acl"
+	"github.com/Conflux-Chain/confura/util/rate"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyRestrictionsSpoofedIP(t *testing.T) {
+	restriction, err := acl.NewKeyRestriction("1.2.3.4", "", "")
+	require.NoError(t, err)
+
+	registry := rate.NewRegistry(rate.NewKeyLoader(func(filter *rate.KeysetFilter) ([]*rate.KeyInfo, error) {
+		var kis []*rate.KeyInfo
+		for _, key := range filter.KeySet {
+			kis = append(kis, &rate.KeyInfo{Key: key, Type: rate.LimitTypeByKey, Restriction: restriction})
+		}
+
+		return kis, nil
+	}), nil)
+
+	handler := KeyRestrictions(func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		return &rpc.JsonRpcMessage{}
+	})
+
+	call := func(remoteAddr, forwardedFor string, trustedProxies []*net.IPNet) *rpc.JsonRpcMessage {
+		r := httptest.NewRequest("POST", "/", nil)
+		r.RemoteAddr = remoteAddr
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+
+		ctx := context.WithValue(context.Background(), handlers.CtxKeyAccessToken, "restrictedKey")
+		ctx = context.WithValue(ctx, handlers.CtxKeyRateRegistry, registry)
+		ctx = context.WithValue(ctx, handlers.CtxKeyClientIP, handlers.GetClientIP(r, trustedProxies))
+
+		return handler(ctx, &rpc.JsonRpcMessage{Method: "cfx_epochNumber"})
+	}
+
+	// allowed IP spoofed by client
+	assert.NotNil(t, call("5.6.7.8:1234", "1.2.3.4", nil).Error)
+
+	// forwarded by trusted proxy
+	trustedProxies, err := acl.ParseIpNets("5.6.7.0/24")
+	require.NoError(t, err)
+	assert.Nil(t, call("5.6.7.8:1234", "1.2.3.4", trustedProxies).Error)
+
+	// allowed IP spoofed by client, and appended by trusted proxy
+	assert.NotNil(t, call("5.6.7.8:1234", "1.2.3.4, 9.9.9.9", trustedProxies).Error)
+
+	// direct connection from allowed IP
+	assert.Nil(t, call("1.2.3.4:1234", "", nil).Error)
+}
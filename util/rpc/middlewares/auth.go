@@ -10,16 +10,24 @@ import (
 )
 
 func Auth() rpc.HandleCallMsgMiddleware {
+	authenticate := Authenticate
+
+	// JWT bearer token issued by identity provider
+	if verifier, ok := mustNewJwtVerifierFromViper(); ok {
+		logrus.WithField("issuer", verifier.conf.Issuer).Info("JWT authentication enabled")
+		authenticate = JwtAuthenticate(verifier)
+	}
+
 	// web3pay
 	if mw, conf, ok := MustNewWeb3PayMiddlewareFromViper(); ok {
 		logrus.WithField("mode", conf.Mode).Info("Web3Pay openweb3 RPC middleware enabled")
 
 		return func(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
-			return mw(Authenticate(next))
+			return mw(authenticate(next))
 		}
 	}
 
-	return Authenticate
+	return authenticate
 }
 
 func Authenticate(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"strings"
 
+	rpcErrors "github.com/Conflux-Chain/confura/util/rpc/errors"
 	"github.com/openweb3/go-rpc-provider"
 	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
 )
@@ -36,11 +37,24 @@ func isServerTooBusy(err error) bool {
 	return matchNginxUnavailableError(err) || errors.Is(err, providers.ErrCircuitOpen)
 }
 
+// UniformError maps heterogeneous errors from upstream full nodes and stores into the uniform
+// error codes defined in package `util/rpc/errors`.
 func UniformError(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
 		resp := next(ctx, msg)
-		if resp.Error != nil && isServerTooBusy(resp.Error) {
-			return resp.ErrorResponse(ErrorServerTooBusy)
+		if resp.Error == nil {
+			return resp
+		}
+
+		if isServerTooBusy(resp.Error) {
+			return resp.ErrorResponse(&rpc.JsonError{
+				Code:    rpcErrors.CodeUpstreamUnavailable,
+				Message: ErrorServerTooBusy.Error(),
+			})
+		}
+
+		if uniformed, ok := rpcErrors.Uniform(resp.Error); ok {
+			return resp.ErrorResponse(uniformed)
 		}
 
 		return resp
@@ -0,0 +1,344 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	rpcErrors "github.com/Conflux-Chain/confura/util/rpc/errors"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	jwtAuthErrorCode = rpcErrors.CodeUnauthorized
+
+	// min interval to refetch JWKS on unknown key ID, in case of attack with forged key IDs
+	jwksMinRefetchInterval = 10 * time.Second
+)
+
+var (
+	jwtValidMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+	errJwtKeyNotFound = errors.New("signing key not found")
+)
+
+// jwtConfig is the settings to authenticate requests by JWT bearer tokens issued by identity
+// provider, which are verified against the public keys from JWKS endpoint.
+type jwtConfig struct {
+	Enabled bool
+	// expected `iss` claim
+	Issuer string
+	// expected `aud` claim, skip checking if empty
+	Audience string
+	// JWKS endpoint to fetch public keys for signature verification
+	JwksUrl string
+	// interval to refresh public keys from JWKS endpoint
+	JwksRefreshInterval time.Duration `default:"1h"`
+	// timeout to fetch public keys from JWKS endpoint
+	JwksTimeout time.Duration `default:"5s"`
+	// clock skew tolerance to check `exp` and `nbf` claims
+	Leeway time.Duration `default:"30s"`
+	// claim to identify the principal for rate limit and usage accounting
+	SubjectClaim string `default:"sub"`
+	// claim to map rate limit tier (strategy) and allowlist
+	TierClaim string `default:"tier"`
+	// mapping from tier claim value to rate limit tier, otherwise claim value used as is
+	Tiers map[string]string
+	// rate limit tier if the tier claim is absent or empty
+	DefaultTier string
+}
+
+func mustNewJwtConfigFromViper() *jwtConfig {
+	var conf jwtConfig
+	viper.MustUnmarshalKey("jwtAuth", &conf)
+
+	if conf.Enabled && (len(conf.Issuer) == 0 || len(conf.JwksUrl) == 0) {
+		logrus.WithField("config", conf).Fatal("Issuer and JWKS url required for JWT authentication")
+	}
+
+	return &conf
+}
+
+// jwtVerifier verifies JWT bearer tokens and extracts claims for authentication.
+type jwtVerifier struct {
+	conf   *jwtConfig
+	keySet *jwks
+	parser *jwt.Parser
+}
+
+func mustNewJwtVerifierFromViper() (*jwtVerifier, bool) {
+	conf := mustNewJwtConfigFromViper()
+	if !conf.Enabled {
+		return nil, false
+	}
+
+	return newJwtVerifier(conf), true
+}
+
+func newJwtVerifier(conf *jwtConfig) *jwtVerifier {
+	return &jwtVerifier{
+		conf:   conf,
+		keySet: newJwks(conf.JwksUrl, conf.JwksRefreshInterval, conf.JwksTimeout),
+		// claims validated manually with leeway
+		parser: jwt.NewParser(jwt.WithValidMethods(jwtValidMethods), jwt.WithoutClaimsValidation()),
+	}
+}
+
+// verify verifies the signature and standard claims of token, and returns the JWT status.
+func (v *jwtVerifier) verify(token string) (*handlers.JwtStatus, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keySet.key(kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !claims.VerifyExpiresAt(now.Add(-v.conf.Leeway).Unix(), true) {
+		return nil, errors.New("token expired")
+	}
+
+	if !claims.VerifyNotBefore(now.Add(v.conf.Leeway).Unix(), false) {
+		return nil, errors.New("token not valid yet")
+	}
+
+	if !claims.VerifyIssuer(v.conf.Issuer, true) {
+		return nil, errors.New("invalid issuer")
+	}
+
+	if len(v.conf.Audience) > 0 && !claims.VerifyAudience(v.conf.Audience, true) {
+		return nil, errors.New("invalid audience")
+	}
+
+	subject, _ := claims[v.conf.SubjectClaim].(string)
+	if len(subject) == 0 {
+		return nil, errors.Errorf("missing %v claim", v.conf.SubjectClaim)
+	}
+
+	return &handlers.JwtStatus{
+		ID:   "jwt:" + subject,
+		Tier: v.tier(claims),
+	}, nil
+}
+
+// tier maps the tier claim to rate limit tier.
+func (v *jwtVerifier) tier(claims jwt.MapClaims) string {
+	claimed, _ := claims[v.conf.TierClaim].(string)
+	if len(claimed) == 0 {
+		return v.conf.DefaultTier
+	}
+
+	if tier, ok := v.conf.Tiers[claimed]; ok {
+		return tier
+	}
+
+	return claimed
+}
+
+// JwtAuthenticate authenticates requests with JWT bearer token if provided, otherwise falls back
+// to authenticate by API key.
+func JwtAuthenticate(v *jwtVerifier) rpc.HandleCallMsgMiddleware {
+	return func(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+		fallback := Authenticate(next)
+
+		return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+			token, ok := handlers.GetBearerTokenFromContext(ctx)
+			if !ok || len(token) == 0 {
+				return fallback(ctx, msg)
+			}
+
+			status, err := v.verify(token)
+			if err != nil {
+				return msg.ErrorResponse(errJwtUnauthorized(err))
+			}
+
+			ctx = context.WithValue(ctx, handlers.CtxKeyJwtStatus, status)
+			ctx = context.WithValue(ctx, handlers.CtxKeyAuthId, status.ID)
+
+			return next(ctx, msg)
+		}
+	}
+}
+
+func errJwtUnauthorized(err error) error {
+	return &rpc.JsonError{
+		Code:    jwtAuthErrorCode,
+		Message: errors.WithMessage(err, "invalid bearer token").Error(),
+	}
+}
+
+// jwks caches the public keys fetched from JWKS endpoint, keyed by key ID.
+type jwks struct {
+	url             string
+	refreshInterval time.Duration
+	client          *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]interface{}
+	lastFetched time.Time
+}
+
+func newJwks(url string, refreshInterval, timeout time.Duration) *jwks {
+	return &jwks{
+		url:             url,
+		refreshInterval: refreshInterval,
+		client:          &http.Client{Timeout: timeout},
+	}
+}
+
+// key returns the public key of the specified key ID, which refetches public keys if expired or
+// key ID not found.
+func (s *jwks) key(kid string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[kid]
+	elapsed := time.Since(s.lastFetched)
+
+	if (ok && elapsed < s.refreshInterval) || (!ok && elapsed < jwksMinRefetchInterval) {
+		if !ok {
+			return nil, errJwtKeyNotFound
+		}
+
+		return key, nil
+	}
+
+	keys, err := s.fetch()
+	if err != nil {
+		logrus.WithError(err).WithField("url", s.url).Warn("Failed to fetch JWKS")
+
+		if ok { // use the stale key
+			return key, nil
+		}
+
+		return nil, errors.WithMessage(err, "failed to fetch JWKS")
+	}
+
+	s.keys, s.lastFetched = keys, time.Now()
+
+	if key, ok = keys[kid]; !ok {
+		return nil, errJwtKeyNotFound
+	}
+
+	return key, nil
+}
+
+// jsonWebKey is the public key of JWKS, and only RSA and EC keys are supported.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA key
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC key
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (s *jwks) fetch() (map[string]interface{}, error) {
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, errors.WithMessage(err, "failed to decode JWKS")
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if len(jwk.Use) > 0 && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			logrus.WithError(err).WithField("kid", jwk.Kid).Warn("Failed to parse JWK, skipped")
+			continue
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (jwk *jsonWebKey) publicKey() (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeJwkInt(jwk.N)
+		if err != nil {
+			return nil, errors.WithMessage(err, "invalid modulus")
+		}
+
+		e, err := decodeJwkInt(jwk.E)
+		if err != nil || !e.IsInt64() {
+			return nil, errors.New("invalid exponent")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("unsupported curve %v", jwk.Crv)
+		}
+
+		x, err := decodeJwkInt(jwk.X)
+		if err != nil {
+			return nil, errors.WithMessage(err, "invalid x coordinate")
+		}
+
+		y, err := decodeJwkInt(jwk.Y)
+		if err != nil {
+			return nil, errors.WithMessage(err, "invalid y coordinate")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	default:
+		return nil, errors.Errorf("unsupported key type %v", jwk.Kty)
+	}
+}
+
+func decodeJwkInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,119 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestJwksServer(t *testing.T, kid string, key *rsa.PublicKey) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []jsonWebKey{{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+}
+
+func signTestJwt(t *testing.T, kid string, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+
+	return signed
+}
+
+func TestJwtVerifier(t *testing.T) {
+	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	server := newTestJwksServer(t, "k1", &privKey.PublicKey)
+	defer server.Close()
+
+	v := newJwtVerifier(&jwtConfig{
+		Issuer:              "https://idp.example.com/",
+		Audience:            "confura",
+		JwksUrl:             server.URL,
+		JwksRefreshInterval: time.Hour,
+		JwksTimeout:         time.Second,
+		Leeway:              time.Minute,
+		SubjectClaim:        "sub",
+		TierClaim:           "tier",
+		Tiers:               map[string]string{"gold": "vip3"},
+		DefaultTier:         "free",
+	})
+
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss": "https://idp.example.com/",
+			"aud": "confura",
+			"sub": "alice",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+
+		for k, v := range overrides {
+			c[k] = v
+		}
+
+		return c
+	}
+
+	// tier mapped
+	status, err := v.verify(signTestJwt(t, "k1", privKey, claims(jwt.MapClaims{"tier": "gold"})))
+	assert.NoError(t, err)
+	assert.Equal(t, "jwt:alice", status.ID)
+	assert.Equal(t, "vip3", status.Tier)
+
+	// tier used as is if not mapped
+	status, err = v.verify(signTestJwt(t, "k1", privKey, claims(jwt.MapClaims{"tier": "silver"})))
+	assert.NoError(t, err)
+	assert.Equal(t, "silver", status.Tier)
+
+	// default tier if absent
+	status, err = v.verify(signTestJwt(t, "k1", privKey, claims(nil)))
+	assert.NoError(t, err)
+	assert.Equal(t, "free", status.Tier)
+
+	// expired within leeway
+	_, err = v.verify(signTestJwt(t, "k1", privKey, claims(jwt.MapClaims{"exp": time.Now().Add(-30 * time.Second).Unix()})))
+	assert.NoError(t, err)
+
+	// expired beyond leeway
+	_, err = v.verify(signTestJwt(t, "k1", privKey, claims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})))
+	assert.Error(t, err)
+
+	// invalid issuer
+	_, err = v.verify(signTestJwt(t, "k1", privKey, claims(jwt.MapClaims{"iss": "https://evil.example.com/"})))
+	assert.Error(t, err)
+
+	// invalid audience
+	_, err = v.verify(signTestJwt(t, "k1", privKey, claims(jwt.MapClaims{"aud": "others"})))
+	assert.Error(t, err)
+
+	// unknown key ID
+	_, err = v.verify(signTestJwt(t, "k2", privKey, claims(nil)))
+	assert.Error(t, err)
+
+	// signed by other key
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	_, err = v.verify(signTestJwt(t, "k1", otherKey, claims(nil)))
+	assert.Error(t, err)
+}
@@ -4,22 +4,36 @@ import (
 	"context"
 	"time"
 
+	"github.com/Conflux-Chain/confura/util/logging"
 	"github.com/openweb3/go-rpc-provider"
 	"github.com/sirupsen/logrus"
 )
 
+// RequestId assigns an ID for each RPC call if not provided by client, which is threaded through
+// context so that logs of all components for the same request could be correlated.
+func RequestId(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		if _, ok := logging.RequestIdFromContext(ctx); !ok {
+			ctx = logging.NewContext(ctx, logging.NewRequestId())
+		}
+
+		return next(ctx, msg)
+	}
+}
+
 func LogBatch(next rpc.HandleBatchFunc) rpc.HandleBatchFunc {
 	return func(ctx context.Context, msgs []*rpc.JsonRpcMessage) []*rpc.JsonRpcMessage {
 		if !logrus.IsLevelEnabled(logrus.DebugLevel) {
 			return next(ctx, msgs)
 		}
 
-		logrus.WithField("batch", len(msgs)).Debug("Batch RPC enter")
+		logger := logging.FromContext(ctx)
+		logger.WithField("batch", len(msgs)).Debug("Batch RPC enter")
 
 		start := time.Now()
 		resp := next(ctx, msgs)
 
-		logrus.WithFields(logrus.Fields{
+		logger.WithFields(logrus.Fields{
 			"batch":   len(resp),
 			"elapsed": time.Since(start),
 		}).Debug("Batch RPC leave")
@@ -34,7 +48,7 @@ func Log(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 			return next(ctx, msg)
 		}
 
-		logger := logrus.WithField("input", msg)
+		logger := logging.FromContext(ctx).WithField("input", msg)
 		logger.Debug("RPC enter")
 
 		start := time.Now()
@@ -8,6 +8,7 @@ import (
 
 	"github.com/Conflux-Chain/confura/util/metrics"
 	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/confura/util/usage"
 	"github.com/openweb3/go-rpc-provider"
 )
 
@@ -40,6 +41,14 @@ func Metrics(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 			metrics.Registry.RPC.ResponseSize(space, metricMethod).Update(int64(len(resp.Result)))
 		}
 
+		// collect usage of API key
+		if authId, ok := handlers.GetAuthIdFromContext(ctx); ok && len(authId) > 0 {
+			metrics.Registry.RPC.ApiKeyRequests(space, authId).UpdateSince(start)
+			metrics.Registry.RPC.ApiKeyMethodRequests(space, authId, metricMethod).Mark(1)
+			metrics.Registry.RPC.ApiKeyErrorRate(space, authId).Mark(resp.Error != nil)
+			usage.Collect(space, authId, metricMethod, resp.Error != nil)
+		}
+
 		// collect traffic hits
 		metrics.DefaultTrafficCollector().MarkHit(getTrafficSourceFromContext(ctx))
 
@@ -5,13 +5,15 @@ import (
 	"fmt"
 
 	"github.com/Conflux-Chain/confura/util/rate"
+	rpcErrors "github.com/Conflux-Chain/confura/util/rpc/errors"
 	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/confura/util/usage"
 	"github.com/openweb3/go-rpc-provider"
 	"github.com/pkg/errors"
 )
 
 const (
-	ratelimitErrorCode = -32005
+	ratelimitErrorCode = rpcErrors.CodeRateLimited
 )
 
 func QpsRateLimit(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
@@ -23,12 +25,14 @@ func QpsRateLimit(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 
 		// overall rate limit
 		if err := registry.Limit(ctx, "rpc_all_qps"); err != nil {
+			collectRateLimited(ctx, msg)
 			return msg.ErrorResponse(errQpsRateLimited(err))
 		}
 
 		// single method rate limit
 		resource := fmt.Sprintf("%v_qps", msg.Method)
 		if err := registry.Limit(ctx, resource); err != nil {
+			collectRateLimited(ctx, msg)
 			return msg.ErrorResponse(errQpsRateLimited(err))
 		}
 
@@ -52,6 +56,7 @@ func DailyMaxReqRateLimit(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 
 		// constrain daily total requests
 		if err := registry.Limit(ctx, "rpc_all_daily"); err != nil {
+			collectRateLimited(ctx, msg)
 			return msg.ErrorResponse(errDailyMaxReqRateLimited(err))
 		}
 
@@ -65,3 +70,41 @@ func errDailyMaxReqRateLimited(err error) error {
 		Message: errors.WithMessage(err, "daily request count exceeded").Error(),
 	}
 }
+
+// TieredRateLimit limits requests at IP, API key and global levels by the distributed limiter
+// of the RPC namespace, if any. Note, API key tier only applies to keys validated by the rate
+// limit registry, otherwise requests are limited per IP.
+func TieredRateLimit(limiters map[string]*rate.TieredLimiter) rpc.HandleCallMsgMiddleware {
+	return func(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+		return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+			space, _ := handlers.GetNamespaceFromContext(ctx)
+
+			limiter, ok := limiters[space]
+			if !ok {
+				return next(ctx, msg)
+			}
+
+			ip, _ := handlers.GetIPAddressFromContext(ctx)
+
+			var key string
+			if registry, ok := ctx.Value(handlers.CtxKeyRateRegistry).(*rate.Registry); ok {
+				key, _ = registry.VerifiedAuthId(ctx)
+			}
+
+			if err := limiter.Limit(ctx, ip, key); err != nil {
+				collectRateLimited(ctx, msg)
+				return msg.ErrorResponse(errQpsRateLimited(err))
+			}
+
+			return next(ctx, msg)
+		}
+	}
+}
+
+// collectRateLimited accounts the rate limited request for usage of API key if any.
+func collectRateLimited(ctx context.Context, msg *rpc.JsonRpcMessage) {
+	if authId, ok := handlers.GetAuthIdFromContext(ctx); ok && len(authId) > 0 {
+		space, _ := handlers.GetNamespaceFromContext(ctx)
+		usage.CollectRateLimited(space, authId, msg.Method)
+	}
+}
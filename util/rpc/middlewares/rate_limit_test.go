@@ -0,0 +1,56 @@
+package middlewares
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/util/rate"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTieredRateLimitUnverifiedKey(t *testing.T) {
+	mr := miniredis.RunT(t)
+	limiter := rate.NewTieredLimiter("cfx", rate.TieredLimitConfig{
+		KeyPrefix: "ratelimit",
+		IP:        rate.TokenBucketOption{Rate: 0.001, Burst: 1},
+		Key:       rate.TokenBucketOption{Rate: 0.001, Burst: 100},
+	}, redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	registry := rate.NewRegistry(rate.NewKeyLoader(func(filter *rate.KeysetFilter) ([]*rate.KeyInfo, error) {
+		var kis []*rate.KeyInfo
+		for _, key := range filter.KeySet {
+			if key == "validKey" {
+				kis = append(kis, &rate.KeyInfo{Key: key, Type: rate.LimitTypeByKey})
+			}
+		}
+
+		return kis, nil
+	}), nil)
+
+	handler := TieredRateLimit(map[string]*rate.TieredLimiter{"cfx": limiter})(
+		func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+			return &rpc.JsonRpcMessage{}
+		},
+	)
+
+	call := func(key string) *rpc.JsonRpcMessage {
+		ctx := context.WithValue(context.Background(), handlers.CtxKeyNamespace, "cfx")
+		ctx = context.WithValue(ctx, handlers.CtxKeyRealIP, "10.0.0.1")
+		ctx = context.WithValue(ctx, handlers.CtxKeyRateRegistry, registry)
+		ctx = context.WithValue(ctx, handlers.CtxKeyAuthId, key)
+
+		return handler(ctx, &rpc.JsonRpcMessage{Method: "cfx_epochNumber"})
+	}
+
+	// rotating random keys are limited per IP
+	assert.Nil(t, call("fakeKey1").Error)
+	assert.NotNil(t, call("fakeKey2").Error)
+
+	// valid key is limited per key
+	assert.Nil(t, call("validKey").Error)
+	assert.Nil(t, call("validKey").Error)
+}
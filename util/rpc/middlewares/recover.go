@@ -5,6 +5,7 @@ import (
 	"errors"
 	"runtime/debug"
 
+	"github.com/Conflux-Chain/confura/util/logging"
 	"github.com/Conflux-Chain/confura/util/rpc/handlers"
 	"github.com/openweb3/go-rpc-provider"
 	"github.com/sirupsen/logrus"
@@ -25,13 +26,14 @@ func Recover(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 				apiToken, _ := handlers.GetAccessTokenFromContext(ctx)
 				ipAddr, _ := handlers.GetIPAddressFromContext(ctx)
 
-				logrus.WithFields(logrus.Fields{
+				logger := logging.FromContext(ctx)
+				logger.WithFields(logrus.Fields{
 					"ipAddress": ipAddr,
 					"apiToken":  apiToken,
 				}).Info("RPC middleware panic with request context")
 
 				// alert error message
-				logrus.WithFields(logrus.Fields{
+				logger.WithFields(logrus.Fields{
 					"inputMsg": newHumanReadableRpcMessage(msg),
 					"panicErr": err,
 				}).Error("RPC middleware panic recovered")
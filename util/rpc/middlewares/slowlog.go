@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/logging"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/confura/util/slowlog"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/sirupsen/logrus"
+)
+
+// SlowLog records the requests exceeding the latency threshold along with timing breakdown, if
+// slow request recorder enabled for the RPC namespace.
+func SlowLog(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		space, _ := handlers.GetNamespaceFromContext(ctx)
+
+		recorder, ok := slowlog.Get(space)
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		ctx, trace := slowlog.NewContext(ctx)
+
+		start := time.Now()
+		resp := next(ctx, msg)
+
+		elapsed := time.Since(start)
+		if elapsed < recorder.Threshold() {
+			return resp
+		}
+
+		entry := slowlog.Entry{
+			Time:         start,
+			Method:       msg.Method,
+			ParamsDigest: slowlog.Digest(msg.Params),
+			Elapsed:      elapsed.Milliseconds(),
+			Stages:       trace.Stages(),
+		}
+
+		entry.AuthId, _ = handlers.GetAuthIdFromContext(ctx)
+		entry.IP, _ = handlers.GetIPAddressFromContext(ctx)
+
+		if resp.Error != nil {
+			entry.Error = resp.Error.Error()
+		}
+
+		recorder.Add(entry)
+
+		logging.FromContext(ctx).WithFields(logrus.Fields{
+			"method":  entry.Method,
+			"params":  entry.ParamsDigest,
+			"authId":  entry.AuthId,
+			"ip":      entry.IP,
+			"elapsed": elapsed,
+			"stages":  entry.Stages,
+		}).Info("Slow RPC request recorded")
+
+		return resp
+	}
+}
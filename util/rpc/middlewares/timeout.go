@@ -0,0 +1,86 @@
+package middlewares
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcErrors "github.com/Conflux-Chain/confura/util/rpc/errors"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/openweb3/go-rpc-provider"
+)
+
+const (
+	requestTimeoutErrorCode = rpcErrors.CodeInternal
+)
+
+var (
+	errRequestTimeout = &rpc.JsonError{
+		Code:    requestTimeoutErrorCode,
+		Message: "request timed out",
+	}
+
+	errRequestCanceled = &rpc.JsonError{
+		Code:    requestTimeoutErrorCode,
+		Message: "request canceled",
+	}
+)
+
+// TimeoutConfig is the per method request timeout settings.
+type TimeoutConfig struct {
+	// default timeout for all methods, zero means no timeout
+	Default time.Duration
+	// timeout overrides keyed by RPC method
+	Methods map[string]time.Duration
+}
+
+// Timeout returns the request timeout of the specified RPC method, and zero if no timeout.
+func (c *TimeoutConfig) Timeout(method string) time.Duration {
+	// method names are case insensitive as config keys
+	for m, timeout := range c.Methods {
+		if strings.EqualFold(m, method) {
+			return timeout
+		}
+	}
+
+	return c.Default
+}
+
+// Timeout sets deadline on the request context per RPC method keyed by RPC namespace, which will
+// be propagated to the full node requests and database queries. Note, the request context is also
+// canceled once client disconnected.
+func Timeout(confs map[string]*TimeoutConfig) rpc.HandleCallMsgMiddleware {
+	return func(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+		return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+			space, _ := handlers.GetNamespaceFromContext(ctx)
+
+			if conf, ok := confs[space]; ok {
+				if timeout := conf.Timeout(msg.Method); timeout > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, timeout)
+					defer cancel()
+				}
+			}
+
+			resp := next(ctx, msg)
+
+			switch ctx.Err() {
+			case context.DeadlineExceeded:
+				metrics.Registry.RPC.Timeouts(space, msg.Method).Mark(1)
+
+				if resp == nil || resp.Error != nil {
+					return msg.ErrorResponse(errRequestTimeout)
+				}
+			case context.Canceled:
+				metrics.Registry.RPC.Cancellations(space, msg.Method).Mark(1)
+
+				if resp == nil || resp.Error != nil {
+					return msg.ErrorResponse(errRequestCanceled)
+				}
+			}
+
+			return resp
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutConfig(t *testing.T) {
+	conf := TimeoutConfig{
+		Default: 3 * time.Second,
+		Methods: map[string]time.Duration{"cfx_getlogs": 10 * time.Second},
+	}
+
+	assert.Equal(t, 10*time.Second, conf.Timeout("cfx_getLogs"))
+	assert.Equal(t, 3*time.Second, conf.Timeout("cfx_epochNumber"))
+}
+
+func TestTimeout(t *testing.T) {
+	handler := Timeout(map[string]*TimeoutConfig{
+		"cfx": {Methods: map[string]time.Duration{"cfx_getLogs": 10 * time.Millisecond}},
+	})(func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		// simulates slow full node or database, which is aborted on context done
+		select {
+		case <-ctx.Done():
+			return msg.ErrorResponse(ctx.Err())
+		case <-time.After(100 * time.Millisecond):
+			return &rpc.JsonRpcMessage{}
+		}
+	})
+
+	ctx := context.WithValue(context.Background(), handlers.CtxKeyNamespace, "cfx")
+
+	// deadline propagated
+	resp := handler(ctx, &rpc.JsonRpcMessage{Method: "cfx_getLogs"})
+	assert.Equal(t, errRequestTimeout.Message, resp.Error.Message)
+
+	// methods without timeout configured
+	resp = handler(ctx, &rpc.JsonRpcMessage{Method: "cfx_epochNumber"})
+	assert.Nil(t, resp.Error)
+
+	// client disconnected
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	resp = handler(ctx, &rpc.JsonRpcMessage{Method: "cfx_epochNumber"})
+	assert.Equal(t, errRequestCanceled.Message, resp.Error.Message)
+}
@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/confura/util/tracing"
+	"github.com/openweb3/go-rpc-provider"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Tracing starts a server span for each RPC request, which is the parent span of the following
+// cache, store and full node spans.
+func Tracing(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		space, _ := handlers.GetNamespaceFromContext(ctx)
+
+		ctx, span := tracing.Start(ctx, "rpc/"+msg.Method,
+			attribute.String("rpc.system", "jsonrpc"),
+			attribute.String("rpc.method", msg.Method),
+			attribute.String("rpc.space", space),
+		)
+
+		resp := next(ctx, msg)
+
+		if resp != nil && resp.Error != nil {
+			tracing.End(span, resp.Error)
+		} else {
+			tracing.End(span, nil)
+		}
+
+		return resp
+	}
+}
@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
+	"github.com/Conflux-Chain/confura/util/tracing"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+
+	prevProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prevProvider) })
+
+	handler := Tracing(func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		_, span := tracing.Start(ctx, "store/getLogs")
+		tracing.End(span, nil)
+
+		if msg.Method == "cfx_getLogs" {
+			return msg.ErrorResponse(errors.New("too many logs"))
+		}
+
+		return &rpc.JsonRpcMessage{}
+	})
+
+	ctx := context.WithValue(context.Background(), handlers.CtxKeyNamespace, "cfx")
+	handler(ctx, &rpc.JsonRpcMessage{Method: "cfx_epochNumber"})
+	handler(ctx, &rpc.JsonRpcMessage{Method: "cfx_getLogs"})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+
+	// spans of the following handlers are children of the server span
+	for i := 0; i < len(spans); i += 2 {
+		child, server := spans[i], spans[i+1]
+		assert.Equal(t, server.SpanContext().SpanID(), child.Parent().SpanID())
+		assert.Contains(t, server.Attributes(), attribute.String("rpc.space", "cfx"))
+	}
+
+	assert.Equal(t, "rpc/cfx_epochNumber", spans[1].Name())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+
+	assert.Equal(t, "rpc/cfx_getLogs", spans[3].Name())
+	assert.Contains(t, spans[3].Attributes(), attribute.String("rpc.method", "cfx_getLogs"))
+	assert.Equal(t, codes.Error, spans[3].Status().Code)
+	assert.Equal(t, "too many logs", spans[3].Status().Description)
+}
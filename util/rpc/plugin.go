@@ -0,0 +1,154 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/openweb3/go-rpc-provider"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// ClientRequest is the request to full node passed through client plugins, which could be mutated
+// by plugins before sent to full node.
+type ClientRequest struct {
+	Space  string // cfx or eth
+	Node   string // full node name
+	Method string
+	Args   []interface{}
+
+	// extra HTTP headers to inject into the request, which is ignored for websocket clients
+	Header http.Header
+}
+
+// ClientResponse is the response from full node passed through client plugins.
+type ClientResponse struct {
+	Result   interface{} // pointer to the result that unmarshaled into
+	Err      error       // could be replaced by plugins
+	Duration time.Duration
+}
+
+// ClientPlugin hooks requests to full nodes for all RPC clients, e.g., custom auditing, header
+// injection or request mutation.
+type ClientPlugin interface {
+	// Name returns the unique plugin name.
+	Name() string
+
+	// BeforeRequest is called before the request sent to full node, which could mutate the request,
+	// or reject the request with an error.
+	BeforeRequest(ctx context.Context, req *ClientRequest) error
+
+	// AfterRequest is called after the response received from full node or the request rejected.
+	AfterRequest(ctx context.Context, req *ClientRequest, resp *ClientResponse)
+}
+
+type pluginHeaderCtxKey struct{}
+
+var (
+	pluginMu      sync.RWMutex
+	clientPlugins []ClientPlugin
+
+	pluginHeaderOnce sync.Once
+)
+
+// RegisterClientPlugin registers the client plugin, which should be called at startup before RPC
+// clients created. Plugins are called in order of registration before request, and in reverse order
+// after request.
+func RegisterClientPlugin(plugin ClientPlugin) error {
+	pluginMu.Lock()
+	defer pluginMu.Unlock()
+
+	for _, p := range clientPlugins {
+		if p.Name() == plugin.Name() {
+			return errors.Errorf("client plugin %v already registered", plugin.Name())
+		}
+	}
+
+	clientPlugins = append(clientPlugins, plugin)
+
+	pluginHeaderOnce.Do(func() {
+		rpc.RegisterBeforeSendHttp(injectPluginHeaders)
+	})
+
+	return nil
+}
+
+// MustRegisterClientPlugin registers the client plugin, and panics if failed.
+func MustRegisterClientPlugin(plugin ClientPlugin) {
+	if err := RegisterClientPlugin(plugin); err != nil {
+		panic(err)
+	}
+}
+
+func registeredClientPlugins() []ClientPlugin {
+	pluginMu.RLock()
+	defer pluginMu.RUnlock()
+
+	return append([]ClientPlugin(nil), clientPlugins...)
+}
+
+func injectPluginHeaders(ctx context.Context, req *fasthttp.Request) error {
+	header, ok := ctx.Value(pluginHeaderCtxKey{}).(http.Header)
+	if !ok {
+		return nil
+	}
+
+	for key, values := range header {
+		for i, v := range values {
+			if i == 0 {
+				req.Header.Set(key, v)
+			} else {
+				req.Header.Add(key, v)
+			}
+		}
+	}
+
+	return nil
+}
+
+func middlewarePlugins(fullnode, space string, plugins []ClientPlugin) providers.CallContextMiddleware {
+	return func(handler providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			req := &ClientRequest{
+				Space:  space,
+				Node:   fullnode,
+				Method: method,
+				Args:   args,
+				Header: make(http.Header),
+			}
+
+			start := time.Now()
+			resp := &ClientResponse{Result: result}
+
+			// plugins called before request, which should be called after request as well
+			var numCalled int
+			for _, p := range plugins {
+				numCalled++
+
+				if resp.Err = p.BeforeRequest(ctx, req); resp.Err != nil {
+					resp.Err = errors.WithMessagef(resp.Err, "rejected by client plugin %v", p.Name())
+					break
+				}
+			}
+
+			if resp.Err == nil {
+				if len(req.Header) > 0 {
+					ctx = context.WithValue(ctx, pluginHeaderCtxKey{}, req.Header)
+				}
+
+				resp.Err = handler(ctx, result, req.Method, req.Args...)
+			}
+
+			resp.Duration = time.Since(start)
+
+			for i := numCalled - 1; i >= 0; i-- {
+				plugins[i].AfterRequest(ctx, req, resp)
+			}
+
+			return resp.Err
+		}
+	}
+}
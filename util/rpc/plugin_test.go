@@ -0,0 +1,64 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type testClientPlugin struct {
+	name   string
+	calls  *[]string
+	reject bool
+}
+
+func (p *testClientPlugin) Name() string { return p.name }
+
+func (p *testClientPlugin) BeforeRequest(ctx context.Context, req *ClientRequest) error {
+	*p.calls = append(*p.calls, "before:"+p.name)
+
+	if p.reject {
+		return errors.New("rejected")
+	}
+
+	req.Method = req.Method + "_" + p.name
+	req.Header.Set("X-Plugin", p.name)
+
+	return nil
+}
+
+func (p *testClientPlugin) AfterRequest(ctx context.Context, req *ClientRequest, resp *ClientResponse) {
+	*p.calls = append(*p.calls, "after:"+p.name)
+}
+
+func TestMiddlewarePlugins(t *testing.T) {
+	var calls []string
+	plugins := []ClientPlugin{
+		&testClientPlugin{name: "p1", calls: &calls},
+		&testClientPlugin{name: "p2", calls: &calls},
+	}
+
+	var requested string
+	handler := func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+		requested = method
+		assert.Equal(t, "p2", ctx.Value(pluginHeaderCtxKey{}).(http.Header).Get("X-Plugin"))
+		return nil
+	}
+
+	err := middlewarePlugins("node", "cfx", plugins)(handler)(context.Background(), nil, "cfx_epochNumber")
+	assert.NoError(t, err)
+	assert.Equal(t, "cfx_epochNumber_p1_p2", requested)
+	assert.Equal(t, []string{"before:p1", "before:p2", "after:p2", "after:p1"}, calls)
+
+	// rejected by plugin, and the plugins after will not be called
+	calls, requested = nil, ""
+	plugins[0].(*testClientPlugin).reject = true
+
+	err = middlewarePlugins("node", "cfx", plugins)(handler)(context.Background(), nil, "cfx_epochNumber")
+	assert.Error(t, err)
+	assert.Empty(t, requested)
+	assert.Equal(t, []string{"before:p1", "after:p1"}, calls)
+}
@@ -0,0 +1,110 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RouteServer serves multiple RPC servers on a shared endpoint, which are routed by URL path
+// prefix, e.g. `/mainnet` and `/testnet`.
+type RouteServer struct {
+	name     string
+	protocol Protocol
+	mux      *http.ServeMux
+	routes   map[string]*Server
+	tlsConf  *tls.Config // nil if TLS not enabled
+}
+
+// NewRouteServer creates an instance of RouteServer for the specified protocol.
+func NewRouteServer(name string, protocol Protocol) *RouteServer {
+	return &RouteServer{
+		name:     name,
+		protocol: protocol,
+		mux:      http.NewServeMux(),
+		routes:   make(map[string]*Server),
+		tlsConf:  MustNewTLSConfigFromViper(name),
+	}
+}
+
+// MustRoute routes requests of the specified URL path prefix to RPC server, and the root path `/`
+// matches all requests not routed to others.
+func (rs *RouteServer) MustRoute(path string, server *Server) {
+	path = "/" + strings.Trim(path, "/")
+
+	logger := logrus.WithFields(logrus.Fields{
+		"name":   rs.name,
+		"path":   path,
+		"server": server.name,
+	})
+
+	if _, ok := rs.routes[path]; ok {
+		logger.Fatal("RPC server route path conflicted")
+	}
+
+	handler := server.servers[rs.protocol].Handler
+	if path == "/" {
+		rs.mux.Handle(path, handler)
+	} else {
+		rs.mux.Handle(path, http.StripPrefix(path, handler))
+		rs.mux.Handle(path+"/", http.StripPrefix(path, handler))
+	}
+
+	rs.routes[path] = server
+	logger.Info("RPC server route added")
+}
+
+// MustServeGraceful serves all the routed RPC servers in a goroutine until graceful shutdown.
+func (rs *RouteServer) MustServeGraceful(ctx context.Context, wg *sync.WaitGroup, endpoint string) {
+	wg.Add(1)
+	defer wg.Done()
+
+	logger := logrus.WithFields(logrus.Fields{
+		"name":     rs.name,
+		"endpoint": endpoint,
+		"protocol": rs.protocol,
+	})
+
+	listener, err := listen(endpoint)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to listen to endpoint")
+	}
+
+	listener = &namedListener{Listener: listener, name: listenerName(endpoint, rs.protocol)}
+
+	// no TLS required for local Unix domain socket
+	_, isUnix := parseUnixEndpoint(endpoint)
+	if rs.tlsConf != nil && !isUnix {
+		listener = tls.NewListener(listener, rs.tlsConf)
+	}
+
+	server := http.Server{Handler: rs.mux, ConnContext: listenerConnContext}
+	go server.Serve(listener)
+
+	logger.WithFields(logrus.Fields{
+		"routes": len(rs.routes),
+		"tls":    rs.tlsConf != nil && !isUnix,
+	}).Info("JSON RPC route server started")
+
+	<-ctx.Done()
+
+	// hijacked websocket connections are not tracked by HTTP server
+	if rs.protocol == ProtocolWS {
+		for _, s := range rs.routes {
+			s.wsHandler.closeAll(wsCloseGoingAway, wsCloseReasonShutdown)
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.WithError(err).Error("Failed to shutdown RPC route server")
+	} else {
+		logger.Info("Succeed to shutdown RPC route server")
+	}
+}
@@ -1,41 +1,45 @@
 package rpc
 
 import (
-	"compress/gzip"
-	"io"
 	"net"
 	"net/http"
 	"strings"
-	"sync"
 
-	"github.com/rs/cors"
+	"github.com/Conflux-Chain/confura/util/logging"
+	"github.com/Conflux-Chain/confura/util/tracing"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 // newHTTPHandlerStack returns wrapped http-related handlers
-func newHTTPHandlerStack(srv http.Handler, cors []string, vhosts []string) http.Handler {
+func newHTTPHandlerStack(srv http.Handler, cors *corsConfig, vhosts []string) http.Handler {
 	// Wrap the CORS-handler within a host-handler
 	handler := newCorsHandler(srv, cors)
 	handler = newVHostHandler(vhosts, handler)
-
-	// Due to potential memory leak (https://github.com/gin-contrib/gzip/issues/26) under
-	// high throughput requests, we disable Gzip compressing for more memory efficiency.
-	// handler = newGzipHandler(handler)
+	handler = newTracingHandler(handler)
+	handler = newRequestIdHandler(handler)
+	handler = newDrainHandler(handler)
 
 	return handler
 }
 
-func newCorsHandler(srv http.Handler, allowedOrigins []string) http.Handler {
-	// disable CORS support if user has not specified a custom CORS configuration
-	if len(allowedOrigins) == 0 {
-		return srv
-	}
-	c := cors.New(cors.Options{
-		AllowedOrigins: allowedOrigins,
-		AllowedMethods: []string{http.MethodPost, http.MethodGet},
-		AllowedHeaders: []string{"*"},
-		MaxAge:         600,
+// newTracingHandler extracts the propagated trace context from HTTP headers.
+func newTracingHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// newRequestIdHandler extracts the request ID provided by client (or upstream service) from HTTP
+// headers, so that logs across services could be correlated.
+func newRequestIdHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := logging.SanitizeRequestId(r.Header.Get(logging.HeaderRequestId)); ok {
+			r = r.WithContext(logging.NewContext(r.Context(), id))
+		}
+
+		next.ServeHTTP(w, r)
 	})
-	return c.Handler(srv)
 }
 
 // virtualHostHandler is a handler which validates the Host-header of incoming requests.
@@ -84,43 +88,3 @@ func (h *virtualHostHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	http.Error(w, "invalid host specified", http.StatusForbidden)
 }
-
-var gzPool = sync.Pool{
-	New: func() interface{} {
-		w := gzip.NewWriter(io.Discard)
-		return w
-	},
-}
-
-type gzipResponseWriter struct {
-	io.Writer
-	http.ResponseWriter
-}
-
-func (w *gzipResponseWriter) WriteHeader(status int) {
-	w.Header().Del("Content-Length")
-	w.ResponseWriter.WriteHeader(status)
-}
-
-func (w *gzipResponseWriter) Write(b []byte) (int, error) {
-	return w.Writer.Write(b)
-}
-
-func newGzipHandler(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		w.Header().Set("Content-Encoding", "gzip")
-
-		gz := gzPool.Get().(*gzip.Writer)
-		defer gzPool.Put(gz)
-
-		gz.Reset(w)
-		defer gz.Close()
-
-		next.ServeHTTP(&gzipResponseWriter{ResponseWriter: w, Writer: gz}, r)
-	})
-}
@@ -2,7 +2,7 @@ package rpc
 
 import (
 	"context"
-	"net"
+	"crypto/tls"
 	"net/http"
 	"sync"
 	"time"
@@ -30,8 +30,14 @@ var (
 
 // Server serves JSON RPC services.
 type Server struct {
-	name    string
-	servers map[Protocol]*http.Server
+	name      string
+	servers   map[Protocol]*http.Server
+	wsHandler *wsHandler
+	tlsConf   *tls.Config // nil if TLS not enabled
+
+	// RPC handler and HTTP middlewares for in-process calls, see `DialInProc` for more details
+	handler     *rpc.Server
+	middlewares []handlers.Middleware
 }
 
 // MustNewServer creates an instance of Server with specified RPC services.
@@ -51,31 +57,51 @@ func MustNewServer(name string, rpcs map[string]interface{}, middlewares ...hand
 		"name": name,
 	}).Info("RPC server APIs registered")
 
+	cors := mustNewCorsConfigFromViper(name)
+
 	httpServer := http.Server{
-		Handler: newHTTPHandlerStack(handler, []string{"*"}, []string{"*"}),
+		Handler:     newHTTPHandlerStack(handler, cors, []string{"*"}),
+		ConnContext: listenerConnContext,
 	}
 
+	compression := mustNewCompressionConfigFromViper()
+
 	viper.SetDefault("rpc.wsPingInterval", defaultWsPingInterval)
-	wsServer := http.Server{
-		Handler: handler.WebsocketHandler([]string{"*"}, rpc.WebsocketOption{
-			WsPingInterval: viper.GetDuration("rpc.wsPingInterval"),
-		}),
-	}
+	wsHandler := newWsHandler(name, mustNewWsConfigFromViper(), compression, newWsOriginHandler(handler.WebsocketHandler(
+		[]string{"*"}, rpc.WebsocketOption{WsPingInterval: viper.GetDuration("rpc.wsPingInterval")},
+	), cors))
+	wsServer := http.Server{Handler: wsHandler, ConnContext: listenerConnContext}
 
 	for i := len(middlewares) - 1; i >= 0; i-- {
 		httpServer.Handler = middlewares[i](httpServer.Handler)
 		wsServer.Handler = middlewares[i](wsServer.Handler)
 	}
 
+	// compress the final response of HTTP server, e.g., the assembled response of batch
+	httpServer.Handler = newCompressHandler(name, compression, httpServer.Handler)
+
 	return &Server{
 		name: name,
 		servers: map[Protocol]*http.Server{
 			ProtocolHttp: &httpServer,
 			ProtocolWS:   &wsServer,
 		},
+		wsHandler:   wsHandler,
+		tlsConf:     MustNewTLSConfigFromViper(name),
+		handler:     handler,
+		middlewares: middlewares,
 	}
 }
 
+// MustNewAdminServer creates an instance of Server for admin endpoint, which requires client
+// certificates for mutual TLS if admin TLS configured.
+func MustNewAdminServer(name string, rpcs map[string]interface{}, middlewares ...handlers.Middleware) *Server {
+	server := MustNewServer(name, rpcs, middlewares...)
+	server.tlsConf = MustNewAdminTLSConfigFromViper()
+
+	return server
+}
+
 // MustServe serves RPC server in blocking way or panics if failed.
 func (s *Server) MustServe(endpoint string, protocol Protocol) {
 	logger := logrus.WithFields(logrus.Fields{
@@ -89,12 +115,20 @@ func (s *Server) MustServe(endpoint string, protocol Protocol) {
 		logger.Fatal("RPC protocol unsupported")
 	}
 
-	listener, err := net.Listen("tcp", endpoint)
+	listener, err := listen(endpoint)
 	if err != nil {
 		logger.WithError(err).Fatal("Failed to listen to endpoint")
 	}
 
-	logger.Info("JSON RPC server started")
+	listener = &namedListener{Listener: listener, name: listenerName(endpoint, protocol)}
+
+	// no TLS required for local Unix domain socket
+	_, isUnix := parseUnixEndpoint(endpoint)
+	if s.tlsConf != nil && !isUnix {
+		listener = tls.NewListener(listener, s.tlsConf)
+	}
+
+	logger.WithField("tls", s.tlsConf != nil && !isUnix).Info("JSON RPC server started")
 
 	server.Serve(listener)
 }
@@ -122,6 +156,11 @@ func (s *Server) shutdown(protocol Protocol) {
 		"protocol": protocol,
 	})
 
+	// hijacked websocket connections are not tracked by HTTP server
+	if protocol == ProtocolWS {
+		s.wsHandler.closeAll(wsCloseGoingAway, wsCloseReasonShutdown)
+	}
+
 	if err := s.servers[protocol].Shutdown(ctx); err != nil {
 		logger.WithError(err).Error("Failed to shutdown RPC server")
 	} else {
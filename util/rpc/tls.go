@@ -0,0 +1,287 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// tlsConfig represents the TLS configuration of listeners, so that small deployments could serve
+// HTTPS/WSS directly without an extra reverse proxy.
+type tlsConfig struct {
+	// whether to disable TLS, e.g., for the internal RPC server behind a load balancer
+	Disabled bool
+	// PEM encoded certificate (chain) and private key files
+	CertFile string
+	KeyFile  string
+	// certificates automatically issued by ACME (e.g., Let's Encrypt) if no certificate file specified
+	AutoCert autoCertConfig
+	// PEM encoded CA certificates to verify client certificates, which are required if specified
+	ClientCAFile string
+}
+
+type autoCertConfig struct {
+	// domains allowed to issue certificates for, which is required to enable auto cert
+	Domains []string
+	// directory to cache the issued certificates and account key
+	CacheDir string `default:"autocert"`
+	// contact email of ACME account, which is optional
+	Email string
+	// endpoint to serve ACME HTTP-01 challenges, which must be reachable on port 80 of the domains
+	HttpEndpoint string `default:":80"`
+}
+
+type adminTlsConfig struct {
+	tlsConfig `mapstructure:",squash"`
+	// client certificate presented by internal clients of admin endpoints, e.g., node manager router
+	Client struct {
+		CertFile string
+		KeyFile  string
+		// PEM encoded CA certificates to verify admin server certificates, system roots if empty
+		RootCAFile string
+	}
+}
+
+type tlsConfigs struct {
+	tlsConfig `mapstructure:",squash"`
+	// overrides of non-empty configurations keyed by RPC server name, e.g., `core_space_rpc`
+	Servers map[string]tlsConfig
+}
+
+// enabled returns true if certificate file or auto cert domains specified.
+func (conf *tlsConfig) enabled() bool {
+	return !conf.Disabled && (len(conf.CertFile) > 0 || len(conf.AutoCert.Domains) > 0)
+}
+
+// merge overrides the non-empty configurations.
+func (conf *tlsConfig) merge(override *tlsConfig) {
+	if override.Disabled {
+		conf.Disabled = true
+	}
+
+	// certificate file takes precedence over auto cert
+	if len(override.CertFile) > 0 {
+		conf.CertFile, conf.KeyFile = override.CertFile, override.KeyFile
+		conf.AutoCert.Domains = nil
+	}
+
+	if len(override.AutoCert.Domains) > 0 {
+		conf.CertFile, conf.KeyFile = "", ""
+		conf.AutoCert.Domains = override.AutoCert.Domains
+	}
+
+	if len(override.AutoCert.CacheDir) > 0 {
+		conf.AutoCert.CacheDir = override.AutoCert.CacheDir
+	}
+
+	if len(override.AutoCert.Email) > 0 {
+		conf.AutoCert.Email = override.AutoCert.Email
+	}
+
+	if len(override.AutoCert.HttpEndpoint) > 0 {
+		conf.AutoCert.HttpEndpoint = override.AutoCert.HttpEndpoint
+	}
+
+	if len(override.ClientCAFile) > 0 {
+		conf.ClientCAFile = override.ClientCAFile
+	}
+}
+
+// MustNewTLSConfigFromViper creates the TLS configuration of the specified public server, or nil
+// if not configured.
+func MustNewTLSConfigFromViper(name string) *tls.Config {
+	var confs tlsConfigs
+	viper.MustUnmarshalKey("rpc.tls", &confs)
+
+	conf := confs.tlsConfig
+	if override, ok := confs.Servers[strings.ToLower(name)]; ok {
+		conf.merge(&override)
+	}
+
+	tlsConf, err := newTLSConfig(&conf, false)
+	if err != nil {
+		logrus.WithError(err).WithField("name", name).Fatal("Failed to create TLS config")
+	}
+
+	return tlsConf
+}
+
+// MustNewAdminTLSConfigFromViper creates the TLS configuration of admin endpoints (including gRPC
+// management services), which requires client certificates for mutual TLS, or nil if not configured.
+func MustNewAdminTLSConfigFromViper() *tls.Config {
+	var conf adminTlsConfig
+	viper.MustUnmarshalKey("rpc.adminTls", &conf)
+
+	tlsConf, err := newTLSConfig(&conf.tlsConfig, true)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create admin TLS config")
+	}
+
+	return tlsConf
+}
+
+// newTLSConfig creates the TLS configuration, or nil if not enabled. Note, client CA is mandatory
+// if client certificates required.
+func newTLSConfig(conf *tlsConfig, requireClientCert bool) (*tls.Config, error) {
+	if !conf.enabled() {
+		return nil, nil
+	}
+
+	if requireClientCert && len(conf.ClientCAFile) == 0 {
+		return nil, errors.New("client CA file required for mutual TLS")
+	}
+
+	var tlsConf *tls.Config
+
+	if len(conf.CertFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to load certificate key pair")
+		}
+
+		tlsConf = &tls.Config{Certificates: []tls.Certificate{cert}}
+	} else {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(conf.AutoCert.Domains...),
+			Cache:      autocert.DirCache(conf.AutoCert.CacheDir),
+			Email:      conf.AutoCert.Email,
+		}
+
+		// certificates are issued via HTTP-01 challenge on port 80, or TLS-ALPN challenge on the
+		// same listener if served on port 443
+		if err := serveAcmeChallenges(conf.AutoCert.HttpEndpoint, manager); err != nil {
+			return nil, errors.WithMessage(err, "failed to serve ACME HTTP-01 challenges")
+		}
+
+		tlsConf = manager.TLSConfig()
+	}
+
+	tlsConf.MinVersion = tls.VersionTLS12
+
+	if len(conf.ClientCAFile) > 0 {
+		pemCerts, err := os.ReadFile(conf.ClientCAFile)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to read client CA file")
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemCerts) {
+			return nil, errors.New("no valid certificate found in client CA file")
+		}
+
+		tlsConf.ClientCAs = pool
+		tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConf, nil
+}
+
+// newAdminClientTLSConfig creates the TLS configuration of internal clients to admin endpoints, or
+// nil if no client certificate configured.
+func newAdminClientTLSConfig(conf *adminTlsConfig) (*tls.Config, error) {
+	if len(conf.Client.CertFile) == 0 {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(conf.Client.CertFile, conf.Client.KeyFile)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load client certificate key pair")
+	}
+
+	tlsConf := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	if len(conf.Client.RootCAFile) > 0 {
+		pemCerts, err := os.ReadFile(conf.Client.RootCAFile)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to read root CA file")
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemCerts) {
+			return nil, errors.New("no valid certificate found in root CA file")
+		}
+
+		tlsConf.RootCAs = pool
+	}
+
+	return tlsConf, nil
+}
+
+var (
+	acmeChallengeMu       sync.Mutex
+	acmeChallengeHandlers = make(map[string]*acmeChallengeHandler) // endpoint => handler
+)
+
+// acmeChallengeHandler serves ACME HTTP-01 challenges of auto cert managers by requested host,
+// since certificates of all RPC servers are validated on the same port 80.
+type acmeChallengeHandler struct {
+	mu       sync.RWMutex
+	managers []*autocert.Manager
+}
+
+func (h *acmeChallengeHandler) add(manager *autocert.Manager) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.managers = append(h.managers, manager)
+}
+
+func (h *acmeChallengeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if v, _, err := net.SplitHostPort(host); err == nil {
+		host = v
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, m := range h.managers {
+		if m.HostPolicy(r.Context(), host) == nil {
+			// redirects to HTTPS if not ACME challenge
+			m.HTTPHandler(nil).ServeHTTP(w, r)
+			return
+		}
+	}
+
+	http.NotFound(w, r)
+}
+
+// serveAcmeChallenges serves ACME HTTP-01 challenges of the auto cert manager on the endpoint,
+// which is shared among all auto cert managers.
+func serveAcmeChallenges(endpoint string, manager *autocert.Manager) error {
+	acmeChallengeMu.Lock()
+	defer acmeChallengeMu.Unlock()
+
+	if h, ok := acmeChallengeHandlers[endpoint]; ok {
+		h.add(manager)
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return err
+	}
+
+	h := &acmeChallengeHandler{managers: []*autocert.Manager{manager}}
+	acmeChallengeHandlers[endpoint] = h
+
+	go func() {
+		logrus.WithField("endpoint", endpoint).Info("ACME HTTP-01 challenge server started")
+
+		if err := http.Serve(listener, h); err != nil {
+			logrus.WithError(err).WithField("endpoint", endpoint).Error("ACME HTTP-01 challenge server stopped")
+		}
+	}()
+
+	return nil
+}
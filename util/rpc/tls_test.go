@@ -0,0 +1,115 @@
+package rpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSelfSignedCert writes a self signed certificate and private key into the directory.
+func writeSelfSignedCert(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "confura"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+
+	return certFile, keyFile
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t, t.TempDir())
+
+	// not configured
+	tlsConf, err := newTLSConfig(&tlsConfig{}, true)
+	assert.NoError(t, err)
+	assert.Nil(t, tlsConf)
+
+	conf := tlsConfig{CertFile: certFile, KeyFile: keyFile}
+
+	tlsConf, err = newTLSConfig(&conf, false)
+	assert.NoError(t, err)
+	assert.Len(t, tlsConf.Certificates, 1)
+	assert.Equal(t, tls.NoClientCert, tlsConf.ClientAuth)
+
+	// client CA required for admin endpoints
+	_, err = newTLSConfig(&conf, true)
+	assert.Error(t, err)
+
+	conf.ClientCAFile = certFile
+	tlsConf, err = newTLSConfig(&conf, true)
+	assert.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, tlsConf.ClientAuth)
+	assert.NotNil(t, tlsConf.ClientCAs)
+
+	conf.Disabled = true
+	tlsConf, err = newTLSConfig(&conf, true)
+	assert.NoError(t, err)
+	assert.Nil(t, tlsConf)
+}
+
+func TestTLSConfigMerge(t *testing.T) {
+	conf := tlsConfig{CertFile: "cert.pem", KeyFile: "key.pem"}
+	conf.AutoCert.CacheDir = "autocert"
+
+	conf.AutoCert.HttpEndpoint = ":80"
+
+	conf.merge(&tlsConfig{AutoCert: autoCertConfig{Domains: []string{"rpc.example.com"}, HttpEndpoint: ":8080"}})
+	assert.Empty(t, conf.CertFile)
+	assert.Equal(t, []string{"rpc.example.com"}, conf.AutoCert.Domains)
+	assert.Equal(t, "autocert", conf.AutoCert.CacheDir)
+	assert.Equal(t, ":8080", conf.AutoCert.HttpEndpoint)
+	assert.True(t, conf.enabled())
+
+	conf.merge(&tlsConfig{Disabled: true})
+	assert.False(t, conf.enabled())
+}
+
+func TestNewAdminClientTLSConfig(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t, t.TempDir())
+
+	// no client certificate configured
+	var conf adminTlsConfig
+	tlsConf, err := newAdminClientTLSConfig(&conf)
+	assert.NoError(t, err)
+	assert.Nil(t, tlsConf)
+
+	conf.Client.CertFile, conf.Client.KeyFile = certFile, keyFile
+	tlsConf, err = newAdminClientTLSConfig(&conf)
+	assert.NoError(t, err)
+	assert.Len(t, tlsConf.Certificates, 1)
+	assert.Nil(t, tlsConf.RootCAs)
+
+	conf.Client.RootCAFile = certFile
+	tlsConf, err = newAdminClientTLSConfig(&conf)
+	assert.NoError(t, err)
+	assert.NotNil(t, tlsConf.RootCAs)
+}
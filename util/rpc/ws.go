@@ -0,0 +1,477 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// WebSocket close codes, refer to RFC 6455 section 7.4.1 for more details.
+const (
+	wsCloseNormal          = 1000
+	wsCloseGoingAway       = 1001
+	wsCloseInvalidPayload  = 1007
+	wsClosePolicyViolation = 1008
+	wsCloseMessageTooBig   = 1009
+)
+
+// WebSocket connection close reasons.
+const (
+	wsCloseReasonPeer         = "peer" // closed by client or upon connection error
+	wsCloseReasonKeepalive    = "keepalive_timeout"
+	wsCloseReasonIdle         = "idle"
+	wsCloseReasonTooBig       = "message_too_big"
+	wsCloseReasonInvalid      = "invalid_payload"
+	wsCloseReasonSlowConsumer = "slow_consumer"
+	wsCloseReasonShutdown     = "shutdown"
+)
+
+// Policies to handle slow consumer.
+const (
+	// close the connection once any write blocked longer than the write timeout
+	wsSlowConsumerDrop = "drop"
+	// buffer the outbound data and close the connection once buffer overflows
+	wsSlowConsumerBuffer = "buffer"
+)
+
+var (
+	errWsMessageTooBig      = errors.New("websocket message too big")
+	errWsSlowConsumer       = errors.New("websocket slow consumer")
+	errWsMaxSubscriptions   = errors.New("too many subscriptions on the connection")
+	errWsConnectionClosed   = errors.New("websocket connection closed")
+	errWsHijackNotSupported = errors.New("hijack not supported")
+)
+
+type wsCtxKey struct{}
+
+// wsConfig represents the configuration of WebSocket connection lifecycle.
+type wsConfig struct {
+	// max number of subscriptions per connection, 0 means unlimited
+	MaxSubscriptions int32 `default:"100"`
+	// max size in bytes of inbound message, 0 means unlimited
+	MaxMessageSize uint64 `default:"5242880"`
+	// close connection without any subscription if no message received for a while, 0 means never
+	IdleTimeout  time.Duration `default:"5m"`
+	SlowConsumer struct {
+		Policy       string        `default:"drop"` // `drop` or `buffer`
+		WriteTimeout time.Duration `default:"10s"`  // max duration for a write to be blocked
+		BufferSize   int64         `default:"4194304"`
+	}
+}
+
+func mustNewWsConfigFromViper() *wsConfig {
+	var conf wsConfig
+	viper.MustUnmarshalKey("rpc.ws", &conf)
+
+	return &conf
+}
+
+// wsHandler tracks the lifecycle of WebSocket connections hijacked by the next handler.
+type wsHandler struct {
+	name        string
+	conf        *wsConfig
+	compression *compressionConfig
+	next        http.Handler
+	conns       sync.Map // *wsConn => struct{}
+}
+
+func newWsHandler(name string, conf *wsConfig, compression *compressionConfig, next http.Handler) *wsHandler {
+	return &wsHandler{name: name, conf: conf, compression: compression, next: next}
+}
+
+func (h *wsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn := &wsConn{
+		h:              h,
+		scanner:        wsFrameScanner{limit: h.conf.MaxMessageSize},
+		deflateOffered: h.compression.Enabled && wsDeflateOffered(r.Header),
+		closed:         make(chan struct{}),
+	}
+
+	ctx := context.WithValue(r.Context(), wsCtxKey{}, conn)
+	h.next.ServeHTTP(&wsResponseWriter{ResponseWriter: w, conn: conn}, r.WithContext(ctx))
+}
+
+// closeAll closes all tracked connections gracefully, eg., upon server shutdown.
+func (h *wsHandler) closeAll(code int, reason string) {
+	h.conns.Range(func(key, value any) bool {
+		key.(*wsConn).closeWith(code, reason)
+		return true
+	})
+}
+
+// wsResponseWriter intercepts hijacking to wrap the underlying connection.
+type wsResponseWriter struct {
+	http.ResponseWriter
+	conn *wsConn
+}
+
+func (w *wsResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errWsHijackNotSupported
+	}
+
+	nc, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w.conn.attach(nc)
+	return w.conn, brw, nil
+}
+
+// wsConn wraps the hijacked connection to enforce limits on WebSocket frames.
+type wsConn struct {
+	net.Conn
+
+	h       *wsHandler
+	scanner wsFrameScanner
+	subs    atomic.Int32 // number of active subscriptions
+
+	idleTimer *time.Timer
+
+	// permessage-deflate extension, which is accepted upon handshake if offered by client
+	deflateOffered bool
+	deflater       *wsDeflater
+	inflater       *wsInflater
+	inflated       []byte // decompressed inbound data not read yet
+
+	writeMu  sync.Mutex
+	outCh    chan []byte  // outbound data queue for buffer policy
+	buffered atomic.Int64 // outbound data size buffered
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func (c *wsConn) attach(nc net.Conn) {
+	c.Conn = nc
+	c.h.conns.Store(c, struct{}{})
+	metrics.Registry.PubSub.WsConnections(c.h.name).Inc(1)
+
+	if c.h.conf.IdleTimeout > 0 {
+		c.idleTimer = time.AfterFunc(c.h.conf.IdleTimeout, c.onIdle)
+	}
+
+	if c.h.conf.SlowConsumer.Policy == wsSlowConsumerBuffer {
+		c.outCh = make(chan []byte, 1024)
+		go c.writeLoop()
+	}
+}
+
+func (c *wsConn) onIdle() {
+	if c.subs.Load() > 0 { // keep alive for subscriptions
+		c.idleTimer.Reset(c.h.conf.IdleTimeout)
+		return
+	}
+
+	c.closeWith(wsCloseNormal, wsCloseReasonIdle)
+}
+
+func (c *wsConn) Read(p []byte) (int, error) {
+	if c.inflater == nil {
+		return c.read(p)
+	}
+
+	for len(c.inflated) == 0 {
+		n, err := c.read(p)
+
+		data, inflateErr := c.inflater.inflate(p[:n])
+		if errors.Is(inflateErr, errWsMessageTooBig) {
+			c.closeWith(wsCloseMessageTooBig, wsCloseReasonTooBig)
+			return 0, inflateErr
+		} else if inflateErr != nil {
+			c.closeWith(wsCloseInvalidPayload, wsCloseReasonInvalid)
+			return 0, inflateErr
+		}
+
+		// error will be returned again on next read
+		if c.inflated = data; len(data) == 0 && err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, c.inflated)
+	c.inflated = c.inflated[n:]
+
+	return n, nil
+}
+
+func (c *wsConn) read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+
+	frames, scanErr := c.scanner.scan(p[:n])
+	if frames > 0 && c.idleTimer != nil {
+		c.idleTimer.Reset(c.h.conf.IdleTimeout)
+	}
+
+	if scanErr != nil {
+		c.closeWith(wsCloseMessageTooBig, wsCloseReasonTooBig)
+		return 0, scanErr
+	}
+
+	// read deadline is only set by keepalive to wait for pong
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		c.closeWith(0, wsCloseReasonKeepalive)
+	}
+
+	return n, err
+}
+
+func (c *wsConn) Write(p []byte) (int, error) {
+	if c.deflater == nil && !c.deflateOffered {
+		return c.write(p)
+	}
+
+	data := p
+	if c.deflater != nil {
+		data = c.deflater.deflate(p)
+	} else { // handshake response
+		c.deflateOffered = false
+
+		var accepted bool
+		if data, accepted = wsAcceptDeflate(p); accepted {
+			c.deflater = &wsDeflater{server: c.h.name, minSize: c.h.compression.MinSize}
+			c.inflater = &wsInflater{limit: c.h.conf.MaxMessageSize}
+		}
+	}
+
+	if len(data) > 0 {
+		if _, err := c.write(data); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+func (c *wsConn) write(p []byte) (int, error) {
+	if c.outCh == nil {
+		return c.writeDirect(p)
+	}
+
+	select {
+	case <-c.closed:
+		return 0, errWsConnectionClosed
+	default:
+	}
+
+	if c.buffered.Add(int64(len(p))) > c.h.conf.SlowConsumer.BufferSize {
+		c.closeWith(wsClosePolicyViolation, wsCloseReasonSlowConsumer)
+		return 0, errWsSlowConsumer
+	}
+
+	data := make([]byte, len(p))
+	copy(data, p)
+
+	select {
+	case c.outCh <- data:
+		return len(p), nil
+	case <-c.closed:
+		return 0, errWsConnectionClosed
+	}
+}
+
+// writeDirect writes data to the underlying connection, and regards the peer as slow consumer
+// if write timeout.
+func (c *wsConn) writeDirect(p []byte) (int, error) {
+	c.writeMu.Lock()
+	c.Conn.SetWriteDeadline(time.Now().Add(c.h.conf.SlowConsumer.WriteTimeout))
+	n, err := c.Conn.Write(p)
+	c.writeMu.Unlock()
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		c.closeWith(wsClosePolicyViolation, wsCloseReasonSlowConsumer)
+		return n, errWsSlowConsumer
+	}
+
+	return n, err
+}
+
+// writeLoop writes the buffered outbound data in sequence for buffer policy.
+func (c *wsConn) writeLoop() {
+	for {
+		select {
+		case data := <-c.outCh:
+			c.writeMu.Lock()
+			c.Conn.SetWriteDeadline(time.Time{})
+			_, err := c.Conn.Write(data)
+			c.writeMu.Unlock()
+
+			c.buffered.Add(-int64(len(data)))
+
+			if err != nil {
+				c.Close()
+				return
+			}
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+// SetWriteDeadline is overridden since write deadline is managed by the slow consumer policy.
+func (c *wsConn) SetWriteDeadline(t time.Time) error { return nil }
+
+func (c *wsConn) SetDeadline(t time.Time) error { return c.Conn.SetReadDeadline(t) }
+
+// Close closes the connection by peer, eg., client closed or connection error.
+func (c *wsConn) Close() error {
+	c.closeWith(0, wsCloseReasonPeer)
+	return nil
+}
+
+// closeWith closes the connection with the specified close code, which is sent to peer as
+// close frame if not zero.
+func (c *wsConn) closeWith(code int, reason string) {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+
+		if c.idleTimer != nil {
+			c.idleTimer.Stop()
+		}
+
+		// send close frame if not blocked by any pending write, eg., slow consumer
+		if code != 0 && c.writeMu.TryLock() {
+			c.Conn.SetWriteDeadline(time.Now().Add(time.Second))
+			c.Conn.Write(wsCloseFrame(code, reason))
+			c.writeMu.Unlock()
+		}
+
+		c.Conn.Close()
+
+		c.h.conns.Delete(c)
+		metrics.Registry.PubSub.WsConnections(c.h.name).Dec(1)
+		metrics.Registry.PubSub.WsClosed(c.h.name, reason).Mark(1)
+
+		logrus.WithFields(logrus.Fields{
+			"server": c.h.name,
+			"remote": c.Conn.RemoteAddr(),
+			"code":   code,
+			"reason": reason,
+		}).Debug("WebSocket connection closed")
+	})
+}
+
+// wsCloseFrame builds an unmasked close frame from server.
+func wsCloseFrame(code int, reason string) []byte {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+
+	return append([]byte{0x88, byte(len(payload))}, payload...)
+}
+
+// AcquireWsSubscription acquires a subscription slot of the WebSocket connection within context,
+// and returns the function to release it once the subscription terminated.
+func AcquireWsSubscription(ctx context.Context) (release func(), err error) {
+	conn, ok := ctx.Value(wsCtxKey{}).(*wsConn)
+	if !ok {
+		return func() {}, nil
+	}
+
+	maxSubs := conn.h.conf.MaxSubscriptions
+	if n := conn.subs.Add(1); maxSubs > 0 && n > maxSubs {
+		conn.subs.Add(-1)
+		return nil, errWsMaxSubscriptions
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { conn.subs.Add(-1) })
+	}, nil
+}
+
+// wsFrameScanner scans inbound WebSocket frame headers to measure message size without
+// decoding the payloads.
+type wsFrameScanner struct {
+	limit     uint64 // max message size, 0 means unlimited
+	header    []byte // partial frame header
+	remaining uint64 // remaining payload size of current frame
+	msgSize   uint64 // accumulated payload size of current message
+}
+
+// scan scans the inbound data, and returns the number of data frames scanned or error
+// if message size exceeds the limit.
+func (s *wsFrameScanner) scan(data []byte) (frames int, err error) {
+	for len(data) > 0 {
+		if s.remaining > 0 {
+			n := min(s.remaining, uint64(len(data)))
+			s.remaining -= n
+			data = data[n:]
+			continue
+		}
+
+		s.header = append(s.header, data[0])
+		data = data[1:]
+
+		size, ok := wsFrameHeaderSize(s.header)
+		if !ok || len(s.header) < size {
+			continue
+		}
+
+		opcode := s.header[0] & 0x0f
+		s.remaining = wsFramePayloadSize(s.header)
+		s.header = s.header[:0]
+
+		if opcode >= 0x8 { // control frame
+			continue
+		}
+
+		if opcode != 0 { // not continuation frame
+			s.msgSize = 0
+		}
+
+		frames++
+		s.msgSize += s.remaining
+
+		if s.limit > 0 && s.msgSize > s.limit {
+			return frames, errWsMessageTooBig
+		}
+	}
+
+	return frames, nil
+}
+
+// wsFrameHeaderSize returns the frame header size if the partial header is long enough to tell.
+func wsFrameHeaderSize(header []byte) (int, bool) {
+	if len(header) < 2 {
+		return 0, false
+	}
+
+	size := 2
+	switch header[1] & 0x7f {
+	case 126:
+		size += 2
+	case 127:
+		size += 8
+	}
+
+	if header[1]&0x80 != 0 { // masked
+		size += 4
+	}
+
+	return size, true
+}
+
+func wsFramePayloadSize(header []byte) uint64 {
+	switch n := header[1] & 0x7f; n {
+	case 126:
+		return uint64(binary.BigEndian.Uint16(header[2:4]))
+	case 127:
+		return binary.BigEndian.Uint64(header[2:10])
+	default:
+		return uint64(n)
+	}
+}
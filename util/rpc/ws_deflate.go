@@ -0,0 +1,338 @@
+package rpc
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/pkg/errors"
+)
+
+// The WebSocket library of RPC server doesn't support compression, so the `permessage-deflate`
+// extension (RFC 7692) is implemented upon the hijacked connection: the extension is accepted by
+// rewriting the handshake response, outbound messages are compressed, and inbound compressed
+// messages are decompressed before handed over to the WebSocket library.
+//
+// To save memory, no context takeover is negotiated for both directions so that each message is
+// compressed independently.
+
+const (
+	wsExtensionDeflate = "permessage-deflate"
+
+	wsDeflateResponseHeader = "Sec-WebSocket-Extensions: permessage-deflate; " +
+		"server_no_context_takeover; client_no_context_takeover\r\n"
+)
+
+var (
+	// tail appended to compressed message before decompression, which is the empty stored block
+	// stripped by sender plus a final empty stored block to terminate the stream.
+	wsDeflateTail = []byte("\x00\x00\xff\xff\x01\x00\x00\xff\xff")
+
+	errWsInvalidPayload = errors.New("invalid compressed websocket message")
+
+	flateWriterPool = sync.Pool{
+		New: func() interface{} {
+			w, _ := flate.NewWriter(io.Discard, flate.BestSpeed)
+			return w
+		},
+	}
+
+	flateReaderPool = sync.Pool{
+		New: func() interface{} {
+			return flate.NewReader(bytes.NewReader(nil))
+		},
+	}
+)
+
+// wsDeflateOffered checks if `permessage-deflate` extension offered by client could be accepted.
+// Note, offers restricting window bits of server are declined, since the window size of the
+// standard flate library is not configurable.
+func wsDeflateOffered(header http.Header) bool {
+	for _, v := range header.Values("Sec-WebSocket-Extensions") {
+		for _, offer := range strings.Split(v, ",") {
+			params := strings.Split(offer, ";")
+			if strings.TrimSpace(params[0]) != wsExtensionDeflate {
+				continue
+			}
+
+			acceptable := true
+			for _, param := range params[1:] {
+				name, _, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if name == "server_max_window_bits" {
+					acceptable = false
+				}
+			}
+
+			if acceptable {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// wsAcceptDeflate adds the `permessage-deflate` extension into the handshake response, or returns
+// false if not a successful handshake response.
+func wsAcceptDeflate(resp []byte) ([]byte, bool) {
+	end := bytes.Index(resp, []byte("\r\n\r\n"))
+	if end < 0 || !bytes.HasPrefix(resp, []byte("HTTP/1.1 101")) {
+		return resp, false
+	}
+
+	data := make([]byte, 0, len(resp)+len(wsDeflateResponseHeader))
+	data = append(data, resp[:end+2]...)
+	data = append(data, wsDeflateResponseHeader...)
+	data = append(data, resp[end+2:]...)
+
+	return data, true
+}
+
+// wsFrame is a decoded WebSocket frame.
+type wsFrame struct {
+	fin     bool
+	rsv1    bool // set on the first frame of compressed message
+	opcode  byte
+	mask    []byte // masking key, nil if unmasked
+	payload []byte // unmasked payload
+}
+
+func (f *wsFrame) isControl() bool { return f.opcode >= 0x8 }
+
+// encode appends the encoded frame to dst, and the payload is masked if masking key specified.
+func (f *wsFrame) encode(dst []byte) []byte {
+	b0 := f.opcode
+	if f.fin {
+		b0 |= 0x80
+	}
+
+	if f.rsv1 {
+		b0 |= 0x40
+	}
+
+	var maskBit byte
+	if f.mask != nil {
+		maskBit = 0x80
+	}
+
+	dst = append(dst, b0)
+
+	switch n := len(f.payload); {
+	case n < 126:
+		dst = append(dst, maskBit|byte(n))
+	case n <= 0xffff:
+		dst = append(dst, maskBit|126)
+		dst = binary.BigEndian.AppendUint16(dst, uint16(n))
+	default:
+		dst = append(dst, maskBit|127)
+		dst = binary.BigEndian.AppendUint64(dst, uint64(n))
+	}
+
+	if f.mask == nil {
+		return append(dst, f.payload...)
+	}
+
+	dst = append(dst, f.mask...)
+	start := len(dst)
+	dst = append(dst, f.payload...)
+	wsMaskBytes(f.mask, dst[start:])
+
+	return dst
+}
+
+func wsMaskBytes(key, data []byte) {
+	for i := range data {
+		data[i] ^= key[i%4]
+	}
+}
+
+// wsFrameParser decodes complete WebSocket frames from stream data.
+type wsFrameParser struct {
+	buf []byte // partial frame
+}
+
+func (p *wsFrameParser) parse(data []byte) (frames []wsFrame) {
+	p.buf = append(p.buf, data...)
+
+	offset := 0
+	for {
+		buf := p.buf[offset:]
+
+		headerSize, ok := wsFrameHeaderSize(buf)
+		if !ok || len(buf) < headerSize {
+			break
+		}
+
+		payloadSize := wsFramePayloadSize(buf)
+		if uint64(len(buf)-headerSize) < payloadSize {
+			break
+		}
+
+		end := headerSize + int(payloadSize)
+		frame := wsFrame{
+			fin:     buf[0]&0x80 != 0,
+			rsv1:    buf[0]&0x40 != 0,
+			opcode:  buf[0] & 0x0f,
+			payload: append([]byte(nil), buf[headerSize:end]...),
+		}
+
+		if buf[1]&0x80 != 0 { // masked
+			frame.mask = append([]byte(nil), buf[headerSize-4:headerSize]...)
+			wsMaskBytes(frame.mask, frame.payload)
+		}
+
+		frames = append(frames, frame)
+		offset += end
+	}
+
+	// move the partial frame ahead
+	p.buf = append(p.buf[:0], p.buf[offset:]...)
+
+	return frames
+}
+
+// wsDeflater compresses the outbound messages of server, which are unmasked.
+type wsDeflater struct {
+	server  string
+	minSize int
+	parser  wsFrameParser
+
+	opcode  byte   // opcode of current message
+	payload []byte // accumulated payload of current message
+}
+
+// deflate decodes the outbound data, and returns the encoded frames of complete messages, which
+// are compressed if large enough. Note, control frames are passed through as they are.
+func (d *wsDeflater) deflate(data []byte) (out []byte) {
+	for _, frame := range d.parser.parse(data) {
+		if frame.isControl() {
+			out = frame.encode(out)
+			continue
+		}
+
+		if frame.opcode != 0 { // not continuation frame
+			d.opcode, d.payload = frame.opcode, nil
+		}
+
+		d.payload = append(d.payload, frame.payload...)
+		if !frame.fin {
+			continue
+		}
+
+		msg := wsFrame{fin: true, opcode: d.opcode, payload: d.payload}
+		if len(d.payload) >= d.minSize {
+			if compressed, ok := wsDeflateMessage(d.payload); ok {
+				msg.rsv1, msg.payload = true, compressed
+
+				metrics.Registry.RPC.CompressionSavedBytes(d.server, wsExtensionDeflate).Mark(int64(len(d.payload) - len(compressed)))
+				metrics.Registry.RPC.CompressionRatio(d.server, wsExtensionDeflate).Update(int64(len(compressed) * 100 / len(d.payload)))
+			}
+		}
+
+		out = msg.encode(out)
+		d.payload = nil
+	}
+
+	return out
+}
+
+// wsDeflateMessage compresses the message payload, and returns false if failed or not smaller.
+func wsDeflateMessage(payload []byte) ([]byte, bool) {
+	var buf bytes.Buffer
+
+	fw := flateWriterPool.Get().(*flate.Writer)
+	defer flateWriterPool.Put(fw)
+
+	fw.Reset(&buf)
+
+	if _, err := fw.Write(payload); err != nil {
+		return nil, false
+	}
+
+	if err := fw.Flush(); err != nil {
+		return nil, false
+	}
+
+	// strip the empty stored block of flush
+	compressed := bytes.TrimSuffix(buf.Bytes(), wsDeflateTail[:4])
+
+	return compressed, len(compressed) < len(payload)
+}
+
+// wsInflater decompresses the inbound messages of client, which are masked.
+type wsInflater struct {
+	limit  uint64 // max size of decompressed message, 0 means unlimited
+	parser wsFrameParser
+
+	compressed bool   // whether current message compressed
+	opcode     byte   // opcode of current message
+	mask       []byte // masking key of the first frame of current message
+	payload    []byte // accumulated payload of current compressed message
+}
+
+// inflate decodes the inbound data, and returns the encoded frames with compressed messages
+// decompressed. Note, uncompressed frames are passed through as they are.
+func (i *wsInflater) inflate(data []byte) (out []byte, err error) {
+	for _, frame := range i.parser.parse(data) {
+		if frame.isControl() {
+			out = frame.encode(out)
+			continue
+		}
+
+		if frame.opcode != 0 { // not continuation frame
+			i.compressed, i.opcode, i.mask, i.payload = frame.rsv1, frame.opcode, frame.mask, nil
+		}
+
+		if !i.compressed {
+			out = frame.encode(out)
+			continue
+		}
+
+		i.payload = append(i.payload, frame.payload...)
+		if !frame.fin {
+			continue
+		}
+
+		msg, err := wsInflateMessage(i.payload, i.limit)
+		if err != nil {
+			return out, err
+		}
+
+		out = (&wsFrame{fin: true, opcode: i.opcode, mask: i.mask, payload: msg}).encode(out)
+		i.payload = nil
+	}
+
+	return out, nil
+}
+
+// wsInflateMessage decompresses the message payload within the size limit.
+func wsInflateMessage(payload []byte, limit uint64) ([]byte, error) {
+	fr := flateReaderPool.Get().(io.ReadCloser)
+	defer flateReaderPool.Put(fr)
+
+	src := io.MultiReader(bytes.NewReader(payload), bytes.NewReader(wsDeflateTail))
+	if err := fr.(flate.Resetter).Reset(src, nil); err != nil {
+		return nil, errWsInvalidPayload
+	}
+
+	reader := io.Reader(fr)
+	if limit > 0 {
+		reader = io.LimitReader(fr, int64(limit)+1)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errWsInvalidPayload
+	}
+
+	if limit > 0 && uint64(len(data)) > limit {
+		return nil, errWsMessageTooBig
+	}
+
+	return data, nil
+}
@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWsDeflateOffered(t *testing.T) {
+	header := http.Header{}
+	assert.False(t, wsDeflateOffered(header))
+
+	header.Set("Sec-WebSocket-Extensions", "permessage-deflate; client_max_window_bits")
+	assert.True(t, wsDeflateOffered(header))
+
+	header.Set("Sec-WebSocket-Extensions", "permessage-deflate; server_max_window_bits=10")
+	assert.False(t, wsDeflateOffered(header))
+
+	header.Set("Sec-WebSocket-Extensions", "permessage-deflate; server_max_window_bits=10, permessage-deflate")
+	assert.True(t, wsDeflateOffered(header))
+}
+
+func TestWsAcceptDeflate(t *testing.T) {
+	resp := []byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\n\r\n")
+
+	data, ok := wsAcceptDeflate(resp)
+	assert.True(t, ok)
+	assert.Equal(t, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\n"+wsDeflateResponseHeader+"\r\n", string(data))
+
+	_, ok = wsAcceptDeflate([]byte("HTTP/1.1 400 Bad Request\r\n\r\n"))
+	assert.False(t, ok)
+}
+
+func TestWsDeflateRoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte(`{"jsonrpc":"2.0","result":"0x0"}`), 100)
+
+	// fragmented outbound message with control frame interleaved
+	var data []byte
+	data = (&wsFrame{opcode: 0x1, payload: payload[:1000]}).encode(data)
+	data = (&wsFrame{fin: true, opcode: 0x9, payload: []byte("ping")}).encode(data)
+	data = (&wsFrame{fin: true, opcode: 0x0, payload: payload[1000:]}).encode(data)
+
+	// small message not compressed
+	data = (&wsFrame{fin: true, opcode: 0x1, payload: []byte("small")}).encode(data)
+
+	deflater := wsDeflater{server: "test", minSize: 100}
+
+	var out []byte
+	for i := 0; i < len(data); i += 7 { // split at arbitrary boundaries
+		out = append(out, deflater.deflate(data[i:min(i+7, len(data))])...)
+	}
+
+	var parser wsFrameParser
+	frames := parser.parse(out)
+	assert.Equal(t, 3, len(frames))
+	assert.Equal(t, byte(0x9), frames[0].opcode)
+	assert.True(t, frames[1].rsv1)
+	assert.Less(t, len(frames[1].payload), len(payload))
+	assert.False(t, frames[2].rsv1)
+	assert.Equal(t, "small", string(frames[2].payload))
+
+	// compressed message sent by client in masked frames
+	mask := []byte{1, 2, 3, 4}
+	compressed := frames[1].payload
+
+	data = (&wsFrame{rsv1: true, opcode: 0x1, mask: mask, payload: compressed[:10]}).encode(nil)
+	data = (&wsFrame{fin: true, opcode: 0x0, mask: mask, payload: compressed[10:]}).encode(data)
+
+	inflater := wsInflater{limit: uint64(len(payload))}
+	out, err := inflater.inflate(data)
+	assert.NoError(t, err)
+
+	frames = parser.parse(out)
+	assert.Equal(t, 1, len(frames))
+	assert.False(t, frames[0].rsv1)
+	assert.Equal(t, mask, frames[0].mask)
+	assert.Equal(t, payload, frames[0].payload)
+
+	// decompressed message too big
+	inflater = wsInflater{limit: uint64(len(payload) - 1)}
+	_, err = inflater.inflate(data)
+	assert.ErrorIs(t, err, errWsMessageTooBig)
+}
@@ -0,0 +1,71 @@
+package rpc
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// maskedFrame builds a masked client frame header with the specified payload size.
+func maskedFrame(opcode byte, fin bool, size int) []byte {
+	b0 := opcode
+	if fin {
+		b0 |= 0x80
+	}
+
+	var header []byte
+	switch {
+	case size < 126:
+		header = []byte{b0, 0x80 | byte(size)}
+	case size <= 0xffff:
+		header = binary.BigEndian.AppendUint16([]byte{b0, 0x80 | 126}, uint16(size))
+	default:
+		header = binary.BigEndian.AppendUint64([]byte{b0, 0x80 | 127}, uint64(size))
+	}
+
+	header = append(header, 1, 2, 3, 4) // masking key
+	return append(header, make([]byte, size)...)
+}
+
+func TestWsFrameScannerMessageSize(t *testing.T) {
+	s := wsFrameScanner{limit: 1000}
+
+	// split data frames and control frames at arbitrary boundaries
+	var data []byte
+	data = append(data, maskedFrame(0x1, false, 300)...)
+	data = append(data, maskedFrame(0x9, true, 10)...)
+	data = append(data, maskedFrame(0x0, true, 500)...)
+
+	var frames int
+	for len(data) > 0 {
+		n := min(7, len(data))
+		scanned, err := s.scan(data[:n])
+		assert.NoError(t, err)
+
+		frames += scanned
+		data = data[n:]
+	}
+
+	assert.Equal(t, 2, frames)
+	assert.Equal(t, uint64(800), s.msgSize)
+
+	// continuation frame exceeds the limit
+	frames, err := s.scan(maskedFrame(0x0, true, 201)[:8])
+	assert.Equal(t, 1, frames)
+	assert.ErrorIs(t, err, errWsMessageTooBig)
+
+	// new message resets the size
+	s = wsFrameScanner{limit: 1000}
+	_, err = s.scan(maskedFrame(0x1, true, 70000))
+	assert.ErrorIs(t, err, errWsMessageTooBig)
+}
+
+func TestWsCloseFrame(t *testing.T) {
+	frame := wsCloseFrame(wsCloseGoingAway, wsCloseReasonShutdown)
+
+	assert.Equal(t, byte(0x88), frame[0])
+	assert.Equal(t, byte(2+len(wsCloseReasonShutdown)), frame[1])
+	assert.Equal(t, uint16(wsCloseGoingAway), binary.BigEndian.Uint16(frame[2:4]))
+	assert.Equal(t, wsCloseReasonShutdown, string(frame[4:]))
+}
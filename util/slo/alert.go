@@ -0,0 +1,138 @@
+package slo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	alertFormatWebhook   = "webhook"
+	alertFormatPagerDuty = "pagerduty"
+)
+
+// AlertStatus is the status of SLO alert.
+type AlertStatus string
+
+const (
+	AlertStatusFiring   AlertStatus = "firing"
+	AlertStatusResolved AlertStatus = "resolved"
+)
+
+// Alert is fired when error budget burn rates exceed the threshold, or resolved once recovered.
+type Alert struct {
+	Status    AlertStatus   `json:"status"`
+	Method    string        `json:"method"`
+	Kind      string        `json:"kind"` // `availability` or `latency`
+	Objective float64       `json:"objective"`
+	Window    time.Duration `json:"window"`
+	// burn rate threshold of the window
+	Threshold float64 `json:"threshold"`
+	// burn rates over the long and short windows
+	LongBurnRate  float64   `json:"longBurnRate"`
+	ShortBurnRate float64   `json:"shortBurnRate"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// Key returns the unique key of alert for deduplication.
+func (a *Alert) Key() string {
+	return fmt.Sprintf("confura/slo/%v/%v/%v", a.Method, a.Kind, a.Window)
+}
+
+// Summary returns the human readable summary of alert.
+func (a *Alert) Summary() string {
+	return fmt.Sprintf(
+		"[%v] SLO %v of %v (objective %v) burning at %.2fx over %v (threshold %.2fx)",
+		a.Status, a.Kind, a.Method, a.Objective, a.LongBurnRate, a.Window, a.Threshold,
+	)
+}
+
+// AlertHook is the callback to notify SLO alerts.
+type AlertHook func(ctx context.Context, alert *Alert) error
+
+// newHttpAlertHook creates an alert hook to POST alerts in the configured format.
+func newHttpAlertHook(conf AlertConfig) AlertHook {
+	client := &http.Client{Timeout: conf.Timeout}
+
+	return func(ctx context.Context, alert *Alert) error {
+		var payload interface{} = alert
+		if conf.Format == alertFormatPagerDuty {
+			payload = newPagerDutyEvent(conf.RoutingKey, alert)
+		}
+
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return errors.WithMessage(err, "failed to marshal alert payload")
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, conf.Url, bytes.NewReader(data))
+		if err != nil {
+			return errors.WithMessage(err, "failed to create HTTP request")
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return errors.WithMessage(err, "failed to send HTTP request")
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return errors.Errorf("unexpected HTTP status %v", resp.Status)
+		}
+
+		return nil
+	}
+}
+
+// pagerDutyEvent is the event payload of PagerDuty Events API v2.
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"` // `trigger` or `resolve`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     *pagerDutyDetail `json:"payload,omitempty"`
+}
+
+type pagerDutyDetail struct {
+	Summary       string    `json:"summary"`
+	Source        string    `json:"source"`
+	Severity      string    `json:"severity"`
+	Timestamp     time.Time `json:"timestamp"`
+	Component     string    `json:"component"`
+	CustomDetails *Alert    `json:"custom_details"`
+}
+
+func newPagerDutyEvent(routingKey string, alert *Alert) *pagerDutyEvent {
+	event := &pagerDutyEvent{
+		RoutingKey:  routingKey,
+		EventAction: "trigger",
+		DedupKey:    alert.Key(),
+	}
+
+	if alert.Status == AlertStatusResolved {
+		event.EventAction = "resolve"
+		return event
+	}
+
+	source, _ := os.Hostname()
+	if len(source) == 0 {
+		source = "confura"
+	}
+
+	event.Payload = &pagerDutyDetail{
+		Summary:       alert.Summary(),
+		Source:        source,
+		Severity:      "critical",
+		Timestamp:     alert.Timestamp,
+		Component:     alert.Method,
+		CustomDetails: alert,
+	}
+
+	return event
+}
@@ -0,0 +1,129 @@
+package slo
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AllMethods matches all RPC methods for objective, in which case requests of all methods are
+// aggregated to evaluate the objective.
+const AllMethods = "*"
+
+// Objective is the service level objective of some RPC method.
+type Objective struct {
+	// RPC method name or `*` for all methods
+	Method string
+	// target ratio of successful requests, e.g. 0.999
+	Availability float64
+	// latency threshold, above which requests are regarded as slow; zero to disable latency objective
+	Latency time.Duration
+	// target ratio of requests served within the latency threshold, defaults to 0.99
+	LatencyTarget float64
+}
+
+// BurnRateWindow is a multi-window burn rate alert rule, which fires only if the error budget burn
+// rates over both the long and short windows exceed the threshold.
+type BurnRateWindow struct {
+	Long     time.Duration
+	Short    time.Duration
+	BurnRate float64
+}
+
+// AlertConfig is the alert callback to notify when burn rate exceeds threshold or recovers.
+type AlertConfig struct {
+	// URL to POST alert payload
+	Url string
+	// payload format, `webhook` (default) or `pagerduty` (Events API v2)
+	Format string
+	// integration routing key of PagerDuty service
+	RoutingKey string
+	// HTTP request timeout, defaults to 5 seconds
+	Timeout time.Duration
+}
+
+type config struct {
+	Enabled bool
+	// interval to evaluate burn rates of all objectives
+	Interval time.Duration `default:"1m"`
+	// burn rate alert rules, defaults to the multi-window rules recommended by Google SRE workbook
+	Windows    []BurnRateWindow
+	Objectives []Objective
+	Alerts     []AlertConfig
+}
+
+var defaultBurnRateWindows = []BurnRateWindow{
+	{Long: time.Hour, Short: 5 * time.Minute, BurnRate: 14.4},
+	{Long: 6 * time.Hour, Short: 30 * time.Minute, BurnRate: 6},
+}
+
+// normalize fills the default values, which could not be specified by struct tags for slice elements.
+func (conf *config) normalize() {
+	if len(conf.Windows) == 0 {
+		conf.Windows = defaultBurnRateWindows
+	}
+
+	for i := range conf.Objectives {
+		if conf.Objectives[i].LatencyTarget == 0 {
+			conf.Objectives[i].LatencyTarget = 0.99
+		}
+	}
+
+	for i := range conf.Alerts {
+		if len(conf.Alerts[i].Format) == 0 {
+			conf.Alerts[i].Format = alertFormatWebhook
+		}
+
+		if conf.Alerts[i].Timeout == 0 {
+			conf.Alerts[i].Timeout = 5 * time.Second
+		}
+	}
+}
+
+func (conf *config) validate() error {
+	if conf.Interval <= 0 {
+		return errors.New("evaluation interval must be positive")
+	}
+
+	for _, w := range conf.Windows {
+		if w.Short <= 0 || w.Long < w.Short {
+			return errors.Errorf("invalid burn rate window (long = %v, short = %v)", w.Long, w.Short)
+		}
+
+		if w.BurnRate <= 0 {
+			return errors.Errorf("burn rate threshold must be positive for window %v", w.Long)
+		}
+	}
+
+	for _, o := range conf.Objectives {
+		if len(o.Method) == 0 {
+			return errors.New("method required for objective")
+		}
+
+		if o.Availability <= 0 || o.Availability >= 1 {
+			return errors.Errorf("availability of method %v should be in range (0, 1)", o.Method)
+		}
+
+		if o.Latency > 0 && (o.LatencyTarget <= 0 || o.LatencyTarget >= 1) {
+			return errors.Errorf("latency target of method %v should be in range (0, 1)", o.Method)
+		}
+	}
+
+	for _, a := range conf.Alerts {
+		if len(a.Url) == 0 {
+			return errors.New("url required for alert")
+		}
+
+		switch a.Format {
+		case alertFormatWebhook:
+		case alertFormatPagerDuty:
+			if len(a.RoutingKey) == 0 {
+				return errors.New("routing key required for PagerDuty alert")
+			}
+		default:
+			return errors.Errorf("unsupported alert format %v", a.Format)
+		}
+	}
+
+	return nil
+}
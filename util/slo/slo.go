@@ -0,0 +1,48 @@
+// Package slo tracks per method success rate and latency of RPC requests against configurable
+// service level objectives over rolling windows, and fires alerts (webhook or PagerDuty) when
+// error budget burn rates exceed thresholds.
+package slo
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/sirupsen/logrus"
+)
+
+var defaultTracker *Tracker
+
+// MustInitFromViper initializes the default SLO tracker if enabled.
+//
+// This package should be initialized after the initialization of viper and logrus.
+func MustInitFromViper() {
+	var conf config
+	viper.MustUnmarshalKey("slo", &conf)
+
+	if !conf.Enabled {
+		return
+	}
+
+	conf.normalize()
+	if err := conf.validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid SLO config")
+	}
+
+	var hooks []AlertHook
+	for _, ac := range conf.Alerts {
+		hooks = append(hooks, newHttpAlertHook(ac))
+	}
+
+	defaultTracker = NewTracker(conf.Windows, conf.Objectives, hooks...)
+	go defaultTracker.Run(context.Background(), conf.Interval)
+
+	logrus.WithField("objectives", len(conf.Objectives)).Info("SLO tracking enabled")
+}
+
+// Observe records the result of an RPC request with the default SLO tracker if enabled.
+func Observe(method string, success bool, latency time.Duration) {
+	if defaultTracker != nil {
+		defaultTracker.Observe(method, success, latency)
+	}
+}
@@ -0,0 +1,227 @@
+package slo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	metricUtil "github.com/Conflux-Chain/go-conflux-util/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// number of slots for each rolling window
+	numWindowSlots = 60
+
+	kindAvailability = "availability"
+	kindLatency      = "latency"
+)
+
+// counts is the requests statistics within a time window slot.
+type counts struct {
+	Total  int64
+	Errors int64
+	Slow   int64
+}
+
+type countsAggregator struct{}
+
+// implements `SlotAggregator` interface
+func (countsAggregator) Add(acc, v counts) counts {
+	return counts{Total: acc.Total + v.Total, Errors: acc.Errors + v.Errors, Slow: acc.Slow + v.Slow}
+}
+
+func (countsAggregator) Sub(acc, v counts) counts {
+	return counts{Total: acc.Total - v.Total, Errors: acc.Errors - v.Errors, Slow: acc.Slow - v.Slow}
+}
+
+// burnRate returns the ratio of actual bad events rate to the error budget.
+func burnRate(bad, total int64, objective float64) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(bad) / float64(total) / (1 - objective)
+}
+
+// objectiveTracker tracks requests of an objective over rolling windows.
+type objectiveTracker struct {
+	Objective
+	windows map[time.Duration]*metricUtil.TimeWindow[counts] // window size => rolling window
+}
+
+func newObjectiveTracker(objective Objective, windows []BurnRateWindow) *objectiveTracker {
+	ot := &objectiveTracker{
+		Objective: objective,
+		windows:   make(map[time.Duration]*metricUtil.TimeWindow[counts]),
+	}
+
+	for _, w := range windows {
+		for _, size := range []time.Duration{w.Long, w.Short} {
+			if _, ok := ot.windows[size]; !ok {
+				ot.windows[size] = metricUtil.NewTimeWindow[counts](size/numWindowSlots, numWindowSlots, countsAggregator{})
+			}
+		}
+	}
+
+	return ot
+}
+
+func (ot *objectiveTracker) observe(success bool, latency time.Duration) {
+	sample := counts{Total: 1}
+
+	if !success {
+		sample.Errors = 1
+	}
+
+	if ot.Latency > 0 && latency > ot.Latency {
+		sample.Slow = 1
+	}
+
+	for _, tw := range ot.windows {
+		tw.Add(sample)
+	}
+}
+
+// burnRates returns the burn rates of both availability and latency objectives over the window.
+func (ot *objectiveTracker) burnRates(window time.Duration) map[string]float64 {
+	data := ot.windows[window].Data()
+
+	rates := map[string]float64{
+		kindAvailability: burnRate(data.Errors, data.Total, ot.Availability),
+	}
+
+	if ot.Latency > 0 {
+		rates[kindLatency] = burnRate(data.Slow, data.Total, ot.LatencyTarget)
+	}
+
+	return rates
+}
+
+// Tracker tracks per method success rate and latency against objectives over rolling windows,
+// and fires alerts when error budget burn rates exceed thresholds.
+type Tracker struct {
+	windows    []BurnRateWindow
+	objectives map[string]*objectiveTracker // method => objective tracker
+	hooks      []AlertHook
+
+	mu     sync.Mutex
+	firing map[string]*Alert // alert key => firing alert
+}
+
+// NewTracker creates a SLO tracker with burn rate alert rules and objectives.
+func NewTracker(windows []BurnRateWindow, objectives []Objective, hooks ...AlertHook) *Tracker {
+	t := &Tracker{
+		windows:    windows,
+		objectives: make(map[string]*objectiveTracker),
+		hooks:      hooks,
+		firing:     make(map[string]*Alert),
+	}
+
+	for _, o := range objectives {
+		t.objectives[o.Method] = newObjectiveTracker(o, windows)
+	}
+
+	return t
+}
+
+// Observe records the result of an RPC request.
+func (t *Tracker) Observe(method string, success bool, latency time.Duration) {
+	if ot, ok := t.objectives[method]; ok {
+		ot.observe(success, latency)
+	}
+
+	if ot, ok := t.objectives[AllMethods]; ok {
+		ot.observe(success, latency)
+	}
+}
+
+// Evaluate evaluates the burn rates of all objectives, and returns alerts of which status changed,
+// i.e. newly firing or resolved. Note, alert hooks are not notified.
+func (t *Tracker) Evaluate() []*Alert {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var alerts []*Alert
+	now := time.Now()
+
+	for _, ot := range t.objectives {
+		for _, w := range t.windows {
+			longRates, shortRates := ot.burnRates(w.Long), ot.burnRates(w.Short)
+
+			for kind, longRate := range longRates {
+				alert := &Alert{
+					Status:        AlertStatusFiring,
+					Method:        ot.Method,
+					Kind:          kind,
+					Objective:     ot.Availability,
+					Window:        w.Long,
+					Threshold:     w.BurnRate,
+					LongBurnRate:  longRate,
+					ShortBurnRate: shortRates[kind],
+					Timestamp:     now,
+				}
+
+				if kind == kindLatency {
+					alert.Objective = ot.LatencyTarget
+				}
+
+				key := alert.Key()
+				_, firing := t.firing[key]
+				exceeded := longRate >= w.BurnRate && shortRates[kind] >= w.BurnRate
+
+				switch {
+				case exceeded && !firing:
+					t.firing[key] = alert
+					alerts = append(alerts, alert)
+				case !exceeded && firing && shortRates[kind] < w.BurnRate:
+					// resolved as soon as the short window recovers
+					delete(t.firing, key)
+					alert.Status = AlertStatusResolved
+					alerts = append(alerts, alert)
+				}
+			}
+		}
+	}
+
+	return alerts
+}
+
+// Run evaluates objectives periodically and notifies alert hooks until context done.
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, alert := range t.Evaluate() {
+				t.notify(ctx, alert)
+			}
+		}
+	}
+}
+
+func (t *Tracker) notify(ctx context.Context, alert *Alert) {
+	logger := logrus.WithFields(logrus.Fields{
+		"method":        alert.Method,
+		"kind":          alert.Kind,
+		"window":        alert.Window,
+		"longBurnRate":  alert.LongBurnRate,
+		"shortBurnRate": alert.ShortBurnRate,
+	})
+
+	if alert.Status == AlertStatusFiring {
+		logger.Warn("SLO error budget burn rate exceeded")
+	} else {
+		logger.Info("SLO error budget burn rate recovered")
+	}
+
+	for _, hook := range t.hooks {
+		if err := hook(ctx, alert); err != nil {
+			logger.WithError(err).Info("Failed to notify SLO alert")
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testWindows = []BurnRateWindow{{Long: time.Minute, Short: 6 * time.Second, BurnRate: 10}}
+
+func TestTrackerAvailability(t *testing.T) {
+	tracker := NewTracker(testWindows, []Objective{{Method: "eth_call", Availability: 0.99}})
+
+	// 5% error rate burns the 1% error budget 5 times faster
+	for i := 0; i < 100; i++ {
+		tracker.Observe("eth_call", i%20 != 0, time.Millisecond)
+	}
+	assert.Empty(t, tracker.Evaluate())
+
+	// untracked method
+	for i := 0; i < 100; i++ {
+		tracker.Observe("eth_getLogs", false, time.Millisecond)
+	}
+	assert.Empty(t, tracker.Evaluate())
+
+	// 20% error rate
+	for i := 0; i < 50; i++ {
+		tracker.Observe("eth_call", false, time.Millisecond)
+	}
+
+	alerts := tracker.Evaluate()
+	if assert.Len(t, alerts, 1) {
+		assert.Equal(t, AlertStatusFiring, alerts[0].Status)
+		assert.Equal(t, kindAvailability, alerts[0].Kind)
+		assert.InDelta(t, 55.0/150/0.01, alerts[0].LongBurnRate, 1e-6)
+	}
+
+	// fire only once
+	assert.Empty(t, tracker.Evaluate())
+}
+
+func TestTrackerLatency(t *testing.T) {
+	tracker := NewTracker(testWindows, []Objective{
+		{Method: AllMethods, Availability: 0.9, Latency: 100 * time.Millisecond, LatencyTarget: 0.99},
+	})
+
+	tracker.Observe("cfx_getStatus", true, time.Second)
+	tracker.Observe("eth_call", true, time.Millisecond)
+
+	alerts := tracker.Evaluate()
+	if assert.Len(t, alerts, 1) {
+		assert.Equal(t, kindLatency, alerts[0].Kind)
+		assert.Equal(t, AllMethods, alerts[0].Method)
+		assert.Equal(t, 0.99, alerts[0].Objective)
+	}
+}
+
+func TestBurnRate(t *testing.T) {
+	assert.Equal(t, float64(0), burnRate(0, 0, 0.99))
+	assert.InDelta(t, 1.0, burnRate(1, 100, 0.99), 1e-9)
+	assert.InDelta(t, 10.0, burnRate(10, 100, 0.99), 1e-9)
+}
@@ -0,0 +1,46 @@
+package slowlog
+
+import (
+	"context"
+)
+
+const (
+	// max number of slow requests or top offenders to query at a time
+	maxQueryLimit = 1000
+	// default number of slow requests or top offenders to query if not specified
+	defaultQueryLimit = 100
+)
+
+// AdminAPI provides JSON-RPC methods to query slow requests for diagnosis.
+type AdminAPI struct {
+	recorder *Recorder
+}
+
+// NewAdminAPI creates admin API of the recorder, which is served in namespace `slowlog`.
+func NewAdminAPI(recorder *Recorder) *AdminAPI {
+	return &AdminAPI{recorder: recorder}
+}
+
+// Latest returns the latest slow requests, newest first. By default, 100 requests are returned.
+func (api *AdminAPI) Latest(ctx context.Context, limit int) []Entry {
+	return api.recorder.Latest(normalizeLimit(limit))
+}
+
+// TopOffenders returns the top offenders of slow requests grouped by `authId`, `ip`, `method` or
+// `query` (method along with params digest), in descending order of total elapsed time. By
+// default, the top 100 offenders grouped by `authId` are returned.
+func (api *AdminAPI) TopOffenders(ctx context.Context, by string, limit int) ([]Offender, error) {
+	if len(by) == 0 {
+		by = GroupByAuthId
+	}
+
+	return api.recorder.TopOffenders(by, normalizeLimit(limit))
+}
+
+func normalizeLimit(limit int) int {
+	if limit <= 0 {
+		return defaultQueryLimit
+	}
+
+	return min(limit, maxQueryLimit)
+}
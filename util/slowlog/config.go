@@ -0,0 +1,42 @@
+package slowlog
+
+import (
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/sirupsen/logrus"
+)
+
+// Config represents the configuration of slow request recorder.
+type Config struct {
+	Enabled bool
+	// latency threshold beyond which requests are recorded
+	Threshold time.Duration `default:"1s"`
+	// max number of latest slow requests kept in memory
+	Capacity int `default:"1000"`
+	// JSON-RPC endpoint to query slow requests, disabled if empty
+	AdminEndpoint string
+	// bearer token to authenticate admin requests, required if admin endpoint configured
+	AuthToken string
+}
+
+// MustNewConfigFromViper loads slow request recorder config of the specified viper key, e.g.,
+// `rpc.slowlog` for core space and `ethrpc.slowlog` for evm space.
+func MustNewConfigFromViper(key string) (*Config, bool) {
+	var conf Config
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Enabled {
+		return nil, false
+	}
+
+	if conf.Threshold <= 0 || conf.Capacity <= 0 {
+		logrus.WithField("config", conf).Fatal("Invalid slow request threshold or capacity")
+	}
+
+	if len(conf.AdminEndpoint) > 0 && len(conf.AuthToken) == 0 {
+		logrus.Fatal("Auth token required for slow request admin endpoint")
+	}
+
+	return &conf, true
+}
@@ -0,0 +1,189 @@
+package slowlog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Dimensions to group slow requests by for top offenders.
+const (
+	GroupByAuthId = "authId" // API key or JWT subject
+	GroupByIp     = "ip"
+	GroupByMethod = "method"
+	GroupByQuery  = "query" // method along with params digest
+)
+
+var (
+	// recorders keyed by RPC namespace, e.g., `cfx` or `eth`
+	recorders   = make(map[string]*Recorder)
+	recordersMu sync.RWMutex
+)
+
+// Entry is a recorded slow request.
+type Entry struct {
+	Time         time.Time        `json:"time"`
+	Method       string           `json:"method"`
+	ParamsDigest string           `json:"paramsDigest"`
+	AuthId       string           `json:"authId,omitempty"`
+	IP           string           `json:"ip,omitempty"`
+	Elapsed      int64            `json:"elapsedMs"`
+	Stages       map[string]Stage `json:"stages,omitempty"` // timing breakdown
+	Error        string           `json:"error,omitempty"`
+}
+
+// groupKey returns the key of entry to group by.
+func (e *Entry) groupKey(by string) string {
+	switch by {
+	case GroupByAuthId:
+		return e.AuthId
+	case GroupByIp:
+		return e.IP
+	case GroupByMethod:
+		return e.Method
+	default:
+		return e.Method + ":" + e.ParamsDigest
+	}
+}
+
+// Offender is the aggregated slow requests of some group, e.g., API key or source IP.
+type Offender struct {
+	Key          string    `json:"key"`
+	Count        int       `json:"count"`
+	TotalElapsed int64     `json:"totalElapsedMs"`
+	MaxElapsed   int64     `json:"maxElapsedMs"`
+	LastSeen     time.Time `json:"lastSeen"`
+}
+
+// Recorder keeps the latest slow requests in a ring buffer.
+type Recorder struct {
+	conf *Config
+
+	mu      sync.Mutex
+	entries []Entry
+	next    int // position to overwrite once ring buffer is full
+}
+
+// NewRecorder creates a recorder of slow requests.
+func NewRecorder(conf *Config) *Recorder {
+	return &Recorder{
+		conf:    conf,
+		entries: make([]Entry, 0, conf.Capacity),
+	}
+}
+
+// Register registers the recorder for the RPC namespace, so that slow requests of the namespace
+// could be recorded by RPC middlewares.
+func Register(namespace string, recorder *Recorder) {
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+
+	recorders[namespace] = recorder
+}
+
+// Get returns the recorder registered for the RPC namespace if any.
+func Get(namespace string) (*Recorder, bool) {
+	recordersMu.RLock()
+	defer recordersMu.RUnlock()
+
+	recorder, ok := recorders[namespace]
+	return recorder, ok
+}
+
+// Threshold returns the latency threshold beyond which requests are recorded.
+func (r *Recorder) Threshold() time.Duration {
+	return r.conf.Threshold
+}
+
+// Add records the slow request, and overwrites the oldest one once full.
+func (r *Recorder) Add(entry Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) < cap(r.entries) {
+		r.entries = append(r.entries, entry)
+		return
+	}
+
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+}
+
+// Latest returns the latest slow requests, newest first.
+func (r *Recorder) Latest(limit int) []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := min(limit, len(r.entries))
+	result := make([]Entry, 0, n)
+
+	for i := 1; i <= n; i++ {
+		// r.next is 0 until ring buffer is full
+		pos := (r.next - i + len(r.entries)) % len(r.entries)
+		result = append(result, r.entries[pos])
+	}
+
+	return result
+}
+
+// TopOffenders aggregates the recorded slow requests by the specified dimension, and returns the
+// top offenders in descending order of total elapsed time.
+func (r *Recorder) TopOffenders(by string, limit int) ([]Offender, error) {
+	switch by {
+	case GroupByAuthId, GroupByIp, GroupByMethod, GroupByQuery:
+	default:
+		return nil, errors.Errorf("invalid dimension %q to group by", by)
+	}
+
+	r.mu.Lock()
+	groups := make(map[string]*Offender)
+
+	for i := range r.entries {
+		key := r.entries[i].groupKey(by)
+
+		offender, ok := groups[key]
+		if !ok {
+			offender = &Offender{Key: key}
+			groups[key] = offender
+		}
+
+		offender.Count++
+		offender.TotalElapsed += r.entries[i].Elapsed
+		offender.MaxElapsed = max(offender.MaxElapsed, r.entries[i].Elapsed)
+
+		if r.entries[i].Time.After(offender.LastSeen) {
+			offender.LastSeen = r.entries[i].Time
+		}
+	}
+	r.mu.Unlock()
+
+	offenders := make([]Offender, 0, len(groups))
+	for _, v := range groups {
+		offenders = append(offenders, *v)
+	}
+
+	sort.Slice(offenders, func(i, j int) bool {
+		if offenders[i].TotalElapsed != offenders[j].TotalElapsed {
+			return offenders[i].TotalElapsed > offenders[j].TotalElapsed
+		}
+
+		return offenders[i].Key < offenders[j].Key
+	})
+
+	if len(offenders) > limit {
+		offenders = offenders[:limit]
+	}
+
+	return offenders, nil
+}
+
+// Digest returns the digest of RPC params to identify identical queries without keeping the
+// params, which might be huge.
+func Digest(params []byte) string {
+	hash := sha256.Sum256(params)
+	return hex.EncodeToString(hash[:8])
+}
@@ -0,0 +1,66 @@
+package slowlog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorderLatest(t *testing.T) {
+	r := NewRecorder(&Config{Capacity: 3})
+	assert.Empty(t, r.Latest(10))
+
+	for i := 1; i <= 5; i++ {
+		r.Add(Entry{Elapsed: int64(i)})
+	}
+
+	// oldest ones overwritten
+	latest := r.Latest(10)
+	assert.Equal(t, 3, len(latest))
+	assert.Equal(t, int64(5), latest[0].Elapsed)
+	assert.Equal(t, int64(4), latest[1].Elapsed)
+	assert.Equal(t, int64(3), latest[2].Elapsed)
+
+	latest = r.Latest(1)
+	assert.Equal(t, 1, len(latest))
+	assert.Equal(t, int64(5), latest[0].Elapsed)
+}
+
+func TestRecorderTopOffenders(t *testing.T) {
+	r := NewRecorder(&Config{Capacity: 10})
+	r.Add(Entry{AuthId: "a", IP: "1.1.1.1", Method: "eth_getLogs", Elapsed: 1000})
+	r.Add(Entry{AuthId: "b", IP: "1.1.1.1", Method: "eth_call", Elapsed: 3000})
+	r.Add(Entry{AuthId: "a", IP: "2.2.2.2", Method: "eth_getLogs", Elapsed: 2500})
+
+	offenders, err := r.TopOffenders(GroupByAuthId, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []Offender{
+		{Key: "a", Count: 2, TotalElapsed: 3500, MaxElapsed: 2500},
+		{Key: "b", Count: 1, TotalElapsed: 3000, MaxElapsed: 3000},
+	}, offenders)
+
+	offenders, err = r.TopOffenders(GroupByIp, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []Offender{{Key: "1.1.1.1", Count: 2, TotalElapsed: 4000, MaxElapsed: 3000}}, offenders)
+
+	_, err = r.TopOffenders("unknown", 10)
+	assert.Error(t, err)
+}
+
+func TestTraceObserve(t *testing.T) {
+	// no trace in context
+	Observe(context.Background(), "store", time.Second)
+
+	ctx, trace := NewContext(context.Background())
+	Observe(ctx, "fullnode", 100*time.Millisecond)
+	Observe(ctx, "fullnode", 200*time.Millisecond)
+	Observe(ctx, "store", 50*time.Millisecond)
+
+	stages := trace.Stages()
+	assert.Equal(t, 2, stages["fullnode"].Calls)
+	assert.Equal(t, int64(300), stages["fullnode"].Elapsed)
+	assert.Equal(t, 1, stages["store"].Calls)
+	assert.Equal(t, int64(50), stages["store"].Elapsed)
+}
@@ -0,0 +1,63 @@
+package slowlog
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type traceCtxKey struct{}
+
+// Stage is the accumulated timing of some stage to serve a request, e.g., full node or store.
+type Stage struct {
+	Calls   int   `json:"calls"`
+	Elapsed int64 `json:"elapsedMs"`
+
+	elapsed time.Duration
+}
+
+// Trace collects the timing breakdown of stages to serve a request, which is safe for concurrent
+// use, e.g., hedged full node requests.
+type Trace struct {
+	mu     sync.Mutex
+	stages map[string]*Stage
+}
+
+// NewContext returns a new context carrying a trace to collect timing breakdown of request.
+func NewContext(ctx context.Context) (context.Context, *Trace) {
+	trace := &Trace{stages: make(map[string]*Stage)}
+	return context.WithValue(ctx, traceCtxKey{}, trace), trace
+}
+
+// Observe accumulates the elapsed duration of stage into the trace within context if any.
+func Observe(ctx context.Context, stage string, elapsed time.Duration) {
+	trace, ok := ctx.Value(traceCtxKey{}).(*Trace)
+	if !ok {
+		return
+	}
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+
+	s, ok := trace.stages[stage]
+	if !ok {
+		s = &Stage{}
+		trace.stages[stage] = s
+	}
+
+	s.Calls++
+	s.elapsed += elapsed
+}
+
+// Stages returns a snapshot of the timing breakdown.
+func (t *Trace) Stages() map[string]Stage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stages := make(map[string]Stage, len(t.stages))
+	for k, v := range t.stages {
+		stages[k] = Stage{Calls: v.Calls, Elapsed: v.elapsed.Milliseconds(), elapsed: v.elapsed}
+	}
+
+	return stages
+}
@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/Conflux-Chain/confura"
+
+var provider *sdktrace.TracerProvider
+
+type config struct {
+	Enabled bool
+	// OTLP gRPC collector endpoint, eg., Jaeger or OpenTelemetry collector
+	Endpoint    string `default:"localhost:4317"`
+	Insecure    bool   `default:"true"`
+	ServiceName string `default:"confura"`
+	// sampling ratio of root spans in range [0, 1]
+	SampleRatio float64 `default:"1"`
+}
+
+// MustInit initializes the global tracer provider to export spans via OTLP if enabled,
+// otherwise spans are no-op.
+//
+// This package should be imported after the initialization of viper and logrus.
+func MustInit() {
+	var conf config
+	viper.MustUnmarshalKey("tracing", &conf)
+
+	// always propagate trace context, even if tracing disabled locally
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	if !conf.Enabled {
+		return
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(conf.Endpoint)}
+	if conf.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(context.Background(), opts...)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create OTLP trace exporter")
+	}
+
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL, semconv.ServiceName(conf.ServiceName),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	logrus.WithField("endpoint", conf.Endpoint).Info("OpenTelemetry tracing enabled")
+}
+
+// Shutdown flushes the pending spans and shuts down the tracer provider.
+func Shutdown() {
+	if provider == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := provider.Shutdown(ctx); err != nil {
+		logrus.WithError(err).Info("Failed to shutdown tracer provider")
+	}
+}
+
+// Start starts a span with the specified name and attributes.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends the span, and records the error if any.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// Extract extracts the propagated trace context from carrier, eg., HTTP headers.
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// Inject injects the trace context into carrier, eg., HTTP headers, to propagate.
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
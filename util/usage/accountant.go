@@ -0,0 +1,222 @@
+package usage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// granularity of usage rollups in seconds
+	bucketSeconds = int64(60)
+	// max length of method name to account, longer ones are probably malicious
+	maxMethodLength = 64
+)
+
+var (
+	// accountants keyed by RPC namespace, e.g., `cfx` or `eth`
+	accountants   = make(map[string]*Accountant)
+	accountantsMu sync.RWMutex
+)
+
+// Rollup is the aggregated usage of an API key for some RPC method within a time bucket.
+type Rollup struct {
+	Bucket      int64 // unix timestamp in seconds aligned to the bucket granularity
+	ApiKey      string
+	Method      string
+	Calls       uint64
+	Errors      uint64
+	RateLimited uint64
+}
+
+type rollupKey struct {
+	bucket int64
+	apiKey string
+	method string
+}
+
+// Sink persists the usage rollups.
+type Sink interface {
+	// AddApiKeyUsages accumulates the usage rollups into persistent storage.
+	AddApiKeyUsages(rollups []Rollup) error
+	// PruneApiKeyUsages removes the usage rollups of buckets before the specified timestamp.
+	PruneApiKeyUsages(before int64) (int64, error)
+}
+
+// Accountant aggregates the RPC usages per API key in memory, and flushes the rollups into sink
+// periodically.
+type Accountant struct {
+	conf *Config
+	sink Sink
+
+	mu      sync.Mutex
+	pending map[rollupKey]*Rollup
+}
+
+// NewAccountant creates accountant to flush usage rollups into the specified sink.
+func NewAccountant(conf *Config, sink Sink) *Accountant {
+	return &Accountant{
+		conf:    conf,
+		sink:    sink,
+		pending: make(map[rollupKey]*Rollup),
+	}
+}
+
+// Register registers the accountant for the RPC namespace, so that usages of the namespace could
+// be collected by RPC middlewares.
+func Register(namespace string, accountant *Accountant) {
+	accountantsMu.Lock()
+	defer accountantsMu.Unlock()
+
+	accountants[namespace] = accountant
+}
+
+func getAccountant(namespace string) (*Accountant, bool) {
+	accountantsMu.RLock()
+	defer accountantsMu.RUnlock()
+
+	accountant, ok := accountants[namespace]
+	return accountant, ok
+}
+
+// Collect accounts an RPC call of the API key if usage accounting enabled for the namespace.
+func Collect(namespace, apiKey, method string, failed bool) {
+	if accountant, ok := getAccountant(namespace); ok {
+		accountant.add(time.Now(), apiKey, method, func(r *Rollup) {
+			r.Calls++
+			if failed {
+				r.Errors++
+			}
+		})
+	}
+}
+
+// CollectRateLimited accounts a rate limited RPC call of the API key if usage accounting enabled
+// for the namespace.
+func CollectRateLimited(namespace, apiKey, method string) {
+	if accountant, ok := getAccountant(namespace); ok {
+		accountant.add(time.Now(), apiKey, method, func(r *Rollup) {
+			r.RateLimited++
+		})
+	}
+}
+
+func (a *Accountant) add(now time.Time, apiKey, method string, update func(r *Rollup)) {
+	if len(method) > maxMethodLength {
+		method = method[:maxMethodLength]
+	}
+
+	key := rollupKey{
+		bucket: now.Unix() / bucketSeconds * bucketSeconds,
+		apiKey: apiKey,
+		method: method,
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	r, ok := a.pending[key]
+	if !ok {
+		r = &Rollup{Bucket: key.bucket, ApiKey: apiKey, Method: method}
+		a.pending[key] = r
+	}
+
+	update(r)
+}
+
+// take takes away all the pending rollups.
+func (a *Accountant) take() []Rollup {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rollups := make([]Rollup, 0, len(a.pending))
+	for _, r := range a.pending {
+		rollups = append(rollups, *r)
+	}
+
+	a.pending = make(map[rollupKey]*Rollup)
+
+	return rollups
+}
+
+// Run flushes usage rollups into sink periodically, and prunes the expired ones.
+func (a *Accountant) Run(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+
+	ticker := time.NewTicker(a.conf.Interval)
+	defer ticker.Stop()
+
+	var lastPruned time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			// flush the remaining rollups before shutdown
+			if err := a.flush(); err != nil {
+				logrus.WithError(err).Error("Usage accountant failed to flush rollups on shutdown")
+			}
+
+			logrus.Info("Usage accountant shutdown ok")
+			return
+		case <-ticker.C:
+			if err := a.flush(); err != nil {
+				logrus.WithError(err).Error("Usage accountant failed to flush rollups")
+			}
+
+			if a.conf.Retention > 0 && time.Since(lastPruned) >= time.Hour {
+				a.prune()
+				lastPruned = time.Now()
+			}
+		}
+	}
+}
+
+func (a *Accountant) flush() error {
+	rollups := a.take()
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	if err := a.sink.AddApiKeyUsages(rollups); err != nil {
+		// merge back to flush again on next round
+		a.mu.Lock()
+		for i := range rollups {
+			a.merge(&rollups[i])
+		}
+		a.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+// merge merges the rollup into pending ones, which requires lock held.
+func (a *Accountant) merge(rollup *Rollup) {
+	key := rollupKey{bucket: rollup.Bucket, apiKey: rollup.ApiKey, method: rollup.Method}
+
+	r, ok := a.pending[key]
+	if !ok {
+		a.pending[key] = rollup
+		return
+	}
+
+	r.Calls += rollup.Calls
+	r.Errors += rollup.Errors
+	r.RateLimited += rollup.RateLimited
+}
+
+func (a *Accountant) prune() {
+	before := time.Now().Add(-a.conf.Retention).Unix()
+
+	pruned, err := a.sink.PruneApiKeyUsages(before)
+	if err != nil {
+		logrus.WithError(err).Error("Usage accountant failed to prune expired rollups")
+		return
+	}
+
+	logrus.WithField("pruned", pruned).Debug("Usage accountant pruned expired rollups")
+}
@@ -0,0 +1,90 @@
+package usage
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSink struct {
+	rollups []Rollup
+	err     error
+}
+
+func (s *mockSink) AddApiKeyUsages(rollups []Rollup) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.rollups = append(s.rollups, rollups...)
+	return nil
+}
+
+func (s *mockSink) PruneApiKeyUsages(before int64) (int64, error) {
+	return 0, nil
+}
+
+func TestAccountantAggregate(t *testing.T) {
+	sink := &mockSink{}
+	a := NewAccountant(&Config{Interval: time.Minute}, sink)
+
+	now := time.Unix(1700000000, 0)
+	calls := func(r *Rollup) { r.Calls++ }
+
+	a.add(now, "key1", "eth_call", calls)
+	a.add(now.Add(30*time.Second), "key1", "eth_call", func(r *Rollup) { r.Calls++; r.Errors++ })
+	a.add(now.Add(time.Minute), "key1", "eth_call", calls)
+	a.add(now, "key2", "eth_call", func(r *Rollup) { r.RateLimited++ })
+
+	assert.NoError(t, a.flush())
+
+	sort.Slice(sink.rollups, func(i, j int) bool {
+		ri, rj := sink.rollups[i], sink.rollups[j]
+		if ri.ApiKey != rj.ApiKey {
+			return ri.ApiKey < rj.ApiKey
+		}
+		return ri.Bucket < rj.Bucket
+	})
+
+	assert.Equal(t, []Rollup{
+		{Bucket: 1699999980, ApiKey: "key1", Method: "eth_call", Calls: 2, Errors: 1},
+		{Bucket: 1700000040, ApiKey: "key1", Method: "eth_call", Calls: 1},
+		{Bucket: 1699999980, ApiKey: "key2", Method: "eth_call", RateLimited: 1},
+	}, sink.rollups)
+
+	// nothing to flush any more
+	sink.rollups = nil
+	assert.NoError(t, a.flush())
+	assert.Empty(t, sink.rollups)
+}
+
+func TestAccountantFlushFailure(t *testing.T) {
+	sink := &mockSink{err: errors.New("db down")}
+	a := NewAccountant(&Config{Interval: time.Minute}, sink)
+
+	now := time.Unix(1700000040, 0)
+	a.add(now, "key", "cfx_getLogs", func(r *Rollup) { r.Calls++ })
+	assert.Error(t, a.flush())
+
+	// rollups merged back and flushed along with new usages on next round
+	a.add(now, "key", "cfx_getLogs", func(r *Rollup) { r.Calls++ })
+
+	sink.err = nil
+	assert.NoError(t, a.flush())
+	assert.Equal(t, []Rollup{
+		{Bucket: 1700000040, ApiKey: "key", Method: "cfx_getLogs", Calls: 2},
+	}, sink.rollups)
+}
+
+func TestNormalizeTimeRange(t *testing.T) {
+	from, to, err := normalizeTimeRange(1700000010, 1700000070)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1699999980), from)
+	assert.Equal(t, int64(1700000100), to)
+
+	_, _, err = normalizeTimeRange(1700000100, 1700000000)
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+package usage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/pkg/errors"
+)
+
+const (
+	// max number of data points of usage series to query at a time
+	maxSeriesPoints = 1440
+	// max number of top methods to query at a time
+	maxTopMethods = 100
+	// default time range to query if not specified
+	defaultQueryRange = 24 * time.Hour
+)
+
+// SeriesPoint is the aggregated usage of an API key within a time step.
+type SeriesPoint struct {
+	Time        int64  `json:"time"` // unix timestamp in seconds of step start
+	Calls       uint64 `json:"calls"`
+	Errors      uint64 `json:"errors"`
+	RateLimited uint64 `json:"rateLimited"`
+}
+
+// MethodUsage is the aggregated usage of an API key for some RPC method.
+type MethodUsage struct {
+	Method      string `json:"method"`
+	Calls       uint64 `json:"calls"`
+	Errors      uint64 `json:"errors"`
+	RateLimited uint64 `json:"rateLimited"`
+}
+
+// Querier queries the persisted usage rollups.
+type Querier interface {
+	// ApiKeyUsageSeries returns the usage series of API key in time range [from, to), aggregated
+	// by the specified step in seconds.
+	ApiKeyUsageSeries(apiKey string, from, to, step int64) ([]SeriesPoint, error)
+	// ApiKeyTopMethods returns the most called methods of API key in time range [from, to).
+	ApiKeyTopMethods(apiKey string, from, to int64, limit int) ([]MethodUsage, error)
+}
+
+// adminAPI provides JSON-RPC methods to query usages per API key for dashboard.
+type adminAPI struct {
+	querier Querier
+}
+
+// Series returns the usage timeseries (calls, errors and rate limited calls) of API key in time
+// range [from, to) of unix timestamps in seconds, aggregated by step in seconds. By default, the
+// usages of last 24 hours are returned by minute.
+func (api *adminAPI) Series(ctx context.Context, apiKey string, from, to, step int64) ([]SeriesPoint, error) {
+	from, to, err := normalizeTimeRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if step <= 0 {
+		step = bucketSeconds
+	}
+
+	if step%bucketSeconds != 0 {
+		return nil, errors.Errorf("step must be multiple of %v seconds", bucketSeconds)
+	}
+
+	if (to-from)/step > maxSeriesPoints {
+		return nil, errors.Errorf("too many data points, max %v allowed", maxSeriesPoints)
+	}
+
+	return api.querier.ApiKeyUsageSeries(apiKey, from, to, step)
+}
+
+// TopMethods returns the most called methods of API key in time range [from, to) of unix
+// timestamps in seconds. By default, the top 10 methods of last 24 hours are returned.
+func (api *adminAPI) TopMethods(ctx context.Context, apiKey string, from, to int64, limit int) ([]MethodUsage, error) {
+	from, to, err := normalizeTimeRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+
+	return api.querier.ApiKeyTopMethods(apiKey, from, to, min(limit, maxTopMethods))
+}
+
+// normalizeTimeRange aligns the time range to buckets, which defaults to the last 24 hours.
+func normalizeTimeRange(from, to int64) (int64, int64, error) {
+	if to <= 0 {
+		to = time.Now().Unix()
+	}
+
+	if from <= 0 {
+		from = to - int64(defaultQueryRange/time.Second)
+	}
+
+	// round down `from` and round up `to` to include partial buckets
+	from = from / bucketSeconds * bucketSeconds
+	to = (to + bucketSeconds - 1) / bucketSeconds * bucketSeconds
+
+	if from >= to {
+		return 0, 0, errors.Errorf("invalid time range [%v, %v)", from, to)
+	}
+
+	return from, to, nil
+}
+
+// MustServeAdmin serves the usage admin JSON-RPC endpoint authenticated by bearer token.
+func MustServeAdmin(ctx context.Context, wg *sync.WaitGroup, conf *Config, querier Querier) {
+	server := rpcutil.MustNewAdminServer("usage_admin", map[string]interface{}{
+		"usage": &adminAPI{querier: querier},
+	}, rpcutil.MustNewBearerAuthMiddleware(conf.AuthToken))
+
+	go server.MustServeGraceful(ctx, wg, conf.AdminEndpoint, rpcutil.ProtocolHttp)
+}
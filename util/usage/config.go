@@ -0,0 +1,42 @@
+package usage
+
+import (
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/sirupsen/logrus"
+)
+
+// Config represents the configuration of per API key usage accounting.
+type Config struct {
+	Enabled bool
+	// interval to flush usage rollups into database
+	Interval time.Duration `default:"1m"`
+	// retention duration of usage rollups, never pruned if zero
+	Retention time.Duration `default:"720h"`
+	// JSON-RPC endpoint to query usage rollups, disabled if empty
+	AdminEndpoint string
+	// bearer token to authenticate admin requests, required if admin endpoint configured
+	AuthToken string
+}
+
+// MustNewConfigFromViper loads usage accounting config of the specified viper key, e.g.,
+// `rpc.usage` for core space and `ethrpc.usage` for evm space.
+func MustNewConfigFromViper(key string) (*Config, bool) {
+	var conf Config
+	viper.MustUnmarshalKey(key, &conf)
+
+	if !conf.Enabled {
+		return nil, false
+	}
+
+	if conf.Interval <= 0 {
+		logrus.WithField("config", conf).Fatal("Invalid usage accounting flush interval")
+	}
+
+	if len(conf.AdminEndpoint) > 0 && len(conf.AuthToken) == 0 {
+		logrus.Fatal("Auth token required for usage admin endpoint")
+	}
+
+	return &conf, true
+}
@@ -0,0 +1,69 @@
+package virtualfilter
+
+import (
+	"strings"
+
+	"github.com/Conflux-Chain/confura/node"
+	rpcErrors "github.com/Conflux-Chain/confura/util/rpc/errors"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errFilterApiUnsupported = errors.New("filter API not supported by the full node")
+
+	// lowercase error messages of full nodes with filter API disabled or not implemented
+	filterApiUnsupportedPatterns = []string{
+		"method not found",
+		"does not exist/is not available",
+		"not supported",
+		"not implemented",
+		"disabled",
+	}
+)
+
+// isFilterApiUnsupported checks if the error indicates that the filter API is disabled or not
+// implemented by full node. Other errors, e.g., network errors, are not regarded as incapable.
+func isFilterApiUnsupported(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var rpcErr interface{ ErrorCode() int }
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == rpcErrors.CodeMethodNotFound {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range filterApiUnsupportedPatterns {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// probeEthFilterCapability probes if the filter API is enabled on full node by installing a
+// block filter, which will be uninstalled at once.
+func probeEthFilterCapability(client *node.Web3goClient) bool {
+	fid, err := client.Filter.NewBlockFilter()
+	if err == nil {
+		if _, err := client.Filter.UninstallFilter(fid); err != nil {
+			logrus.WithField("nodeName", client.NodeName()).
+				WithError(err).
+				Info("Failed to uninstall probe filter on full node")
+		}
+
+		return true
+	}
+
+	capable := !isFilterApiUnsupported(err)
+
+	logrus.WithFields(logrus.Fields{
+		"nodeName": client.NodeName(),
+		"capable":  capable,
+	}).WithError(err).Info("Failed to probe filter API on full node")
+
+	return capable
+}
@@ -0,0 +1,27 @@
+package virtualfilter
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCodedError struct {
+	code int
+	msg  string
+}
+
+func (e *mockCodedError) Error() string  { return e.msg }
+func (e *mockCodedError) ErrorCode() int { return e.code }
+
+func TestIsFilterApiUnsupported(t *testing.T) {
+	assert.False(t, isFilterApiUnsupported(nil))
+	assert.False(t, isFilterApiUnsupported(errors.New("connection refused")))
+	assert.False(t, isFilterApiUnsupported(&mockCodedError{-32000, "filter not found"}))
+
+	assert.True(t, isFilterApiUnsupported(&mockCodedError{-32601, "unknown method"}))
+	assert.True(t, isFilterApiUnsupported(errors.WithMessage(&mockCodedError{-32601, "unknown method"}, "wrapped")))
+	assert.True(t, isFilterApiUnsupported(errors.New("the method eth_newBlockFilter does not exist/is not available")))
+	assert.True(t, isFilterApiUnsupported(errors.New("Filter API Disabled")))
+}
@@ -1,23 +1,42 @@
 package virtualfilter
 
 import (
-	"github.com/Conflux-Chain/confura/util"
 	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
 	w3rpc "github.com/openweb3/go-rpc-provider"
-	"github.com/sirupsen/logrus"
 )
 
 // core space filter API
 
 type cfxFilterApi struct {
-	fs        *cfxFilterSystem   // filter system
-	fnClients util.ConcurrentMap // full node clients: node name => sdk client
+	fs        *cfxFilterSystem // filter system
+	fnClients *fnClientPool    // full node client pool
 }
 
 func newCfxFilterApi(sys *cfxFilterSystem) *cfxFilterApi {
-	return &cfxFilterApi{fs: sys}
+	fnClients := newFnClientPool(
+		"cfx", sys.conf.ClientPool,
+		func(url string, conf clientPoolConfig) (interface{}, error) {
+			return rpcutil.NewCfxClient(
+				url,
+				rpcutil.WithClientHookMetrics(true),
+				rpcutil.WithClientMaxConnsPerHost(conf.MaxConnsPerHost),
+				rpcutil.WithClientMaxIdleConnDuration(conf.MaxIdleConnDuration),
+			)
+		},
+		func(client interface{}) error {
+			_, err := client.(*sdk.Client).GetEpochNumber(types.EpochLatestMined)
+			return err
+		},
+		func(client interface{}) {
+			client.(*sdk.Client).Close()
+		},
+		// reset filter worker so that the new client will be used
+		func(nodeName string) { sys.resetWorker(nodeName) },
+	)
+
+	return &cfxFilterApi{fs: sys, fnClients: fnClients}
 }
 
 func (api *cfxFilterApi) NewBlockFilter(nodeUrl string) (w3rpc.ID, error) {
@@ -39,6 +58,13 @@ func (api *cfxFilterApi) NewPendingTransactionFilter(nodeUrl string) (w3rpc.ID,
 }
 
 func (api *cfxFilterApi) UninstallFilter(id w3rpc.ID) (bool, error) {
+	if _, ok := api.fs.getFilter(id); !ok {
+		var res bool
+		if forwarded, err := api.fs.forward(id, &res, "cfx_uninstallFilter", id); forwarded {
+			return res, err
+		}
+	}
+
 	return api.fs.uninstallFilter(id)
 }
 
@@ -53,6 +79,13 @@ func (api *cfxFilterApi) NewFilter(nodeUrl string, crit types.LogFilter) (w3rpc.
 
 func (api *cfxFilterApi) GetLogFilter(fid w3rpc.ID) (*types.LogFilter, error) {
 	vf, ok := api.fs.getFilter(fid)
+	if !ok {
+		var res *types.LogFilter
+		if forwarded, err := api.fs.forward(fid, &res, "cfx_getLogFilter", fid); forwarded {
+			return res, err
+		}
+	}
+
 	if !ok || vf.ftype() != filterTypeLog {
 		return nil, errFilterNotFound
 	}
@@ -62,23 +95,18 @@ func (api *cfxFilterApi) GetLogFilter(fid w3rpc.ID) (*types.LogFilter, error) {
 }
 
 func (api *cfxFilterApi) GetFilterChanges(id w3rpc.ID) (*types.CfxFilterChanges, error) {
+	if _, ok := api.fs.getFilter(id); !ok {
+		var res *types.CfxFilterChanges
+		if forwarded, err := api.fs.forward(id, &res, "cfx_getFilterChanges", id); forwarded {
+			return res, err
+		}
+	}
+
 	return api.fs.getFilterChanges(id)
 }
 
 func (api *cfxFilterApi) loadOrGetFnClient(nodeUrl string) (*sdk.Client, error) {
-	nodeName := rpcutil.Url2NodeName(nodeUrl)
-	client, _, err := api.fnClients.LoadOrStoreFnErr(nodeName, func(interface{}) (interface{}, error) {
-		client, err := rpcutil.NewCfxClient(nodeUrl, rpcutil.WithClientHookMetrics(true))
-		if err != nil {
-			logrus.WithField("fnNodeUrl", nodeUrl).
-				WithError(err).
-				Error("Failed to new cfx client for virtual filter")
-			return nil, err
-		}
-
-		return client, nil
-	})
-
+	client, err := api.fnClients.get(nodeUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -95,7 +95,7 @@ func newCfxLogFilter(
 		cfxFilter: newCfxFilter(rpc.NewID(), filterTypeLog, client),
 	}
 
-	if err := worker.accept(lf); err != nil {
+	if err := worker.accept(lf, cfxCritKey(&crit)); err != nil {
 		return nil, err
 	}
 
@@ -115,11 +115,30 @@ func (f *cfxLogFilter) fetch() (filterChanges, error) {
 		return nil, err
 	}
 
+	// matched event logs multiplexed from the filter group with identical criteria
+	groupLogs := make(map[int][]types.Log, len(pchanges.epochs))
+	for i, fe := range pchanges.epochs {
+		if fe.reorged() {
+			continue
+		}
+
+		v, ok := pchanges.group.load(fe.groupKey())
+		metrics.Registry.VirtualFilter.CoalescedHitPercentage("cfx", f.nodeName()).Mark(ok)
+
+		if ok {
+			groupLogs[i] = v.([]types.Log)
+		}
+	}
+
 	// distinguish filter epochs missing of event logs due to cache evict
 	var missingBlockhashes []string
 	bnMin, bnMax := uint64(math.MaxUint64), uint64(0)
 
-	for _, fe := range pchanges.epochs {
+	for i, fe := range pchanges.epochs {
+		if _, ok := groupLogs[i]; ok { // already matched by filter group
+			continue
+		}
+
 		if !fe.reorged() && len(fe.logs) == 0 { // filter epochs missing of event logs
 			missingBlockhashes = append(missingBlockhashes, fe.blockHash.String())
 			bnMin, bnMax = util.MinUint64(fe.epochNum, bnMin), util.MaxUint64(fe.epochNum, bnMax)
@@ -180,12 +199,18 @@ func (f *cfxLogFilter) fetch() (filterChanges, error) {
 
 	for ; idx < len(pchanges.epochs); idx++ { // append normal event logs
 		fe := pchanges.epochs[idx]
-		logs := fe.logs
-		if len(logs) == 0 { // load from store logs
-			logs = blockLogs[fe.blockHash.String()]
+
+		logs, ok := groupLogs[idx]
+		if !ok {
+			logs = fe.logs
+			if len(logs) == 0 { // load from store logs
+				logs = blockLogs[fe.blockHash.String()]
+			}
+
+			logs = filterCfxLogs(logs, &f.crit)
+			pchanges.group.store(fe.groupKey(), logs)
 		}
 
-		logs = filterCfxLogs(logs, &f.crit)
 		for i := range logs {
 			changeLogs = append(changeLogs, &types.SubscriptionLog{
 				Log: &logs[i],
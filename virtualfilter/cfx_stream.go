@@ -0,0 +1,271 @@
+package virtualfilter
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/Conflux-Chain/confura/virtualfilter/streampb"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	w3rpc "github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// gRPC service name of core space streaming
+	cfxStreamServiceName = "confura.stream.Cfx"
+)
+
+// CfxStreamServer is the server API of core space streaming service.
+type CfxStreamServer interface {
+	// StreamLogs streams event logs matched with the filter.
+	StreamLogs(*streampb.CfxStreamLogsRequest, grpc.ServerStream) error
+	// StreamHeads streams hashes of new blocks.
+	StreamHeads(*streampb.StreamRequest, grpc.ServerStream) error
+	// StreamPendingTxs streams hashes of new pending transactions.
+	StreamPendingTxs(*streampb.StreamRequest, grpc.ServerStream) error
+}
+
+// cfxStreamServiceDesc is the gRPC service descriptor for CfxStreamServer.
+var cfxStreamServiceDesc = grpc.ServiceDesc{
+	ServiceName: cfxStreamServiceName,
+	HandlerType: (*CfxStreamServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamLogs",
+			ServerStreams: true,
+			Handler: streamHandler(func(srv CfxStreamServer, req *streampb.CfxStreamLogsRequest, stream grpc.ServerStream) error {
+				return srv.StreamLogs(req, stream)
+			}),
+		},
+		{
+			StreamName:    "StreamHeads",
+			ServerStreams: true,
+			Handler: streamHandler(func(srv CfxStreamServer, req *streampb.StreamRequest, stream grpc.ServerStream) error {
+				return srv.StreamHeads(req, stream)
+			}),
+		},
+		{
+			StreamName:    "StreamPendingTxs",
+			ServerStreams: true,
+			Handler: streamHandler(func(srv CfxStreamServer, req *streampb.StreamRequest, stream grpc.ServerStream) error {
+				return srv.StreamPendingTxs(req, stream)
+			}),
+		},
+	},
+}
+
+// cfxStreamService implements CfxStreamServer upon core space virtual filters.
+type cfxStreamService struct {
+	*filterStreamer
+
+	api *cfxFilterApi
+}
+
+func newCfxStreamService(conf streamConfig, api *cfxFilterApi, shutdownCtx context.Context) *cfxStreamService {
+	return &cfxStreamService{
+		filterStreamer: &filterStreamer{
+			conf:        conf,
+			shutdownCtx: shutdownCtx,
+			uninstall:   api.UninstallFilter,
+			poll: func(fid w3rpc.ID) ([]interface{}, error) {
+				changes, err := api.GetFilterChanges(fid)
+				return cfxFilterChangesMessages(changes), err
+			},
+		},
+		api: api,
+	}
+}
+
+func (s *cfxStreamService) StreamLogs(req *streampb.CfxStreamLogsRequest, stream grpc.ServerStream) error {
+	filter, err := cfxLogFilterFromPb(req.GetFilter())
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+
+	return s.serve(stream, func() (w3rpc.ID, error) {
+		return s.api.NewFilter(s.conf.NodeUrl, filter)
+	})
+}
+
+func (s *cfxStreamService) StreamHeads(req *streampb.StreamRequest, stream grpc.ServerStream) error {
+	return s.serve(stream, func() (w3rpc.ID, error) {
+		return s.api.NewBlockFilter(s.conf.NodeUrl)
+	})
+}
+
+func (s *cfxStreamService) StreamPendingTxs(req *streampb.StreamRequest, stream grpc.ServerStream) error {
+	return s.serve(stream, func() (w3rpc.ID, error) {
+		return s.api.NewPendingTransactionFilter(s.conf.NodeUrl)
+	})
+}
+
+// cfxFilterChangesMessages converts event logs or hashes of filter changes to stream messages.
+func cfxFilterChangesMessages(changes *types.CfxFilterChanges) (msgs []interface{}) {
+	if changes == nil {
+		return nil
+	}
+
+	for _, log := range changes.Logs {
+		if msg := cfxLogToPb(log); msg != nil {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	for _, hash := range changes.Hashes {
+		msgs = append(msgs, &streampb.HashMessage{Hash: cfxHashBytes(&hash)})
+	}
+
+	return msgs
+}
+
+func cfxLogToPb(log *types.SubscriptionLog) *streampb.CfxLogMessage {
+	switch {
+	case log == nil:
+		return nil
+	case log.ChainReorg != nil:
+		return &streampb.CfxLogMessage{Message: &streampb.CfxLogMessage_Reorg{
+			Reorg: &streampb.CfxChainReorg{RevertTo: cfxBigUint64(log.ChainReorg.RevertTo)},
+		}}
+	case log.Log == nil:
+		return nil
+	}
+
+	topics := make([][]byte, 0, len(log.Topics))
+	for i := range log.Topics {
+		topics = append(topics, cfxHashBytes(&log.Topics[i]))
+	}
+
+	pb := &streampb.CfxLog{
+		Address:             log.Address.String(),
+		Topics:              topics,
+		Data:                log.Data,
+		BlockHash:           cfxHashBytes(log.BlockHash),
+		EpochNumber:         cfxBigUint64(log.EpochNumber),
+		TransactionHash:     cfxHashBytes(log.TransactionHash),
+		TransactionIndex:    cfxBigUint64(log.TransactionIndex),
+		LogIndex:            cfxBigUint64(log.LogIndex),
+		TransactionLogIndex: cfxBigUint64(log.TransactionLogIndex),
+	}
+
+	if log.Space != nil {
+		pb.Space = string(*log.Space)
+	}
+
+	return &streampb.CfxLogMessage{Message: &streampb.CfxLogMessage_Log{Log: pb}}
+}
+
+func cfxHashBytes(hash *types.Hash) []byte {
+	if hash == nil {
+		return nil
+	}
+
+	return hash.ToCommonHash().Bytes()
+}
+
+func cfxBigUint64(v *hexutil.Big) uint64 {
+	if v == nil {
+		return 0
+	}
+
+	return v.ToInt().Uint64()
+}
+
+// cfxLogFilterFromPb converts the stream request filter to log filter, where epoch and block
+// numbers are parsed the same as JSON-RPC, e.g., hex or tag `latest_state`.
+func cfxLogFilterFromPb(pb *streampb.CfxLogFilter) (filter types.LogFilter, err error) {
+	if pb == nil {
+		return filter, nil
+	}
+
+	if filter.FromEpoch, err = parseEpoch(pb.FromEpoch); err != nil {
+		return filter, errors.WithMessage(err, "invalid from epoch")
+	}
+
+	if filter.ToEpoch, err = parseEpoch(pb.ToEpoch); err != nil {
+		return filter, errors.WithMessage(err, "invalid to epoch")
+	}
+
+	if filter.FromBlock, err = parseBig(pb.FromBlock); err != nil {
+		return filter, errors.WithMessage(err, "invalid from block")
+	}
+
+	if filter.ToBlock, err = parseBig(pb.ToBlock); err != nil {
+		return filter, errors.WithMessage(err, "invalid to block")
+	}
+
+	for _, hash := range pb.BlockHashes {
+		if len(hash) != common.HashLength {
+			return filter, errors.New("invalid block hash length")
+		}
+
+		filter.BlockHashes = append(filter.BlockHashes, types.Hash(common.BytesToHash(hash).Hex()))
+	}
+
+	for _, addr := range pb.Addresses {
+		address, err := cfxaddress.NewFromBase32(addr)
+		if err != nil {
+			return filter, errors.WithMessagef(err, "invalid address %v", addr)
+		}
+
+		filter.Address = append(filter.Address, address)
+	}
+
+	for _, alternatives := range pb.Topics {
+		var topics []types.Hash // nil matches any topic
+
+		for _, topic := range alternatives.GetTopics() {
+			if len(topic) != common.HashLength {
+				return filter, errors.New("invalid topic length")
+			}
+
+			topics = append(topics, types.Hash(common.BytesToHash(topic).Hex()))
+		}
+
+		filter.Topics = append(filter.Topics, topics)
+	}
+
+	return filter, nil
+}
+
+// parseEpoch parses epoch number in hex or tag, or returns nil if empty.
+func parseEpoch(s string) (*types.Epoch, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+
+	var epoch types.Epoch
+	if err := epoch.UnmarshalJSON([]byte(strconv.Quote(s))); err != nil {
+		return nil, err
+	}
+
+	return &epoch, nil
+}
+
+// parseBig parses big number in hex, or returns nil if empty.
+func parseBig(s string) (*hexutil.Big, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+
+	v, err := hexutil.DecodeBig(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return (*hexutil.Big)(v), nil
+}
+
+// mustServeCfxStream serves the core space gRPC streaming service, which will be gracefully
+// stopped once context done.
+func mustServeCfxStream(shutdownContext util.GracefulShutdownContext, conf streamConfig, api *cfxFilterApi) {
+	service := newCfxStreamService(conf, api, shutdownContext.Ctx)
+	mustServeStream(shutdownContext, conf, &cfxStreamServiceDesc, service)
+}
@@ -29,7 +29,7 @@ func newCfxFilterSystem(
 ) *cfxFilterSystem {
 	return &cfxFilterSystem{
 		conf:             conf,
-		filterSystemBase: newFilterSystemBase(conf.TTL, vfls, shutdownCtx),
+		filterSystemBase: newFilterSystemBase("cfx", conf.TTL, conf.UpstreamGC, conf.Registry, conf.AuthToken, vfls, shutdownCtx),
 	}
 }
 
@@ -39,7 +39,8 @@ func (fs *cfxFilterSystem) newBlockFilter(client *sdk.Client) (rpc.ID, error) {
 		return nilRpcId, err
 	}
 
-	fs.filterMgr.add(f)
+	fs.addFilter(f)
+	fs.upstreams.track(f.nodeName(), f.fid(), client.Filter().UninstallFilter)
 	return f.fid(), nil
 }
 
@@ -49,7 +50,8 @@ func (fs *cfxFilterSystem) newPendingTransactionFilter(client *sdk.Client) (rpc.
 		return nilRpcId, err
 	}
 
-	fs.filterMgr.add(f)
+	fs.addFilter(f)
+	fs.upstreams.track(f.nodeName(), f.fid(), client.Filter().UninstallFilter)
 	return f.fid(), nil
 }
 
@@ -57,7 +59,7 @@ func (fs *cfxFilterSystem) newFilter(client *sdk.Client, crit types.LogFilter) (
 	nodeName := rpcutil.Url2NodeName(client.GetNodeURL())
 	worker, _ := fs.workers.LoadOrStoreFn(nodeName, func(k interface{}) interface{} {
 		return newCfxFilterWorker(
-			fs.conf.MaxFullFilterEpochs, fs, client, fs.shutdownCtx,
+			fs.conf.MaxFullFilterEpochs, fs, client, fs.upstreams, fs.conf.Polling, fs.shutdownCtx,
 		)
 	})
 
@@ -66,7 +68,7 @@ func (fs *cfxFilterSystem) newFilter(client *sdk.Client, crit types.LogFilter) (
 		return nilRpcId, err
 	}
 
-	fs.filterMgr.add(f)
+	fs.addFilter(f)
 	return f.fid(), nil
 }
 
@@ -81,13 +83,13 @@ func (fs *cfxFilterSystem) getFilterChanges(id rpc.ID) (*types.CfxFilterChanges,
 		return nil, err
 	}
 
-	fs.filterMgr.refresh(id)
+	fs.refresh(id)
 	return fc.(*types.CfxFilterChanges), nil
 }
 
 func (fs *cfxFilterSystem) uninstallFilter(id rpc.ID) (bool, error) {
 	if vf, ok := fs.filterMgr.delete(id); ok {
-		return vf.uninstall()
+		return fs.uninstall(vf)
 	}
 
 	return true, nil
@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	citypes "github.com/Conflux-Chain/confura/types"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	cfxtypes "github.com/Conflux-Chain/go-conflux-sdk/types"
 	"github.com/Conflux-Chain/go-conflux-util/viper"
 	"github.com/openweb3/go-rpc-provider"
@@ -13,34 +15,36 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-const (
-	defaultClientRetryCount     = 0
-	defaultClientRetryInterval  = 1 * time.Second
-	defaultClientRequestTimeout = 3 * time.Second
-)
-
 type clientConfig struct {
-	Enabled       bool
+	Enabled bool
+	// internal RPC URL of the standalone virtual filter service
 	ServiceRpcUrl string
+	// shared bearer token to authenticate requests to virtual filter service if configured
+	AuthToken string
+	// request timeout, which is also bounded by the deadline of RPC request context
+	RequestTimeout time.Duration `default:"3s"`
+	RetryCount     int
+	RetryInterval  time.Duration `default:"1s"`
 }
 
-type EthClient struct {
-	// underlying rpc client provider to request virtual filter service
-	p interfaces.Provider
-}
-
-func MustNewEthClientFromViper() (*EthClient, bool) {
+// mustNewProviderFromViper creates RPC provider to request the standalone virtual filter service
+// from the client settings of the specified viper key, or false if not enabled.
+func mustNewProviderFromViper(key string) (interfaces.Provider, bool) {
 	var conf clientConfig
-	viper.MustUnmarshalKey("ethVirtualFilters.client", &conf)
+	viper.MustUnmarshalKey(key, &conf)
 
 	if !conf.Enabled {
 		return nil, false
 	}
 
+	if len(conf.AuthToken) > 0 {
+		rpcutil.MustRegisterBearerCredential(conf.ServiceRpcUrl, conf.AuthToken)
+	}
+
 	option := providers.Option{
-		RetryCount:     defaultClientRetryCount,
-		RetryInterval:  defaultClientRetryInterval,
-		RequestTimeout: defaultClientRequestTimeout,
+		RetryCount:     conf.RetryCount,
+		RetryInterval:  conf.RetryInterval,
+		RequestTimeout: conf.RequestTimeout,
 	}
 
 	p, err := providers.NewProviderWithOption(conf.ServiceRpcUrl, option)
@@ -50,36 +54,70 @@ func MustNewEthClientFromViper() (*EthClient, bool) {
 			Fatal("Failed to create RPC provider for virtual filter client")
 	}
 
+	return p, true
+}
+
+type EthClient struct {
+	// underlying rpc client provider to request virtual filter service
+	p interfaces.Provider
+}
+
+func MustNewEthClientFromViper() (*EthClient, bool) {
+	p, ok := mustNewProviderFromViper("ethVirtualFilters.client")
+	if !ok {
+		return nil, false
+	}
+
 	return &EthClient{p: p}, true
 }
 
-func (client *EthClient) NewFilter(delFnUrl string, fq *ethtypes.FilterQuery) (val *rpc.ID, err error) {
-	err = client.p.CallContext(context.Background(), &val, "eth_newFilter", delFnUrl, fq)
+func (client *EthClient) NewFilter(ctx context.Context, delFnUrl string, fq *ethtypes.FilterQuery) (val *rpc.ID, err error) {
+	err = client.p.CallContext(ctx, &val, "eth_newFilter", delFnUrl, fq)
 	return
 }
 
-func (client *EthClient) NewBlockFilter(delFnUrl string) (val *rpc.ID, err error) {
-	err = client.p.CallContext(context.Background(), &val, "eth_newBlockFilter", delFnUrl)
+func (client *EthClient) NewBlockFilter(
+	ctx context.Context, delFnUrl string, extMode ...citypes.FilterExtMode,
+) (val *rpc.ID, err error) {
+	err = client.p.CallContext(ctx, &val, "eth_newBlockFilter", filterArgs(delFnUrl, extMode)...)
 	return
 }
 
-func (client *EthClient) NewPendingTransactionFilter(delFnUrl string) (val *rpc.ID, err error) {
-	err = client.p.CallContext(context.Background(), &val, "eth_newPendingTransactionFilter", delFnUrl)
+func (client *EthClient) NewPendingTransactionFilter(
+	ctx context.Context, delFnUrl string, extMode ...citypes.FilterExtMode,
+) (val *rpc.ID, err error) {
+	err = client.p.CallContext(ctx, &val, "eth_newPendingTransactionFilter", filterArgs(delFnUrl, extMode)...)
 	return
 }
 
-func (client *EthClient) GetFilterChanges(filterID rpc.ID) (val *ethtypes.FilterChanges, err error) {
-	err = client.p.CallContext(context.Background(), &val, "eth_getFilterChanges", filterID)
+// GetFilterExtMode returns the extension mode of block or pending transaction filter, which is
+// shared among all the RPC proxy instances.
+func (client *EthClient) GetFilterExtMode(ctx context.Context, filterID rpc.ID) (val citypes.FilterExtMode, err error) {
+	err = client.p.CallContext(ctx, &val, "eth_getFilterExtMode", filterID)
 	return
 }
 
-func (client *EthClient) GetLogFilter(filterID rpc.ID) (val *ethtypes.FilterQuery, err error) {
-	err = client.p.CallContext(context.Background(), &val, "eth_getLogFilter", filterID)
+// filterArgs appends the extension mode (if any) to the arguments of filter creation.
+func filterArgs(delFnUrl string, extMode []citypes.FilterExtMode) []interface{} {
+	if len(extMode) == 0 || extMode[0] == citypes.FilterExtModeNone {
+		return []interface{}{delFnUrl}
+	}
+
+	return []interface{}{delFnUrl, extMode[0]}
+}
+
+func (client *EthClient) GetFilterChanges(ctx context.Context, filterID rpc.ID) (val *ethtypes.FilterChanges, err error) {
+	err = client.p.CallContext(ctx, &val, "eth_getFilterChanges", filterID)
+	return
+}
+
+func (client *EthClient) GetLogFilter(ctx context.Context, filterID rpc.ID) (val *ethtypes.FilterQuery, err error) {
+	err = client.p.CallContext(ctx, &val, "eth_getLogFilter", filterID)
 	return
 }
 
-func (client *EthClient) UninstallFilter(filterID rpc.ID) (val bool, err error) {
-	err = client.p.CallContext(context.Background(), &val, "eth_uninstallFilter", filterID)
+func (client *EthClient) UninstallFilter(ctx context.Context, filterID rpc.ID) (val bool, err error) {
+	err = client.p.CallContext(ctx, &val, "eth_uninstallFilter", filterID)
 	return
 }
 
@@ -89,55 +127,40 @@ type CfxClient struct {
 }
 
 func MustNewCfxClientFromViper() (*CfxClient, bool) {
-	var conf clientConfig
-	viper.MustUnmarshalKey("virtualFilters.client", &conf)
-
-	if !conf.Enabled {
+	p, ok := mustNewProviderFromViper("virtualFilters.client")
+	if !ok {
 		return nil, false
 	}
 
-	option := providers.Option{
-		RetryCount:     defaultClientRetryCount,
-		RetryInterval:  defaultClientRetryInterval,
-		RequestTimeout: defaultClientRequestTimeout,
-	}
-
-	p, err := providers.NewProviderWithOption(conf.ServiceRpcUrl, option)
-	if err != nil {
-		logrus.WithError(err).
-			WithField("serviceRpcUrl", conf.ServiceRpcUrl).
-			Fatal("Failed to create RPC provider for virtual filter client")
-	}
-
 	return &CfxClient{p: p}, true
 }
 
-func (client *CfxClient) NewFilter(delFnUrl string, filterCrit *cfxtypes.LogFilter) (val *rpc.ID, err error) {
-	err = client.p.CallContext(context.Background(), &val, "cfx_newFilter", delFnUrl, filterCrit)
+func (client *CfxClient) NewFilter(ctx context.Context, delFnUrl string, filterCrit *cfxtypes.LogFilter) (val *rpc.ID, err error) {
+	err = client.p.CallContext(ctx, &val, "cfx_newFilter", delFnUrl, filterCrit)
 	return
 }
 
-func (client *CfxClient) NewBlockFilter(delFnUrl string) (val *rpc.ID, err error) {
-	err = client.p.CallContext(context.Background(), &val, "cfx_newBlockFilter", delFnUrl)
+func (client *CfxClient) NewBlockFilter(ctx context.Context, delFnUrl string) (val *rpc.ID, err error) {
+	err = client.p.CallContext(ctx, &val, "cfx_newBlockFilter", delFnUrl)
 	return
 }
 
-func (client *CfxClient) NewPendingTransactionFilter(delFnUrl string) (val *rpc.ID, err error) {
-	err = client.p.CallContext(context.Background(), &val, "cfx_newPendingTransactionFilter", delFnUrl)
+func (client *CfxClient) NewPendingTransactionFilter(ctx context.Context, delFnUrl string) (val *rpc.ID, err error) {
+	err = client.p.CallContext(ctx, &val, "cfx_newPendingTransactionFilter", delFnUrl)
 	return
 }
 
-func (client *CfxClient) GetFilterChanges(filterID rpc.ID) (val *cfxtypes.CfxFilterChanges, err error) {
-	err = client.p.CallContext(context.Background(), &val, "cfx_getFilterChanges", filterID)
+func (client *CfxClient) GetFilterChanges(ctx context.Context, filterID rpc.ID) (val *cfxtypes.CfxFilterChanges, err error) {
+	err = client.p.CallContext(ctx, &val, "cfx_getFilterChanges", filterID)
 	return
 }
 
-func (client *CfxClient) GetLogFilter(filterID rpc.ID) (val *cfxtypes.LogFilter, err error) {
-	err = client.p.CallContext(context.Background(), &val, "cfx_getLogFilter", filterID)
+func (client *CfxClient) GetLogFilter(ctx context.Context, filterID rpc.ID) (val *cfxtypes.LogFilter, err error) {
+	err = client.p.CallContext(ctx, &val, "cfx_getLogFilter", filterID)
 	return
 }
 
-func (client *CfxClient) UninstallFilter(filterID rpc.ID) (val bool, err error) {
-	err = client.p.CallContext(context.Background(), &val, "cfx_uninstallFilter", filterID)
+func (client *CfxClient) UninstallFilter(ctx context.Context, filterID rpc.ID) (val bool, err error) {
+	err = client.p.CallContext(ctx, &val, "cfx_uninstallFilter", filterID)
 	return
 }
@@ -0,0 +1,151 @@
+package virtualfilter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/sirupsen/logrus"
+)
+
+// clientPoolConfig represents the configuration of full node client pool for virtual filters.
+type clientPoolConfig struct {
+	// max connections per full node
+	MaxConnsPerHost int `default:"64"`
+	// duration to close idle keep-alive connections to full node
+	MaxIdleConnDuration time.Duration `default:"30s"`
+	// interval to health check the pooled clients
+	PingInterval time.Duration `default:"10s"`
+	// max continuous health check failures before client evicted
+	MaxPingFailures int `default:"3"`
+}
+
+// pooledClient is a full node client managed by client pool.
+type pooledClient struct {
+	url      string
+	client   interface{}
+	failures int // continuous health check failures
+}
+
+// fnClientPool manages full node clients for virtual filters, and evicts the dead ones by
+// periodic health check.
+type fnClientPool struct {
+	space   string
+	conf    clientPoolConfig
+	clients util.ConcurrentMap // node name => *pooledClient
+	mu      sync.Mutex         // health check lock
+
+	newFn   func(url string, conf clientPoolConfig) (interface{}, error) // creates client
+	pingFn  func(client interface{}) error                               // health checks client
+	closeFn func(client interface{})                                     // closes client
+
+	// hook fired when client evicted
+	onEvicted func(nodeName string)
+}
+
+func newFnClientPool(
+	space string,
+	conf clientPoolConfig,
+	newFn func(url string, conf clientPoolConfig) (interface{}, error),
+	pingFn func(client interface{}) error,
+	closeFn func(client interface{}),
+	onEvicted func(nodeName string),
+) *fnClientPool {
+	pool := &fnClientPool{
+		space:     space,
+		conf:      conf,
+		newFn:     newFn,
+		pingFn:    pingFn,
+		closeFn:   closeFn,
+		onEvicted: onEvicted,
+	}
+
+	go pool.healthCheckLoop()
+
+	return pool
+}
+
+// get loads pooled client or creates a new one for the specified full node.
+func (p *fnClientPool) get(nodeUrl string) (interface{}, error) {
+	nodeName := rpcutil.Url2NodeName(nodeUrl)
+	v, _, err := p.clients.LoadOrStoreFnErr(nodeName, func(interface{}) (interface{}, error) {
+		client, err := p.newFn(nodeUrl, p.conf)
+		if err != nil {
+			logrus.WithField("fnNodeUrl", nodeUrl).
+				WithError(err).
+				Errorf("Failed to new %v client for virtual filter", p.space)
+			return nil, err
+		}
+
+		return &pooledClient{url: nodeUrl, client: client}, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*pooledClient).client, nil
+}
+
+func (p *fnClientPool) healthCheckLoop() {
+	ticker := time.NewTicker(p.conf.PingInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		p.healthCheck()
+	}
+}
+
+// healthCheck pings all pooled clients, and evicts the dead ones.
+func (p *fnClientPool) healthCheck() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var size int64
+
+	p.clients.Range(func(key, value interface{}) bool {
+		size++
+
+		nodeName, pc := key.(string), value.(*pooledClient)
+		err := p.pingFn(pc.client)
+		if err == nil {
+			pc.failures = 0
+			return true
+		}
+
+		pc.failures++
+
+		logrus.WithFields(logrus.Fields{
+			"space":    p.space,
+			"nodeName": nodeName,
+			"failures": pc.failures,
+		}).WithError(err).Info("Virtual filter client pool failed to ping full node")
+
+		if pc.failures >= p.conf.MaxPingFailures {
+			p.evict(nodeName, pc)
+			size--
+		}
+
+		return true
+	})
+
+	metrics.Registry.VirtualFilter.ClientPoolSize(p.space).Update(size)
+}
+
+func (p *fnClientPool) evict(nodeName string, pc *pooledClient) {
+	p.clients.Delete(nodeName)
+
+	if p.onEvicted != nil {
+		p.onEvicted(nodeName)
+	}
+
+	p.closeFn(pc.client)
+	metrics.Registry.VirtualFilter.ClientPoolEvictions(p.space).Mark(1)
+
+	logrus.WithFields(logrus.Fields{
+		"space":    p.space,
+		"nodeName": nodeName,
+	}).Warn("Virtual filter client pool evicted dead full node client")
+}
@@ -0,0 +1,153 @@
+package virtualfilter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	cmdutil "github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPooledClient struct {
+	pingErr error
+	closed  bool
+}
+
+func newMockFnClientPool(evicted *[]string) *fnClientPool {
+	conf := clientPoolConfig{MaxConnsPerHost: 8, PingInterval: time.Hour, MaxPingFailures: 2}
+
+	return newFnClientPool(
+		"eth", conf,
+		func(url string, conf clientPoolConfig) (interface{}, error) {
+			return &mockPooledClient{}, nil
+		},
+		func(client interface{}) error { return client.(*mockPooledClient).pingErr },
+		func(client interface{}) { client.(*mockPooledClient).closed = true },
+		func(nodeName string) { *evicted = append(*evicted, nodeName) },
+	)
+}
+
+func TestFnClientPoolGet(t *testing.T) {
+	var evicted []string
+	pool := newMockFnClientPool(&evicted)
+
+	v0, err := pool.get("http://node0:8545")
+	assert.NoError(t, err)
+
+	v1, err := pool.get("http://node1:8545")
+	assert.NoError(t, err)
+	assert.NotSame(t, v0, v1)
+
+	// reused for the same full node
+	v, err := pool.get("http://node0:8545")
+	assert.NoError(t, err)
+	assert.Same(t, v0, v)
+}
+
+func TestFnClientPoolPingRecovered(t *testing.T) {
+	var evicted []string
+	pool := newMockFnClientPool(&evicted)
+
+	v, err := pool.get("http://node0:8545")
+	assert.NoError(t, err)
+
+	client := v.(*mockPooledClient)
+	for i := 0; i < 3; i++ {
+		client.pingErr = errors.New("connection refused")
+		pool.healthCheck()
+
+		client.pingErr = nil
+		pool.healthCheck()
+	}
+
+	assert.Empty(t, evicted)
+	assert.False(t, client.closed)
+}
+
+func TestFnClientPoolEviction(t *testing.T) {
+	var evicted []string
+	pool := newMockFnClientPool(&evicted)
+
+	v, err := pool.get("http://node0:8545")
+	assert.NoError(t, err)
+
+	client := v.(*mockPooledClient)
+	client.pingErr = errors.New("connection refused")
+
+	pool.healthCheck()
+	assert.Empty(t, evicted)
+	assert.False(t, client.closed)
+
+	pool.healthCheck()
+	assert.Equal(t, []string{"node0:8545"}, evicted)
+	assert.True(t, client.closed)
+
+	// reconnect for the next request
+	v, err = pool.get("http://node0:8545")
+	assert.NoError(t, err)
+	assert.NotSame(t, client, v)
+}
+
+type mockPollingClient struct {
+	mu          sync.Mutex
+	uninstalled []rpc.ID
+}
+
+func (c *mockPollingClient) establish() (pollingSession, error) {
+	return nilPollingSession, errors.New("not supported")
+}
+
+func (c *mockPollingClient) fetch(fid rpc.ID) (filterChanges, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *mockPollingClient) uninstall(fid rpc.ID) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.uninstalled = append(c.uninstalled, fid)
+	return true, nil
+}
+
+func (c *mockPollingClient) uninstalledFids() []rpc.ID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.uninstalled
+}
+
+func TestResetWorkerStopsPolling(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	shutdownCtx := cmdutil.GracefulShutdownContext{Ctx: ctx, Wg: &sync.WaitGroup{}}
+	polling := pollingConfig{MinInterval: time.Hour, MaxInterval: time.Hour}
+
+	client := &mockPollingClient{}
+	worker := newFilterWorker("eth", "node0", client, nil, polling, shutdownCtx)
+
+	// polling session established with a delegate virtual filter
+	worker.session = *newPollingSession("0x1", nil)
+	worker.session.fcursors["0x2"] = nilFilterCursor
+	go worker.poll()
+
+	fs := &filterSystemBase{}
+	fs.workers.Store("node0", worker)
+	fs.resetWorker("node0")
+
+	_, ok := fs.workers.Load("node0")
+	assert.False(t, ok)
+
+	// delegate filter on full node uninstalled once worker stopped
+	assert.Eventually(t, func() bool {
+		return len(client.uninstalledFids()) > 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []rpc.ID{"0x1"}, client.uninstalledFids())
+
+	// stopped worker won't accept delegates any more
+	assert.ErrorIs(t, worker.accept(nil, ""), errFilterWorkerShutdown)
+}
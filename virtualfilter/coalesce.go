@@ -0,0 +1,156 @@
+package virtualfilter
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	lru "github.com/hashicorp/golang-lru"
+	ethtypes "github.com/openweb3/web3go/types"
+)
+
+const (
+	// max number of changed blocks (or epochs) to memoize matched event logs per filter group
+	maxFilterGroupMatchedBlocks = 100
+)
+
+// filterGroup coalesces the virtual log filters with identical normalized criteria on the same
+// filter worker. All of them share the delegate filter of polling session, and the matched event
+// logs of each changed block are loaded and filtered only once and then multiplexed to all the
+// member filters, each of which still tracks its own filter cursor.
+type filterGroup struct {
+	key  string // normalized filter criteria
+	refs int    // number of member filters, guarded by the filter worker lock
+
+	// memoized matched event logs keyed by block hash (and revert flag)
+	matched *lru.Cache
+}
+
+func newFilterGroup(key string) *filterGroup {
+	cache, _ := lru.New(maxFilterGroupMatchedBlocks)
+	return &filterGroup{key: key, matched: cache}
+}
+
+// load returns the memoized matched event logs of the changed block.
+func (g *filterGroup) load(blockKey string) (interface{}, bool) {
+	if g == nil {
+		return nil, false
+	}
+
+	return g.matched.Get(blockKey)
+}
+
+// store memoizes the matched event logs of the changed block.
+func (g *filterGroup) store(blockKey string, logs interface{}) {
+	if g != nil {
+		g.matched.Add(blockKey, logs)
+	}
+}
+
+// groupKey returns the key to memoize matched event logs of the filter block within filter group.
+func (b *ethFilterBlock) groupKey() string {
+	return b.blockHash.String() + ":" + strconv.FormatBool(b.reverted)
+}
+
+// groupKey returns the key to memoize matched event logs of the filter epoch within filter group.
+func (fe *cfxFilterEpoch) groupKey() string {
+	return fe.blockHash.String()
+}
+
+// ethCritKey returns the normalized key of evm space log filter criteria, so that the criteria
+// with the same block range, address set and topic sets per position are regarded identical.
+func ethCritKey(crit *ethtypes.FilterQuery) string {
+	var bounds []string
+	for _, bn := range []*ethtypes.BlockNumber{crit.FromBlock, crit.ToBlock} {
+		if bn != nil {
+			bounds = append(bounds, strconv.FormatInt(bn.Int64(), 10))
+		} else {
+			bounds = append(bounds, "")
+		}
+	}
+
+	addrs := make([]string, 0, len(crit.Addresses))
+	for _, addr := range crit.Addresses {
+		addrs = append(addrs, addr.Hex())
+	}
+
+	topics := make([][]string, 0, len(crit.Topics))
+	for _, hashes := range crit.Topics {
+		var ts []string
+		for _, h := range hashes {
+			ts = append(ts, h.Hex())
+		}
+
+		topics = append(topics, ts)
+	}
+
+	return normalizeCritKey(bounds, addrs, topics)
+}
+
+// cfxCritKey returns the normalized key of core space log filter criteria, which only takes the
+// fields used to match event logs of changed epochs into account.
+func cfxCritKey(crit *types.LogFilter) string {
+	var bounds []string
+	for _, epoch := range []*types.Epoch{crit.FromEpoch, crit.ToEpoch} {
+		if epoch != nil {
+			bounds = append(bounds, epoch.String())
+		} else {
+			bounds = append(bounds, "")
+		}
+	}
+
+	addrs := make([]string, 0, len(crit.Address))
+	for _, addr := range crit.Address {
+		addrs = append(addrs, addr.GetHexAddress())
+	}
+
+	topics := make([][]string, 0, len(crit.Topics))
+	for _, hashes := range crit.Topics {
+		var ts []string
+		for _, h := range hashes {
+			ts = append(ts, h.String())
+		}
+
+		topics = append(topics, ts)
+	}
+
+	return normalizeCritKey(bounds, addrs, topics)
+}
+
+// normalizeCritKey builds the key of filter criteria, which is insensitive to letter cases, the
+// orders and duplicates of addresses or topics within the same position, and trailing wildcards.
+func normalizeCritKey(bounds, addrs []string, topics [][]string) string {
+	// trailing wildcard topics match anything
+	for len(topics) > 0 && len(topics[len(topics)-1]) == 0 {
+		topics = topics[:len(topics)-1]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(strings.Join(bounds, ",")))
+
+	sb.WriteString("|")
+	sb.WriteString(normalizeSet(addrs))
+
+	for _, ts := range topics {
+		sb.WriteString("|")
+		sb.WriteString(normalizeSet(ts))
+	}
+
+	return sb.String()
+}
+
+func normalizeSet(items []string) string {
+	set := make(map[string]bool, len(items))
+	for _, v := range items {
+		set[strings.ToLower(v)] = true
+	}
+
+	result := make([]string, 0, len(set))
+	for v := range set {
+		result = append(result, v)
+	}
+
+	sort.Strings(result)
+	return strings.Join(result, ",")
+}
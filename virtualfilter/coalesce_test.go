@@ -0,0 +1,70 @@
+package virtualfilter
+
+import (
+	"testing"
+
+	cmdutil "github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEthCritKey(t *testing.T) {
+	addr1 := common.HexToAddress("0x8888888888888888888888888888888888888888")
+	addr2 := common.HexToAddress("0x9999999999999999999999999999999999999999")
+	topic1 := common.HexToHash("0x01")
+	topic2 := common.HexToHash("0x02")
+	latest := types.LatestBlockNumber
+
+	crit := types.FilterQuery{
+		Addresses: []common.Address{addr1, addr2},
+		Topics:    [][]common.Hash{{topic1, topic2}, nil},
+	}
+
+	// identical regardless of orders, duplicates and trailing wildcards
+	assert.Equal(t, ethCritKey(&crit), ethCritKey(&types.FilterQuery{
+		Addresses: []common.Address{addr2, addr1, addr2},
+		Topics:    [][]common.Hash{{topic2, topic1}},
+	}))
+
+	// different block range
+	assert.NotEqual(t, ethCritKey(&crit), ethCritKey(&types.FilterQuery{
+		FromBlock: &latest,
+		Addresses: []common.Address{addr1, addr2},
+		Topics:    [][]common.Hash{{topic1, topic2}},
+	}))
+
+	// different topic position
+	assert.NotEqual(t, ethCritKey(&crit), ethCritKey(&types.FilterQuery{
+		Addresses: []common.Address{addr1, addr2},
+		Topics:    [][]common.Hash{nil, {topic1, topic2}},
+	}))
+
+	// different addresses
+	assert.NotEqual(t, ethCritKey(&crit), ethCritKey(&types.FilterQuery{
+		Addresses: []common.Address{addr1},
+		Topics:    [][]common.Hash{{topic1, topic2}},
+	}))
+}
+
+func TestFilterWorkerGroups(t *testing.T) {
+	w := newFilterWorker("eth", "node", nil, nil, pollingConfig{}, cmdutil.GracefulShutdownContext{})
+	w.session = *newPollingSession("0x1", newEthFilterChain(10))
+
+	f1 := newEthFilter("0xa", filterTypeLog, nil)
+	f2 := newEthFilter("0xb", filterTypeLog, nil)
+
+	assert.NoError(t, w.accept(f1, "key"))
+	assert.NoError(t, w.accept(f2, "key"))
+	assert.Len(t, w.session.groups, 1)
+	assert.Same(t, w.session.fgroups[f1.fid()], w.session.fgroups[f2.fid()])
+
+	ok, _ := w.reject(f1)
+	assert.True(t, ok)
+	assert.Len(t, w.session.groups, 1)
+
+	ok, _ = w.reject(f2)
+	assert.True(t, ok)
+	assert.Empty(t, w.session.groups)
+	assert.Empty(t, w.session.fgroups)
+}
@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 // ethConfig represents the configuration of the EVM space virtual filter system.
@@ -11,14 +13,71 @@ type ethConfig struct {
 	Endpoint string        `default:":48545"` // server listening endpoint (default: :48545)
 	TTL      time.Duration `default:"1m"`     // how long filters stay active (default: 1min)
 
+	// shared bearer token to authenticate internal RPC requests from RPC gateways and peer
+	// instances, disabled if empty
+	AuthToken string
+
 	// max number of filter blocks full of event logs to restrict memory usage (default: 100)
 	MaxFullFilterBlocks int `default:"100"`
+
+	// adaptive interval settings to poll filter changes
+	Polling pollingConfig
+
+	// whether to poll filter changes from the synced evm space database rather than full nodes
+	FromStore bool
+	// max number of blocks to poll from database at a time (default: 100)
+	MaxStorePollBlocks uint64 `default:"100"`
+	// whether to poll filter changes from the synced evm space database for full nodes with filter
+	// API disabled, which are probed when client created (default: true)
+	StoreFallback bool `default:"true"`
+
+	// cursor based change tracking settings of log filters
+	Cursor cursorConfig
+
+	// full node client pool settings
+	ClientPool clientPoolConfig
+
+	// garbage collection settings of delegate filters on full nodes
+	UpstreamGC upstreamGCConfig
+
+	// shared filter registry settings to scale out with multiple instances
+	Registry registryConfig
+
+	// gRPC streaming service settings
+	Stream streamConfig
+}
+
+// upstreamGCConfig is the garbage collection settings of delegate filters on full nodes.
+type upstreamGCConfig struct {
+	// interval to retry failed uninstalls and audit orphaned delegate filters (default: 1min)
+	Interval time.Duration `default:"1m"`
+	// max number of retries to uninstall delegate filter on full node (default: 5)
+	MaxRetries int `default:"5"`
+	// grace period before delegate filter without owner regarded as orphaned (default: 1min)
+	OrphanGracePeriod time.Duration `default:"1m"`
+}
+
+// ttlConfig is the hot-reloadable filter TTL.
+type ttlConfig struct {
+	TTL time.Duration `default:"1m"`
+}
+
+func (c ttlConfig) validate() error {
+	if c.TTL <= time.Second {
+		return errors.New("filter TTL must be greater than 1 second")
+	}
+
+	return nil
 }
 
 func mustNewEthConfigFromViper() *ethConfig {
 	var conf ethConfig
 	viper.MustUnmarshalKey("ethVirtualFilters", &conf)
 
+	if err := conf.Polling.validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid polling config of evm space virtual filters")
+	}
+
 	return &conf
 }
 
@@ -27,13 +86,36 @@ type cfxConfig struct {
 	Endpoint string        `default:":42537"` // server listening endpoint (default: :42537)
 	TTL      time.Duration `default:"1m"`     // how long filters stay active (default: 1min)
 
+	// shared bearer token to authenticate internal RPC requests from RPC gateways and peer
+	// instances, disabled if empty
+	AuthToken string
+
 	// max number of filter epochs full of event logs to restrict memory usage (default: 100)
 	MaxFullFilterEpochs int `default:"100"`
+
+	// adaptive interval settings to poll filter changes
+	Polling pollingConfig
+
+	// full node client pool settings
+	ClientPool clientPoolConfig
+
+	// garbage collection settings of delegate filters on full nodes
+	UpstreamGC upstreamGCConfig
+
+	// shared filter registry settings to scale out with multiple instances
+	Registry registryConfig
+
+	// gRPC streaming service settings
+	Stream streamConfig
 }
 
 func mustNewCfxConfigFromViper() *cfxConfig {
 	var conf cfxConfig
 	viper.MustUnmarshalKey("virtualFilters", &conf)
 
+	if err := conf.Polling.validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid polling config of core space virtual filters")
+	}
+
 	return &conf
 }
@@ -2,11 +2,10 @@ package virtualfilter
 
 import (
 	"github.com/Conflux-Chain/confura/node"
-	"github.com/Conflux-Chain/confura/util"
+	citypes "github.com/Conflux-Chain/confura/types"
 	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
 	w3rpc "github.com/openweb3/go-rpc-provider"
 	"github.com/openweb3/web3go/types"
-	"github.com/sirupsen/logrus"
 )
 
 var (
@@ -16,33 +15,96 @@ var (
 // EVM space filter API
 
 type ethFilterApi struct {
-	fs        *ethFilterSystem   // filter system
-	fnClients util.ConcurrentMap // full node clients: node name => sdk client
+	fs        *ethFilterSystem // filter system
+	fnClients *fnClientPool    // full node client pool
 }
 
 func newEthFilterApi(sys *ethFilterSystem) *ethFilterApi {
-	return &ethFilterApi{fs: sys}
+	fnClients := newFnClientPool(
+		"eth", sys.conf.ClientPool,
+		func(url string, conf clientPoolConfig) (interface{}, error) {
+			client, err := rpcutil.NewEthClient(
+				url,
+				rpcutil.WithClientHookMetrics(true),
+				rpcutil.WithClientMaxConnsPerHost(conf.MaxConnsPerHost),
+				rpcutil.WithClientMaxIdleConnDuration(conf.MaxIdleConnDuration),
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			w3c := &node.Web3goClient{Client: client, URL: url}
+			sys.probeCapability(w3c)
+
+			return w3c, nil
+		},
+		func(client interface{}) error {
+			_, err := client.(*node.Web3goClient).Eth.BlockNumber()
+			return err
+		},
+		func(client interface{}) {
+			client.(*node.Web3goClient).Provider().Close()
+		},
+		// reset filter worker and capability so that the new client will be used and re-probed
+		func(nodeName string) {
+			sys.resetWorker(nodeName)
+			sys.capabilities.Delete(nodeName)
+		},
+	)
+
+	return &ethFilterApi{fs: sys, fnClients: fnClients}
 }
 
-func (api *ethFilterApi) NewBlockFilter(nodeUrl string) (w3rpc.ID, error) {
+// NewBlockFilter creates a block filter, along with the optional extension mode persisted to be
+// shared among RPC proxy instances.
+func (api *ethFilterApi) NewBlockFilter(nodeUrl string, extMode *citypes.FilterExtMode) (w3rpc.ID, error) {
 	client, err := api.loadOrGetFnClient(nodeUrl)
 	if err != nil {
 		return nilRpcId, err
 	}
 
-	return api.fs.newBlockFilter(client)
+	return api.fs.newBlockFilter(client, derefFilterExtMode(extMode))
 }
 
-func (api *ethFilterApi) NewPendingTransactionFilter(nodeUrl string) (w3rpc.ID, error) {
+// NewPendingTransactionFilter creates a pending transaction filter, along with the optional
+// extension mode persisted to be shared among RPC proxy instances.
+func (api *ethFilterApi) NewPendingTransactionFilter(nodeUrl string, extMode *citypes.FilterExtMode) (w3rpc.ID, error) {
 	client, err := api.loadOrGetFnClient(nodeUrl)
 	if err != nil {
 		return nilRpcId, err
 	}
 
-	return api.fs.newPendingTransactionFilter(client)
+	return api.fs.newPendingTransactionFilter(client, derefFilterExtMode(extMode))
+}
+
+// GetFilterExtMode returns the extension mode of block or pending transaction filter.
+func (api *ethFilterApi) GetFilterExtMode(fid w3rpc.ID) (citypes.FilterExtMode, error) {
+	if _, ok := api.fs.getFilter(fid); !ok {
+		var res citypes.FilterExtMode
+		if forwarded, err := api.fs.forward(fid, &res, "eth_getFilterExtMode", fid); forwarded {
+			return res, err
+		}
+	}
+
+	return api.fs.getFilterExtMode(fid)
+}
+
+func derefFilterExtMode(extMode *citypes.FilterExtMode) citypes.FilterExtMode {
+	if extMode == nil {
+		return citypes.FilterExtModeNone
+	}
+
+	return *extMode
 }
 
 func (api *ethFilterApi) UninstallFilter(id w3rpc.ID) (bool, error) {
+	if _, ok := api.fs.getFilter(id); !ok {
+		var res bool
+		if forwarded, err := api.fs.forward(id, &res, "eth_uninstallFilter", id); forwarded {
+			return res, err
+		}
+	}
+
 	return api.fs.uninstallFilter(id)
 }
 
@@ -57,32 +119,38 @@ func (api *ethFilterApi) NewFilter(nodeUrl string, crit types.FilterQuery) (w3rp
 
 func (api *ethFilterApi) GetLogFilter(fid w3rpc.ID) (*types.FilterQuery, error) {
 	vf, ok := api.fs.getFilter(fid)
+	if !ok {
+		var res *types.FilterQuery
+		if forwarded, err := api.fs.forward(fid, &res, "eth_getLogFilter", fid); forwarded {
+			return res, err
+		}
+	}
+
 	if !ok || vf.ftype() != filterTypeLog {
 		return nil, errFilterNotFound
 	}
 
+	if ethf, ok := vf.(*ethCursorLogFilter); ok {
+		return &ethf.crit, nil
+	}
+
 	ethf := vf.(*ethLogFilter)
 	return &ethf.crit, nil
 }
 
 func (api *ethFilterApi) GetFilterChanges(id w3rpc.ID) (*types.FilterChanges, error) {
+	if _, ok := api.fs.getFilter(id); !ok {
+		var res *types.FilterChanges
+		if forwarded, err := api.fs.forward(id, &res, "eth_getFilterChanges", id); forwarded {
+			return res, err
+		}
+	}
+
 	return api.fs.getFilterChanges(id)
 }
 
 func (api *ethFilterApi) loadOrGetFnClient(nodeUrl string) (*node.Web3goClient, error) {
-	nodeName := rpcutil.Url2NodeName(nodeUrl)
-	client, _, err := api.fnClients.LoadOrStoreFnErr(nodeName, func(interface{}) (interface{}, error) {
-		client, err := rpcutil.NewEthClient(nodeUrl, rpcutil.WithClientHookMetrics(true))
-		if err != nil {
-			logrus.WithField("fnNodeUrl", nodeUrl).
-				WithError(err).
-				Error("Failed to new eth client for virtual filter")
-			return nil, err
-		}
-
-		return &node.Web3goClient{Client: client, URL: nodeUrl}, nil
-	})
-
+	client, err := api.fnClients.get(nodeUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,276 @@
+package virtualfilter
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/Conflux-Chain/confura/rpc/ethbridge"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+var (
+	errEthCursorBlockNotFound = errors.New("block not found on full node")
+)
+
+// cursorConfig is the settings of cursor based change tracking for log filters.
+type cursorConfig struct {
+	// whether to compute log filter changes from the synced database by per filter cursor
+	Enabled bool
+	// max number of blocks near head to poll from full node at a time (default: 10)
+	MaxUpstreamPollBlocks uint64 `default:"10"`
+}
+
+// ethCursorLogFilter is the evm space log filter which tracks the last delivered block as cursor,
+// and computes filter changes from the synced evm space database rather than the deltas of delegate
+// filter on full node, so that the filter changes are deterministic and replayable. Blocks near
+// head that not synced into database yet are polled from full node instead.
+type ethCursorLogFilter struct {
+	*ethFilter
+
+	mu sync.Mutex
+
+	db        ethStore
+	crit      types.FilterQuery
+	networkId uint32
+	conf      *ethConfig
+
+	// recently delivered blocks in ascending order, of which the last one is the cursor
+	delivered []ethStorePolledBlock
+}
+
+func newEthCursorLogFilter(
+	conf *ethConfig,
+	db ethStore,
+	client *node.Web3goClient,
+	networkId uint32,
+	crit types.FilterQuery,
+) (*ethCursorLogFilter, error) {
+	head, err := client.Eth.BlockNumber()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get latest block number")
+	}
+
+	hash, err := upstreamBlockHash(client, head.Uint64())
+	if err != nil {
+		return nil, err
+	}
+
+	lf := &ethCursorLogFilter{
+		db:        db,
+		crit:      crit,
+		networkId: networkId,
+		conf:      conf,
+		delivered: []ethStorePolledBlock{{number: head.Uint64(), hash: hash}},
+		ethFilter: newEthFilter(rpc.NewID(), filterTypeLog, client),
+	}
+
+	metricVirtualFilterSession("eth", lf, 1)
+	return lf, nil
+}
+
+func (f *ethCursorLogFilter) uninstall() (bool, error) {
+	metricVirtualFilterSession("eth", f, -1)
+	return true, nil
+}
+
+func (f *ethCursorLogFilter) fetch() (filterChanges, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	maxSynced, synced, err := f.db.MaxEpoch()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get max synced block")
+	}
+
+	// revert the delivered blocks which are no longer canonical due to reorg
+	delivered, removedLogs, err := f.revert(maxSynced, synced)
+	if err != nil {
+		return nil, err
+	}
+
+	var newBlocks []ethStorePolledBlock
+
+	fromBlock := delivered[len(delivered)-1].number + 1
+	fromStore := synced && fromBlock <= maxSynced
+	metrics.Registry.VirtualFilter.StoreQueryPercentage("eth", f.nodeName(), "mysql").Mark(fromStore)
+
+	if fromStore {
+		toBlock := min(maxSynced, fromBlock+f.conf.MaxStorePollBlocks-1)
+		newBlocks, err = f.pollStore(fromBlock, toBlock)
+	} else {
+		newBlocks, err = f.pollUpstream(fromBlock)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	delivered = append(delivered, newBlocks...)
+	if len(delivered) > ethStoreReorgWindow {
+		delivered = delivered[len(delivered)-ethStoreReorgWindow:]
+	}
+	f.delivered = delivered
+
+	changes := &types.FilterChanges{Logs: removedLogs}
+	for i := range newBlocks {
+		changes.Logs = append(changes.Logs, newBlocks[i].logs...)
+	}
+
+	if changes.Logs == nil {
+		changes.Logs = []types.Log{}
+	}
+
+	return changes, nil
+}
+
+// canonicalHash returns the canonical block hash, from database if synced or full node otherwise.
+func (f *ethCursorLogFilter) canonicalHash(bn, maxSynced uint64, synced bool) (string, bool, error) {
+	if synced && bn <= maxSynced {
+		hash, ok, err := f.db.PivotHash(bn)
+		if err != nil {
+			return "", false, errors.WithMessage(err, "failed to get block hash")
+		}
+
+		return hash, ok, nil
+	}
+
+	hash, err := upstreamBlockHash(f.client, bn)
+	if err == errEthCursorBlockNotFound {
+		return "", false, nil
+	}
+
+	return hash, err == nil, err
+}
+
+// revert finds the reverted blocks from the cursor backwards, and returns the remaining delivered
+// blocks along with the removed event logs of reverted blocks (from the latest to oldest).
+func (f *ethCursorLogFilter) revert(maxSynced uint64, synced bool) ([]ethStorePolledBlock, []types.Log, error) {
+	var removedLogs []types.Log
+
+	for i := len(f.delivered) - 1; i >= 0; i-- {
+		hash, ok, err := f.canonicalHash(f.delivered[i].number, maxSynced, synced)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if ok && strings.EqualFold(hash, f.delivered[i].hash) {
+			return f.delivered[:i+1], removedLogs, nil
+		}
+
+		for _, log := range f.delivered[i].logs {
+			log.Removed = true
+			removedLogs = append(removedLogs, log)
+		}
+	}
+
+	return nil, nil, errEthStoreReorgTooDeep
+}
+
+// pollStore polls the matched event logs of the synced blocks within the specified range from database.
+func (f *ethCursorLogFilter) pollStore(fromBlock, toBlock uint64) ([]ethStorePolledBlock, error) {
+	startTime := time.Now()
+	defer metrics.Registry.VirtualFilter.QueryFilterChanges("eth", f.nodeName(), "mysql").UpdateSince(startTime)
+
+	hashes, err := f.db.PivotHashes(fromBlock, toBlock)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get block hashes")
+	}
+
+	// event logs within a few blocks won't be too many, so bound checks are unnecessary
+	ctx, cancel := context.WithTimeout(store.NewContextWithBoundChecksDisabled(context.Background()), store.TimeoutGetLogs)
+	defer cancel()
+
+	sfilter := store.ParseEthLogFilter(fromBlock, toBlock, &f.crit, f.networkId)
+	slogs, err := f.db.GetLogs(ctx, sfilter)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get event logs")
+	}
+
+	blockLogs := make(map[uint64][]types.Log)
+	for _, slog := range slogs {
+		log := *ethbridge.ConvertLog(slog.ToCfxLog())
+
+		// block popped and re-synced between queries?
+		if !strings.EqualFold(hashes[log.BlockNumber], log.BlockHash.String()) {
+			return nil, errEthStoreChanged
+		}
+
+		blockLogs[log.BlockNumber] = append(blockLogs[log.BlockNumber], log)
+	}
+
+	blocks := make([]ethStorePolledBlock, 0, toBlock-fromBlock+1)
+	for bn := fromBlock; bn <= toBlock; bn++ {
+		hash, ok := hashes[bn]
+		if !ok { // block popped between queries
+			return nil, errEthStoreChanged
+		}
+
+		logs := filterEthLogs(blockLogs[bn], &f.crit)
+		blocks = append(blocks, ethStorePolledBlock{number: bn, hash: hash, logs: logs})
+	}
+
+	return blocks, nil
+}
+
+// pollUpstream polls the matched event logs of the new blocks near head since the specified block
+// number from full node, which are not synced into database yet.
+func (f *ethCursorLogFilter) pollUpstream(fromBlock uint64) ([]ethStorePolledBlock, error) {
+	head, err := f.client.Eth.BlockNumber()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get latest block number")
+	}
+
+	if head.Uint64() < fromBlock {
+		return nil, nil
+	}
+
+	toBlock := min(head.Uint64(), fromBlock+f.conf.Cursor.MaxUpstreamPollBlocks-1)
+	blocks := make([]ethStorePolledBlock, 0, toBlock-fromBlock+1)
+
+	for bn := fromBlock; bn <= toBlock; bn++ {
+		block, err := f.client.Eth.BlockByNumber(types.BlockNumber(bn), false)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to get block")
+		}
+
+		if block == nil { // block reverted during polling
+			break
+		}
+
+		// query by block hash to guarantee consistency between block and event logs
+		logs, err := f.client.Eth.Logs(types.FilterQuery{
+			BlockHash: &block.Hash,
+			Addresses: f.crit.Addresses,
+			Topics:    f.crit.Topics,
+		})
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to get event logs")
+		}
+
+		logs = filterEthLogs(logs, &f.crit)
+		blocks = append(blocks, ethStorePolledBlock{number: bn, hash: block.Hash.String(), logs: logs})
+	}
+
+	return blocks, nil
+}
+
+// upstreamBlockHash returns the hash of the specified block from full node.
+func upstreamBlockHash(client *node.Web3goClient, bn uint64) (string, error) {
+	block, err := client.Eth.BlockByNumber(types.BlockNumber(bn), false)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed to get block")
+	}
+
+	if block == nil {
+		return "", errEthCursorBlockNotFound
+	}
+
+	return block.Hash.String(), nil
+}
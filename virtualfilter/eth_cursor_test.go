@@ -0,0 +1,129 @@
+package virtualfilter
+
+import (
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/node"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/web3go"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// testEthUpstream is a fake evm space full node, of which each block has one event log.
+type testEthUpstream struct {
+	mu   sync.Mutex
+	head uint64
+	fork int
+}
+
+func (u *testEthUpstream) set(head uint64, fork int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.head, u.fork = head, fork
+}
+
+func (u *testEthUpstream) BlockNumber() hexutil.Uint64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return hexutil.Uint64(u.head)
+}
+
+func (u *testEthUpstream) GetBlockByNumber(bn hexutil.Uint64, fullTx bool) (map[string]interface{}, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if uint64(bn) > u.head {
+		return nil, nil
+	}
+
+	return map[string]interface{}{
+		"hash":         testEthBlockHash(uint64(bn), u.fork),
+		"parentHash":   common.Hash{},
+		"number":       bn,
+		"difficulty":   "0x0",
+		"transactions": []common.Hash{},
+	}, nil
+}
+
+func (u *testEthUpstream) GetLogs(crit types.FilterQuery) ([]types.Log, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for bn := uint64(0); bn <= u.head; bn++ {
+		if hash := common.HexToHash(testEthBlockHash(bn, u.fork)); crit.BlockHash != nil && *crit.BlockHash == hash {
+			return []types.Log{{BlockHash: hash, BlockNumber: bn, Topics: []common.Hash{}}}, nil
+		}
+	}
+
+	return []types.Log{}, nil
+}
+
+func newTestEthCursorLogFilter(t *testing.T, db *testEthStore, upstream *testEthUpstream) *ethCursorLogFilter {
+	srv := rpc.NewServer()
+	assert.NoError(t, srv.RegisterName("eth", upstream))
+
+	httpSrv := httptest.NewServer(srv)
+	t.Cleanup(httpSrv.Close)
+
+	client, err := web3go.NewClient(httpSrv.URL)
+	assert.NoError(t, err)
+
+	conf := &ethConfig{MaxStorePollBlocks: 10, Cursor: cursorConfig{Enabled: true, MaxUpstreamPollBlocks: 10}}
+	lf, err := newEthCursorLogFilter(conf, db, &node.Web3goClient{Client: client, URL: httpSrv.URL}, 71, types.FilterQuery{})
+	assert.NoError(t, err)
+
+	return lf
+}
+
+func fetchEthCursorLogs(t *testing.T, lf *ethCursorLogFilter) []types.Log {
+	changes, err := lf.fetch()
+	assert.NoError(t, err)
+
+	return changes.(*types.FilterChanges).Logs
+}
+
+func TestEthCursorLogFilterReorg(t *testing.T) {
+	db := newTestEthStore(11)
+	upstream := &testEthUpstream{head: 10}
+	lf := newTestEthCursorLogFilter(t, db, upstream)
+
+	// blocks near head not synced into database yet are polled from full node
+	upstream.set(12, 0)
+	logs := fetchEthCursorLogs(t, lf)
+	assertEthLogs(t, []string{testEthBlockHash(11, 0), testEthBlockHash(12, 0)}, false, logs)
+
+	// blocks delivered from full node are reverted once database synced with a new pivot chain
+	db.mine(2, 1)
+	upstream.set(13, 1)
+
+	logs = fetchEthCursorLogs(t, lf)
+	assertEthLogs(t, []string{testEthBlockHash(12, 0), testEthBlockHash(11, 0)}, true, logs[:2])
+	assertEthLogs(t, []string{testEthBlockHash(11, 1), testEthBlockHash(12, 1)}, false, logs[2:])
+
+	// continue to poll from full node
+	logs = fetchEthCursorLogs(t, lf)
+	assertEthLogs(t, []string{testEthBlockHash(13, 1)}, false, logs)
+}
+
+func TestEthCursorLogFilterReplay(t *testing.T) {
+	db := newTestEthStore(11)
+	upstream := &testEthUpstream{head: 10}
+
+	// filter changes computed from the same database are deterministic
+	db.mine(5, 0)
+	expected := fetchEthCursorLogs(t, newTestEthCursorLogFilter(t, db, upstream))
+	assert.Len(t, expected, 5)
+
+	lf := newTestEthCursorLogFilter(t, db, upstream)
+	assert.Equal(t, expected, fetchEthCursorLogs(t, lf))
+
+	// nothing more to deliver
+	assert.Empty(t, fetchEthCursorLogs(t, lf))
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/Conflux-Chain/confura/node"
 	"github.com/Conflux-Chain/confura/store"
 	"github.com/Conflux-Chain/confura/store/mysql"
+	citypes "github.com/Conflux-Chain/confura/types"
 	"github.com/Conflux-Chain/confura/util"
 	"github.com/Conflux-Chain/confura/util/metrics"
 	"github.com/openweb3/go-rpc-provider"
@@ -20,7 +21,8 @@ import (
 // evm space virtual filter
 type ethFilter struct {
 	filterBase
-	client *node.Web3goClient
+	client  *node.Web3goClient
+	extMode citypes.FilterExtMode // extension mode of block or pending transaction filter
 }
 
 func newEthFilter(fid rpc.ID, typ filterType, client *node.Web3goClient) *ethFilter {
@@ -49,25 +51,27 @@ func (f *ethFilter) nodeName() string {
 	return f.client.NodeName()
 }
 
-func newEthBlockFilter(client *node.Web3goClient) (*ethFilter, error) {
+func newEthBlockFilter(client *node.Web3goClient, extMode citypes.FilterExtMode) (*ethFilter, error) {
 	fid, err := client.Filter.NewBlockFilter()
 	if err != nil {
 		return nil, err
 	}
 
 	f := newEthFilter(*fid, filterTypeBlock, client)
+	f.extMode = extMode
 	metricVirtualFilterSession("eth", f, 1)
 
 	return f, nil
 }
 
-func newEthPendingTxnFilter(client *node.Web3goClient) (*ethFilter, error) {
+func newEthPendingTxnFilter(client *node.Web3goClient, extMode citypes.FilterExtMode) (*ethFilter, error) {
 	fid, err := client.Filter.NewPendingTransactionFilter()
 	if err != nil {
 		return nil, err
 	}
 
 	f := newEthFilter(*fid, filterTypePendingTxn, client)
+	f.extMode = extMode
 	metricVirtualFilterSession("eth", f, 1)
 
 	return f, nil
@@ -94,7 +98,7 @@ func newEthLogFilter(
 		ethFilter: newEthFilter(rpc.NewID(), filterTypeLog, client),
 	}
 
-	if err := worker.accept(lf); err != nil {
+	if err := worker.accept(lf, ethCritKey(&crit)); err != nil {
 		return nil, err
 	}
 
@@ -114,11 +118,26 @@ func (f *ethLogFilter) fetch() (filterChanges, error) {
 		return nil, err
 	}
 
+	// matched event logs multiplexed from the filter group with identical criteria
+	groupLogs := make(map[int][]types.Log, len(pchanges.blocks))
+	for i, fb := range pchanges.blocks {
+		v, ok := pchanges.group.load(fb.groupKey())
+		metrics.Registry.VirtualFilter.CoalescedHitPercentage("eth", f.nodeName()).Mark(ok)
+
+		if ok {
+			groupLogs[i] = v.([]types.Log)
+		}
+	}
+
 	// distinguish filter blocks missing of event logs due to cache evict
 	var missingBlockhashes []string
 	bnMin, bnMax := uint64(math.MaxUint64), uint64(0)
 
-	for _, fb := range pchanges.blocks {
+	for i, fb := range pchanges.blocks {
+		if _, ok := groupLogs[i]; ok { // already matched by filter group
+			continue
+		}
+
 		if len(fb.logs) == 0 { // filter blocks missing of event logs
 			missingBlockhashes = append(missingBlockhashes, fb.blockHash.String())
 			bnMin, bnMax = util.MinUint64(fb.blockNum, bnMin), util.MaxUint64(fb.blockNum, bnMax)
@@ -160,13 +179,20 @@ func (f *ethLogFilter) fetch() (filterChanges, error) {
 	}
 
 	changeLogs := make([]types.Log, 0)
-	for _, fb := range pchanges.blocks {
+	for i, fb := range pchanges.blocks {
+		if logs, ok := groupLogs[i]; ok {
+			changeLogs = append(changeLogs, logs...)
+			continue
+		}
+
 		logs := fb.logs
 		if len(logs) == 0 { // load from store logs
 			logs = blockLogs[fb.blockHash.String()]
 		}
 
 		logs = filterEthLogs(logs, &f.crit)
+		pchanges.group.store(fb.groupKey(), logs)
+
 		changeLogs = append(changeLogs, logs...)
 	}
 
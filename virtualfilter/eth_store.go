@@ -0,0 +1,213 @@
+package virtualfilter
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/Conflux-Chain/confura/rpc/ethbridge"
+	"github.com/Conflux-Chain/confura/store"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// node name of the filter worker polling from evm space database
+	ethStoreNodeName = "ethdb"
+
+	// max number of recently polled blocks kept to detect reorg
+	ethStoreReorgWindow = 200
+)
+
+var (
+	errEthStoreNotSynced    = errors.New("no block synced into database yet")
+	errEthStoreReorgTooDeep = errors.New("reorg too deep to revert filter changes")
+	errEthStoreChanged      = errors.New("database changed during polling")
+)
+
+// ethStore is the synced evm space database to poll filter changes from, in which epoch number
+// is the same as block number.
+type ethStore interface {
+	MaxEpoch() (uint64, bool, error)
+	PivotHash(epoch uint64) (string, bool, error)
+	PivotHashes(epochFrom, epochTo uint64) (map[uint64]string, error)
+	GetLogs(ctx context.Context, filter store.LogFilter) ([]*store.Log, error)
+}
+
+// ethStorePolledBlock is a recently polled block along with the event logs.
+type ethStorePolledBlock struct {
+	number uint64
+	hash   string
+	logs   []types.Log
+}
+
+// ethStorePollingClient polls filter changes from the synced evm space database rather than full
+// node, so that virtual filters could be served entirely from confura's own database.
+type ethStorePollingClient struct {
+	mu sync.Mutex
+
+	db                  ethStore
+	maxPollBlocks       uint64
+	maxFullFilterBlocks int
+
+	// recently polled blocks in ascending order per polling session
+	sessions map[rpc.ID][]ethStorePolledBlock
+}
+
+func newEthStorePollingClient(db ethStore, maxPollBlocks uint64, maxFullFilterBlocks int) *ethStorePollingClient {
+	return &ethStorePollingClient{
+		db:                  db,
+		maxPollBlocks:       maxPollBlocks,
+		maxFullFilterBlocks: maxFullFilterBlocks,
+		sessions:            make(map[rpc.ID][]ethStorePolledBlock),
+	}
+}
+
+// implements `pollingClient` interface
+
+func (c *ethStorePollingClient) establish() (pollingSession, error) {
+	maxBlock, ok, err := c.db.MaxEpoch()
+	if err != nil {
+		return nilPollingSession, errors.WithMessage(err, "failed to get max synced block")
+	}
+
+	if !ok {
+		return nilPollingSession, errEthStoreNotSynced
+	}
+
+	hash, _, err := c.db.PivotHash(maxBlock)
+	if err != nil {
+		return nilPollingSession, errors.WithMessage(err, "failed to get block hash")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fid := rpc.NewID()
+	c.sessions[fid] = []ethStorePolledBlock{{number: maxBlock, hash: hash}}
+
+	session := newPollingSession(fid, newEthFilterChain(c.maxFullFilterBlocks))
+	return *session, nil
+}
+
+func (c *ethStorePollingClient) fetch(fid rpc.ID) (filterChanges, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	polled, ok := c.sessions[fid]
+	if !ok {
+		return nil, errFilterNotFound
+	}
+
+	// revert the polled blocks which have been popped from database due to reorg
+	polled, removedLogs, err := c.revert(polled)
+	if err != nil {
+		return nil, err
+	}
+
+	newBlocks, err := c.pollNewBlocks(polled[len(polled)-1].number + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	polled = append(polled, newBlocks...)
+	if len(polled) > ethStoreReorgWindow {
+		polled = polled[len(polled)-ethStoreReorgWindow:]
+	}
+	c.sessions[fid] = polled
+
+	changes := &types.FilterChanges{Logs: removedLogs}
+	for i := range newBlocks {
+		changes.Logs = append(changes.Logs, newBlocks[i].logs...)
+	}
+
+	return changes, nil
+}
+
+func (c *ethStorePollingClient) uninstall(fid rpc.ID) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.sessions[fid]
+	delete(c.sessions, fid)
+
+	return ok, nil
+}
+
+// revert finds the reverted blocks from the latest polled block backwards, and returns the remaining
+// polled blocks along with the removed event logs of reverted blocks (from the latest to oldest).
+func (c *ethStorePollingClient) revert(polled []ethStorePolledBlock) ([]ethStorePolledBlock, []types.Log, error) {
+	var removedLogs []types.Log
+
+	for i := len(polled) - 1; i >= 0; i-- {
+		hash, ok, err := c.db.PivotHash(polled[i].number)
+		if err != nil {
+			return nil, nil, errors.WithMessage(err, "failed to get block hash")
+		}
+
+		// the ancestors must be canonical too, since blocks are always synced continuously
+		if ok && strings.EqualFold(hash, polled[i].hash) {
+			return polled[:i+1], removedLogs, nil
+		}
+
+		for _, log := range polled[i].logs {
+			log.Removed = true
+			removedLogs = append(removedLogs, log)
+		}
+	}
+
+	return nil, nil, errEthStoreReorgTooDeep
+}
+
+// pollNewBlocks polls the new synced blocks since the specified block number along with event logs.
+func (c *ethStorePollingClient) pollNewBlocks(fromBlock uint64) ([]ethStorePolledBlock, error) {
+	maxBlock, ok, err := c.db.MaxEpoch()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get max synced block")
+	}
+
+	if !ok || maxBlock < fromBlock {
+		return nil, nil
+	}
+
+	toBlock := min(maxBlock, fromBlock+c.maxPollBlocks-1)
+
+	hashes, err := c.db.PivotHashes(fromBlock, toBlock)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get block hashes")
+	}
+
+	// event logs within a few blocks won't be too many, so bound checks are unnecessary
+	ctx, cancel := context.WithTimeout(store.NewContextWithBoundChecksDisabled(context.Background()), store.TimeoutGetLogs)
+	defer cancel()
+
+	slogs, err := c.db.GetLogs(ctx, store.LogFilter{BlockFrom: fromBlock, BlockTo: toBlock})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get event logs")
+	}
+
+	blockLogs := make(map[uint64][]types.Log)
+	for _, slog := range slogs {
+		log := *ethbridge.ConvertLog(slog.ToCfxLog())
+
+		// block popped and re-synced between queries?
+		if !strings.EqualFold(hashes[log.BlockNumber], log.BlockHash.String()) {
+			return nil, errEthStoreChanged
+		}
+
+		blockLogs[log.BlockNumber] = append(blockLogs[log.BlockNumber], log)
+	}
+
+	blocks := make([]ethStorePolledBlock, 0, toBlock-fromBlock+1)
+	for bn := fromBlock; bn <= toBlock; bn++ {
+		hash, ok := hashes[bn]
+		if !ok { // block popped between queries
+			return nil, errEthStoreChanged
+		}
+
+		blocks = append(blocks, ethStorePolledBlock{number: bn, hash: hash, logs: blockLogs[bn]})
+	}
+
+	return blocks, nil
+}
@@ -0,0 +1,161 @@
+package virtualfilter
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/Conflux-Chain/confura/store"
+	cfxtypes "github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// testEthStore is an in-memory evm space database, of which each block has one event log.
+type testEthStore struct {
+	mu     sync.Mutex
+	hashes []string // block number => block hash
+}
+
+func newTestEthStore(numBlocks int) *testEthStore {
+	s := &testEthStore{}
+	s.mine(numBlocks, 0)
+	return s
+}
+
+func testEthBlockHash(bn uint64, fork int) string {
+	return common.BigToHash(new(big.Int).SetUint64(bn*100 + uint64(fork))).String()
+}
+
+// mine appends blocks of the specified fork.
+func (s *testEthStore) mine(numBlocks, fork int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 0; i < numBlocks; i++ {
+		s.hashes = append(s.hashes, testEthBlockHash(uint64(len(s.hashes)), fork))
+	}
+}
+
+// reorg pops blocks since the specified block number, and mines the same number of blocks on a new fork.
+func (s *testEthStore) reorg(bn uint64, fork int) {
+	s.mu.Lock()
+	numBlocks := len(s.hashes) - int(bn)
+	s.hashes = s.hashes[:bn]
+	s.mu.Unlock()
+
+	s.mine(numBlocks, fork)
+}
+
+func (s *testEthStore) MaxEpoch() (uint64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return uint64(len(s.hashes) - 1), len(s.hashes) > 0, nil
+}
+
+func (s *testEthStore) PivotHash(epoch uint64) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if epoch >= uint64(len(s.hashes)) {
+		return "", false, nil
+	}
+
+	return s.hashes[epoch], true, nil
+}
+
+func (s *testEthStore) PivotHashes(epochFrom, epochTo uint64) (map[uint64]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hashes := make(map[uint64]string)
+	for bn := epochFrom; bn <= epochTo && bn < uint64(len(s.hashes)); bn++ {
+		hashes[bn] = s.hashes[bn]
+	}
+
+	return hashes, nil
+}
+
+func (s *testEthStore) GetLogs(ctx context.Context, filter store.LogFilter) ([]*store.Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var logs []*store.Log
+	for bn := filter.BlockFrom; bn <= filter.BlockTo && bn < uint64(len(s.hashes)); bn++ {
+		blockHash := cfxtypes.Hash(s.hashes[bn])
+		txHash := cfxtypes.Hash(common.HexToHash("0x1").String())
+
+		logs = append(logs, store.ParseCfxLog(&cfxtypes.Log{
+			Address:             cfxaddress.MustNewFromCommon(common.HexToAddress("0x1"), 71),
+			BlockHash:           &blockHash,
+			EpochNumber:         cfxtypes.NewBigInt(bn),
+			TransactionHash:     &txHash,
+			TransactionIndex:    cfxtypes.NewBigInt(0),
+			LogIndex:            cfxtypes.NewBigInt(0),
+			TransactionLogIndex: cfxtypes.NewBigInt(0),
+		}, 0, bn, nil))
+	}
+
+	return logs, nil
+}
+
+// assertEthLogs asserts the block hashes and removed flags of event logs.
+func assertEthLogs(t *testing.T, expectedHashes []string, removed bool, logs []types.Log) {
+	var hashes []string
+	for i := range logs {
+		hashes = append(hashes, logs[i].BlockHash.String())
+		assert.Equal(t, removed, logs[i].Removed, fmt.Sprintf("log #%v", i))
+	}
+
+	assert.Equal(t, expectedHashes, hashes)
+}
+
+func TestEthStorePollingReorg(t *testing.T) {
+	db := newTestEthStore(11)
+	client := newEthStorePollingClient(db, 10, 100)
+
+	session, err := client.establish()
+	assert.NoError(t, err)
+
+	// new blocks polled since the latest block when established
+	db.mine(2, 0)
+	changes, err := client.fetch(session.fid)
+	assert.NoError(t, err)
+	assertEthLogs(t, []string{testEthBlockHash(11, 0), testEthBlockHash(12, 0)}, false, changes.(*types.FilterChanges).Logs)
+
+	// event logs of reverted blocks are removed from the latest to oldest, and then replaced by
+	// the event logs of the new pivot chain
+	db.reorg(11, 1)
+	db.mine(1, 1)
+	changes, err = client.fetch(session.fid)
+	assert.NoError(t, err)
+
+	logs := changes.(*types.FilterChanges).Logs
+	assertEthLogs(t, []string{testEthBlockHash(12, 0), testEthBlockHash(11, 0)}, true, logs[:2])
+	assertEthLogs(t, []string{
+		testEthBlockHash(11, 1), testEthBlockHash(12, 1), testEthBlockHash(13, 1),
+	}, false, logs[2:])
+
+	// nothing changed
+	changes, err = client.fetch(session.fid)
+	assert.NoError(t, err)
+	assert.Empty(t, changes.(*types.FilterChanges).Logs)
+}
+
+func TestEthStorePollingReorgTooDeep(t *testing.T) {
+	db := newTestEthStore(11)
+	client := newEthStorePollingClient(db, 10, 100)
+
+	session, err := client.establish()
+	assert.NoError(t, err)
+
+	// all polled blocks reverted
+	db.reorg(5, 1)
+	_, err = client.fetch(session.fid)
+	assert.ErrorIs(t, err, errEthStoreReorgTooDeep)
+}
@@ -0,0 +1,213 @@
+package virtualfilter
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Conflux-Chain/confura/cmd/util"
+	"github.com/Conflux-Chain/confura/virtualfilter/streampb"
+	"github.com/ethereum/go-ethereum/common"
+	w3rpc "github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// gRPC service name of EVM space streaming
+	ethStreamServiceName = "confura.stream.Eth"
+)
+
+// EthStreamServer is the server API of EVM space streaming service.
+type EthStreamServer interface {
+	// StreamLogs streams event logs matched with the filter.
+	StreamLogs(*streampb.EthStreamLogsRequest, grpc.ServerStream) error
+	// StreamHeads streams hashes of new blocks.
+	StreamHeads(*streampb.StreamRequest, grpc.ServerStream) error
+	// StreamPendingTxs streams hashes of new pending transactions.
+	StreamPendingTxs(*streampb.StreamRequest, grpc.ServerStream) error
+}
+
+// ethStreamServiceDesc is the gRPC service descriptor for EthStreamServer.
+var ethStreamServiceDesc = grpc.ServiceDesc{
+	ServiceName: ethStreamServiceName,
+	HandlerType: (*EthStreamServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamLogs",
+			ServerStreams: true,
+			Handler: streamHandler(func(srv EthStreamServer, req *streampb.EthStreamLogsRequest, stream grpc.ServerStream) error {
+				return srv.StreamLogs(req, stream)
+			}),
+		},
+		{
+			StreamName:    "StreamHeads",
+			ServerStreams: true,
+			Handler: streamHandler(func(srv EthStreamServer, req *streampb.StreamRequest, stream grpc.ServerStream) error {
+				return srv.StreamHeads(req, stream)
+			}),
+		},
+		{
+			StreamName:    "StreamPendingTxs",
+			ServerStreams: true,
+			Handler: streamHandler(func(srv EthStreamServer, req *streampb.StreamRequest, stream grpc.ServerStream) error {
+				return srv.StreamPendingTxs(req, stream)
+			}),
+		},
+	},
+}
+
+// ethStreamService implements EthStreamServer upon virtual filters, each stream of which
+// creates a virtual filter and pushes the polled filter changes until stream closed.
+type ethStreamService struct {
+	*filterStreamer
+
+	api *ethFilterApi
+}
+
+func newEthStreamService(conf streamConfig, api *ethFilterApi, shutdownCtx context.Context) *ethStreamService {
+	return &ethStreamService{
+		filterStreamer: &filterStreamer{
+			conf:        conf,
+			shutdownCtx: shutdownCtx,
+			uninstall:   api.UninstallFilter,
+			poll: func(fid w3rpc.ID) ([]interface{}, error) {
+				changes, err := api.GetFilterChanges(fid)
+				return ethFilterChangesMessages(changes), err
+			},
+		},
+		api: api,
+	}
+}
+
+func (s *ethStreamService) StreamLogs(req *streampb.EthStreamLogsRequest, stream grpc.ServerStream) error {
+	filter, err := ethLogFilterFromPb(req.GetFilter())
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+
+	return s.serve(stream, func() (w3rpc.ID, error) {
+		return s.api.NewFilter(s.conf.NodeUrl, filter)
+	})
+}
+
+func (s *ethStreamService) StreamHeads(req *streampb.StreamRequest, stream grpc.ServerStream) error {
+	return s.serve(stream, func() (w3rpc.ID, error) {
+		return s.api.NewBlockFilter(s.conf.NodeUrl, nil)
+	})
+}
+
+func (s *ethStreamService) StreamPendingTxs(req *streampb.StreamRequest, stream grpc.ServerStream) error {
+	return s.serve(stream, func() (w3rpc.ID, error) {
+		return s.api.NewPendingTransactionFilter(s.conf.NodeUrl, nil)
+	})
+}
+
+// ethFilterChangesMessages converts event logs or hashes of filter changes to stream messages.
+func ethFilterChangesMessages(changes *types.FilterChanges) (msgs []interface{}) {
+	if changes == nil {
+		return nil
+	}
+
+	for i := range changes.Logs {
+		msgs = append(msgs, ethLogToPb(&changes.Logs[i]))
+	}
+
+	for _, hash := range changes.Hashes {
+		msgs = append(msgs, &streampb.HashMessage{Hash: hash.Bytes()})
+	}
+
+	return msgs
+}
+
+func ethLogToPb(log *types.Log) *streampb.EthLog {
+	topics := make([][]byte, 0, len(log.Topics))
+	for _, topic := range log.Topics {
+		topics = append(topics, topic.Bytes())
+	}
+
+	return &streampb.EthLog{
+		Address:          log.Address.Bytes(),
+		Topics:           topics,
+		Data:             log.Data,
+		BlockNumber:      log.BlockNumber,
+		BlockHash:        log.BlockHash.Bytes(),
+		TransactionHash:  log.TxHash.Bytes(),
+		TransactionIndex: uint64(log.TxIndex),
+		LogIndex:         uint64(log.Index),
+		Removed:          log.Removed,
+	}
+}
+
+// ethLogFilterFromPb converts the stream request filter to log filter, where block numbers are
+// parsed the same as JSON-RPC, e.g., hex or tag `latest`.
+func ethLogFilterFromPb(pb *streampb.EthLogFilter) (filter types.FilterQuery, err error) {
+	if pb == nil {
+		return filter, nil
+	}
+
+	if filter.FromBlock, err = parseBlockNumber(pb.FromBlock); err != nil {
+		return filter, errors.WithMessage(err, "invalid from block")
+	}
+
+	if filter.ToBlock, err = parseBlockNumber(pb.ToBlock); err != nil {
+		return filter, errors.WithMessage(err, "invalid to block")
+	}
+
+	if len(pb.BlockHash) > 0 {
+		if len(pb.BlockHash) != common.HashLength {
+			return filter, errors.New("invalid block hash length")
+		}
+
+		blockHash := common.BytesToHash(pb.BlockHash)
+		filter.BlockHash = &blockHash
+	}
+
+	for _, addr := range pb.Addresses {
+		if len(addr) != common.AddressLength {
+			return filter, errors.New("invalid address length")
+		}
+
+		filter.Addresses = append(filter.Addresses, common.BytesToAddress(addr))
+	}
+
+	for _, alternatives := range pb.Topics {
+		var topics []common.Hash // nil matches any topic
+
+		for _, topic := range alternatives.GetTopics() {
+			if len(topic) != common.HashLength {
+				return filter, errors.New("invalid topic length")
+			}
+
+			topics = append(topics, common.BytesToHash(topic))
+		}
+
+		filter.Topics = append(filter.Topics, topics)
+	}
+
+	return filter, nil
+}
+
+// parseBlockNumber parses block number in hex or tag, or returns nil if empty.
+func parseBlockNumber(s string) (*types.BlockNumber, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+
+	var bn types.BlockNumber
+	if err := bn.UnmarshalJSON([]byte(strconv.Quote(s))); err != nil {
+		return nil, err
+	}
+
+	return &bn, nil
+}
+
+// mustServeEthStream serves the EVM space gRPC streaming service, which will be gracefully
+// stopped once context done.
+func mustServeEthStream(shutdownContext util.GracefulShutdownContext, conf streamConfig, api *ethFilterApi) {
+	service := newEthStreamService(conf, api, shutdownContext.Ctx)
+	mustServeStream(shutdownContext, conf, &ethStreamServiceDesc, service)
+}
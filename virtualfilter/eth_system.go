@@ -2,11 +2,13 @@ package virtualfilter
 
 import (
 	"encoding/json"
+	"sync/atomic"
 	"time"
 
 	cmdutil "github.com/Conflux-Chain/confura/cmd/util"
 	"github.com/Conflux-Chain/confura/node"
 	"github.com/Conflux-Chain/confura/store/mysql"
+	citypes "github.com/Conflux-Chain/confura/types"
 	"github.com/Conflux-Chain/confura/util"
 	"github.com/Conflux-Chain/confura/util/metrics"
 	"github.com/openweb3/go-rpc-provider"
@@ -19,55 +21,161 @@ import (
 type ethFilterSystem struct {
 	*filterSystemBase
 	conf *ethConfig
+	// client to poll filter changes from database, nil if polling from full nodes
+	storeClient *ethStorePollingClient
+	// client to poll filter changes from database for full nodes with filter API disabled, nil if
+	// fallback not enabled
+	fallbackClient *ethStorePollingClient
+	// filter API capabilities of full nodes probed when client created
+	capabilities util.ConcurrentMap // node name => bool
+
+	// synced database to compute log filter changes by per filter cursor, nil if not enabled
+	cursorDB  *mysql.MysqlStore
+	networkId atomic.Value
 }
 
 func newEthFilterSystem(
 	conf *ethConfig,
-	vfls *mysql.VirtualFilterLogStore,
+	db *mysql.MysqlStore,
 	shutdownCtx cmdutil.GracefulShutdownContext,
 ) *ethFilterSystem {
-	return &ethFilterSystem{
+	fs := &ethFilterSystem{
 		conf:             conf,
-		filterSystemBase: newFilterSystemBase(conf.TTL, vfls, shutdownCtx),
+		filterSystemBase: newFilterSystemBase("eth", conf.TTL, conf.UpstreamGC, conf.Registry, conf.AuthToken, db.VirtualFilterLogStore, shutdownCtx),
 	}
+
+	if conf.FromStore {
+		fs.storeClient = newEthStorePollingClient(db, conf.MaxStorePollBlocks, conf.MaxFullFilterBlocks)
+	} else if conf.StoreFallback {
+		fs.fallbackClient = newEthStorePollingClient(db, conf.MaxStorePollBlocks, conf.MaxFullFilterBlocks)
+	}
+
+	if conf.Cursor.Enabled {
+		fs.cursorDB = db
+	}
+
+	return fs
 }
 
-func (fs *ethFilterSystem) newBlockFilter(client *node.Web3goClient) (rpc.ID, error) {
-	f, err := newEthBlockFilter(client)
+func (fs *ethFilterSystem) newBlockFilter(client *node.Web3goClient, extMode citypes.FilterExtMode) (rpc.ID, error) {
+	if !fs.filterCapable(client) {
+		return nilRpcId, errFilterApiUnsupported
+	}
+
+	f, err := newEthBlockFilter(client, extMode)
 	if err != nil {
 		return nilRpcId, err
 	}
 
-	fs.filterMgr.add(f)
+	fs.addFilter(f)
+	fs.upstreams.track(f.nodeName(), f.fid(), client.Filter.UninstallFilter)
 	return f.fid(), nil
 }
 
-func (fs *ethFilterSystem) newPendingTransactionFilter(client *node.Web3goClient) (rpc.ID, error) {
-	f, err := newEthPendingTxnFilter(client)
+func (fs *ethFilterSystem) newPendingTransactionFilter(
+	client *node.Web3goClient, extMode citypes.FilterExtMode,
+) (rpc.ID, error) {
+	if !fs.filterCapable(client) {
+		return nilRpcId, errFilterApiUnsupported
+	}
+
+	f, err := newEthPendingTxnFilter(client, extMode)
 	if err != nil {
 		return nilRpcId, err
 	}
 
-	fs.filterMgr.add(f)
+	fs.addFilter(f)
+	fs.upstreams.track(f.nodeName(), f.fid(), client.Filter.UninstallFilter)
 	return f.fid(), nil
 }
 
 func (fs *ethFilterSystem) newFilter(client *node.Web3goClient, crit types.FilterQuery) (rpc.ID, error) {
-	worker, _ := fs.workers.LoadOrStoreFn(client.NodeName(), func(k interface{}) interface{} {
-		return newEthFilterWorker(
-			fs.conf.MaxFullFilterBlocks, fs, client, fs.shutdownCtx,
-		)
-	})
+	if fs.cursorDB != nil { // compute filter changes from database by per filter cursor
+		return fs.newCursorFilter(client, crit)
+	}
+
+	storeClient := fs.storeClient
+	if storeClient == nil && !fs.filterCapable(client) {
+		if fs.fallbackClient == nil {
+			return nilRpcId, errFilterApiUnsupported
+		}
+
+		// fall back to poll filter changes from database transparently
+		storeClient = fs.fallbackClient
+		metrics.Registry.VirtualFilter.StoreFallbacks("eth", client.NodeName()).Mark(1)
+	}
+
+	var worker interface{}
+	if storeClient != nil { // poll filter changes from database
+		worker, _ = fs.workers.LoadOrStoreFn(ethStoreNodeName, func(k interface{}) interface{} {
+			return newEthStoreFilterWorker(
+				fs.conf.MaxFullFilterBlocks, fs, storeClient, fs.conf.Polling, fs.shutdownCtx,
+			)
+		})
+	} else {
+		worker, _ = fs.workers.LoadOrStoreFn(client.NodeName(), func(k interface{}) interface{} {
+			return newEthFilterWorker(
+				fs.conf.MaxFullFilterBlocks, fs, client, fs.upstreams, fs.conf.Polling, fs.shutdownCtx,
+			)
+		})
+	}
 
 	f, err := newEthLogFilter(fs.logStore, worker.(*ethFilterWorker), client, crit)
 	if err != nil {
 		return nilRpcId, err
 	}
 
-	fs.filterMgr.add(f)
+	fs.addFilter(f)
+	return f.fid(), nil
+}
+
+func (fs *ethFilterSystem) newCursorFilter(client *node.Web3goClient, crit types.FilterQuery) (rpc.ID, error) {
+	networkId, err := fs.getNetworkId(client)
+	if err != nil {
+		return nilRpcId, err
+	}
+
+	f, err := newEthCursorLogFilter(fs.conf, fs.cursorDB, client, networkId, crit)
+	if err != nil {
+		return nilRpcId, err
+	}
+
+	fs.addFilter(f)
 	return f.fid(), nil
 }
 
+// probeCapability probes the filter API capability of full node when client created.
+func (fs *ethFilterSystem) probeCapability(client *node.Web3goClient) {
+	fs.capabilities.Store(client.NodeName(), probeEthFilterCapability(client))
+}
+
+// filterCapable checks if the filter API is enabled on full node, which is regarded as capable if
+// not probed yet.
+func (fs *ethFilterSystem) filterCapable(client *node.Web3goClient) bool {
+	if v, ok := fs.capabilities.Load(client.NodeName()); ok {
+		return v.(bool)
+	}
+
+	return true
+}
+
+// getNetworkId returns the network ID to parse log filter for database, which is cached once retrieved.
+func (fs *ethFilterSystem) getNetworkId(client *node.Web3goClient) (uint32, error) {
+	if val := fs.networkId.Load(); val != nil {
+		return val.(uint32), nil
+	}
+
+	chainId, err := client.Eth.ChainId()
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to get chain ID")
+	}
+
+	networkId := uint32(*chainId)
+	fs.networkId.Store(networkId)
+
+	return networkId, nil
+}
+
 func (fs *ethFilterSystem) getFilterChanges(id rpc.ID) (*types.FilterChanges, error) {
 	vf, ok := fs.filterMgr.get(id)
 	if !ok {
@@ -79,13 +187,27 @@ func (fs *ethFilterSystem) getFilterChanges(id rpc.ID) (*types.FilterChanges, er
 		return nil, err
 	}
 
-	fs.filterMgr.refresh(id)
+	fs.refresh(id)
 	return fc.(*types.FilterChanges), nil
 }
 
+// getFilterExtMode returns the extension mode of block or pending transaction filter.
+func (fs *ethFilterSystem) getFilterExtMode(id rpc.ID) (citypes.FilterExtMode, error) {
+	vf, ok := fs.filterMgr.get(id)
+	if !ok {
+		return citypes.FilterExtModeNone, errFilterNotFound
+	}
+
+	if f, ok := vf.(*ethFilter); ok {
+		return f.extMode, nil
+	}
+
+	return citypes.FilterExtModeNone, nil
+}
+
 func (fs *ethFilterSystem) uninstallFilter(id rpc.ID) (bool, error) {
 	if vf, ok := fs.filterMgr.delete(id); ok {
-		return vf.uninstall()
+		return fs.uninstall(vf)
 	}
 
 	return true, nil
@@ -124,6 +124,17 @@ func (m *filterManager) expire(ttl time.Duration) map[rpc.ID]virtualFilter {
 	return res
 }
 
+// clear removes and returns all virtual filters.
+func (m *filterManager) clear() map[rpc.ID]virtualFilter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := m.filters
+	m.filters = make(map[rpc.ID]virtualFilter)
+
+	return res
+}
+
 // isFilterNotFoundError check if error content contains `filter not found`
 func isFilterNotFoundError(err error) bool {
 	if err != nil {
@@ -0,0 +1,53 @@
+package virtualfilter
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// pollingConfig is the adaptive interval settings to poll filter changes from full node (or database).
+type pollingConfig struct {
+	// min interval to poll when blocks are arriving or filters are actively read (default: 500ms)
+	MinInterval time.Duration `default:"500ms"`
+	// max interval to poll when idle (default: 5s)
+	MaxInterval time.Duration `default:"5s"`
+}
+
+func (c pollingConfig) validate() error {
+	if c.MinInterval <= 0 || c.MinInterval > c.MaxInterval {
+		return errors.New("polling min interval must be positive and not greater than max interval")
+	}
+
+	if c.MaxInterval >= maxPollingDelayDuration {
+		return errors.Errorf("polling max interval must be less than %v", maxPollingDelayDuration)
+	}
+
+	return nil
+}
+
+// adaptiveInterval adapts the polling interval to chain activity, which halves the interval if
+// any activity observed since last polling, otherwise doubles it to back off, within bounds.
+type adaptiveInterval struct {
+	conf    pollingConfig
+	current time.Duration
+}
+
+func newAdaptiveInterval(conf pollingConfig) *adaptiveInterval {
+	return &adaptiveInterval{
+		conf:    conf,
+		current: min(max(pollingInterval, conf.MinInterval), conf.MaxInterval),
+	}
+}
+
+// next returns the next polling interval based on whether any activity observed, e.g. new blocks
+// polled or filter changes read by client.
+func (ai *adaptiveInterval) next(active bool) time.Duration {
+	if active {
+		ai.current = max(ai.current/2, ai.conf.MinInterval)
+	} else {
+		ai.current = min(ai.current*2, ai.conf.MaxInterval)
+	}
+
+	return ai.current
+}
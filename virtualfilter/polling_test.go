@@ -0,0 +1,38 @@
+package virtualfilter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveInterval(t *testing.T) {
+	conf := pollingConfig{MinInterval: 200 * time.Millisecond, MaxInterval: 5 * time.Second}
+	ai := newAdaptiveInterval(conf)
+	assert.Equal(t, pollingInterval, ai.current)
+
+	// back off when idle, bounded by max interval
+	assert.Equal(t, 2*time.Second, ai.next(false))
+	assert.Equal(t, 4*time.Second, ai.next(false))
+	assert.Equal(t, 5*time.Second, ai.next(false))
+	assert.Equal(t, 5*time.Second, ai.next(false))
+
+	// poll faster when active, bounded by min interval
+	assert.Equal(t, 2500*time.Millisecond, ai.next(true))
+	for i := 0; i < 10; i++ {
+		ai.next(true)
+	}
+	assert.Equal(t, conf.MinInterval, ai.current)
+
+	// initial interval is bounded too
+	ai = newAdaptiveInterval(pollingConfig{MinInterval: 2 * time.Second, MaxInterval: 3 * time.Second})
+	assert.Equal(t, 2*time.Second, ai.current)
+}
+
+func TestPollingConfigValidate(t *testing.T) {
+	assert.NoError(t, pollingConfig{MinInterval: 500 * time.Millisecond, MaxInterval: 5 * time.Second}.validate())
+	assert.Error(t, pollingConfig{MinInterval: 0, MaxInterval: 5 * time.Second}.validate())
+	assert.Error(t, pollingConfig{MinInterval: 6 * time.Second, MaxInterval: 5 * time.Second}.validate())
+	assert.Error(t, pollingConfig{MinInterval: time.Second, MaxInterval: maxPollingDelayDuration}.validate())
+}
@@ -0,0 +1,182 @@
+package virtualfilter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/store/redis"
+	"github.com/Conflux-Chain/confura/util"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/openweb3/go-rpc-provider/interfaces"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// request timeout to forward filter requests to the owner instance
+	forwardRequestTimeout = 3 * time.Second
+)
+
+// registryConfig is the shared filter registry settings to horizontally scale virtual filter
+// service with multiple instances behind load balancer.
+type registryConfig struct {
+	// redis URL of the shared filter registry, disabled if empty
+	RedisUrl string
+	// internal RPC URL of this instance advertised to peer instances, to which filter requests
+	// will be forwarded, e.g. http://10.0.0.1:48545
+	AdvertiseUrl string
+}
+
+// filterRegistry is the shared filter registry backed by redis, which maps filter ID to the owning
+// instance, so that filter requests received by any instance could be forwarded to the owner.
+//
+// To reduce redis round trips on each filter polling, the ownership is kept twice as long as the
+// filter TTL, and only extended once half of the TTL elapsed since last extension. So that the
+// ownership always outlives the virtual filter, which is removed from registry once uninstalled
+// or expired, while ownership of crashed instance expires within 2*TTL.
+type filterRegistry struct {
+	space     string
+	selfUrl   string
+	authToken string // shared bearer token to request peer instances
+	client    *goredis.Client
+	peers     util.ConcurrentMap // owner URL => RPC provider
+
+	mu        sync.Mutex
+	refreshed map[rpc.ID]time.Time // filter ID => last time to extend ownership
+}
+
+// mustNewFilterRegistry creates shared filter registry, or nil if not enabled.
+func mustNewFilterRegistry(space string, conf registryConfig, authToken string) *filterRegistry {
+	if len(conf.RedisUrl) == 0 {
+		return nil
+	}
+
+	if len(conf.AdvertiseUrl) == 0 {
+		logrus.WithField("space", space).Fatal("Advertise URL required for shared virtual filter registry")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"space":        space,
+		"advertiseUrl": conf.AdvertiseUrl,
+	}).Info("Shared virtual filter registry enabled")
+
+	return newFilterRegistry(space, conf.AdvertiseUrl, authToken, redis.MustNewRedisClient(conf.RedisUrl))
+}
+
+func newFilterRegistry(space, selfUrl, authToken string, client *goredis.Client) *filterRegistry {
+	return &filterRegistry{
+		space:     space,
+		selfUrl:   selfUrl,
+		authToken: authToken,
+		client:    client,
+		refreshed: make(map[rpc.ID]time.Time),
+	}
+}
+
+func (r *filterRegistry) key(fid rpc.ID) string {
+	return redis.RedisKey("vfilter", "registry", r.space, string(fid))
+}
+
+// register claims the ownership of filter, which expires if not refreshed within 2*TTL.
+func (r *filterRegistry) register(fid rpc.ID, ttl time.Duration) error {
+	if err := r.client.Set(context.Background(), r.key(fid), r.selfUrl, 2*ttl).Err(); err != nil {
+		return err
+	}
+
+	r.setRefreshed(fid, time.Now())
+	return nil
+}
+
+// refresh extends the expiration of filter ownership, which is skipped if extended within TTL/2.
+func (r *filterRegistry) refresh(fid rpc.ID, ttl time.Duration) error {
+	r.mu.Lock()
+	last, ok := r.refreshed[fid]
+	r.mu.Unlock()
+
+	if ok && time.Since(last) < ttl/2 {
+		return nil
+	}
+
+	if err := r.client.Expire(context.Background(), r.key(fid), 2*ttl).Err(); err != nil {
+		return err
+	}
+
+	r.setRefreshed(fid, time.Now())
+	return nil
+}
+
+func (r *filterRegistry) setRefreshed(fid rpc.ID, t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refreshed[fid] = t
+}
+
+// unregister removes the ownership of filters in a single round trip.
+func (r *filterRegistry) unregister(fids ...rpc.ID) error {
+	if len(fids) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(fids))
+
+	r.mu.Lock()
+	for _, fid := range fids {
+		keys = append(keys, r.key(fid))
+		delete(r.refreshed, fid)
+	}
+	r.mu.Unlock()
+
+	return r.client.Del(context.Background(), keys...).Err()
+}
+
+// owner returns the URL of the owner instance for the specified filter.
+func (r *filterRegistry) owner(fid rpc.ID) (string, bool, error) {
+	url, err := r.client.Get(context.Background(), r.key(fid)).Result()
+	if err == goredis.Nil {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, err
+	}
+
+	return url, true, nil
+}
+
+// forward forwards the filter request to the owner instance if owned by any peer instance, and
+// returns false if not forwarded.
+func (r *filterRegistry) forward(
+	fid rpc.ID, result interface{}, method string, args ...interface{},
+) (bool, error) {
+	url, ok, err := r.owner(fid)
+	if err != nil {
+		return false, errors.WithMessage(err, "failed to get filter owner from registry")
+	}
+
+	// not found, or owned by self however already removed
+	if !ok || url == r.selfUrl {
+		return false, nil
+	}
+
+	peer, _, err := r.peers.LoadOrStoreFnErr(url, func(k interface{}) (interface{}, error) {
+		if len(r.authToken) > 0 {
+			rpcutil.MustRegisterBearerCredential(url, r.authToken)
+		}
+
+		return providers.NewProviderWithOption(url, providers.Option{RequestTimeout: forwardRequestTimeout})
+	})
+	if err != nil {
+		return true, errors.WithMessage(err, "failed to create RPC provider for filter owner")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), forwardRequestTimeout)
+	defer cancel()
+
+	err = peer.(interfaces.Provider).CallContext(ctx, result, method, args...)
+	return true, err
+}
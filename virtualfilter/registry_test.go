@@ -0,0 +1,105 @@
+package virtualfilter
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestFilterRegistry creates shared filter registry backed by miniredis for the instance of
+// the specified URL.
+func newTestFilterRegistry(t *testing.T, mr *miniredis.Miniredis, selfUrl string) *filterRegistry {
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return newFilterRegistry("eth", selfUrl, "", client)
+}
+
+// testFilterPeer is a fake peer instance, which serves filter changes of owned filters.
+type testFilterPeer struct{}
+
+func (p *testFilterPeer) GetFilterChanges(fid rpc.ID) (string, error) {
+	return "changes of " + string(fid), nil
+}
+
+func TestFilterRegistryOwnership(t *testing.T) {
+	mr := miniredis.RunT(t)
+	registry := newTestFilterRegistry(t, mr, "http://self")
+
+	fid, ttl := rpc.ID("0x1"), time.Minute
+
+	assert.NoError(t, registry.register(fid, ttl))
+	owner, ok, err := registry.owner(fid)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "http://self", owner)
+
+	// ownership outlives the virtual filter
+	key := registry.key(fid)
+	assert.Equal(t, 2*ttl, mr.TTL(key))
+
+	// refresh is throttled within half of TTL
+	mr.SetTTL(key, ttl)
+	assert.NoError(t, registry.refresh(fid, ttl))
+	assert.Equal(t, ttl, mr.TTL(key))
+
+	// and extended once half of TTL elapsed
+	registry.setRefreshed(fid, time.Now().Add(-ttl/2))
+	assert.NoError(t, registry.refresh(fid, ttl))
+	assert.Equal(t, 2*ttl, mr.TTL(key))
+
+	// ownership expires if not refreshed
+	mr.FastForward(2 * ttl)
+	_, ok, err = registry.owner(fid)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// unregister in batch
+	fids := []rpc.ID{"0x2", "0x3"}
+	for _, fid := range fids {
+		assert.NoError(t, registry.register(fid, ttl))
+	}
+
+	assert.NoError(t, registry.unregister(fids...))
+	for _, fid := range fids {
+		assert.False(t, mr.Exists(registry.key(fid)))
+	}
+	assert.Empty(t, registry.refreshed)
+}
+
+func TestFilterRegistryForward(t *testing.T) {
+	srv := rpc.NewServer()
+	assert.NoError(t, srv.RegisterName("eth", &testFilterPeer{}))
+
+	httpSrv := httptest.NewServer(srv)
+	t.Cleanup(httpSrv.Close)
+
+	mr := miniredis.RunT(t)
+	peer := newTestFilterRegistry(t, mr, httpSrv.URL)
+	self := newTestFilterRegistry(t, mr, "http://self")
+
+	// forwarded to the owner peer
+	assert.NoError(t, peer.register("0x1", time.Minute))
+
+	var result string
+	forwarded, err := self.forward("0x1", &result, "eth_getFilterChanges", rpc.ID("0x1"))
+	assert.NoError(t, err)
+	assert.True(t, forwarded)
+	assert.Equal(t, "changes of 0x1", result)
+
+	// not forwarded if owned by self
+	assert.NoError(t, self.register("0x2", time.Minute))
+	forwarded, err = self.forward("0x2", &result, "eth_getFilterChanges", rpc.ID("0x2"))
+	assert.NoError(t, err)
+	assert.False(t, forwarded)
+
+	// not forwarded if not found
+	forwarded, err = self.forward("0x3", &result, "eth_getFilterChanges", rpc.ID("0x3"))
+	assert.NoError(t, err)
+	assert.False(t, forwarded)
+}
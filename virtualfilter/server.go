@@ -3,20 +3,30 @@ package virtualfilter
 import (
 	"github.com/Conflux-Chain/confura/cmd/util"
 	"github.com/Conflux-Chain/confura/store/mysql"
+	"github.com/Conflux-Chain/confura/util/reload"
 	"github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/rpc/handlers"
 )
 
 // MustNewEvmSpaceServerFromViper creates evm space virtual filters RPC server from viper settings
 func MustNewEvmSpaceServerFromViper(
 	shutdownContext util.GracefulShutdownContext,
-	vfls *mysql.VirtualFilterLogStore,
+	db *mysql.MysqlStore,
 ) (*rpc.Server, string) {
 	conf := mustNewEthConfigFromViper()
-	fs := newEthFilterSystem(conf, vfls, shutdownContext)
+	fs := newEthFilterSystem(conf, db, shutdownContext)
+	reload.Register("ethVirtualFilters", ttlConfig{conf.TTL}, ttlConfig.validate, func(c ttlConfig) {
+		fs.setTTL(c.TTL)
+	})
+
+	api := newEthFilterApi(fs)
+	if len(conf.Stream.Endpoint) > 0 {
+		mustServeEthStream(shutdownContext, conf.Stream, api)
+	}
 
 	srv := rpc.MustNewServer("eth_vfilter", map[string]interface{}{
-		"eth": newEthFilterApi(fs),
-	})
+		"eth": api,
+	}, authMiddlewares(conf.AuthToken)...)
 
 	return srv, conf.Endpoint
 }
@@ -28,10 +38,28 @@ func MustNewCoreSpaceServerFromViper(
 ) (*rpc.Server, string) {
 	conf := mustNewCfxConfigFromViper()
 	fs := newCfxFilterSystem(conf, vfls, shutdownContext)
+	reload.Register("virtualFilters", ttlConfig{conf.TTL}, ttlConfig.validate, func(c ttlConfig) {
+		fs.setTTL(c.TTL)
+	})
+
+	api := newCfxFilterApi(fs)
+	if len(conf.Stream.Endpoint) > 0 {
+		mustServeCfxStream(shutdownContext, conf.Stream, api)
+	}
 
 	srv := rpc.MustNewServer("cfx_vfilter", map[string]interface{}{
-		"cfx": newCfxFilterApi(fs),
-	})
+		"cfx": api,
+	}, authMiddlewares(conf.AuthToken)...)
 
 	return srv, conf.Endpoint
 }
+
+// authMiddlewares returns the HTTP middlewares to authenticate internal RPC requests if auth token
+// configured.
+func authMiddlewares(authToken string) []handlers.Middleware {
+	if len(authToken) == 0 {
+		return nil
+	}
+
+	return []handlers.Middleware{rpc.MustNewBearerAuthMiddleware(authToken)}
+}
@@ -0,0 +1,225 @@
+package virtualfilter
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/cmd/util"
+	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	w3rpc "github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// streamConfig represents the configuration of gRPC streaming service.
+type streamConfig struct {
+	Endpoint     string        // served gRPC endpoint, disabled if empty
+	NodeUrl      string        // full node to poll filter changes from
+	PollInterval time.Duration `default:"1s"` // interval to poll filter changes (default: 1s)
+	// bearer token to authenticate stream consumers, which is mandatory once enabled
+	AuthToken string
+	// max number of concurrent streams of all connections (default: 1000)
+	MaxStreams int `default:"1000"`
+	// max number of concurrent streams per connection (default: 10)
+	MaxStreamsPerConn int `default:"10"`
+}
+
+// streamHandler adapts typed server-streaming method to gRPC stream handler.
+func streamHandler[S, T any](
+	call func(srv S, req *T, stream grpc.ServerStream) error,
+) grpc.StreamHandler {
+	return func(srv interface{}, stream grpc.ServerStream) error {
+		req := new(T)
+		if err := stream.RecvMsg(req); err != nil {
+			return err
+		}
+
+		return call(srv.(S), req, stream)
+	}
+}
+
+// filterStreamer streams the polled changes of virtual filter until stream closed.
+type filterStreamer struct {
+	conf        streamConfig
+	shutdownCtx context.Context
+
+	// uninstalls the virtual filter once stream closed
+	uninstall func(fid w3rpc.ID) (bool, error)
+	// polls filter changes as messages to stream
+	poll func(fid w3rpc.ID) ([]interface{}, error)
+}
+
+func (s *filterStreamer) serve(stream grpc.ServerStream, newFilter func() (w3rpc.ID, error)) error {
+	fid, err := newFilter()
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "failed to create filter: %v", err)
+	}
+
+	defer s.uninstall(fid)
+
+	ticker := time.NewTicker(s.conf.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.shutdownCtx.Done(): // so that server could be gracefully stopped
+			return status.Error(codes.Unavailable, "server shutting down")
+		case <-ticker.C:
+		}
+
+		msgs, err := s.poll(fid)
+		if errors.Is(err, errFilterNotFound) {
+			return status.Error(codes.NotFound, "filter expired")
+		}
+
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to get filter changes: %v", err)
+		}
+
+		for _, msg := range msgs {
+			if err := stream.SendMsg(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// streamAuthInterceptor authenticates stream consumers by bearer token in the `authorization` metadata.
+func streamAuthInterceptor(token string) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+
+		for _, v := range md.Get("authorization") {
+			provided := strings.TrimPrefix(v, "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1 {
+				return handler(srv, ss)
+			}
+		}
+
+		return status.Error(codes.Unauthenticated, "invalid stream auth token")
+	}
+}
+
+// streamLimiter limits the number of concurrent streams in total and per connection, since each
+// stream holds a virtual filter along with delegate filter on full node.
+type streamLimiter struct {
+	mu sync.Mutex
+
+	maxStreams        int
+	maxStreamsPerConn int
+
+	numStreams  int
+	connStreams map[string]int // connection (remote address) => number of streams
+}
+
+func newStreamLimiter(maxStreams, maxStreamsPerConn int) *streamLimiter {
+	return &streamLimiter{
+		maxStreams:        maxStreams,
+		maxStreamsPerConn: maxStreamsPerConn,
+		connStreams:       make(map[string]int),
+	}
+}
+
+func (l *streamLimiter) acquire(conn string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.numStreams >= l.maxStreams {
+		return status.Errorf(codes.ResourceExhausted, "too many streams (max %v)", l.maxStreams)
+	}
+
+	if l.connStreams[conn] >= l.maxStreamsPerConn {
+		return status.Errorf(codes.ResourceExhausted, "too many streams per connection (max %v)", l.maxStreamsPerConn)
+	}
+
+	l.numStreams++
+	l.connStreams[conn]++
+
+	return nil
+}
+
+func (l *streamLimiter) release(conn string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.numStreams--
+	if l.connStreams[conn]--; l.connStreams[conn] <= 0 {
+		delete(l.connStreams, conn)
+	}
+}
+
+// interceptor rejects streams beyond limits before any virtual filter created.
+func (l *streamLimiter) interceptor(
+	srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	var conn string
+	if p, ok := peer.FromContext(ss.Context()); ok && p.Addr != nil {
+		conn = p.Addr.String()
+	}
+
+	if err := l.acquire(conn); err != nil {
+		return err
+	}
+	defer l.release(conn)
+
+	return handler(srv, ss)
+}
+
+// mustServeStream serves the gRPC streaming service, which will be gracefully stopped once
+// context done.
+func mustServeStream(
+	shutdownContext util.GracefulShutdownContext, conf streamConfig, desc *grpc.ServiceDesc, impl interface{},
+) {
+	if len(conf.NodeUrl) == 0 {
+		logrus.Fatal("Full node URL required for virtual filter streaming service")
+	}
+
+	if len(conf.AuthToken) == 0 {
+		logrus.Fatal("Auth token required for virtual filter streaming service")
+	}
+
+	listener, err := net.Listen("tcp", conf.Endpoint)
+	if err != nil {
+		logrus.WithError(err).
+			WithField("endpoint", conf.Endpoint).
+			Fatal("Failed to listen for virtual filter streaming service")
+	}
+
+	limiter := newStreamLimiter(conf.MaxStreams, conf.MaxStreamsPerConn)
+	server := grpc.NewServer(grpc.ChainStreamInterceptor(
+		streamAuthInterceptor(rpcutil.MustResolveBearerToken(conf.AuthToken)), limiter.interceptor,
+	))
+	server.RegisterService(desc, impl)
+
+	shutdownContext.Wg.Add(1)
+	go func() {
+		defer shutdownContext.Wg.Done()
+
+		<-shutdownContext.Ctx.Done()
+		server.GracefulStop()
+	}()
+
+	go func() {
+		logrus.WithFields(logrus.Fields{
+			"service":  desc.ServiceName,
+			"endpoint": conf.Endpoint,
+		}).Info("Virtual filter streaming service started")
+
+		if err := server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			logrus.WithError(err).Fatal("Failed to serve virtual filter streaming service")
+		}
+	}()
+}
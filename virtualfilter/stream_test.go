@@ -0,0 +1,188 @@
+package virtualfilter
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/confura/virtualfilter/streampb"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	w3rpc "github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// testServerStream records the sent messages of server stream.
+type testServerStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+
+	mu   sync.Mutex
+	msgs []interface{}
+}
+
+func (s *testServerStream) Context() context.Context { return s.ctx }
+
+func (s *testServerStream) SendMsg(m interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.msgs = append(s.msgs, m)
+	return nil
+}
+
+func (s *testServerStream) sent() []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]interface{}{}, s.msgs...)
+}
+
+func TestStreamAuthInterceptor(t *testing.T) {
+	interceptor := streamAuthInterceptor("secret")
+	handler := func(srv interface{}, stream grpc.ServerStream) error { return nil }
+
+	stream := &testServerStream{ctx: context.Background()}
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	stream.ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer invalid"))
+	err = interceptor(nil, stream, &grpc.StreamServerInfo{}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	stream.ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer secret"))
+	assert.NoError(t, interceptor(nil, stream, &grpc.StreamServerInfo{}, handler))
+}
+
+func TestStreamLimiter(t *testing.T) {
+	limiter := newStreamLimiter(3, 2)
+
+	assert.NoError(t, limiter.acquire("conn0"))
+	assert.NoError(t, limiter.acquire("conn0"))
+
+	// per connection limit exceeded
+	err := limiter.acquire("conn0")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	assert.NoError(t, limiter.acquire("conn1"))
+
+	// global limit exceeded
+	err = limiter.acquire("conn2")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// streams released once closed
+	limiter.release("conn0")
+	assert.NoError(t, limiter.acquire("conn2"))
+
+	limiter.release("conn0")
+	assert.NotContains(t, limiter.connStreams, "conn0")
+}
+
+func TestFilterStreamerServe(t *testing.T) {
+	var mu sync.Mutex
+	var uninstalled []w3rpc.ID
+
+	streamer := &filterStreamer{
+		conf:        streamConfig{PollInterval: 10 * time.Millisecond},
+		shutdownCtx: context.Background(),
+		uninstall: func(fid w3rpc.ID) (bool, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			uninstalled = append(uninstalled, fid)
+			return true, nil
+		},
+		poll: func(fid w3rpc.ID) ([]interface{}, error) {
+			return cfxFilterChangesMessages(&types.CfxFilterChanges{
+				Type: "hash", Hashes: []types.Hash{"0x1"},
+			}), nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &testServerStream{ctx: ctx}
+
+	done := make(chan error)
+	go func() {
+		done <- streamer.serve(stream, func() (w3rpc.ID, error) { return "0x100", nil })
+	}()
+
+	// filter changes streamed until stream closed
+	assert.Eventually(t, func() bool { return len(stream.sent()) >= 2 }, time.Second, 10*time.Millisecond)
+	assert.True(t, proto.Equal(&streampb.HashMessage{Hash: common.HexToHash("0x1").Bytes()}, stream.sent()[0].(proto.Message)))
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	// virtual filter uninstalled once stream closed
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []w3rpc.ID{"0x100"}, uninstalled)
+}
+
+func TestFilterStreamerFilterExpired(t *testing.T) {
+	streamer := &filterStreamer{
+		conf:        streamConfig{PollInterval: 10 * time.Millisecond},
+		shutdownCtx: context.Background(),
+		uninstall:   func(fid w3rpc.ID) (bool, error) { return false, nil },
+		poll:        func(fid w3rpc.ID) ([]interface{}, error) { return nil, errFilterNotFound },
+	}
+
+	stream := &testServerStream{ctx: context.Background()}
+	err := streamer.serve(stream, func() (w3rpc.ID, error) { return "0x100", nil })
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestEthLogFilterFromPb(t *testing.T) {
+	addr := common.HexToAddress("0x1")
+	topic := common.HexToHash("0x2")
+
+	filter, err := ethLogFilterFromPb(&streampb.EthLogFilter{
+		FromBlock: "0x10",
+		ToBlock:   "latest",
+		Addresses: [][]byte{addr.Bytes()},
+		Topics:    []*streampb.Topics{{}, {Topics: [][]byte{topic.Bytes()}}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, w3rpc.BlockNumber(16), *filter.FromBlock)
+	assert.Equal(t, w3rpc.LatestBlockNumber, *filter.ToBlock)
+	assert.Equal(t, []common.Address{addr}, filter.Addresses)
+	assert.Equal(t, [][]common.Hash{nil, {topic}}, filter.Topics)
+
+	_, err = ethLogFilterFromPb(&streampb.EthLogFilter{FromBlock: "invalid"})
+	assert.Error(t, err)
+
+	_, err = ethLogFilterFromPb(&streampb.EthLogFilter{Addresses: [][]byte{{1}}})
+	assert.Error(t, err)
+}
+
+func TestCfxFilterChangesMessages(t *testing.T) {
+	epoch := hexutil.Big(*big.NewInt(5))
+	blockHash := types.Hash(common.HexToHash("0x3").Hex())
+
+	msgs := cfxFilterChangesMessages(&types.CfxFilterChanges{
+		Type: "log",
+		Logs: []*types.SubscriptionLog{
+			{Log: &types.Log{Topics: []types.Hash{blockHash}, BlockHash: &blockHash, EpochNumber: &epoch}},
+			{ChainReorg: &types.ChainReorg{RevertTo: &epoch}},
+		},
+	})
+	assert.Len(t, msgs, 2)
+
+	log := msgs[0].(*streampb.CfxLogMessage).GetLog()
+	assert.Equal(t, uint64(5), log.EpochNumber)
+	assert.Equal(t, common.HexToHash("0x3").Bytes(), log.BlockHash)
+	assert.Equal(t, [][]byte{common.HexToHash("0x3").Bytes()}, log.Topics)
+
+	// chain reorg notified as well
+	assert.Equal(t, uint64(5), msgs[1].(*streampb.CfxLogMessage).GetReorg().GetRevertTo())
+}
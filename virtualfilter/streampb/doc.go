@@ -0,0 +1,4 @@
+// Package streampb defines the protobuf messages of virtual filter gRPC streaming services.
+package streampb
+
+//go:generate protoc --proto_path=../.. --go_out=../.. --go_opt=paths=source_relative virtualfilter/streampb/stream.proto
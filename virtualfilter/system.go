@@ -1,6 +1,7 @@
 package virtualfilter
 
 import (
+	"sync/atomic"
 	"time"
 
 	cmdutil "github.com/Conflux-Chain/confura/cmd/util"
@@ -24,6 +25,13 @@ const (
 type filterSystemBase struct {
 	filterMgr *filterManager     // virtual filter manager
 	workers   util.ConcurrentMap // filter workers
+	ttl       atomic.Int64       // how long filters stay active, which is hot-reloadable
+
+	// delegate filters on full nodes to retry failed uninstalls and clean up orphaned ones
+	upstreams *upstreamFilterRegistry
+
+	// shared filter registry among multiple instances, nil if not enabled
+	registry *filterRegistry
 
 	// log store to persist changed logs for more reliability
 	logStore *mysql.VirtualFilterLogStore
@@ -33,7 +41,11 @@ type filterSystemBase struct {
 }
 
 func newFilterSystemBase(
+	space string,
 	ttl time.Duration,
+	gcConf upstreamGCConfig,
+	regConf registryConfig,
+	authToken string,
 	vfls *mysql.VirtualFilterLogStore,
 	shutdownCtx cmdutil.GracefulShutdownContext,
 ) *filterSystemBase {
@@ -41,27 +53,183 @@ func newFilterSystemBase(
 		logStore:    vfls,
 		shutdownCtx: shutdownCtx,
 		filterMgr:   newFilterManager(),
+		registry:    mustNewFilterRegistry(space, regConf, authToken),
 	}
 
-	go fs.timeoutLoop(ttl)
+	fs.ttl.Store(int64(ttl))
+	fs.upstreams = newUpstreamFilterRegistry(space, gcConf, fs.ownsUpstream)
+
+	shutdownCtx.Wg.Add(2)
+	go fs.timeoutLoop()
+	go fs.reconcileLoop(gcConf.Interval)
 	return fs
 }
 
+// setTTL updates how long filters stay active.
+func (fs *filterSystemBase) setTTL(ttl time.Duration) {
+	fs.ttl.Store(int64(ttl))
+}
+
 func (fs *filterSystemBase) getFilter(id rpc.ID) (virtualFilter, bool) {
 	return fs.filterMgr.get(id)
 }
 
-// timeoutLoop runs at the interval set by 'ttl' and deletes expired virtual filters
-func (fs *filterSystemBase) timeoutLoop(ttl time.Duration) {
+// addFilter adds the virtual filter, and claims the ownership in shared filter registry if enabled.
+func (fs *filterSystemBase) addFilter(vf virtualFilter) {
+	fs.filterMgr.add(vf)
+
+	if fs.registry == nil {
+		return
+	}
+
+	if err := fs.registry.register(vf.fid(), time.Duration(fs.ttl.Load())); err != nil {
+		logrus.WithField("fid", vf.fid()).WithError(err).Error("Failed to register virtual filter to shared registry")
+	}
+}
+
+// refresh refreshes the last polling time of virtual filter, and extends the ownership in shared
+// filter registry if enabled.
+func (fs *filterSystemBase) refresh(id rpc.ID) {
+	fs.filterMgr.refresh(id)
+
+	if fs.registry == nil {
+		return
+	}
+
+	if err := fs.registry.refresh(id, time.Duration(fs.ttl.Load())); err != nil {
+		logrus.WithField("fid", id).WithError(err).Debug("Failed to refresh virtual filter in shared registry")
+	}
+}
+
+// forward forwards the filter request to the owner instance if the filter is owned by any peer
+// instance, and returns false if not forwarded.
+func (fs *filterSystemBase) forward(
+	id rpc.ID, result interface{}, method string, args ...interface{},
+) (bool, error) {
+	if fs.registry == nil {
+		return false, nil
+	}
+
+	return fs.registry.forward(id, result, method, args...)
+}
+
+// timeoutLoop runs at the interval set by 'ttl' and deletes expired virtual filters, and
+// uninstalls all virtual filters on shutdown.
+func (fs *filterSystemBase) timeoutLoop() {
+	defer fs.shutdownCtx.Wg.Done()
+
+	ttl := time.Duration(fs.ttl.Load())
 	ticker := time.NewTicker(ttl / 2)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-fs.shutdownCtx.Ctx.Done():
+			fs.uninstallAll()
+			return
+		case <-ticker.C:
+		}
+
+		if latest := time.Duration(fs.ttl.Load()); latest != ttl {
+			ttl = latest
+			ticker.Reset(ttl / 2)
+		}
+
 		expfs := fs.filterMgr.expire(ttl)
 		for _, vf := range expfs {
-			vf.uninstall()
+			fs.uninstall(vf)
+		}
+	}
+}
+
+// reconcileLoop periodically retries failed uninstalls and cleans up orphaned delegate filters
+// on full nodes.
+func (fs *filterSystemBase) reconcileLoop(interval time.Duration) {
+	defer fs.shutdownCtx.Wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-fs.shutdownCtx.Ctx.Done():
+			return
+		case <-ticker.C:
+			fs.upstreams.reconcile()
+		}
+	}
+}
+
+// uninstall uninstalls the virtual filter, and the delegate filter on full node if any will be
+// retried later if failed to uninstall.
+func (fs *filterSystemBase) uninstall(vf virtualFilter) (bool, error) {
+	ok, err := fs.uninstallUpstream(vf)
+	fs.unregister(vf.fid())
+
+	return ok, err
+}
+
+// uninstallUpstream uninstalls the virtual filter along with the delegate filter on full node.
+func (fs *filterSystemBase) uninstallUpstream(vf virtualFilter) (bool, error) {
+	ok, err := vf.uninstall()
+	fs.upstreams.settle(vf.nodeName(), vf.fid(), err)
+
+	return ok, err
+}
+
+// unregister removes the ownership of virtual filters from shared filter registry if enabled.
+func (fs *filterSystemBase) unregister(fids ...rpc.ID) {
+	if fs.registry == nil {
+		return
+	}
+
+	if err := fs.registry.unregister(fids...); err != nil {
+		logrus.WithField("fids", fids).WithError(err).Debug("Failed to unregister virtual filters from shared registry")
+	}
+}
+
+// ownsUpstream checks if the delegate filter on full node is owned by any virtual filter or
+// polling session of filter worker.
+func (fs *filterSystemBase) ownsUpstream(nodeName string, fid rpc.ID) bool {
+	if _, ok := fs.filterMgr.get(fid); ok {
+		return true
+	}
+
+	if w, ok := fs.workers.Load(nodeName); ok {
+		return w.(interface{ sessionFid() rpc.ID }).sessionFid() == fid
+	}
+
+	return false
+}
+
+// resetWorker stops and removes the filter worker of the full node, so that a new worker will be
+// created for the next virtual filter.
+func (fs *filterSystemBase) resetWorker(nodeName string) {
+	if w, ok := fs.workers.LoadAndDelete(nodeName); ok {
+		w.(interface{ stop() }).stop()
+	}
+}
+
+// uninstallAll uninstalls all virtual filters, including the delegate filters on full nodes and
+// the ownership in shared filter registry.
+func (fs *filterSystemBase) uninstallAll() {
+	vfs := fs.filterMgr.clear()
+
+	// release the ownership in shared filter registry at first, so that peer instances won't
+	// forward filter requests to this instance any more
+	fids := make([]rpc.ID, 0, len(vfs))
+	for fid := range vfs {
+		fids = append(fids, fid)
+	}
+	fs.unregister(fids...)
+
+	for _, vf := range vfs {
+		if _, err := fs.uninstallUpstream(vf); err != nil {
+			logrus.WithField("fid", vf.fid()).WithError(err).Debug("Failed to uninstall virtual filter on shutdown")
 		}
 	}
+
+	logrus.WithField("numFilters", len(vfs)).Info("Virtual filters uninstalled on shutdown")
 }
 
 // implement `filterWorkerObserver` interface
@@ -0,0 +1,54 @@
+package virtualfilter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+// testVirtualFilter is a virtual filter which records whether uninstalled.
+type testVirtualFilter struct {
+	filterBase
+	uninstalled bool
+}
+
+func (f *testVirtualFilter) nodeName() string              { return "node0" }
+func (f *testVirtualFilter) fetch() (filterChanges, error) { return nil, nil }
+
+func (f *testVirtualFilter) uninstall() (bool, error) {
+	f.uninstalled = true
+	return true, nil
+}
+
+func TestFilterSystemUninstallAll(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	fs := &filterSystemBase{
+		filterMgr: newFilterManager(),
+		registry:  newTestFilterRegistry(t, mr, "http://instance0"),
+		upstreams: newUpstreamFilterRegistry("eth", upstreamGCConfig{}, func(string, rpc.ID) bool { return true }),
+	}
+	fs.ttl.Store(int64(time.Minute))
+
+	vfs := []*testVirtualFilter{
+		{filterBase: filterBase{id: "0x1"}}, {filterBase: filterBase{id: "0x2"}},
+	}
+	for _, vf := range vfs {
+		fs.addFilter(vf)
+		assert.True(t, mr.Exists(fs.registry.key(vf.fid())))
+	}
+
+	fs.uninstallAll()
+
+	// both delegate filters and ownership in shared registry removed on shutdown
+	for _, vf := range vfs {
+		assert.True(t, vf.uninstalled)
+		assert.False(t, mr.Exists(fs.registry.key(vf.fid())))
+	}
+
+	_, ok := fs.getFilter("0x1")
+	assert.False(t, ok)
+}
@@ -0,0 +1,158 @@
+package virtualfilter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/confura/util/metrics"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/sirupsen/logrus"
+)
+
+// upstreamUninstaller uninstalls the delegate filter on full node.
+type upstreamUninstaller func(fid rpc.ID) (bool, error)
+
+// upstreamOwnerChecker checks if the delegate filter on full node is still owned by any virtual
+// filter or filter worker polling session.
+type upstreamOwnerChecker func(nodeName string, fid rpc.ID) bool
+
+// upstreamFilter is a delegate filter created on full node.
+type upstreamFilter struct {
+	nodeName  string
+	fid       rpc.ID
+	uninstall upstreamUninstaller
+	createdAt time.Time
+	failures  int // number of failed uninstall attempts, pending for retry if non-zero
+}
+
+// upstreamFilterRegistry tracks the delegate filters created on full nodes, so that the failed
+// uninstalls could be retried, and orphaned delegate filters (e.g., whose virtual filter expired
+// or polling session killed without uninstall) could be audited and cleaned up, in case of
+// leaking filters on full nodes.
+type upstreamFilterRegistry struct {
+	mu      sync.Mutex
+	space   string
+	conf    upstreamGCConfig
+	owned   upstreamOwnerChecker
+	filters map[string]map[rpc.ID]*upstreamFilter // node name => filter ID => delegate filter
+}
+
+func newUpstreamFilterRegistry(
+	space string, conf upstreamGCConfig, owned upstreamOwnerChecker,
+) *upstreamFilterRegistry {
+	return &upstreamFilterRegistry{
+		space:   space,
+		conf:    conf,
+		owned:   owned,
+		filters: make(map[string]map[rpc.ID]*upstreamFilter),
+	}
+}
+
+// track starts to track the delegate filter newly created on full node.
+func (r *upstreamFilterRegistry) track(nodeName string, fid rpc.ID, uninstall upstreamUninstaller) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nodeFilters, ok := r.filters[nodeName]
+	if !ok {
+		nodeFilters = make(map[rpc.ID]*upstreamFilter)
+		r.filters[nodeName] = nodeFilters
+	}
+
+	nodeFilters[fid] = &upstreamFilter{
+		nodeName:  nodeName,
+		fid:       fid,
+		uninstall: uninstall,
+		createdAt: time.Now(),
+	}
+}
+
+// settle updates the tracked delegate filter with the uninstall result. It is untracked if
+// uninstalled successfully or already removed by full node, otherwise pending for retry.
+func (r *upstreamFilterRegistry) settle(nodeName string, fid rpc.ID, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.filters[nodeName][fid]
+	if !ok {
+		return
+	}
+
+	if err == nil || isFilterNotFoundError(err) {
+		r.untrack(nodeName, fid)
+		return
+	}
+
+	f.failures++
+	if f.failures > r.conf.MaxRetries {
+		// give up, and leave it to be expired by full node
+		logrus.WithFields(logrus.Fields{
+			"space":    r.space,
+			"nodeName": nodeName,
+			"fid":      fid,
+		}).WithError(err).Warn("Virtual filter gave up uninstalling delegate filter on full node")
+
+		metrics.Registry.VirtualFilter.UpstreamLeaked(r.space, nodeName).Mark(1)
+		r.untrack(nodeName, fid)
+	}
+}
+
+// untrack removes the tracked delegate filter, and requires the lock held by caller.
+func (r *upstreamFilterRegistry) untrack(nodeName string, fid rpc.ID) {
+	delete(r.filters[nodeName], fid)
+
+	if len(r.filters[nodeName]) == 0 {
+		delete(r.filters, nodeName)
+	}
+}
+
+// size returns the number of tracked delegate filters on full node.
+func (r *upstreamFilterRegistry) size(nodeName string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.filters[nodeName])
+}
+
+// reconcile retries the failed uninstalls and cleans up orphaned delegate filters on full nodes.
+func (r *upstreamFilterRegistry) reconcile() {
+	var candidates []*upstreamFilter
+
+	r.mu.Lock()
+	for _, nodeFilters := range r.filters {
+		for _, f := range nodeFilters {
+			candidates = append(candidates, f)
+		}
+	}
+	r.mu.Unlock()
+
+	var numRetried, numOrphaned int
+	for _, f := range candidates {
+		r.mu.Lock()
+		failures := f.failures
+		r.mu.Unlock()
+
+		if failures == 0 {
+			// orphaned check, with grace period in case that the owner is being created
+			if time.Since(f.createdAt) < r.conf.OrphanGracePeriod || r.owned(f.nodeName, f.fid) {
+				continue
+			}
+
+			numOrphaned++
+			metrics.Registry.VirtualFilter.UpstreamOrphaned(r.space, f.nodeName).Mark(1)
+		} else {
+			numRetried++
+		}
+
+		_, err := f.uninstall(f.fid)
+		r.settle(f.nodeName, f.fid, err)
+	}
+
+	if numRetried > 0 || numOrphaned > 0 {
+		logrus.WithFields(logrus.Fields{
+			"space":       r.space,
+			"numRetried":  numRetried,
+			"numOrphaned": numOrphaned,
+		}).Info("Virtual filter reconciled delegate filters on full nodes")
+	}
+}
@@ -0,0 +1,76 @@
+package virtualfilter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockUpstreamNode struct {
+	err   error
+	calls int
+}
+
+func (m *mockUpstreamNode) uninstall(fid rpc.ID) (bool, error) {
+	m.calls++
+	return m.err == nil, m.err
+}
+
+func TestUpstreamFilterRegistryRetry(t *testing.T) {
+	conf := upstreamGCConfig{MaxRetries: 2}
+	registry := newUpstreamFilterRegistry("eth", conf, func(string, rpc.ID) bool { return true })
+
+	node := &mockUpstreamNode{err: errors.New("connection refused")}
+	registry.track("node0", "0x1", node.uninstall)
+
+	// uninstalled successfully
+	registry.track("node0", "0x2", node.uninstall)
+	registry.settle("node0", "0x2", nil)
+	assert.Equal(t, 1, registry.size("node0"))
+
+	// pending for retry once failed
+	registry.settle("node0", "0x1", node.err)
+	registry.reconcile()
+	assert.Equal(t, 1, node.calls)
+	assert.Equal(t, 1, registry.size("node0"))
+
+	// give up if max retries exceeded
+	registry.reconcile()
+	assert.Equal(t, 2, node.calls)
+	assert.Equal(t, 0, registry.size("node0"))
+}
+
+func TestUpstreamFilterRegistryFilterNotFound(t *testing.T) {
+	registry := newUpstreamFilterRegistry("cfx", upstreamGCConfig{MaxRetries: 5}, nil)
+
+	node := &mockUpstreamNode{}
+	registry.track("node0", "0x1", node.uninstall)
+
+	// already removed by full node, e.g. due to reboot
+	registry.settle("node0", "0x1", errors.New("Filter not found"))
+	assert.Equal(t, 0, registry.size("node0"))
+}
+
+func TestUpstreamFilterRegistryOrphaned(t *testing.T) {
+	owners := map[rpc.ID]bool{"0x1": true}
+	registry := newUpstreamFilterRegistry("eth", upstreamGCConfig{MaxRetries: 5}, func(_ string, fid rpc.ID) bool {
+		return owners[fid]
+	})
+
+	node := &mockUpstreamNode{}
+	registry.track("node0", "0x1", node.uninstall)
+	registry.track("node0", "0x2", node.uninstall)
+
+	// orphaned filter cleaned up
+	registry.reconcile()
+	assert.Equal(t, 1, node.calls)
+	assert.Equal(t, 1, registry.size("node0"))
+
+	// owner removed
+	delete(owners, "0x1")
+	registry.reconcile()
+	assert.Equal(t, 2, node.calls)
+	assert.Equal(t, 0, registry.size("node0"))
+}
@@ -11,12 +11,14 @@ import (
 	"github.com/Conflux-Chain/confura/node"
 	"github.com/Conflux-Chain/confura/util/metrics"
 	rpcutil "github.com/Conflux-Chain/confura/util/rpc"
+	"github.com/Conflux-Chain/confura/util/tracing"
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	cfxtypes "github.com/Conflux-Chain/go-conflux-sdk/types"
 	"github.com/openweb3/go-rpc-provider"
 	ethtypes "github.com/openweb3/web3go/types"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
@@ -33,6 +35,10 @@ type pollingSession struct {
 	lastPollingTime time.Time
 	// delegate virtual filter cursors
 	fcursors map[rpc.ID]filterCursor
+	// coalesced groups of delegate virtual filters keyed by normalized filter criteria
+	groups map[string]*filterGroup
+	// filter group of each delegate virtual filter
+	fgroups map[rpc.ID]*filterGroup
 	// simulated filter blockchain (for world outlook) to which
 	// the polling will be applied
 	fchain filterChain
@@ -54,6 +60,8 @@ func newPollingSession(fid rpc.ID, chain filterChain) *pollingSession {
 		fchain:          chain,
 		lastPollingTime: time.Now(),
 		fcursors:        make(map[rpc.ID]filterCursor),
+		groups:          make(map[string]*filterGroup),
+		fgroups:         make(map[rpc.ID]*filterGroup),
 	}
 }
 
@@ -72,6 +80,7 @@ type pollingObserver interface {
 type filterWorker struct {
 	mu          sync.Mutex
 	quitflag    uint32
+	quit        chan struct{} // closed once worker stopped
 	shutdownCtx cmdutil.GracefulShutdownContext
 
 	space    string          // network space
@@ -79,12 +88,21 @@ type filterWorker struct {
 	session  pollingSession  // ongoing polling session
 	client   pollingClient   // polling client
 	observer pollingObserver // polling observer
+
+	// delegate filters on full node, nil if not polling from full node
+	upstreams *upstreamFilterRegistry
+
+	// adaptive polling interval settings
+	polling pollingConfig
+	// whether any delegate virtual filter read changes since last polling
+	read atomic.Bool
 }
 
 func newFilterWorker(
 	space, nodeName string,
 	client pollingClient,
 	obs pollingObserver,
+	polling pollingConfig,
 	shutdownCtx cmdutil.GracefulShutdownContext,
 ) *filterWorker {
 	return &filterWorker{
@@ -93,19 +111,22 @@ func newFilterWorker(
 		nodeName:    nodeName,
 		client:      client,
 		session:     nilPollingSession,
+		polling:     polling,
+		quit:        make(chan struct{}),
 		shutdownCtx: shutdownCtx,
 	}
 }
 
-// accept accepts delegate for virtual filter
-func (w *filterWorker) accept(f virtualFilter) error {
+// accept accepts delegate for virtual filter, which joins the filter group of the same
+// normalized filter criteria.
+func (w *filterWorker) accept(f virtualFilter, critKey string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	if atomic.LoadUint32(&w.quitflag) != 0 { // worker already shutdown
 		return errFilterWorkerShutdown
 	}
 
-	w.mu.Lock()
-	defer w.mu.Unlock()
-
 	// establish filter polling session if not done yet
 	if w.session.fid == nilRpcId {
 		session, err := w.client.establish()
@@ -115,6 +136,10 @@ func (w *filterWorker) accept(f virtualFilter) error {
 
 		w.session = session
 
+		if w.upstreams != nil {
+			w.upstreams.track(w.nodeName, session.fid, w.client.uninstall)
+		}
+
 		if w.observer != nil {
 			w.observer.onEstablished(w.nodeName, w.session.fid)
 		}
@@ -124,6 +149,16 @@ func (w *filterWorker) accept(f virtualFilter) error {
 
 	// snapshot filter cursor for the delegate virtual filter
 	w.session.fcursors[f.fid()] = w.session.fchain.snapshotLatestCursor()
+
+	group, ok := w.session.groups[critKey]
+	if !ok {
+		group = newFilterGroup(critKey)
+		w.session.groups[critKey] = group
+	}
+
+	group.refs++
+	w.session.fgroups[f.fid()] = group
+
 	return nil
 }
 
@@ -134,17 +169,35 @@ func (w *filterWorker) reject(f virtualFilter) (bool, error) {
 
 	if _, ok := w.session.fcursors[f.fid()]; ok {
 		delete(w.session.fcursors, f.fid())
+		w.leaveGroup(f.fid())
 		return true, nil
 	}
 
 	return false, nil
 }
 
+// leaveGroup removes the virtual filter from its filter group, which requires worker lock held.
+func (w *filterWorker) leaveGroup(fid rpc.ID) {
+	group, ok := w.session.fgroups[fid]
+	if !ok {
+		return
+	}
+
+	delete(w.session.fgroups, fid)
+
+	if group.refs--; group.refs <= 0 {
+		delete(w.session.groups, group.key)
+	}
+}
+
 // poll consistantly polls filter changes from full node and applies the polled data
-// to the current polling session.
+// to the current polling session. The polling interval adapts to chain activity, so as
+// to reduce upstream load during idle periods.
 func (w *filterWorker) poll() {
-	ticker := time.NewTicker(pollingInterval)
-	defer ticker.Stop()
+	interval := newAdaptiveInterval(w.polling)
+
+	timer := time.NewTimer(interval.current)
+	defer timer.Stop()
 
 	done := make(chan bool, 1)
 	defer close(done)
@@ -154,30 +207,45 @@ func (w *filterWorker) poll() {
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-timer.C:
 			if w.gc() { // garbage collected?
 				return
 			}
 
+			_, span := tracing.Start(context.Background(), "virtualfilter/poll",
+				attribute.String("rpc.space", w.space),
+				attribute.String("fullnode", w.nodeName),
+			)
+
 			start := time.Now()
-			err := w.pollOnce()
+			advanced, err := w.pollOnce()
 			metrics.Registry.VirtualFilter.
 				PollOnceQps(w.space, w.nodeName, err).UpdateSince(start)
 
+			tracing.End(span, err)
+
 			if err != nil {
 				logrus.WithError(err).Info("Virtual filter session closed due to error")
 				w.close()
 				return
 			}
+
+			// poll faster if new blocks arrived or filters read since last polling
+			read := w.read.Swap(false)
+			timer.Reset(interval.next(advanced || read))
 		case <-w.shutdownCtx.Ctx.Done():
 			atomic.StoreUint32(&w.quitflag, 1)
 			w.close()
 			return
+		case <-w.quit:
+			w.close()
+			return
 		}
 	}
 }
 
-func (w *filterWorker) pollOnce() error {
+// pollOnce polls filter changes once, and returns whether the filter chain advanced.
+func (w *filterWorker) pollOnce() (bool, error) {
 	logger := logrus.WithFields(logrus.Fields{
 		"fid":      w.session.fid,
 		"nodeName": w.nodeName,
@@ -187,20 +255,21 @@ func (w *filterWorker) pollOnce() error {
 	if err != nil {
 		// shared proxy filter not found by full node? this may be due to full node reboot
 		if isFilterNotFoundError(err) {
-			return err
+			return false, err
 		}
 
 		duration := time.Since(w.session.lastPollingTime)
 		if duration < maxPollingDelayDuration { // retry for fault tolerance
 			logger.WithError(err).Info("Filter worker client failed to poll filter changes")
-			return nil
+			return false, nil
 		}
 
-		return err
+		return false, err
 	}
 
 	// merge the changes to the filter chain
-	if err := w.merge(fchanges); err != nil {
+	advanced, err := w.merge(fchanges)
+	if err != nil {
 		// logging the invalid polled filter changes for diagonistics
 		fcJsonStr, _ := json.Marshal(fchanges)
 		logger.WithField("filterChangesJson", string(fcJsonStr)).Info("Invalid filter changes to merge")
@@ -208,19 +277,19 @@ func (w *filterWorker) pollOnce() error {
 		w.dumpFilterChain()
 
 		logger.WithError(err).Error("Filter worker failed to merge filter chain")
-		return err
+		return false, err
 	}
 
 	// notify polled changes
 	if w.observer != nil {
 		if err := w.observer.onPolled(w.nodeName, w.session.fid, fchanges); err != nil {
-			return errors.WithMessage(err, "poll event handling error by observer")
+			return false, errors.WithMessage(err, "poll event handling error by observer")
 		}
 	}
 
 	// update last polling time
 	w.session.lastPollingTime = time.Now()
-	return nil
+	return advanced, nil
 }
 
 func (w *filterWorker) dumpFilterChain() {
@@ -232,18 +301,25 @@ func (w *filterWorker) dumpFilterChain() {
 	}
 }
 
-func (w *filterWorker) merge(fchanges filterChanges) error {
+// merge merges the filter changes to the filter chain, and returns whether the filter chain advanced.
+func (w *filterWorker) merge(fchanges filterChanges) (bool, error) {
 	startTime := time.Now()
 	defer metrics.Registry.VirtualFilter.PersistFilterChanges(w.space, w.nodeName, "memory").UpdateSince(startTime)
 
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	if c := w.session.fchain; c != nil {
-		return c.merge(fchanges)
+	c := w.session.fchain
+	if c == nil {
+		return false, nil
 	}
 
-	return nil
+	cursor := c.snapshotLatestCursor()
+	if err := c.merge(fchanges); err != nil {
+		return false, err
+	}
+
+	return c.snapshotLatestCursor() != cursor, nil
 }
 
 // To make the virtual filter service more resilient, we'd like it not take too long
@@ -288,6 +364,24 @@ func (w *filterWorker) gc() bool {
 	return false
 }
 
+// sessionFid returns the id of shared proxy filter for the ongoing polling session.
+func (w *filterWorker) sessionFid() rpc.ID {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.session.fid
+}
+
+// stop shuts down the filter worker, and closes the polling session if any.
+func (w *filterWorker) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if atomic.SwapUint32(&w.quitflag, 1) == 0 {
+		close(w.quit)
+	}
+}
+
 // close the polling session of filter worker
 func (w *filterWorker) close(lockfree ...bool) {
 	if len(lockfree) == 0 || !lockfree[0] {
@@ -296,7 +390,11 @@ func (w *filterWorker) close(lockfree ...bool) {
 	}
 
 	fid := w.session.fid
-	w.client.uninstall(fid)
+	_, err := w.client.uninstall(fid)
+
+	if w.upstreams != nil {
+		w.upstreams.settle(w.nodeName, fid, err)
+	}
 
 	// reset polling session
 	w.session = nilPollingSession
@@ -318,6 +416,8 @@ func newEthFilterWorker(
 	maxFullFilterBlocks int,
 	obs pollingObserver,
 	client *node.Web3goClient,
+	upstreams *upstreamFilterRegistry,
+	polling pollingConfig,
 	shutdownCtx cmdutil.GracefulShutdownContext,
 ) *ethFilterWorker {
 	w := &ethFilterWorker{
@@ -326,8 +426,23 @@ func newEthFilterWorker(
 	}
 
 	w.filterWorker = newFilterWorker(
-		"eth", client.NodeName(), w, obs, shutdownCtx,
+		"eth", client.NodeName(), w, obs, polling, shutdownCtx,
 	)
+	w.filterWorker.upstreams = upstreams
+
+	return w
+}
+
+// newEthStoreFilterWorker creates evm space filter worker which polls filter changes from database.
+func newEthStoreFilterWorker(
+	maxFullFilterBlocks int,
+	obs pollingObserver,
+	client *ethStorePollingClient,
+	polling pollingConfig,
+	shutdownCtx cmdutil.GracefulShutdownContext,
+) *ethFilterWorker {
+	w := &ethFilterWorker{maxFullFilterBlocks: maxFullFilterBlocks}
+	w.filterWorker = newFilterWorker("eth", ethStoreNodeName, client, obs, polling, shutdownCtx)
 
 	return w
 }
@@ -335,6 +450,7 @@ func newEthFilterWorker(
 type ethPollingChanges struct {
 	fid    rpc.ID           // proxy filter where changes are polled
 	blocks []ethFilterBlock // changed blocks since last polling
+	group  *filterGroup     // filter group to multiplex matched event logs
 }
 
 // fetchPollingChanges fetch filter changes since last polling
@@ -384,9 +500,10 @@ func (w *ethFilterWorker) fetchPollingChanges(fid rpc.ID) (*ethPollingChanges, e
 
 	// update the filter cursor
 	w.session.fcursors[fid] = w.session.fchain.snapshotLatestCursor()
+	w.read.Store(true)
 
 	pchanges := &ethPollingChanges{
-		fid: w.session.fid, blocks: fblocks,
+		fid: w.session.fid, blocks: fblocks, group: w.session.fgroups[fid],
 	}
 	return pchanges, nil
 }
@@ -429,6 +546,8 @@ func newCfxFilterWorker(
 	maxFullFilterEpochs int,
 	obs pollingObserver,
 	client *sdk.Client,
+	upstreams *upstreamFilterRegistry,
+	polling pollingConfig,
 	shutdownCtx cmdutil.GracefulShutdownContext,
 ) *cfxFilterWorker {
 	w := &cfxFilterWorker{
@@ -438,8 +557,9 @@ func newCfxFilterWorker(
 
 	nodeName := rpcutil.Url2NodeName(client.GetNodeURL())
 	w.filterWorker = newFilterWorker(
-		"cfx", nodeName, w, obs, shutdownCtx,
+		"cfx", nodeName, w, obs, polling, shutdownCtx,
 	)
+	w.filterWorker.upstreams = upstreams
 
 	return w
 }
@@ -473,6 +593,7 @@ func (w *cfxFilterWorker) uninstall(fid rpc.ID) (bool, error) {
 type cfxPollingChanges struct {
 	fid    rpc.ID           // proxy filter where changes are polled
 	epochs []cfxFilterEpoch // changed epochs since last polling
+	group  *filterGroup     // filter group to multiplex matched event logs
 }
 
 // fetchPollingChanges fetch filter changes since last polling
@@ -522,9 +643,10 @@ func (w *cfxFilterWorker) fetchPollingChanges(fid rpc.ID) (*cfxPollingChanges, e
 
 	// update the filter cursor
 	w.session.fcursors[fid] = w.session.fchain.snapshotLatestCursor()
+	w.read.Store(true)
 
 	pchanges := &cfxPollingChanges{
-		fid: w.session.fid, epochs: fepochs,
+		fid: w.session.fid, epochs: fepochs, group: w.session.fgroups[fid],
 	}
 	return pchanges, nil
 }